@@ -2,19 +2,44 @@ package admin
 
 import (
 	"payd/middleware"
+	"payd/services/audit"
 	"payd/services/auth"
+	"payd/services/calendar"
+	"payd/services/employee"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/payroll"
+	"payd/services/permission"
 	"payd/services/role"
+	"payd/services/roster"
 	"payd/services/shift"
+	"payd/services/shiftrequest"
+	"payd/services/shiftswap"
+	"payd/services/timesheet"
+	"payd/services/webhook"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
 type Admin struct {
-	auth      auth.AuthInterface
-	role      role.RoleManagerInterface
-	shift     shift.ShiftInterface
-	validator *validator.Validate
+	audit        audit.AuditInterface
+	auth         auth.AuthInterface
+	apiToken     auth.APITokenInterface
+	calendar     calendar.CalendarInterface
+	employee     employee.EmployeeInterface
+	leave        leave.LeaveInterface
+	location     location.LocationInterface
+	payroll      payroll.PayrollInterface
+	permission   permission.ManagerInterface
+	role         role.RoleManagerInterface
+	roster       roster.RosterInterface
+	shift        shift.ShiftInterface
+	shiftRequest shiftrequest.ShiftRequestInterface
+	shiftSwap    shiftswap.ShiftSwapInterface
+	timesheet    timesheet.TimesheetInterface
+	webhook      webhook.WebhookInterface
+	validator    *validator.Validate
 }
 
 type Option func(*Admin) error
@@ -26,10 +51,71 @@ func NewAdminHandler(router *gin.RouterGroup, opts ...Option) error {
 			return err
 		}
 	}
-	router.Use(middleware.JWTAuthorizeRoles(admin.auth, "admin"))
-	router.POST("/register", admin.register)
-	router.GET("/list-role", admin.listRole)
-	router.POST("/schedules", admin.createNewShiftSchedule)
+	// employees reach the admin routes through the permissions of their privilege roles
+	router.Use(middleware.JWTAuthorizeRoles(admin.auth, "admin", "employee"))
+	can := func(perm string) gin.HandlerFunc {
+		return middleware.RequirePermission(admin.permission, perm)
+	}
+	router.POST("/register", can(permission.AccessManage), admin.register)
+	router.GET("/list-role", can(permission.RolesRead), admin.listRole)
+	router.GET("/roles", can(permission.RolesRead), admin.listAllRoles)
+	router.POST("/roles", can(permission.RolesManage), admin.createRole)
+	router.PUT("/roles/:id", can(permission.RolesManage), admin.renameRole)
+	router.POST("/roles/:id/archive", can(permission.RolesManage), admin.archiveRole)
+	router.GET("/employees", can(permission.EmployeesRead), admin.listEmployees)
+	router.GET("/employees/:id", can(permission.EmployeesRead), admin.getEmployee)
+	router.PUT("/employees/:id/role", can(permission.EmployeesManage), admin.changeEmployeeRole)
+	router.POST("/employees/:id/deactivate", can(permission.EmployeesManage), admin.deactivateEmployee)
+	router.POST("/employees/:id/reactivate", can(permission.EmployeesManage), admin.reactivateEmployee)
+	router.GET("/employees/:id/privilege-roles", can(permission.AccessManage), admin.getEmployeePrivilegeRoles)
+	router.PUT("/employees/:id/privilege-roles", can(permission.AccessManage), admin.setEmployeePrivilegeRoles)
+	router.PUT("/employees/:id/locations", can(permission.AccessManage), admin.setEmployeeLocations)
+	router.GET("/locations", can(permission.LocationsRead), admin.listLocations)
+	router.POST("/locations", can(permission.LocationsManage), admin.createLocation)
+	router.PUT("/locations/:id", can(permission.LocationsManage), admin.updateLocation)
+	router.POST("/schedules", can(permission.ShiftsWrite), admin.createNewShiftSchedule)
+	router.POST("/schedules/bulk", can(permission.ShiftsWrite), admin.bulkCreateShiftSchedules)
+	router.GET("/schedules", can(permission.ShiftsRead), admin.listShiftSchedules)
+	router.GET("/schedules/:id", can(permission.ShiftsRead), admin.getShiftSchedule)
+	router.GET("/schedules/:id/edits", can(permission.ShiftsRead), admin.listShiftScheduleEdits)
+	router.PUT("/schedules/:id", can(permission.ShiftsWrite), admin.updateShiftSchedule)
+	router.DELETE("/schedules/:id", can(permission.ShiftsWrite), admin.cancelShiftSchedule)
+	router.GET("/schedule-templates", can(permission.ShiftsRead), admin.listShiftTemplates)
+	router.POST("/schedule-templates", can(permission.ShiftsWrite), admin.createShiftTemplate)
+	router.DELETE("/schedule-templates/:id", can(permission.ShiftsWrite), admin.deleteShiftTemplate)
+	router.POST("/schedule-templates/:id/generate", can(permission.ShiftsWrite), admin.generateShiftsFromTemplate)
+	router.GET("/shift-requests", can(permission.RequestsRead), admin.listShiftRequests)
+	router.POST("/shift-requests/:id/approve", can(permission.RequestsApprove), admin.approveShiftRequest)
+	router.POST("/shift-requests/:id/reject", can(permission.RequestsApprove), admin.rejectShiftRequest)
+	router.GET("/shift-swaps", can(permission.RequestsRead), admin.listShiftSwaps)
+	router.POST("/shift-swaps/:id/approve", can(permission.RequestsApprove), admin.approveShiftSwap)
+	router.POST("/shift-swaps/:id/reject", can(permission.RequestsApprove), admin.rejectShiftSwap)
+	router.POST("/rosters/preview", can(permission.RequestsApprove), admin.previewRoster)
+	router.POST("/rosters/commit", can(permission.RequestsApprove), admin.commitRoster)
+	router.GET("/leave-requests", can(permission.LeaveRead), admin.listLeaveRequests)
+	router.POST("/leave-requests/:id/approve", can(permission.LeaveApprove), admin.approveLeaveRequest)
+	router.POST("/leave-requests/:id/reject", can(permission.LeaveApprove), admin.rejectLeaveRequest)
+	router.GET("/employees/:id/leave-balances", can(permission.LeaveRead), admin.listEmployeeLeaveBalances)
+	router.POST("/employees/:id/leave-balances", can(permission.LeaveManage), admin.adjustEmployeeLeaveBalance)
+	router.GET("/timesheets", can(permission.TimesheetsRead), admin.listTimesheets)
+	router.GET("/timesheets/summary", can(permission.TimesheetsRead), admin.summarizeTimesheets)
+	router.GET("/payroll/export", can(permission.PayrollExport), admin.exportPayroll)
+	router.GET("/calendar-feeds", can(permission.ShiftsRead), admin.listCalendarFeeds)
+	router.POST("/calendar-feeds", can(permission.ShiftsWrite), admin.createCalendarFeed)
+	router.DELETE("/calendar-feeds/:id", can(permission.ShiftsWrite), admin.revokeCalendarFeed)
+	router.GET("/api-tokens", can(permission.AccessManage), admin.listAPITokens)
+	router.POST("/api-tokens", can(permission.AccessManage), admin.createAPIToken)
+	router.DELETE("/api-tokens/:id", can(permission.AccessManage), admin.revokeAPIToken)
+	router.GET("/privilege-roles", can(permission.AccessManage), admin.listPrivilegeRoles)
+	router.POST("/privilege-roles", can(permission.AccessManage), admin.createPrivilegeRole)
+	router.PUT("/privilege-roles/:id/permissions", can(permission.AccessManage), admin.setPrivilegeRolePermissions)
+	router.DELETE("/privilege-roles/:id", can(permission.AccessManage), admin.deletePrivilegeRole)
+	router.GET("/audit-logs", can(permission.AuditRead), admin.listAuditLogs)
+	router.GET("/webhooks", can(permission.WebhooksManage), admin.listWebhooks)
+	router.POST("/webhooks", can(permission.WebhooksManage), admin.createWebhook)
+	router.DELETE("/webhooks/:id", can(permission.WebhooksManage), admin.deleteWebhook)
+	router.GET("/webhook-deliveries", can(permission.WebhooksManage), admin.listWebhookDeliveries)
+	router.POST("/webhook-deliveries/:id/retry", can(permission.WebhooksManage), admin.retryWebhookDelivery)
 
 	return nil
 }
@@ -41,6 +127,83 @@ func WithShiftSvc(shift shift.ShiftInterface) Option {
 	}
 }
 
+func WithShiftRequestSvc(shiftRequest shiftrequest.ShiftRequestInterface) Option {
+	return func(s *Admin) error {
+		s.shiftRequest = shiftRequest
+		return nil
+	}
+}
+
+func WithShiftSwapSvc(shiftSwap shiftswap.ShiftSwapInterface) Option {
+	return func(s *Admin) error {
+		s.shiftSwap = shiftSwap
+		return nil
+	}
+}
+
+func WithRosterSvc(roster roster.RosterInterface) Option {
+	return func(s *Admin) error {
+		s.roster = roster
+		return nil
+	}
+}
+
+func WithTimesheetSvc(timesheet timesheet.TimesheetInterface) Option {
+	return func(s *Admin) error {
+		s.timesheet = timesheet
+		return nil
+	}
+}
+
+func WithPayrollSvc(payroll payroll.PayrollInterface) Option {
+	return func(s *Admin) error {
+		s.payroll = payroll
+		return nil
+	}
+}
+
+func WithCalendarSvc(calendar calendar.CalendarInterface) Option {
+	return func(s *Admin) error {
+		s.calendar = calendar
+		return nil
+	}
+}
+
+func WithWebhookSvc(webhook webhook.WebhookInterface) Option {
+	return func(s *Admin) error {
+		s.webhook = webhook
+		return nil
+	}
+}
+
+func WithEmployeeSvc(employee employee.EmployeeInterface) Option {
+	return func(s *Admin) error {
+		s.employee = employee
+		return nil
+	}
+}
+
+func WithLeaveSvc(leave leave.LeaveInterface) Option {
+	return func(s *Admin) error {
+		s.leave = leave
+		return nil
+	}
+}
+
+func WithLocationSvc(location location.LocationInterface) Option {
+	return func(s *Admin) error {
+		s.location = location
+		return nil
+	}
+}
+
+func WithAuditSvc(audit audit.AuditInterface) Option {
+	return func(s *Admin) error {
+		s.audit = audit
+		return nil
+	}
+}
+
 func WithAuthSvc(auth auth.AuthInterface) Option {
 	return func(s *Admin) error {
 		s.auth = auth
@@ -48,6 +211,20 @@ func WithAuthSvc(auth auth.AuthInterface) Option {
 	}
 }
 
+func WithAPITokenSvc(apiToken auth.APITokenInterface) Option {
+	return func(s *Admin) error {
+		s.apiToken = apiToken
+		return nil
+	}
+}
+
+func WithPermissionManager(permission permission.ManagerInterface) Option {
+	return func(s *Admin) error {
+		s.permission = permission
+		return nil
+	}
+}
+
 func WithRoleManager(role role.RoleManagerInterface) Option {
 	return func(s *Admin) error {
 		s.role = role
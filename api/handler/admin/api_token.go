@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"payd/middleware"
+	"payd/services/auth"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAPITokenRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// the employee the token acts as, defaults to the calling admin
+	EmployeeID *int   `json:"employeeId" binding:"omitempty,min=1"`
+	Role       string `json:"role" binding:"required,oneof=admin employee"`
+	// "[METHOD ]ROUTE" with ROUTE as registered e.g. "GET /admin/schedules/:id", a trailing /* matches every route under it
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"required,min=1,max=365"`
+}
+
+type APITokenResponse struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	EmployeeID   int        `json:"employeeId"`
+	EmployeeName string     `json:"employeeName"`
+	Role         string     `json:"role"`
+	Scopes       []string   `json:"scopes"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	CreatedBy    int        `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+}
+
+func (a *Admin) listAPITokens(c *gin.Context) {
+	ctx := c.Request.Context()
+	tokens, err := a.apiToken.ListAPITokens(ctx)
+	if err != nil {
+		a.apiTokenError(c, err, "list api tokens")
+		return
+	}
+	res := make([]APITokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, APITokenResponse{
+			ID:           t.ID,
+			Name:         t.Name,
+			Prefix:       t.Prefix,
+			EmployeeID:   t.EmployeeID,
+			EmployeeName: t.EmployeeName,
+			Role:         t.Role,
+			Scopes:       t.Scopes,
+			ExpiresAt:    t.ExpiresAt,
+			LastUsedAt:   t.LastUsedAt,
+			CreatedBy:    t.CreatedBy,
+			CreatedAt:    t.CreatedAt,
+			RevokedAt:    t.RevokedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"apiTokens": res})
+}
+
+// the token is only returned in this response, it's stored hashed
+func (a *Admin) createAPIToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	identity, _ := middleware.GetIdentity(c)
+	if identity != nil && identity.APITokenID != 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "api tokens can't issue api tokens"})
+		return
+	}
+	creatorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin is not linked to an employee"})
+		return
+	}
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	employeeId := creatorId
+	if req.EmployeeID != nil {
+		employeeId = *req.EmployeeID
+	}
+
+	id, token, err := a.apiToken.CreateAPIToken(ctx, auth.NewAPIToken{
+		Name:       req.Name,
+		EmployeeID: employeeId,
+		Role:       req.Role,
+		Scopes:     req.Scopes,
+		ExpiresAt:  time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour),
+		CreatedBy:  creatorId,
+	})
+	if err != nil {
+		a.apiTokenError(c, err, "create api token")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "api token created successfully, it won't be shown again",
+		"id":      id,
+		"token":   token,
+	})
+}
+
+func (a *Admin) revokeAPIToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api token id"})
+		return
+	}
+	if err := a.apiToken.RevokeAPIToken(ctx, id); err != nil {
+		a.apiTokenError(c, err, "revoke api token")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "api token revoked successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) apiTokenError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, auth.ErrAPITokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "employee not found"})
+	case errors.Is(err, auth.ErrInvalidScope), errors.Is(err, auth.ErrInvalidAPITokenRole),
+		errors.Is(err, auth.ErrInvalidExpiration), errors.Is(err, auth.ErrAccountDeactivated):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
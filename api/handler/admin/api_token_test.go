@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPITokenService struct {
+	mock.Mock
+}
+
+func (m *MockAPITokenService) CreateAPIToken(ctx context.Context, t auth.NewAPIToken) (int, string, error) {
+	args := m.Called(ctx, t)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAPITokenService) ListAPITokens(ctx context.Context) ([]st.APIToken, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]st.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenService) RevokeAPIToken(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	matchToken := func(employeeId int) interface{} {
+		return mock.MatchedBy(func(n auth.NewAPIToken) bool {
+			expiresIn := time.Until(n.ExpiresAt)
+			return n.Name == "payroll" && n.EmployeeID == employeeId && n.Role == "admin" && n.CreatedBy == 1 &&
+				len(n.Scopes) == 1 && n.Scopes[0] == "GET /admin/schedules" &&
+				expiresIn > 29*24*time.Hour && expiresIn <= 30*24*time.Hour
+		})
+	}
+	tests := []struct {
+		name           string
+		body           string
+		identity       *auth.Identity
+		mockArg        interface{}
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"name":"payroll","role":"admin","scopes":["GET /admin/schedules"],"expiresInDays":30}`,
+			identity:       adminIdentity,
+			mockArg:        matchToken(1),
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"token":"payd_secret"`,
+		},
+		{
+			name:           "for another employee",
+			body:           `{"name":"payroll","employeeId":4,"role":"admin","scopes":["GET /admin/schedules"],"expiresInDays":30}`,
+			identity:       adminIdentity,
+			mockArg:        matchToken(4),
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"token":"payd_secret"`,
+		},
+		{
+			name:           "missing scopes",
+			body:           `{"name":"payroll","role":"admin","scopes":[],"expiresInDays":30}`,
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "Scopes",
+		},
+		{
+			name:           "expiration too long",
+			body:           `{"name":"payroll","role":"admin","scopes":["/admin/*"],"expiresInDays":400}`,
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "ExpiresInDays",
+		},
+		{
+			name:           "invalid scope",
+			body:           `{"name":"payroll","role":"admin","scopes":["GET /admin/schedules"],"expiresInDays":30}`,
+			identity:       adminIdentity,
+			mockArg:        matchToken(1),
+			mockErr:        auth.ErrInvalidScope,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   auth.ErrInvalidScope.Error(),
+		},
+		{
+			name:           "api token caller",
+			body:           `{"name":"payroll","role":"admin","scopes":["GET /admin/schedules"],"expiresInDays":30}`,
+			identity:       &auth.Identity{EmployeeId: "1", Role: "admin", APITokenID: 3, Scopes: []string{"/admin/*"}},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "api tokens can't issue api tokens",
+		},
+		{
+			name:           "admin without employee",
+			body:           `{"name":"payroll","role":"admin","scopes":["GET /admin/schedules"],"expiresInDays":30}`,
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "admin is not linked to an employee",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockAPITokenService)
+			if tc.mockArg != nil {
+				mockSvc.On("CreateAPIToken", mock.Anything, tc.mockArg).Return(7, "payd_secret", tc.mockErr)
+			}
+			a := &Admin{apiToken: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity))
+			router.POST("/api-tokens", a.createAPIToken)
+
+			req := httptest.NewRequest(http.MethodPost, "/api-tokens", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListAndRevokeAPITokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockAPITokenService)
+	mockSvc.On("ListAPITokens", mock.Anything).Return([]st.APIToken{
+		{ID: 7, Name: "payroll", TokenHash: "hash", Prefix: "payd_abcdef", EmployeeID: 1, Role: "admin",
+			Scopes: []string{"GET /admin/schedules"}},
+	}, nil)
+	mockSvc.On("RevokeAPIToken", mock.Anything, 7).Return(nil)
+	mockSvc.On("RevokeAPIToken", mock.Anything, 8).Return(auth.ErrAPITokenNotFound)
+	a := &Admin{apiToken: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(adminIdentity))
+	router.GET("/api-tokens", a.listAPITokens)
+	router.DELETE("/api-tokens/:id", a.revokeAPIToken)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api-tokens", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"prefix":"payd_abcdef"`)
+	assert.NotContains(t, w.Body.String(), "hash", "the token hash is never returned")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api-tokens/7", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api-tokens/8", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api-tokens/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertExpectations(t)
+}
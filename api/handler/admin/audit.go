@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultAuditLogPageSize = 50
+
+// ListAuditLogsQuery filters the audit logs, start and end bound the creation time to [start, end)
+type ListAuditLogsQuery struct {
+	EntityType string    `form:"entityType" binding:"omitempty,max=50"`
+	EntityID   int       `form:"entityId" binding:"omitempty,min=1"`
+	ActorID    int       `form:"actorId" binding:"omitempty,min=1"`
+	Start      time.Time `form:"start"`
+	End        time.Time `form:"end"`
+	Limit      int       `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset     int       `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AuditLogResponse struct {
+	ID         int64            `json:"id"`
+	ActorID    *int             `json:"actorId"`
+	Actor      string           `json:"actor"`
+	Action     string           `json:"action"`
+	EntityType string           `json:"entityType"`
+	EntityID   int              `json:"entityId"`
+	Before     *json.RawMessage `json:"before"`
+	After      *json.RawMessage `json:"after"`
+	RequestID  *string          `json:"requestId"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func newAuditLogResponse(l st.AuditLog) AuditLogResponse {
+	return AuditLogResponse{
+		ID:         l.ID,
+		ActorID:    l.ActorID,
+		Actor:      l.Actor,
+		Action:     l.Action,
+		EntityType: l.EntityType,
+		EntityID:   l.EntityID,
+		Before:     l.Before,
+		After:      l.After,
+		RequestID:  l.RequestID,
+		CreatedAt:  l.CreatedAt,
+	}
+}
+
+func (a *Admin) listAuditLogs(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListAuditLogsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultAuditLogPageSize
+	}
+
+	logs, total, err := a.audit.ListLogs(ctx, st.ListAuditLogFilter{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		ActorID:    req.ActorID,
+		Start:      req.Start,
+		End:        req.End,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	})
+	if err != nil {
+		if errors.Is(err, audit.ErrInvalidTimeRange) || errors.Is(err, audit.ErrInvalidPage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.WithError(err).Error("list audit logs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		res = append(res, newAuditLogResponse(l))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"auditLogs": res,
+		"total":     total,
+		"limit":     req.Limit,
+		"offset":    req.Offset,
+	})
+}
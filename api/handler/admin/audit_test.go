@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/audit"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListLogs(ctx context.Context, filter st.ListAuditLogFilter) ([]st.AuditLog, int, error) {
+	args := m.Called(ctx, filter)
+	logs, _ := args.Get(0).([]st.AuditLog)
+	return logs, args.Int(1), args.Error(2)
+}
+
+func TestListAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	actorId := 3
+	requestId := "req-1"
+	before := json.RawMessage(`{"status":"PENDING"}`)
+	after := json.RawMessage(`{"status":"APPROVED"}`)
+	logs := []st.AuditLog{{
+		ID: 7, ActorID: &actorId, Actor: "alice@example.com", Action: "approve",
+		EntityType: audit.EntityShiftRequest, EntityID: 12, Before: &before, After: &after,
+		RequestID: &requestId, CreatedAt: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC),
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *st.ListAuditLogFilter
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   []string
+	}{
+		{
+			name:           "default page",
+			wantFilter:     &st.ListAuditLogFilter{Limit: defaultAuditLogPageSize},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"action":"approve"`, `"before":{"status":"PENDING"}`, `"requestId":"req-1"`, `"total":1`},
+		},
+		{
+			name:  "filters",
+			query: "entityType=shift_request&entityId=12&actorId=3&start=2025-06-01T00:00:00Z&end=2025-06-02T00:00:00Z&limit=10&offset=10",
+			wantFilter: &st.ListAuditLogFilter{
+				EntityType: audit.EntityShiftRequest, EntityID: 12, ActorID: 3,
+				Start: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+				Limit: 10, Offset: 10,
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"limit":10`, `"offset":10`},
+		},
+		{
+			name:           "page too large",
+			query:          "limit=501",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time range",
+			query:          "start=2025-06-02T00:00:00Z&end=2025-06-01T00:00:00Z",
+			wantFilter:     &st.ListAuditLogFilter{Start: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), Limit: defaultAuditLogPageSize},
+			mockErr:        audit.ErrInvalidTimeRange,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   []string{audit.ErrInvalidTimeRange.Error()},
+		},
+		{
+			name:           "storage failure",
+			wantFilter:     &st.ListAuditLogFilter{Limit: defaultAuditLogPageSize},
+			mockErr:        assert.AnError,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   []string{"internal error"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockAuditService)
+			if tc.wantFilter != nil {
+				if tc.mockErr != nil {
+					mockSvc.On("ListLogs", mock.Anything, *tc.wantFilter).Return(nil, 0, tc.mockErr)
+				} else {
+					mockSvc.On("ListLogs", mock.Anything, *tc.wantFilter).Return(logs, len(logs), nil)
+				}
+			}
+			a := &Admin{audit: mockSvc}
+
+			router := gin.New()
+			router.GET("/audit-logs", a.listAuditLogs)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs?"+tc.query, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			for _, body := range tc.wantRespBody {
+				assert.Contains(t, w.Body.String(), body)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
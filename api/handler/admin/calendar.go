@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/calendar"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateCalendarFeedRequest struct {
+	RoleID int `json:"roleId" binding:"required,min=1"`
+}
+
+type CalendarFeedResponse struct {
+	ID          int       `json:"id"`
+	RoleID      int       `json:"roleId"`
+	RoleName    string    `json:"roleName"`
+	LocationIDs []int64   `json:"locationIds"` // null for every location
+	CreatedBy   int       `json:"createdBy"`
+	CreatorName string    `json:"creatorName"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (a *Admin) listCalendarFeeds(c *gin.Context) {
+	feeds, err := a.calendar.ListRoleFeeds(c.Request.Context())
+	if err != nil {
+		calendarError(c, err, "list calendar feeds")
+		return
+	}
+	res := make([]CalendarFeedResponse, 0, len(feeds))
+	for _, f := range feeds {
+		r := CalendarFeedResponse{
+			ID:          f.ID,
+			LocationIDs: f.LocationIDs,
+			CreatedBy:   f.EmployeeID,
+			CreatorName: f.EmployeeName,
+			CreatedAt:   f.CreatedAt,
+		}
+		if f.RoleID != nil {
+			r.RoleID = *f.RoleID
+		}
+		if f.RoleName != nil {
+			r.RoleName = *f.RoleName
+		}
+		res = append(res, r)
+	}
+	c.JSON(http.StatusOK, gin.H{"calendarFeeds": res})
+}
+
+// createCalendarFeed issues a feed of every approved shift of the job role within the caller's locations.
+// the token is only returned in this response, it's stored hashed
+func (a *Admin) createCalendarFeed(c *gin.Context) {
+	creatorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin is not linked to an employee"})
+		return
+	}
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+
+	id, token, err := a.calendar.CreateRoleFeed(c.Request.Context(), creatorId, req.RoleID, locationIds)
+	if err != nil {
+		calendarError(c, err, "create calendar feed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "calendar feed created successfully, it won't be shown again",
+		"id":      id,
+		"token":   token,
+		"path":    "/calendar/" + token + ".ics",
+	})
+}
+
+func (a *Admin) revokeCalendarFeed(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid calendar feed id"})
+		return
+	}
+	if err := a.calendar.RevokeRoleFeed(c.Request.Context(), id); err != nil {
+		calendarError(c, err, "revoke calendar feed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "calendar feed revoked successfully",
+		"id":      id,
+	})
+}
+
+func calendarError(c *gin.Context, err error, msg string) {
+	switch err {
+	case calendar.ErrFeedNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case calendar.ErrRoleNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
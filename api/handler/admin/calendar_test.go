@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/calendar"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCalendarService struct {
+	mock.Mock
+}
+
+func (m *MockCalendarService) CreateEmployeeFeed(ctx context.Context, employeeId int) (string, error) {
+	args := m.Called(ctx, employeeId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeEmployeeFeed(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) CreateRoleFeed(ctx context.Context, createdBy, roleId int, locationIds []int) (int, string, error) {
+	args := m.Called(ctx, createdBy, roleId, locationIds)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockCalendarService) ListRoleFeeds(ctx context.Context) ([]st.CalendarFeed, error) {
+	args := m.Called(ctx)
+	feeds, _ := args.Get(0).([]st.CalendarFeed)
+	return feeds, args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeRoleFeed(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	args := m.Called(ctx, token)
+	ics, _ := args.Get(0).([]byte)
+	return ics, args.Error(1)
+}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	manager := &auth.Identity{EmployeeId: "2", Role: "employee", LocationIDs: []int{1, 3}}
+	tests := []struct {
+		name           string
+		identity       *auth.Identity
+		body           string
+		wantLocations  []int
+		mockErr        error
+		wantStatusCode int
+	}{
+		{
+			name:           "every location",
+			identity:       adminIdentity,
+			body:           `{"roleId": 2}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "within the locations of the caller",
+			identity:       manager,
+			body:           `{"roleId": 2}`,
+			wantLocations:  []int{1, 3},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "unknown role",
+			identity:       adminIdentity,
+			body:           `{"roleId": 2}`,
+			mockErr:        calendar.ErrRoleNotFound,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "missing role",
+			identity:       adminIdentity,
+			body:           `{}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockCalendarService)
+			if tc.wantStatusCode == http.StatusOK || tc.mockErr != nil {
+				employeeId := 1
+				if tc.identity == manager {
+					employeeId = 2
+				}
+				token := "cal_abc"
+				if tc.mockErr != nil {
+					token = ""
+				}
+				mockSvc.On("CreateRoleFeed", mock.Anything, employeeId, 2, tc.wantLocations).Return(5, token, tc.mockErr)
+			}
+			a := &Admin{calendar: mockSvc}
+			router := gin.New()
+			router.POST("/calendar-feeds", withIdentity(tc.identity), a.createCalendarFeed)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/calendar-feeds", bytes.NewBufferString(tc.body)))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantStatusCode == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"path":"/calendar/cal_abc.ics"`)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListCalendarFeeds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	roleId, roleName := 2, "Waiter"
+	mockSvc := new(MockCalendarService)
+	mockSvc.On("ListRoleFeeds", mock.Anything).Return([]st.CalendarFeed{{
+		ID: 5, EmployeeID: 1, EmployeeName: "Admin", RoleID: &roleId, RoleName: &roleName,
+		CreatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	}}, nil)
+	a := &Admin{calendar: mockSvc}
+	router := gin.New()
+	router.GET("/calendar-feeds", a.listCalendarFeeds)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar-feeds", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"roleName":"Waiter"`)
+	assert.Contains(t, w.Body.String(), `"locationIds":null`)
+	assert.NotContains(t, w.Body.String(), "token")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRevokeCalendarFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockCalendarService)
+	mockSvc.On("RevokeRoleFeed", mock.Anything, 5).Return(nil)
+	mockSvc.On("RevokeRoleFeed", mock.Anything, 6).Return(calendar.ErrFeedNotFound)
+	a := &Admin{calendar: mockSvc}
+	router := gin.New()
+	router.DELETE("/calendar-feeds/:id", a.revokeCalendarFeed)
+
+	for path, want := range map[string]int{"/calendar-feeds/5": http.StatusOK, "/calendar-feeds/6": http.StatusNotFound,
+		"/calendar-feeds/x": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/employee"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultEmployeePageSize = 50
+
+type ListEmployeesQuery struct {
+	RoleID int    `form:"roleId"`
+	Status string `form:"status" binding:"omitempty,oneof=ACTIVE INACTIVE"`
+	Search string `form:"search" binding:"max=100"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type ChangeEmployeeRoleRequest struct {
+	RoleID int `json:"roleId" binding:"required"`
+}
+
+type EmployeeResponse struct {
+	ID                 int       `json:"id"`
+	Name               string    `json:"name"`
+	PrimaryRole        int       `json:"primaryRole"`
+	LocationID         int       `json:"locationId"`
+	ManagedLocationIDs []int64   `json:"managedLocationIds,omitempty"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+func newEmployeeResponse(e st.Employee) EmployeeResponse {
+	return EmployeeResponse{
+		ID:                 e.ID,
+		Name:               e.Name,
+		PrimaryRole:        e.PrimaryRole,
+		LocationID:         e.LocationID,
+		ManagedLocationIDs: e.ManagedLocationIDs,
+		Status:             e.Status,
+		CreatedAt:          e.CreatedAt,
+	}
+}
+
+func (a *Admin) listEmployees(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListEmployeesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultEmployeePageSize
+	}
+
+	locationIds, _ := middleware.GetLocationScope(c)
+	employees, total, err := a.employee.ListEmployees(ctx, st.ListEmployeeFilter{
+		RoleID:      req.RoleID,
+		LocationIDs: locationIds,
+		Status:      req.Status,
+		Search:      req.Search,
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+	})
+	if err != nil {
+		log.WithError(err).Error("list employees")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]EmployeeResponse, 0, len(employees))
+	for _, e := range employees {
+		res = append(res, newEmployeeResponse(e))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"employees": res,
+		"total":     total,
+		"limit":     req.Limit,
+		"offset":    req.Offset,
+	})
+}
+
+func (a *Admin) getEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	e, ok := a.scopedEmployee(c, id)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newEmployeeResponse(*e))
+}
+
+func (a *Admin) changeEmployeeRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	var req ChangeEmployeeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	e, ok := a.manageableEmployee(c, id)
+	if !ok {
+		return
+	}
+	if !a.isValidRoleID(req.RoleID, e.LocationID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roleId is not a valid role ID"})
+		return
+	}
+
+	if err := a.employee.ChangePrimaryRole(ctx, id, req.RoleID); err != nil {
+		a.employeeError(c, err, "change employee role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "employee role changed successfully",
+		"id":      id,
+	})
+}
+
+// deactivation withdraws the pending shift requests of the employee and disables their login
+func (a *Admin) deactivateEmployee(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	actorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin is not linked to an employee"})
+		return
+	}
+	if _, ok := a.manageableEmployee(c, id); !ok {
+		return
+	}
+
+	if err := a.employee.DeactivateEmployee(ctx, id, actorId); err != nil {
+		a.employeeError(c, err, "deactivate employee")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "employee deactivated successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) reactivateEmployee(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	if _, ok := a.manageableEmployee(c, id); !ok {
+		return
+	}
+
+	if err := a.employee.ReactivateEmployee(ctx, id); err != nil {
+		a.employeeError(c, err, "reactivate employee")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "employee reactivated successfully",
+		"id":      id,
+	})
+}
+
+// scopedEmployee returns the employee if the caller may access their location,
+// the employees of other locations are answered as not found
+func (a *Admin) scopedEmployee(c *gin.Context, id int) (*st.Employee, bool) {
+	e, err := a.employee.GetEmployee(c.Request.Context(), id)
+	if err != nil {
+		a.employeeError(c, err, "get employee")
+		return nil, false
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+	if !st.InLocations(locationIds, e.LocationID) {
+		a.employeeError(c, employee.ErrEmployeeNotFound, "get employee")
+		return nil, false
+	}
+	return e, true
+}
+
+// manageableEmployee returns the employee like scopedEmployee if the caller holds every privilege
+// of the employee, so that managers can't lock out an admin. admins may manage anyone
+func (a *Admin) manageableEmployee(c *gin.Context, id int) (*st.Employee, bool) {
+	e, ok := a.scopedEmployee(c, id)
+	if !ok {
+		return nil, false
+	}
+	if identity, _ := middleware.GetIdentity(c); identity != nil && identity.Role == "admin" {
+		return e, true
+	}
+	identityRole := ""
+	if e.IdentityID != nil {
+		role, err := a.auth.GetIdentityRole(c.Request.Context(), *e.IdentityID)
+		if err != nil {
+			util.Log().WithContext(c.Request.Context()).WithError(err).Error("get identity role")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return nil, false
+		}
+		identityRole = role
+	}
+	grants, _ := middleware.GetGrants(c)
+	if !grants.Covers(a.permission.Grants(identityRole, e.ID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "employee holds privileges the caller lacks"})
+		return nil, false
+	}
+	return e, true
+}
+
+func (a *Admin) employeeError(c *gin.Context, err error, msg string) {
+	switch err {
+	case employee.ErrEmployeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case employee.ErrAlreadyActive, employee.ErrAlreadyInactive:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case employee.ErrSelfDeactivation:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,318 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/employee"
+	"payd/services/permission"
+	"payd/services/role"
+	st "payd/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmployeeService struct {
+	mock.Mock
+}
+
+func (m *MockEmployeeService) ListEmployees(ctx context.Context, filter st.ListEmployeeFilter) ([]st.Employee, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]st.Employee), args.Int(1), args.Error(2)
+}
+
+func (m *MockEmployeeService) GetEmployee(ctx context.Context, id int) (*st.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*st.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeService) ChangePrimaryRole(ctx context.Context, id, roleId int) error {
+	args := m.Called(ctx, id, roleId)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeService) DeactivateEmployee(ctx context.Context, id, actorId int) error {
+	args := m.Called(ctx, id, actorId)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeService) ReactivateEmployee(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestListEmployees(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *st.ListEmployeeFilter
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "default page",
+			wantFilter:     &st.ListEmployeeFilter{Limit: defaultEmployeePageSize},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"name":"Alice"`,
+		},
+		{
+			name:           "filters",
+			query:          "roleId=2&status=INACTIVE&search=ali&limit=10&offset=10",
+			wantFilter:     &st.ListEmployeeFilter{RoleID: 2, Status: "INACTIVE", Search: "ali", Limit: 10, Offset: 10},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"total":11`,
+		},
+		{
+			name:           "invalid status",
+			query:          "status=FIRED",
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockEmployeeService)
+			if tc.wantFilter != nil {
+				mockSvc.On("ListEmployees", mock.Anything, *tc.wantFilter).
+					Return([]st.Employee{{ID: 4, Name: "Alice", PrimaryRole: 2, Status: "ACTIVE"}}, 11, nil)
+			}
+			a := &Admin{employee: mockSvc}
+
+			router := gin.New()
+			router.GET("/employees", a.listEmployees)
+
+			req := httptest.NewRequest(http.MethodGet, "/employees?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetEmployee(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockEmployeeService)
+	mockSvc.On("GetEmployee", mock.Anything, 4).Return(&st.Employee{ID: 4, Name: "Alice", LocationID: 1}, nil)
+	mockSvc.On("GetEmployee", mock.Anything, 5).Return(nil, employee.ErrEmployeeNotFound)
+	mockSvc.On("GetEmployee", mock.Anything, 6).Return(&st.Employee{ID: 6, Name: "Bob", LocationID: 2}, nil)
+	a := &Admin{employee: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}}))
+	router.GET("/employees/:id", a.getEmployee)
+
+	for path, want := range map[string]int{
+		"/employees/4":   http.StatusOK,
+		"/employees/5":   http.StatusNotFound,
+		"/employees/6":   http.StatusNotFound, // another location
+		"/employees/abc": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestChangeEmployeeRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockEmployeeService)
+	mockSvc.On("ChangePrimaryRole", mock.Anything, 4, 1).Return(nil)
+	mockSvc.On("GetEmployee", mock.Anything, 4).Return(&st.Employee{ID: 4, LocationID: 1}, nil)
+	mockRoleService := new(MockRoleService)
+	otherLocation := 2
+	mockRoleService.On("GetRoles").Return([]role.Role{{ID: 1}, {ID: 2, LocationID: &otherLocation}})
+	a := &Admin{employee: mockSvc, role: mockRoleService}
+
+	router := gin.New()
+	router.Use(withIdentity(adminIdentity))
+	router.PUT("/employees/:id/role", a.changeEmployeeRole)
+
+	for body, want := range map[string]int{
+		`{"roleId":1}`: http.StatusOK,
+		`{"roleId":2}`: http.StatusBadRequest, // role of another location
+		`{"roleId":9}`: http.StatusBadRequest,
+		`{}`:           http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/employees/4/role", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, body)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestEmployeeStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		identity       *auth.Identity
+		mockMethod     string
+		mockArgs       []interface{}
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "deactivate",
+			path:           "/employees/4/deactivate",
+			identity:       adminIdentity,
+			mockMethod:     "DeactivateEmployee",
+			mockArgs:       []interface{}{mock.Anything, 4, 1},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "employee deactivated successfully",
+		},
+		{
+			name:           "already inactive",
+			path:           "/employees/4/deactivate",
+			identity:       adminIdentity,
+			mockMethod:     "DeactivateEmployee",
+			mockArgs:       []interface{}{mock.Anything, 4, 1},
+			mockErr:        employee.ErrAlreadyInactive,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   employee.ErrAlreadyInactive.Error(),
+		},
+		{
+			name:           "self deactivation",
+			path:           "/employees/1/deactivate",
+			identity:       adminIdentity,
+			mockMethod:     "DeactivateEmployee",
+			mockArgs:       []interface{}{mock.Anything, 1, 1},
+			mockErr:        employee.ErrSelfDeactivation,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   employee.ErrSelfDeactivation.Error(),
+		},
+		{
+			name:           "employee of another location",
+			path:           "/employees/5/deactivate",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}},
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   employee.ErrEmployeeNotFound.Error(),
+		},
+		{
+			name:           "admin without employee",
+			path:           "/employees/4/deactivate",
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "reactivate",
+			path:           "/employees/4/reactivate",
+			identity:       adminIdentity,
+			mockMethod:     "ReactivateEmployee",
+			mockArgs:       []interface{}{mock.Anything, 4},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "employee reactivated successfully",
+		},
+		{
+			name:           "reactivate internal error",
+			path:           "/employees/4/reactivate",
+			identity:       adminIdentity,
+			mockMethod:     "ReactivateEmployee",
+			mockArgs:       []interface{}{mock.Anything, 4},
+			mockErr:        assert.AnError,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockEmployeeService)
+			if tc.mockMethod != "" {
+				mockSvc.On(tc.mockMethod, tc.mockArgs...).Return(tc.mockErr)
+			}
+			mockSvc.On("GetEmployee", mock.Anything, 1).Return(&st.Employee{ID: 1, LocationID: 1}, nil).Maybe()
+			mockSvc.On("GetEmployee", mock.Anything, 4).Return(&st.Employee{ID: 4, LocationID: 1}, nil).Maybe()
+			mockSvc.On("GetEmployee", mock.Anything, 5).Return(&st.Employee{ID: 5, LocationID: 2}, nil).Maybe()
+			a := &Admin{employee: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity))
+			router.POST("/employees/:id/deactivate", a.deactivateEmployee)
+			router.POST("/employees/:id/reactivate", a.reactivateEmployee)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManageEmployeePrivileges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	adminId, staffId := "kratos-4", "kratos-5"
+	manager := &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}}
+	managerGrants := permission.Grants{permission.EmployeesRead: nil, permission.EmployeesManage: nil}
+	adminGrants := permission.Grants{}
+	for _, p := range permission.All {
+		adminGrants[p] = nil
+	}
+
+	mockSvc := new(MockEmployeeService)
+	mockSvc.On("GetEmployee", mock.Anything, 4).Return(&st.Employee{ID: 4, LocationID: 1, IdentityID: &adminId}, nil)
+	mockSvc.On("GetEmployee", mock.Anything, 5).Return(&st.Employee{ID: 5, LocationID: 1, IdentityID: &staffId}, nil)
+	mockSvc.On("DeactivateEmployee", mock.Anything, 5, 1).Return(nil)
+	mockSvc.On("DeactivateEmployee", mock.Anything, 4, 1).Return(nil).Once()
+	mockAuth := new(MockAuth)
+	mockAuth.On("GetIdentityRole", mock.Anything, adminId).Return("admin", nil)
+	mockAuth.On("GetIdentityRole", mock.Anything, staffId).Return("employee", nil)
+	mockPermission := new(MockPermissionManager)
+	mockPermission.On("Grants", "admin", 4).Return(adminGrants)
+	mockPermission.On("Grants", "employee", 5).Return(permission.Grants{permission.EmployeesRead: nil})
+	a := &Admin{employee: mockSvc, auth: mockAuth, permission: mockPermission}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		identity       *auth.Identity
+		wantStatusCode int
+	}{
+		{name: "deactivate an admin", method: http.MethodPost, path: "/employees/4/deactivate", identity: manager, wantStatusCode: http.StatusForbidden},
+		{name: "reactivate an admin", method: http.MethodPost, path: "/employees/4/reactivate", identity: manager, wantStatusCode: http.StatusForbidden},
+		{name: "change the role of an admin", method: http.MethodPut, path: "/employees/4/role", body: `{"roleId":1}`, identity: manager, wantStatusCode: http.StatusForbidden},
+		{name: "deactivate a peer", method: http.MethodPost, path: "/employees/5/deactivate", identity: manager, wantStatusCode: http.StatusOK},
+		{name: "admins manage admins", method: http.MethodPost, path: "/employees/4/deactivate", identity: adminIdentity, wantStatusCode: http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(withIdentity(tc.identity), withGrants(managerGrants))
+			router.POST("/employees/:id/deactivate", a.deactivateEmployee)
+			router.POST("/employees/:id/reactivate", a.reactivateEmployee)
+			router.PUT("/employees/:id/role", a.changeEmployeeRole)
+
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,243 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/leave"
+	"payd/services/permission"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListLeaveRequestsQuery struct {
+	Start       string `form:"start" binding:"required,datetime=2006-01-02"`
+	End         string `form:"end" binding:"required,datetime=2006-01-02"`
+	EmployeeID  int    `form:"employeeId"`
+	LeaveTypeID int    `form:"leaveTypeId"`
+	Status      string `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED WITHDRAWN"`
+}
+
+type LeaveRequestResponse struct {
+	ID            int        `json:"id"`
+	EmployeeID    int        `json:"employeeId"`
+	EmployeeName  string     `json:"employeeName"`
+	LocationID    int        `json:"locationId"`
+	LeaveTypeID   int        `json:"leaveTypeId"`
+	LeaveTypeCode string     `json:"leaveTypeCode"`
+	StartDate     string     `json:"startDate"`
+	EndDate       string     `json:"endDate"`
+	Days          float64    `json:"days"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	RequestedAt   time.Time  `json:"requestedAt"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+	ReviewedBy    *int       `json:"reviewedBy,omitempty"`
+}
+
+type LeaveBalanceResponse struct {
+	LeaveTypeID   int     `json:"leaveTypeId"`
+	LeaveTypeCode string  `json:"leaveTypeCode"`
+	Days          float64 `json:"days"`
+}
+
+type AdjustLeaveBalanceRequest struct {
+	LeaveTypeID int     `json:"leaveTypeId" binding:"required"`
+	Days        float64 `json:"days" binding:"required"` // debits when negative
+	Note        string  `json:"note"`
+}
+
+func (a *Admin) listLeaveRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListLeaveRequestsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, _ := time.Parse(dateLayout, req.Start)
+	end, _ := time.Parse(dateLayout, req.End)
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must not be after end"})
+		return
+	}
+
+	// reviewers restricted to some job roles or locations only see the leave of their employees
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	requests, err := a.leave.ListLeaveRequests(ctx, st.ListLeaveRequestFilter{
+		EmployeeID:  req.EmployeeID,
+		LeaveTypeID: req.LeaveTypeID,
+		RoleIDs:     grants.JobRoles(permission.LeaveRead),
+		LocationIDs: locationIds,
+		Status:      req.Status,
+	}, start, end)
+	if err != nil {
+		log.WithError(err).Error("list leave requests")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LeaveRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		res = append(res, LeaveRequestResponse{
+			ID:            r.ID,
+			EmployeeID:    r.EmployeeID,
+			EmployeeName:  r.EmployeeName,
+			LocationID:    r.LocationID,
+			LeaveTypeID:   r.LeaveTypeID,
+			LeaveTypeCode: r.LeaveTypeCode,
+			StartDate:     r.StartDate.Format(dateLayout),
+			EndDate:       r.EndDate.Format(dateLayout),
+			Days:          r.Days,
+			Reason:        r.Reason,
+			Status:        r.Status,
+			RequestedAt:   r.RequestedAt,
+			ReviewedAt:    r.ReviewedAt,
+			ReviewedBy:    r.ReviewedBy,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// approving leave flags the approved shifts of the employee during the leave, see conflictingShiftIds
+func (a *Admin) approveLeaveRequest(c *gin.Context) {
+	a.reviewLeaveRequest(c, leave.StatusApproved)
+}
+
+func (a *Admin) rejectLeaveRequest(c *gin.Context) {
+	a.reviewLeaveRequest(c, leave.StatusRejected)
+}
+
+func (a *Admin) reviewLeaveRequest(c *gin.Context, status string) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid leave request id"})
+		return
+	}
+	reviewerId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "reviewer is not linked to an employee"})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	reviewer := leave.Reviewer{
+		EmployeeID:  reviewerId,
+		RoleIDs:     grants.JobRoles(permission.LeaveApprove),
+		LocationIDs: locationIds,
+	}
+
+	var conflictingShiftIds []int
+	if status == leave.StatusApproved {
+		conflictingShiftIds, err = a.leave.ApproveLeaveRequest(ctx, id, reviewer)
+	} else {
+		err = a.leave.RejectLeaveRequest(ctx, id, reviewer)
+	}
+	if err != nil {
+		a.leaveError(c, err, "review leave request")
+		return
+	}
+
+	res := gin.H{
+		"message": "leave request reviewed successfully",
+		"id":      id,
+		"status":  status,
+	}
+	if len(conflictingShiftIds) > 0 {
+		res["conflictingShiftIds"] = conflictingShiftIds
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *Admin) listEmployeeLeaveBalances(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	if _, ok := a.scopedEmployee(c, id); !ok {
+		return
+	}
+	balances, err := a.leave.ListBalances(ctx, id)
+	if err != nil {
+		log.WithError(err).Error("list leave balances")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LeaveBalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		res = append(res, LeaveBalanceResponse{
+			LeaveTypeID:   b.LeaveTypeID,
+			LeaveTypeCode: b.LeaveTypeCode,
+			Days:          b.Days,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// credits or debits the balance of an employee on top of the monthly accruals, e.g. for carried over leave
+func (a *Admin) adjustEmployeeLeaveBalance(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	var req AdjustLeaveBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adjusterId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "caller is not linked to an employee"})
+		return
+	}
+	if _, ok := a.scopedEmployee(c, id); !ok {
+		return
+	}
+
+	if err := a.leave.AdjustBalance(ctx, st.LeaveBalanceEntry{
+		EmployeeID:  id,
+		LeaveTypeID: req.LeaveTypeID,
+		Days:        req.Days,
+		Note:        req.Note,
+		CreatedBy:   &adjusterId,
+	}); err != nil {
+		a.leaveError(c, err, "adjust leave balance")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "leave balance adjusted successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) leaveError(c *gin.Context, err error, msg string) {
+	switch err {
+	case leave.ErrRequestNotFound, leave.ErrEmployeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case leave.ErrLeaveTypeNotFound, leave.ErrInvalidAdjustment, leave.ErrInvalidReason, leave.ErrBalanceNotTracked:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case leave.ErrRequestNotPending, leave.ErrInsufficientBalance, leave.ErrOverlappingLeave:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case leave.ErrReviewNotAllowed:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,223 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/leave"
+	"payd/services/permission"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLeaveService struct {
+	mock.Mock
+}
+
+func (m *MockLeaveService) ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error) {
+	args := m.Called(ctx)
+	types, _ := args.Get(0).([]st.LeaveType)
+	return types, args.Error(1)
+}
+
+func (m *MockLeaveService) RequestLeave(ctx context.Context, r st.LeaveRequest) (int, error) {
+	args := m.Called(ctx, r)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLeaveService) WithdrawLeaveRequest(ctx context.Context, employeeId, id int) error {
+	args := m.Called(ctx, employeeId, id)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) ListLeaveRequests(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error) {
+	args := m.Called(ctx, filter, start, end)
+	requests, _ := args.Get(0).([]st.LeaveRequestWithDetails)
+	return requests, args.Error(1)
+}
+
+func (m *MockLeaveService) ListBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error) {
+	args := m.Called(ctx, employeeId)
+	balances, _ := args.Get(0).([]st.LeaveBalance)
+	return balances, args.Error(1)
+}
+
+func (m *MockLeaveService) AdjustBalance(ctx context.Context, entry st.LeaveBalanceEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) ApproveLeaveRequest(ctx context.Context, id int, reviewer leave.Reviewer) ([]int, error) {
+	args := m.Called(ctx, id, reviewer)
+	shiftIds, _ := args.Get(0).([]int)
+	return shiftIds, args.Error(1)
+}
+
+func (m *MockLeaveService) RejectLeaveRequest(ctx context.Context, id int, reviewer leave.Reviewer) error {
+	args := m.Called(ctx, id, reviewer)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	args := m.Called(ctx, employeeId, locationId, start, end)
+	return args.Error(0)
+}
+
+func TestReviewLeaveRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		identity       *auth.Identity
+		grants         permission.Grants
+		mockMethod     string
+		mockShiftIds   []int
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "approve",
+			path:           "/leave-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveLeaveRequest",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"APPROVED"`,
+		},
+		{
+			name:           "approve over approved shifts",
+			path:           "/leave-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveLeaveRequest",
+			mockShiftIds:   []int{8, 9},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"conflictingShiftIds":[8,9]`,
+		},
+		{
+			name:           "reject",
+			path:           "/leave-requests/5/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectLeaveRequest",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"REJECTED"`,
+		},
+		{
+			name:           "insufficient balance",
+			path:           "/leave-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveLeaveRequest",
+			mockErr:        leave.ErrInsufficientBalance,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   leave.ErrInsufficientBalance.Error(),
+		},
+		{
+			name:           "reviewer restricted to another job role",
+			path:           "/leave-requests/5/approve",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee"},
+			grants:         permission.Grants{permission.LeaveApprove: {2}},
+			mockMethod:     "ApproveLeaveRequest",
+			mockErr:        leave.ErrReviewNotAllowed,
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   leave.ErrReviewNotAllowed.Error(),
+		},
+		{
+			name:           "unknown request",
+			path:           "/leave-requests/5/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectLeaveRequest",
+			mockErr:        leave.ErrRequestNotFound,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "reviewer without employee",
+			path:           "/leave-requests/5/approve",
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockLeaveService)
+			if tc.mockMethod != "" {
+				reviewer := leave.Reviewer{EmployeeID: 1, RoleIDs: tc.grants.JobRoles(permission.LeaveApprove)}
+				if tc.mockMethod == "ApproveLeaveRequest" {
+					mockSvc.On(tc.mockMethod, mock.Anything, 5, reviewer).Return(tc.mockShiftIds, tc.mockErr)
+				} else {
+					mockSvc.On(tc.mockMethod, mock.Anything, 5, reviewer).Return(tc.mockErr)
+				}
+			}
+			a := &Admin{leave: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity), withGrants(tc.grants))
+			router.POST("/leave-requests/:id/approve", a.approveLeaveRequest)
+			router.POST("/leave-requests/:id/reject", a.rejectLeaveRequest)
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListLeaveRequestsScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc := new(MockLeaveService)
+	mockSvc.On("ListLeaveRequests", mock.Anything, st.ListLeaveRequestFilter{
+		RoleIDs: []int{2}, LocationIDs: []int{1}, Status: "PENDING",
+	}, start, start.AddDate(0, 0, 29)).Return([]st.LeaveRequestWithDetails{}, nil)
+	a := &Admin{leave: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}}),
+		withGrants(permission.Grants{permission.LeaveRead: {2}}))
+	router.GET("/leave-requests", a.listLeaveRequests)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leave-requests?start=2025-06-01&end=2025-06-30&status=PENDING", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAdjustEmployeeLeaveBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockLeaveService)
+	adjusterId := 1
+	mockSvc.On("AdjustBalance", mock.Anything, st.LeaveBalanceEntry{
+		EmployeeID: 4, LeaveTypeID: 1, Days: 3, Note: "carried over", CreatedBy: &adjusterId,
+	}).Return(nil)
+	employeeSvc := new(MockEmployeeService)
+	employeeSvc.On("GetEmployee", mock.Anything, 4).Return(&st.Employee{ID: 4, LocationID: 1}, nil)
+	a := &Admin{leave: mockSvc, employee: employeeSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(adminIdentity))
+	router.POST("/employees/:id/leave-balances", a.adjustEmployeeLeaveBalance)
+
+	body := `{"leaveTypeId":1,"days":3,"note":"carried over"}`
+	req := httptest.NewRequest(http.MethodPost, "/employees/4/leave-balances", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+	employeeSvc.AssertExpectations(t)
+}
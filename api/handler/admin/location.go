@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/location"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocationRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Timezone string `json:"timezone" binding:"max=64"` // IANA timezone, UTC on creation and unchanged on update when empty
+}
+
+type LocationResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SetEmployeeLocationsRequest struct {
+	LocationIDs []int `json:"locationIds"`
+}
+
+// lists the locations the caller may access
+func (a *Admin) listLocations(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	locationIds, _ := middleware.GetLocationScope(c)
+	locations, err := a.location.ListLocations(ctx, locationIds)
+	if err != nil {
+		log.WithError(err).Error("list locations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LocationResponse, 0, len(locations))
+	for _, l := range locations {
+		res = append(res, LocationResponse{
+			ID:        l.ID,
+			Name:      l.Name,
+			Timezone:  l.Timezone,
+			CreatedAt: l.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *Admin) createLocation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := a.location.CreateLocation(ctx, req.Name, req.Timezone)
+	if err != nil {
+		a.locationError(c, err, "create location")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "location created successfully",
+		"id":      created.ID,
+	})
+}
+
+// renames the location and moves it to another timezone, the shifts keep their instants
+func (a *Admin) updateLocation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location id"})
+		return
+	}
+	var req LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+	if !st.InLocations(locationIds, id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": location.ErrLocationNotFound.Error()})
+		return
+	}
+	if err := a.location.UpdateLocation(ctx, id, req.Name, req.Timezone); err != nil {
+		a.locationError(c, err, "update location")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "location updated successfully",
+		"id":      id,
+	})
+}
+
+// replaces the locations the employee manages on top of their own, effective from their next login
+func (a *Admin) setEmployeeLocations(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	var req SetEmployeeLocationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.location.SetEmployeeLocations(ctx, id, req.LocationIDs); err != nil {
+		a.locationError(c, err, "set employee locations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "employee locations updated successfully",
+		"id":      id,
+	})
+}
+
+// requestLocation resolves the optional locationId of a request, the caller's own location by default,
+// ok is false if the caller may not access it
+func requestLocation(c *gin.Context, locationId *int) (int, bool) {
+	locationIds, own := middleware.GetLocationScope(c)
+	if locationId == nil {
+		return own, true
+	}
+	return *locationId, st.InLocations(locationIds, *locationId)
+}
+
+// timezones returns the timezone of every location to render the times of the responses in,
+// the times are rendered in UTC if they can't be loaded
+func (a *Admin) timezones(c *gin.Context) location.Timezones {
+	if a.location == nil {
+		return nil
+	}
+	zones, err := a.location.Timezones(c.Request.Context())
+	if err != nil {
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("load location timezones")
+		return nil
+	}
+	return zones
+}
+
+func (a *Admin) locationError(c *gin.Context, err error, msg string) {
+	switch err {
+	case location.ErrLocationNotFound, location.ErrEmployeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case location.ErrInvalidLocationName, location.ErrInvalidTimezone:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case location.ErrDuplicateLocationName:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/location"
+	st "payd/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLocationService struct {
+	mock.Mock
+}
+
+func (m *MockLocationService) ListLocations(ctx context.Context, locationIds []int) ([]st.Location, error) {
+	args := m.Called(ctx, locationIds)
+	return args.Get(0).([]st.Location), args.Error(1)
+}
+
+func (m *MockLocationService) CreateLocation(ctx context.Context, name, timezone string) (st.Location, error) {
+	args := m.Called(ctx, name, timezone)
+	return args.Get(0).(st.Location), args.Error(1)
+}
+
+func (m *MockLocationService) UpdateLocation(ctx context.Context, id int, name, timezone string) error {
+	args := m.Called(ctx, id, name, timezone)
+	return args.Error(0)
+}
+
+func (m *MockLocationService) SetEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) error {
+	args := m.Called(ctx, employeeId, locationIds)
+	return args.Error(0)
+}
+
+func (m *MockLocationService) Timezones(ctx context.Context) (location.Timezones, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(location.Timezones), args.Error(1)
+}
+
+func TestListLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockLocationService)
+	mockSvc.On("ListLocations", mock.Anything, []int{2}).Return([]st.Location{{ID: 2, Name: "Harbour"}}, nil)
+	a := &Admin{location: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}}))
+	router.GET("/locations", a.listLocations)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/locations", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":2,"name":"Harbour"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		wantStatusCode int
+	}{
+		{
+			name:           "create location",
+			body:           `{"name":"Harbour"}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "duplicate name",
+			body:           `{"name":"Harbour"}`,
+			mockErr:        location.ErrDuplicateLocationName,
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name:           "invalid timezone",
+			body:           `{"name":"Harbour","timezone":"Mars/Olympus"}`,
+			mockErr:        location.ErrInvalidTimezone,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "missing name",
+			body:           `{}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockLocationService)
+			mockSvc.On("CreateLocation", mock.Anything, "Harbour", mock.Anything).Return(st.Location{ID: 2, Name: "Harbour"}, tc.mockErr).Maybe()
+			a := &Admin{location: mockSvc}
+
+			router := gin.New()
+			router.POST("/locations", a.createLocation)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/locations", bytes.NewBufferString(tc.body)))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestUpdateLocationOutOfScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockLocationService)
+	a := &Admin{location: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}}))
+	router.PUT("/locations/:id", a.updateLocation)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/locations/3", bytes.NewBufferString(`{"name":"Dock"}`)))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertNotCalled(t, "UpdateLocation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
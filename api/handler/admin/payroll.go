@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"payd/middleware"
+	"payd/services/payroll"
+	"payd/services/permission"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the hours of the approved shifts starting within the pay period from start through end
+type ExportPayrollQuery struct {
+	Start      string `form:"start" binding:"required,datetime=2006-01-02"`
+	End        string `form:"end" binding:"required,datetime=2006-01-02"`
+	Format     string `form:"format" binding:"omitempty,oneof=csv xlsx"`          // defaults to csv
+	Source     string `form:"source" binding:"omitempty,oneof=scheduled clocked"` // defaults to scheduled
+	EmployeeID int    `form:"employeeId"`
+	RoleID     int    `form:"roleId"`
+}
+
+var payrollColumns = []interface{}{
+	"Employee ID", "Employee", "Role ID", "Role", "Shifts",
+	"Total hours", "Regular hours", "Overtime hours", "Night hours", "Weekend hours",
+}
+
+// the rows are flushed to the client every payrollFlushRows rows
+const payrollFlushRows = 100
+
+// rowWriter is implemented by the export formats
+type rowWriter interface {
+	WriteRow(cells ...interface{}) error
+	Flush() error
+	Close() error
+}
+
+// exportPayroll streams the regular, overtime, night and weekend hours of every employee and job role as CSV or XLSX
+func (a *Admin) exportPayroll(c *gin.Context) {
+	var req ExportPayrollQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, _ := time.Parse(dateLayout, req.Start)
+	end, _ := time.Parse(dateLayout, req.End)
+	period := payroll.Period{Start: start, End: end, Source: req.Source}
+	if period.Source == "" {
+		period.Source = payroll.SourceScheduled
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	filter := st.ListShiftRequestFilter{
+		EmployeeID:  req.EmployeeID,
+		RoleID:      req.RoleID,
+		RoleIDs:     grants.JobRoles(permission.PayrollExport),
+		LocationIDs: locationIds,
+	}
+
+	// the response starts with the first row so that a refused period is still answered with a JSON error
+	var w rowWriter
+	rows := 0
+	open := func() error {
+		filename := fmt.Sprintf("payroll_%s_%s.%s", req.Start, req.End, req.Format)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		if req.Format == "xlsx" {
+			c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			xw, err := util.NewXLSXWriter(c.Writer, "Payroll")
+			if err != nil {
+				return err
+			}
+			w = xw
+		} else {
+			c.Header("Content-Type", "text/csv")
+			w = &csvWriter{csv.NewWriter(c.Writer)}
+		}
+		c.Status(http.StatusOK)
+		return w.WriteRow(payrollColumns...)
+	}
+	err := a.payroll.Export(c.Request.Context(), period, filter, func(r payroll.Row) error {
+		if w == nil {
+			if err := open(); err != nil {
+				return err
+			}
+		}
+		err := w.WriteRow(r.EmployeeID, r.EmployeeName, r.RoleID, r.RoleName, r.Shifts,
+			hours(r.Total()), hours(r.Regular), hours(r.Overtime), hours(r.Night), hours(r.Weekend))
+		if err != nil {
+			return err
+		}
+		if rows++; rows%payrollFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && w == nil {
+		err = open()
+	}
+	if err != nil {
+		if w == nil {
+			payrollError(c, err)
+			return
+		}
+		// the export is cut short, the client gets a truncated file
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("export payroll")
+		c.Abort()
+		return
+	}
+	if err := w.Close(); err != nil {
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("export payroll")
+	}
+}
+
+// hours returns the duration as decimal hours rounded to the hundredth
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+type csvWriter struct {
+	*csv.Writer
+}
+
+func (w *csvWriter) WriteRow(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return w.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.Writer.Flush()
+	return w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+func payrollError(c *gin.Context, err error) {
+	switch err {
+	case payroll.ErrInvalidPeriod, payroll.ErrPeriodTooLong, payroll.ErrInvalidSource:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("export payroll")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/payroll"
+	"payd/services/permission"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPayrollService struct {
+	mock.Mock
+}
+
+func (m *MockPayrollService) Export(ctx context.Context, period payroll.Period, filter st.ListShiftRequestFilter, fn func(payroll.Row) error) error {
+	args := m.Called(ctx, period, filter)
+	rows, _ := args.Get(0).([]payroll.Row)
+	for _, r := range rows {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func TestExportPayroll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	june := payroll.Period{
+		Start:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+		Source: payroll.SourceScheduled,
+	}
+	rows := []payroll.Row{
+		{EmployeeID: 4, EmployeeName: "Alice", RoleID: 1, RoleName: "Waiter", Shifts: 2,
+			Hours: payroll.Hours{Regular: 8 * time.Hour, Overtime: 2*time.Hour + 20*time.Minute}},
+		{EmployeeID: 5, EmployeeName: "Bob, Jr.", RoleID: 1, RoleName: "Waiter", Shifts: 1,
+			Hours: payroll.Hours{Regular: 2 * time.Hour, Night: 6 * time.Hour}},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		grants         permission.Grants
+		wantPeriod     *payroll.Period
+		wantFilter     st.ListShiftRequestFilter
+		mockRows       []payroll.Row
+		mockErr        error
+		wantStatusCode int
+		wantHeader     string
+		wantRespBody   string
+	}{
+		{
+			name:           "csv",
+			query:          "start=2025-06-01&end=2025-06-30",
+			wantPeriod:     &june,
+			mockRows:       rows,
+			wantStatusCode: http.StatusOK,
+			wantHeader:     `attachment; filename="payroll_2025-06-01_2025-06-30.csv"`,
+			wantRespBody: "Employee ID,Employee,Role ID,Role,Shifts,Total hours,Regular hours,Overtime hours,Night hours,Weekend hours\n" +
+				"4,Alice,1,Waiter,2,10.33,8.00,2.33,0.00,0.00\n" +
+				"5,\"Bob, Jr.\",1,Waiter,1,8.00,2.00,0.00,6.00,0.00\n",
+		},
+		{
+			name:           "clocked hours of the granted job roles",
+			query:          "start=2025-06-01&end=2025-06-30&source=clocked&employeeId=5",
+			wantPeriod:     &payroll.Period{Start: june.Start, End: june.End, Source: payroll.SourceClocked},
+			grants:         permission.Grants{permission.PayrollExport: {1}},
+			wantFilter:     st.ListShiftRequestFilter{EmployeeID: 5, RoleIDs: []int{1}},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "Employee ID,Employee,Role ID,Role,Shifts,Total hours,Regular hours,Overtime hours,Night hours,Weekend hours\n",
+		},
+		{
+			name:           "invalid date",
+			query:          "start=2025-06-01&end=2025-06-31",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown format",
+			query:          "start=2025-06-01&end=2025-06-30&format=pdf",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "period too long",
+			query:          "start=2025-06-01&end=2025-06-30",
+			wantPeriod:     &june,
+			mockErr:        payroll.ErrPeriodTooLong,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   `{"error":"` + payroll.ErrPeriodTooLong.Error() + `"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockPayrollService)
+			if tc.wantPeriod != nil {
+				mockSvc.On("Export", mock.Anything, *tc.wantPeriod, tc.wantFilter).Return(tc.mockRows, tc.mockErr)
+			}
+			a := &Admin{payroll: mockSvc}
+
+			router := gin.New()
+			router.Use(withGrants(tc.grants))
+			router.GET("/payroll/export", a.exportPayroll)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payroll/export?"+tc.query, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantHeader != "" {
+				assert.Equal(t, tc.wantHeader, w.Header().Get("Content-Disposition"))
+			}
+			if tc.wantRespBody != "" {
+				assert.Equal(t, tc.wantRespBody, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportPayrollXLSX(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockPayrollService)
+	mockSvc.On("Export", mock.Anything, mock.Anything, st.ListShiftRequestFilter{}).Return([]payroll.Row{
+		{EmployeeID: 4, EmployeeName: "Alice", RoleID: 1, RoleName: "Waiter", Shifts: 1, Hours: payroll.Hours{Weekend: 90 * time.Minute}},
+	}, nil)
+	a := &Admin{payroll: mockSvc}
+
+	router := gin.New()
+	router.Use(withGrants(nil))
+	router.GET("/payroll/export", a.exportPayroll)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payroll/export?start=2025-06-01&end=2025-06-30&format=xlsx", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "xl/worksheets/sheet1.xml")
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"payd/services/permission"
+	"payd/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionDTO struct {
+	Name string `json:"name" binding:"required"`
+	// restricts the permission to the shifts of a job role, only for requests:read and requests:approve
+	JobRoleID *int `json:"jobRoleId,omitempty"`
+}
+
+type PrivilegeRoleResponse struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Builtin     bool            `json:"builtin,omitempty"`
+	Permissions []PermissionDTO `json:"permissions"`
+}
+
+type CreatePrivilegeRoleRequest struct {
+	Name        string          `json:"name" binding:"required,max=50"`
+	Permissions []PermissionDTO `json:"permissions" binding:"dive"`
+}
+
+type SetPrivilegeRolePermissionsRequest struct {
+	Permissions []PermissionDTO `json:"permissions" binding:"dive"`
+}
+
+type SetEmployeePrivilegeRolesRequest struct {
+	PrivilegeRoleIDs []int `json:"privilegeRoleIds"`
+}
+
+// the builtin admin role is held by every admin, its permissions can't be changed
+func (a *Admin) listPrivilegeRoles(c *gin.Context) {
+	roles := a.permission.GetPrivilegeRoles()
+	res := make([]PrivilegeRoleResponse, 0, len(roles))
+	for _, r := range roles {
+		res = append(res, PrivilegeRoleResponse{
+			ID:          r.ID,
+			Name:        r.Name,
+			Builtin:     r.Builtin,
+			Permissions: toPermissionDTOs(r.Permissions),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"privilegeRoles": res, "permissions": permission.All})
+}
+
+func (a *Admin) createPrivilegeRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreatePrivilegeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := a.permission.CreatePrivilegeRole(ctx, req.Name, fromPermissionDTOs(req.Permissions))
+	if err != nil {
+		a.privilegeRoleError(c, err, "create privilege role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "privilege role created successfully",
+		"id":      created.ID,
+	})
+}
+
+// replaces every permission of the role, its holders get them on their next request
+func (a *Admin) setPrivilegeRolePermissions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid privilege role id"})
+		return
+	}
+	var req SetPrivilegeRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.permission.SetPrivilegeRolePermissions(ctx, id, fromPermissionDTOs(req.Permissions)); err != nil {
+		a.privilegeRoleError(c, err, "set privilege role permissions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "privilege role permissions updated successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) deletePrivilegeRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid privilege role id"})
+		return
+	}
+	if err := a.permission.DeletePrivilegeRole(ctx, id); err != nil {
+		a.privilegeRoleError(c, err, "delete privilege role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "privilege role deleted successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) getEmployeePrivilegeRoles(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"privilegeRoleIds": a.permission.GetEmployeePrivilegeRoles(id)})
+}
+
+// replaces the privilege roles of the employee, an empty list takes them all away
+func (a *Admin) setEmployeePrivilegeRoles(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee id"})
+		return
+	}
+	var req SetEmployeePrivilegeRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.permission.SetEmployeePrivilegeRoles(ctx, id, req.PrivilegeRoleIDs); err != nil {
+		a.privilegeRoleError(c, err, "set employee privilege roles")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "employee privilege roles updated successfully",
+		"id":      id,
+	})
+}
+
+func toPermissionDTOs(perms []permission.Permission) []PermissionDTO {
+	res := make([]PermissionDTO, 0, len(perms))
+	for _, p := range perms {
+		res = append(res, PermissionDTO{Name: p.Name, JobRoleID: p.JobRoleID})
+	}
+	return res
+}
+
+func fromPermissionDTOs(dtos []PermissionDTO) []permission.Permission {
+	perms := make([]permission.Permission, 0, len(dtos))
+	for _, d := range dtos {
+		perms = append(perms, permission.Permission{Name: d.Name, JobRoleID: d.JobRoleID})
+	}
+	return perms
+}
+
+func (a *Admin) privilegeRoleError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, permission.ErrPrivilegeRoleNotFound), errors.Is(err, permission.ErrEmployeeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, permission.ErrInvalidPrivilegeRoleName), errors.Is(err, permission.ErrBuiltinPrivilegeRole),
+		errors.Is(err, permission.ErrUnknownPermission), errors.Is(err, permission.ErrUnscopedPermission),
+		errors.Is(err, permission.ErrUnknownJobRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, permission.ErrDuplicatePrivilegeRoleName):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/permission"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPermissionManager struct {
+	mock.Mock
+}
+
+func (m *MockPermissionManager) Grants(identityRole string, employeeId int) permission.Grants {
+	args := m.Called(identityRole, employeeId)
+	return args.Get(0).(permission.Grants)
+}
+
+func (m *MockPermissionManager) GetPrivilegeRoles() []permission.PrivilegeRole {
+	args := m.Called()
+	return args.Get(0).([]permission.PrivilegeRole)
+}
+
+func (m *MockPermissionManager) GetEmployeePrivilegeRoles(employeeId int) []int {
+	args := m.Called(employeeId)
+	return args.Get(0).([]int)
+}
+
+func (m *MockPermissionManager) CreatePrivilegeRole(ctx context.Context, name string, permissions []permission.Permission) (permission.PrivilegeRole, error) {
+	args := m.Called(ctx, name, permissions)
+	return args.Get(0).(permission.PrivilegeRole), args.Error(1)
+}
+
+func (m *MockPermissionManager) SetPrivilegeRolePermissions(ctx context.Context, id int, permissions []permission.Permission) error {
+	args := m.Called(ctx, id, permissions)
+	return args.Error(0)
+}
+
+func (m *MockPermissionManager) DeletePrivilegeRole(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPermissionManager) SetEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) error {
+	args := m.Called(ctx, employeeId, privilegeRoleIds)
+	return args.Error(0)
+}
+
+func TestListPrivilegeRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cook := 2
+	mockSvc := new(MockPermissionManager)
+	mockSvc.On("GetPrivilegeRoles").Return([]permission.PrivilegeRole{
+		{ID: 1, Name: "admin", Builtin: true, Permissions: []permission.Permission{{Name: permission.ShiftsRead}}},
+		{ID: 2, Name: "Cook supervisor", Permissions: []permission.Permission{{Name: permission.RequestsApprove, JobRoleID: &cook}}},
+	})
+	a := &Admin{permission: mockSvc}
+
+	router := gin.New()
+	router.GET("/privilege-roles", a.listPrivilegeRoles)
+
+	req := httptest.NewRequest(http.MethodGet, "/privilege-roles", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"id":1,"name":"admin","builtin":true,"permissions":[{"name":"shifts:read"}]}`)
+	assert.Contains(t, w.Body.String(), `{"id":2,"name":"Cook supervisor","permissions":[{"name":"requests:approve","jobRoleId":2}]}`)
+	assert.Contains(t, w.Body.String(), `"access:manage"`)
+}
+
+func TestManagePrivilegeRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cook := 2
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMock      func(m *MockPermissionManager)
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/privilege-roles",
+			body:   `{"name":"Cook supervisor","permissions":[{"name":"requests:approve","jobRoleId":2}]}`,
+			setupMock: func(m *MockPermissionManager) {
+				m.On("CreatePrivilegeRole", mock.Anything, "Cook supervisor",
+					[]permission.Permission{{Name: permission.RequestsApprove, JobRoleID: &cook}}).
+					Return(permission.PrivilegeRole{ID: 3}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":3`,
+		},
+		{
+			name:   "create duplicate",
+			method: http.MethodPost,
+			path:   "/privilege-roles",
+			body:   `{"name":"Cook supervisor"}`,
+			setupMock: func(m *MockPermissionManager) {
+				m.On("CreatePrivilegeRole", mock.Anything, "Cook supervisor", []permission.Permission{}).
+					Return(permission.PrivilegeRole{}, permission.ErrDuplicatePrivilegeRoleName)
+			},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   permission.ErrDuplicatePrivilegeRoleName.Error(),
+		},
+		{
+			name:   "set permissions of the builtin role",
+			method: http.MethodPut,
+			path:   "/privilege-roles/1/permissions",
+			body:   `{"permissions":[]}`,
+			setupMock: func(m *MockPermissionManager) {
+				m.On("SetPrivilegeRolePermissions", mock.Anything, 1, []permission.Permission{}).
+					Return(permission.ErrBuiltinPrivilegeRole)
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   permission.ErrBuiltinPrivilegeRole.Error(),
+		},
+		{
+			name:   "delete unknown",
+			method: http.MethodDelete,
+			path:   "/privilege-roles/9",
+			setupMock: func(m *MockPermissionManager) {
+				m.On("DeletePrivilegeRole", mock.Anything, 9).Return(permission.ErrPrivilegeRoleNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   permission.ErrPrivilegeRoleNotFound.Error(),
+		},
+		{
+			name:   "assign to employee",
+			method: http.MethodPut,
+			path:   "/employees/7/privilege-roles",
+			body:   `{"privilegeRoleIds":[3]}`,
+			setupMock: func(m *MockPermissionManager) {
+				m.On("SetEmployeePrivilegeRoles", mock.Anything, 7, []int{3}).Return(nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "employee privilege roles updated successfully",
+		},
+		{
+			name:   "employee privilege roles",
+			method: http.MethodGet,
+			path:   "/employees/7/privilege-roles",
+			setupMock: func(m *MockPermissionManager) {
+				m.On("GetEmployeePrivilegeRoles", 7).Return([]int{3})
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"privilegeRoleIds":[3]`,
+		},
+		{
+			name:           "invalid id",
+			method:         http.MethodDelete,
+			path:           "/privilege-roles/abc",
+			setupMock:      func(m *MockPermissionManager) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "invalid privilege role id",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockPermissionManager)
+			tc.setupMock(mockSvc)
+			a := &Admin{permission: mockSvc}
+
+			router := gin.New()
+			router.POST("/privilege-roles", a.createPrivilegeRole)
+			router.PUT("/privilege-roles/:id/permissions", a.setPrivilegeRolePermissions)
+			router.DELETE("/privilege-roles/:id", a.deletePrivilegeRole)
+			router.GET("/employees/:id/privilege-roles", a.getEmployeePrivilegeRoles)
+			router.PUT("/employees/:id/privilege-roles", a.setEmployeePrivilegeRoles)
+
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -12,6 +12,7 @@ type CreateUserRequest struct {
 	Email       string `json:"email" binding:"required,email"`
 	PrimaryRole *int   `json:"primaryRole"` // must be nil if RoleAdmin true
 	RoleAdmin   bool   `json:"roleAdmin,omitempty"`
+	LocationID  *int   `json:"locationId"` // defaults to the location of the caller
 }
 
 // only admins can register new users
@@ -25,6 +26,12 @@ func (a *Admin) register(c *gin.Context) {
 		return
 	}
 
+	locationId, ok := requestLocation(c, req.LocationID)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no access to the location"})
+		return
+	}
+
 	primaryRole := 0
 	// custom validations
 	if req.RoleAdmin {
@@ -37,14 +44,14 @@ func (a *Admin) register(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "primaryRole is required when roleAdmin is false"})
 			return
 		}
-		if !a.isValidRoleID(*req.PrimaryRole) {
+		if !a.isValidRoleID(*req.PrimaryRole, locationId) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "primaryRole is not a valid role ID"})
 			return
 		}
 		primaryRole = *req.PrimaryRole
 	}
 
-	userid, err := a.auth.RegisterNewUser(ctx, req.Email, primaryRole, req.RoleAdmin)
+	userid, err := a.auth.RegisterNewUser(ctx, req.Email, primaryRole, locationId, req.RoleAdmin)
 	if err != nil {
 		switch err {
 		case auth.ErrAlreadyExists:
@@ -62,10 +69,11 @@ func (a *Admin) register(c *gin.Context) {
 	})
 }
 
-func (a *Admin) isValidRoleID(id int) bool {
+// isValidRoleID reports whether the role is active and available at the location
+func (a *Admin) isValidRoleID(id, locationId int) bool {
 	for _, role := range a.role.GetRoles() {
 		if role.ID == id {
-			return true
+			return role.AvailableAt(locationId)
 		}
 	}
 	return false
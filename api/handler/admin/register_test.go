@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"payd/services/auth"
 	"payd/services/role"
+	st "payd/storage"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -27,8 +28,8 @@ func (m *MockAuth) Login(ctx context.Context, username, password string) (*auth.
 	return nil, nil
 }
 
-func (m *MockAuth) RegisterNewUser(ctx context.Context, email string, primaryRole int, roleAdmin bool) (string, error) {
-	args := m.Called(ctx, email, primaryRole, roleAdmin)
+func (m *MockAuth) RegisterNewUser(ctx context.Context, email string, primaryRole, locationId int, roleAdmin bool) (string, error) {
+	args := m.Called(ctx, email, primaryRole, locationId, roleAdmin)
 	return args.String(0), args.Error(1)
 }
 
@@ -36,6 +37,39 @@ func (m *MockAuth) ActivateNewUser(ctx context.Context, userId string, name stri
 	return nil
 }
 
+func (m *MockAuth) IssueRefreshToken(ctx context.Context, identity *auth.Identity) (string, error) {
+	return "", nil
+}
+
+func (m *MockAuth) RefreshSession(ctx context.Context, token string) (*auth.Identity, string, error) {
+	return nil, "", nil
+}
+
+func (m *MockAuth) RevokeRefreshToken(ctx context.Context, token string) error {
+	return nil
+}
+
+func (m *MockAuth) RevokeAccessToken(ctx context.Context, tokenStr string) error {
+	return nil
+}
+
+func (m *MockAuth) RevokeAllSessions(ctx context.Context, employeeId int) error {
+	return nil
+}
+
+func (m *MockAuth) GetIdentityRole(ctx context.Context, identityId string) (string, error) {
+	args := m.Called(ctx, identityId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuth) JWKS() auth.JWKS {
+	return auth.JWKS{}
+}
+
+func (m *MockAuth) VerifyAPIToken(ctx context.Context, token string) (*auth.Identity, error) {
+	return nil, nil
+}
+
 type MockRoleService struct {
 	mock.Mock
 }
@@ -45,6 +79,26 @@ func (m *MockRoleService) GetRoles() []role.Role {
 	return args.Get(0).([]role.Role)
 }
 
+func (m *MockRoleService) GetAllRoles() []role.Role {
+	args := m.Called()
+	return args.Get(0).([]role.Role)
+}
+
+func (m *MockRoleService) CreateRole(ctx context.Context, name string, locationId *int) (role.Role, error) {
+	args := m.Called(ctx, name, locationId)
+	return args.Get(0).(role.Role), args.Error(1)
+}
+
+func (m *MockRoleService) RenameRole(ctx context.Context, id int, name string) error {
+	args := m.Called(ctx, id, name)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) ArchiveRole(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func TestRegister(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -148,7 +202,7 @@ func TestRegister(t *testing.T) {
 				if req.PrimaryRole != nil {
 					role = *req.PrimaryRole
 				}
-				mockAuth.On("RegisterNewUser", mock.Anything, req.Email, role, req.RoleAdmin).
+				mockAuth.On("RegisterNewUser", mock.Anything, req.Email, role, st.DefaultLocationID, req.RoleAdmin).
 					Return(tc.mockReturnID, tc.mockReturnErr)
 			}
 
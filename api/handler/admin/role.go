@@ -2,23 +2,178 @@ package admin
 
 import (
 	"net/http"
+	"payd/middleware"
+	"payd/services/role"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type RoleResponse struct {
-	ID       int    `json:"id"`
-	RoleName string `json:"roleName"`
+	ID         int    `json:"id"`
+	RoleName   string `json:"roleName"`
+	LocationID *int   `json:"locationId,omitempty"` // omitted for the roles shared by every location
+	Archived   bool   `json:"archived,omitempty"`
 }
 
+type RoleRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+type CreateRoleRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+	// omitted creates a role shared by every location, only for callers who may access every location
+	LocationID *int `json:"locationId"`
+}
+
+// lists the active roles only, the ones new shifts and employees can use, of the locations the caller may access
 func (a *Admin) listRole(c *gin.Context) {
 	var res []RoleResponse
+	locationIds, _ := middleware.GetLocationScope(c)
 	listRole := a.role.GetRoles()
 	for _, role := range listRole {
+		if !role.VisibleIn(locationIds) {
+			continue
+		}
+		res = append(res, RoleResponse{
+			ID:         role.ID,
+			RoleName:   role.Name,
+			LocationID: role.LocationID,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// lists every role including the archived ones, of the locations the caller may access
+func (a *Admin) listAllRoles(c *gin.Context) {
+	locationIds, _ := middleware.GetLocationScope(c)
+	roles := a.role.GetAllRoles()
+	res := make([]RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		if !r.VisibleIn(locationIds) {
+			continue
+		}
 		res = append(res, RoleResponse{
-			ID:       role.ID,
-			RoleName: role.Name,
+			ID:         r.ID,
+			RoleName:   r.Name,
+			LocationID: r.LocationID,
+			Archived:   r.Archived,
 		})
 	}
 	c.JSON(http.StatusOK, res)
 }
+
+func (a *Admin) createRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+	if req.LocationID == nil && locationIds != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "shared roles need access to every location"})
+		return
+	}
+	if req.LocationID != nil && !st.InLocations(locationIds, *req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no access to the location"})
+		return
+	}
+	created, err := a.role.CreateRole(ctx, req.Name, req.LocationID)
+	if err != nil {
+		a.roleError(c, err, "create role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role created successfully",
+		"id":      created.ID,
+	})
+}
+
+func (a *Admin) renameRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !a.checkRoleScope(c, id) {
+		return
+	}
+	if err := a.role.RenameRole(ctx, id, req.Name); err != nil {
+		a.roleError(c, err, "rename role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role renamed successfully",
+		"id":      id,
+	})
+}
+
+// archived roles can't be used for new shifts and employees, existing ones keep them
+func (a *Admin) archiveRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+	if !a.checkRoleScope(c, id) {
+		return
+	}
+	if err := a.role.ArchiveRole(ctx, id); err != nil {
+		a.roleError(c, err, "archive role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role archived successfully",
+		"id":      id,
+	})
+}
+
+// checkRoleScope answers 404 for the roles of other locations, and 403 for the shared roles
+// when the caller may not access every location. unknown roles are left to the role manager
+func (a *Admin) checkRoleScope(c *gin.Context, id int) bool {
+	locationIds, _ := middleware.GetLocationScope(c)
+	if locationIds == nil {
+		return true
+	}
+	for _, r := range a.role.GetAllRoles() {
+		if r.ID != id {
+			continue
+		}
+		if r.LocationID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "shared roles need access to every location"})
+			return false
+		}
+		if !st.InLocations(locationIds, *r.LocationID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": role.ErrRoleNotFound.Error()})
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Admin) roleError(c *gin.Context, err error, msg string) {
+	switch err {
+	case role.ErrRoleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case role.ErrInvalidRoleName, role.ErrProtectedRole, role.ErrUnknownLocation:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case role.ErrDuplicateRoleName, role.ErrRoleArchived:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
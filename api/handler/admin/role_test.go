@@ -1,14 +1,17 @@
 package admin
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"payd/services/auth"
 	"payd/services/role"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestListRole(t *testing.T) {
@@ -71,3 +74,244 @@ func TestListRole(t *testing.T) {
 		})
 	}
 }
+
+func TestListRoleOfLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	location2, location3 := 2, 3
+	mockRoleService := new(MockRoleService)
+	mockRoleService.On("GetRoles").Return([]role.Role{
+		{ID: 1, Name: "Cashier"},
+		{ID: 5, Name: "Host", LocationID: &location2},
+		{ID: 6, Name: "Sommelier", LocationID: &location3},
+	})
+	a := &Admin{role: mockRoleService}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}}))
+	router.GET("/roles", a.listRole)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/roles", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []RoleResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, []RoleResponse{
+		{ID: 1, RoleName: "Cashier"},
+		{ID: 5, RoleName: "Host", LocationID: &location2},
+	}, got)
+}
+
+func TestListAllRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRoleService := new(MockRoleService)
+	mockRoleService.On("GetAllRoles").Return([]role.Role{
+		{ID: 1, Name: "Cashier"},
+		{ID: 4, Name: "Barista", Archived: true},
+	})
+	a := &Admin{role: mockRoleService}
+
+	router := gin.New()
+	router.GET("/roles", a.listAllRoles)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/roles", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []RoleResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, []RoleResponse{
+		{ID: 1, RoleName: "Cashier"},
+		{ID: 4, RoleName: "Barista", Archived: true},
+	}, got)
+}
+
+func TestManageRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	branchManager := &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}}
+	location2, location3 := 2, 3
+	scopedRoles := []role.Role{{ID: 1, Name: "Cashier"}, {ID: 5, Name: "Host", LocationID: &location2},
+		{ID: 6, Name: "Sommelier", LocationID: &location3}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		identity       *auth.Identity
+		setup          func(m *MockRoleService)
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/roles",
+			body:   `{"name":"Barista"}`,
+			setup: func(m *MockRoleService) {
+				m.On("CreateRole", mock.Anything, "Barista", (*int)(nil)).Return(role.Role{ID: 4, Name: "Barista"}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":4`,
+		},
+		{
+			name:           "create without name",
+			method:         http.MethodPost,
+			path:           "/roles",
+			body:           `{}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "create duplicate",
+			method: http.MethodPost,
+			path:   "/roles",
+			body:   `{"name":"cashier"}`,
+			setup: func(m *MockRoleService) {
+				m.On("CreateRole", mock.Anything, "cashier", (*int)(nil)).Return(role.Role{}, role.ErrDuplicateRoleName)
+			},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   role.ErrDuplicateRoleName.Error(),
+		},
+		{
+			name:     "create for the location of a branch manager",
+			method:   http.MethodPost,
+			path:     "/roles",
+			body:     `{"name":"Host","locationId":2}`,
+			identity: branchManager,
+			setup: func(m *MockRoleService) {
+				m.On("CreateRole", mock.Anything, "Host", &location2).Return(role.Role{ID: 5, Name: "Host"}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":5`,
+		},
+		{
+			name:           "branch manager can't create a shared role",
+			method:         http.MethodPost,
+			path:           "/roles",
+			body:           `{"name":"Host"}`,
+			identity:       branchManager,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "branch manager can't create a role of another location",
+			method:         http.MethodPost,
+			path:           "/roles",
+			body:           `{"name":"Host","locationId":3}`,
+			identity:       branchManager,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:     "branch manager renames a role of their location",
+			method:   http.MethodPut,
+			path:     "/roles/5",
+			body:     `{"name":"Greeter"}`,
+			identity: branchManager,
+			setup: func(m *MockRoleService) {
+				m.On("GetAllRoles").Return(scopedRoles)
+				m.On("RenameRole", mock.Anything, 5, "Greeter").Return(nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:     "branch manager can't rename a shared role",
+			method:   http.MethodPut,
+			path:     "/roles/1",
+			body:     `{"name":"Greeter"}`,
+			identity: branchManager,
+			setup: func(m *MockRoleService) {
+				m.On("GetAllRoles").Return(scopedRoles)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:     "branch manager can't archive a role of another location",
+			method:   http.MethodPost,
+			path:     "/roles/6/archive",
+			identity: branchManager,
+			setup: func(m *MockRoleService) {
+				m.On("GetAllRoles").Return(scopedRoles)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "rename",
+			method: http.MethodPut,
+			path:   "/roles/1",
+			body:   `{"name":"Cashier Lead"}`,
+			setup: func(m *MockRoleService) {
+				m.On("RenameRole", mock.Anything, 1, "Cashier Lead").Return(nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "role renamed successfully",
+		},
+		{
+			name:   "rename unknown role",
+			method: http.MethodPut,
+			path:   "/roles/9",
+			body:   `{"name":"Cook"}`,
+			setup: func(m *MockRoleService) {
+				m.On("RenameRole", mock.Anything, 9, "Cook").Return(role.ErrRoleNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "archive",
+			method: http.MethodPost,
+			path:   "/roles/1/archive",
+			setup: func(m *MockRoleService) {
+				m.On("ArchiveRole", mock.Anything, 1).Return(nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "role archived successfully",
+		},
+		{
+			name:   "archive admin role",
+			method: http.MethodPost,
+			path:   "/roles/0/archive",
+			setup: func(m *MockRoleService) {
+				m.On("ArchiveRole", mock.Anything, 0).Return(role.ErrProtectedRole)
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   role.ErrProtectedRole.Error(),
+		},
+		{
+			name:   "archive archived role",
+			method: http.MethodPost,
+			path:   "/roles/4/archive",
+			setup: func(m *MockRoleService) {
+				m.On("ArchiveRole", mock.Anything, 4).Return(role.ErrRoleArchived)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRoleService := new(MockRoleService)
+			if tc.setup != nil {
+				tc.setup(mockRoleService)
+			}
+			a := &Admin{role: mockRoleService}
+
+			router := gin.New()
+			if tc.identity != nil {
+				router.Use(withIdentity(tc.identity))
+			}
+			router.POST("/roles", a.createRole)
+			router.PUT("/roles/:id", a.renameRole)
+			router.POST("/roles/:id/archive", a.archiveRole)
+
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockRoleService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"payd/middleware"
+	"payd/services/permission"
+	"payd/services/roster"
+	"payd/services/shiftrequest"
+	"payd/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the open shifts starting within [start, end) to fill, limits of 0 are not enforced
+type PreviewRosterRequest struct {
+	Start                time.Time `json:"start" binding:"required"`
+	End                  time.Time `json:"end" binding:"required"`
+	RoleIDs              []int     `json:"roleIds"` // defaults to the job roles the caller may approve requests for
+	MaxHoursPerWeek      float64   `json:"maxHoursPerWeek" binding:"min=0"`
+	MaxShiftsPerEmployee int       `json:"maxShiftsPerEmployee" binding:"min=0"`
+}
+
+// the request ids of the previewed changes to approve
+type CommitRosterRequest struct {
+	ShiftRequestIDs []int `json:"shiftRequestIds" binding:"required,min=1"`
+}
+
+type RosterChangeResponse struct {
+	ShiftID             int       `json:"shiftId"`
+	RoleID              int       `json:"roleId"`
+	LocationID          int       `json:"locationId"`
+	StartTime           time.Time `json:"startTime"`
+	EndTime             time.Time `json:"endTime"`
+	ShiftRequestID      int       `json:"shiftRequestId"`
+	EmployeeID          int       `json:"employeeId"`
+	EmployeeName        string    `json:"employeeName"`
+	RejectedRequestIDs  []int     `json:"rejectedRequestIds"`
+	OutsideAvailability bool      `json:"outsideAvailability"`
+}
+
+type RosterUnfilledResponse struct {
+	ShiftID    int       `json:"shiftId"`
+	RoleID     int       `json:"roleId"`
+	LocationID int       `json:"locationId"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	Reason     string    `json:"reason"`
+	OpenSlots  int       `json:"openSlots"`
+}
+
+type RosterPreviewResponse struct {
+	Changes  []RosterChangeResponse   `json:"changes"`
+	Unfilled []RosterUnfilledResponse `json:"unfilled"`
+}
+
+// previewRoster proposes an assignment of the open shifts to their pending requests, nothing is stored
+func (a *Admin) previewRoster(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req PreviewRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	roleIds := grants.JobRoles(permission.RequestsApprove)
+	if len(req.RoleIDs) > 0 {
+		for _, id := range req.RoleIDs {
+			if !grants.Allows(permission.RequestsApprove, id) {
+				c.JSON(http.StatusForbidden, gin.H{"error": shiftrequest.ErrReviewNotAllowed.Error()})
+				return
+			}
+		}
+		roleIds = req.RoleIDs
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+
+	proposal, err := a.roster.Propose(ctx, roster.Options{
+		Start:                req.Start,
+		End:                  req.End,
+		RoleIDs:              roleIds,
+		LocationIDs:          locationIds,
+		MaxHoursPerWeek:      req.MaxHoursPerWeek,
+		MaxShiftsPerEmployee: req.MaxShiftsPerEmployee,
+	})
+	if err != nil {
+		switch err {
+		case roster.ErrInvalidTimeRange, roster.ErrRangeTooLong, roster.ErrInvalidLimits:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("preview roster")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	res := RosterPreviewResponse{
+		Changes:  make([]RosterChangeResponse, 0, len(proposal.Changes)),
+		Unfilled: make([]RosterUnfilledResponse, 0, len(proposal.Unfilled)),
+	}
+	zones := a.timezones(c)
+	for _, ch := range proposal.Changes {
+		rejected := ch.RejectedRequestIDs
+		if rejected == nil {
+			rejected = []int{}
+		}
+		res.Changes = append(res.Changes, RosterChangeResponse{
+			ShiftID:             ch.Shift.ID,
+			RoleID:              ch.Shift.RoleID,
+			LocationID:          ch.Shift.LocationID,
+			StartTime:           zones.In(ch.Shift.LocationID, ch.Shift.StartTime),
+			EndTime:             zones.In(ch.Shift.LocationID, ch.Shift.EndTime),
+			ShiftRequestID:      ch.Request.ID,
+			EmployeeID:          ch.Request.EmployeeID,
+			EmployeeName:        ch.Request.EmployeeName,
+			RejectedRequestIDs:  rejected,
+			OutsideAvailability: ch.OutsideAvailability,
+		})
+	}
+	for _, u := range proposal.Unfilled {
+		res.Unfilled = append(res.Unfilled, RosterUnfilledResponse{
+			ShiftID:    u.Shift.ID,
+			RoleID:     u.Shift.RoleID,
+			LocationID: u.Shift.LocationID,
+			StartTime:  zones.In(u.Shift.LocationID, u.Shift.StartTime),
+			EndTime:    zones.In(u.Shift.LocationID, u.Shift.EndTime),
+			Reason:     u.Reason,
+			OpenSlots:  u.Slots,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// commitRoster approves the requests of a previewed roster, all or none
+func (a *Admin) commitRoster(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req CommitRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reviewerId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "reviewer is not linked to an employee"})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	warnings, err := a.roster.Commit(ctx, req.ShiftRequestIDs, shiftrequest.Reviewer{
+		EmployeeID:  reviewerId,
+		RoleIDs:     grants.JobRoles(permission.RequestsApprove),
+		LocationIDs: locationIds,
+	})
+	if err != nil {
+		// the roster went stale since the preview, the error names the request
+		if assignmentConflict(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, roster.ErrNothingToCommit):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, shiftrequest.ErrRequestNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, shiftrequest.ErrRequestNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, shiftrequest.ErrReviewNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("commit roster")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	res := gin.H{
+		"message":         "roster committed successfully",
+		"shiftRequestIds": req.ShiftRequestIDs,
+	}
+	if len(warnings) > 0 {
+		res["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,192 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/permission"
+	"payd/services/roster"
+	"payd/services/shift"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRosterService struct {
+	mock.Mock
+}
+
+func (m *MockRosterService) Propose(ctx context.Context, opts roster.Options) (*roster.Proposal, error) {
+	args := m.Called(ctx, opts)
+	proposal, _ := args.Get(0).(*roster.Proposal)
+	return proposal, args.Error(1)
+}
+
+func (m *MockRosterService) Commit(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	args := m.Called(ctx, requestIds, reviewer)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func TestPreviewRoster(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	body := `{"start":"2025-05-12T00:00:00Z","end":"2025-05-19T00:00:00Z","maxHoursPerWeek":40%s}`
+
+	tests := []struct {
+		name           string
+		body           string
+		grants         permission.Grants
+		wantOpts       *roster.Options
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "every job role",
+			body:           fmt.Sprintf(body, ""),
+			wantOpts:       &roster.Options{Start: start, End: end, MaxHoursPerWeek: 40},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"shiftRequestId":11`,
+		},
+		{
+			name:           "defaults to the granted job roles",
+			body:           fmt.Sprintf(body, ""),
+			grants:         permission.Grants{permission.RequestsApprove: {2}},
+			wantOpts:       &roster.Options{Start: start, End: end, RoleIDs: []int{2}, MaxHoursPerWeek: 40},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"reason":"no pending requests"`,
+		},
+		{
+			name:           "job role not granted",
+			body:           fmt.Sprintf(body, `,"roleIds":[3]`),
+			grants:         permission.Grants{permission.RequestsApprove: {2}},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "negative limit",
+			body:           fmt.Sprintf(body, `,"maxShiftsPerEmployee":-1`),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "range too long",
+			body:           fmt.Sprintf(body, ""),
+			wantOpts:       &roster.Options{Start: start, End: end, MaxHoursPerWeek: 40},
+			mockErr:        roster.ErrRangeTooLong,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   roster.ErrRangeTooLong.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockRosterService)
+			if tc.wantOpts != nil {
+				proposal := &roster.Proposal{
+					Changes: []roster.Change{{
+						Shift:   st.Shift{ID: 1, RoleID: 2},
+						Request: st.ShiftRequestWithShiftDetails{ID: 11, EmployeeID: 5, ShiftID: 1},
+					}},
+					Unfilled: []roster.UnfilledShift{{Shift: st.Shift{ID: 3, RoleID: 2}, Reason: roster.ReasonNoRequests}},
+				}
+				if tc.mockErr != nil {
+					proposal = nil
+				}
+				mockSvc.On("Propose", mock.Anything, *tc.wantOpts).Return(proposal, tc.mockErr)
+			}
+			a := &Admin{roster: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(adminIdentity), withGrants(tc.grants))
+			router.POST("/rosters/preview", a.previewRoster)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rosters/preview", strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCommitRoster(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		identity       *auth.Identity
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "commit",
+			body:           `{"shiftRequestIds":[11,12]}`,
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"shiftRequestIds":[11,12]`,
+		},
+		{
+			name:           "no request",
+			body:           `{"shiftRequestIds":[]}`,
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "request reviewed since the preview",
+			body:           `{"shiftRequestIds":[11,12]}`,
+			identity:       adminIdentity,
+			mockErr:        fmt.Errorf("shift request 12: %w", shiftrequest.ErrRequestNotPending),
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   "shift request 12: shift request is not pending",
+		},
+		{
+			name:     "employee double-booked since the preview",
+			body:     `{"shiftRequestIds":[11,12]}`,
+			identity: adminIdentity,
+			mockErr: fmt.Errorf("shift request 11: %w",
+				&shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: 5, ShiftID: 1, ConflictingShiftID: 8}),
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"conflictingShiftId":8`,
+		},
+		{
+			name:           "reviewer without employee",
+			body:           `{"shiftRequestIds":[11,12]}`,
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockRosterService)
+			if tc.wantStatusCode != http.StatusBadRequest && tc.identity.EmployeeId != "" {
+				mockSvc.On("Commit", mock.Anything, []int{11, 12}, shiftrequest.Reviewer{EmployeeID: 1}).Return(nil, tc.mockErr)
+			}
+			a := &Admin{roster: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity), withGrants(nil))
+			router.POST("/rosters/commit", a.commitRoster)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rosters/commit", strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
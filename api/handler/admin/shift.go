@@ -1,17 +1,103 @@
 package admin
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"payd/middleware"
+	"payd/services/location"
+	"payd/services/shift"
+	st "payd/storage"
 	"payd/util"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// upper bound of a bulk request, keeps the multi-row insert far below the postgres parameter limit
+const maxBulkShifts = 500
+
+const defaultShiftPageSize = 50
+
 type CreateNewShiftScheduleRequest struct {
+	RoleID     int       `json:"roleId" binding:"required"`
+	LocationID *int      `json:"locationId"` // defaults to the location of the caller
+	StartTime  time.Time `json:"startTime" binding:"required"`
+	EndTime    time.Time `json:"endTime" binding:"required"`
+	Headcount  int       `json:"headcount" binding:"omitempty,min=1"` // defaults to a single employee
+}
+
+// items are validated one by one, see bulkCreateShiftSchedules
+type BulkCreateShiftScheduleRequest struct {
+	Shifts []CreateNewShiftScheduleRequest `json:"shifts" binding:"required,min=1"`
+}
+
+type BulkItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type ListShiftsQuery struct {
+	Start    time.Time `form:"start" binding:"required"`
+	End      time.Time `form:"end" binding:"required"`
+	RoleID   int       `form:"roleId"`
+	Assigned *bool     `form:"assigned"` // omitted lists both assigned and unassigned shifts
+	Filled   *bool     `form:"filled"`   // omitted lists both fully staffed shifts and shifts with open slots
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset   int       `form:"offset" binding:"omitempty,min=0"`
+}
+
+// Force and Reason are required when the shift already has an approved assignee
+type UpdateShiftScheduleRequest struct {
 	RoleID    int       `json:"roleId" binding:"required"`
 	StartTime time.Time `json:"startTime" binding:"required"`
 	EndTime   time.Time `json:"endTime" binding:"required"`
+	Headcount int       `json:"headcount" binding:"omitempty,min=1"` // omitted keeps the current headcount
+	Force     bool      `json:"force"`
+	Reason    string    `json:"reason"`
+}
+
+type CancelShiftScheduleQuery struct {
+	Force  bool   `form:"force"`
+	Reason string `form:"reason"`
+}
+
+type ShiftResponse struct {
+	ID         int                     `json:"id"`
+	RoleID     int                     `json:"roleId"`
+	LocationID int                     `json:"locationId"`
+	StartTime  time.Time               `json:"startTime"`
+	EndTime    time.Time               `json:"endTime"`
+	TemplateID *int                    `json:"templateId,omitempty"`
+	CreatedAt  time.Time               `json:"createdAt"`
+	UpdatedAt  *time.Time              `json:"updatedAt,omitempty"`
+	Headcount  int                     `json:"headcount"`
+	Approved   int                     `json:"approved"`
+	FillRatio  float64                 `json:"fillRatio"` // approved over headcount
+	Assignees  []ShiftAssigneeResponse `json:"assignees"`
+}
+
+type ShiftAssigneeResponse struct {
+	EmployeeID int    `json:"employeeId"`
+	Name       string `json:"name"`
+}
+
+type ShiftEditResponse struct {
+	Action       string     `json:"action"`
+	OldRoleID    int        `json:"oldRoleId"`
+	OldStartTime time.Time  `json:"oldStartTime"`
+	OldEndTime   time.Time  `json:"oldEndTime"`
+	NewRoleID    *int       `json:"newRoleId,omitempty"`
+	NewStartTime *time.Time `json:"newStartTime,omitempty"`
+	NewEndTime   *time.Time `json:"newEndTime,omitempty"`
+	OldHeadcount int        `json:"oldHeadcount"`
+	NewHeadcount *int       `json:"newHeadcount,omitempty"`
+	AssigneeIDs  []int64    `json:"assigneeIds"`
+	Reason       *string    `json:"reason,omitempty"`
+	EditedBy     int        `json:"editedBy"`
+	EditedAt     time.Time  `json:"editedAt"`
 }
 
 func (a *Admin) createNewShiftSchedule(c *gin.Context) {
@@ -24,7 +110,13 @@ func (a *Admin) createNewShiftSchedule(c *gin.Context) {
 		return
 	}
 
-	if !a.isValidRoleID(req.RoleID) {
+	locationId, ok := requestLocation(c, req.LocationID)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no access to the location"})
+		return
+	}
+
+	if !a.isValidRoleID(req.RoleID, locationId) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "roleId is not a valid role ID"})
 		return
 	}
@@ -34,8 +126,13 @@ func (a *Admin) createNewShiftSchedule(c *gin.Context) {
 		return
 	}
 
-	// Pretend to create and return a schedule ID
-	scheduleID, err := a.shift.CreateNewShiftSchedule(ctx, req.RoleID, req.StartTime, req.EndTime)
+	scheduleID, err := a.shift.CreateNewShiftSchedule(ctx, st.NewShift{
+		RoleID:     req.RoleID,
+		LocationID: locationId,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Headcount:  req.Headcount,
+	})
 	if err != nil {
 		log.WithError(err).Error("create schedule")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
@@ -47,3 +144,320 @@ func (a *Admin) createNewShiftSchedule(c *gin.Context) {
 		"id":      scheduleID,
 	})
 }
+
+// all the shifts are created in a single statement, or none of them if any item is invalid
+func (a *Admin) bulkCreateShiftSchedules(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req BulkCreateShiftScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Shifts) > maxBulkShifts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d shifts per request", maxBulkShifts)})
+		return
+	}
+
+	type shiftKey struct {
+		roleID, locationID int
+		start, end         int64
+	}
+	seen := make(map[shiftKey]int, len(req.Shifts))
+	var itemErrors []BulkItemError
+	shifts := make([]st.NewShift, 0, len(req.Shifts))
+	for i, item := range req.Shifts {
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			itemErrors = append(itemErrors, BulkItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		locationId, ok := requestLocation(c, item.LocationID)
+		if !ok {
+			itemErrors = append(itemErrors, BulkItemError{Index: i, Error: "no access to the location"})
+			continue
+		}
+		if !a.isValidRoleID(item.RoleID, locationId) {
+			itemErrors = append(itemErrors, BulkItemError{Index: i, Error: "roleId is not a valid role ID"})
+			continue
+		}
+		if !item.StartTime.Before(item.EndTime) {
+			itemErrors = append(itemErrors, BulkItemError{Index: i, Error: "startTime must be before endTime"})
+			continue
+		}
+		key := shiftKey{item.RoleID, locationId, item.StartTime.UnixNano(), item.EndTime.UnixNano()}
+		if first, ok := seen[key]; ok {
+			itemErrors = append(itemErrors, BulkItemError{Index: i, Error: fmt.Sprintf("duplicate of shift at index %d", first)})
+			continue
+		}
+		seen[key] = i
+		shifts = append(shifts, st.NewShift{
+			RoleID:     item.RoleID,
+			LocationID: locationId,
+			StartTime:  item.StartTime,
+			EndTime:    item.EndTime,
+			Headcount:  item.Headcount,
+		})
+	}
+	if len(itemErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid shifts, nothing was created",
+			"errors": itemErrors,
+		})
+		return
+	}
+
+	ids, err := a.shift.CreateNewShiftSchedules(ctx, shifts)
+	if err != nil {
+		log.WithError(err).Error("bulk create schedules")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedules created successfully",
+		"ids":     ids,
+	})
+}
+
+// newShiftResponse renders the times of the shift in the timezone of its location
+func newShiftResponse(s st.ShiftWithAssignees, zones location.Timezones) ShiftResponse {
+	res := ShiftResponse{
+		ID:         s.ID,
+		RoleID:     s.RoleID,
+		LocationID: s.LocationID,
+		StartTime:  zones.In(s.LocationID, s.StartTime),
+		EndTime:    zones.In(s.LocationID, s.EndTime),
+		TemplateID: s.TemplateID,
+		CreatedAt:  zones.In(s.LocationID, s.CreatedAt),
+		UpdatedAt:  zones.InPtr(s.LocationID, s.UpdatedAt),
+		Headcount:  s.Headcount,
+		Approved:   s.Approved,
+		Assignees:  make([]ShiftAssigneeResponse, 0, len(s.AssigneeIDs)),
+	}
+	if s.Headcount > 0 {
+		res.FillRatio = float64(s.Approved) / float64(s.Headcount)
+	}
+	for i, id := range s.AssigneeIDs {
+		res.Assignees = append(res.Assignees, ShiftAssigneeResponse{EmployeeID: int(id), Name: s.AssigneeNames[i]})
+	}
+	return res
+}
+
+func (a *Admin) listShiftSchedules(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListShiftsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultShiftPageSize
+	}
+
+	locationIds, _ := middleware.GetLocationScope(c)
+	shifts, total, err := a.shift.ListShifts(ctx, st.ListShiftFilter{
+		Start:       req.Start,
+		End:         req.End,
+		RoleID:      req.RoleID,
+		LocationIDs: locationIds,
+		Assigned:    req.Assigned,
+		Filled:      req.Filled,
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+	})
+	if err != nil {
+		log.WithError(err).Error("list schedules")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := a.timezones(c)
+	res := make([]ShiftResponse, 0, len(shifts))
+	for _, s := range shifts {
+		res = append(res, newShiftResponse(s, zones))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"shifts": res,
+		"total":  total,
+		"limit":  req.Limit,
+		"offset": req.Offset,
+	})
+}
+
+func (a *Admin) getShiftSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+	s, err := a.shift.GetShift(ctx, id)
+	if err == nil {
+		if locationIds, _ := middleware.GetLocationScope(c); !st.InLocations(locationIds, s.LocationID) {
+			err = shift.ErrShiftNotFound
+		}
+	}
+	if err != nil {
+		switch err {
+		case shift.ErrShiftNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("get schedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, newShiftResponse(*s, a.timezones(c)))
+}
+
+// the edit log of a cancelled shift is still listed
+func (a *Admin) listShiftScheduleEdits(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+	locationIds, _ := middleware.GetLocationScope(c)
+	edits, err := a.shift.ListShiftEdits(ctx, id, locationIds)
+	if err != nil {
+		log.WithError(err).Error("list schedule edits")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := a.timezones(c)
+	res := make([]ShiftEditResponse, 0, len(edits))
+	for _, e := range edits {
+		res = append(res, ShiftEditResponse{
+			Action:       e.Action,
+			OldRoleID:    e.OldRoleID,
+			OldStartTime: zones.In(e.LocationID, e.OldStartTime),
+			OldEndTime:   zones.In(e.LocationID, e.OldEndTime),
+			NewRoleID:    e.NewRoleID,
+			NewStartTime: zones.InPtr(e.LocationID, e.NewStartTime),
+			NewEndTime:   zones.InPtr(e.LocationID, e.NewEndTime),
+			OldHeadcount: e.OldHeadcount,
+			NewHeadcount: e.NewHeadcount,
+			AssigneeIDs:  e.AssigneeIDs,
+			Reason:       e.Reason,
+			EditedBy:     e.EditedBy,
+			EditedAt:     zones.In(e.LocationID, e.EditedAt),
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *Admin) updateShiftSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+	var req UpdateShiftScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "startTime must be before endTime"})
+		return
+	}
+	editorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "editor is not linked to an employee"})
+		return
+	}
+	// the shift keeps its location, the role must be available there
+	locationIds, _ := middleware.GetLocationScope(c)
+	current, err := a.shift.GetShift(ctx, id)
+	if err == nil && !st.InLocations(locationIds, current.LocationID) {
+		err = shift.ErrShiftNotFound
+	}
+	if err != nil {
+		a.shiftEditError(c, err, "update schedule")
+		return
+	}
+	if !a.isValidRoleID(req.RoleID, current.LocationID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roleId is not a valid role ID"})
+		return
+	}
+
+	err = a.shift.UpdateShift(ctx, id,
+		st.NewShift{RoleID: req.RoleID, StartTime: req.StartTime, EndTime: req.EndTime, Headcount: req.Headcount},
+		shift.ShiftEdit{EditedBy: editorId, Force: req.Force, Reason: req.Reason, LocationIDs: locationIds})
+	if err != nil {
+		a.shiftEditError(c, err, "update schedule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule updated successfully",
+		"id":      id,
+	})
+}
+
+// cancelling deletes the shift along with its requests
+func (a *Admin) cancelShiftSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+	var req CancelShiftScheduleQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	editorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "editor is not linked to an employee"})
+		return
+	}
+
+	locationIds, _ := middleware.GetLocationScope(c)
+	err = a.shift.CancelShift(ctx, id, shift.ShiftEdit{EditedBy: editorId, Force: req.Force, Reason: req.Reason, LocationIDs: locationIds})
+	if err != nil {
+		a.shiftEditError(c, err, "cancel schedule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule cancelled successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) shiftEditError(c *gin.Context, err error, msg string) {
+	var conflict *shift.ConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              err.Error(),
+			"shiftId":            conflict.ShiftID,
+			"conflictingShiftId": conflict.ConflictingShiftID,
+		})
+		return
+	}
+	switch err {
+	case shift.ErrShiftNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case shift.ErrShiftHasAssignee, shift.ErrHeadcountBelowApproved, shift.ErrRoleChangeWithAssignee:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"payd/middleware"
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/permission"
+	"payd/services/shift"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListShiftRequestsQuery struct {
+	Start      time.Time `form:"start" binding:"required"`
+	End        time.Time `form:"end" binding:"required"`
+	EmployeeID int       `form:"employeeId"`
+	ShiftID    int       `form:"shiftId"`
+	RoleID     int       `form:"roleId"`
+	Status     string    `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED WITHDRAWN"`
+}
+
+type ShiftRequestResponse struct {
+	ID           int        `json:"id"`
+	EmployeeID   int        `json:"employeeId"`
+	EmployeeName string     `json:"employeeName"`
+	ShiftID      int        `json:"shiftId"`
+	Status       string     `json:"status"`
+	RequestedAt  time.Time  `json:"requestedAt"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+	ReviewedBy   *int       `json:"reviewedBy,omitempty"`
+	RoleID       int        `json:"roleId"`
+	RoleName     string     `json:"roleName"`
+	LocationID   int        `json:"locationId"`
+	StartTime    time.Time  `json:"startTime"`
+	EndTime      time.Time  `json:"endTime"`
+	// set when the employee took approved leave during the shift after it was approved
+	ConflictingLeaveRequestID *int `json:"conflictingLeaveRequestId,omitempty"`
+}
+
+func (a *Admin) listShiftRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListShiftRequestsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	// reviewers restricted to some job roles or locations only see the requests for their shifts
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	requests, err := a.shiftRequest.ListShiftRequests(ctx, st.ListShiftRequestFilter{
+		EmployeeID:  req.EmployeeID,
+		ShiftID:     req.ShiftID,
+		RoleID:      req.RoleID,
+		RoleIDs:     grants.JobRoles(permission.RequestsRead),
+		LocationIDs: locationIds,
+		Status:      req.Status,
+	}, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list shift requests")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := a.timezones(c)
+	res := make([]ShiftRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		res = append(res, ShiftRequestResponse{
+			ID:           r.ID,
+			EmployeeID:   r.EmployeeID,
+			EmployeeName: r.EmployeeName,
+			ShiftID:      r.ShiftID,
+			Status:       r.Status,
+			RequestedAt:  zones.In(r.LocationID, r.RequestedAt),
+			ReviewedAt:   zones.InPtr(r.LocationID, r.ReviewedAt),
+			ReviewedBy:   r.ReviewedBy,
+			RoleID:       r.RoleID,
+			RoleName:     r.RoleName,
+			LocationID:   r.LocationID,
+			StartTime:    zones.In(r.LocationID, r.StartTime),
+			EndTime:      zones.In(r.LocationID, r.EndTime),
+
+			ConflictingLeaveRequestID: r.ConflictingLeaveRequestID,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// approving a request rejects every other pending request of the same shift
+func (a *Admin) approveShiftRequest(c *gin.Context) {
+	a.reviewShiftRequest(c, shiftrequest.StatusApproved)
+}
+
+func (a *Admin) rejectShiftRequest(c *gin.Context) {
+	a.reviewShiftRequest(c, shiftrequest.StatusRejected)
+}
+
+func (a *Admin) reviewShiftRequest(c *gin.Context, status string) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	requestId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift request id"})
+		return
+	}
+	reviewerId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "reviewer is not linked to an employee"})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	reviewer := shiftrequest.Reviewer{
+		EmployeeID:  reviewerId,
+		RoleIDs:     grants.JobRoles(permission.RequestsApprove),
+		LocationIDs: locationIds,
+	}
+
+	var warnings []string
+	if status == shiftrequest.StatusApproved {
+		warnings, err = a.shiftRequest.ApproveShiftRequest(ctx, requestId, reviewer)
+	} else {
+		err = a.shiftRequest.RejectShiftRequest(ctx, requestId, reviewer)
+	}
+	if err != nil {
+		if assignmentConflict(c, err) {
+			return
+		}
+		switch err {
+		case shiftrequest.ErrRequestNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case shiftrequest.ErrRequestNotPending:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case shiftrequest.ErrReviewNotAllowed:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("review shift request")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	res := gin.H{
+		"message": "shift request reviewed successfully",
+		"id":      requestId,
+		"status":  status,
+	}
+	if len(warnings) > 0 {
+		res["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// assignmentConflict writes the 409 response of the errors refusing to assign an employee to a shift:
+// a double-booking, approved leave or a declared unavailability. returns false for any other error
+func assignmentConflict(c *gin.Context, err error) bool {
+	var conflict *shift.ConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              err.Error(),
+			"shiftId":            conflict.ShiftID,
+			"conflictingShiftId": conflict.ConflictingShiftID,
+		})
+		return true
+	}
+	var onLeave *leave.OnLeaveError
+	if errors.As(err, &onLeave) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          err.Error(),
+			"leaveRequestId": onLeave.LeaveRequestID,
+		})
+		return true
+	}
+	var unavailable *availability.UnavailableError
+	if errors.As(err, &unavailable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            err.Error(),
+			"unavailabilityId": unavailable.Unavailability.ID,
+			"reason":           unavailable.Unavailability.Reason,
+		})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/middleware"
+	"payd/services/auth"
+	"payd/services/availability"
+	"payd/services/permission"
+	"payd/services/shift"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShiftRequestService struct {
+	mock.Mock
+}
+
+func (m *MockShiftRequestService) GetAvailableShifts(ctx context.Context, roleId int, locationIds []int, start, end time.Time) ([]st.Shift, error) {
+	return nil, nil
+}
+
+func (m *MockShiftRequestService) RequestShift(ctx context.Context, employeeId, roleId int, locationIds []int, shiftId int) (int, []string, error) {
+	return 0, nil, nil
+}
+
+func (m *MockShiftRequestService) ListEmployeeShiftRequests(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	return nil, nil
+}
+
+func (m *MockShiftRequestService) ListShiftRequests(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	args := m.Called(ctx, filter, start, end)
+	requests, _ := args.Get(0).([]st.ShiftRequestWithShiftDetails)
+	return requests, args.Error(1)
+}
+
+func (m *MockShiftRequestService) ApproveShiftRequest(ctx context.Context, requestId int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	args := m.Called(ctx, requestId, reviewer)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftRequestService) ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	args := m.Called(ctx, requestIds, reviewer)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftRequestService) RejectShiftRequest(ctx context.Context, requestId int, reviewer shiftrequest.Reviewer) error {
+	args := m.Called(ctx, requestId, reviewer)
+	return args.Error(0)
+}
+
+// withIdentity mimics middleware.JWTAuthorizeRoles for the given identity
+func withIdentity(identity *auth.Identity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.IdentityKey, identity)
+		c.Next()
+	}
+}
+
+// withGrants mimics middleware.RequirePermission for the given grants
+func withGrants(grants permission.Grants) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.GrantsKey, grants)
+		c.Next()
+	}
+}
+
+var adminIdentity = &auth.Identity{EmployeeId: "1", Role: "admin"}
+
+func TestListShiftRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	timeRange := "start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z"
+
+	tests := []struct {
+		name           string
+		query          string
+		grants         permission.Grants
+		wantFilter     *st.ListShiftRequestFilter
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "no filter",
+			query:          timeRange,
+			wantFilter:     &st.ListShiftRequestFilter{},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"employeeName":"Alice"`,
+		},
+		{
+			name:           "filter by role and status",
+			query:          timeRange + "&roleId=2&status=PENDING",
+			wantFilter:     &st.ListShiftRequestFilter{RoleID: 2, Status: "PENDING"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"employeeName":"Alice"`,
+		},
+		{
+			name:           "reviewer restricted to job roles",
+			query:          timeRange,
+			grants:         permission.Grants{permission.RequestsRead: {2, 3}},
+			wantFilter:     &st.ListShiftRequestFilter{RoleIDs: []int{2, 3}},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"employeeName":"Alice"`,
+		},
+		{
+			name:           "missing time range",
+			query:          "roleId=2",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftRequestService)
+			if tc.wantFilter != nil {
+				mockSvc.On("ListShiftRequests", mock.Anything, *tc.wantFilter,
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(start) }),
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(end) })).
+					Return([]st.ShiftRequestWithShiftDetails{
+						{ID: 1, EmployeeID: 4, EmployeeName: "Alice", ShiftID: 3, Status: "PENDING"},
+					}, nil)
+			}
+			a := &Admin{shiftRequest: mockSvc}
+
+			router := gin.New()
+			router.Use(withGrants(tc.grants))
+			router.GET("/shift-requests", a.listShiftRequests)
+
+			req := httptest.NewRequest(http.MethodGet, "/shift-requests?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReviewShiftRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		identity       *auth.Identity
+		grants         permission.Grants
+		mockMethod     string
+		mockWarnings   []string
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "approve",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"APPROVED"`,
+		},
+		{
+			name:           "reject",
+			path:           "/shift-requests/5/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectShiftRequest",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"REJECTED"`,
+		},
+		{
+			name:           "invalid id",
+			path:           "/shift-requests/abc/approve",
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "invalid shift request id",
+		},
+		{
+			name:           "reviewer without employee",
+			path:           "/shift-requests/5/approve",
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "reviewer is not linked to an employee",
+		},
+		{
+			name:           "not found",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			mockErr:        shiftrequest.ErrRequestNotFound,
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shiftrequest.ErrRequestNotFound.Error(),
+		},
+		{
+			name:           "already reviewed",
+			path:           "/shift-requests/5/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectShiftRequest",
+			mockErr:        shiftrequest.ErrRequestNotPending,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shiftrequest.ErrRequestNotPending.Error(),
+		},
+		{
+			name:           "double-booked shift",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			mockErr:        &shift.ConflictError{Err: shift.ErrShiftFullyStaffed, EmployeeID: 4, ShiftID: 3},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shift.ErrShiftFullyStaffed.Error(),
+		},
+		{
+			name:           "employee declared to be unavailable",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			mockErr:        &availability.UnavailableError{Unavailability: st.Unavailability{ID: 2, Reason: "dentist"}},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"reason":"dentist"`,
+		},
+		{
+			name:           "approve outside the weekly availability",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			mockWarnings:   []string{availability.ErrOutsideAvailability.Error()},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"warnings":["shift is outside the employee's declared availability"]`,
+		},
+		{
+			name:           "reviewer restricted to another job role",
+			path:           "/shift-requests/5/approve",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee"},
+			grants:         permission.Grants{permission.RequestsApprove: {2}},
+			mockMethod:     "ApproveShiftRequest",
+			mockErr:        shiftrequest.ErrReviewNotAllowed,
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   shiftrequest.ErrReviewNotAllowed.Error(),
+		},
+		{
+			name:           "internal error",
+			path:           "/shift-requests/5/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveShiftRequest",
+			mockErr:        assert.AnError,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftRequestService)
+			if tc.mockMethod != "" {
+				reviewer := shiftrequest.Reviewer{EmployeeID: 1, RoleIDs: tc.grants.JobRoles(permission.RequestsApprove)}
+				if tc.mockMethod == "ApproveShiftRequest" {
+					mockSvc.On(tc.mockMethod, mock.Anything, 5, reviewer).Return(tc.mockWarnings, tc.mockErr)
+				} else {
+					mockSvc.On(tc.mockMethod, mock.Anything, 5, reviewer).Return(tc.mockErr)
+				}
+			}
+			a := &Admin{shiftRequest: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity), withGrants(tc.grants))
+			router.POST("/shift-requests/:id/approve", a.approveShiftRequest)
+			router.POST("/shift-requests/:id/reject", a.rejectShiftRequest)
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
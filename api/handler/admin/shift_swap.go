@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/permission"
+	"payd/services/shiftswap"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListShiftSwapsQuery struct {
+	Start      time.Time `form:"start" binding:"required"`
+	End        time.Time `form:"end" binding:"required"`
+	EmployeeID int       `form:"employeeId"`
+	Status     string    `form:"status" binding:"omitempty,oneof=OPEN CLAIMED APPROVED REJECTED CANCELLED"`
+}
+
+type ShiftSwapResponse struct {
+	ID                 int        `json:"id"`
+	ShiftRequestID     int        `json:"shiftRequestId"`
+	ShiftID            int        `json:"shiftId"`
+	RoleID             int        `json:"roleId"`
+	LocationID         int        `json:"locationId"`
+	StartTime          time.Time  `json:"startTime"`
+	EndTime            time.Time  `json:"endTime"`
+	OfferedBy          int        `json:"offeredBy"`
+	OfferedByName      string     `json:"offeredByName"`
+	Status             string     `json:"status"`
+	ClaimedBy          *int       `json:"claimedBy,omitempty"`
+	ClaimedByName      *string    `json:"claimedByName,omitempty"`
+	ClaimedAt          *time.Time `json:"claimedAt,omitempty"`
+	SwapShiftRequestID *int       `json:"swapShiftRequestId,omitempty"`
+	SwapShiftID        *int       `json:"swapShiftId,omitempty"`
+	SwapStartTime      *time.Time `json:"swapStartTime,omitempty"`
+	SwapEndTime        *time.Time `json:"swapEndTime,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	ReviewedAt         *time.Time `json:"reviewedAt,omitempty"`
+	ReviewedBy         *int       `json:"reviewedBy,omitempty"`
+}
+
+// the swaps of shifts starting within the time range, closed ones included as the history of the transfers
+func (a *Admin) listShiftSwaps(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req ListShiftSwapsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	swaps, err := a.shiftSwap.ListSwaps(ctx, st.ListShiftSwapFilter{
+		EmployeeID:  req.EmployeeID,
+		RoleIDs:     grants.JobRoles(permission.RequestsRead),
+		LocationIDs: locationIds,
+		Status:      req.Status,
+	}, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list shift swaps")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := a.timezones(c)
+	res := make([]ShiftSwapResponse, 0, len(swaps))
+	for _, sw := range swaps {
+		res = append(res, ShiftSwapResponse{
+			ID:                 sw.ID,
+			ShiftRequestID:     sw.ShiftRequestID,
+			ShiftID:            sw.ShiftID,
+			RoleID:             sw.RoleID,
+			LocationID:         sw.LocationID,
+			StartTime:          zones.In(sw.LocationID, sw.StartTime),
+			EndTime:            zones.In(sw.LocationID, sw.EndTime),
+			OfferedBy:          sw.OfferedBy,
+			OfferedByName:      sw.OfferedByName,
+			Status:             sw.Status,
+			ClaimedBy:          sw.ClaimedBy,
+			ClaimedByName:      sw.ClaimedByName,
+			ClaimedAt:          zones.InPtr(sw.LocationID, sw.ClaimedAt),
+			SwapShiftRequestID: sw.SwapShiftRequestID,
+			SwapShiftID:        sw.SwapShiftID,
+			SwapStartTime:      zones.InPtr(sw.LocationID, sw.SwapStartTime),
+			SwapEndTime:        zones.InPtr(sw.LocationID, sw.SwapEndTime),
+			CreatedAt:          zones.In(sw.LocationID, sw.CreatedAt),
+			ReviewedAt:         zones.InPtr(sw.LocationID, sw.ReviewedAt),
+			ReviewedBy:         sw.ReviewedBy,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *Admin) approveShiftSwap(c *gin.Context) {
+	a.reviewShiftSwap(c, shiftswap.StatusApproved)
+}
+
+func (a *Admin) rejectShiftSwap(c *gin.Context) {
+	a.reviewShiftSwap(c, shiftswap.StatusRejected)
+}
+
+func (a *Admin) reviewShiftSwap(c *gin.Context, status string) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift swap id"})
+		return
+	}
+	reviewerId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "reviewer is not linked to an employee"})
+		return
+	}
+
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	reviewer := shiftswap.Reviewer{
+		EmployeeID:  reviewerId,
+		RoleIDs:     grants.JobRoles(permission.RequestsApprove),
+		LocationIDs: locationIds,
+	}
+
+	var warnings []string
+	if status == shiftswap.StatusApproved {
+		warnings, err = a.shiftSwap.ApproveSwap(ctx, id, reviewer)
+	} else {
+		err = a.shiftSwap.RejectSwap(ctx, id, reviewer)
+	}
+	if err != nil {
+		if assignmentConflict(c, err) {
+			return
+		}
+		switch err {
+		case shiftswap.ErrSwapNotFound, shiftswap.ErrRequestNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case shiftswap.ErrSwapNotClaimed, shiftswap.ErrAssignmentChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case shiftswap.ErrReviewNotAllowed:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("review shift swap")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	res := gin.H{
+		"message": "shift swap reviewed successfully",
+		"id":      id,
+		"status":  status,
+	}
+	if len(warnings) > 0 {
+		res["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,185 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/permission"
+	"payd/services/shift"
+	"payd/services/shiftswap"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShiftSwapService struct {
+	mock.Mock
+}
+
+func (m *MockShiftSwapService) OfferShift(ctx context.Context, employeeId, shiftRequestId int) (int, error) {
+	args := m.Called(ctx, employeeId, shiftRequestId)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShiftSwapService) ListOpenSwaps(ctx context.Context, employeeId, roleId int, locationIds []int) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, employeeId, roleId, locationIds)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ListEmployeeSwaps(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, employeeId, status, start, end)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ClaimSwap(ctx context.Context, employeeId, roleId int, locationIds []int, id int, swapShiftRequestId *int) ([]string, error) {
+	args := m.Called(ctx, employeeId, roleId, locationIds, id, swapShiftRequestId)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftSwapService) CancelSwap(ctx context.Context, employeeId, id int) error {
+	args := m.Called(ctx, employeeId, id)
+	return args.Error(0)
+}
+
+func (m *MockShiftSwapService) ListSwaps(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, filter, start, end)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ApproveSwap(ctx context.Context, id int, reviewer shiftswap.Reviewer) ([]string, error) {
+	args := m.Called(ctx, id, reviewer)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftSwapService) RejectSwap(ctx context.Context, id int, reviewer shiftswap.Reviewer) error {
+	args := m.Called(ctx, id, reviewer)
+	return args.Error(0)
+}
+
+func TestReviewShiftSwap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		identity       *auth.Identity
+		grants         permission.Grants
+		mockMethod     string
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "approve",
+			path:           "/shift-swaps/7/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveSwap",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"APPROVED"`,
+		},
+		{
+			name:           "reject",
+			path:           "/shift-swaps/7/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectSwap",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"REJECTED"`,
+		},
+		{
+			name:           "claimant double-booked",
+			path:           "/shift-swaps/7/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveSwap",
+			mockErr:        &shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: 5, ShiftID: 3, ConflictingShiftID: 8},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"conflictingShiftId":8`,
+		},
+		{
+			name:           "assignment changed",
+			path:           "/shift-swaps/7/approve",
+			identity:       adminIdentity,
+			mockMethod:     "ApproveSwap",
+			mockErr:        shiftswap.ErrAssignmentChanged,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shiftswap.ErrAssignmentChanged.Error(),
+		},
+		{
+			name:           "reviewer restricted to another job role",
+			path:           "/shift-swaps/7/approve",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee"},
+			grants:         permission.Grants{permission.RequestsApprove: {2}},
+			mockMethod:     "ApproveSwap",
+			mockErr:        shiftswap.ErrReviewNotAllowed,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "unknown swap",
+			path:           "/shift-swaps/7/reject",
+			identity:       adminIdentity,
+			mockMethod:     "RejectSwap",
+			mockErr:        shiftswap.ErrSwapNotFound,
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftSwapService)
+			reviewer := shiftswap.Reviewer{EmployeeID: 1, RoleIDs: tc.grants.JobRoles(permission.RequestsApprove)}
+			if tc.mockMethod == "ApproveSwap" {
+				mockSvc.On(tc.mockMethod, mock.Anything, 7, reviewer).Return(nil, tc.mockErr)
+			} else {
+				mockSvc.On(tc.mockMethod, mock.Anything, 7, reviewer).Return(tc.mockErr)
+			}
+			a := &Admin{shiftSwap: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity), withGrants(tc.grants))
+			router.POST("/shift-swaps/:id/approve", a.approveShiftSwap)
+			router.POST("/shift-swaps/:id/reject", a.rejectShiftSwap)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListShiftSwaps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	claimant := "Bob"
+	mockSvc := new(MockShiftSwapService)
+	mockSvc.On("ListSwaps", mock.Anything, st.ListShiftSwapFilter{RoleIDs: []int{2}, Status: shiftswap.StatusApproved},
+		start, start.Add(24*time.Hour)).Return([]st.ShiftSwapWithShiftDetails{{
+		ShiftSwap:     st.ShiftSwap{ID: 7, OfferedBy: 4, Status: shiftswap.StatusApproved},
+		ClaimedByName: &claimant,
+	}}, nil)
+	a := &Admin{shiftSwap: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee"}), withGrants(permission.Grants{permission.RequestsRead: {2}}))
+	router.GET("/shift-swaps", a.listShiftSwaps)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/shift-swaps?start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z&status=APPROVED", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"claimedByName":"Bob"`)
+	mockSvc.AssertExpectations(t)
+}
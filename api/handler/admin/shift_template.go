@@ -0,0 +1,215 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"payd/middleware"
+	"payd/services/shift"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateShiftTemplateRequest struct {
+	RoleID     int    `json:"roleId" binding:"required"`
+	LocationID *int   `json:"locationId"`                                  // defaults to the location of the caller
+	StartTime  string `json:"startTime" binding:"required,datetime=15:04"` // local wall clock
+	EndTime    string `json:"endTime" binding:"required,datetime=15:04"`   // not after startTime means the next day
+	Timezone   string `json:"timezone" binding:"required"`                 // e.g. Asia/Jakarta
+	Recurrence string `json:"recurrence" binding:"required"`               // e.g. FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+	StartsOn   string `json:"startsOn" binding:"required,datetime=2006-01-02"`
+	EndsOn     string `json:"endsOn" binding:"omitempty,datetime=2006-01-02"`
+	Headcount  int    `json:"headcount" binding:"omitempty,min=1"` // of the generated shifts, defaults to a single employee
+}
+
+type ShiftTemplateResponse struct {
+	ID               int     `json:"id"`
+	RoleID           int     `json:"roleId"`
+	LocationID       int     `json:"locationId"`
+	StartTime        string  `json:"startTime"`
+	EndTime          string  `json:"endTime"`
+	Timezone         string  `json:"timezone"`
+	Recurrence       string  `json:"recurrence"`
+	StartsOn         string  `json:"startsOn"`
+	EndsOn           *string `json:"endsOn,omitempty"`
+	GeneratedThrough *string `json:"generatedThrough,omitempty"`
+	Headcount        int     `json:"headcount"`
+}
+
+const dateLayout = "2006-01-02"
+
+func (a *Admin) createShiftTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	var req CreateShiftTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locationId, ok := requestLocation(c, req.LocationID)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no access to the location"})
+		return
+	}
+	if !a.isValidRoleID(req.RoleID, locationId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roleId is not a valid role ID"})
+		return
+	}
+
+	tmpl := st.ShiftTemplate{
+		RoleID:         req.RoleID,
+		LocationID:     locationId,
+		StartTimeOfDay: req.StartTime,
+		EndTimeOfDay:   req.EndTime,
+		Timezone:       req.Timezone,
+		Recurrence:     req.Recurrence,
+		Headcount:      req.Headcount,
+	}
+	tmpl.StartsOn, _ = time.Parse(dateLayout, req.StartsOn)
+	if req.EndsOn != "" {
+		endsOn, _ := time.Parse(dateLayout, req.EndsOn)
+		if endsOn.Before(tmpl.StartsOn) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "endsOn must not be before startsOn"})
+			return
+		}
+		tmpl.EndsOn = &endsOn
+	}
+
+	id, err := a.shift.CreateShiftTemplate(ctx, tmpl)
+	if err != nil {
+		switch {
+		case errors.Is(err, shift.ErrInvalidRecurrence), errors.Is(err, shift.ErrInvalidTimezone),
+			errors.Is(err, shift.ErrInvalidTimeOfDay):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("create shift template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule template created successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) listShiftTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	locationIds, _ := middleware.GetLocationScope(c)
+	templates, err := a.shift.ListShiftTemplates(ctx, locationIds)
+	if err != nil {
+		log.WithError(err).Error("list shift templates")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	formatDate := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		s := t.Format(dateLayout)
+		return &s
+	}
+	res := make([]ShiftTemplateResponse, 0, len(templates))
+	for _, tmpl := range templates {
+		res = append(res, ShiftTemplateResponse{
+			ID:               tmpl.ID,
+			RoleID:           tmpl.RoleID,
+			LocationID:       tmpl.LocationID,
+			StartTime:        tmpl.StartTimeOfDay,
+			EndTime:          tmpl.EndTimeOfDay,
+			Timezone:         tmpl.Timezone,
+			Recurrence:       tmpl.Recurrence,
+			StartsOn:         tmpl.StartsOn.Format(dateLayout),
+			EndsOn:           formatDate(tmpl.EndsOn),
+			GeneratedThrough: formatDate(tmpl.GeneratedThrough),
+			Headcount:        tmpl.Headcount,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// already generated shifts are kept
+func (a *Admin) deleteShiftTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule template id"})
+		return
+	}
+	if !a.checkTemplateScope(c, id) {
+		return
+	}
+	if err := a.shift.DeleteShiftTemplate(ctx, id); err != nil {
+		switch err {
+		case shift.ErrTemplateNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("delete shift template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "schedule template deleted successfully"})
+}
+
+// materializes the template up to the rolling horizon right away instead of waiting for the background generator
+func (a *Admin) generateShiftsFromTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule template id"})
+		return
+	}
+	if !a.checkTemplateScope(c, id) {
+		return
+	}
+	created, err := a.shift.GenerateShiftsFromTemplate(ctx, id)
+	if err != nil {
+		switch err {
+		case shift.ErrTemplateNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("generate shifts from template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "shifts generated successfully",
+		"created": created,
+	})
+}
+
+// checkTemplateScope answers 404 for the templates of the locations the caller may not access
+func (a *Admin) checkTemplateScope(c *gin.Context, id int) bool {
+	locationIds, _ := middleware.GetLocationScope(c)
+	if locationIds == nil {
+		return true
+	}
+	tmpl, err := a.shift.GetShiftTemplate(c.Request.Context(), id)
+	if err == nil && !st.InLocations(locationIds, tmpl.LocationID) {
+		err = shift.ErrTemplateNotFound
+	}
+	switch err {
+	case nil:
+		return true
+	case shift.ErrTemplateNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("get shift template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/role"
+	"payd/services/shift"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateShiftTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	valid := CreateShiftTemplateRequest{
+		RoleID:     2,
+		StartTime:  "08:00",
+		EndTime:    "16:00",
+		Timezone:   "Asia/Jakarta",
+		Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		StartsOn:   "2025-06-02",
+	}
+	expected := st.ShiftTemplate{
+		RoleID:         2,
+		LocationID:     st.DefaultLocationID,
+		StartTimeOfDay: "08:00",
+		EndTimeOfDay:   "16:00",
+		Timezone:       "Asia/Jakarta",
+		Recurrence:     "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		StartsOn:       time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		body           CreateShiftTemplateRequest
+		identity       *auth.Identity
+		callService    bool
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           valid,
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "schedule template created successfully",
+		},
+		{
+			name: "invalid role",
+			body: func() CreateShiftTemplateRequest {
+				r := valid
+				r.RoleID = 9
+				return r
+			}(),
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "roleId is not a valid role ID",
+		},
+		{
+			name: "location the caller may not access",
+			body: func() CreateShiftTemplateRequest {
+				r := valid
+				r.LocationID = ptrInt(2)
+				return r
+			}(),
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "no access to the location",
+		},
+		{
+			name: "invalid time format",
+			body: func() CreateShiftTemplateRequest {
+				r := valid
+				r.StartTime = "8am"
+				return r
+			}(),
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "StartTime",
+		},
+		{
+			name: "endsOn before startsOn",
+			body: func() CreateShiftTemplateRequest {
+				r := valid
+				r.EndsOn = "2025-06-01"
+				return r
+			}(),
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "endsOn must not be before startsOn",
+		},
+		{
+			name:           "invalid recurrence",
+			body:           valid,
+			callService:    true,
+			mockErr:        shift.ErrInvalidRecurrence,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   shift.ErrInvalidRecurrence.Error(),
+		},
+		{
+			name:           "internal error",
+			body:           valid,
+			callService:    true,
+			mockErr:        assert.AnError,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShift := new(MockShiftService)
+			mockRoleService := new(MockRoleService)
+			mockRoleService.On("GetRoles").Return([]role.Role{{ID: 2}})
+			if tc.callService {
+				mockShift.On("CreateShiftTemplate", mock.Anything, expected).Return(1, tc.mockErr)
+			}
+			a := &Admin{shift: mockShift, role: mockRoleService}
+
+			router := gin.New()
+			if tc.identity != nil {
+				router.Use(withIdentity(tc.identity))
+			}
+			router.POST("/schedule-templates", a.createShiftTemplate)
+
+			bodyJSON, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/schedule-templates", bytes.NewReader(bodyJSON))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockShift.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListShiftTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	generated := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	mockShift := new(MockShiftService)
+	mockShift.On("ListShiftTemplates", mock.Anything, []int(nil)).Return([]st.ShiftTemplate{{
+		ID: 1, RoleID: 2, StartTimeOfDay: "08:00:00", EndTimeOfDay: "16:00:00", Timezone: "Asia/Jakarta",
+		Recurrence: "FREQ=DAILY", StartsOn: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), GeneratedThrough: &generated,
+	}}, nil)
+	a := &Admin{shift: mockShift}
+
+	router := gin.New()
+	router.GET("/schedule-templates", a.listShiftTemplates)
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule-templates", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []ShiftTemplateResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Len(t, got, 1)
+	assert.Equal(t, "2025-06-02", got[0].StartsOn)
+	assert.Nil(t, got[0].EndsOn)
+	assert.Equal(t, "2025-06-30", *got[0].GeneratedThrough)
+}
+
+func TestShiftTemplateActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		identity       *auth.Identity
+		template       *st.ShiftTemplate // looked up for the callers restricted to some locations
+		mockMethod     string
+		mockReturn     []interface{}
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "generate",
+			method:         http.MethodPost,
+			path:           "/schedule-templates/1/generate",
+			mockMethod:     "GenerateShiftsFromTemplate",
+			mockReturn:     []interface{}{5, nil},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"created":5`,
+		},
+		{
+			name:           "generate unknown template",
+			method:         http.MethodPost,
+			path:           "/schedule-templates/1/generate",
+			mockMethod:     "GenerateShiftsFromTemplate",
+			mockReturn:     []interface{}{0, shift.ErrTemplateNotFound},
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shift.ErrTemplateNotFound.Error(),
+		},
+		{
+			name:           "delete",
+			method:         http.MethodDelete,
+			path:           "/schedule-templates/1",
+			mockMethod:     "DeleteShiftTemplate",
+			mockReturn:     []interface{}{nil},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "schedule template deleted successfully",
+		},
+		{
+			name:           "delete template of the branch",
+			method:         http.MethodDelete,
+			path:           "/schedule-templates/1",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}},
+			template:       &st.ShiftTemplate{ID: 1, LocationID: 2},
+			mockMethod:     "DeleteShiftTemplate",
+			mockReturn:     []interface{}{nil},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "generate template of another location",
+			method:         http.MethodPost,
+			path:           "/schedule-templates/1/generate",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}},
+			template:       &st.ShiftTemplate{ID: 1, LocationID: 1},
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shift.ErrTemplateNotFound.Error(),
+		},
+		{
+			name:           "delete invalid id",
+			method:         http.MethodDelete,
+			path:           "/schedule-templates/abc",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "invalid schedule template id",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShift := new(MockShiftService)
+			if tc.mockMethod != "" {
+				mockShift.On(tc.mockMethod, mock.Anything, 1).Return(tc.mockReturn...)
+			}
+			if tc.template != nil {
+				mockShift.On("GetShiftTemplate", mock.Anything, 1).Return(tc.template, nil)
+			}
+			a := &Admin{shift: mockShift}
+
+			router := gin.New()
+			if tc.identity != nil {
+				router.Use(withIdentity(tc.identity))
+			}
+			router.POST("/schedule-templates/:id/generate", a.generateShiftsFromTemplate)
+			router.DELETE("/schedule-templates/:id", a.deleteShiftTemplate)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockShift.AssertExpectations(t)
+		})
+	}
+}
@@ -6,21 +6,87 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/location"
 	"payd/services/role"
+	"payd/services/shift"
+	st "payd/storage"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockShiftService struct {
 	mock.Mock
 }
 
-func (m *MockShiftService) CreateNewShiftSchedule(ctx context.Context, roleID int, startTime, endTime time.Time) (int, error) {
-	args := m.Called(mock.Anything, roleID, startTime, endTime)
+func (m *MockShiftService) CreateNewShiftSchedule(ctx context.Context, shift st.NewShift) (int, error) {
+	args := m.Called(mock.Anything, shift)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShiftService) CreateNewShiftSchedules(ctx context.Context, shifts []st.NewShift) ([]int, error) {
+	args := m.Called(ctx, shifts)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockShiftService) ListShifts(ctx context.Context, filter st.ListShiftFilter) ([]st.ShiftWithAssignees, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]st.ShiftWithAssignees), args.Int(1), args.Error(2)
+}
+
+func (m *MockShiftService) GetShift(ctx context.Context, id int) (*st.ShiftWithAssignees, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*st.ShiftWithAssignees), args.Error(1)
+}
+
+func (m *MockShiftService) ListShiftEdits(ctx context.Context, id int, locationIds []int) ([]st.ShiftEditLog, error) {
+	args := m.Called(ctx, id, locationIds)
+	return args.Get(0).([]st.ShiftEditLog), args.Error(1)
+}
+
+func (m *MockShiftService) UpdateShift(ctx context.Context, id int, update st.NewShift, edit shift.ShiftEdit) error {
+	args := m.Called(ctx, id, update, edit)
+	return args.Error(0)
+}
+
+func (m *MockShiftService) CancelShift(ctx context.Context, id int, edit shift.ShiftEdit) error {
+	args := m.Called(ctx, id, edit)
+	return args.Error(0)
+}
+
+func (m *MockShiftService) CreateShiftTemplate(ctx context.Context, tmpl st.ShiftTemplate) (int, error) {
+	args := m.Called(mock.Anything, tmpl)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShiftService) ListShiftTemplates(ctx context.Context, locationIds []int) ([]st.ShiftTemplate, error) {
+	args := m.Called(mock.Anything, locationIds)
+	templates, _ := args.Get(0).([]st.ShiftTemplate)
+	return templates, args.Error(1)
+}
+
+func (m *MockShiftService) GetShiftTemplate(ctx context.Context, id int) (*st.ShiftTemplate, error) {
+	args := m.Called(mock.Anything, id)
+	tmpl, _ := args.Get(0).(*st.ShiftTemplate)
+	return tmpl, args.Error(1)
+}
+
+func (m *MockShiftService) DeleteShiftTemplate(ctx context.Context, id int) error {
+	args := m.Called(mock.Anything, id)
+	return args.Error(0)
+}
+
+func (m *MockShiftService) GenerateShiftsFromTemplate(ctx context.Context, id int) (int, error) {
+	args := m.Called(mock.Anything, id)
 	return args.Int(0), args.Error(1)
 }
 
@@ -31,6 +97,7 @@ func TestCreateSchedule(t *testing.T) {
 		RoleID    int       `json:"roleId"`
 		StartTime time.Time `json:"startTime"`
 		EndTime   time.Time `json:"endTime"`
+		Headcount int       `json:"headcount,omitempty"`
 	}
 
 	now := time.Now().Round(0)
@@ -55,6 +122,28 @@ func TestCreateSchedule(t *testing.T) {
 			wantStatusCode: http.StatusOK,
 			wantRespBody:   "schedule created successfully",
 		},
+		{
+			name: "with headcount",
+			body: request{
+				RoleID:    1,
+				StartTime: now,
+				EndTime:   now.Add(1 * time.Hour),
+				Headcount: 3,
+			},
+			mockReturnID:   124,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "schedule created successfully",
+		},
+		{
+			name: "negative headcount",
+			body: request{
+				RoleID:    1,
+				StartTime: now,
+				EndTime:   now.Add(1 * time.Hour),
+				Headcount: -1,
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "invalid role ID",
 			body: request{
@@ -102,12 +191,10 @@ func TestCreateSchedule(t *testing.T) {
 			if tc.wantStatusCode == http.StatusOK || tc.mockReturnErr != nil {
 				mockShift.On("CreateNewShiftSchedule",
 					mock.Anything,
-					tc.body.RoleID,
-					mock.MatchedBy(func(t time.Time) bool {
-						return t.Equal(tc.body.StartTime)
-					}),
-					mock.MatchedBy(func(t time.Time) bool {
-						return t.Equal(tc.body.EndTime)
+					mock.MatchedBy(func(s st.NewShift) bool {
+						return s.RoleID == tc.body.RoleID && s.LocationID == st.DefaultLocationID &&
+							s.StartTime.Equal(tc.body.StartTime) && s.EndTime.Equal(tc.body.EndTime) &&
+							s.Headcount == tc.body.Headcount
 					})).
 					Return(tc.mockReturnID, tc.mockReturnErr)
 			}
@@ -131,3 +218,396 @@ func TestCreateSchedule(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkCreateSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type item struct {
+		RoleID    int       `json:"roleId,omitempty"`
+		StartTime time.Time `json:"startTime"`
+		EndTime   time.Time `json:"endTime"`
+	}
+
+	now := time.Now().Round(0)
+	valid := item{RoleID: 1, StartTime: now, EndTime: now.Add(time.Hour)}
+	tests := []struct {
+		name           string
+		items          []item
+		mockCreate     bool
+		mockReturnErr  error
+		wantStatusCode int
+		wantRespBody   []string
+	}{
+		{
+			name:           "success",
+			items:          []item{valid, {RoleID: 1, StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}},
+			mockCreate:     true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"ids":[1,2]`},
+		},
+		{
+			name:           "empty batch",
+			items:          []item{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "per item errors",
+			items: []item{
+				valid,
+				{RoleID: 999, StartTime: now, EndTime: now.Add(time.Hour)},
+				{RoleID: 1, StartTime: now.Add(time.Hour), EndTime: now},
+				valid,
+				{StartTime: now, EndTime: now.Add(time.Hour)},
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody: []string{
+				`{"index":1,"error":"roleId is not a valid role ID"}`,
+				`{"index":2,"error":"startTime must be before endTime"}`,
+				`{"index":3,"error":"duplicate of shift at index 0"}`,
+				`"index":4`,
+			},
+		},
+		{
+			name:           "internal error",
+			items:          []item{valid},
+			mockCreate:     true,
+			mockReturnErr:  assert.AnError,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   []string{"internal error"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShift := new(MockShiftService)
+			mockRoleService := new(MockRoleService)
+			mockRoleService.On("GetRoles").Return([]role.Role{{ID: 1}})
+
+			a := &Admin{
+				shift: mockShift,
+				role:  mockRoleService,
+			}
+
+			if tc.mockCreate {
+				var ids []int
+				if tc.mockReturnErr == nil {
+					for i := range tc.items {
+						ids = append(ids, i+1)
+					}
+				}
+				mockShift.On("CreateNewShiftSchedules", mock.Anything,
+					mock.MatchedBy(func(shifts []st.NewShift) bool {
+						if len(shifts) != len(tc.items) {
+							return false
+						}
+						for i, s := range shifts {
+							if s.RoleID != tc.items[i].RoleID || s.LocationID != st.DefaultLocationID ||
+								!s.StartTime.Equal(tc.items[i].StartTime) || !s.EndTime.Equal(tc.items[i].EndTime) {
+								return false
+							}
+						}
+						return true
+					})).
+					Return(ids, tc.mockReturnErr)
+			}
+
+			router := gin.New()
+			router.POST("/schedules/bulk", a.bulkCreateShiftSchedules)
+
+			bodyJSON, err := json.Marshal(map[string]interface{}{"shifts": tc.items})
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/schedules/bulk", bytes.NewReader(bodyJSON))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			for _, body := range tc.wantRespBody {
+				assert.Contains(t, w.Body.String(), body)
+			}
+
+			mockShift.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListShiftSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	timeRange := "start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z"
+	assigned := true
+	filled := false
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *st.ListShiftFilter
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "default page",
+			query:          timeRange,
+			wantFilter:     &st.ListShiftFilter{Start: start, End: end, Limit: defaultShiftPageSize},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"assignees":[{"employeeId":4,"name":"Alice"}]`,
+		},
+		{
+			name:           "understaffed shifts",
+			query:          timeRange + "&filled=false",
+			wantFilter:     &st.ListShiftFilter{Start: start, End: end, Filled: &filled, Limit: defaultShiftPageSize},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"fillRatio":0.5`,
+		},
+		{
+			name:           "filters",
+			query:          timeRange + "&roleId=2&assigned=true&limit=10&offset=20",
+			wantFilter:     &st.ListShiftFilter{Start: start, End: end, RoleID: 2, Assigned: &assigned, Limit: 10, Offset: 20},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"total":21`,
+		},
+		{
+			name:           "missing range",
+			query:          "roleId=2",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "limit too large",
+			query:          timeRange + "&limit=1000",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "start after end",
+			query:          "start=2025-05-16T00:00:00Z&end=2025-05-15T00:00:00Z",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "start must be before end",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShift := new(MockShiftService)
+			if tc.wantFilter != nil {
+				mockShift.On("ListShifts", mock.Anything, mock.MatchedBy(func(f st.ListShiftFilter) bool {
+					return f.Start.Equal(tc.wantFilter.Start) && f.End.Equal(tc.wantFilter.End) &&
+						f.RoleID == tc.wantFilter.RoleID && assert.ObjectsAreEqual(tc.wantFilter.Assigned, f.Assigned) &&
+						assert.ObjectsAreEqual(tc.wantFilter.Filled, f.Filled) &&
+						f.Limit == tc.wantFilter.Limit && f.Offset == tc.wantFilter.Offset
+				})).Return([]st.ShiftWithAssignees{
+					{Shift: st.Shift{ID: 3, RoleID: 2, Headcount: 2}, Approved: 1, AssigneeIDs: pq.Int64Array{4}, AssigneeNames: pq.StringArray{"Alice"}},
+				}, 21, nil)
+			}
+			a := &Admin{shift: mockShift}
+
+			router := gin.New()
+			router.GET("/schedules", a.listShiftSchedules)
+
+			req := httptest.NewRequest(http.MethodGet, "/schedules?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockShift.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetShiftSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockShift := new(MockShiftService)
+	mockShift.On("GetShift", mock.Anything, 3).Return(&st.ShiftWithAssignees{Shift: st.Shift{ID: 3, RoleID: 2, LocationID: 1}}, nil)
+	mockShift.On("GetShift", mock.Anything, 4).Return(nil, shift.ErrShiftNotFound)
+	mockShift.On("GetShift", mock.Anything, 5).Return(&st.ShiftWithAssignees{Shift: st.Shift{ID: 5, RoleID: 2, LocationID: 2}}, nil)
+	a := &Admin{shift: mockShift}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}}))
+	router.GET("/schedules/:id", a.getShiftSchedule)
+
+	for path, want := range map[string]int{
+		"/schedules/3":   http.StatusOK,
+		"/schedules/4":   http.StatusNotFound,
+		"/schedules/5":   http.StatusNotFound, // another location
+		"/schedules/abc": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockShift.AssertExpectations(t)
+}
+
+func TestGetShiftScheduleInLocationTimezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// the night of the switch to summer time, 22:00 CET to 06:00 CEST is 7 hours
+	start := time.Date(2025, 3, 29, 21, 0, 0, 0, time.UTC)
+	mockShift := new(MockShiftService)
+	mockShift.On("GetShift", mock.Anything, 3).Return(&st.ShiftWithAssignees{Shift: st.Shift{
+		ID: 3, RoleID: 2, LocationID: 1, StartTime: start, EndTime: start.Add(7 * time.Hour), CreatedAt: start,
+	}}, nil)
+	mockLocation := new(MockLocationService)
+	mockLocation.On("Timezones", mock.Anything).Return(location.Timezones{1: berlin}, nil)
+	a := &Admin{shift: mockShift, location: mockLocation}
+
+	router := gin.New()
+	router.Use(withIdentity(&auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 1, LocationIDs: []int{1}}))
+	router.GET("/schedules/:id", a.getShiftSchedule)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedules/3", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"startTime":"2025-03-29T22:00:00+01:00","endTime":"2025-03-30T06:00:00+02:00"`)
+}
+
+func TestUpdateShiftSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+	body := map[string]interface{}{"roleId": 1, "startTime": start, "endTime": start.Add(8 * time.Hour)}
+	forced := map[string]interface{}{"roleId": 1, "startTime": start, "endTime": start.Add(8 * time.Hour),
+		"force": true, "reason": "store opens later"}
+
+	tests := []struct {
+		name           string
+		body           map[string]interface{}
+		identity       *auth.Identity
+		wantEdit       *shift.ShiftEdit
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           body,
+			identity:       adminIdentity,
+			wantEdit:       &shift.ShiftEdit{EditedBy: 1},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "schedule updated successfully",
+		},
+		{
+			name:           "forced with reason",
+			body:           forced,
+			identity:       adminIdentity,
+			wantEdit:       &shift.ShiftEdit{EditedBy: 1, Force: true, Reason: "store opens later"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "schedule updated successfully",
+		},
+		{
+			name:           "startTime after endTime",
+			body:           map[string]interface{}{"roleId": 1, "startTime": start, "endTime": start.Add(-time.Hour)},
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "startTime must be before endTime",
+		},
+		{
+			name:           "invalid role",
+			body:           map[string]interface{}{"roleId": 9, "startTime": start, "endTime": start.Add(time.Hour)},
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "roleId is not a valid role ID",
+		},
+		{
+			name:           "editor without employee",
+			body:           body,
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "editor is not linked to an employee",
+		},
+		{
+			name:           "assigned shift without force",
+			body:           body,
+			identity:       adminIdentity,
+			wantEdit:       &shift.ShiftEdit{EditedBy: 1},
+			mockErr:        shift.ErrShiftHasAssignee,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shift.ErrShiftHasAssignee.Error(),
+		},
+		{
+			name:           "assignee double-booked",
+			body:           forced,
+			identity:       adminIdentity,
+			wantEdit:       &shift.ShiftEdit{EditedBy: 1, Force: true, Reason: "store opens later"},
+			mockErr:        &shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: 4, ShiftID: 3, ConflictingShiftID: 8},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"conflictingShiftId":8`,
+		},
+		{
+			name:           "shift of another location",
+			body:           body,
+			identity:       &auth.Identity{EmployeeId: "1", Role: "employee", LocationID: 2, LocationIDs: []int{2}},
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shift.ErrShiftNotFound.Error(),
+		},
+		{
+			name:           "not found",
+			body:           body,
+			identity:       adminIdentity,
+			wantEdit:       &shift.ShiftEdit{EditedBy: 1},
+			mockErr:        shift.ErrShiftNotFound,
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shift.ErrShiftNotFound.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShift := new(MockShiftService)
+			mockRoleService := new(MockRoleService)
+			mockRoleService.On("GetRoles").Return([]role.Role{{ID: 1}})
+			mockShift.On("GetShift", mock.Anything, 3).Return(&st.ShiftWithAssignees{Shift: st.Shift{ID: 3, LocationID: 1}}, nil).Maybe()
+			if tc.wantEdit != nil {
+				mockShift.On("UpdateShift", mock.Anything, 3, mock.MatchedBy(func(s st.NewShift) bool {
+					return s.RoleID == 1 && s.StartTime.Equal(start)
+				}), *tc.wantEdit).Return(tc.mockErr)
+			}
+			a := &Admin{shift: mockShift, role: mockRoleService}
+
+			router := gin.New()
+			router.PUT("/schedules/:id", withIdentity(tc.identity), a.updateShiftSchedule)
+
+			bodyJSON, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPut, "/schedules/3", bytes.NewReader(bodyJSON))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockShift.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancelShiftSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockShift := new(MockShiftService)
+	mockShift.On("CancelShift", mock.Anything, 3, shift.ShiftEdit{EditedBy: 1, Force: true, Reason: "closed"}).Return(nil)
+	mockShift.On("CancelShift", mock.Anything, 3, shift.ShiftEdit{EditedBy: 1}).Return(shift.ErrShiftHasAssignee)
+	a := &Admin{shift: mockShift}
+
+	router := gin.New()
+	router.DELETE("/schedules/:id", withIdentity(adminIdentity), a.cancelShiftSchedule)
+
+	for path, want := range map[string]int{
+		"/schedules/3?force=true&reason=closed": http.StatusOK,
+		"/schedules/3":                          http.StatusConflict,
+		"/schedules/abc":                        http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockShift.AssertExpectations(t)
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/permission"
+	"payd/services/timesheet"
+	st "payd/storage"
+	"payd/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the approved shifts starting within [start, end]
+type ListTimesheetsQuery struct {
+	Start      time.Time `form:"start" binding:"required"`
+	End        time.Time `form:"end" binding:"required"`
+	EmployeeID int       `form:"employeeId"`
+	RoleID     int       `form:"roleId"`
+	// only the late, missed or overtime shifts
+	Issue string `form:"issue" binding:"omitempty,oneof=late no-show overtime"`
+}
+
+type AttendanceResponse struct {
+	ShiftRequestID  int        `json:"shiftRequestId"`
+	EmployeeID      int        `json:"employeeId"`
+	EmployeeName    string     `json:"employeeName"`
+	ShiftID         int        `json:"shiftId"`
+	RoleID          int        `json:"roleId"`
+	LocationID      int        `json:"locationId"`
+	StartTime       time.Time  `json:"startTime"`
+	EndTime         time.Time  `json:"endTime"`
+	Status          string     `json:"status"`
+	ClockIn         *time.Time `json:"clockIn,omitempty"`
+	ClockOut        *time.Time `json:"clockOut,omitempty"`
+	BreakMinutes    int        `json:"breakMinutes"`
+	LateMinutes     int        `json:"lateMinutes"`
+	WorkedMinutes   int        `json:"workedMinutes"`
+	OvertimeMinutes int        `json:"overtimeMinutes"`
+}
+
+type TimesheetSummaryResponse struct {
+	EmployeeID       int    `json:"employeeId"`
+	EmployeeName     string `json:"employeeName"`
+	Shifts           int    `json:"shifts"`
+	ScheduledMinutes int    `json:"scheduledMinutes"`
+	WorkedMinutes    int    `json:"workedMinutes"`
+	LateShifts       int    `json:"lateShifts"`
+	LateMinutes      int    `json:"lateMinutes"`
+	NoShows          int    `json:"noShows"`
+	OvertimeMinutes  int    `json:"overtimeMinutes"`
+}
+
+// listTimesheets compares every approved shift of the period with its time entry
+func (a *Admin) listTimesheets(c *gin.Context) {
+	req, filter, ok := bindTimesheetsQuery(c)
+	if !ok {
+		return
+	}
+	attendance, err := a.timesheet.ListAttendance(c.Request.Context(), filter, req.Start, req.End)
+	if err != nil {
+		timesheetError(c, err, "list timesheets")
+		return
+	}
+
+	zones := a.timezones(c)
+	res := make([]AttendanceResponse, 0, len(attendance))
+	for _, at := range attendance {
+		switch {
+		case req.Issue == "late" && at.Late == 0,
+			req.Issue == "no-show" && at.Status != timesheet.StatusNoShow,
+			req.Issue == "overtime" && at.Overtime == 0:
+			continue
+		}
+		r := AttendanceResponse{
+			ShiftRequestID:  at.Request.ID,
+			EmployeeID:      at.Request.EmployeeID,
+			EmployeeName:    at.Request.EmployeeName,
+			ShiftID:         at.Request.ShiftID,
+			RoleID:          at.Request.RoleID,
+			LocationID:      at.Request.LocationID,
+			StartTime:       zones.In(at.Request.LocationID, at.Request.StartTime),
+			EndTime:         zones.In(at.Request.LocationID, at.Request.EndTime),
+			Status:          at.Status,
+			LateMinutes:     int(at.Late / time.Minute),
+			WorkedMinutes:   int(at.Worked / time.Minute),
+			OvertimeMinutes: int(at.Overtime / time.Minute),
+		}
+		if at.Entry != nil {
+			r.ClockIn = zones.InPtr(at.Request.LocationID, &at.Entry.ClockIn)
+			r.ClockOut = zones.InPtr(at.Request.LocationID, at.Entry.ClockOut)
+			r.BreakMinutes = int(at.Entry.Breaks() / time.Minute)
+		}
+		res = append(res, r)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// summarizeTimesheets totals the lateness, no-shows and overtime of every employee over the period
+func (a *Admin) summarizeTimesheets(c *gin.Context) {
+	req, filter, ok := bindTimesheetsQuery(c)
+	if !ok {
+		return
+	}
+	summaries, err := a.timesheet.Summarize(c.Request.Context(), filter, req.Start, req.End)
+	if err != nil {
+		timesheetError(c, err, "summarize timesheets")
+		return
+	}
+
+	res := make([]TimesheetSummaryResponse, 0, len(summaries))
+	for _, s := range summaries {
+		res = append(res, TimesheetSummaryResponse{
+			EmployeeID:       s.EmployeeID,
+			EmployeeName:     s.EmployeeName,
+			Shifts:           s.Shifts,
+			ScheduledMinutes: int(s.Scheduled / time.Minute),
+			WorkedMinutes:    int(s.Worked / time.Minute),
+			LateShifts:       s.LateShifts,
+			LateMinutes:      int(s.Late / time.Minute),
+			NoShows:          s.NoShows,
+			OvertimeMinutes:  int(s.Overtime / time.Minute),
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// bindTimesheetsQuery restricts the shifts to the job roles and locations of the caller,
+// writes the error response and returns false if the query is invalid
+func bindTimesheetsQuery(c *gin.Context) (ListTimesheetsQuery, st.ListShiftRequestFilter, bool) {
+	var req ListTimesheetsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, st.ListShiftRequestFilter{}, false
+	}
+	grants, _ := middleware.GetGrants(c)
+	locationIds, _ := middleware.GetLocationScope(c)
+	return req, st.ListShiftRequestFilter{
+		EmployeeID:  req.EmployeeID,
+		RoleID:      req.RoleID,
+		RoleIDs:     grants.JobRoles(permission.TimesheetsRead),
+		LocationIDs: locationIds,
+	}, true
+}
+
+func timesheetError(c *gin.Context, err error, msg string) {
+	switch err {
+	case timesheet.ErrInvalidTimeRange, timesheet.ErrRangeTooLong:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
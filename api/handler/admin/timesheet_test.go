@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/permission"
+	"payd/services/timesheet"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTimesheetService struct {
+	mock.Mock
+}
+
+func (m *MockTimesheetService) ClockIn(ctx context.Context, employeeId, shiftRequestId int) (int, error) {
+	args := m.Called(ctx, employeeId, shiftRequestId)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTimesheetService) ClockOut(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) StartBreak(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) EndBreak(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) ListAttendance(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]timesheet.Attendance, error) {
+	args := m.Called(ctx, filter, start, end)
+	attendance, _ := args.Get(0).([]timesheet.Attendance)
+	return attendance, args.Error(1)
+}
+
+func (m *MockTimesheetService) Summarize(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]timesheet.Summary, error) {
+	args := m.Called(ctx, filter, start, end)
+	summaries, _ := args.Get(0).([]timesheet.Summary)
+	return summaries, args.Error(1)
+}
+
+func TestListTimesheets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	timeRange := "start=2025-06-01T00:00:00Z&end=2025-06-08T00:00:00Z"
+	clockIn := start.Add(9*time.Hour + 20*time.Minute)
+	attendance := []timesheet.Attendance{
+		{
+			Request: st.ShiftRequestWithShiftDetails{ID: 5, EmployeeID: 4, EmployeeName: "Alice", StartTime: start.Add(9 * time.Hour)},
+			Entry:   &st.TimeEntry{ClockIn: clockIn},
+			Status:  timesheet.StatusClockedIn,
+			Late:    20 * time.Minute,
+		},
+		{
+			Request: st.ShiftRequestWithShiftDetails{ID: 6, EmployeeID: 5, EmployeeName: "Bob", StartTime: start.Add(9 * time.Hour)},
+			Status:  timesheet.StatusNoShow,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		grants         permission.Grants
+		wantFilter     *st.ListShiftRequestFilter
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   []string
+		wantNotInBody  string
+	}{
+		{
+			name:           "every shift",
+			query:          timeRange,
+			wantFilter:     &st.ListShiftRequestFilter{},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"lateMinutes":20`, `"status":"NO_SHOW"`},
+		},
+		{
+			name:           "no-shows of the granted job roles",
+			query:          timeRange + "&issue=no-show&employeeId=5",
+			grants:         permission.Grants{permission.TimesheetsRead: {2}},
+			wantFilter:     &st.ListShiftRequestFilter{EmployeeID: 5, RoleIDs: []int{2}},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"employeeName":"Bob"`},
+			wantNotInBody:  "Alice",
+		},
+		{
+			name:           "unknown issue",
+			query:          timeRange + "&issue=absent",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "range too long",
+			query:          timeRange,
+			wantFilter:     &st.ListShiftRequestFilter{},
+			mockErr:        timesheet.ErrRangeTooLong,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   []string{timesheet.ErrRangeTooLong.Error()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockTimesheetService)
+			if tc.wantFilter != nil {
+				res := attendance
+				if tc.mockErr != nil {
+					res = nil
+				}
+				mockSvc.On("ListAttendance", mock.Anything, *tc.wantFilter, start, end).Return(res, tc.mockErr)
+			}
+			a := &Admin{timesheet: mockSvc}
+
+			router := gin.New()
+			router.Use(withGrants(tc.grants))
+			router.GET("/timesheets", a.listTimesheets)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/timesheets?"+tc.query, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			for _, body := range tc.wantRespBody {
+				assert.Contains(t, w.Body.String(), body)
+			}
+			if tc.wantNotInBody != "" {
+				assert.NotContains(t, w.Body.String(), tc.wantNotInBody)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSummarizeTimesheets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc := new(MockTimesheetService)
+	mockSvc.On("Summarize", mock.Anything, st.ListShiftRequestFilter{}, start, start.AddDate(0, 1, 0)).
+		Return([]timesheet.Summary{{
+			EmployeeID: 4, EmployeeName: "Alice", Shifts: 3, Scheduled: 24 * time.Hour, Worked: 23 * time.Hour,
+			LateShifts: 1, Late: 20 * time.Minute, NoShows: 1, Overtime: 90 * time.Minute,
+		}}, nil)
+	a := &Admin{timesheet: mockSvc}
+
+	router := gin.New()
+	router.Use(withGrants(nil))
+	router.GET("/timesheets/summary", a.summarizeTimesheets)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/timesheets/summary?start=2025-06-01T00:00:00Z&end=2025-07-01T00:00:00Z", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"scheduledMinutes":1440`)
+	assert.Contains(t, w.Body.String(), `"noShows":1`)
+	assert.Contains(t, w.Body.String(), `"overtimeMinutes":90`)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payd/middleware"
+	"payd/services/webhook"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultWebhookDeliveryPageSize = 50
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required,url,max=2000"`
+	// omitted or empty for every event type
+	EventTypes []string `json:"eventTypes" binding:"omitempty,dive,required"`
+}
+
+// ListWebhookDeliveriesQuery filters the deliveries, status DEAD lists the dead letters
+type ListWebhookDeliveriesQuery struct {
+	WebhookID int    `form:"webhookId" binding:"omitempty,min=1"`
+	Status    string `form:"status" binding:"omitempty,oneof=PENDING DELIVERED DEAD"`
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset    int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type WebhookResponse struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedBy  int       `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventID        int64           `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	WebhookID      int             `json:"webhookId"`
+	WebhookURL     string          `json:"webhookUrl"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt"` // only for the pending deliveries
+	LastStatusCode *int            `json:"lastStatusCode"`
+	LastError      *string         `json:"lastError"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+func newWebhookDeliveryResponse(d st.WebhookDelivery) WebhookDeliveryResponse {
+	res := WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		WebhookID:      d.WebhookID,
+		WebhookURL:     d.WebhookURL,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == st.WebhookDeliveryPending {
+		res.NextAttemptAt = &d.NextAttemptAt
+	}
+	return res
+}
+
+func (a *Admin) listWebhooks(c *gin.Context) {
+	webhooks, err := a.webhook.ListWebhooks(c.Request.Context())
+	if err != nil {
+		a.webhookError(c, err, "list webhooks")
+		return
+	}
+	res := make([]WebhookResponse, 0, len(webhooks))
+	for _, w := range webhooks {
+		res = append(res, WebhookResponse{
+			ID:         w.ID,
+			URL:        w.URL,
+			EventTypes: w.EventTypes,
+			CreatedBy:  w.CreatedBy,
+			CreatedAt:  w.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": res, "eventTypes": webhook.EventTypes})
+}
+
+// the secret is only returned in this response
+func (a *Admin) createWebhook(c *gin.Context) {
+	creatorId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin is not linked to an employee"})
+		return
+	}
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, secret, err := a.webhook.CreateWebhook(c.Request.Context(), creatorId, req.URL, req.EventTypes)
+	if err != nil {
+		a.webhookError(c, err, "create webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook created successfully, the secret won't be shown again",
+		"id":      id,
+		"secret":  secret,
+	})
+}
+
+func (a *Admin) deleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+	if err := a.webhook.DeleteWebhook(c.Request.Context(), id); err != nil {
+		a.webhookError(c, err, "delete webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook deleted successfully",
+		"id":      id,
+	})
+}
+
+func (a *Admin) listWebhookDeliveries(c *gin.Context) {
+	var req ListWebhookDeliveriesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultWebhookDeliveryPageSize
+	}
+
+	deliveries, total, err := a.webhook.ListDeliveries(c.Request.Context(), st.ListWebhookDeliveryFilter{
+		WebhookID: req.WebhookID,
+		Status:    req.Status,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	})
+	if err != nil {
+		a.webhookError(c, err, "list webhook deliveries")
+		return
+	}
+
+	res := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		res = append(res, newWebhookDeliveryResponse(d))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": res,
+		"total":      total,
+		"limit":      req.Limit,
+		"offset":     req.Offset,
+	})
+}
+
+// retryWebhookDelivery queues a dead delivery again
+func (a *Admin) retryWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook delivery id"})
+		return
+	}
+	if err := a.webhook.RetryDelivery(c.Request.Context(), id); err != nil {
+		a.webhookError(c, err, "retry webhook delivery")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook delivery queued again",
+		"id":      id,
+	})
+}
+
+func (a *Admin) webhookError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, webhook.ErrWebhookNotFound), errors.Is(err, webhook.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, webhook.ErrUnknownEventType),
+		errors.Is(err, webhook.ErrInvalidStatus), errors.Is(err, webhook.ErrInvalidPage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/webhook"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, createdBy int, url string, eventTypes []string) (int, string, error) {
+	args := m.Called(ctx, createdBy, url, eventTypes)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockWebhookService) ListWebhooks(ctx context.Context) ([]st.Webhook, error) {
+	args := m.Called(ctx)
+	webhooks, _ := args.Get(0).([]st.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, filter st.ListWebhookDeliveryFilter) ([]st.WebhookDelivery, int, error) {
+	args := m.Called(ctx, filter)
+	deliveries, _ := args.Get(0).([]st.WebhookDelivery)
+	return deliveries, args.Int(1), args.Error(2)
+}
+
+func (m *MockWebhookService) RetryDelivery(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		identity       *auth.Identity
+		mockTypes      []string
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"url":"https://pos.example.com/hooks","eventTypes":["shift.created"]}`,
+			identity:       adminIdentity,
+			mockTypes:      []string{webhook.ShiftCreated},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"secret":"whsec_secret"`,
+		},
+		{
+			name:           "invalid url",
+			body:           `{"url":"pos"}`,
+			identity:       adminIdentity,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "URL",
+		},
+		{
+			name:           "unknown event type",
+			body:           `{"url":"https://pos.example.com/hooks","eventTypes":["shift.moved"]}`,
+			identity:       adminIdentity,
+			mockTypes:      []string{"shift.moved"},
+			mockErr:        fmt.Errorf("%w: shift.moved", webhook.ErrUnknownEventType),
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   webhook.ErrUnknownEventType.Error(),
+		},
+		{
+			name:           "admin without employee",
+			body:           `{"url":"https://pos.example.com/hooks"}`,
+			identity:       &auth.Identity{Role: "admin"},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "admin is not linked to an employee",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockWebhookService)
+			if tc.mockTypes != nil {
+				mockSvc.On("CreateWebhook", mock.Anything, 1, "https://pos.example.com/hooks", tc.mockTypes).
+					Return(3, "whsec_secret", tc.mockErr)
+			}
+			a := &Admin{webhook: mockSvc}
+
+			router := gin.New()
+			router.Use(withIdentity(tc.identity))
+			router.POST("/webhooks", a.createWebhook)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListAndDeleteWebhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockWebhookService)
+	mockSvc.On("ListWebhooks", mock.Anything).Return([]st.Webhook{
+		{ID: 3, URL: "https://pos.example.com/hooks", Secret: "whsec_secret", EventTypes: []string{webhook.ShiftCreated}, CreatedBy: 1},
+	}, nil)
+	mockSvc.On("DeleteWebhook", mock.Anything, 3).Return(nil)
+	mockSvc.On("DeleteWebhook", mock.Anything, 4).Return(webhook.ErrWebhookNotFound)
+	a := &Admin{webhook: mockSvc}
+
+	router := gin.New()
+	router.GET("/webhooks", a.listWebhooks)
+	router.DELETE("/webhooks/:id", a.deleteWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"url":"https://pos.example.com/hooks"`)
+	assert.NotContains(t, w.Body.String(), "whsec_secret")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/webhooks/3", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/webhooks/4", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	code := http.StatusBadGateway
+	lastError := "unexpected status 502"
+	deliveries := []st.WebhookDelivery{{
+		ID: 11, EventID: 7, EventType: webhook.ShiftCreated, Payload: json.RawMessage(`{"id":3}`), WebhookID: 3,
+		WebhookURL: "https://pos.example.com/hooks", WebhookSecret: "whsec_secret", Status: st.WebhookDeliveryDead, Attempts: 8,
+		NextAttemptAt: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), LastStatusCode: &code, LastError: &lastError,
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *st.ListWebhookDeliveryFilter
+		wantStatusCode int
+		wantRespBody   []string
+	}{
+		{
+			name:           "dead letters",
+			query:          "status=DEAD&webhookId=3",
+			wantFilter:     &st.ListWebhookDeliveryFilter{WebhookID: 3, Status: st.WebhookDeliveryDead, Limit: defaultWebhookDeliveryPageSize},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   []string{`"status":"DEAD"`, `"lastStatusCode":502`, `"payload":{"id":3}`, `"nextAttemptAt":null`, `"total":1`},
+		},
+		{
+			name:           "unknown status",
+			query:          "status=LOST",
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockWebhookService)
+			if tc.wantFilter != nil {
+				mockSvc.On("ListDeliveries", mock.Anything, *tc.wantFilter).Return(deliveries, len(deliveries), nil)
+			}
+			a := &Admin{webhook: mockSvc}
+
+			router := gin.New()
+			router.GET("/webhook-deliveries", a.listWebhookDeliveries)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhook-deliveries?"+tc.query, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			for _, body := range tc.wantRespBody {
+				assert.Contains(t, w.Body.String(), body)
+			}
+			assert.NotContains(t, w.Body.String(), "whsec_secret")
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockWebhookService)
+	mockSvc.On("RetryDelivery", mock.Anything, int64(11)).Return(nil)
+	mockSvc.On("RetryDelivery", mock.Anything, int64(12)).Return(webhook.ErrDeliveryNotFound)
+	a := &Admin{webhook: mockSvc}
+
+	router := gin.New()
+	router.POST("/webhook-deliveries/:id/retry", a.retryWebhookDelivery)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook-deliveries/11/retry", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook-deliveries/12/retry", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook-deliveries/abc/retry", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,208 @@
+package employee
+
+import (
+	"errors"
+	"net/http"
+	"payd/services/availability"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AvailabilityWindowRequest struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"`
+	StartTime string `json:"startTime" binding:"required"` // HH:MM
+	EndTime   string `json:"endTime" binding:"required"`   // HH:MM, not after the start time for the next day
+}
+
+type SetAvailabilityRequest struct {
+	Timezone string                      `json:"timezone" binding:"required"`
+	Windows  []AvailabilityWindowRequest `json:"windows" binding:"dive"`
+}
+
+type AvailabilityWindowResponse struct {
+	Weekday   int    `json:"weekday"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Timezone  string `json:"timezone"`
+}
+
+type UnavailabilityRequest struct {
+	Start  time.Time `json:"start" binding:"required"`
+	End    time.Time `json:"end" binding:"required"`
+	Reason string    `json:"reason" binding:"required"`
+}
+
+type UnavailabilityResponse struct {
+	ID        int       `json:"id"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// the caller's weekly availability, empty when they can work at any time
+func (e *Employee) getAvailability(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	windows, err := e.availability.ListAvailability(ctx, employeeId)
+	if err != nil {
+		log.WithError(err).Error("list availability")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]AvailabilityWindowResponse, 0, len(windows))
+	for _, w := range windows {
+		res = append(res, AvailabilityWindowResponse{
+			Weekday:   w.Weekday,
+			StartTime: w.StartTimeOfDay,
+			EndTime:   w.EndTimeOfDay,
+			Timezone:  w.Timezone,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// replaces the caller's weekly availability
+func (e *Employee) setAvailability(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req SetAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	windows := make([]st.AvailabilityWindow, 0, len(req.Windows))
+	for _, w := range req.Windows {
+		windows = append(windows, st.AvailabilityWindow{
+			EmployeeID:     employeeId,
+			Weekday:        w.Weekday,
+			StartTimeOfDay: w.StartTime,
+			EndTimeOfDay:   w.EndTime,
+			Timezone:       req.Timezone,
+		})
+	}
+	if err := e.availability.SetAvailability(ctx, employeeId, windows); err != nil {
+		availabilityError(c, err, "set availability")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "availability updated successfully"})
+}
+
+// the caller's unavailabilities overlapping the time range
+func (e *Employee) listUnavailabilities(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req TimeRangeQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	unavailabilities, err := e.availability.ListUnavailabilities(ctx, employeeId, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list unavailabilities")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]UnavailabilityResponse, 0, len(unavailabilities))
+	for _, u := range unavailabilities {
+		res = append(res, UnavailabilityResponse{
+			ID:        u.ID,
+			Start:     u.StartTime,
+			End:       u.EndTime,
+			Reason:    u.Reason,
+			CreatedAt: u.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) createUnavailability(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req UnavailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := e.availability.AddUnavailability(ctx, st.Unavailability{
+		EmployeeID: employeeId,
+		StartTime:  req.Start,
+		EndTime:    req.End,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		availabilityError(c, err, "create unavailability")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "unavailability created successfully",
+		"id":      id,
+	})
+}
+
+func (e *Employee) deleteUnavailability(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid unavailability id"})
+		return
+	}
+	if err := e.availability.DeleteUnavailability(ctx, employeeId, id); err != nil {
+		availabilityError(c, err, "delete unavailability")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "unavailability deleted successfully",
+		"id":      id,
+	})
+}
+
+func availabilityError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, availability.ErrUnavailabilityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, availability.ErrInvalidWeekday), errors.Is(err, availability.ErrInvalidTimeOfDay),
+		errors.Is(err, availability.ErrInvalidTimezone), errors.Is(err, availability.ErrInvalidTimeRange),
+		errors.Is(err, availability.ErrInvalidReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,151 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/availability"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAvailabilityService struct {
+	mock.Mock
+}
+
+func (m *MockAvailabilityService) ListAvailability(ctx context.Context, employeeId int) ([]st.AvailabilityWindow, error) {
+	args := m.Called(ctx, employeeId)
+	windows, _ := args.Get(0).([]st.AvailabilityWindow)
+	return windows, args.Error(1)
+}
+
+func (m *MockAvailabilityService) SetAvailability(ctx context.Context, employeeId int, windows []st.AvailabilityWindow) error {
+	args := m.Called(ctx, employeeId, windows)
+	return args.Error(0)
+}
+
+func (m *MockAvailabilityService) ListUnavailabilities(ctx context.Context, employeeId int, start, end time.Time) ([]st.Unavailability, error) {
+	args := m.Called(ctx, employeeId, start, end)
+	unavailabilities, _ := args.Get(0).([]st.Unavailability)
+	return unavailabilities, args.Error(1)
+}
+
+func (m *MockAvailabilityService) AddUnavailability(ctx context.Context, u st.Unavailability) (int, error) {
+	args := m.Called(ctx, u)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAvailabilityService) DeleteUnavailability(ctx context.Context, employeeId, id int) error {
+	args := m.Called(ctx, employeeId, id)
+	return args.Error(0)
+}
+
+func (m *MockAvailabilityService) CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error) {
+	args := m.Called(ctx, employeeId, start, end)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestSetAvailability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"timezone":"Europe/Paris","windows":[{"weekday":1,"startTime":"09:00","endTime":"17:00"}]}`,
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "availability updated successfully",
+		},
+		{
+			name:           "weekday out of range",
+			body:           `{"timezone":"Europe/Paris","windows":[{"weekday":7,"startTime":"09:00","endTime":"17:00"}]}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time of day",
+			body:           `{"timezone":"Europe/Paris","windows":[{"weekday":1,"startTime":"09:00","endTime":"17:00"}]}`,
+			mockErr:        availability.ErrInvalidTimeOfDay,
+			callService:    true,
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   availability.ErrInvalidTimeOfDay.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockAvailabilityService)
+			if tc.callService {
+				mockSvc.On("SetAvailability", mock.Anything, 4, []st.AvailabilityWindow{
+					{EmployeeID: 4, Weekday: 1, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "Europe/Paris"},
+				}).Return(tc.mockErr)
+			}
+			e := &Employee{availability: mockSvc}
+
+			router := gin.New()
+			router.PUT("/availability", withIdentity(employeeIdentity), e.setAvailability)
+
+			req := httptest.NewRequest(http.MethodPut, "/availability", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateUnavailability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+	mockSvc := new(MockAvailabilityService)
+	mockSvc.On("AddUnavailability", mock.Anything, st.Unavailability{
+		EmployeeID: 4, StartTime: start, EndTime: start.Add(2 * time.Hour), Reason: "dentist",
+	}).Return(7, nil)
+	e := &Employee{availability: mockSvc}
+
+	router := gin.New()
+	router.POST("/unavailabilities", withIdentity(employeeIdentity), e.createUnavailability)
+
+	body := `{"start":"2025-05-15T09:00:00Z","end":"2025-05-15T11:00:00Z","reason":"dentist"}`
+	req := httptest.NewRequest(http.MethodPost, "/unavailabilities", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":7`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestDeleteUnavailability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockAvailabilityService)
+	mockSvc.On("DeleteUnavailability", mock.Anything, 4, 8).Return(availability.ErrUnavailabilityNotFound)
+	e := &Employee{availability: mockSvc}
+
+	router := gin.New()
+	router.DELETE("/unavailabilities/:id", withIdentity(employeeIdentity), e.deleteUnavailability)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/unavailabilities/8", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,50 @@
+package employee
+
+import (
+	"net/http"
+	"payd/services/calendar"
+	"payd/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createCalendarFeed issues the feed of the caller's approved shifts, replacing the previous one.
+// the token is only returned in this response, it's stored hashed
+func (e *Employee) createCalendarFeed(c *gin.Context) {
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	token, err := e.calendar.CreateEmployeeFeed(c.Request.Context(), employeeId)
+	if err != nil {
+		calendarError(c, err, "create calendar feed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "calendar feed created successfully, it won't be shown again",
+		"token":   token,
+		"path":    "/calendar/" + token + ".ics",
+	})
+}
+
+func (e *Employee) revokeCalendarFeed(c *gin.Context) {
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	if err := e.calendar.RevokeEmployeeFeed(c.Request.Context(), employeeId); err != nil {
+		calendarError(c, err, "revoke calendar feed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "calendar feed revoked successfully"})
+}
+
+func calendarError(c *gin.Context, err error, msg string) {
+	switch err {
+	case calendar.ErrFeedNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
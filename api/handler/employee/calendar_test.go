@@ -0,0 +1,97 @@
+package employee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/calendar"
+	st "payd/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCalendarService struct {
+	mock.Mock
+}
+
+func (m *MockCalendarService) CreateEmployeeFeed(ctx context.Context, employeeId int) (string, error) {
+	args := m.Called(ctx, employeeId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeEmployeeFeed(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) CreateRoleFeed(ctx context.Context, createdBy, roleId int, locationIds []int) (int, string, error) {
+	args := m.Called(ctx, createdBy, roleId, locationIds)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockCalendarService) ListRoleFeeds(ctx context.Context) ([]st.CalendarFeed, error) {
+	args := m.Called(ctx)
+	feeds, _ := args.Get(0).([]st.CalendarFeed)
+	return feeds, args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeRoleFeed(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	args := m.Called(ctx, token)
+	ics, _ := args.Get(0).([]byte)
+	return ics, args.Error(1)
+}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockCalendarService)
+	mockSvc.On("CreateEmployeeFeed", mock.Anything, 4).Return("cal_abc", nil)
+	e := &Employee{calendar: mockSvc}
+	router := gin.New()
+	router.POST("/calendar-feed", withIdentity(employeeIdentity), e.createCalendarFeed)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/calendar-feed", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"token":"cal_abc"`)
+	assert.Contains(t, w.Body.String(), `"path":"/calendar/cal_abc.ics"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRevokeCalendarFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		mockErr        error
+		wantStatusCode int
+	}{
+		{name: "revoked", wantStatusCode: http.StatusOK},
+		{name: "no feed", mockErr: calendar.ErrFeedNotFound, wantStatusCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockCalendarService)
+			mockSvc.On("RevokeEmployeeFeed", mock.Anything, 4).Return(tc.mockErr)
+			e := &Employee{calendar: mockSvc}
+			router := gin.New()
+			router.DELETE("/calendar-feed", withIdentity(employeeIdentity), e.revokeCalendarFeed)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/calendar-feed", nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
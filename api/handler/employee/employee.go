@@ -0,0 +1,162 @@
+package employee
+
+import (
+	"net/http"
+	"payd/middleware"
+	"payd/services/auth"
+	"payd/services/availability"
+	"payd/services/calendar"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/shiftrequest"
+	"payd/services/shiftswap"
+	"payd/services/timesheet"
+	"payd/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Employee struct {
+	auth         auth.AuthInterface
+	availability availability.AvailabilityInterface
+	calendar     calendar.CalendarInterface
+	leave        leave.LeaveInterface
+	location     location.LocationInterface
+	shiftRequest shiftrequest.ShiftRequestInterface
+	shiftSwap    shiftswap.ShiftSwapInterface
+	timesheet    timesheet.TimesheetInterface
+	validator    *validator.Validate
+}
+
+type Option func(*Employee) error
+
+func NewEmployeeHandler(router *gin.RouterGroup, opts ...Option) error {
+	employee := &Employee{}
+	for _, opt := range opts {
+		if err := opt(employee); err != nil {
+			return err
+		}
+	}
+	router.Use(middleware.JWTAuthorizeRoles(employee.auth, "employee"))
+	router.GET("/shifts", employee.listAvailableShifts)
+	router.GET("/shift-requests", employee.listShiftRequests)
+	router.POST("/shift-requests", employee.createShiftRequest)
+	router.GET("/shift-swaps", employee.listShiftSwaps)
+	router.GET("/shift-swaps/open", employee.listOpenShiftSwaps)
+	router.POST("/shift-swaps", employee.createShiftSwap)
+	router.POST("/shift-swaps/:id/claim", employee.claimShiftSwap)
+	router.POST("/shift-swaps/:id/cancel", employee.cancelShiftSwap)
+	router.GET("/availability", employee.getAvailability)
+	router.PUT("/availability", employee.setAvailability)
+	router.GET("/unavailabilities", employee.listUnavailabilities)
+	router.POST("/unavailabilities", employee.createUnavailability)
+	router.DELETE("/unavailabilities/:id", employee.deleteUnavailability)
+	router.GET("/leave-types", employee.listLeaveTypes)
+	router.GET("/leave-balances", employee.listLeaveBalances)
+	router.GET("/leave-requests", employee.listLeaveRequests)
+	router.POST("/leave-requests", employee.createLeaveRequest)
+	router.POST("/leave-requests/:id/withdraw", employee.withdrawLeaveRequest)
+	router.GET("/timesheet", employee.listTimesheet)
+	router.POST("/timesheet/clock-in", employee.clockIn)
+	router.POST("/timesheet/clock-out", employee.clockOut)
+	router.POST("/timesheet/breaks/start", employee.startBreak)
+	router.POST("/timesheet/breaks/end", employee.endBreak)
+	router.POST("/calendar-feed", employee.createCalendarFeed)
+	router.DELETE("/calendar-feed", employee.revokeCalendarFeed)
+
+	return nil
+}
+
+// currentEmployee returns the caller identity and its db employee id,
+// writes the error response and returns false if there is none
+func currentEmployee(c *gin.Context) (*auth.Identity, int, bool) {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing identity"})
+		return nil, 0, false
+	}
+	employeeId, ok := middleware.GetEmployeeID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "caller is not linked to an employee"})
+		return nil, 0, false
+	}
+	return identity, employeeId, true
+}
+
+// timezones returns the timezone of every location to render the times of the responses in,
+// the times are rendered in UTC if they can't be loaded
+func (e *Employee) timezones(c *gin.Context) location.Timezones {
+	if e.location == nil {
+		return nil
+	}
+	zones, err := e.location.Timezones(c.Request.Context())
+	if err != nil {
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("load location timezones")
+		return nil
+	}
+	return zones
+}
+
+func WithShiftRequestSvc(shiftRequest shiftrequest.ShiftRequestInterface) Option {
+	return func(s *Employee) error {
+		s.shiftRequest = shiftRequest
+		return nil
+	}
+}
+
+func WithShiftSwapSvc(shiftSwap shiftswap.ShiftSwapInterface) Option {
+	return func(s *Employee) error {
+		s.shiftSwap = shiftSwap
+		return nil
+	}
+}
+
+func WithAvailabilitySvc(availability availability.AvailabilityInterface) Option {
+	return func(s *Employee) error {
+		s.availability = availability
+		return nil
+	}
+}
+
+func WithLeaveSvc(leave leave.LeaveInterface) Option {
+	return func(s *Employee) error {
+		s.leave = leave
+		return nil
+	}
+}
+
+func WithTimesheetSvc(timesheet timesheet.TimesheetInterface) Option {
+	return func(s *Employee) error {
+		s.timesheet = timesheet
+		return nil
+	}
+}
+
+func WithLocationSvc(location location.LocationInterface) Option {
+	return func(s *Employee) error {
+		s.location = location
+		return nil
+	}
+}
+
+func WithCalendarSvc(calendar calendar.CalendarInterface) Option {
+	return func(s *Employee) error {
+		s.calendar = calendar
+		return nil
+	}
+}
+
+func WithAuthSvc(auth auth.AuthInterface) Option {
+	return func(s *Employee) error {
+		s.auth = auth
+		return nil
+	}
+}
+
+func WithValidator(validator *validator.Validate) Option {
+	return func(s *Employee) error {
+		s.validator = validator
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package employee
+
+import (
+	"net/http"
+	"payd/services/leave"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+type LeaveTypeResponse struct {
+	ID             int     `json:"id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	TracksBalance  bool    `json:"tracksBalance"`
+	MonthlyAccrual float64 `json:"monthlyAccrual"`
+}
+
+type LeaveBalanceResponse struct {
+	LeaveTypeID   int     `json:"leaveTypeId"`
+	LeaveTypeCode string  `json:"leaveTypeCode"`
+	Days          float64 `json:"days"`
+}
+
+type CreateLeaveRequestRequest struct {
+	LeaveTypeID int    `json:"leaveTypeId" binding:"required"`
+	StartDate   string `json:"startDate" binding:"required,datetime=2006-01-02"`
+	EndDate     string `json:"endDate" binding:"required,datetime=2006-01-02"` // inclusive
+	Reason      string `json:"reason"`
+}
+
+type ListLeaveRequestsQuery struct {
+	Start  string `form:"start" binding:"required,datetime=2006-01-02"`
+	End    string `form:"end" binding:"required,datetime=2006-01-02"`
+	Status string `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED WITHDRAWN"`
+}
+
+type LeaveRequestResponse struct {
+	ID            int        `json:"id"`
+	LeaveTypeID   int        `json:"leaveTypeId"`
+	LeaveTypeCode string     `json:"leaveTypeCode"`
+	StartDate     string     `json:"startDate"`
+	EndDate       string     `json:"endDate"`
+	Days          float64    `json:"days"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	RequestedAt   time.Time  `json:"requestedAt"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+}
+
+func (e *Employee) listLeaveTypes(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	types, err := e.leave.ListLeaveTypes(ctx)
+	if err != nil {
+		log.WithError(err).Error("list leave types")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LeaveTypeResponse, 0, len(types))
+	for _, t := range types {
+		res = append(res, LeaveTypeResponse{
+			ID:             t.ID,
+			Code:           t.Code,
+			Name:           t.Name,
+			TracksBalance:  t.TracksBalance,
+			MonthlyAccrual: t.MonthlyAccrual,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// the caller's balance for every leave type tracking one
+func (e *Employee) listLeaveBalances(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	balances, err := e.leave.ListBalances(ctx, employeeId)
+	if err != nil {
+		log.WithError(err).Error("list leave balances")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LeaveBalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		res = append(res, LeaveBalanceResponse{
+			LeaveTypeID:   b.LeaveTypeID,
+			LeaveTypeCode: b.LeaveTypeCode,
+			Days:          b.Days,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// the caller's own leave requests overlapping the dates
+func (e *Employee) listLeaveRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req ListLeaveRequestsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, _ := time.Parse(dateLayout, req.Start)
+	end, _ := time.Parse(dateLayout, req.End)
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must not be after end"})
+		return
+	}
+
+	requests, err := e.leave.ListLeaveRequests(ctx, st.ListLeaveRequestFilter{
+		EmployeeID: employeeId,
+		Status:     req.Status,
+	}, start, end)
+	if err != nil {
+		log.WithError(err).Error("list leave requests")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	res := make([]LeaveRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		res = append(res, LeaveRequestResponse{
+			ID:            r.ID,
+			LeaveTypeID:   r.LeaveTypeID,
+			LeaveTypeCode: r.LeaveTypeCode,
+			StartDate:     r.StartDate.Format(dateLayout),
+			EndDate:       r.EndDate.Format(dateLayout),
+			Days:          r.Days,
+			Reason:        r.Reason,
+			Status:        r.Status,
+			RequestedAt:   r.RequestedAt,
+			ReviewedAt:    r.ReviewedAt,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) createLeaveRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req CreateLeaveRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, _ := time.Parse(dateLayout, req.StartDate)
+	end, _ := time.Parse(dateLayout, req.EndDate)
+
+	id, err := e.leave.RequestLeave(ctx, st.LeaveRequest{
+		EmployeeID:  employeeId,
+		LeaveTypeID: req.LeaveTypeID,
+		StartDate:   start,
+		EndDate:     end,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		leaveError(c, err, "create leave request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "leave requested successfully",
+		"id":      id,
+	})
+}
+
+func (e *Employee) withdrawLeaveRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid leave request id"})
+		return
+	}
+	if err := e.leave.WithdrawLeaveRequest(ctx, employeeId, id); err != nil {
+		leaveError(c, err, "withdraw leave request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "leave request withdrawn successfully",
+		"id":      id,
+	})
+}
+
+func leaveError(c *gin.Context, err error, msg string) {
+	switch err {
+	case leave.ErrLeaveTypeNotFound, leave.ErrInvalidPeriod, leave.ErrInvalidReason:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case leave.ErrInsufficientBalance, leave.ErrOverlappingLeave, leave.ErrRequestNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
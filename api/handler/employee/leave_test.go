@@ -0,0 +1,169 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/leave"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLeaveService struct {
+	mock.Mock
+}
+
+func (m *MockLeaveService) ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error) {
+	args := m.Called(ctx)
+	types, _ := args.Get(0).([]st.LeaveType)
+	return types, args.Error(1)
+}
+
+func (m *MockLeaveService) RequestLeave(ctx context.Context, r st.LeaveRequest) (int, error) {
+	args := m.Called(ctx, r)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLeaveService) WithdrawLeaveRequest(ctx context.Context, employeeId, id int) error {
+	args := m.Called(ctx, employeeId, id)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) ListLeaveRequests(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error) {
+	args := m.Called(ctx, filter, start, end)
+	requests, _ := args.Get(0).([]st.LeaveRequestWithDetails)
+	return requests, args.Error(1)
+}
+
+func (m *MockLeaveService) ListBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error) {
+	args := m.Called(ctx, employeeId)
+	balances, _ := args.Get(0).([]st.LeaveBalance)
+	return balances, args.Error(1)
+}
+
+func (m *MockLeaveService) AdjustBalance(ctx context.Context, entry st.LeaveBalanceEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) ApproveLeaveRequest(ctx context.Context, id int, reviewer leave.Reviewer) ([]int, error) {
+	args := m.Called(ctx, id, reviewer)
+	shiftIds, _ := args.Get(0).([]int)
+	return shiftIds, args.Error(1)
+}
+
+func (m *MockLeaveService) RejectLeaveRequest(ctx context.Context, id int, reviewer leave.Reviewer) error {
+	args := m.Called(ctx, id, reviewer)
+	return args.Error(0)
+}
+
+func (m *MockLeaveService) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	args := m.Called(ctx, employeeId, locationId, start, end)
+	return args.Error(0)
+}
+
+func TestCreateLeaveRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"leaveTypeId":1,"startDate":"2025-06-02","endDate":"2025-06-04","reason":"holiday"}`,
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":5`,
+		},
+		{
+			name:           "invalid date",
+			body:           `{"leaveTypeId":1,"startDate":"2025-06-02T00:00:00Z","endDate":"2025-06-04"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "insufficient balance",
+			body:           `{"leaveTypeId":1,"startDate":"2025-06-02","endDate":"2025-06-04","reason":"holiday"}`,
+			mockErr:        leave.ErrInsufficientBalance,
+			callService:    true,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   leave.ErrInsufficientBalance.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockLeaveService)
+			if tc.callService {
+				mockSvc.On("RequestLeave", mock.Anything, st.LeaveRequest{
+					EmployeeID: 4, LeaveTypeID: 1, StartDate: start, EndDate: start.AddDate(0, 0, 2), Reason: "holiday",
+				}).Return(5, tc.mockErr)
+			}
+			e := &Employee{leave: mockSvc}
+
+			router := gin.New()
+			router.POST("/leave-requests", withIdentity(employeeIdentity), e.createLeaveRequest)
+
+			req := httptest.NewRequest(http.MethodPost, "/leave-requests", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListLeaveRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc := new(MockLeaveService)
+	mockSvc.On("ListLeaveRequests", mock.Anything, st.ListLeaveRequestFilter{EmployeeID: 4}, start, start.AddDate(0, 0, 29)).
+		Return([]st.LeaveRequestWithDetails{{
+			LeaveRequest:  st.LeaveRequest{ID: 5, StartDate: start, EndDate: start.AddDate(0, 0, 2), Days: 3, Status: "PENDING"},
+			LeaveTypeCode: "ANNUAL",
+		}}, nil)
+	e := &Employee{leave: mockSvc}
+
+	router := gin.New()
+	router.GET("/leave-requests", withIdentity(employeeIdentity), e.listLeaveRequests)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leave-requests?start=2025-06-01&end=2025-06-30", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"startDate":"2025-06-01","endDate":"2025-06-03"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestWithdrawLeaveRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockLeaveService)
+	mockSvc.On("WithdrawLeaveRequest", mock.Anything, 4, 5).Return(leave.ErrRequestNotPending)
+	e := &Employee{leave: mockSvc}
+
+	router := gin.New()
+	router.POST("/leave-requests/:id/withdraw", withIdentity(employeeIdentity), e.withdrawLeaveRequest)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/leave-requests/5/withdraw", nil))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,209 @@
+package employee
+
+import (
+	"errors"
+	"net/http"
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/shift"
+	"payd/services/shiftrequest"
+	"payd/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimeRangeQuery struct {
+	Start time.Time `form:"start" binding:"required"`
+	End   time.Time `form:"end" binding:"required"`
+}
+
+type ListShiftRequestsQuery struct {
+	TimeRangeQuery
+	Status string `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED WITHDRAWN"`
+}
+
+type CreateShiftRequestRequest struct {
+	ShiftID int `json:"shiftId" binding:"required"`
+}
+
+type ShiftResponse struct {
+	ID         int       `json:"id"`
+	RoleID     int       `json:"roleId"`
+	LocationID int       `json:"locationId"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+}
+
+type ShiftRequestResponse struct {
+	ID          int        `json:"id"`
+	ShiftID     int        `json:"shiftId"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty"`
+	RoleID      int        `json:"roleId"`
+	RoleName    string     `json:"roleName"`
+	StartTime   time.Time  `json:"startTime"`
+	EndTime     time.Time  `json:"endTime"`
+}
+
+// open shifts of the caller's primary role at the locations they may access
+func (e *Employee) listAvailableShifts(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	identity, _, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req TimeRangeQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	shifts, err := e.shiftRequest.GetAvailableShifts(ctx, identity.PrimaryRole, identity.LocationIDs, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list available shifts")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := e.timezones(c)
+	res := make([]ShiftResponse, 0, len(shifts))
+	for _, shift := range shifts {
+		res = append(res, ShiftResponse{
+			ID:         shift.ID,
+			RoleID:     shift.RoleID,
+			LocationID: shift.LocationID,
+			StartTime:  zones.In(shift.LocationID, shift.StartTime),
+			EndTime:    zones.In(shift.LocationID, shift.EndTime),
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) createShiftRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	identity, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req CreateShiftRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, warnings, err := e.shiftRequest.RequestShift(ctx, employeeId, identity.PrimaryRole, identity.LocationIDs, req.ShiftID)
+	if err != nil {
+		if assignmentConflict(c, err) {
+			return
+		}
+		switch err {
+		case shiftrequest.ErrShiftNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case shiftrequest.ErrRoleMismatch:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case shiftrequest.ErrShiftAlreadyStarted:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case shiftrequest.ErrAlreadyRequested:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("create shift request")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	res := gin.H{
+		"message": "shift requested successfully",
+		"id":      id,
+	}
+	if len(warnings) > 0 {
+		res["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// the caller's own shift requests
+func (e *Employee) listShiftRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req ListShiftRequestsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	requests, err := e.shiftRequest.ListEmployeeShiftRequests(ctx, employeeId, req.Status, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list shift requests")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := e.timezones(c)
+	res := make([]ShiftRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		res = append(res, ShiftRequestResponse{
+			ID:          r.ID,
+			ShiftID:     r.ShiftID,
+			Status:      r.Status,
+			RequestedAt: zones.In(r.LocationID, r.RequestedAt),
+			ReviewedAt:  zones.InPtr(r.LocationID, r.ReviewedAt),
+			RoleID:      r.RoleID,
+			RoleName:    r.RoleName,
+			StartTime:   zones.In(r.LocationID, r.StartTime),
+			EndTime:     zones.In(r.LocationID, r.EndTime),
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// assignmentConflict writes the 409 response of the errors refusing to assign an employee to a shift:
+// a double-booking, approved leave or a declared unavailability. returns false for any other error
+func assignmentConflict(c *gin.Context, err error) bool {
+	var conflict *shift.ConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              err.Error(),
+			"shiftId":            conflict.ShiftID,
+			"conflictingShiftId": conflict.ConflictingShiftID,
+		})
+		return true
+	}
+	var onLeave *leave.OnLeaveError
+	if errors.As(err, &onLeave) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          err.Error(),
+			"leaveRequestId": onLeave.LeaveRequestID,
+		})
+		return true
+	}
+	var unavailable *availability.UnavailableError
+	if errors.As(err, &unavailable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            err.Error(),
+			"unavailabilityId": unavailable.Unavailability.ID,
+			"reason":           unavailable.Unavailability.Reason,
+		})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payd/middleware"
+	"payd/services/auth"
+	"payd/services/availability"
+	"payd/services/shift"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShiftRequestService struct {
+	mock.Mock
+}
+
+func (m *MockShiftRequestService) GetAvailableShifts(ctx context.Context, roleId int, locationIds []int, start, end time.Time) ([]st.Shift, error) {
+	args := m.Called(ctx, roleId, locationIds, start, end)
+	shifts, _ := args.Get(0).([]st.Shift)
+	return shifts, args.Error(1)
+}
+
+func (m *MockShiftRequestService) RequestShift(ctx context.Context, employeeId, roleId int, locationIds []int, shiftId int) (int, []string, error) {
+	args := m.Called(ctx, employeeId, roleId, locationIds, shiftId)
+	warnings, _ := args.Get(1).([]string)
+	return args.Int(0), warnings, args.Error(2)
+}
+
+func (m *MockShiftRequestService) ListEmployeeShiftRequests(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	args := m.Called(ctx, employeeId, status, start, end)
+	requests, _ := args.Get(0).([]st.ShiftRequestWithShiftDetails)
+	return requests, args.Error(1)
+}
+
+func (m *MockShiftRequestService) ListShiftRequests(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	return nil, nil
+}
+
+func (m *MockShiftRequestService) ApproveShiftRequest(ctx context.Context, requestId int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockShiftRequestService) ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockShiftRequestService) RejectShiftRequest(ctx context.Context, requestId int, reviewer shiftrequest.Reviewer) error {
+	return nil
+}
+
+// withIdentity mimics middleware.JWTAuthorizeRoles for the given identity
+func withIdentity(identity *auth.Identity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.IdentityKey, identity)
+		c.Next()
+	}
+}
+
+var employeeIdentity = &auth.Identity{EmployeeId: "4", Role: "employee", PrimaryRole: 2, LocationID: 1, LocationIDs: []int{1}}
+
+func TestListAvailableShifts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	query := "?start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z"
+
+	tests := []struct {
+		name           string
+		query          string
+		mockShifts     []st.Shift
+		mockErr        error
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:  "success",
+			query: query,
+			mockShifts: []st.Shift{
+				{ID: 1, RoleID: 2, StartTime: start.Add(9 * time.Hour), EndTime: start.Add(17 * time.Hour)},
+			},
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":1`,
+		},
+		{
+			name:           "missing time range",
+			query:          "",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "error",
+		},
+		{
+			name:           "start after end",
+			query:          "?start=2025-05-16T00:00:00Z&end=2025-05-15T00:00:00Z",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "start must be before end",
+		},
+		{
+			name:           "internal error",
+			query:          query,
+			mockErr:        assert.AnError,
+			callService:    true,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftRequestService)
+			if tc.callService {
+				mockSvc.On("GetAvailableShifts", mock.Anything, 2, []int{1},
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(start) }),
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(end) })).
+					Return(tc.mockShifts, tc.mockErr)
+			}
+			e := &Employee{shiftRequest: mockSvc}
+
+			router := gin.New()
+			router.GET("/shifts", withIdentity(employeeIdentity), e.listAvailableShifts)
+
+			req := httptest.NewRequest(http.MethodGet, "/shifts"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateShiftRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		identity       *auth.Identity
+		body           interface{}
+		mockReturnID   int
+		mockWarnings   []string
+		mockErr        error
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockReturnID:   11,
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "shift requested successfully",
+		},
+		{
+			name:           "missing shift id",
+			identity:       employeeIdentity,
+			body:           map[string]interface{}{},
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "CreateShiftRequestRequest.ShiftID",
+		},
+		{
+			name:           "identity without employee",
+			identity:       &auth.Identity{Role: "employee", PrimaryRole: 2},
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "not linked to an employee",
+		},
+		{
+			name:           "shift not found",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        shiftrequest.ErrShiftNotFound,
+			callService:    true,
+			wantStatusCode: http.StatusNotFound,
+			wantRespBody:   shiftrequest.ErrShiftNotFound.Error(),
+		},
+		{
+			name:           "role mismatch",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        shiftrequest.ErrRoleMismatch,
+			callService:    true,
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   shiftrequest.ErrRoleMismatch.Error(),
+		},
+		{
+			name:           "already requested",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        shiftrequest.ErrAlreadyRequested,
+			callService:    true,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shiftrequest.ErrAlreadyRequested.Error(),
+		},
+		{
+			name:           "double-booked employee",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        &shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: 4, ShiftID: 3, ConflictingShiftID: 8},
+			callService:    true,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"conflictingShiftId":8`,
+		},
+		{
+			name:           "employee declared to be unavailable",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        &availability.UnavailableError{Unavailability: st.Unavailability{ID: 2, Reason: "dentist"}},
+			callService:    true,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"reason":"dentist"`,
+		},
+		{
+			name:           "shift outside the weekly availability",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockReturnID:   11,
+			mockWarnings:   []string{availability.ErrOutsideAvailability.Error()},
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"warnings":["shift is outside the employee's declared availability"]`,
+		},
+		{
+			name:           "internal error",
+			identity:       employeeIdentity,
+			body:           CreateShiftRequestRequest{ShiftID: 3},
+			mockErr:        assert.AnError,
+			callService:    true,
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftRequestService)
+			if tc.callService {
+				mockSvc.On("RequestShift", mock.Anything, 4, 2, []int{1}, 3).Return(tc.mockReturnID, tc.mockWarnings, tc.mockErr)
+			}
+			e := &Employee{shiftRequest: mockSvc}
+
+			router := gin.New()
+			router.POST("/shift-requests", withIdentity(tc.identity), e.createShiftRequest)
+
+			bodyJSON, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/shift-requests", bytes.NewReader(bodyJSON))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListShiftRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tests := []struct {
+		name           string
+		query          string
+		status         string
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "all statuses",
+			query:          "?start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z",
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"status":"PENDING"`,
+		},
+		{
+			name:           "filter by status",
+			query:          "?start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z&status=PENDING",
+			status:         "PENDING",
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"roleName":"Cook"`,
+		},
+		{
+			name:           "invalid status",
+			query:          "?start=2025-05-15T00:00:00Z&end=2025-05-16T00:00:00Z&status=UNKNOWN",
+			wantStatusCode: http.StatusBadRequest,
+			wantRespBody:   "Status",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftRequestService)
+			if tc.callService {
+				mockSvc.On("ListEmployeeShiftRequests", mock.Anything, 4, tc.status,
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(start) }),
+					mock.MatchedBy(func(t time.Time) bool { return t.Equal(end) })).
+					Return([]st.ShiftRequestWithShiftDetails{
+						{ID: 1, EmployeeID: 4, ShiftID: 3, Status: "PENDING", RoleID: 2, RoleName: "Cook"},
+					}, nil)
+			}
+			e := &Employee{shiftRequest: mockSvc}
+
+			router := gin.New()
+			router.GET("/shift-requests", withIdentity(employeeIdentity), e.listShiftRequests)
+
+			req := httptest.NewRequest(http.MethodGet, "/shift-requests"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
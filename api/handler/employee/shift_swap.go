@@ -0,0 +1,224 @@
+package employee
+
+import (
+	"net/http"
+	"payd/services/location"
+	"payd/services/shiftswap"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListShiftSwapsQuery struct {
+	TimeRangeQuery
+	Status string `form:"status" binding:"omitempty,oneof=OPEN CLAIMED APPROVED REJECTED CANCELLED"`
+}
+
+type CreateShiftSwapRequest struct {
+	ShiftRequestID int `json:"shiftRequestId" binding:"required"`
+}
+
+type ClaimShiftSwapRequest struct {
+	// the caller's approved shift request given in exchange, omitted for a give-away
+	SwapShiftRequestID *int `json:"swapShiftRequestId"`
+}
+
+type ShiftSwapResponse struct {
+	ID                 int        `json:"id"`
+	ShiftRequestID     int        `json:"shiftRequestId"`
+	ShiftID            int        `json:"shiftId"`
+	RoleID             int        `json:"roleId"`
+	LocationID         int        `json:"locationId"`
+	StartTime          time.Time  `json:"startTime"`
+	EndTime            time.Time  `json:"endTime"`
+	OfferedBy          int        `json:"offeredBy"`
+	OfferedByName      string     `json:"offeredByName"`
+	Status             string     `json:"status"`
+	ClaimedBy          *int       `json:"claimedBy,omitempty"`
+	SwapShiftRequestID *int       `json:"swapShiftRequestId,omitempty"`
+	SwapShiftID        *int       `json:"swapShiftId,omitempty"`
+	SwapStartTime      *time.Time `json:"swapStartTime,omitempty"`
+	SwapEndTime        *time.Time `json:"swapEndTime,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// newShiftSwapResponse renders the times of the swap in the timezone of the location of the shift
+func newShiftSwapResponse(sw st.ShiftSwapWithShiftDetails, zones location.Timezones) ShiftSwapResponse {
+	return ShiftSwapResponse{
+		ID:                 sw.ID,
+		ShiftRequestID:     sw.ShiftRequestID,
+		ShiftID:            sw.ShiftID,
+		RoleID:             sw.RoleID,
+		LocationID:         sw.LocationID,
+		StartTime:          zones.In(sw.LocationID, sw.StartTime),
+		EndTime:            zones.In(sw.LocationID, sw.EndTime),
+		OfferedBy:          sw.OfferedBy,
+		OfferedByName:      sw.OfferedByName,
+		Status:             sw.Status,
+		ClaimedBy:          sw.ClaimedBy,
+		SwapShiftRequestID: sw.SwapShiftRequestID,
+		SwapShiftID:        sw.SwapShiftID,
+		SwapStartTime:      zones.InPtr(sw.LocationID, sw.SwapStartTime),
+		SwapEndTime:        zones.InPtr(sw.LocationID, sw.SwapEndTime),
+		CreatedAt:          zones.In(sw.LocationID, sw.CreatedAt),
+	}
+}
+
+// shifts offered by colleagues of the caller's primary role at the locations they may access
+func (e *Employee) listOpenShiftSwaps(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	identity, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	swaps, err := e.shiftSwap.ListOpenSwaps(ctx, employeeId, identity.PrimaryRole, identity.LocationIDs)
+	if err != nil {
+		log.WithError(err).Error("list open shift swaps")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := e.timezones(c)
+	res := make([]ShiftSwapResponse, 0, len(swaps))
+	for _, sw := range swaps {
+		res = append(res, newShiftSwapResponse(sw, zones))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// the swaps the caller offered or claimed
+func (e *Employee) listShiftSwaps(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req ListShiftSwapsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Start.Before(req.End) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start must be before end"})
+		return
+	}
+
+	swaps, err := e.shiftSwap.ListEmployeeSwaps(ctx, employeeId, req.Status, req.Start, req.End)
+	if err != nil {
+		log.WithError(err).Error("list shift swaps")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	zones := e.timezones(c)
+	res := make([]ShiftSwapResponse, 0, len(swaps))
+	for _, sw := range swaps {
+		res = append(res, newShiftSwapResponse(sw, zones))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) createShiftSwap(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req CreateShiftSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := e.shiftSwap.OfferShift(ctx, employeeId, req.ShiftRequestID)
+	if err != nil {
+		shiftSwapError(c, err, "create shift swap")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "shift offered successfully",
+		"id":      id,
+	})
+}
+
+func (e *Employee) claimShiftSwap(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	identity, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift swap id"})
+		return
+	}
+	var req ClaimShiftSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warnings, err := e.shiftSwap.ClaimSwap(ctx, employeeId, identity.PrimaryRole, identity.LocationIDs, id, req.SwapShiftRequestID)
+	if err != nil {
+		shiftSwapError(c, err, "claim shift swap")
+		return
+	}
+	res := gin.H{
+		"message": "shift swap claimed successfully",
+		"id":      id,
+	}
+	if len(warnings) > 0 {
+		res["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) cancelShiftSwap(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift swap id"})
+		return
+	}
+	if err := e.shiftSwap.CancelSwap(ctx, employeeId, id); err != nil {
+		shiftSwapError(c, err, "cancel shift swap")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "shift swap cancelled successfully",
+		"id":      id,
+	})
+}
+
+func shiftSwapError(c *gin.Context, err error, msg string) {
+	if assignmentConflict(c, err) {
+		return
+	}
+	switch err {
+	case shiftswap.ErrRequestNotFound, shiftswap.ErrSwapNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case shiftswap.ErrRoleMismatch, shiftswap.ErrOwnSwap:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case shiftswap.ErrRequestNotApproved, shiftswap.ErrShiftAlreadyStarted, shiftswap.ErrInvalidExchange:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case shiftswap.ErrAlreadyOffered, shiftswap.ErrSwapNotOpen, shiftswap.ErrSwapNotActive:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
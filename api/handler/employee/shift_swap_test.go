@@ -0,0 +1,188 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/leave"
+	"payd/services/shiftswap"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShiftSwapService struct {
+	mock.Mock
+}
+
+func (m *MockShiftSwapService) OfferShift(ctx context.Context, employeeId, shiftRequestId int) (int, error) {
+	args := m.Called(ctx, employeeId, shiftRequestId)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShiftSwapService) ListOpenSwaps(ctx context.Context, employeeId, roleId int, locationIds []int) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, employeeId, roleId, locationIds)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ListEmployeeSwaps(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, employeeId, status, start, end)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ClaimSwap(ctx context.Context, employeeId, roleId int, locationIds []int, id int, swapShiftRequestId *int) ([]string, error) {
+	args := m.Called(ctx, employeeId, roleId, locationIds, id, swapShiftRequestId)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftSwapService) CancelSwap(ctx context.Context, employeeId, id int) error {
+	args := m.Called(ctx, employeeId, id)
+	return args.Error(0)
+}
+
+func (m *MockShiftSwapService) ListSwaps(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	args := m.Called(ctx, filter, start, end)
+	swaps, _ := args.Get(0).([]st.ShiftSwapWithShiftDetails)
+	return swaps, args.Error(1)
+}
+
+func (m *MockShiftSwapService) ApproveSwap(ctx context.Context, id int, reviewer shiftswap.Reviewer) ([]string, error) {
+	args := m.Called(ctx, id, reviewer)
+	warnings, _ := args.Get(0).([]string)
+	return warnings, args.Error(1)
+}
+
+func (m *MockShiftSwapService) RejectSwap(ctx context.Context, id int, reviewer shiftswap.Reviewer) error {
+	args := m.Called(ctx, id, reviewer)
+	return args.Error(0)
+}
+
+func TestClaimShiftSwap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	exchange := 11
+
+	tests := []struct {
+		name           string
+		body           string
+		swapRequestId  *int
+		mockWarnings   []string
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "give-away",
+			body:           `{}`,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "shift swap claimed successfully",
+		},
+		{
+			name:           "exchange",
+			body:           `{"swapShiftRequestId":11}`,
+			swapRequestId:  &exchange,
+			mockWarnings:   []string{"shift is outside the employee's declared availability"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"warnings":["shift is outside the employee's declared availability"]`,
+		},
+		{
+			name:           "not open",
+			body:           `{}`,
+			mockErr:        shiftswap.ErrSwapNotOpen,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   shiftswap.ErrSwapNotOpen.Error(),
+		},
+		{
+			name:           "own offer",
+			body:           `{}`,
+			mockErr:        shiftswap.ErrOwnSwap,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "on leave",
+			body:           `{}`,
+			mockErr:        &leave.OnLeaveError{LeaveRequestID: 9},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `"leaveRequestId":9`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockShiftSwapService)
+			mockSvc.On("ClaimSwap", mock.Anything, 4, 2, []int{1}, 7, tc.swapRequestId).Return(tc.mockWarnings, tc.mockErr)
+			e := &Employee{shiftSwap: mockSvc}
+
+			router := gin.New()
+			router.POST("/shift-swaps/:id/claim", withIdentity(employeeIdentity), e.claimShiftSwap)
+
+			req := httptest.NewRequest(http.MethodPost, "/shift-swaps/7/claim", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateShiftSwap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockShiftSwapService)
+	mockSvc.On("OfferShift", mock.Anything, 4, 10).Return(7, nil)
+	mockSvc.On("OfferShift", mock.Anything, 4, 11).Return(0, shiftswap.ErrAlreadyOffered)
+	e := &Employee{shiftSwap: mockSvc}
+
+	router := gin.New()
+	router.POST("/shift-swaps", withIdentity(employeeIdentity), e.createShiftSwap)
+
+	for body, want := range map[string]int{
+		`{"shiftRequestId":10}`: http.StatusOK,
+		`{"shiftRequestId":11}`: http.StatusConflict,
+		`{}`:                    http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/shift-swaps", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, body)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListOpenShiftSwaps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 5, 16, 9, 0, 0, 0, time.UTC)
+	mockSvc := new(MockShiftSwapService)
+	mockSvc.On("ListOpenSwaps", mock.Anything, 4, 2, []int{1}).Return([]st.ShiftSwapWithShiftDetails{{
+		ShiftSwap:     st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 5, Status: shiftswap.StatusOpen},
+		OfferedByName: "Bob",
+		ShiftID:       3,
+		StartTime:     start,
+		EndTime:       start.Add(8 * time.Hour),
+	}}, nil)
+	e := &Employee{shiftSwap: mockSvc}
+
+	router := gin.New()
+	router.GET("/shift-swaps/open", withIdentity(employeeIdentity), e.listOpenShiftSwaps)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shift-swaps/open", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"offeredByName":"Bob"`)
+	assert.NotContains(t, w.Body.String(), "swapShiftId")
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,149 @@
+package employee
+
+import (
+	"context"
+	"net/http"
+	"payd/services/timesheet"
+	st "payd/storage"
+	"payd/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClockInRequest struct {
+	ShiftRequestID int `json:"shiftRequestId" binding:"required"` // an approved request of the caller
+}
+
+type ListTimesheetQuery struct {
+	Start time.Time `form:"start" binding:"required"`
+	End   time.Time `form:"end" binding:"required"`
+}
+
+type AttendanceResponse struct {
+	ShiftRequestID  int        `json:"shiftRequestId"`
+	ShiftID         int        `json:"shiftId"`
+	RoleID          int        `json:"roleId"`
+	LocationID      int        `json:"locationId"`
+	StartTime       time.Time  `json:"startTime"`
+	EndTime         time.Time  `json:"endTime"`
+	Status          string     `json:"status"`
+	ClockIn         *time.Time `json:"clockIn,omitempty"`
+	ClockOut        *time.Time `json:"clockOut,omitempty"`
+	OnBreak         bool       `json:"onBreak"`
+	BreakMinutes    int        `json:"breakMinutes"`
+	LateMinutes     int        `json:"lateMinutes"`
+	WorkedMinutes   int        `json:"workedMinutes"`
+	OvertimeMinutes int        `json:"overtimeMinutes"`
+}
+
+// the attendance of the caller's approved shifts starting within [start, end]
+func (e *Employee) listTimesheet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req ListTimesheetQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendance, err := e.timesheet.ListAttendance(ctx, st.ListShiftRequestFilter{EmployeeID: employeeId}, req.Start, req.End)
+	if err != nil {
+		timesheetError(c, err, "list timesheet")
+		return
+	}
+
+	zones := e.timezones(c)
+	res := make([]AttendanceResponse, 0, len(attendance))
+	for _, a := range attendance {
+		r := AttendanceResponse{
+			ShiftRequestID:  a.Request.ID,
+			ShiftID:         a.Request.ShiftID,
+			RoleID:          a.Request.RoleID,
+			LocationID:      a.Request.LocationID,
+			StartTime:       zones.In(a.Request.LocationID, a.Request.StartTime),
+			EndTime:         zones.In(a.Request.LocationID, a.Request.EndTime),
+			Status:          a.Status,
+			LateMinutes:     int(a.Late / time.Minute),
+			WorkedMinutes:   int(a.Worked / time.Minute),
+			OvertimeMinutes: int(a.Overtime / time.Minute),
+		}
+		if a.Entry != nil {
+			r.ClockIn = zones.InPtr(a.Request.LocationID, &a.Entry.ClockIn)
+			r.ClockOut = zones.InPtr(a.Request.LocationID, a.Entry.ClockOut)
+			r.OnBreak = a.Entry.OnBreak
+			r.BreakMinutes = int(a.Entry.Breaks() / time.Minute)
+		}
+		res = append(res, r)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (e *Employee) clockIn(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	var req ClockInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := e.timesheet.ClockIn(ctx, employeeId, req.ShiftRequestID)
+	if err != nil {
+		timesheetError(c, err, "clock in")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "clocked in successfully",
+		"id":      id,
+	})
+}
+
+// clocking out ends the ongoing break too
+func (e *Employee) clockOut(c *gin.Context) {
+	e.recordTime(c, e.timesheet.ClockOut, "clocked out successfully", "clock out")
+}
+
+func (e *Employee) startBreak(c *gin.Context) {
+	e.recordTime(c, e.timesheet.StartBreak, "break started successfully", "start break")
+}
+
+func (e *Employee) endBreak(c *gin.Context) {
+	e.recordTime(c, e.timesheet.EndBreak, "break ended successfully", "end break")
+}
+
+// recordTime applies record to the time entry the caller is clocked in for
+func (e *Employee) recordTime(c *gin.Context, record func(ctx context.Context, employeeId int) error, message, op string) {
+	_, employeeId, ok := currentEmployee(c)
+	if !ok {
+		return
+	}
+	if err := record(c.Request.Context(), employeeId); err != nil {
+		timesheetError(c, err, op)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func timesheetError(c *gin.Context, err error, msg string) {
+	switch err {
+	case timesheet.ErrInvalidTimeRange, timesheet.ErrRangeTooLong:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case timesheet.ErrRequestNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case timesheet.ErrRequestNotApproved, timesheet.ErrTooEarly, timesheet.ErrShiftEnded, timesheet.ErrAlreadyRecorded,
+		timesheet.ErrAlreadyClockedIn, timesheet.ErrNotClockedIn, timesheet.ErrAlreadyOnBreak, timesheet.ErrNotOnBreak:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error(msg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,170 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/timesheet"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTimesheetService struct {
+	mock.Mock
+}
+
+func (m *MockTimesheetService) ClockIn(ctx context.Context, employeeId, shiftRequestId int) (int, error) {
+	args := m.Called(ctx, employeeId, shiftRequestId)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTimesheetService) ClockOut(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) StartBreak(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) EndBreak(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockTimesheetService) ListAttendance(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]timesheet.Attendance, error) {
+	args := m.Called(ctx, filter, start, end)
+	attendance, _ := args.Get(0).([]timesheet.Attendance)
+	return attendance, args.Error(1)
+}
+
+func (m *MockTimesheetService) Summarize(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]timesheet.Summary, error) {
+	args := m.Called(ctx, filter, start, end)
+	summaries, _ := args.Get(0).([]timesheet.Summary)
+	return summaries, args.Error(1)
+}
+
+func TestClockIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		callService    bool
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"shiftRequestId":5}`,
+			callService:    true,
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `"id":7`,
+		},
+		{
+			name:           "missing shift request",
+			body:           `{}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "too early",
+			body:           `{"shiftRequestId":5}`,
+			mockErr:        timesheet.ErrTooEarly,
+			callService:    true,
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   timesheet.ErrTooEarly.Error(),
+		},
+		{
+			name:           "request of another employee",
+			body:           `{"shiftRequestId":5}`,
+			mockErr:        timesheet.ErrRequestNotFound,
+			callService:    true,
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockTimesheetService)
+			if tc.callService {
+				mockSvc.On("ClockIn", mock.Anything, 4, 5).Return(7, tc.mockErr)
+			}
+			e := &Employee{timesheet: mockSvc}
+
+			router := gin.New()
+			router.POST("/timesheet/clock-in", withIdentity(employeeIdentity), e.clockIn)
+
+			req := httptest.NewRequest(http.MethodPost, "/timesheet/clock-in", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := new(MockTimesheetService)
+	mockSvc.On("ClockOut", mock.Anything, 4).Return(nil)
+	mockSvc.On("StartBreak", mock.Anything, 4).Return(timesheet.ErrAlreadyOnBreak)
+	mockSvc.On("EndBreak", mock.Anything, 4).Return(timesheet.ErrNotClockedIn)
+	e := &Employee{timesheet: mockSvc}
+
+	router := gin.New()
+	router.Use(withIdentity(employeeIdentity))
+	router.POST("/timesheet/clock-out", e.clockOut)
+	router.POST("/timesheet/breaks/start", e.startBreak)
+	router.POST("/timesheet/breaks/end", e.endBreak)
+
+	for path, want := range map[string]int{
+		"/timesheet/clock-out":    http.StatusOK,
+		"/timesheet/breaks/start": http.StatusConflict,
+		"/timesheet/breaks/end":   http.StatusConflict,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListTimesheet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	clockIn := start.Add(9*time.Hour + 20*time.Minute)
+	mockSvc := new(MockTimesheetService)
+	mockSvc.On("ListAttendance", mock.Anything, st.ListShiftRequestFilter{EmployeeID: 4}, start, start.AddDate(0, 0, 7)).
+		Return([]timesheet.Attendance{{
+			Request: st.ShiftRequestWithShiftDetails{ID: 5, ShiftID: 3, StartTime: start.Add(9 * time.Hour), EndTime: start.Add(17 * time.Hour)},
+			Entry:   &st.TimeEntry{ID: 7, ClockIn: clockIn},
+			Status:  timesheet.StatusClockedIn,
+			Late:    20 * time.Minute,
+		}}, nil)
+	e := &Employee{timesheet: mockSvc}
+
+	router := gin.New()
+	router.GET("/timesheet", withIdentity(employeeIdentity), e.listTimesheet)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/timesheet?start=2025-06-01T00:00:00Z&end=2025-06-08T00:00:00Z", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"CLOCKED_IN"`)
+	assert.Contains(t, w.Body.String(), `"lateMinutes":20`)
+	assert.NotContains(t, w.Body.String(), `"clockOut"`)
+	mockSvc.AssertExpectations(t)
+}
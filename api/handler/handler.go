@@ -2,10 +2,25 @@ package handler
 
 import (
 	"payd/handler/admin"
+	"payd/handler/employee"
 	"payd/handler/public"
+	"payd/middleware"
+	"payd/services/audit"
 	"payd/services/auth"
+	"payd/services/availability"
+	"payd/services/calendar"
+	employeesvc "payd/services/employee"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/payroll"
+	"payd/services/permission"
 	"payd/services/role"
+	"payd/services/roster"
 	"payd/services/shift"
+	"payd/services/shiftrequest"
+	"payd/services/shiftswap"
+	"payd/services/timesheet"
+	"payd/services/webhook"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -15,16 +30,31 @@ import (
 
 type Handler struct {
 	*gin.Engine
-	auth      auth.AuthInterface
-	validator *validator.Validate
-	role      role.RoleManagerInterface
-	shift     shift.ShiftInterface
+	audit        audit.AuditInterface
+	auth         auth.AuthInterface
+	apiToken     auth.APITokenInterface
+	availability availability.AvailabilityInterface
+	calendar     calendar.CalendarInterface
+	employee     employeesvc.EmployeeInterface
+	leave        leave.LeaveInterface
+	location     location.LocationInterface
+	payroll      payroll.PayrollInterface
+	validator    *validator.Validate
+	role         role.RoleManagerInterface
+	roster       roster.RosterInterface
+	permission   permission.ManagerInterface
+	shift        shift.ShiftInterface
+	shiftRequest shiftrequest.ShiftRequestInterface
+	shiftSwap    shiftswap.ShiftSwapInterface
+	timesheet    timesheet.TimesheetInterface
+	webhook      webhook.WebhookInterface
 }
 
 type Option func(*Handler) error
 
 func NewHandler(opts ...Option) (*Handler, error) {
 	router := gin.Default()
+	router.Use(middleware.RequestID())
 	handler := &Handler{Engine: router}
 
 	for _, opt := range opts {
@@ -34,19 +64,101 @@ func NewHandler(opts ...Option) (*Handler, error) {
 	}
 
 	if err := public.PublicHandler(router.Group("/"),
-		public.WithAuthSvc(handler.auth), public.WithValidator(handler.validator)); err != nil {
+		public.WithAuthSvc(handler.auth), public.WithCalendarSvc(handler.calendar),
+		public.WithValidator(handler.validator)); err != nil {
 		return nil, err
 	}
 	if err := admin.NewAdminHandler(router.Group("/admin"),
 		admin.WithAuthSvc(handler.auth),
+		admin.WithAuditSvc(handler.audit),
+		admin.WithAPITokenSvc(handler.apiToken),
+		admin.WithEmployeeSvc(handler.employee),
+		admin.WithLocationSvc(handler.location),
+		admin.WithLeaveSvc(handler.leave),
 		admin.WithValidator(handler.validator),
 		admin.WithShiftSvc(handler.shift),
-		admin.WithRoleManager(handler.role)); err != nil {
+		admin.WithShiftRequestSvc(handler.shiftRequest),
+		admin.WithShiftSwapSvc(handler.shiftSwap),
+		admin.WithRosterSvc(handler.roster),
+		admin.WithTimesheetSvc(handler.timesheet),
+		admin.WithPayrollSvc(handler.payroll),
+		admin.WithCalendarSvc(handler.calendar),
+		admin.WithWebhookSvc(handler.webhook),
+		admin.WithRoleManager(handler.role),
+		admin.WithPermissionManager(handler.permission)); err != nil {
+		return nil, err
+	}
+	if err := employee.NewEmployeeHandler(router.Group("/employee"),
+		employee.WithAuthSvc(handler.auth),
+		employee.WithValidator(handler.validator),
+		employee.WithAvailabilitySvc(handler.availability),
+		employee.WithLeaveSvc(handler.leave),
+		employee.WithShiftRequestSvc(handler.shiftRequest),
+		employee.WithShiftSwapSvc(handler.shiftSwap),
+		employee.WithTimesheetSvc(handler.timesheet),
+		employee.WithLocationSvc(handler.location),
+		employee.WithCalendarSvc(handler.calendar)); err != nil {
 		return nil, err
 	}
 	return handler, nil
 }
 
+func WithShiftRequestSvc(shiftRequest shiftrequest.ShiftRequestInterface) Option {
+	return func(s *Handler) error {
+		s.shiftRequest = shiftRequest
+		return nil
+	}
+}
+
+func WithShiftSwapSvc(shiftSwap shiftswap.ShiftSwapInterface) Option {
+	return func(s *Handler) error {
+		s.shiftSwap = shiftSwap
+		return nil
+	}
+}
+
+func WithRosterSvc(roster roster.RosterInterface) Option {
+	return func(s *Handler) error {
+		s.roster = roster
+		return nil
+	}
+}
+
+func WithTimesheetSvc(timesheet timesheet.TimesheetInterface) Option {
+	return func(s *Handler) error {
+		s.timesheet = timesheet
+		return nil
+	}
+}
+
+func WithPayrollSvc(payroll payroll.PayrollInterface) Option {
+	return func(s *Handler) error {
+		s.payroll = payroll
+		return nil
+	}
+}
+
+func WithAuditSvc(audit audit.AuditInterface) Option {
+	return func(s *Handler) error {
+		s.audit = audit
+		return nil
+	}
+}
+
+func WithWebhookSvc(webhook webhook.WebhookInterface) Option {
+	return func(s *Handler) error {
+		s.webhook = webhook
+		return nil
+	}
+}
+
+func WithCalendarSvc(calendar calendar.CalendarInterface) Option {
+	return func(s *Handler) error {
+		s.calendar = calendar
+		return nil
+	}
+}
+
 func WithShiftSvc(shift shift.ShiftInterface) Option {
 	return func(s *Handler) error {
 		s.shift = shift
@@ -54,12 +166,47 @@ func WithShiftSvc(shift shift.ShiftInterface) Option {
 	}
 }
 
+func WithAvailabilitySvc(availability availability.AvailabilityInterface) Option {
+	return func(s *Handler) error {
+		s.availability = availability
+		return nil
+	}
+}
+
+func WithLeaveSvc(leave leave.LeaveInterface) Option {
+	return func(s *Handler) error {
+		s.leave = leave
+		return nil
+	}
+}
+
+func WithEmployeeSvc(employee employeesvc.EmployeeInterface) Option {
+	return func(s *Handler) error {
+		s.employee = employee
+		return nil
+	}
+}
+
+func WithLocationSvc(location location.LocationInterface) Option {
+	return func(s *Handler) error {
+		s.location = location
+		return nil
+	}
+}
+
 func WithAuthSvc(auth auth.AuthInterface) Option {
 	return func(s *Handler) error {
 		s.auth = auth
 		return nil
 	}
 }
+func WithAPITokenSvc(apiToken auth.APITokenInterface) Option {
+	return func(s *Handler) error {
+		s.apiToken = apiToken
+		return nil
+	}
+}
+
 func WithRoleManager(role role.RoleManagerInterface) Option {
 	return func(s *Handler) error {
 		s.role = role
@@ -67,6 +214,13 @@ func WithRoleManager(role role.RoleManagerInterface) Option {
 	}
 }
 
+func WithPermissionManager(permission permission.ManagerInterface) Option {
+	return func(s *Handler) error {
+		s.permission = permission
+		return nil
+	}
+}
+
 func WithValidator(validator *validator.Validate) Option {
 	return func(s *Handler) error {
 		s.validator = validator
@@ -79,8 +233,8 @@ func WithAllowedOrigins(allowedOrigins []string) Option {
 		s.Use(cors.New(cors.Config{
 			AllowOrigins:     allowedOrigins,
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			ExposeHeaders:    []string{"Content-Length"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
+			ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader},
 			AllowCredentials: true,
 			MaxAge:           12 * time.Hour,
 		}))
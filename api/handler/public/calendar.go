@@ -0,0 +1,28 @@
+package public
+
+import (
+	"net/http"
+	"payd/services/calendar"
+	"payd/util"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarFeed serves the iCalendar feed of the token in the path, calendar apps subscribe to it
+// without any other credentials so the token is the only authentication
+func (p *Public) calendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	ics, err := p.calendar.Feed(c.Request.Context(), token)
+	if err == calendar.ErrFeedNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		util.Log().WithContext(c.Request.Context()).WithError(err).Error("calendar feed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
@@ -0,0 +1,93 @@
+package public
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/calendar"
+	st "payd/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCalendarService struct {
+	mock.Mock
+}
+
+func (m *MockCalendarService) CreateEmployeeFeed(ctx context.Context, employeeId int) (string, error) {
+	args := m.Called(ctx, employeeId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeEmployeeFeed(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) CreateRoleFeed(ctx context.Context, createdBy, roleId int, locationIds []int) (int, string, error) {
+	args := m.Called(ctx, createdBy, roleId, locationIds)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockCalendarService) ListRoleFeeds(ctx context.Context) ([]st.CalendarFeed, error) {
+	args := m.Called(ctx)
+	feeds, _ := args.Get(0).([]st.CalendarFeed)
+	return feeds, args.Error(1)
+}
+
+func (m *MockCalendarService) RevokeRoleFeed(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	args := m.Called(ctx, token)
+	ics, _ := args.Get(0).([]byte)
+	return ics, args.Error(1)
+}
+
+func TestCalendarFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ics := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		mockErr        error
+		wantStatusCode int
+	}{
+		{name: "feed", path: "/calendar/cal_abc.ics", token: "cal_abc", wantStatusCode: http.StatusOK},
+		{name: "without extension", path: "/calendar/cal_abc", token: "cal_abc", wantStatusCode: http.StatusOK},
+		{name: "revoked", path: "/calendar/cal_abc.ics", token: "cal_abc", mockErr: calendar.ErrFeedNotFound, wantStatusCode: http.StatusNotFound},
+		{name: "storage error", path: "/calendar/cal_abc.ics", token: "cal_abc", mockErr: errors.New("db down"), wantStatusCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockCalendarService)
+			res := ics
+			if tc.mockErr != nil {
+				res = nil
+			}
+			mockSvc.On("Feed", mock.Anything, tc.token).Return(res, tc.mockErr)
+			p := &Public{calendar: mockSvc}
+			router := gin.New()
+			router.GET("/calendar/:token", p.calendarFeed)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantStatusCode == http.StatusOK {
+				assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.Equal(t, string(ics), w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package public
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwks publishes the public keys verifying the access tokens, so other services don't need a shared secret
+func (p *Public) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, p.auth.JWKS())
+}
@@ -0,0 +1,35 @@
+package public
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwks := auth.JWKS{Keys: []auth.JWK{{Kty: "OKP", Kid: "2025-06", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "abc"}}}
+	mockAuth := new(MockAuth)
+	mockAuth.On("JWKS").Return(jwks)
+	p := &Public{auth: mockAuth}
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", p.jwks)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var got auth.JWKS
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, jwks, got)
+	assert.NotContains(t, w.Body.String(), `"n"`, "empty fields are omitted")
+	mockAuth.AssertExpectations(t)
+}
@@ -2,7 +2,6 @@ package public
 
 import (
 	"net/http"
-	"time"
 
 	"payd/services/auth"
 	"payd/util"
@@ -35,28 +34,25 @@ func (p *Public) login(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case auth.ErrNotYetActivatingAccount:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case auth.ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			log.WithError(err).Error("login")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		}
 		return
 	}
-	token, err := identity.GenerateJWT(time.Minute * 15)
+	refreshToken, err := p.auth.IssueRefreshToken(ctx, identity)
 	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("generate jwt")
+		log.WithError(err).Error("issue refresh token")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	if err := p.setSessionCookies(c, identity, refreshToken); err != nil {
+		log.WithError(err).Error("generate jwt")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
-
-	c.SetCookie(
-		p.cookie.name,     // cookie name
-		token,             // value
-		900,               // maxAge in seconds (15 minutes)
-		p.cookie.path,     // path
-		p.cookie.domain,   // domain (empty = current domain)
-		p.cookie.httpOnly, // secure (true = HTTPS only)
-		true,              // httpOnly (not accessible via JS)
-	)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
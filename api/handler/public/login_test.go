@@ -21,7 +21,9 @@ type MockAuth struct {
 }
 
 func (m *MockAuth) VerifySignatureJWT(tokenStr string) (*auth.Identity, error) {
-	return nil, nil
+	args := m.Called(tokenStr)
+	identity, _ := args.Get(0).(*auth.Identity)
+	return identity, args.Error(1)
 }
 
 func (m *MockAuth) Login(ctx context.Context, username, password string) (*auth.Identity, error) {
@@ -30,10 +32,50 @@ func (m *MockAuth) Login(ctx context.Context, username, password string) (*auth.
 	return identity, args.Error(1)
 }
 
-func (m *MockAuth) RegisterNewUser(ctx context.Context, email string, primaryRole int, roleAdmin bool) (string, error) {
+func (m *MockAuth) RegisterNewUser(ctx context.Context, email string, primaryRole, locationId int, roleAdmin bool) (string, error) {
 	return "", nil
 }
 
+func (m *MockAuth) IssueRefreshToken(ctx context.Context, identity *auth.Identity) (string, error) {
+	args := m.Called(ctx, identity)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuth) RefreshSession(ctx context.Context, token string) (*auth.Identity, string, error) {
+	args := m.Called(ctx, token)
+	identity, _ := args.Get(0).(*auth.Identity)
+	return identity, args.String(1), args.Error(2)
+}
+
+func (m *MockAuth) RevokeRefreshToken(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuth) RevokeAccessToken(ctx context.Context, tokenStr string) error {
+	args := m.Called(ctx, tokenStr)
+	return args.Error(0)
+}
+
+func (m *MockAuth) RevokeAllSessions(ctx context.Context, employeeId int) error {
+	args := m.Called(ctx, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockAuth) GetIdentityRole(ctx context.Context, identityId string) (string, error) {
+	args := m.Called(ctx, identityId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuth) JWKS() auth.JWKS {
+	args := m.Called()
+	return args.Get(0).(auth.JWKS)
+}
+
+func (m *MockAuth) VerifyAPIToken(ctx context.Context, token string) (*auth.Identity, error) {
+	return nil, nil
+}
+
 // Sample login request body struct matching your expected input
 type loginRequest struct {
 	Username string `json:"username" validate:"required,email"`
@@ -119,13 +161,17 @@ func TestPublicLogin(t *testing.T) {
 				mockAuth.On("Login", mock.Anything, loginReq.Username, loginReq.Password).
 					Return(tc.mockLoginResp, tc.mockLoginErr)
 			}
+			if tc.mockLoginResp != nil {
+				mockAuth.On("IssueRefreshToken", mock.Anything, tc.mockLoginResp).Return("refresh", nil)
+			}
 
 			validate := validator.New()
 
 			p := &Public{
-				auth:      mockAuth,
-				validator: validate,
-				cookie:    cookieConfig(),
+				auth:          mockAuth,
+				validator:     validate,
+				cookie:        cookieConfig(),
+				refreshCookie: refreshCookieConfig(),
 			}
 
 			router := gin.New()
@@ -156,6 +202,13 @@ func TestPublicLogin(t *testing.T) {
 				assert.True(t, tokenCookie.Secure)
 				assert.True(t, tokenCookie.HttpOnly)
 				assert.Greater(t, tokenCookie.MaxAge, 0)
+
+				refreshCookie := findCookie(cookies, "refresh_token")
+				assert.NotNil(t, refreshCookie, "expected refresh cookie to be set")
+				assert.Equal(t, "refresh", refreshCookie.Value)
+				assert.Equal(t, "/refresh", refreshCookie.Path)
+				assert.True(t, refreshCookie.HttpOnly)
+				assert.Greater(t, refreshCookie.MaxAge, tokenCookie.MaxAge)
 			}
 
 			assert.Contains(t, w.Body.String(), tc.wantRespBody)
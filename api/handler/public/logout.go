@@ -2,20 +2,55 @@ package public
 
 import (
 	"net/http"
+	"strconv"
+
+	"payd/util"
 
 	"github.com/gin-gonic/gin"
 )
 
 func (p *Public) logout(c *gin.Context) {
-	c.SetCookie(
-		p.cookie.name,
-		"",
-		-1,
-		p.cookie.path,
-		p.cookie.domain,
-		p.cookie.secure,
-		p.cookie.httpOnly,
-	)
+	ctx := c.Request.Context()
+	// the cookies are cleared anyway, a failed revocation only leaves the tokens to expire
+	if token, err := c.Cookie(p.cookie.name); err == nil && token != "" {
+		if err := p.auth.RevokeAccessToken(ctx, token); err != nil {
+			util.Log().WithContext(ctx).WithError(err).Error("revoke access token")
+		}
+	}
+	if refreshToken, err := c.Cookie(p.refreshCookie.name); err == nil && refreshToken != "" {
+		if err := p.auth.RevokeRefreshToken(ctx, refreshToken); err != nil {
+			util.Log().WithContext(ctx).WithError(err).Error("revoke refresh token")
+		}
+	}
+	p.clearSessionCookies(c)
 
 	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
 }
+
+// logoutAll revokes every session of the caller, including the ones on other devices
+func (p *Public) logoutAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	token, err := c.Cookie(p.cookie.name)
+	if err != nil || token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+		return
+	}
+	identity, err := p.auth.VerifySignatureJWT(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+	employeeId, err := strconv.Atoi(identity.EmployeeId)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+	if err := p.auth.RevokeAllSessions(ctx, employeeId); err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("revoke all sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	p.clearSessionCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out everywhere"})
+}
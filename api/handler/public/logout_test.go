@@ -0,0 +1,142 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublicLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		token         string
+		refreshCookie string
+		mockErr       error
+	}{
+		{name: "revokes access and refresh token", token: "jwt", refreshCookie: "refresh"},
+		{name: "revocation failure still logs out", token: "jwt", refreshCookie: "refresh", mockErr: errors.New("db error")},
+		{name: "no cookies"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuth := new(MockAuth)
+			if tc.token != "" {
+				mockAuth.On("RevokeAccessToken", mock.Anything, tc.token).Return(tc.mockErr)
+			}
+			if tc.refreshCookie != "" {
+				mockAuth.On("RevokeRefreshToken", mock.Anything, tc.refreshCookie).Return(tc.mockErr)
+			}
+			p := &Public{
+				auth:          mockAuth,
+				cookie:        cookieConfig(),
+				refreshCookie: refreshCookieConfig(),
+			}
+			router := gin.New()
+			router.POST("/logout", p.logout)
+
+			req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if tc.token != "" {
+				req.AddCookie(&http.Cookie{Name: "token", Value: tc.token})
+			}
+			if tc.refreshCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tc.refreshCookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			for _, name := range []string{"token", "refresh_token"} {
+				c := findCookie(w.Result().Cookies(), name)
+				assert.NotNil(t, c)
+				assert.Less(t, c.MaxAge, 0)
+			}
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPublicLogoutAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		token          string
+		mockIdentity   *auth.Identity
+		mockVerifyErr  error
+		mockRevokeErr  error
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "success",
+			token:          "jwt",
+			mockIdentity:   &auth.Identity{EmployeeId: "4"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "logged out everywhere",
+		},
+		{
+			name:           "missing token",
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "Missing token",
+		},
+		{
+			name:           "revoked token",
+			token:          "jwt",
+			mockVerifyErr:  auth.ErrTokenRevoked,
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "Invalid token",
+		},
+		{
+			name:           "revocation failure",
+			token:          "jwt",
+			mockIdentity:   &auth.Identity{EmployeeId: "4"},
+			mockRevokeErr:  errors.New("db error"),
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuth := new(MockAuth)
+			if tc.token != "" {
+				mockAuth.On("VerifySignatureJWT", tc.token).Return(tc.mockIdentity, tc.mockVerifyErr)
+			}
+			if tc.mockIdentity != nil {
+				mockAuth.On("RevokeAllSessions", mock.Anything, 4).Return(tc.mockRevokeErr)
+			}
+			p := &Public{
+				auth:          mockAuth,
+				cookie:        cookieConfig(),
+				refreshCookie: refreshCookieConfig(),
+			}
+			router := gin.New()
+			router.POST("/logout/all", p.logoutAll)
+
+			req := httptest.NewRequest(http.MethodPost, "/logout/all", nil)
+			if tc.token != "" {
+				req.AddCookie(&http.Cookie{Name: "token", Value: tc.token})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+			if tc.wantStatusCode == http.StatusOK {
+				c := findCookie(w.Result().Cookies(), "refresh_token")
+				assert.NotNil(t, c)
+				assert.Less(t, c.MaxAge, 0)
+			}
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
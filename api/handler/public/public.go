@@ -2,15 +2,18 @@ package public
 
 import (
 	"payd/services/auth"
+	"payd/services/calendar"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
 type Public struct {
-	auth      auth.AuthInterface
-	validator *validator.Validate
-	cookie    cookie
+	auth          auth.AuthInterface
+	calendar      calendar.CalendarInterface
+	validator     *validator.Validate
+	cookie        cookie
+	refreshCookie cookie
 }
 
 type cookie struct {
@@ -31,11 +34,26 @@ func cookieConfig() cookie {
 	}
 }
 
+// refreshPath is the route of the refresh endpoints, the public handler is mounted at the root
+const refreshPath = "/refresh"
+
+// the refresh cookie is only sent to the refresh endpoints, logging out with it revokes the refresh token
+func refreshCookieConfig() cookie {
+	return cookie{
+		name:     "refresh_token",
+		path:     refreshPath,
+		domain:   "",
+		secure:   true,
+		httpOnly: true,
+	}
+}
+
 type Option func(*Public) error
 
 func PublicHandler(router *gin.RouterGroup, opts ...Option) error {
 	public := &Public{
-		cookie: cookieConfig(),
+		cookie:        cookieConfig(),
+		refreshCookie: refreshCookieConfig(),
 	}
 	for _, opt := range opts {
 		if err := opt(public); err != nil {
@@ -46,8 +64,14 @@ func PublicHandler(router *gin.RouterGroup, opts ...Option) error {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 	router.POST("/login", public.login)
+	// the refresh cookie isn't sent to /logout, which only revokes the access token
 	router.POST("/logout", public.logout)
+	router.POST("/logout/all", public.logoutAll)
+	router.POST(refreshPath, public.refresh)
+	router.POST(refreshPath+"/logout", public.logout)
 	router.POST("/activate", public.activateAccount)
+	router.GET("/.well-known/jwks.json", public.jwks)
+	router.GET("/calendar/:token", public.calendarFeed)
 	return nil
 }
 
@@ -58,6 +82,13 @@ func WithAuthSvc(auth auth.AuthInterface) Option {
 	}
 }
 
+func WithCalendarSvc(calendar calendar.CalendarInterface) Option {
+	return func(s *Public) error {
+		s.calendar = calendar
+		return nil
+	}
+}
+
 func WithValidator(validator *validator.Validate) Option {
 	return func(s *Public) error {
 		s.validator = validator
@@ -0,0 +1,74 @@
+package public
+
+import (
+	"net/http"
+	"time"
+
+	"payd/services/auth"
+	"payd/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const accessTokenTTL = time.Minute * 15
+
+// refresh rotates the refresh cookie and issues a new access token
+func (p *Public) refresh(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := util.Log().WithContext(ctx)
+
+	refreshToken, err := c.Cookie(p.refreshCookie.name)
+	if err != nil || refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
+		return
+	}
+	identity, newRefreshToken, err := p.auth.RefreshSession(ctx, refreshToken)
+	if err != nil {
+		switch err {
+		case auth.ErrInvalidRefreshToken, auth.ErrRefreshTokenReused:
+			p.clearSessionCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidRefreshToken.Error()})
+		case auth.ErrAccountDeactivated:
+			p.clearSessionCookies(c)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.WithError(err).Error("refresh session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+	if err := p.setSessionCookies(c, identity, newRefreshToken); err != nil {
+		log.WithError(err).Error("generate jwt")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "token refreshed"})
+}
+
+func (p *Public) setSessionCookies(c *gin.Context, identity *auth.Identity, refreshToken string) error {
+	token, err := identity.GenerateJWT(accessTokenTTL)
+	if err != nil {
+		return err
+	}
+	p.setCookie(c, p.cookie, token, int(accessTokenTTL.Seconds()))
+	p.setCookie(c, p.refreshCookie, refreshToken, int(auth.RefreshTokenIdleTTL.Seconds()))
+	return nil
+}
+
+func (p *Public) clearSessionCookies(c *gin.Context) {
+	p.setCookie(c, p.cookie, "", -1)
+	p.setCookie(c, p.refreshCookie, "", -1)
+}
+
+func (p *Public) setCookie(c *gin.Context, ck cookie, value string, maxAge int) {
+	c.SetCookie(
+		ck.name,
+		value,
+		maxAge, // in seconds, negative deletes the cookie
+		ck.path,
+		ck.domain, // empty = current domain
+		ck.secure, // true = HTTPS only
+		ck.httpOnly,
+	)
+}
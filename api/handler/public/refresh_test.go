@@ -0,0 +1,138 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestPublicRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	identity := &auth.Identity{
+		ID:         "userid",
+		Email:      "abc@mail.com",
+		Role:       "employee",
+		EmployeeId: "4",
+	}
+	tests := []struct {
+		name           string
+		refreshCookie  string
+		mockIdentity   *auth.Identity
+		mockToken      string
+		mockErr        error
+		wantStatusCode int
+		wantRespBody   string
+		wantCleared    bool
+	}{
+		{
+			name:           "success refresh",
+			refreshCookie:  "old",
+			mockIdentity:   identity,
+			mockToken:      "new",
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "token refreshed",
+		},
+		{
+			name:           "missing cookie",
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "missing refresh token",
+		},
+		{
+			name:           "invalid token",
+			refreshCookie:  "old",
+			mockErr:        auth.ErrInvalidRefreshToken,
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "invalid refresh token",
+			wantCleared:    true,
+		},
+		{
+			name:           "reused token",
+			refreshCookie:  "old",
+			mockErr:        auth.ErrRefreshTokenReused,
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "invalid refresh token",
+			wantCleared:    true,
+		},
+		{
+			name:           "deactivated account",
+			refreshCookie:  "old",
+			mockErr:        auth.ErrAccountDeactivated,
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "the account has been deactivated",
+			wantCleared:    true,
+		},
+		{
+			name:           "internal error",
+			refreshCookie:  "old",
+			mockErr:        errors.New("db error"),
+			wantStatusCode: http.StatusInternalServerError,
+			wantRespBody:   "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuth := new(MockAuth)
+			if tc.refreshCookie != "" {
+				mockAuth.On("RefreshSession", mock.Anything, tc.refreshCookie).
+					Return(tc.mockIdentity, tc.mockToken, tc.mockErr)
+			}
+			p := &Public{
+				auth:          mockAuth,
+				cookie:        cookieConfig(),
+				refreshCookie: refreshCookieConfig(),
+			}
+			router := gin.New()
+			router.POST("/refresh", p.refresh)
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			if tc.refreshCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tc.refreshCookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+
+			cookies := w.Result().Cookies()
+			if tc.wantStatusCode == http.StatusOK {
+				tokenCookie := findCookie(cookies, "token")
+				assert.NotNil(t, tokenCookie)
+				assert.NotEmpty(t, tokenCookie.Value)
+				refreshCookie := findCookie(cookies, "refresh_token")
+				assert.NotNil(t, refreshCookie)
+				assert.Equal(t, tc.mockToken, refreshCookie.Value)
+				assert.Equal(t, "/", tokenCookie.Path)
+				// the refresh cookie is only sent to the refresh endpoints
+				assert.Equal(t, "/refresh", refreshCookie.Path)
+			}
+			if tc.wantCleared {
+				for _, name := range []string{"token", "refresh_token"} {
+					c := findCookie(cookies, name)
+					assert.NotNil(t, c)
+					assert.Empty(t, c.Value)
+					assert.Less(t, c.MaxAge, 0)
+				}
+				assert.Equal(t, "/refresh", findCookie(cookies, "refresh_token").Path)
+			}
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
@@ -5,14 +5,28 @@ import (
 	"fmt"
 	"os"
 	"payd/handler"
+	"payd/services/audit"
 	"payd/services/auth"
+	"payd/services/availability"
+	"payd/services/calendar"
+	"payd/services/employee"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/payroll"
+	"payd/services/permission"
 	"payd/services/role"
+	"payd/services/roster"
 	"payd/services/shift"
+	"payd/services/shiftrequest"
+	"payd/services/shiftswap"
+	"payd/services/timesheet"
+	"payd/services/webhook"
 	"payd/storage"
 	"payd/util"
 	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/sirupsen/logrus"
@@ -31,15 +45,42 @@ func main() {
 
 	st := initStorage()
 	roleManager := initRoleCache(ctx, st, 5*time.Second)
-	authSvc := initAuth(st)
-	shiftSvc := initShift(st)
+	permissionManager := initPermissionCache(ctx, st, 5*time.Second)
+	authSvc := initAuth(ctx, st)
+	shiftSvc := initShift(ctx, st)
+	availabilitySvc := availability.NewAvailability(st)
+	leaveSvc := initLeave(ctx, st)
+	shiftRequestSvc := initShiftRequest(st, shiftSvc, availabilitySvc, leaveSvc)
+	shiftSwapSvc := shiftswap.NewShiftSwap(st, availabilitySvc, leaveSvc)
+	locationSvc := location.NewLocation(st)
+	rosterSvc := roster.NewRoster(st, availabilitySvc, leaveSvc, shiftRequestSvc, locationSvc, roster.Greedy{})
+	timesheetSvc := initTimesheet(st)
+	payrollSvc := payroll.NewPayroll(st, payroll.WithRules(initPayrollRules()))
+	calendarSvc := initCalendar(st)
+	employeeSvc := employee.NewEmployee(st, authSvc)
+	auditSvc := audit.NewAudit(st)
+	webhookSvc := initWebhook(ctx, st)
 
 	logrus.WithField("port", port).Info("starting...")
 	validator := util.NewValidator()
 	httpHandler, err := handler.NewHandler(handler.WithAuthSvc(authSvc),
+		handler.WithAPITokenSvc(authSvc),
 		handler.WithShiftSvc(shiftSvc),
+		handler.WithShiftRequestSvc(shiftRequestSvc),
+		handler.WithShiftSwapSvc(shiftSwapSvc),
+		handler.WithRosterSvc(rosterSvc),
+		handler.WithTimesheetSvc(timesheetSvc),
+		handler.WithPayrollSvc(payrollSvc),
+		handler.WithCalendarSvc(calendarSvc),
+		handler.WithWebhookSvc(webhookSvc),
+		handler.WithAvailabilitySvc(availabilitySvc),
+		handler.WithLeaveSvc(leaveSvc),
+		handler.WithEmployeeSvc(employeeSvc),
+		handler.WithLocationSvc(locationSvc),
+		handler.WithAuditSvc(auditSvc),
 		handler.WithValidator(validator),
 		handler.WithRoleManager(roleManager),
+		handler.WithPermissionManager(permissionManager),
 		func() handler.Option {
 			origins := os.Getenv("CORS_ORIGINS")
 			allowedOrigins := []string{"*"}
@@ -65,18 +106,114 @@ func initRoleCache(ctx context.Context, st *storage.Storage, tick time.Duration)
 	return rm
 }
 
-func initShift(st *storage.Storage) *shift.Shift {
-	return shift.NewShift(st)
+// background process for privilege role cache, every request is authorized against it
+func initPermissionCache(ctx context.Context, st *storage.Storage, tick time.Duration) *permission.Manager {
+	pm := permission.NewManager(st, tick)
+	if err := pm.Start(ctx); err != nil {
+		util.Log().Fatal(err)
+	}
+	return pm
+}
+
+// shift templates are materialized in the background up to SHIFT_TEMPLATE_HORIZON_DAYS ahead
+func initShift(ctx context.Context, st *storage.Storage) *shift.Shift {
+	horizonDays, _ := strconv.Atoi(os.Getenv("SHIFT_TEMPLATE_HORIZON_DAYS"))
+	shiftSvc := shift.NewShift(st, shift.WithTemplateHorizon(time.Duration(horizonDays)*24*time.Hour))
+	shiftSvc.StartTemplateGenerator(ctx, time.Hour)
+	return shiftSvc
 }
 
-func initAuth(st *storage.Storage) *auth.Auth {
+func initShiftRequest(st *storage.Storage, shiftSvc *shift.Shift, availabilitySvc *availability.Availability,
+	leaveSvc *leave.Leave) *shiftrequest.ShiftRequest {
+	return shiftrequest.NewShiftRequest(st, shiftSvc, availabilitySvc, leaveSvc)
+}
+
+// the monthly leave accruals are credited in the background, at most once per month
+func initLeave(ctx context.Context, st *storage.Storage) *leave.Leave {
+	leaveSvc := leave.NewLeave(st)
+	leaveSvc.StartAccrual(ctx, time.Hour)
+	return leaveSvc
+}
+
+// employees clock in from TIMESHEET_EARLY_GRACE_MINUTES before the shift start
+// and are late after TIMESHEET_LATE_GRACE_MINUTES past it
+func initTimesheet(st *storage.Storage) *timesheet.Timesheet {
+	earlyGrace, _ := strconv.Atoi(os.Getenv("TIMESHEET_EARLY_GRACE_MINUTES"))
+	lateGrace, _ := strconv.Atoi(os.Getenv("TIMESHEET_LATE_GRACE_MINUTES"))
+	return timesheet.NewTimesheet(st,
+		timesheet.WithEarlyGrace(time.Duration(earlyGrace)*time.Minute),
+		timesheet.WithLateGrace(time.Duration(lateGrace)*time.Minute))
+}
+
+// the calendar feeds keep the shifts of the last CALENDAR_FEED_HISTORY_DAYS
+func initCalendar(st *storage.Storage) *calendar.Calendar {
+	historyDays, _ := strconv.Atoi(os.Getenv("CALENDAR_FEED_HISTORY_DAYS"))
+	return calendar.NewCalendar(st, calendar.WithHistory(time.Duration(historyDays)*24*time.Hour))
+}
+
+// the outbox events are delivered to the webhooks in the background, a failed delivery is attempted
+// up to WEBHOOK_MAX_ATTEMPTS times with an exponential backoff before being given up on
+func initWebhook(ctx context.Context, st *storage.Storage) *webhook.Webhook {
+	maxAttempts, _ := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	webhookSvc := webhook.NewWebhook(st, webhook.WithMaxAttempts(maxAttempts))
+	webhookSvc.StartDispatcher(ctx, 10*time.Second)
+	return webhookSvc
+}
+
+// the payroll export rules default to payroll.DefaultRules, each PAYROLL_* variable overrides its rule when set
+func initPayrollRules() payroll.Rules {
+	rules := payroll.DefaultRules()
+	if v, err := strconv.Atoi(os.Getenv("PAYROLL_NIGHT_START_HOUR")); err == nil {
+		rules.NightStart = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PAYROLL_NIGHT_END_HOUR")); err == nil {
+		rules.NightEnd = v
+	}
+	if v, ok := os.LookupEnv("PAYROLL_WEEKEND_DAYS"); ok && v != "" {
+		weekdays := map[string]time.Weekday{}
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			weekdays[strings.ToLower(d.String())] = d
+		}
+		rules.WeekendDays = nil
+		for _, name := range strings.Split(v, ",") {
+			d, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				util.Log().Fatalf("invalid PAYROLL_WEEKEND_DAYS day %q", name)
+			}
+			rules.WeekendDays = append(rules.WeekendDays, d)
+		}
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PAYROLL_DAILY_OVERTIME_HOURS"), 64); err == nil {
+		rules.DailyOvertime = time.Duration(v * float64(time.Hour))
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PAYROLL_WEEKLY_OVERTIME_HOURS"), 64); err == nil {
+		rules.WeeklyOvertime = time.Duration(v * float64(time.Hour))
+	}
+	return rules
+}
+
+func initAuth(ctx context.Context, st *storage.Storage) *auth.Auth {
+	// revoked access tokens are cached, the ones revoked by other instances are picked up on the next tick
+	revocations := auth.NewRevocationList(st, 5*time.Second)
+	if err := revocations.Start(ctx); err != nil {
+		util.Log().Fatal(err)
+	}
 	kratosPubliURL := os.Getenv("KRATO_PUBLIC_URL")
 	kratosAdminUrl := os.Getenv("KRATO_ADMIN_URL")
 
 	jwtSecret := os.Getenv("JWT_SECRET")
+	opts := []auth.AuthOption{auth.WithKratosPublicURL(kratosPubliURL), auth.WithKratosAdminURL(kratosAdminUrl),
+		auth.WithJWTSecret(jwtSecret), auth.WithRevocationList(revocations)}
+	// with a key directory the tokens are signed asymmetrically and the secret only verifies the older tokens
+	if keyDir := os.Getenv("JWT_KEY_DIR"); keyDir != "" {
+		keys, err := auth.LoadKeySet(keyDir, os.Getenv("JWT_SIGNING_KID"))
+		if err != nil {
+			util.Log().Fatal(err)
+		}
+		opts = append(opts, auth.WithKeySet(keys))
+	}
 
-	authSvc, err := auth.NewAuth(st, auth.WithKratosPublicURL(kratosPubliURL), auth.WithKratosAdminURL(kratosAdminUrl),
-		auth.WithJWTSecret(jwtSecret))
+	authSvc, err := auth.NewAuth(st, opts...)
 	if err != nil {
 		util.Log().Fatal(err)
 	}
@@ -1,21 +1,45 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"payd/services/audit"
 	"payd/services/auth"
+	st "payd/storage"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// IdentityKey is the gin context key holding the *auth.Identity of the authorized caller
+const IdentityKey = "identity"
+
+// JWTAuthorizeRoles authorizes the token of the Authorization: Bearer header, or of the token cookie without it.
+// A bearer token is either an access token or an API token, API tokens are also restricted to their scopes.
 func JWTAuthorizeRoles(authService auth.AuthInterface, allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := c.Cookie("token")
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
-			return
+		token, bearer := bearerToken(c)
+		if !bearer {
+			cookie, err := c.Cookie("token")
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+				return
+			}
+			token = cookie
 		}
 
-		identity, err := authService.VerifySignatureJWT(token)
+		var identity *auth.Identity
+		var err error
+		if bearer && strings.HasPrefix(token, auth.APITokenPrefix) {
+			identity, err = authService.VerifyAPIToken(c.Request.Context(), token)
+		} else {
+			identity, err = authService.VerifySignatureJWT(token)
+		}
+		if err == auth.ErrTokenRevoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Revoked token"})
+			return
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
@@ -33,8 +57,70 @@ func JWTAuthorizeRoles(authService auth.AuthInterface, allowedRoles ...string) g
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
 			return
 		}
+		if !identity.CanAccess(c.Request.Method, c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient token scope"})
+			return
+		}
 
-		c.Set("identity", identity)
+		c.Set(IdentityKey, identity)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), auditActor(identity)))
 		c.Next()
 	}
 }
+
+// auditActor returns the caller recorded in the audit log of the actions of the identity
+func auditActor(identity *auth.Identity) audit.Actor {
+	actor := audit.Actor{Name: identity.ID}
+	actor.EmployeeID, _ = strconv.Atoi(identity.EmployeeId)
+	switch {
+	case identity.APITokenID != 0:
+		actor.Name = fmt.Sprintf("api_token:%d", identity.APITokenID)
+	case actor.Name == "":
+		actor.Name = "employee:" + identity.EmployeeId
+	}
+	return actor
+}
+
+// bearerToken returns the token of the Authorization header, false without a bearer authorization
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// GetIdentity returns the identity set by JWTAuthorizeRoles
+func GetIdentity(c *gin.Context) (*auth.Identity, bool) {
+	val, ok := c.Get(IdentityKey)
+	if !ok {
+		return nil, false
+	}
+	identity, ok := val.(*auth.Identity)
+	return identity, ok
+}
+
+// GetEmployeeID returns the db employee id of the identity set by JWTAuthorizeRoles
+func GetEmployeeID(c *gin.Context) (int, bool) {
+	identity, ok := GetIdentity(c)
+	if !ok {
+		return 0, false
+	}
+	employeeId, err := strconv.Atoi(identity.EmployeeId)
+	if err != nil || employeeId == 0 {
+		return 0, false
+	}
+	return employeeId, true
+}
+
+// GetLocationScope returns the locations the identity set by JWTAuthorizeRoles may access, nil for every location,
+// and the location of the identity itself
+func GetLocationScope(c *gin.Context) ([]int, int) {
+	identity, ok := GetIdentity(c)
+	if !ok {
+		return nil, st.DefaultLocationID
+	}
+	return identity.LocationIDs, identity.LocationID
+}
@@ -29,7 +29,7 @@ func (m *MockAuthService) Login(ctx context.Context, username, password string)
 	return nil, nil
 }
 
-func (m *MockAuthService) RegisterNewUser(ctx context.Context, email string, primaryRole int, roleAdmin bool) (string, error) {
+func (m *MockAuthService) RegisterNewUser(ctx context.Context, email string, primaryRole, locationId int, roleAdmin bool) (string, error) {
 	return "", nil
 }
 
@@ -37,6 +37,42 @@ func (m *MockAuthService) ActivateNewUser(ctx context.Context, userId string, na
 	return nil
 }
 
+func (m *MockAuthService) IssueRefreshToken(ctx context.Context, identity *auth.Identity) (string, error) {
+	return "", nil
+}
+
+func (m *MockAuthService) RefreshSession(ctx context.Context, token string) (*auth.Identity, string, error) {
+	return nil, "", nil
+}
+
+func (m *MockAuthService) RevokeRefreshToken(ctx context.Context, token string) error {
+	return nil
+}
+
+func (m *MockAuthService) RevokeAccessToken(ctx context.Context, tokenStr string) error {
+	return nil
+}
+
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, employeeId int) error {
+	return nil
+}
+
+func (m *MockAuthService) GetIdentityRole(ctx context.Context, identityId string) (string, error) {
+	return "", nil
+}
+
+func (m *MockAuthService) JWKS() auth.JWKS {
+	return auth.JWKS{}
+}
+
+func (m *MockAuthService) VerifyAPIToken(ctx context.Context, token string) (*auth.Identity, error) {
+	args := m.Called(token)
+	if identity, ok := args.Get(0).(*auth.Identity); ok {
+		return identity, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestJWTAuthorizeRoles(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -64,6 +100,14 @@ func TestJWTAuthorizeRoles(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   "Invalid token",
 		},
+		{
+			name:           "revoked token",
+			cookiePresent:  true,
+			token:          "revoked-token",
+			mockError:      auth.ErrTokenRevoked,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Revoked token",
+		},
 		{
 			name:           "unauthorized role",
 			cookiePresent:  true,
@@ -112,3 +156,122 @@ func TestJWTAuthorizeRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestJWTAuthorizeRolesBearer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		cookie         string
+		mockMethod     string
+		mockToken      string
+		mockIdentity   *auth.Identity
+		mockError      error
+		method         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "bearer access token",
+			authorization:  "Bearer jwt",
+			mockMethod:     "VerifySignatureJWT",
+			mockToken:      "jwt",
+			mockIdentity:   &auth.Identity{Role: "admin"},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "access granted",
+		},
+		{
+			name:           "bearer takes precedence over the cookie",
+			authorization:  "bearer jwt",
+			cookie:         "cookie-jwt",
+			mockMethod:     "VerifySignatureJWT",
+			mockToken:      "jwt",
+			mockIdentity:   &auth.Identity{Role: "admin"},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "access granted",
+		},
+		{
+			name:           "non bearer authorization falls back to the cookie",
+			authorization:  "Basic abc",
+			cookie:         "cookie-jwt",
+			mockMethod:     "VerifySignatureJWT",
+			mockToken:      "cookie-jwt",
+			mockIdentity:   &auth.Identity{Role: "admin"},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "access granted",
+		},
+		{
+			name:           "api token in scope",
+			authorization:  "Bearer payd_abc",
+			mockMethod:     "VerifyAPIToken",
+			mockToken:      "payd_abc",
+			mockIdentity:   &auth.Identity{Role: "admin", Scopes: []string{"GET /protected/*"}},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "access granted",
+		},
+		{
+			name:           "api token out of scope",
+			authorization:  "Bearer payd_abc",
+			mockMethod:     "VerifyAPIToken",
+			mockToken:      "payd_abc",
+			mockIdentity:   &auth.Identity{Role: "admin", Scopes: []string{"GET /protected/:id"}},
+			method:         http.MethodPost,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "Insufficient token scope",
+		},
+		{
+			name:           "invalid api token",
+			authorization:  "Bearer payd_abc",
+			mockMethod:     "VerifyAPIToken",
+			mockToken:      "payd_abc",
+			mockError:      auth.ErrInvalidToken,
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Invalid token",
+		},
+		{
+			name:           "api token prefix only in the cookie is verified as jwt",
+			cookie:         "payd_abc",
+			mockMethod:     "VerifySignatureJWT",
+			mockToken:      "payd_abc",
+			mockError:      errors.New("invalid"),
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Invalid token",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuth := new(MockAuthService)
+			mockAuth.On(tc.mockMethod, tc.mockToken).Return(tc.mockIdentity, tc.mockError)
+
+			router := gin.New()
+			router.Use(JWTAuthorizeRoles(mockAuth, "admin"))
+			handler := func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "access granted"})
+			}
+			router.GET("/protected/:id", handler)
+			router.POST("/protected/:id", handler)
+
+			req := httptest.NewRequest(tc.method, "/protected/1", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "token", Value: tc.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"payd/services/permission"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GrantsKey is the gin context key holding the permission.Grants of the authorized caller
+const GrantsKey = "grants"
+
+// RequirePermission lets the request through if the caller set by JWTAuthorizeRoles holds the permission,
+// for at least one job role when the permission can be restricted to some.
+func RequirePermission(resolver permission.GrantResolver, perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+			return
+		}
+		employeeId, _ := GetEmployeeID(c)
+		grants := resolver.Grants(identity.Role, employeeId)
+		if !grants.Has(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission " + perm})
+			return
+		}
+
+		c.Set(GrantsKey, grants)
+		c.Next()
+	}
+}
+
+// GetGrants returns the grants set by RequirePermission
+func GetGrants(c *gin.Context) (permission.Grants, bool) {
+	val, ok := c.Get(GrantsKey)
+	if !ok {
+		return nil, false
+	}
+	grants, ok := val.(permission.Grants)
+	return grants, ok
+}
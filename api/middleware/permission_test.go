@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"payd/services/auth"
+	"payd/services/permission"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGrantResolver struct {
+	grants map[int]permission.Grants
+}
+
+func (m *mockGrantResolver) Grants(identityRole string, employeeId int) permission.Grants {
+	if identityRole == "admin" {
+		return permission.Grants{permission.RequestsApprove: nil}
+	}
+	return m.grants[employeeId]
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolver := &mockGrantResolver{grants: map[int]permission.Grants{
+		7: {permission.RequestsApprove: {2}},
+	}}
+
+	tests := []struct {
+		name           string
+		identity       *auth.Identity
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:           "admin",
+			identity:       &auth.Identity{EmployeeId: "1", Role: "admin"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "null",
+		},
+		{
+			name:           "supervisor restricted to a job role",
+			identity:       &auth.Identity{EmployeeId: "7", Role: "employee"},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   "[2]",
+		},
+		{
+			name:           "employee without the permission",
+			identity:       &auth.Identity{EmployeeId: "8", Role: "employee"},
+			wantStatusCode: http.StatusForbidden,
+			wantRespBody:   "Missing permission requests:approve",
+		},
+		{
+			name:           "not authorized",
+			wantStatusCode: http.StatusUnauthorized,
+			wantRespBody:   "Missing token",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.identity != nil {
+					c.Set(IdentityKey, tc.identity)
+				}
+				c.Next()
+			})
+			router.POST("/approve", RequirePermission(resolver, permission.RequestsApprove), func(c *gin.Context) {
+				grants, ok := GetGrants(c)
+				assert.True(t, ok)
+				c.JSON(http.StatusOK, grants.JobRoles(permission.RequestsApprove))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/approve", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantRespBody)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"payd/util"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantKept   bool
+		wantLength int
+	}{
+		{name: "generated", wantLength: 32},
+		{name: "kept from the client", header: "abc-123", wantKept: true},
+		{name: "invalid replaced", header: "bad id\n", wantLength: 32},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(c *gin.Context) {
+				seen = util.RequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+			if tc.wantKept {
+				assert.Equal(t, tc.header, seen)
+			} else {
+				assert.Len(t, seen, tc.wantLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	st "payd/storage"
+	"payd/util"
+)
+
+// the types of the audited entities
+const (
+	EntityAPIToken        = "api_token"
+	EntityAvailability    = "availability" // the weekly windows of an employee, by employee id
+	EntityCalendarFeed    = "calendar_feed"
+	EntityEmployee        = "employee"
+	EntityIdentity        = "identity"      // a kratos identity, its id is in the state as the entity id is 0
+	EntityLeaveBalance    = "leave_balance" // by employee id
+	EntityLeaveRequest    = "leave_request"
+	EntityLocation        = "location"
+	EntityPrivilegeRole   = "privilege_role"
+	EntityRole            = "role"
+	EntityShift           = "shift"
+	EntityShiftRequest    = "shift_request"
+	EntityShiftSwap       = "shift_swap"
+	EntityShiftTemplate   = "shift_template"
+	EntityTimeEntry       = "time_entry"
+	EntityUnavailability  = "unavailability"
+	EntityWebhook         = "webhook"
+	EntityWebhookDelivery = "webhook_delivery"
+)
+
+// maxPageSize bounds the page of ListLogs
+const maxPageSize = 500
+
+var ErrInvalidTimeRange = errors.New("start must be before end")
+var ErrInvalidPage = errors.New("limit must be within 1-500 and offset must not be negative")
+
+// Actor is the caller of a state-changing action
+type Actor struct {
+	EmployeeID int    // 0 when the caller isn't linked to an employee
+	Name       string // the identity of the caller, see middleware.JWTAuthorizeRoles
+}
+
+// System is the actor of the actions done without a caller, such as the scheduled jobs
+var System = Actor{Name: "system"}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the caller of the actions done with it
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, System without one
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return System
+}
+
+// Recorder stores the audit logs, implemented by the storage
+type Recorder interface {
+	InsertAuditLog(ctx context.Context, l st.AuditLog) error
+}
+
+// Record appends the action on the entity to the audit log, along with the actor and the request id of ctx.
+// call it with the transactional context of the change so that both are committed or rolled back together.
+// before and after are stored as JSON, nil when the action creates or deletes the entity
+func Record(ctx context.Context, r Recorder, action, entityType string, entityId int, before, after interface{}) error {
+	l := st.AuditLog{Action: action, EntityType: entityType, EntityID: entityId}
+	actor := ActorFrom(ctx)
+	l.Actor = actor.Name
+	if actor.EmployeeID != 0 {
+		l.ActorID = &actor.EmployeeID
+	}
+	if id := util.RequestID(ctx); id != "" {
+		l.RequestID = &id
+	}
+	var err error
+	if l.Before, err = marshal(before); err != nil {
+		return err
+	}
+	if l.After, err = marshal(after); err != nil {
+		return err
+	}
+	return r.InsertAuditLog(ctx, l)
+}
+
+func marshal(v interface{}) (*json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(b)
+	return &raw, nil
+}
+
+type storage interface {
+	ListAuditLogs(ctx context.Context, filter st.ListAuditLogFilter) ([]st.AuditLog, int, error)
+}
+
+type AuditInterface interface {
+	ListLogs(ctx context.Context, filter st.ListAuditLogFilter) ([]st.AuditLog, int, error)
+}
+
+type Audit struct {
+	storage storage
+}
+
+func NewAudit(storage storage) *Audit {
+	return &Audit{storage: storage}
+}
+
+// ListLogs returns a page of the audit logs matching the filter, newest first, and the number of matching logs
+func (a *Audit) ListLogs(ctx context.Context, filter st.ListAuditLogFilter) ([]st.AuditLog, int, error) {
+	if !filter.Start.IsZero() && !filter.End.IsZero() && !filter.Start.Before(filter.End) {
+		return nil, 0, ErrInvalidTimeRange
+	}
+	if filter.Limit <= 0 || filter.Limit > maxPageSize || filter.Offset < 0 {
+		return nil, 0, ErrInvalidPage
+	}
+	return a.storage.ListAuditLogs(ctx, filter)
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	st "payd/storage"
+	"payd/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	logs     []st.AuditLog
+	filter   st.ListAuditLogFilter
+	listings int
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.logs = append(m.logs, l)
+	return nil
+}
+
+func (m *mockStorage) ListAuditLogs(ctx context.Context, filter st.ListAuditLogFilter) ([]st.AuditLog, int, error) {
+	m.filter = filter
+	m.listings++
+	return m.logs, len(m.logs), nil
+}
+
+func TestRecord(t *testing.T) {
+	t.Run("actor and request of the context", func(t *testing.T) {
+		m := &mockStorage{}
+		ctx := WithActor(util.WithRequestID(context.Background(), "req-1"), Actor{EmployeeID: 4, Name: "alice@example.com"})
+
+		err := Record(ctx, m, "approve", EntityShiftRequest, 9, map[string]string{"status": "PENDING"}, map[string]string{"status": "APPROVED"})
+		require.NoError(t, err)
+		require.Len(t, m.logs, 1)
+
+		l := m.logs[0]
+		assert.Equal(t, "approve", l.Action)
+		assert.Equal(t, EntityShiftRequest, l.EntityType)
+		assert.Equal(t, 9, l.EntityID)
+		assert.Equal(t, "alice@example.com", l.Actor)
+		require.NotNil(t, l.ActorID)
+		assert.Equal(t, 4, *l.ActorID)
+		require.NotNil(t, l.RequestID)
+		assert.Equal(t, "req-1", *l.RequestID)
+		require.NotNil(t, l.Before)
+		assert.JSONEq(t, `{"status":"PENDING"}`, string(*l.Before))
+		require.NotNil(t, l.After)
+		assert.JSONEq(t, `{"status":"APPROVED"}`, string(*l.After))
+	})
+
+	t.Run("system without a caller", func(t *testing.T) {
+		m := &mockStorage{}
+
+		err := Record(context.Background(), m, "create", EntityShift, 1, nil, map[string]int{"id": 1})
+		require.NoError(t, err)
+		require.Len(t, m.logs, 1)
+
+		l := m.logs[0]
+		assert.Equal(t, System.Name, l.Actor)
+		assert.Nil(t, l.ActorID)
+		assert.Nil(t, l.RequestID)
+		assert.Nil(t, l.Before)
+		assert.NotNil(t, l.After)
+	})
+}
+
+func TestListLogs(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  st.ListAuditLogFilter
+		wantErr error
+	}{
+		{name: "open range", filter: st.ListAuditLogFilter{Limit: 50}},
+		{name: "bounded range", filter: st.ListAuditLogFilter{Start: start, End: start.AddDate(0, 0, 1), Limit: 50}},
+		{name: "end before start", filter: st.ListAuditLogFilter{Start: start, End: start, Limit: 50}, wantErr: ErrInvalidTimeRange},
+		{name: "no limit", filter: st.ListAuditLogFilter{}, wantErr: ErrInvalidPage},
+		{name: "limit too large", filter: st.ListAuditLogFilter{Limit: maxPageSize + 1}, wantErr: ErrInvalidPage},
+		{name: "negative offset", filter: st.ListAuditLogFilter{Limit: 50, Offset: -1}, wantErr: ErrInvalidPage},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockStorage{}
+			a := NewAudit(m)
+
+			_, _, err := a.ListLogs(context.Background(), tc.filter)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Zero(t, m.listings)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.filter, m.filter)
+		})
+	}
+}
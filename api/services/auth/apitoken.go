@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APITokenPrefix starts every API token, it tells them apart from JWTs in the Authorization header
+const APITokenPrefix = "payd_"
+
+const (
+	MaxAPITokenTTL = 365 * 24 * time.Hour
+	// last_used_at is written at most once per interval
+	apiTokenTouchInterval = time.Minute
+	// characters of the token kept in clear to recognize it
+	apiTokenDisplayPrefixLen = len(APITokenPrefix) + 6
+)
+
+var ErrAPITokenNotFound = errors.New("api token not found")
+var ErrInvalidScope = errors.New("invalid scope")
+var ErrInvalidAPITokenRole = errors.New("invalid api token role")
+var ErrInvalidExpiration = errors.New("invalid expiration")
+
+var scopeMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+type APITokenInterface interface {
+	CreateAPIToken(ctx context.Context, t NewAPIToken) (int, string, error)
+	ListAPITokens(ctx context.Context) ([]st.APIToken, error)
+	RevokeAPIToken(ctx context.Context, id int) error
+}
+
+type NewAPIToken struct {
+	Name       string
+	EmployeeID int    // the employee the token acts as
+	Role       string // privilege-based(admin/employee)
+	Scopes     []string
+	ExpiresAt  time.Time
+	CreatedBy  int
+}
+
+// CreateAPIToken returns the id and the token, the token can't be retrieved afterwards
+func (a *Auth) CreateAPIToken(ctx context.Context, t NewAPIToken) (id int, token string, err error) {
+	if t.Role != "admin" && t.Role != "employee" {
+		return 0, "", ErrInvalidAPITokenRole
+	}
+	if len(t.Scopes) == 0 {
+		return 0, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range t.Scopes {
+		if err := ValidateScope(scope); err != nil {
+			return 0, "", err
+		}
+	}
+	now := time.Now().UTC()
+	expiresAt := t.ExpiresAt.UTC()
+	if !expiresAt.After(now) || expiresAt.After(now.Add(MaxAPITokenTTL)) {
+		return 0, "", ErrInvalidExpiration
+	}
+	employee, err := a.storage.SelectEmployeeByID(ctx, t.EmployeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotFound
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	if employee.Status == employeeInactive {
+		return 0, "", ErrAccountDeactivated
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return 0, "", err
+	}
+	token = APITokenPrefix + secret
+
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	rec := st.APIToken{
+		Name:       t.Name,
+		TokenHash:  hashToken(token),
+		Prefix:     token[:apiTokenDisplayPrefixLen],
+		EmployeeID: t.EmployeeID,
+		Role:       t.Role,
+		Scopes:     t.Scopes,
+		ExpiresAt:  expiresAt,
+		CreatedBy:  t.CreatedBy,
+	}
+	id, err = a.storage.CreateAPIToken(tctx, rec)
+	if err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("storage create api token")
+		return 0, "", err
+	}
+	// the token hash stays out of the log
+	err = audit.Record(tctx, a.storage, "create", audit.EntityAPIToken, id, nil, map[string]interface{}{
+		"Name":       rec.Name,
+		"Prefix":     rec.Prefix,
+		"EmployeeID": rec.EmployeeID,
+		"Role":       rec.Role,
+		"Scopes":     rec.Scopes,
+		"ExpiresAt":  rec.ExpiresAt,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return id, token, nil
+}
+
+func (a *Auth) ListAPITokens(ctx context.Context) ([]st.APIToken, error) {
+	return a.storage.ListAPITokens(ctx)
+}
+
+func (a *Auth) RevokeAPIToken(ctx context.Context, id int) (err error) {
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	revoked, err := a.storage.RevokeAPIToken(tctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPITokenNotFound
+	}
+	return audit.Record(tctx, a.storage, "revoke", audit.EntityAPIToken, id, nil, map[string]interface{}{"Revoked": true})
+}
+
+// VerifyAPIToken returns the identity the API token acts as, restricted to the token scopes
+func (a *Auth) VerifyAPIToken(ctx context.Context, token string) (*Identity, error) {
+	rec, err := a.storage.SelectAPITokenByHash(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if rec.RevokedAt != nil || !now.Before(rec.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if rec.EmployeeStatus == employeeInactive {
+		return nil, ErrAccountDeactivated
+	}
+	// the request goes on if the use can't be recorded
+	if err := a.storage.TouchAPIToken(ctx, rec.ID, now, apiTokenTouchInterval); err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("storage touch api token")
+	}
+
+	return &Identity{
+		EmployeeId:   strconv.Itoa(rec.EmployeeID),
+		EmployeeName: rec.EmployeeName,
+		Role:         rec.Role,
+		PrimaryRole:  rec.EmployeeRoleID,
+		LocationID:   rec.EmployeeLocationID,
+		LocationIDs:  allowedLocations(rec.Role, rec.EmployeeLocationID, rec.EmployeeManagedLocationIDs),
+		ExpiresAt:    rec.ExpiresAt,
+		APITokenID:   rec.ID,
+		Scopes:       append([]string{}, rec.Scopes...), // never nil, nil scopes allow every route
+	}, nil
+}
+
+// ValidateScope checks the "[METHOD ]ROUTE" format of a scope. ROUTE is a route as registered,
+// e.g. /admin/schedules/:id, a trailing /* matches the route prefix and every route under it.
+func ValidateScope(scope string) error {
+	method, route := splitScope(scope)
+	if method != "" && !scopeMethods[method] {
+		return fmt.Errorf("%w %q: unknown method %s", ErrInvalidScope, scope, method)
+	}
+	if !strings.HasPrefix(route, "/") || strings.Contains(route, " ") {
+		return fmt.Errorf("%w %q: route must start with /", ErrInvalidScope, scope)
+	}
+	if i := strings.Index(route, "*"); i >= 0 && (i != len(route)-1 || !strings.HasSuffix(route, "/*")) {
+		return fmt.Errorf("%w %q: * is only allowed as a trailing /*", ErrInvalidScope, scope)
+	}
+	return nil
+}
+
+func splitScope(scope string) (method, route string) {
+	if i := strings.Index(scope, " "); i >= 0 {
+		return scope[:i], strings.TrimSpace(scope[i+1:])
+	}
+	return "", scope
+}
+
+// CanAccess reports whether the identity may call the route, identities without scopes may call any route
+func (i *Identity) CanAccess(method, route string) bool {
+	if i.Scopes == nil {
+		return true
+	}
+	for _, scope := range i.Scopes {
+		scopeMethod, pattern := splitScope(scope)
+		if scopeMethod != "" && scopeMethod != method {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if route == prefix || strings.HasPrefix(route, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if route == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	st "payd/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// CreateAPIToken implements storage.
+func (m *mockStorage) CreateAPIToken(ctx context.Context, t st.APIToken) (int, error) {
+	t.ID = len(m.apiTokens) + 1
+	t.EmployeeStatus = "ACTIVE"
+	m.apiTokens = append(m.apiTokens, &t)
+	return t.ID, nil
+}
+
+// SelectAPITokenByHash implements storage.
+func (m *mockStorage) SelectAPITokenByHash(ctx context.Context, tokenHash string) (*st.APIToken, error) {
+	for _, t := range m.apiTokens {
+		if t.TokenHash == tokenHash {
+			rec := *t
+			return &rec, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ListAPITokens implements storage.
+func (m *mockStorage) ListAPITokens(ctx context.Context) ([]st.APIToken, error) {
+	var recs []st.APIToken
+	for _, t := range m.apiTokens {
+		recs = append(recs, *t)
+	}
+	return recs, nil
+}
+
+// TouchAPIToken implements storage.
+func (m *mockStorage) TouchAPIToken(ctx context.Context, id int, now time.Time, interval time.Duration) error {
+	t := m.apiTokens[id-1]
+	if t.LastUsedAt == nil || !t.LastUsedAt.After(now.Add(-interval)) {
+		t.LastUsedAt = &now
+	}
+	return nil
+}
+
+// RevokeAPIToken implements storage.
+func (m *mockStorage) RevokeAPIToken(ctx context.Context, id int) (bool, error) {
+	if id < 1 || id > len(m.apiTokens) || m.apiTokens[id-1].RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now().UTC()
+	m.apiTokens[id-1].RevokedAt = &now
+	return true, nil
+}
+
+func newAPITokenTestAuth(t *testing.T) (*Auth, *mockStorage) {
+	storage := &mockStorage{
+		selectEmployeeByIDFunc: func(ctx context.Context, id int) (*st.Employee, error) {
+			switch id {
+			case 4:
+				return &st.Employee{ID: 4, Status: "ACTIVE"}, nil
+			case 5:
+				return &st.Employee{ID: 5, Status: "INACTIVE"}, nil
+			}
+			return nil, sql.ErrNoRows
+		},
+	}
+	auth, err := NewAuth(storage)
+	require.NoError(t, err)
+	return auth, storage
+}
+
+func validAPIToken() NewAPIToken {
+	return NewAPIToken{Name: "payroll export", EmployeeID: 4, Role: "admin", Scopes: []string{"GET /admin/schedules"},
+		ExpiresAt: time.Now().Add(24 * time.Hour), CreatedBy: 1}
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	auth, storage := newAPITokenTestAuth(t)
+	ctx := context.Background()
+
+	id, token, err := auth.CreateAPIToken(ctx, validAPIToken())
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+	assert.True(t, strings.HasPrefix(token, APITokenPrefix))
+	rec := storage.apiTokens[0]
+	assert.NotEqual(t, token, rec.TokenHash, "only the hash is persisted")
+	assert.True(t, strings.HasPrefix(token, rec.Prefix))
+	assert.Len(t, rec.Prefix, len(APITokenPrefix)+6)
+
+	tests := []struct {
+		name    string
+		modify  func(*NewAPIToken)
+		wantErr error
+	}{
+		{"invalid role", func(n *NewAPIToken) { n.Role = "root" }, ErrInvalidAPITokenRole},
+		{"no scopes", func(n *NewAPIToken) { n.Scopes = nil }, ErrInvalidScope},
+		{"invalid scope", func(n *NewAPIToken) { n.Scopes = []string{"FETCH /admin"} }, ErrInvalidScope},
+		{"expired", func(n *NewAPIToken) { n.ExpiresAt = time.Now().Add(-time.Minute) }, ErrInvalidExpiration},
+		{"too long", func(n *NewAPIToken) { n.ExpiresAt = time.Now().Add(MaxAPITokenTTL + time.Hour) }, ErrInvalidExpiration},
+		{"unknown employee", func(n *NewAPIToken) { n.EmployeeID = 9 }, ErrNotFound},
+		{"inactive employee", func(n *NewAPIToken) { n.EmployeeID = 5 }, ErrAccountDeactivated},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n := validAPIToken()
+			tc.modify(&n)
+			_, _, err := auth.CreateAPIToken(ctx, n)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestVerifyAPIToken(t *testing.T) {
+	auth, storage := newAPITokenTestAuth(t)
+	ctx := context.Background()
+
+	_, token, err := auth.CreateAPIToken(ctx, validAPIToken())
+	require.NoError(t, err)
+	storage.apiTokens[0].EmployeeName = "Payroll"
+	storage.apiTokens[0].EmployeeRoleID = 2
+
+	identity, err := auth.VerifyAPIToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "4", identity.EmployeeId)
+	assert.Equal(t, "Payroll", identity.EmployeeName)
+	assert.Equal(t, "admin", identity.Role)
+	assert.Equal(t, 2, identity.PrimaryRole)
+	assert.Equal(t, 1, identity.APITokenID)
+	assert.Equal(t, []string{"GET /admin/schedules"}, identity.Scopes)
+	require.NotNil(t, storage.apiTokens[0].LastUsedAt)
+	lastUsed := *storage.apiTokens[0].LastUsedAt
+
+	// the use is recorded once per interval
+	_, err = auth.VerifyAPIToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, lastUsed, *storage.apiTokens[0].LastUsedAt)
+
+	_, err = auth.VerifyAPIToken(ctx, APITokenPrefix+"unknown")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	storage.apiTokens[0].EmployeeStatus = "INACTIVE"
+	_, err = auth.VerifyAPIToken(ctx, token)
+	assert.Equal(t, ErrAccountDeactivated, err)
+	storage.apiTokens[0].EmployeeStatus = "ACTIVE"
+
+	storage.apiTokens[0].ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	_, err = auth.VerifyAPIToken(ctx, token)
+	assert.Equal(t, ErrInvalidToken, err)
+	storage.apiTokens[0].ExpiresAt = time.Now().UTC().Add(time.Hour)
+
+	require.NoError(t, auth.RevokeAPIToken(ctx, 1))
+	_, err = auth.VerifyAPIToken(ctx, token)
+	assert.Equal(t, ErrInvalidToken, err)
+	rollbackCtx := context.WithValue(ctx, rollback{}, "...")
+	assert.Equal(t, ErrAPITokenNotFound, auth.RevokeAPIToken(rollbackCtx, 1))
+	assert.Equal(t, ErrAPITokenNotFound, auth.RevokeAPIToken(rollbackCtx, 9))
+
+	require.Len(t, storage.audits, 2)
+	assert.Equal(t, "create", storage.audits[0].Action)
+	assert.NotContains(t, string(*storage.audits[0].After), storage.apiTokens[0].TokenHash)
+	assert.Equal(t, "revoke", storage.audits[1].Action)
+}
+
+func TestValidateScope(t *testing.T) {
+	for _, scope := range []string{"/admin/schedules", "GET /admin/schedules/:id", "POST /admin/*", "/*"} {
+		assert.NoError(t, ValidateScope(scope), scope)
+	}
+	for _, scope := range []string{"", "admin", "FETCH /admin", "GET admin", "/admin*", "/admin/*/edits", "GET /a /b"} {
+		assert.ErrorIs(t, ValidateScope(scope), ErrInvalidScope, scope)
+	}
+}
+
+func TestIdentityCanAccess(t *testing.T) {
+	unrestricted := &Identity{}
+	assert.True(t, unrestricted.CanAccess("DELETE", "/admin/schedules/:id"))
+
+	identity := &Identity{Scopes: []string{"GET /admin/schedules", "/admin/shift-requests/*"}}
+	tests := []struct {
+		method string
+		route  string
+		want   bool
+	}{
+		{"GET", "/admin/schedules", true},
+		{"POST", "/admin/schedules", false},
+		{"GET", "/admin/schedules/:id", false},
+		{"GET", "/admin/shift-requests", true},
+		{"POST", "/admin/shift-requests/:id/approve", true},
+		{"GET", "/admin/shift-requests-archive", false},
+		{"GET", "/admin/employees", false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, identity.CanAccess(tc.method, tc.route), tc.method+" "+tc.route)
+	}
+
+	assert.False(t, (&Identity{Scopes: []string{}}).CanAccess("GET", "/admin/schedules"))
+}
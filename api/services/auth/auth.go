@@ -3,7 +3,10 @@ package auth
 import (
 	"context"
 	"errors"
+	"time"
 
+	"payd/services/audit"
+	"payd/services/webhook"
 	st "payd/storage"
 
 	kratos "github.com/ory/kratos-client-go"
@@ -17,21 +20,45 @@ var ErrTraitsKeyNotFound = errors.New("key not found")
 var ErrTraitsInvalidType = errors.New("invalid type")
 var ErrNotFound = errors.New("not found")
 var ErrNotYetActivatingAccount = errors.New("the user has not yet activated the account")
+var ErrAccountDeactivated = errors.New("the account has been deactivated")
+var ErrTokenRevoked = errors.New("token revoked")
 
 type storage interface {
-	CreateNewEmployee(ctx context.Context, name string, status string, roleId int) (int, error)
+	CreateNewEmployee(ctx context.Context, name string, status string, roleId, locationId int) (int, error)
+	UpdateEmployeeIdentityID(ctx context.Context, id int, identityId string) error
 	SelectEmployeeByID(ctx context.Context, id int) (*st.Employee, error)
+	CreateRefreshToken(ctx context.Context, t st.RefreshToken) error
+	LockRefreshTokenByHash(ctx context.Context, tokenHash string) (*st.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) (int64, error)
+	RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string) (int64, error)
+	RevokeRefreshTokensByEmployeeID(ctx context.Context, employeeId int) (int64, error)
+	CreateAPIToken(ctx context.Context, t st.APIToken) (int, error)
+	SelectAPITokenByHash(ctx context.Context, tokenHash string) (*st.APIToken, error)
+	ListAPITokens(ctx context.Context) ([]st.APIToken, error)
+	TouchAPIToken(ctx context.Context, id int, now time.Time, interval time.Duration) error
+	RevokeAPIToken(ctx context.Context, id int) (bool, error)
+
+	audit.Recorder
+	webhook.Publisher
 
 	NewTransacton(ctx context.Context) (context.Context, error)
-	Commit(ctx context.Context) error
-	Rollback(ctx context.Context) error
+	Finish(ctx context.Context, err *error)
 }
 
 type AuthInterface interface {
 	Login(ctx context.Context, username, password string) (*Identity, error)
-	RegisterNewUser(ctx context.Context, email string, primaryRole int, roleAdmin bool) (string, error)
+	RegisterNewUser(ctx context.Context, email string, primaryRole, locationId int, roleAdmin bool) (string, error)
 	ActivateNewUser(ctx context.Context, userId string, name string, password string) error
 	VerifySignatureJWT(tokenStr string) (*Identity, error)
+	VerifyAPIToken(ctx context.Context, token string) (*Identity, error)
+	IssueRefreshToken(ctx context.Context, identity *Identity) (string, error)
+	RefreshSession(ctx context.Context, token string) (*Identity, string, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	RevokeAccessToken(ctx context.Context, tokenStr string) error
+	RevokeAllSessions(ctx context.Context, employeeId int) error
+	GetIdentityRole(ctx context.Context, identityId string) (string, error)
+	JWKS() JWKS
 }
 
 type Auth struct {
@@ -39,6 +66,8 @@ type Auth struct {
 	kratosPublic *kratos.APIClient
 	storage      storage
 	jwtSecret    []byte
+	keys         *KeySet
+	revocations  *RevocationList
 }
 
 type AuthOption func(*Auth) error
@@ -56,7 +85,7 @@ func NewAuth(storage storage, opts ...AuthOption) (*Auth, error) {
 
 func (a *Auth) BootstrapAdminAccount(email, name, password string) error {
 	ctx := context.Background()
-	id, err := a.RegisterNewUser(ctx, email, 0, true)
+	id, err := a.RegisterNewUser(ctx, email, 0, st.DefaultLocationID, true)
 	if err == ErrAlreadyExists {
 		return nil
 	}
@@ -87,6 +116,31 @@ func WithJWTSecret(secret string) AuthOption {
 	}
 }
 
+// WithKeySet signs the access tokens with the asymmetric signing key of ks,
+// a JWT secret configured along only verifies the HS256 tokens issued before
+func WithKeySet(ks *KeySet) AuthOption {
+	return func(a *Auth) error {
+		a.keys = ks
+		return nil
+	}
+}
+
+// WithRevocationList enables the revocation of access tokens before they expire
+func WithRevocationList(rl *RevocationList) AuthOption {
+	return func(a *Auth) error {
+		a.revocations = rl
+		return nil
+	}
+}
+
+// JWKS returns the public keys verifying the access tokens, empty when they are signed with the JWT secret
+func (a *Auth) JWKS() JWKS {
+	if a.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return a.keys.JWKS()
+}
+
 func initKratos(url string) *kratos.APIClient {
 	config := kratos.NewConfiguration()
 	config.Servers = []kratos.ServerConfiguration{
@@ -16,8 +16,21 @@ type Identity struct {
 	EmployeeName string // db employee name
 	Role         string // privilege-based(admin/employee)
 	PrimaryRole  int    // responsibility-based from db role_id
+	LocationID   int    // db location_id of the employee
+	// the locations the identity may access, nil for every location, see allowedLocations
+	LocationIDs []int
+
+	// set from the claims of a verified access token
+	TokenID   string // jti
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// set for the API tokens, see VerifyAPIToken
+	APITokenID int
+	Scopes     []string
 
 	jwtSecret []byte
+	keys      *KeySet // signs instead of jwtSecret when set
 }
 
 // kratos traits schema
@@ -27,31 +40,79 @@ func (i *Identity) GetTraits() map[string]interface{} {
 		"role":         i.Role,
 		"primary_role": i.PrimaryRole,
 		"employee_id":  i.EmployeeId,
+		"location_id":  i.LocationID,
+	}
+}
+
+// CanAccessLocation reports whether the identity may access the resources of the location
+func (i *Identity) CanAccessLocation(locationId int) bool {
+	return st.InLocations(i.LocationIDs, locationId)
+}
+
+// allowedLocations returns nil for admins, who access every location,
+// otherwise the location of the employee and the ones they manage
+func allowedLocations(role string, locationId int, managed []int64) []int {
+	if role == "admin" {
+		return nil
+	}
+	ids := []int{locationId}
+	for _, id := range managed {
+		if int(id) != locationId {
+			ids = append(ids, int(id))
+		}
 	}
+	return ids
 }
 
 func (i *Identity) GenerateJWT(expiration time.Duration) (string, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
+		"jti":           jti,
+		"iat":           now.Unix(),
 		"sub":           i.ID,
 		"email":         i.Email,
 		"employee_id":   i.EmployeeId,
 		"employee_name": i.EmployeeName,
 		"role":          i.Role,
 		"primary_role":  i.PrimaryRole,
-		"exp":           time.Now().Add(expiration).Unix(),
+		"location_id":   i.LocationID,
+		"exp":           now.Add(expiration).Unix(),
+	}
+	if i.LocationIDs != nil {
+		claims["location_ids"] = i.LocationIDs
 	}
 
+	if i.keys != nil {
+		return i.keys.sign(claims)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(i.jwtSecret)
 }
 
 func (a *Auth) VerifySignatureJWT(tokenStr string) (*Identity, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+	identity, err := a.parseJWT(tokenStr)
+	if err != nil || identity == nil {
+		return nil, err
+	}
+	if time.Now().After(identity.ExpiresAt) {
+		return nil, errors.New("token expired")
+	}
+	if a.revocations != nil {
+		employeeId, _ := strconv.Atoi(identity.EmployeeId)
+		if a.revocations.IsRevoked(identity.TokenID, employeeId, identity.IssuedAt) {
+			return nil, ErrTokenRevoked
 		}
-		return a.jwtSecret, nil
-	})
+	}
+	return identity, nil
+}
+
+// parseJWT verifies the token and returns the identity of its claims
+func (a *Auth) parseJWT(tokenStr string, opts ...jwt.ParserOption) (*Identity, error) {
+	token, err := jwt.Parse(tokenStr, a.verificationKey, opts...)
 	if err != nil || !token.Valid {
 		return nil, err
 	}
@@ -61,29 +122,58 @@ func (a *Auth) VerifySignatureJWT(tokenStr string) (*Identity, error) {
 		return nil, errors.New("invalid claims type")
 	}
 
-	exp := int64(claims["exp"].(float64))
-	if time.Now().Unix() > exp {
-		return nil, errors.New("token expired")
-	}
-
-	return &Identity{
+	identity := &Identity{
 		ID:           claims["sub"].(string),
 		Email:        claims["email"].(string),
 		EmployeeId:   claims["employee_id"].(string),
 		EmployeeName: claims["employee_name"].(string),
 		Role:         claims["role"].(string),
 		PrimaryRole:  int(claims["primary_role"].(float64)),
-	}, nil
+		ExpiresAt:    time.Unix(int64(claims["exp"].(float64)), 0),
+	}
+	// tokens issued before revocation support have neither jti nor iat
+	if jti, ok := claims["jti"].(string); ok {
+		identity.TokenID = jti
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		identity.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	// tokens issued before locations belong to the seeded location
+	identity.LocationID = st.DefaultLocationID
+	if locationId, ok := claims["location_id"].(float64); ok {
+		identity.LocationID = int(locationId)
+	}
+	if locationIds, ok := claims["location_ids"].([]interface{}); ok {
+		identity.LocationIDs = make([]int, 0, len(locationIds))
+		for _, id := range locationIds {
+			if id, ok := id.(float64); ok {
+				identity.LocationIDs = append(identity.LocationIDs, int(id))
+			}
+		}
+	} else {
+		identity.LocationIDs = allowedLocations(identity.Role, identity.LocationID, nil)
+	}
+	return identity, nil
+}
+
+// verificationKey picks the key of the token, HS256 tokens are only accepted while a secret is configured,
+// which lets the tokens signed before switching to a key set expire instead of logging everyone out
+func (a *Auth) verificationKey(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(a.jwtSecret) == 0 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return a.jwtSecret, nil
+	}
+	if a.keys == nil {
+		return nil, errors.New("unexpected signing method")
+	}
+	return a.keys.verificationKey(t)
 }
 
 // build identity struct based on kratos traits map and employee table db and set jwt secret
 func (a *Auth) newIdentityStruct(kratosId string, traits map[string]interface{}, employee *st.Employee) *Identity {
-	identity := &Identity{jwtSecret: a.jwtSecret}
-	if employee != nil {
-		identity.EmployeeId = strconv.Itoa(employee.ID)
-		identity.EmployeeName = employee.Name
-		identity.PrimaryRole = employee.PrimaryRole
-	}
+	identity := &Identity{jwtSecret: a.jwtSecret, keys: a.keys}
 	identity.ID = kratosId
 	email, err := castTrait[string](traits, "email")
 	if err == nil {
@@ -93,5 +183,12 @@ func (a *Auth) newIdentityStruct(kratosId string, traits map[string]interface{},
 	if err == nil {
 		identity.Role = role
 	}
+	if employee != nil {
+		identity.EmployeeId = strconv.Itoa(employee.ID)
+		identity.EmployeeName = employee.Name
+		identity.PrimaryRole = employee.PrimaryRole
+		identity.LocationID = employee.LocationID
+		identity.LocationIDs = allowedLocations(identity.Role, employee.LocationID, employee.ManagedLocationIDs)
+	}
 	return identity
 }
@@ -55,3 +55,51 @@ func TestGenerateAndVerifyJWT(t *testing.T) {
 	assert.Error(t, err)
 
 }
+
+func TestLocationClaims(t *testing.T) {
+	secret := []byte("supersecretkey")
+	auth := &Auth{jwtSecret: secret}
+
+	t.Run("branch manager", func(t *testing.T) {
+		identity := &Identity{ID: "user1", EmployeeId: "4", Role: "employee", LocationID: 2,
+			LocationIDs: allowedLocations("employee", 2, []int64{3, 2}), jwtSecret: secret}
+		assert.Equal(t, []int{2, 3}, identity.LocationIDs)
+
+		tokenStr, err := identity.GenerateJWT(time.Minute)
+		assert.NoError(t, err)
+		verified, err := auth.VerifySignatureJWT(tokenStr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, verified.LocationID)
+		assert.Equal(t, []int{2, 3}, verified.LocationIDs)
+		assert.True(t, verified.CanAccessLocation(3))
+		assert.False(t, verified.CanAccessLocation(1))
+	})
+
+	t.Run("admin accesses every location", func(t *testing.T) {
+		identity := &Identity{ID: "user2", EmployeeId: "1", Role: "admin", LocationID: 2,
+			LocationIDs: allowedLocations("admin", 2, nil), jwtSecret: secret}
+		tokenStr, err := identity.GenerateJWT(time.Minute)
+		assert.NoError(t, err)
+		verified, err := auth.VerifySignatureJWT(tokenStr)
+		assert.NoError(t, err)
+		assert.Nil(t, verified.LocationIDs)
+		assert.True(t, verified.CanAccessLocation(7))
+	})
+
+	t.Run("token issued before locations", func(t *testing.T) {
+		tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":           "user3",
+			"email":         "user3@example.com",
+			"employee_id":   "3",
+			"employee_name": "user3",
+			"role":          "employee",
+			"primary_role":  1,
+			"exp":           time.Now().Add(time.Minute).Unix(),
+		}).SignedString(secret)
+		assert.NoError(t, err)
+		verified, err := auth.VerifySignatureJWT(tokenStr)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, verified.LocationID)
+		assert.Equal(t, []int{1}, verified.LocationIDs)
+	})
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, kid, typ string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600))
+}
+
+func writeRSAKey(t *testing.T, dir, kid string, bits int) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return key
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return key
+}
+
+func base64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func keyTestIdentity(a *Auth) *Identity {
+	return a.newIdentityStruct("userid", map[string]interface{}{"email": "abc@mail.com", "role": "employee"}, nil)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2025-01", 2048)
+	writeEd25519Key(t, dir, "2025-06")
+
+	ks, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06", ks.signing.kid, "the greatest kid signs by default")
+	assert.Equal(t, jwt.SigningMethodEdDSA, ks.signing.method)
+
+	ks, err = LoadKeySet(dir, "2025-01")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", ks.signing.kid)
+	assert.Equal(t, jwt.SigningMethodRS256, ks.signing.method)
+
+	_, err = LoadKeySet(dir, "unknown")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+	_, err = LoadKeySet(t.TempDir(), "")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	weak := t.TempDir()
+	writeRSAKey(t, weak, "weak", 1024)
+	_, err = LoadKeySet(weak, "")
+	assert.Error(t, err)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := writeRSAKey(t, dir, "2025-01", 2048)
+
+	oldKeys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	oldAuth, err := NewAuth(&mockStorage{}, WithKeySet(oldKeys))
+	require.NoError(t, err)
+	oldToken, err := keyTestIdentity(oldAuth).GenerateJWT(time.Minute)
+	require.NoError(t, err)
+
+	// the old key is retired to a public key, the new one signs
+	der, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	require.NoError(t, err)
+	writePEM(t, dir, "2025-01", "PUBLIC KEY", der)
+	writeEd25519Key(t, dir, "2025-06")
+
+	newKeys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	newAuth, err := NewAuth(&mockStorage{}, WithKeySet(newKeys))
+	require.NoError(t, err)
+	newToken, err := keyTestIdentity(newAuth).GenerateJWT(time.Minute)
+	require.NoError(t, err)
+
+	identity, err := newAuth.VerifySignatureJWT(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "abc@mail.com", identity.Email)
+	_, err = newAuth.VerifySignatureJWT(newToken)
+	assert.NoError(t, err)
+
+	// the old instances don't know the new key yet
+	_, err = oldAuth.VerifySignatureJWT(newToken)
+	assert.Error(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+}
+
+func TestVerifySignatureJWTSigningMethods(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa", 2048)
+	keys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	hsAuth, err := NewAuth(&mockStorage{}, WithJWTSecret("secret"))
+	require.NoError(t, err)
+	hsToken, err := keyTestIdentity(hsAuth).GenerateJWT(time.Minute)
+	require.NoError(t, err)
+
+	// HS256 tokens are only accepted while the secret is configured
+	migrating, err := NewAuth(&mockStorage{}, WithJWTSecret("secret"), WithKeySet(keys))
+	require.NoError(t, err)
+	_, err = migrating.VerifySignatureJWT(hsToken)
+	assert.NoError(t, err)
+
+	keysOnly, err := NewAuth(&mockStorage{}, WithKeySet(keys))
+	require.NoError(t, err)
+	_, err = keysOnly.VerifySignatureJWT(hsToken)
+	assert.Error(t, err)
+
+	// a token claiming a known kid with another algorithm is rejected
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "userid", "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = "rsa"
+	forgedStr, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = keysOnly.VerifySignatureJWT(forgedStr)
+	assert.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "a-rsa", 2048)
+	edKey := writeEd25519Key(t, dir, "b-ed")
+	keys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	auth, err := NewAuth(&mockStorage{}, WithKeySet(keys))
+	require.NoError(t, err)
+	jwks := auth.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, JWK{Kty: "RSA", Kid: "a-rsa", Use: "sig", Alg: "RS256",
+		N: base64URL(rsaKey.N.Bytes()), E: "AQAB"}, jwks.Keys[0])
+	assert.Equal(t, JWK{Kty: "OKP", Kid: "b-ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519",
+		X: base64URL(edKey.Public().(ed25519.PublicKey))}, jwks.Keys[1])
+
+	secretOnly, err := NewAuth(&mockStorage{}, WithJWTSecret("secret"))
+	require.NoError(t, err)
+	assert.Empty(t, secretOnly.JWKS().Keys)
+}
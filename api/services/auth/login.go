@@ -62,5 +62,9 @@ func (a *Auth) Login(ctx context.Context, username, password string) (*Identity,
 	if err != nil {
 		return nil, err
 	}
+	// employees deactivated before their identity was linked still have an active kratos identity
+	if employee.Status == employeeInactive {
+		return nil, ErrAccountDeactivated
+	}
 	return a.newIdentityStruct(identity.Id, traits, employee), nil
 }
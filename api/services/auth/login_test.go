@@ -203,6 +203,26 @@ var loginScenarios = []struct {
 		},
 		expectedError: ErrNotYetActivatingAccount,
 	},
+	{
+		name: "deactivated employee",
+		funcParams: loginFuncParam{
+			username: "abc@mail.com",
+			password: "password123",
+		},
+		expectedApiRequests: loginApiRequests,
+		mockApiResponses:    loginApiResponses,
+		mockStorage: &mockStorage{
+			selectEmployeeByIDFunc: func(ctx context.Context, id int) (*st.Employee, error) {
+				return &st.Employee{
+					ID:          4,
+					Name:        "name",
+					PrimaryRole: 7,
+					Status:      "INACTIVE",
+				}, nil
+			},
+		},
+		expectedError: ErrAccountDeactivated,
+	},
 	{
 		name: "db error",
 		funcParams: loginFuncParam{
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	st "payd/storage"
+	"payd/util"
+	"strconv"
+	"time"
+)
+
+// refresh token lifetimes, every rotation extends the idle expiration up to the absolute one of the login
+const (
+	RefreshTokenIdleTTL     = 7 * 24 * time.Hour
+	RefreshTokenAbsoluteTTL = 30 * 24 * time.Hour
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// IssueRefreshToken starts a new token family for the logged in identity
+func (a *Auth) IssueRefreshToken(ctx context.Context, identity *Identity) (string, error) {
+	employeeId, err := strconv.Atoi(identity.EmployeeId)
+	if err != nil {
+		return "", err
+	}
+	familyId, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	return a.createRefreshToken(ctx, st.RefreshToken{
+		FamilyID:        familyId,
+		IdentityID:      identity.ID,
+		EmployeeID:      employeeId,
+		Email:           identity.Email,
+		Role:            identity.Role,
+		FamilyExpiresAt: now.Add(RefreshTokenAbsoluteTTL),
+	}, now)
+}
+
+// RefreshSession exchanges the refresh token for a new one of the same family and returns the identity to
+// sign the new access token. Presenting an already rotated token revokes the whole family, since either
+// the legitimate client or an attacker holds a stolen copy.
+func (a *Auth) RefreshSession(ctx context.Context, token string) (*Identity, string, error) {
+	identity, newToken, familyId, err := a.rotateRefreshToken(ctx, hashToken(token))
+	if err == ErrRefreshTokenReused || err == ErrAccountDeactivated {
+		// the rotation transaction is rolled back, revoke outside of it
+		if _, rvErr := a.storage.RevokeRefreshTokenFamily(ctx, familyId); rvErr != nil {
+			util.Log().WithContext(ctx).WithError(rvErr).Error("storage revoke refresh token family")
+		}
+		if err == ErrRefreshTokenReused {
+			util.Log().WithContext(ctx).WithField("family_id", familyId).Warn("refresh token reuse detected, family revoked")
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return identity, newToken, nil
+}
+
+func (a *Auth) rotateRefreshToken(ctx context.Context, tokenHash string) (identity *Identity, newToken string, familyId string, err error) {
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("failed to start db transaction")
+		return nil, "", "", err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	rec, err := a.storage.LockRefreshTokenByHash(tctx, tokenHash)
+	if err == sql.ErrNoRows {
+		return nil, "", "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		util.Log().WithContext(tctx).WithError(err).Error("storage lock refresh token")
+		return nil, "", "", err
+	}
+	familyId = rec.FamilyID
+	if rec.RevokedAt != nil {
+		return nil, "", familyId, ErrInvalidRefreshToken
+	}
+	if rec.UsedAt != nil {
+		return nil, "", familyId, ErrRefreshTokenReused
+	}
+	now := time.Now().UTC()
+	if !now.Before(rec.ExpiresAt) || !now.Before(rec.FamilyExpiresAt) {
+		return nil, "", familyId, ErrInvalidRefreshToken
+	}
+
+	employee, err := a.storage.SelectEmployeeByID(tctx, rec.EmployeeID)
+	if err != nil {
+		util.Log().WithContext(tctx).WithError(err).Error("storage select employee")
+		return nil, "", familyId, err
+	}
+	if employee.Status == employeeInactive {
+		return nil, "", familyId, ErrAccountDeactivated
+	}
+
+	if err = a.storage.MarkRefreshTokenUsed(tctx, rec.ID); err != nil {
+		util.Log().WithContext(tctx).WithError(err).Error("storage mark refresh token used")
+		return nil, "", familyId, err
+	}
+	newToken, err = a.createRefreshToken(tctx, *rec, now)
+	if err != nil {
+		return nil, "", familyId, err
+	}
+
+	identity = a.newIdentityStruct(rec.IdentityID, map[string]interface{}{
+		"email": rec.Email,
+		"role":  rec.Role,
+	}, employee)
+	return identity, newToken, familyId, nil
+}
+
+// RevokeRefreshToken revokes the family of the token, unknown tokens are ignored
+func (a *Auth) RevokeRefreshToken(ctx context.Context, token string) error {
+	_, err := a.storage.RevokeRefreshTokenFamilyByHash(ctx, hashToken(token))
+	if err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("storage revoke refresh token")
+	}
+	return err
+}
+
+// createRefreshToken stores a new token of rec's family, the idle expiration never exceeds the family's
+func (a *Auth) createRefreshToken(ctx context.Context, rec st.RefreshToken, now time.Time) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	rec.TokenHash = hashToken(token)
+	rec.ExpiresAt = now.Add(RefreshTokenIdleTTL)
+	if rec.ExpiresAt.After(rec.FamilyExpiresAt) {
+		rec.ExpiresAt = rec.FamilyExpiresAt
+	}
+	if err := a.storage.CreateRefreshToken(ctx, rec); err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("storage create refresh token")
+		return "", err
+	}
+	return token, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// only the hash is persisted, a leaked table can't be replayed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
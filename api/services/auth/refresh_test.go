@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateRefreshToken implements storage.
+func (m *mockStorage) CreateRefreshToken(ctx context.Context, t st.RefreshToken) error {
+	t.ID = len(m.refreshTokens) + 1
+	m.refreshTokens = append(m.refreshTokens, &t)
+	return nil
+}
+
+// LockRefreshTokenByHash implements storage.
+func (m *mockStorage) LockRefreshTokenByHash(ctx context.Context, tokenHash string) (*st.RefreshToken, error) {
+	for _, t := range m.refreshTokens {
+		if t.TokenHash == tokenHash {
+			rec := *t
+			return &rec, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// MarkRefreshTokenUsed implements storage.
+func (m *mockStorage) MarkRefreshTokenUsed(ctx context.Context, id int) error {
+	now := time.Now().UTC()
+	m.refreshTokens[id-1].UsedAt = &now
+	return nil
+}
+
+// RevokeRefreshTokenFamily implements storage.
+func (m *mockStorage) RevokeRefreshTokenFamily(ctx context.Context, familyId string) (int64, error) {
+	var n int64
+	now := time.Now().UTC()
+	for _, t := range m.refreshTokens {
+		if t.FamilyID == familyId && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RevokeRefreshTokenFamilyByHash implements storage.
+func (m *mockStorage) RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string) (int64, error) {
+	for _, t := range m.refreshTokens {
+		if t.TokenHash == tokenHash {
+			return m.RevokeRefreshTokenFamily(ctx, t.FamilyID)
+		}
+	}
+	return 0, nil
+}
+
+func newRefreshTestAuth(t *testing.T, status string) (*Auth, *mockStorage) {
+	storage := &mockStorage{
+		selectEmployeeByIDFunc: func(ctx context.Context, id int) (*st.Employee, error) {
+			return &st.Employee{ID: id, Name: "name", Status: status, PrimaryRole: 7}, nil
+		},
+	}
+	auth, err := NewAuth(storage, WithJWTSecret("secret"))
+	assert.NoError(t, err)
+	return auth, storage
+}
+
+var refreshTestIdentity = &Identity{ID: "userid", Email: "abc@mail.com", Role: "employee", EmployeeId: "4"}
+
+func TestRefreshSessionRotates(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "ACTIVE")
+	ctx := context.Background()
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	assert.Len(t, storage.refreshTokens, 1)
+	assert.NotEqual(t, token, storage.refreshTokens[0].TokenHash, "only the hash is persisted")
+
+	identity, next, err := auth.RefreshSession(ctx, token)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, next)
+	assert.Equal(t, "userid", identity.ID)
+	assert.Equal(t, "abc@mail.com", identity.Email)
+	assert.Equal(t, "employee", identity.Role)
+	assert.Equal(t, "4", identity.EmployeeId)
+	assert.Equal(t, 7, identity.PrimaryRole)
+	_, err = identity.GenerateJWT(time.Minute)
+	assert.NoError(t, err)
+
+	assert.Len(t, storage.refreshTokens, 2)
+	assert.NotNil(t, storage.refreshTokens[0].UsedAt)
+	assert.Equal(t, storage.refreshTokens[0].FamilyID, storage.refreshTokens[1].FamilyID)
+	assert.Equal(t, storage.refreshTokens[0].FamilyExpiresAt, storage.refreshTokens[1].FamilyExpiresAt)
+
+	_, _, err = auth.RefreshSession(ctx, next)
+	assert.NoError(t, err)
+}
+
+func TestRefreshSessionReuseRevokesFamily(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "ACTIVE")
+	ctx := context.Background()
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	_, next, err := auth.RefreshSession(ctx, token)
+	assert.NoError(t, err)
+
+	_, _, err = auth.RefreshSession(context.WithValue(ctx, rollback{}, "..."), token)
+	assert.Equal(t, ErrRefreshTokenReused, err)
+	for _, rec := range storage.refreshTokens {
+		assert.NotNil(t, rec.RevokedAt)
+	}
+
+	_, _, err = auth.RefreshSession(context.WithValue(ctx, rollback{}, "..."), next)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+}
+
+func TestRefreshSessionInvalid(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "ACTIVE")
+	ctx := context.WithValue(context.Background(), rollback{}, "...")
+
+	_, _, err := auth.RefreshSession(ctx, "unknown")
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	storage.refreshTokens[0].ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	_, _, err = auth.RefreshSession(ctx, token)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	assert.Nil(t, storage.refreshTokens[0].RevokedAt, "an expired token doesn't revoke its family")
+}
+
+func TestRefreshSessionDeactivated(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "INACTIVE")
+	ctx := context.WithValue(context.Background(), rollback{}, "...")
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	_, _, err = auth.RefreshSession(ctx, token)
+	assert.Equal(t, ErrAccountDeactivated, err)
+	assert.NotNil(t, storage.refreshTokens[0].RevokedAt)
+}
+
+func TestRefreshTokenIdleExpirationCapped(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "ACTIVE")
+	ctx := context.Background()
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	familyExpiresAt := time.Now().UTC().Add(time.Hour)
+	storage.refreshTokens[0].FamilyExpiresAt = familyExpiresAt
+
+	_, _, err = auth.RefreshSession(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, familyExpiresAt, storage.refreshTokens[1].ExpiresAt)
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	auth, storage := newRefreshTestAuth(t, "ACTIVE")
+	ctx := context.Background()
+
+	token, err := auth.IssueRefreshToken(ctx, refreshTestIdentity)
+	assert.NoError(t, err)
+	_, next, err := auth.RefreshSession(ctx, token)
+	assert.NoError(t, err)
+
+	assert.NoError(t, auth.RevokeRefreshToken(ctx, next))
+	for _, rec := range storage.refreshTokens {
+		assert.NotNil(t, rec.RevokedAt)
+	}
+	_, _, err = auth.RefreshSession(context.WithValue(ctx, rollback{}, "..."), next)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	assert.NoError(t, auth.RevokeRefreshToken(ctx, "unknown"))
+}
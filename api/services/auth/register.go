@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
 	"payd/util"
 	"strconv"
 
@@ -13,11 +16,14 @@ import (
 var inactiveState = "inactive"
 var activeState = "active"
 
+const employeeInactive = "INACTIVE"
+
 // register a new user in an inactive state
 // later, the user will activate the account and fill in the password and other information
 // roleAdmin = privilege-based meaning refers to the level of access, permissions, or authority
 // primaryRole = responsibility-based meaning refers to the main job or task someone is assigned to do
-func (a *Auth) RegisterNewUser(ctx context.Context, email string, primaryRole int, roleAdmin bool) (string, error) {
+// locationId = the location the employee works at
+func (a *Auth) RegisterNewUser(ctx context.Context, email string, primaryRole, locationId int, roleAdmin bool) (string, error) {
 	role := "employee"
 	if roleAdmin {
 		role = "admin"
@@ -26,6 +32,7 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, primaryRole in
 		"email":        email,
 		"role":         role,
 		"primary_role": primaryRole,
+		"location_id":  locationId,
 	}
 	pass := "123456"
 	identity, httpResp, err := a.kratosAdmin.IdentityAPI.CreateIdentity(ctx).
@@ -55,6 +62,16 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, primaryRole in
 		util.Log().WithContext(ctx).WithError(err).Error("unhandled error")
 		return "", err
 	}
+	// the identity exists whatever happens to the audit log, failing the registration would only make it retried
+	err = audit.Record(ctx, a.storage, "register", audit.EntityIdentity, 0, nil, map[string]interface{}{
+		"IdentityID": identity.Id,
+		"Email":      email,
+		"Role":       role,
+		"LocationID": locationId,
+	})
+	if err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("audit registration")
+	}
 	return identity.Id, nil
 }
 
@@ -79,6 +96,10 @@ func (a *Auth) GetIdentity(ctx context.Context, userId string) (*Identity, error
 		// should be considered as internal error
 		return nil, fmt.Errorf("expecting map traits")
 	}
+	// an inactive identity linked to an employee has been deactivated, not yet activated
+	if employeeId, _ := castTrait[string](traits, "employee_id"); employeeId != "" {
+		return nil, ErrAlreadyExists
+	}
 	primaryRole, err := castTrait[float64](traits, "primary_role")
 	if err != nil {
 		return nil, err
@@ -91,15 +112,21 @@ func (a *Auth) GetIdentity(ctx context.Context, userId string) (*Identity, error
 	if err != nil {
 		return nil, err
 	}
+	// identities registered before locations belong to the seeded location
+	locationId := float64(st.DefaultLocationID)
+	if id, err := castTrait[float64](traits, "location_id"); err == nil {
+		locationId = id
+	}
 	return &Identity{
 		ID:          userId,
 		Email:       email,
 		PrimaryRole: int(primaryRole),
+		LocationID:  int(locationId),
 		Role:        role,
 	}, nil
 }
 
-func (a *Auth) ActivateNewUser(ctx context.Context, userId, name, password string) error {
+func (a *Auth) ActivateNewUser(ctx context.Context, userId, name, password string) (err error) {
 	identity, err := a.GetIdentity(ctx, userId)
 	if err != nil {
 		return err
@@ -109,12 +136,37 @@ func (a *Auth) ActivateNewUser(ctx context.Context, userId, name, password strin
 		util.Log().WithContext(ctx).WithError(err).Error("failed to start db transaction")
 		return err
 	}
-	defer a.dbTransactions(tctx, err)
-	employeeId, err := a.storage.CreateNewEmployee(tctx, name, "ACTIVE", int(identity.PrimaryRole))
+	defer a.storage.Finish(tctx, &err)
+	employeeId, err := a.storage.CreateNewEmployee(tctx, name, "ACTIVE", int(identity.PrimaryRole), identity.LocationID)
 	if err != nil {
 		util.Log().WithContext(tctx).WithError(err).Error("storage create new employee")
 		return err
 	}
+	err = a.storage.UpdateEmployeeIdentityID(tctx, employeeId, userId)
+	if err != nil {
+		util.Log().WithContext(tctx).WithError(err).Error("storage link employee identity")
+		return err
+	}
+	err = audit.Record(tctx, a.storage, "activate", audit.EntityEmployee, employeeId, nil, map[string]interface{}{
+		"Name":        name,
+		"Status":      "ACTIVE",
+		"PrimaryRole": identity.PrimaryRole,
+		"LocationID":  identity.LocationID,
+		"IdentityID":  userId,
+	})
+	if err != nil {
+		return err
+	}
+	err = webhook.Publish(tctx, a.storage, webhook.EmployeeActivated, employeeId, webhook.EmployeePayload{
+		ID:          employeeId,
+		Name:        name,
+		Status:      "ACTIVE",
+		PrimaryRole: int(identity.PrimaryRole),
+		LocationID:  identity.LocationID,
+	})
+	if err != nil {
+		return err
+	}
 	traits := identity.GetTraits()
 	traits["employee_id"] = strconv.Itoa(employeeId)
 
@@ -142,20 +194,48 @@ func (a *Auth) ActivateNewUser(ctx context.Context, userId, name, password strin
 	return nil
 }
 
-// defer only after calling storage.NewTransacton
-func (a *Auth) dbTransactions(ctx context.Context, err error) {
+// SetIdentityState activates or deactivates the kratos identity, an inactive identity can't log in
+func (a *Auth) SetIdentityState(ctx context.Context, identityId string, active bool) error {
+	state := inactiveState
+	if active {
+		state = activeState
+	}
+	_, httpResp, err := a.kratosAdmin.IdentityAPI.PatchIdentity(ctx, identityId).
+		JsonPatch([]kratos.JsonPatch{{Op: "replace", Path: "/state", Value: state}}).
+		Execute()
 	if err != nil {
-		util.Log().WithContext(ctx).WithError(err).Error("rollback db trx")
-		err = a.storage.Rollback(ctx)
-		if err != nil {
-			util.Log().WithContext(ctx).WithError(err).Error("failed rollback")
+		if httpResp == nil {
+			return err
 		}
-		return
+		switch httpResp.StatusCode {
+		case 404:
+			return ErrNotFound
+		}
+		util.Log().WithContext(ctx).WithError(err).Error("unhandled error")
+		return err
 	}
-	err = a.storage.Commit(ctx)
+	return nil
+}
+
+// GetIdentityRole returns the role trait of the kratos identity, admin or employee
+func (a *Auth) GetIdentityRole(ctx context.Context, identityId string) (string, error) {
+	identity, httpResp, err := a.kratosAdmin.IdentityAPI.GetIdentity(ctx, identityId).Execute()
 	if err != nil {
-		util.Log().WithContext(ctx).WithError(err).Error("failed commit")
+		if httpResp == nil {
+			return "", err
+		}
+		switch httpResp.StatusCode {
+		case 404:
+			return "", ErrNotFound
+		}
+		util.Log().WithContext(ctx).WithError(err).Error("unhandled error")
+		return "", err
+	}
+	traits, ok := identity.Traits.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expecting map traits")
 	}
+	return castTrait[string](traits, "role")
 }
 
 func castTrait[T any](m map[string]interface{}, key string) (T, error) {
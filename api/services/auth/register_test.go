@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"payd/services/audit"
+	"payd/services/webhook"
 	st "payd/storage"
 	"strings"
 	"testing"
@@ -18,6 +20,7 @@ import (
 type registerNewUserScenarioFuncParam struct {
 	email       string
 	primaryRole int
+	locationId  int
 	roleAdmin   bool
 }
 
@@ -28,6 +31,7 @@ func scsRegisterNewUserAPIReqBody() kratos.CreateIdentityBody {
 		"email":        "abc@gmail.com",
 		"role":         "admin",
 		"primary_role": 1,
+		"location_id":  1,
 	}
 	requestBody := kratos.CreateIdentityBody{
 		Credentials: &kratos.IdentityWithCredentials{
@@ -64,7 +68,7 @@ var registerNewUserScenario = []struct {
 		name:       "success register admin",
 		expectedId: "10",
 		funcParams: registerNewUserScenarioFuncParam{
-			"abc@gmail.com", 1, true,
+			"abc@gmail.com", 1, 1, true,
 		},
 		mockApiResponse: mockApiResponse{
 			body:       scsRegisterNewUserAPIRespBody(),
@@ -80,7 +84,7 @@ var registerNewUserScenario = []struct {
 		name:       "success register employee",
 		expectedId: "10",
 		funcParams: registerNewUserScenarioFuncParam{
-			"abc@gmail.com", 1, false,
+			"abc@gmail.com", 1, 1, false,
 		},
 		mockApiResponse: mockApiResponse{
 			body: kratos.Identity{
@@ -103,7 +107,7 @@ var registerNewUserScenario = []struct {
 		name:          "already exists",
 		expectedError: ErrAlreadyExists,
 		funcParams: registerNewUserScenarioFuncParam{
-			"abc@gmail.com", 1, true,
+			"abc@gmail.com", 1, 1, true,
 		},
 		mockApiResponse: mockApiResponse{
 			statusCode: 409,
@@ -117,7 +121,7 @@ var registerNewUserScenario = []struct {
 		name:          "invalid email",
 		expectedError: ErrInvalidEmail,
 		funcParams: registerNewUserScenarioFuncParam{
-			"aa", 1, true,
+			"aa", 1, 1, true,
 		},
 		mockApiResponse: mockApiResponse{
 			statusCode: 400,
@@ -131,16 +135,32 @@ var registerNewUserScenario = []struct {
 
 func TestRegisterNewUser(t *testing.T) {
 	t.Parallel()
-	ctx := context.Background()
+	ctx := audit.WithActor(context.Background(), audit.Actor{EmployeeID: 1, Name: "admin@payd"})
 	for _, sc := range registerNewUserScenario {
 		t.Run(sc.name, func(t *testing.T) {
 			server := mockAPIServer(t, []expectedApiRequest{sc.expectedApiRequest}, []mockApiResponse{sc.mockApiResponse})
 			defer server.Close()
-			auth, err := NewAuth(&mockStorage{}, WithKratosAdminURL(server.URL))
+			storage := &mockStorage{}
+			auth, err := NewAuth(storage, WithKratosAdminURL(server.URL))
 			assert.NoError(t, err)
-			id, err := auth.RegisterNewUser(ctx, sc.funcParams.email, sc.funcParams.primaryRole, sc.funcParams.roleAdmin)
+			id, err := auth.RegisterNewUser(ctx, sc.funcParams.email, sc.funcParams.primaryRole, sc.funcParams.locationId, sc.funcParams.roleAdmin)
 			assert.Equal(t, sc.expectedError, err)
 			assert.Equal(t, sc.expectedId, id)
+			if sc.expectedError != nil {
+				assert.Empty(t, storage.audits)
+				return
+			}
+			if assert.Len(t, storage.audits, 1) {
+				l := storage.audits[0]
+				assert.Equal(t, "register", l.Action)
+				assert.Equal(t, audit.EntityIdentity, l.EntityType)
+				assert.Equal(t, 1, *l.ActorID)
+				role := "employee"
+				if sc.funcParams.roleAdmin {
+					role = "admin"
+				}
+				assert.JSONEq(t, `{"IdentityID":"10","Email":"abc@gmail.com","Role":"`+role+`","LocationID":1}`, string(*l.After))
+			}
 		})
 	}
 }
@@ -149,6 +169,22 @@ var dbError = errors.New("db error")
 
 type mockStorage struct {
 	selectEmployeeByIDFunc func(ctx context.Context, id int) (*st.Employee, error)
+	refreshTokens          []*st.RefreshToken
+	apiTokens              []*st.APIToken
+	audits                 []st.AuditLog
+	events                 []st.OutboxEvent
+}
+
+// InsertAuditLog implements storage.
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+// InsertOutboxEvent implements storage.
+func (m *mockStorage) InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error {
+	m.events = append(m.events, e)
+	return nil
 }
 
 // Commit implements storage.
@@ -171,14 +207,27 @@ func (m *mockStorage) Rollback(ctx context.Context) error {
 	return nil
 }
 
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
 // CreateNewEmployee implements storage.
-func (m *mockStorage) CreateNewEmployee(ctx context.Context, name string, status string, roleId int) (int, error) {
+func (m *mockStorage) CreateNewEmployee(ctx context.Context, name string, status string, roleId, locationId int) (int, error) {
 	if name == "invalid name" {
 		return 0, dbError
 	}
 	return 4, nil
 }
 
+// UpdateEmployeeIdentityID implements storage.
+func (m *mockStorage) UpdateEmployeeIdentityID(ctx context.Context, id int, identityId string) error {
+	return nil
+}
+
 type registerActivateNewUserFuncParam struct {
 	userid   string
 	name     string
@@ -208,6 +257,7 @@ var scsActivateNewUserApiReqs = []expectedApiRequest{
 			Traits: map[string]interface{}{
 				"employee_id":  "4",
 				"primary_role": 7,
+				"location_id":  1, // defaulted for identities without the trait
 				"email":        "abc@mail.com",
 				"role":         "employee",
 			},
@@ -278,6 +328,29 @@ var activateNewUserScenario = []struct {
 		},
 		expectedError: ErrNotFound,
 	},
+	{
+		name: "deactivated identity can't be activated again",
+		funcParams: registerActivateNewUserFuncParam{
+			"userid", "name", "password",
+		},
+		expectedApiRequests: []expectedApiRequest{scsActivateNewUserApiReqs[0]},
+		mockApiResponses: []mockApiResponse{
+			{
+				statusCode: 200,
+				body: kratos.Identity{
+					Id:    "userid",
+					State: &inactiveState,
+					Traits: map[string]interface{}{
+						"employee_id":  "4",
+						"primary_role": 7,
+						"email":        "abc@mail.com",
+						"role":         "employee",
+					},
+				},
+			},
+		},
+		expectedError: ErrAlreadyExists,
+	},
 
 	{
 		name: "UpdateIdentity throw 400 should return error and rollback db createNewEmployee call",
@@ -308,10 +381,44 @@ func TestActivateNewUser(t *testing.T) {
 			}
 			server := mockAPIServer(t, sc.expectedApiRequests, sc.mockApiResponses)
 			defer server.Close()
-			auth, err := NewAuth(&mockStorage{}, WithKratosAdminURL(server.URL))
+			storage := &mockStorage{}
+			auth, err := NewAuth(storage, WithKratosAdminURL(server.URL))
 			assert.NoError(t, err)
 			err = auth.ActivateNewUser(ctx, sc.funcParams.userid, sc.funcParams.name, sc.funcParams.password)
 			assert.Equal(t, sc.expectedError, err)
+			if sc.expectedError == nil && assert.Len(t, storage.events, 1) {
+				assert.Equal(t, webhook.EmployeeActivated, storage.events[0].EventType)
+				assert.Contains(t, string(storage.events[0].Payload), `"name":"name"`)
+			}
+		})
+	}
+}
+
+func TestSetIdentityState(t *testing.T) {
+	t.Parallel()
+	scenarios := []struct {
+		name          string
+		active        bool
+		statusCode    int
+		expectedState string
+		expectedError error
+	}{
+		{name: "deactivate", statusCode: 200, expectedState: "inactive"},
+		{name: "reactivate", active: true, statusCode: 200, expectedState: "active"},
+		{name: "not found", statusCode: 404, expectedState: "inactive", expectedError: ErrNotFound},
+	}
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			server := mockAPIServer(t, []expectedApiRequest{{
+				method: "PATCH",
+				path:   "/admin/identities/userid",
+				body:   []kratos.JsonPatch{{Op: "replace", Path: "/state", Value: sc.expectedState}},
+			}}, []mockApiResponse{{statusCode: sc.statusCode, body: kratos.Identity{Id: "userid", Traits: map[string]interface{}{}}}})
+			defer server.Close()
+			auth, err := NewAuth(&mockStorage{}, WithKratosAdminURL(server.URL))
+			assert.NoError(t, err)
+			err = auth.SetIdentityState(context.Background(), "userid", sc.active)
+			assert.Equal(t, sc.expectedError, err)
 		})
 	}
 }
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	st "payd/storage"
+	"payd/util"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+var ErrRevocationDisabled = errors.New("token revocation is not configured")
+
+type revocationStorage interface {
+	RevokeJWT(ctx context.Context, jti string, expiresAt time.Time) error
+	SelectRevokedJWTs(ctx context.Context, now time.Time) ([]st.RevokedJWT, error)
+	DeleteExpiredRevokedJWTs(ctx context.Context, now time.Time) (int64, error)
+	RevokeEmployeeJWTs(ctx context.Context, employeeId int, before time.Time) error
+	SelectEmployeeJWTRevocations(ctx context.Context) ([]st.EmployeeJWTRevocation, error)
+}
+
+// RevocationList caches the revoked access tokens so every request can be checked without hitting the db.
+// Revocations made by this instance apply immediately, the ones made by other instances after the next refresh.
+type RevocationList struct {
+	storage revocationStorage
+
+	mu        sync.RWMutex
+	tokens    map[string]time.Time // jti -> exp
+	employees map[int]time.Time    // employee id -> revoked before
+	tick      time.Duration
+}
+
+func NewRevocationList(storage revocationStorage, tick time.Duration) *RevocationList {
+	return &RevocationList{
+		storage:   storage,
+		tokens:    make(map[string]time.Time),
+		employees: make(map[int]time.Time),
+		tick:      tick,
+	}
+}
+
+// Start begins the periodic refresh of revocations and performs an initial fetch.
+func (rl *RevocationList) Start(ctx context.Context) error {
+	if err := rl.refresh(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(rl.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := rl.refresh(ctx); err != nil {
+					util.Log().WithContext(ctx).WithError(err).Error("periodic revocation fetch failed")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// refresh replaces the cache with the revocations in storage and prunes the expired ones.
+func (rl *RevocationList) refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	if _, err := rl.storage.DeleteExpiredRevokedJWTs(ctx, now); err != nil {
+		return err
+	}
+	revokedTokens, err := rl.storage.SelectRevokedJWTs(ctx, now)
+	if err != nil {
+		return err
+	}
+	revokedEmployees, err := rl.storage.SelectEmployeeJWTRevocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens := make(map[string]time.Time, len(revokedTokens))
+	for _, t := range revokedTokens {
+		tokens[t.JTI] = t.ExpiresAt
+	}
+	employees := make(map[int]time.Time, len(revokedEmployees))
+	for _, e := range revokedEmployees {
+		employees[e.EmployeeID] = e.RevokedBefore
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens = tokens
+	rl.employees = employees
+	return nil
+}
+
+// RevokeToken revokes a single access token until it expires
+func (rl *RevocationList) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	expiresAt = expiresAt.UTC()
+	if err := rl.storage.RevokeJWT(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeEmployee revokes every access token of the employee issued up to now
+func (rl *RevocationList) RevokeEmployee(ctx context.Context, employeeId int) error {
+	now := time.Now().UTC()
+	if err := rl.storage.RevokeEmployeeJWTs(ctx, employeeId, now); err != nil {
+		return err
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if now.After(rl.employees[employeeId]) {
+		rl.employees[employeeId] = now
+	}
+	return nil
+}
+
+// IsRevoked reports whether the access token was revoked by its jti or by a revocation of its employee.
+// iat has a second precision, a token issued in the same second as an employee revocation is revoked.
+func (rl *RevocationList) IsRevoked(jti string, employeeId int, issuedAt time.Time) bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	if _, ok := rl.tokens[jti]; ok && jti != "" {
+		return true
+	}
+	revokedBefore, ok := rl.employees[employeeId]
+	return ok && !issuedAt.After(revokedBefore)
+}
+
+// RevokeAccessToken revokes the access token until it expires, expired tokens are ignored
+func (a *Auth) RevokeAccessToken(ctx context.Context, tokenStr string) error {
+	if a.revocations == nil {
+		return ErrRevocationDisabled
+	}
+	identity, err := a.parseJWT(tokenStr, jwt.WithoutClaimsValidation())
+	if err != nil || identity == nil {
+		return ErrInvalidToken
+	}
+	// tokens issued before revocation support have no jti, they expire shortly anyway
+	if identity.TokenID == "" || !time.Now().Before(identity.ExpiresAt) {
+		return nil
+	}
+	return a.revocations.RevokeToken(ctx, identity.TokenID, identity.ExpiresAt)
+}
+
+// RevokeAllSessions logs the employee out everywhere, their access tokens are rejected immediately and
+// their refresh tokens can't be used anymore
+func (a *Auth) RevokeAllSessions(ctx context.Context, employeeId int) error {
+	if a.revocations == nil {
+		return ErrRevocationDisabled
+	}
+	revoked, err := a.storage.RevokeRefreshTokensByEmployeeID(ctx, employeeId)
+	if err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("storage revoke refresh tokens of employee")
+		return err
+	}
+	if err := a.revocations.RevokeEmployee(ctx, employeeId); err != nil {
+		util.Log().WithContext(ctx).WithError(err).Error("revoke access tokens of employee")
+		return err
+	}
+	util.Log().WithContext(ctx).WithField("employee_id", employeeId).WithField("refresh_tokens", revoked).
+		Info("revoked all sessions of employee")
+	return nil
+}
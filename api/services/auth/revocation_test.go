@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	st "payd/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RevokeRefreshTokensByEmployeeID implements storage.
+func (m *mockStorage) RevokeRefreshTokensByEmployeeID(ctx context.Context, employeeId int) (int64, error) {
+	var n int64
+	now := time.Now().UTC()
+	for _, t := range m.refreshTokens {
+		if t.EmployeeID == employeeId && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+type mockRevocationStorage struct {
+	tokens    map[string]time.Time
+	employees map[int]time.Time
+}
+
+func newMockRevocationStorage() *mockRevocationStorage {
+	return &mockRevocationStorage{tokens: map[string]time.Time{}, employees: map[int]time.Time{}}
+}
+
+func (m *mockRevocationStorage) RevokeJWT(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.tokens[jti] = expiresAt
+	return nil
+}
+
+func (m *mockRevocationStorage) SelectRevokedJWTs(ctx context.Context, now time.Time) ([]st.RevokedJWT, error) {
+	var recs []st.RevokedJWT
+	for jti, exp := range m.tokens {
+		if exp.After(now) {
+			recs = append(recs, st.RevokedJWT{JTI: jti, ExpiresAt: exp})
+		}
+	}
+	return recs, nil
+}
+
+func (m *mockRevocationStorage) DeleteExpiredRevokedJWTs(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	for jti, exp := range m.tokens {
+		if !exp.After(now) {
+			delete(m.tokens, jti)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockRevocationStorage) RevokeEmployeeJWTs(ctx context.Context, employeeId int, before time.Time) error {
+	if before.After(m.employees[employeeId]) {
+		m.employees[employeeId] = before
+	}
+	return nil
+}
+
+func (m *mockRevocationStorage) SelectEmployeeJWTRevocations(ctx context.Context) ([]st.EmployeeJWTRevocation, error) {
+	var recs []st.EmployeeJWTRevocation
+	for id, before := range m.employees {
+		recs = append(recs, st.EmployeeJWTRevocation{EmployeeID: id, RevokedBefore: before})
+	}
+	return recs, nil
+}
+
+func newRevocationTestAuth(t *testing.T) (*Auth, *mockStorage, *mockRevocationStorage) {
+	revStorage := newMockRevocationStorage()
+	rl := NewRevocationList(revStorage, time.Hour)
+	assert.NoError(t, rl.refresh(context.Background()))
+	storage := &mockStorage{}
+	auth, err := NewAuth(storage, WithJWTSecret("secret"), WithRevocationList(rl))
+	assert.NoError(t, err)
+	return auth, storage, revStorage
+}
+
+func (a *Auth) testToken(t *testing.T, employeeId string) string {
+	identity := &Identity{ID: "userid", Email: "abc@mail.com", Role: "employee", EmployeeId: employeeId,
+		jwtSecret: a.jwtSecret}
+	token, err := identity.GenerateJWT(time.Minute)
+	assert.NoError(t, err)
+	return token
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	auth, _, revStorage := newRevocationTestAuth(t)
+	ctx := context.Background()
+
+	token := auth.testToken(t, "4")
+	other := auth.testToken(t, "4")
+	identity, err := auth.VerifySignatureJWT(token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, identity.TokenID)
+
+	assert.NoError(t, auth.RevokeAccessToken(ctx, token))
+	_, err = auth.VerifySignatureJWT(token)
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Contains(t, revStorage.tokens, identity.TokenID)
+
+	// only the revoked token is rejected
+	_, err = auth.VerifySignatureJWT(other)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrInvalidToken, auth.RevokeAccessToken(ctx, "garbage"))
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	auth, storage, _ := newRevocationTestAuth(t)
+	ctx := context.Background()
+	storage.refreshTokens = []*st.RefreshToken{{ID: 1, EmployeeID: 4}, {ID: 2, EmployeeID: 5}}
+
+	token := auth.testToken(t, "4")
+	other := auth.testToken(t, "5")
+	assert.NoError(t, auth.RevokeAllSessions(ctx, 4))
+
+	_, err := auth.VerifySignatureJWT(token)
+	assert.Equal(t, ErrTokenRevoked, err)
+	_, err = auth.VerifySignatureJWT(other)
+	assert.NoError(t, err)
+	assert.NotNil(t, storage.refreshTokens[0].RevokedAt)
+	assert.Nil(t, storage.refreshTokens[1].RevokedAt)
+}
+
+func TestRevocationListRefresh(t *testing.T) {
+	revStorage := newMockRevocationStorage()
+	rl := NewRevocationList(revStorage, time.Hour)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// revocations made by another instance
+	revStorage.tokens["jti-1"] = now.Add(time.Minute)
+	revStorage.tokens["jti-expired"] = now.Add(-time.Minute)
+	revStorage.employees[4] = now
+	assert.False(t, rl.IsRevoked("jti-1", 1, now))
+
+	assert.NoError(t, rl.refresh(ctx))
+	assert.True(t, rl.IsRevoked("jti-1", 1, now))
+	assert.False(t, rl.IsRevoked("jti-2", 1, now))
+	assert.NotContains(t, revStorage.tokens, "jti-expired", "expired revocations are pruned")
+
+	assert.True(t, rl.IsRevoked("jti-2", 4, now.Add(-time.Minute)))
+	assert.True(t, rl.IsRevoked("jti-2", 4, now))
+	assert.False(t, rl.IsRevoked("jti-2", 4, now.Add(time.Second)), "tokens issued after the revocation are valid")
+	assert.False(t, rl.IsRevoked("", 1, now))
+}
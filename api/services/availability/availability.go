@@ -0,0 +1,234 @@
+package availability
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+)
+
+const maxReasonLength = 500
+
+var ErrInvalidWeekday = errors.New("weekday must be between 0 (sunday) and 6")
+var ErrInvalidTimeOfDay = util.ErrInvalidTimeOfDay
+var ErrInvalidTimezone = errors.New("invalid timezone")
+var ErrInvalidTimeRange = errors.New("end must be after start")
+var ErrInvalidReason = errors.New("a reason of at most 500 characters is required")
+var ErrUnavailabilityNotFound = errors.New("unavailability not found")
+
+// ErrEmployeeUnavailable refuses a shift overlapping a declared unavailability, see UnavailableError
+var ErrEmployeeUnavailable = errors.New("employee declared to be unavailable during the shift")
+
+// ErrOutsideAvailability only warns, the shift isn't within the employee's weekly availability
+var ErrOutsideAvailability = errors.New("shift is outside the employee's declared availability")
+
+type storage interface {
+	ListAvailabilityWindows(ctx context.Context, employeeId int) ([]st.AvailabilityWindow, error)
+	ReplaceAvailabilityWindows(ctx context.Context, employeeId int, windows []st.AvailabilityWindow) error
+	CreateUnavailability(ctx context.Context, u st.Unavailability) (int, error)
+	ListUnavailabilitiesByTimeRange(ctx context.Context, employeeId int, start, end time.Time) ([]st.Unavailability, error)
+	DeleteUnavailability(ctx context.Context, id, employeeId int) (*st.Unavailability, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type AvailabilityInterface interface {
+	ListAvailability(ctx context.Context, employeeId int) ([]st.AvailabilityWindow, error)
+	SetAvailability(ctx context.Context, employeeId int, windows []st.AvailabilityWindow) error
+	ListUnavailabilities(ctx context.Context, employeeId int, start, end time.Time) ([]st.Unavailability, error)
+	AddUnavailability(ctx context.Context, u st.Unavailability) (int, error)
+	DeleteUnavailability(ctx context.Context, employeeId, id int) error
+	CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error)
+}
+
+// UnavailableError is returned when a shift overlaps a declared unavailability of the employee
+type UnavailableError struct {
+	Unavailability st.Unavailability
+}
+
+func (e *UnavailableError) Error() string {
+	return ErrEmployeeUnavailable.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return ErrEmployeeUnavailable
+}
+
+type Availability struct {
+	storage storage
+}
+
+func NewAvailability(storage storage) *Availability {
+	return &Availability{storage: storage}
+}
+
+// ListAvailability lists the weekly windows of the employee, none means available at any time
+func (a *Availability) ListAvailability(ctx context.Context, employeeId int) ([]st.AvailabilityWindow, error) {
+	return a.storage.ListAvailabilityWindows(ctx, employeeId)
+}
+
+// SetAvailability replaces the weekly windows of the employee, an empty list clears them
+func (a *Availability) SetAvailability(ctx context.Context, employeeId int, windows []st.AvailabilityWindow) (err error) {
+	for _, w := range windows {
+		if w.Weekday < 0 || w.Weekday > 6 {
+			return ErrInvalidWeekday
+		}
+		if _, err := util.ParseTimeOfDay(w.StartTimeOfDay); err != nil {
+			return err
+		}
+		if _, err := util.ParseTimeOfDay(w.EndTimeOfDay); err != nil {
+			return err
+		}
+		if _, err := time.LoadLocation(w.Timezone); err != nil || w.Timezone == "" {
+			return ErrInvalidTimezone
+		}
+	}
+
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	before, err := a.storage.ListAvailabilityWindows(tctx, employeeId)
+	if err != nil {
+		return err
+	}
+	if err = a.storage.ReplaceAvailabilityWindows(tctx, employeeId, windows); err != nil {
+		return err
+	}
+	return audit.Record(tctx, a.storage, "set", audit.EntityAvailability, employeeId, before, windows)
+}
+
+// ListUnavailabilities lists the periods of the employee overlapping the time range
+func (a *Availability) ListUnavailabilities(ctx context.Context, employeeId int, start, end time.Time) ([]st.Unavailability, error) {
+	return a.storage.ListUnavailabilitiesByTimeRange(ctx, employeeId, start, end)
+}
+
+func (a *Availability) AddUnavailability(ctx context.Context, u st.Unavailability) (id int, err error) {
+	if !u.StartTime.Before(u.EndTime) {
+		return 0, ErrInvalidTimeRange
+	}
+	u.Reason = strings.TrimSpace(u.Reason)
+	if u.Reason == "" || len([]rune(u.Reason)) > maxReasonLength {
+		return 0, ErrInvalidReason
+	}
+	u.StartTime = u.StartTime.UTC()
+	u.EndTime = u.EndTime.UTC()
+
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	if u.ID, err = a.storage.CreateUnavailability(tctx, u); err != nil {
+		return 0, err
+	}
+	return u.ID, audit.Record(tctx, a.storage, "create", audit.EntityUnavailability, u.ID, nil, u)
+}
+
+// DeleteUnavailability deletes a period of the employee, the periods of the others are reported as not found
+func (a *Availability) DeleteUnavailability(ctx context.Context, employeeId, id int) (err error) {
+	tctx, err := a.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.storage.Finish(tctx, &err)
+
+	deleted, err := a.storage.DeleteUnavailability(tctx, id, employeeId)
+	if err != nil {
+		return err
+	}
+	if deleted == nil {
+		return ErrUnavailabilityNotFound
+	}
+	return audit.Record(tctx, a.storage, "delete", audit.EntityUnavailability, id, deleted, nil)
+}
+
+// CheckShift returns an *UnavailableError if the shift overlaps a declared unavailability of the employee.
+// outside is true when the employee declared weekly windows and the shift isn't entirely within them
+func (a *Availability) CheckShift(ctx context.Context, employeeId int, start, end time.Time) (outside bool, err error) {
+	unavailabilities, err := a.storage.ListUnavailabilitiesByTimeRange(ctx, employeeId, start, end)
+	if err != nil {
+		return false, err
+	}
+	if len(unavailabilities) > 0 {
+		return false, &UnavailableError{Unavailability: unavailabilities[0]}
+	}
+
+	windows, err := a.storage.ListAvailabilityWindows(ctx, employeeId)
+	if err != nil {
+		return false, err
+	}
+	if len(windows) == 0 {
+		return false, nil
+	}
+	covered, err := covers(windows, start, end)
+	if err != nil {
+		return false, err
+	}
+	return !covered, nil
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// covers reports whether the occurrences of the weekly windows cover the whole time range,
+// adjacent or overlapping windows add up, e.g. 09:00-12:00 and 12:00-17:00 cover 10:00-14:00
+func covers(windows []st.AvailabilityWindow, start, end time.Time) (bool, error) {
+	var occurrences []interval
+	for _, w := range windows {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false, ErrInvalidTimezone
+		}
+		startTod, err := util.ParseTimeOfDay(w.StartTimeOfDay)
+		if err != nil {
+			return false, err
+		}
+		endTod, err := util.ParseTimeOfDay(w.EndTimeOfDay)
+		if err != nil {
+			return false, err
+		}
+		// a window starting the day before may reach into the range
+		from := util.CivilDate(start.In(loc)).AddDate(0, 0, -1)
+		through := util.CivilDate(end.In(loc))
+		for day := from; !day.After(through); day = day.AddDate(0, 0, 1) {
+			if int(day.Weekday()) != w.Weekday {
+				continue
+			}
+			endDay := day
+			if endTod <= startTod {
+				endDay = day.AddDate(0, 0, 1)
+			}
+			occurrences = append(occurrences, interval{start: util.OnDay(startTod, day, loc), end: util.OnDay(endTod, endDay, loc)})
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+	covered := start
+	for _, o := range occurrences {
+		if !covered.Before(end) {
+			break
+		}
+		if o.start.After(covered) {
+			return false, nil
+		}
+		if o.end.After(covered) {
+			covered = o.end
+		}
+	}
+	return !covered.Before(end), nil
+}
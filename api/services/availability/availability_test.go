@@ -0,0 +1,239 @@
+package availability
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	windows          []st.AvailabilityWindow
+	unavailabilities []st.Unavailability
+	created          *st.Unavailability
+	audits           []st.AuditLog
+	committed        bool
+	rolledBack       bool
+}
+
+func (m *mockStorage) ListAvailabilityWindows(ctx context.Context, employeeId int) ([]st.AvailabilityWindow, error) {
+	return m.windows, nil
+}
+
+func (m *mockStorage) ReplaceAvailabilityWindows(ctx context.Context, employeeId int, windows []st.AvailabilityWindow) error {
+	m.windows = windows
+	return nil
+}
+
+func (m *mockStorage) CreateUnavailability(ctx context.Context, u st.Unavailability) (int, error) {
+	m.created = &u
+	return 7, nil
+}
+
+func (m *mockStorage) ListUnavailabilitiesByTimeRange(ctx context.Context, employeeId int, start, end time.Time) ([]st.Unavailability, error) {
+	var recs []st.Unavailability
+	for _, u := range m.unavailabilities {
+		if u.StartTime.Before(end) && u.EndTime.After(start) {
+			recs = append(recs, u)
+		}
+	}
+	return recs, nil
+}
+
+func (m *mockStorage) DeleteUnavailability(ctx context.Context, id, employeeId int) (*st.Unavailability, error) {
+	if id != 7 {
+		return nil, nil
+	}
+	return &st.Unavailability{ID: id, EmployeeID: employeeId}, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func TestSetAvailability(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      st.AvailabilityWindow
+		expectedErr error
+	}{
+		{
+			name:   "valid window",
+			window: st.AvailabilityWindow{Weekday: 1, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "Europe/Paris"},
+		},
+		{
+			name:        "invalid weekday",
+			window:      st.AvailabilityWindow{Weekday: 7, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "UTC"},
+			expectedErr: ErrInvalidWeekday,
+		},
+		{
+			name:        "invalid time of day",
+			window:      st.AvailabilityWindow{Weekday: 1, StartTimeOfDay: "9am", EndTimeOfDay: "17:00", Timezone: "UTC"},
+			expectedErr: ErrInvalidTimeOfDay,
+		},
+		{
+			name:        "invalid timezone",
+			window:      st.AvailabilityWindow{Weekday: 1, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "Mars/Olympus"},
+			expectedErr: ErrInvalidTimezone,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &mockStorage{}
+			err := NewAvailability(storage).SetAvailability(context.Background(), 4, []st.AvailabilityWindow{tc.window})
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Empty(t, storage.windows)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []st.AvailabilityWindow{tc.window}, storage.windows)
+			assert.True(t, storage.committed)
+		})
+	}
+}
+
+func TestAddUnavailability(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	start := time.Date(2025, 5, 14, 9, 0, 0, 0, paris)
+
+	svc := NewAvailability(&mockStorage{})
+	_, err = svc.AddUnavailability(context.Background(), st.Unavailability{EmployeeID: 4, StartTime: start, EndTime: start, Reason: "dentist"})
+	assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	_, err = svc.AddUnavailability(context.Background(), st.Unavailability{EmployeeID: 4, StartTime: start, EndTime: start.Add(time.Hour), Reason: "  "})
+	assert.ErrorIs(t, err, ErrInvalidReason)
+
+	storage := &mockStorage{}
+	id, err := NewAvailability(storage).AddUnavailability(context.Background(), st.Unavailability{
+		EmployeeID: 4, StartTime: start, EndTime: start.Add(time.Hour), Reason: " dentist ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, id)
+	assert.Equal(t, "dentist", storage.created.Reason)
+	assert.Equal(t, time.UTC, storage.created.StartTime.Location())
+
+	assert.ErrorIs(t, NewAvailability(storage).DeleteUnavailability(context.Background(), 4, 8), ErrUnavailabilityNotFound)
+	require.NoError(t, NewAvailability(storage).DeleteUnavailability(context.Background(), 4, 7))
+
+	require.Len(t, storage.audits, 2)
+	assert.Equal(t, "create", storage.audits[0].Action)
+	assert.Equal(t, audit.EntityUnavailability, storage.audits[0].EntityType)
+	assert.Equal(t, 7, storage.audits[0].EntityID)
+	assert.Equal(t, "delete", storage.audits[1].Action)
+	assert.NotNil(t, storage.audits[1].Before)
+	assert.Nil(t, storage.audits[1].After)
+}
+
+func TestCheckShift(t *testing.T) {
+	// wednesday
+	day := time.Date(2025, 5, 14, 0, 0, 0, 0, time.UTC)
+	weekdays := []st.AvailabilityWindow{
+		{Weekday: 3, StartTimeOfDay: "09:00:00", EndTimeOfDay: "12:00:00", Timezone: "UTC"},
+		{Weekday: 3, StartTimeOfDay: "12:00:00", EndTimeOfDay: "17:00:00", Timezone: "UTC"},
+		{Weekday: 3, StartTimeOfDay: "22:00:00", EndTimeOfDay: "06:00:00", Timezone: "UTC"},
+	}
+
+	tests := []struct {
+		name            string
+		storage         *mockStorage
+		start, end      time.Time
+		expectedOutside bool
+		expectedErr     error
+	}{
+		{
+			name:    "no declared availability",
+			storage: &mockStorage{},
+			start:   day.Add(2 * time.Hour),
+			end:     day.Add(10 * time.Hour),
+		},
+		{
+			name:    "within adjacent windows",
+			storage: &mockStorage{windows: weekdays},
+			start:   day.Add(10 * time.Hour),
+			end:     day.Add(14 * time.Hour),
+		},
+		{
+			name:            "ends after the windows",
+			storage:         &mockStorage{windows: weekdays},
+			start:           day.Add(15 * time.Hour),
+			end:             day.Add(19 * time.Hour),
+			expectedOutside: true,
+		},
+		{
+			name:            "another weekday",
+			storage:         &mockStorage{windows: weekdays},
+			start:           day.Add(34 * time.Hour),
+			end:             day.Add(38 * time.Hour),
+			expectedOutside: true,
+		},
+		{
+			name:    "within an overnight window",
+			storage: &mockStorage{windows: weekdays},
+			start:   day.Add(23 * time.Hour),
+			end:     day.Add(29 * time.Hour),
+		},
+		{
+			name: "window in another timezone",
+			storage: &mockStorage{windows: []st.AvailabilityWindow{
+				{Weekday: 3, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "Europe/Paris"},
+			}},
+			start:           day.Add(15 * time.Hour),
+			end:             day.Add(16 * time.Hour),
+			expectedOutside: true,
+		},
+		{
+			name: "declared unavailability",
+			storage: &mockStorage{windows: weekdays, unavailabilities: []st.Unavailability{
+				{ID: 2, StartTime: day.Add(13 * time.Hour), EndTime: day.Add(15 * time.Hour), Reason: "dentist"},
+			}},
+			start:       day.Add(10 * time.Hour),
+			end:         day.Add(14 * time.Hour),
+			expectedErr: ErrEmployeeUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			outside, err := NewAvailability(tc.storage).CheckShift(context.Background(), 4, tc.start, tc.end)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				var unavailable *UnavailableError
+				require.ErrorAs(t, err, &unavailable)
+				assert.Equal(t, "dentist", unavailable.Unavailability.Reason)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedOutside, outside)
+		})
+	}
+}
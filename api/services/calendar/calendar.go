@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+)
+
+// FeedTokenPrefix starts every feed token, the token is the last segment of the feed URL
+const FeedTokenPrefix = "cal_"
+
+// defaultHistory is how long the past shifts stay in a feed
+const defaultHistory = 30 * 24 * time.Hour
+
+const employeeActive = "ACTIVE"
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+var ErrRoleNotFound = errors.New("job role not found")
+
+type storage interface {
+	CreateCalendarFeed(ctx context.Context, f st.CalendarFeed, tokenHash string) (int, error)
+	SelectCalendarFeedByHash(ctx context.Context, tokenHash string) (*st.CalendarFeed, error)
+	ListRoleCalendarFeeds(ctx context.Context) ([]st.CalendarFeed, error)
+	RevokeEmployeeCalendarFeed(ctx context.Context, employeeId int) (int, error)
+	RevokeRoleCalendarFeed(ctx context.Context, id int) (bool, error)
+	ListCalendarShifts(ctx context.Context, filter st.CalendarShiftFilter, since time.Time) ([]st.CalendarShift, error)
+	ListCancelledCalendarShifts(ctx context.Context, filter st.CalendarShiftFilter, since time.Time) ([]st.CalendarShift, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type CalendarInterface interface {
+	CreateEmployeeFeed(ctx context.Context, employeeId int) (string, error)
+	RevokeEmployeeFeed(ctx context.Context, employeeId int) error
+	CreateRoleFeed(ctx context.Context, createdBy, roleId int, locationIds []int) (int, string, error)
+	ListRoleFeeds(ctx context.Context) ([]st.CalendarFeed, error)
+	RevokeRoleFeed(ctx context.Context, id int) error
+	Feed(ctx context.Context, token string) ([]byte, error)
+}
+
+type Calendar struct {
+	storage storage
+	history time.Duration
+	now     func() time.Time
+}
+
+type Option func(*Calendar)
+
+func NewCalendar(storage storage, opts ...Option) *Calendar {
+	c := &Calendar{
+		storage: storage,
+		history: defaultHistory,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithHistory sets how long the past shifts stay in a feed, defaults to 30 days
+func WithHistory(history time.Duration) Option {
+	return func(c *Calendar) {
+		if history > 0 {
+			c.history = history
+		}
+	}
+}
+
+// CreateEmployeeFeed returns the token of a new feed of the approved shifts of the employee,
+// it replaces the previous feed of the employee. the token can't be retrieved afterwards
+func (c *Calendar) CreateEmployeeFeed(ctx context.Context, employeeId int) (token string, err error) {
+	token, hash, err := newFeedToken()
+	if err != nil {
+		return "", err
+	}
+	tctx, err := c.storage.NewTransacton(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.storage.Finish(tctx, &err)
+
+	revoked, err := c.storage.RevokeEmployeeCalendarFeed(tctx, employeeId)
+	if err != nil {
+		return "", err
+	}
+	if revoked != 0 {
+		if err = recordRevoke(tctx, c.storage, revoked); err != nil {
+			return "", err
+		}
+	}
+	feed := st.CalendarFeed{EmployeeID: employeeId}
+	if feed.ID, err = c.storage.CreateCalendarFeed(tctx, feed, hash); err != nil {
+		return "", err
+	}
+	if err = recordCreate(tctx, c.storage, feed); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (c *Calendar) RevokeEmployeeFeed(ctx context.Context, employeeId int) (err error) {
+	tctx, err := c.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.storage.Finish(tctx, &err)
+
+	revoked, err := c.storage.RevokeEmployeeCalendarFeed(tctx, employeeId)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrFeedNotFound
+	}
+	return recordRevoke(tctx, c.storage, revoked)
+}
+
+// CreateRoleFeed returns the id and the token of a new feed of every approved shift of the job role
+// within locationIds, nil for every location. the feed stops working once its creator is deactivated
+func (c *Calendar) CreateRoleFeed(ctx context.Context, createdBy, roleId int, locationIds []int) (id int, token string, err error) {
+	token, hash, err := newFeedToken()
+	if err != nil {
+		return 0, "", err
+	}
+	feed := st.CalendarFeed{EmployeeID: createdBy, RoleID: &roleId}
+	if locationIds != nil {
+		feed.LocationIDs = make([]int64, 0, len(locationIds))
+		for _, id := range locationIds {
+			feed.LocationIDs = append(feed.LocationIDs, int64(id))
+		}
+	}
+
+	tctx, err := c.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer c.storage.Finish(tctx, &err)
+
+	feed.ID, err = c.storage.CreateCalendarFeed(tctx, feed, hash)
+	if errors.Is(err, st.ErrUnknownJobRole) {
+		return 0, "", ErrRoleNotFound
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	if err = recordCreate(tctx, c.storage, feed); err != nil {
+		return 0, "", err
+	}
+	return feed.ID, token, nil
+}
+
+func (c *Calendar) ListRoleFeeds(ctx context.Context) ([]st.CalendarFeed, error) {
+	return c.storage.ListRoleCalendarFeeds(ctx)
+}
+
+func (c *Calendar) RevokeRoleFeed(ctx context.Context, id int) (err error) {
+	tctx, err := c.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.storage.Finish(tctx, &err)
+
+	revoked, err := c.storage.RevokeRoleCalendarFeed(tctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrFeedNotFound
+	}
+	return recordRevoke(tctx, c.storage, id)
+}
+
+// recordCreate audits a new feed, the token hash stays out of the log
+func recordCreate(ctx context.Context, r audit.Recorder, feed st.CalendarFeed) error {
+	return audit.Record(ctx, r, "create", audit.EntityCalendarFeed, feed.ID, nil, map[string]interface{}{
+		"EmployeeID":  feed.EmployeeID,
+		"RoleID":      feed.RoleID,
+		"LocationIDs": feed.LocationIDs,
+	})
+}
+
+func recordRevoke(ctx context.Context, r audit.Recorder, id int) error {
+	return audit.Record(ctx, r, "revoke", audit.EntityCalendarFeed, id, nil, map[string]interface{}{"Revoked": true})
+}
+
+// Feed returns the iCalendar document of the feed of the token. every shift keeps the same UID
+// across updates and cancellations so that subscribed calendars update their event in place
+func (c *Calendar) Feed(ctx context.Context, token string) ([]byte, error) {
+	if !strings.HasPrefix(token, FeedTokenPrefix) {
+		return nil, ErrFeedNotFound
+	}
+	feed, err := c.storage.SelectCalendarFeedByHash(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feed.RevokedAt != nil || feed.EmployeeStatus != employeeActive {
+		return nil, ErrFeedNotFound
+	}
+
+	name := "Shifts - " + feed.EmployeeName
+	filter := st.CalendarShiftFilter{EmployeeID: feed.EmployeeID}
+	if feed.RoleID != nil {
+		name = "Shifts - " + *feed.RoleName
+		filter = st.CalendarShiftFilter{RoleID: *feed.RoleID}
+		if feed.LocationIDs != nil {
+			filter.LocationIDs = make([]int, 0, len(feed.LocationIDs))
+			for _, id := range feed.LocationIDs {
+				filter.LocationIDs = append(filter.LocationIDs, int(id))
+			}
+		}
+	}
+
+	now := c.now().UTC()
+	since := now.Add(-c.history)
+	shifts, err := c.storage.ListCalendarShifts(ctx, filter, since)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := c.storage.ListCancelledCalendarShifts(ctx, filter, since)
+	if err != nil {
+		return nil, err
+	}
+	shifts = append(shifts, cancelled...)
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].StartTime.Before(shifts[j].StartTime)
+	})
+
+	var b strings.Builder
+	writeICS(&b, name, shifts, feed.RoleID != nil, now)
+	return []byte(b.String()), nil
+}
+
+// newFeedToken returns a token and its hash, only the hash is stored
+func newFeedToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := FeedTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
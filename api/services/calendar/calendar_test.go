@@ -0,0 +1,247 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	feeds     map[string]*st.CalendarFeed // by token hash
+	shifts    []st.CalendarShift
+	cancelled []st.CalendarShift
+	filter    st.CalendarShiftFilter
+	since     time.Time
+	audits    []st.AuditLog
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{feeds: map[string]*st.CalendarFeed{}}
+}
+
+func (m *mockStorage) CreateCalendarFeed(ctx context.Context, f st.CalendarFeed, tokenHash string) (int, error) {
+	if f.RoleID != nil && *f.RoleID > 10 {
+		return 0, st.ErrUnknownJobRole
+	}
+	f.ID = len(m.feeds) + 1
+	f.EmployeeName, f.EmployeeStatus = "Alice", employeeActive
+	if f.RoleID != nil {
+		name := "Waiter"
+		f.RoleName = &name
+	}
+	m.feeds[tokenHash] = &f
+	return f.ID, nil
+}
+
+func (m *mockStorage) SelectCalendarFeedByHash(ctx context.Context, tokenHash string) (*st.CalendarFeed, error) {
+	f, ok := m.feeds[tokenHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return f, nil
+}
+
+func (m *mockStorage) ListRoleCalendarFeeds(ctx context.Context) ([]st.CalendarFeed, error) {
+	var feeds []st.CalendarFeed
+	for _, f := range m.feeds {
+		if f.RoleID != nil && f.RevokedAt == nil {
+			feeds = append(feeds, *f)
+		}
+	}
+	return feeds, nil
+}
+
+func (m *mockStorage) RevokeEmployeeCalendarFeed(ctx context.Context, employeeId int) (int, error) {
+	for _, f := range m.feeds {
+		if f.RevokedAt == nil && f.RoleID == nil && f.EmployeeID == employeeId {
+			now := time.Now()
+			f.RevokedAt = &now
+			return f.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *mockStorage) RevokeRoleCalendarFeed(ctx context.Context, id int) (bool, error) {
+	return m.revoke(func(f *st.CalendarFeed) bool { return f.RoleID != nil && f.ID == id }), nil
+}
+
+func (m *mockStorage) revoke(match func(*st.CalendarFeed) bool) bool {
+	revoked := false
+	for _, f := range m.feeds {
+		if f.RevokedAt == nil && match(f) {
+			now := time.Now()
+			f.RevokedAt = &now
+			revoked = true
+		}
+	}
+	return revoked
+}
+
+func (m *mockStorage) ListCalendarShifts(ctx context.Context, filter st.CalendarShiftFilter, since time.Time) ([]st.CalendarShift, error) {
+	m.filter, m.since = filter, since
+	return m.shifts, nil
+}
+
+func (m *mockStorage) ListCancelledCalendarShifts(ctx context.Context, filter st.CalendarShiftFilter, since time.Time) ([]st.CalendarShift, error) {
+	return m.cancelled, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) { return ctx, nil }
+func (m *mockStorage) Commit(ctx context.Context) error                           { return nil }
+func (m *mockStorage) Rollback(ctx context.Context) error                         { return nil }
+func (m *mockStorage) Finish(ctx context.Context, err *error)                     {}
+
+func TestEmployeeFeed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	storage := newMockStorage()
+	storage.shifts = []st.CalendarShift{{
+		ShiftID: 7, RoleName: "Waiter", LocationName: "Main St, 1", StartTime: start, EndTime: start.Add(8 * time.Hour),
+		ModifiedAt: now.Add(-time.Hour), Edits: 2, Assignees: []string{"Alice"},
+	}}
+	storage.cancelled = []st.CalendarShift{{
+		ShiftID: 3, RoleName: "Waiter", LocationName: "Main St, 1", StartTime: start.Add(-24 * time.Hour),
+		EndTime: start.Add(-16 * time.Hour), ModifiedAt: now, Edits: 1, Cancelled: true,
+	}}
+	c := NewCalendar(storage)
+	c.now = func() time.Time { return now }
+
+	previous, err := c.CreateEmployeeFeed(ctx, 4)
+	require.NoError(t, err)
+	token, err := c.CreateEmployeeFeed(ctx, 4)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, FeedTokenPrefix))
+
+	_, err = c.Feed(ctx, previous)
+	assert.ErrorIs(t, err, ErrFeedNotFound, "replaced by the new feed")
+
+	ics, err := c.Feed(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, st.CalendarShiftFilter{EmployeeID: 4}, storage.filter)
+	assert.Equal(t, now.Add(-defaultHistory), storage.since)
+	want := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//payd//shifts//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"METHOD:PUBLISH\r\n" +
+		"X-WR-CALNAME:Shifts - Alice\r\n" +
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n" +
+		"X-PUBLISHED-TTL:PT1H\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:shift-3@payd\r\n" +
+		"DTSTAMP:20250601T120000Z\r\n" +
+		"DTSTART:20250601T090000Z\r\n" +
+		"DTEND:20250601T170000Z\r\n" +
+		"LAST-MODIFIED:20250601T120000Z\r\n" +
+		"SEQUENCE:1\r\n" +
+		"SUMMARY:Waiter\r\n" +
+		"LOCATION:Main St\\, 1\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"TRANSP:OPAQUE\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:shift-7@payd\r\n" +
+		"DTSTAMP:20250601T120000Z\r\n" +
+		"DTSTART:20250602T090000Z\r\n" +
+		"DTEND:20250602T170000Z\r\n" +
+		"LAST-MODIFIED:20250601T110000Z\r\n" +
+		"SEQUENCE:2\r\n" +
+		"SUMMARY:Waiter\r\n" +
+		"LOCATION:Main St\\, 1\r\n" +
+		"STATUS:CONFIRMED\r\n" +
+		"TRANSP:OPAQUE\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	assert.Equal(t, want, string(ics))
+
+	require.NoError(t, c.RevokeEmployeeFeed(ctx, 4))
+	_, err = c.Feed(ctx, token)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+	assert.ErrorIs(t, c.RevokeEmployeeFeed(ctx, 4), ErrFeedNotFound)
+
+	// both creations, the revocation of the replaced feed then the explicit one
+	var actions []string
+	for _, l := range storage.audits {
+		assert.Equal(t, audit.EntityCalendarFeed, l.EntityType)
+		actions = append(actions, l.Action)
+	}
+	assert.Equal(t, []string{"create", "revoke", "create", "revoke"}, actions)
+	assert.Equal(t, storage.audits[1].EntityID, storage.audits[0].EntityID)
+}
+
+func TestRoleFeed(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	storage := newMockStorage()
+	storage.shifts = []st.CalendarShift{{
+		ShiftID: 7, RoleName: "Waiter", LocationName: "Main", StartTime: start, EndTime: start.Add(8 * time.Hour),
+		Assignees: []string{"Alice", "Bob"},
+	}}
+	c := NewCalendar(storage, WithHistory(7*24*time.Hour))
+
+	_, _, err := c.CreateRoleFeed(ctx, 1, 11, nil)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+
+	id, token, err := c.CreateRoleFeed(ctx, 1, 2, []int{1, 3})
+	require.NoError(t, err)
+	ics, err := c.Feed(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, st.CalendarShiftFilter{RoleID: 2, LocationIDs: []int{1, 3}}, storage.filter)
+	assert.Contains(t, string(ics), "X-WR-CALNAME:Shifts - Waiter\r\n")
+	assert.Contains(t, string(ics), "DESCRIPTION:Alice\\, Bob\r\n")
+
+	t.Run("deactivated creator", func(t *testing.T) {
+		storage.feeds[hashToken(token)].EmployeeStatus = "INACTIVE"
+		defer func() { storage.feeds[hashToken(token)].EmployeeStatus = employeeActive }()
+		_, err := c.Feed(ctx, token)
+		assert.ErrorIs(t, err, ErrFeedNotFound)
+	})
+
+	feeds, err := c.ListRoleFeeds(ctx)
+	require.NoError(t, err)
+	assert.Len(t, feeds, 1)
+	require.NoError(t, c.RevokeRoleFeed(ctx, id))
+	assert.ErrorIs(t, c.RevokeRoleFeed(ctx, id), ErrFeedNotFound)
+	_, err = c.Feed(ctx, token)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+}
+
+func TestFeedUnknownToken(t *testing.T) {
+	c := NewCalendar(newMockStorage())
+	for _, token := range []string{"", "payd_abc", FeedTokenPrefix + "abc"} {
+		_, err := c.Feed(context.Background(), token)
+		assert.ErrorIs(t, err, ErrFeedNotFound, token)
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	var b strings.Builder
+	writeFolded(&b, "DESCRIPTION:"+strings.Repeat("é", 80))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 3)
+	unfolded := lines[0]
+	for _, l := range lines {
+		assert.LessOrEqual(t, len(l), 75)
+	}
+	for _, l := range lines[1:] {
+		require.True(t, strings.HasPrefix(l, " "))
+		unfolded += l[1:]
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("é", 80), unfolded)
+}
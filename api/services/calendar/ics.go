@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	st "payd/storage"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// how often calendar apps are asked to refresh the feed
+const refreshInterval = "PT1H"
+
+// writeICS writes the shifts as an RFC 5545 calendar, withAssignees lists the approved employees of each shift
+func writeICS(b *strings.Builder, name string, shifts []st.CalendarShift, withAssignees bool, now time.Time) {
+	line := func(name, value string) {
+		writeFolded(b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//payd//shifts//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeText(name))
+	line("REFRESH-INTERVAL;VALUE=DURATION", refreshInterval)
+	line("X-PUBLISHED-TTL", refreshInterval)
+	for _, s := range shifts {
+		line("BEGIN", "VEVENT")
+		line("UID", shiftUID(s.ShiftID))
+		line("DTSTAMP", now.UTC().Format(icsTimeLayout))
+		line("DTSTART", s.StartTime.UTC().Format(icsTimeLayout))
+		line("DTEND", s.EndTime.UTC().Format(icsTimeLayout))
+		line("LAST-MODIFIED", s.ModifiedAt.UTC().Format(icsTimeLayout))
+		// every admin edit supersedes the previous version of the event
+		line("SEQUENCE", strconv.Itoa(s.Edits))
+		line("SUMMARY", escapeText(s.RoleName))
+		line("LOCATION", escapeText(s.LocationName))
+		if withAssignees && len(s.Assignees) > 0 {
+			line("DESCRIPTION", escapeText(strings.Join(s.Assignees, ", ")))
+		}
+		if s.Cancelled {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("TRANSP", "OPAQUE")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+}
+
+// shiftUID is the same for every version of the shift, in every feed
+func shiftUID(shiftId int) string {
+	return fmt.Sprintf("shift-%d@payd", shiftId)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes the content line folded at 75 octets without splitting a UTF-8 character, ended by CRLF
+func writeFolded(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts towards the limit
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
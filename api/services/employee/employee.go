@@ -0,0 +1,192 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
+	"payd/util"
+)
+
+const (
+	StatusActive   = "ACTIVE"
+	StatusInactive = "INACTIVE"
+)
+
+var ErrEmployeeNotFound = errors.New("employee not found")
+var ErrAlreadyActive = errors.New("employee is already active")
+var ErrAlreadyInactive = errors.New("employee is already inactive")
+var ErrSelfDeactivation = errors.New("can't deactivate your own account")
+
+type storage interface {
+	SelectEmployeeByID(ctx context.Context, id int) (*st.Employee, error)
+	LockEmployeeByID(ctx context.Context, id int) (*st.Employee, error)
+	ListEmployeesByFilter(ctx context.Context, filter st.ListEmployeeFilter) ([]st.Employee, int, error)
+	UpdateEmployeeStatus(ctx context.Context, id int, status string) error
+	UpdateEmployeeRole(ctx context.Context, id int, roleId int) error
+	WithdrawPendingShiftRequestsByEmployeeID(ctx context.Context, employeeId, reviewedBy int) (int64, error)
+
+	audit.Recorder
+	webhook.Publisher
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+// identityManager toggles the login identity and the sessions of an employee, see auth.Auth
+type identityManager interface {
+	SetIdentityState(ctx context.Context, identityId string, active bool) error
+	RevokeAllSessions(ctx context.Context, employeeId int) error
+}
+
+type EmployeeInterface interface {
+	ListEmployees(ctx context.Context, filter st.ListEmployeeFilter) ([]st.Employee, int, error)
+	GetEmployee(ctx context.Context, id int) (*st.Employee, error)
+	ChangePrimaryRole(ctx context.Context, id, roleId int) error
+	DeactivateEmployee(ctx context.Context, id, actorId int) error
+	ReactivateEmployee(ctx context.Context, id int) error
+}
+
+type Employee struct {
+	storage    storage
+	identities identityManager
+}
+
+func NewEmployee(storage storage, identities identityManager) *Employee {
+	return &Employee{
+		storage:    storage,
+		identities: identities,
+	}
+}
+
+func (e *Employee) ListEmployees(ctx context.Context, filter st.ListEmployeeFilter) ([]st.Employee, int, error) {
+	return e.storage.ListEmployeesByFilter(ctx, filter)
+}
+
+func (e *Employee) GetEmployee(ctx context.Context, id int) (*st.Employee, error) {
+	employee, err := e.storage.SelectEmployeeByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmployeeNotFound
+	}
+	return employee, err
+}
+
+// ChangePrimaryRole only affects the shifts the employee can request from now on,
+// existing requests and assignments are kept
+func (e *Employee) ChangePrimaryRole(ctx context.Context, id, roleId int) (err error) {
+	tctx, err := e.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.storage.Finish(tctx, &err)
+
+	employee, err := e.lockEmployee(tctx, id)
+	if err != nil {
+		return err
+	}
+	before := map[string]interface{}{"PrimaryRole": employee.PrimaryRole}
+	if err = e.storage.UpdateEmployeeRole(tctx, id, roleId); err != nil {
+		return err
+	}
+	return audit.Record(tctx, e.storage, "change_role", audit.EntityEmployee, id, before, map[string]interface{}{"PrimaryRole": roleId})
+}
+
+// DeactivateEmployee marks the employee inactive, withdraws their pending shift requests and disables their login
+// identity, the database changes are rolled back if the identity can't be disabled. their sessions are revoked once
+// the deactivation is committed, a failed revocation is only logged since their refresh tokens are rejected
+// along with the inactive employee and their access tokens expire shortly
+func (e *Employee) DeactivateEmployee(ctx context.Context, id, actorId int) error {
+	if id == actorId {
+		return ErrSelfDeactivation
+	}
+	err := e.setStatus(ctx, id, StatusInactive, func(tctx context.Context) error {
+		withdrawn, err := e.storage.WithdrawPendingShiftRequestsByEmployeeID(tctx, id, actorId)
+		if err != nil {
+			return err
+		}
+		util.Log().WithContext(ctx).WithField("employee_id", id).WithField("withdrawn", withdrawn).
+			Info("withdrew pending shift requests of deactivated employee")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := e.identities.RevokeAllSessions(ctx, id); err != nil {
+		util.Log().WithContext(ctx).WithError(err).WithField("employee_id", id).
+			Warn("sessions of deactivated employee not revoked")
+	}
+	return nil
+}
+
+// ReactivateEmployee marks the employee active again and re-enables their login identity,
+// the requests withdrawn on deactivation are not restored
+func (e *Employee) ReactivateEmployee(ctx context.Context, id int) error {
+	return e.setStatus(ctx, id, StatusActive, nil)
+}
+
+func (e *Employee) setStatus(ctx context.Context, id int, status string, onChange func(tctx context.Context) error) (err error) {
+	tctx, err := e.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.storage.Finish(tctx, &err)
+
+	employee, err := e.lockEmployee(tctx, id)
+	if err != nil {
+		return err
+	}
+	if employee.Status == status {
+		if status == StatusActive {
+			return ErrAlreadyActive
+		}
+		return ErrAlreadyInactive
+	}
+
+	before := map[string]interface{}{"Status": employee.Status}
+	if err = e.storage.UpdateEmployeeStatus(tctx, id, status); err != nil {
+		return err
+	}
+	action := "deactivate"
+	if status == StatusActive {
+		action = "reactivate"
+	}
+	if err = audit.Record(tctx, e.storage, action, audit.EntityEmployee, id, before, map[string]interface{}{"Status": status}); err != nil {
+		return err
+	}
+	if status == StatusActive {
+		err = webhook.Publish(tctx, e.storage, webhook.EmployeeActivated, id, webhook.EmployeePayload{
+			ID:          id,
+			Name:        employee.Name,
+			Status:      status,
+			PrimaryRole: employee.PrimaryRole,
+			LocationID:  employee.LocationID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if onChange != nil {
+		if err = onChange(tctx); err != nil {
+			return err
+		}
+	}
+
+	// the identity is updated last so a failure rolls back the status change
+	if employee.IdentityID == nil {
+		util.Log().WithContext(ctx).WithField("employee_id", id).
+			Warn("employee has no linked identity, only the employee status is changed")
+		return nil
+	}
+	return e.identities.SetIdentityState(tctx, *employee.IdentityID, status == StatusActive)
+}
+
+func (e *Employee) lockEmployee(ctx context.Context, id int) (*st.Employee, error) {
+	employee, err := e.storage.LockEmployeeByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmployeeNotFound
+	}
+	return employee, err
+}
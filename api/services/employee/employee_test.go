@@ -0,0 +1,209 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	employees map[int]*st.Employee
+	withdrawn []int
+	audits    []st.AuditLog
+	events    []st.OutboxEvent
+
+	committed  bool
+	rolledBack bool
+}
+
+func (m *mockStorage) SelectEmployeeByID(ctx context.Context, id int) (*st.Employee, error) {
+	return m.LockEmployeeByID(ctx, id)
+}
+
+func (m *mockStorage) LockEmployeeByID(ctx context.Context, id int) (*st.Employee, error) {
+	employee, ok := m.employees[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *employee
+	return &copied, nil
+}
+
+func (m *mockStorage) ListEmployeesByFilter(ctx context.Context, filter st.ListEmployeeFilter) ([]st.Employee, int, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (m *mockStorage) UpdateEmployeeStatus(ctx context.Context, id int, status string) error {
+	m.employees[id].Status = status
+	return nil
+}
+
+func (m *mockStorage) UpdateEmployeeRole(ctx context.Context, id int, roleId int) error {
+	m.employees[id].PrimaryRole = roleId
+	return nil
+}
+
+func (m *mockStorage) WithdrawPendingShiftRequestsByEmployeeID(ctx context.Context, employeeId, reviewedBy int) (int64, error) {
+	m.withdrawn = append(m.withdrawn, employeeId)
+	return 1, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error {
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+type mockIdentityManager struct {
+	states    map[string]bool
+	revoked   []int
+	err       error
+	revokeErr error
+}
+
+func (m *mockIdentityManager) SetIdentityState(ctx context.Context, identityId string, active bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.states[identityId] = active
+	return nil
+}
+
+func (m *mockIdentityManager) RevokeAllSessions(ctx context.Context, employeeId int) error {
+	if m.revokeErr != nil {
+		return m.revokeErr
+	}
+	m.revoked = append(m.revoked, employeeId)
+	return nil
+}
+
+func newMocks() (*mockStorage, *mockIdentityManager) {
+	identityId := "kratos-4"
+	return &mockStorage{employees: map[int]*st.Employee{
+			1: {ID: 1, Status: StatusActive, PrimaryRole: 0},
+			4: {ID: 4, Name: "Alice", Status: StatusActive, PrimaryRole: 1, LocationID: 2, IdentityID: &identityId},
+			5: {ID: 5, Status: StatusInactive, PrimaryRole: 1},
+		}},
+		&mockIdentityManager{states: map[string]bool{}}
+}
+
+func TestDeactivateEmployee(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		storage, identities := newMocks()
+		err := NewEmployee(storage, identities).DeactivateEmployee(ctx, 4, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusInactive, storage.employees[4].Status)
+		assert.Equal(t, []int{4}, storage.withdrawn)
+		assert.Equal(t, false, identities.states["kratos-4"])
+		assert.Equal(t, []int{4}, identities.revoked)
+		assert.True(t, storage.committed)
+		require.Len(t, storage.audits, 1)
+		assert.Equal(t, "deactivate", storage.audits[0].Action)
+		assert.Equal(t, audit.EntityEmployee, storage.audits[0].EntityType)
+		assert.JSONEq(t, `{"Status":"ACTIVE"}`, string(*storage.audits[0].Before))
+		assert.JSONEq(t, `{"Status":"INACTIVE"}`, string(*storage.audits[0].After))
+	})
+
+	t.Run("identity failure rolls back", func(t *testing.T) {
+		storage, identities := newMocks()
+		identities.err = errors.New("kratos down")
+		err := NewEmployee(storage, identities).DeactivateEmployee(ctx, 4, 1)
+		assert.EqualError(t, err, "kratos down")
+		assert.True(t, storage.rolledBack)
+		assert.False(t, storage.committed)
+		// the sessions are only revoked once the deactivation is committed
+		assert.Empty(t, identities.revoked)
+	})
+
+	t.Run("revocation failure keeps the deactivation", func(t *testing.T) {
+		storage, identities := newMocks()
+		identities.revokeErr = errors.New("token revocation is not configured")
+		err := NewEmployee(storage, identities).DeactivateEmployee(ctx, 4, 1)
+		assert.NoError(t, err)
+		assert.True(t, storage.committed)
+		assert.Equal(t, StatusInactive, storage.employees[4].Status)
+		assert.Equal(t, false, identities.states["kratos-4"])
+	})
+
+	t.Run("employee without identity", func(t *testing.T) {
+		storage, identities := newMocks()
+		storage.employees[5].Status = StatusActive
+		err := NewEmployee(storage, identities).DeactivateEmployee(ctx, 5, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusInactive, storage.employees[5].Status)
+		assert.Empty(t, identities.states)
+		assert.Equal(t, []int{5}, identities.revoked)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		storage, identities := newMocks()
+		svc := NewEmployee(storage, identities)
+		assert.ErrorIs(t, svc.DeactivateEmployee(ctx, 1, 1), ErrSelfDeactivation)
+		assert.ErrorIs(t, svc.DeactivateEmployee(ctx, 5, 1), ErrAlreadyInactive)
+		assert.ErrorIs(t, svc.DeactivateEmployee(ctx, 9, 1), ErrEmployeeNotFound)
+		assert.Empty(t, storage.withdrawn)
+	})
+}
+
+func TestReactivateEmployee(t *testing.T) {
+	ctx := context.Background()
+	storage, identities := newMocks()
+	svc := NewEmployee(storage, identities)
+
+	assert.NoError(t, svc.DeactivateEmployee(ctx, 4, 1))
+	assert.Empty(t, storage.events)
+	assert.NoError(t, svc.ReactivateEmployee(ctx, 4))
+	assert.Equal(t, StatusActive, storage.employees[4].Status)
+	assert.Equal(t, true, identities.states["kratos-4"])
+	require.Len(t, storage.events, 1)
+	assert.Equal(t, webhook.EmployeeActivated, storage.events[0].EventType)
+	assert.JSONEq(t, `{"id":4,"name":"Alice","status":"ACTIVE","primaryRole":1,"locationId":2}`, string(storage.events[0].Payload))
+
+	assert.ErrorIs(t, svc.ReactivateEmployee(ctx, 4), ErrAlreadyActive)
+}
+
+func TestChangePrimaryRole(t *testing.T) {
+	ctx := context.Background()
+	storage, identities := newMocks()
+	svc := NewEmployee(storage, identities)
+
+	assert.NoError(t, svc.ChangePrimaryRole(ctx, 4, 2))
+	assert.Equal(t, 2, storage.employees[4].PrimaryRole)
+	assert.ErrorIs(t, svc.ChangePrimaryRole(ctx, 9, 2), ErrEmployeeNotFound)
+}
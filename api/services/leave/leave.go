@@ -0,0 +1,193 @@
+package leave
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+)
+
+const (
+	StatusPending   = "PENDING"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusWithdrawn = "WITHDRAWN"
+)
+
+const maxReasonLength = 500
+
+var ErrLeaveTypeNotFound = errors.New("leave type not found")
+var ErrInvalidPeriod = errors.New("end date must not be before start date")
+var ErrInvalidReason = errors.New("reason must be at most 500 characters")
+var ErrInvalidAdjustment = errors.New("adjustment must be a non zero number of days")
+var ErrBalanceNotTracked = errors.New("leave type does not track a balance")
+var ErrInsufficientBalance = errors.New("insufficient leave balance")
+var ErrOverlappingLeave = errors.New("employee already has leave overlapping this period")
+var ErrRequestNotFound = errors.New("leave request not found")
+var ErrRequestNotPending = errors.New("leave request is not pending")
+var ErrReviewNotAllowed = errors.New("reviewer may not review leave of this employee")
+var ErrEmployeeNotFound = errors.New("employee not found")
+
+// ErrEmployeeOnLeave refuses a shift during approved leave, see OnLeaveError
+var ErrEmployeeOnLeave = errors.New("employee is on approved leave during the shift")
+
+type storage interface {
+	ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error)
+	SelectLeaveTypeByID(ctx context.Context, id int) (*st.LeaveType, error)
+	CreateLeaveRequest(ctx context.Context, r st.LeaveRequest) (int, error)
+	LockLeaveRequestByID(ctx context.Context, id int) (*st.LeaveRequest, error)
+	ReviewLeaveRequest(ctx context.Context, id int, status string, reviewedBy int) error
+	WithdrawLeaveRequest(ctx context.Context, id, employeeId int) (bool, error)
+	ListLeaveRequestsByFilter(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error)
+	ListApprovedLeaveByTimeRange(ctx context.Context, employeeId, locationId int, start, end time.Time) ([]st.LeaveRequest, error)
+	FlagApprovedShiftRequestsOnLeave(ctx context.Context, leaveRequestId int) ([]int, error)
+	ListLeaveBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error)
+	SumLeaveBalance(ctx context.Context, employeeId, leaveTypeId int) (float64, error)
+	CreateLeaveBalanceEntry(ctx context.Context, e st.LeaveBalanceEntry) (int, error)
+	AccrueLeave(ctx context.Context, month time.Time) (int64, error)
+	LockEmployeeByID(ctx context.Context, id int) (*st.Employee, error)
+
+	audit.Recorder
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type LeaveInterface interface {
+	ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error)
+	RequestLeave(ctx context.Context, r st.LeaveRequest) (int, error)
+	WithdrawLeaveRequest(ctx context.Context, employeeId, id int) error
+	ListLeaveRequests(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error)
+	ListBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error)
+	AdjustBalance(ctx context.Context, entry st.LeaveBalanceEntry) error
+
+	ApproveLeaveRequest(ctx context.Context, id int, reviewer Reviewer) ([]int, error)
+	RejectLeaveRequest(ctx context.Context, id int, reviewer Reviewer) error
+	CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error
+}
+
+// OnLeaveError is returned when a shift falls into approved leave of the employee
+type OnLeaveError struct {
+	LeaveRequestID int
+}
+
+func (e *OnLeaveError) Error() string {
+	return ErrEmployeeOnLeave.Error()
+}
+
+func (e *OnLeaveError) Unwrap() error {
+	return ErrEmployeeOnLeave
+}
+
+type Leave struct {
+	storage storage
+	now     func() time.Time
+}
+
+func NewLeave(storage storage) *Leave {
+	return &Leave{
+		storage: storage,
+		now:     time.Now,
+	}
+}
+
+func (l *Leave) ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error) {
+	return l.storage.ListLeaveTypes(ctx)
+}
+
+// ListBalances returns the balance of the employee for every leave type tracking one
+func (l *Leave) ListBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error) {
+	return l.storage.ListLeaveBalances(ctx, employeeId)
+}
+
+// AdjustBalance credits, or debits when negative, the balance of the employee, e.g. for carried over leave
+func (l *Leave) AdjustBalance(ctx context.Context, entry st.LeaveBalanceEntry) (err error) {
+	if entry.Days == 0 {
+		return ErrInvalidAdjustment
+	}
+	if len([]rune(entry.Note)) > maxReasonLength {
+		return ErrInvalidReason
+	}
+	leaveType, err := l.leaveType(ctx, entry.LeaveTypeID)
+	if err != nil {
+		return err
+	}
+	if !leaveType.TracksBalance {
+		return ErrBalanceNotTracked
+	}
+	entry.Kind = st.LeaveEntryAdjustment
+	entry.AccrualMonth, entry.LeaveRequestID = nil, nil
+
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	entry.ID, err = l.storage.CreateLeaveBalanceEntry(tctx, entry)
+	if errors.Is(err, st.ErrUnknownEmployee) {
+		return ErrEmployeeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return audit.Record(tctx, l.storage, "adjust", audit.EntityLeaveBalance, entry.EmployeeID, nil, entry)
+}
+
+// CheckShift returns an *OnLeaveError if the shift of the location overlaps approved leave of the employee,
+// the leave days are bounded in the timezone of the location
+func (l *Leave) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	leave, err := l.storage.ListApprovedLeaveByTimeRange(ctx, employeeId, locationId, start, end)
+	if err != nil {
+		return err
+	}
+	if len(leave) > 0 {
+		return &OnLeaveError{LeaveRequestID: leave[0].ID}
+	}
+	return nil
+}
+
+// Accrue credits the monthly accrual of the current month, re-running it within the month is a no-op
+func (l *Leave) Accrue(ctx context.Context) (int64, error) {
+	now := l.now().UTC()
+	return l.storage.AccrueLeave(ctx, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// StartAccrual credits the monthly accruals now and then on every tick until ctx is done
+func (l *Leave) StartAccrual(ctx context.Context, tick time.Duration) {
+	accrue := func() {
+		credited, err := l.Accrue(ctx)
+		if err != nil {
+			util.Log().WithError(err).Error("periodic leave accrual failed")
+		}
+		if credited > 0 {
+			util.Log().WithField("credited", credited).Info("leave accrued")
+		}
+	}
+	accrue()
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				accrue()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (l *Leave) leaveType(ctx context.Context, id int) (*st.LeaveType, error) {
+	leaveType, err := l.storage.SelectLeaveTypeByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLeaveTypeNotFound
+	}
+	return leaveType, err
+}
@@ -0,0 +1,353 @@
+package leave
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	types      map[int]st.LeaveType
+	requests   map[int]*st.LeaveRequest
+	employees  map[int]*st.Employee
+	balance    float64
+	entries    []st.LeaveBalanceEntry
+	created    *st.LeaveRequest
+	reviewed   string
+	flagged    []int
+	approved   []st.LeaveRequest
+	accrued    time.Time
+	createErr  error
+	audits     []st.AuditLog
+	committed  bool
+	rolledBack bool
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		types: map[int]st.LeaveType{
+			1: {ID: 1, Code: "ANNUAL", TracksBalance: true, MonthlyAccrual: 2.08},
+			2: {ID: 2, Code: "SICK"},
+		},
+		requests: map[int]*st.LeaveRequest{},
+		employees: map[int]*st.Employee{
+			10: {ID: 10, PrimaryRole: 3, LocationID: 1},
+		},
+	}
+}
+
+func (m *mockStorage) ListLeaveTypes(ctx context.Context) ([]st.LeaveType, error) {
+	var recs []st.LeaveType
+	for _, t := range m.types {
+		recs = append(recs, t)
+	}
+	return recs, nil
+}
+
+func (m *mockStorage) SelectLeaveTypeByID(ctx context.Context, id int) (*st.LeaveType, error) {
+	t, ok := m.types[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+func (m *mockStorage) CreateLeaveRequest(ctx context.Context, r st.LeaveRequest) (int, error) {
+	if m.createErr != nil {
+		return 0, m.createErr
+	}
+	m.created = &r
+	return 5, nil
+}
+
+func (m *mockStorage) LockLeaveRequestByID(ctx context.Context, id int) (*st.LeaveRequest, error) {
+	r, ok := m.requests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (m *mockStorage) ReviewLeaveRequest(ctx context.Context, id int, status string, reviewedBy int) error {
+	m.reviewed = status
+	return nil
+}
+
+func (m *mockStorage) WithdrawLeaveRequest(ctx context.Context, id, employeeId int) (bool, error) {
+	r, ok := m.requests[id]
+	return ok && r.EmployeeID == employeeId && r.Status == StatusPending, nil
+}
+
+func (m *mockStorage) ListLeaveRequestsByFilter(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) ListApprovedLeaveByTimeRange(ctx context.Context, employeeId, locationId int, start, end time.Time) ([]st.LeaveRequest, error) {
+	var recs []st.LeaveRequest
+	for _, r := range m.approved {
+		if r.StartDate.Before(end) && r.EndDate.AddDate(0, 0, 1).After(start) {
+			recs = append(recs, r)
+		}
+	}
+	return recs, nil
+}
+
+func (m *mockStorage) FlagApprovedShiftRequestsOnLeave(ctx context.Context, leaveRequestId int) ([]int, error) {
+	return m.flagged, nil
+}
+
+func (m *mockStorage) ListLeaveBalances(ctx context.Context, employeeId int) ([]st.LeaveBalance, error) {
+	return []st.LeaveBalance{{LeaveTypeID: 1, LeaveTypeCode: "ANNUAL", Days: m.balance}}, nil
+}
+
+func (m *mockStorage) SumLeaveBalance(ctx context.Context, employeeId, leaveTypeId int) (float64, error) {
+	return m.balance, nil
+}
+
+func (m *mockStorage) CreateLeaveBalanceEntry(ctx context.Context, e st.LeaveBalanceEntry) (int, error) {
+	m.entries = append(m.entries, e)
+	return len(m.entries), nil
+}
+
+func (m *mockStorage) AccrueLeave(ctx context.Context, month time.Time) (int64, error) {
+	m.accrued = month
+	return 1, nil
+}
+
+func (m *mockStorage) LockEmployeeByID(ctx context.Context, id int) (*st.Employee, error) {
+	e, ok := m.employees[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestRequestLeave(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     st.LeaveRequest
+		balance     float64
+		createErr   error
+		expectedErr error
+		days        float64
+	}{
+		{
+			name:    "within balance",
+			request: st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 1, StartDate: date("2026-03-02"), EndDate: date("2026-03-04")},
+			balance: 3,
+			days:    3,
+		},
+		{
+			name:        "beyond balance",
+			request:     st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 1, StartDate: date("2026-03-02"), EndDate: date("2026-03-04")},
+			balance:     2.5,
+			expectedErr: ErrInsufficientBalance,
+		},
+		{
+			name:    "untracked type ignores the balance",
+			request: st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 2, StartDate: date("2026-03-02"), EndDate: date("2026-03-02")},
+			days:    1,
+		},
+		{
+			name:        "end before start",
+			request:     st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 2, StartDate: date("2026-03-02"), EndDate: date("2026-03-01")},
+			expectedErr: ErrInvalidPeriod,
+		},
+		{
+			name:        "unknown leave type",
+			request:     st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 9, StartDate: date("2026-03-02"), EndDate: date("2026-03-02")},
+			expectedErr: ErrLeaveTypeNotFound,
+		},
+		{
+			name:        "overlapping leave",
+			request:     st.LeaveRequest{EmployeeID: 10, LeaveTypeID: 2, StartDate: date("2026-03-02"), EndDate: date("2026-03-02")},
+			createErr:   st.ErrOverlappingLeaveRequest,
+			expectedErr: ErrOverlappingLeave,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMockStorage()
+			storage.balance = tt.balance
+			storage.createErr = tt.createErr
+			l := NewLeave(storage)
+
+			id, err := l.RequestLeave(context.Background(), tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 5, id)
+			assert.Equal(t, tt.days, storage.created.Days)
+		})
+	}
+}
+
+func TestApproveLeaveRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     st.LeaveRequest
+		reviewer    Reviewer
+		balance     float64
+		expectedErr error
+		debited     bool
+	}{
+		{
+			name:     "tracked type debits the balance",
+			request:  st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 1, Days: 2, Status: StatusPending},
+			reviewer: Reviewer{EmployeeID: 20},
+			balance:  5,
+			debited:  true,
+		},
+		{
+			name:     "untracked type",
+			request:  st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 2, Days: 2, Status: StatusPending},
+			reviewer: Reviewer{EmployeeID: 20},
+		},
+		{
+			name:        "balance spent since the request",
+			request:     st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 1, Days: 2, Status: StatusPending},
+			reviewer:    Reviewer{EmployeeID: 20},
+			balance:     1,
+			expectedErr: ErrInsufficientBalance,
+		},
+		{
+			name:        "not pending",
+			request:     st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 2, Days: 2, Status: StatusWithdrawn},
+			reviewer:    Reviewer{EmployeeID: 20},
+			expectedErr: ErrRequestNotPending,
+		},
+		{
+			name:        "reviewer restricted to other job roles",
+			request:     st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 2, Days: 2, Status: StatusPending},
+			reviewer:    Reviewer{EmployeeID: 20, RoleIDs: []int{4}},
+			expectedErr: ErrReviewNotAllowed,
+		},
+		{
+			name:        "reviewer restricted to other locations",
+			request:     st.LeaveRequest{ID: 1, EmployeeID: 10, LeaveTypeID: 2, Days: 2, Status: StatusPending},
+			reviewer:    Reviewer{EmployeeID: 20, LocationIDs: []int{2}},
+			expectedErr: ErrRequestNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMockStorage()
+			storage.balance = tt.balance
+			storage.requests[tt.request.ID] = &tt.request
+			storage.flagged = []int{42}
+			l := NewLeave(storage)
+
+			shiftIds, err := l.ApproveLeaveRequest(context.Background(), tt.request.ID, tt.reviewer)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, storage.rolledBack)
+				assert.Empty(t, storage.reviewed)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, storage.committed)
+			assert.Equal(t, StatusApproved, storage.reviewed)
+			assert.Equal(t, []int{42}, shiftIds)
+			if tt.debited {
+				require.Len(t, storage.entries, 1)
+				assert.Equal(t, -tt.request.Days, storage.entries[0].Days)
+				assert.Equal(t, st.LeaveEntryLeave, storage.entries[0].Kind)
+			} else {
+				assert.Empty(t, storage.entries)
+			}
+		})
+	}
+}
+
+func TestRejectLeaveRequestNotFound(t *testing.T) {
+	l := NewLeave(newMockStorage())
+	err := l.RejectLeaveRequest(context.Background(), 99, Reviewer{EmployeeID: 20})
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+}
+
+func TestAdjustBalance(t *testing.T) {
+	storage := newMockStorage()
+	l := NewLeave(storage)
+
+	assert.ErrorIs(t, l.AdjustBalance(context.Background(), st.LeaveBalanceEntry{EmployeeID: 10, LeaveTypeID: 1}), ErrInvalidAdjustment)
+	assert.ErrorIs(t, l.AdjustBalance(context.Background(), st.LeaveBalanceEntry{EmployeeID: 10, LeaveTypeID: 2, Days: 1}), ErrBalanceNotTracked)
+
+	require.NoError(t, l.AdjustBalance(context.Background(), st.LeaveBalanceEntry{EmployeeID: 10, LeaveTypeID: 1, Days: -1.5}))
+	require.Len(t, storage.entries, 1)
+	assert.Equal(t, st.LeaveEntryAdjustment, storage.entries[0].Kind)
+	require.Len(t, storage.audits, 1)
+	assert.Equal(t, "adjust", storage.audits[0].Action)
+	assert.Equal(t, audit.EntityLeaveBalance, storage.audits[0].EntityType)
+	assert.Equal(t, 10, storage.audits[0].EntityID)
+	assert.Nil(t, storage.audits[0].Before)
+	assert.True(t, storage.committed)
+}
+
+func TestCheckShift(t *testing.T) {
+	storage := newMockStorage()
+	storage.approved = []st.LeaveRequest{{ID: 3, StartDate: date("2026-03-02"), EndDate: date("2026-03-03")}}
+	l := NewLeave(storage)
+
+	// the last leave day runs until midnight
+	err := l.CheckShift(context.Background(), 10, 1, date("2026-03-03").Add(22*time.Hour), date("2026-03-04").Add(2*time.Hour))
+	var onLeave *OnLeaveError
+	require.ErrorAs(t, err, &onLeave)
+	assert.Equal(t, 3, onLeave.LeaveRequestID)
+	assert.ErrorIs(t, err, ErrEmployeeOnLeave)
+
+	err = l.CheckShift(context.Background(), 10, 1, date("2026-03-04").Add(8*time.Hour), date("2026-03-04").Add(16*time.Hour))
+	assert.NoError(t, err)
+}
+
+func TestAccrue(t *testing.T) {
+	storage := newMockStorage()
+	l := NewLeave(storage)
+	l.now = func() time.Time { return time.Date(2026, 3, 17, 10, 0, 0, 0, time.UTC) }
+
+	_, err := l.Accrue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, date("2026-03-01"), storage.accrued)
+}
@@ -0,0 +1,210 @@
+package leave
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+)
+
+// RequestLeave submits a PENDING request of the employee for whole days from StartDate through EndDate.
+// the requests of a leave type tracking a balance are refused beyond the current balance
+func (l *Leave) RequestLeave(ctx context.Context, r st.LeaveRequest) (id int, err error) {
+	r.StartDate, r.EndDate = util.CivilDate(r.StartDate), util.CivilDate(r.EndDate)
+	if r.EndDate.Before(r.StartDate) {
+		return 0, ErrInvalidPeriod
+	}
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len([]rune(r.Reason)) > maxReasonLength {
+		return 0, ErrInvalidReason
+	}
+	leaveType, err := l.leaveType(ctx, r.LeaveTypeID)
+	if err != nil {
+		return 0, err
+	}
+	r.Days = leaveDays(r.StartDate, r.EndDate)
+	if leaveType.TracksBalance {
+		balance, err := l.storage.SumLeaveBalance(ctx, r.EmployeeID, r.LeaveTypeID)
+		if err != nil {
+			return 0, err
+		}
+		if balance < r.Days {
+			return 0, ErrInsufficientBalance
+		}
+	}
+
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	id, err = l.storage.CreateLeaveRequest(tctx, r)
+	switch {
+	case errors.Is(err, st.ErrOverlappingLeaveRequest):
+		return 0, ErrOverlappingLeave
+	case errors.Is(err, st.ErrUnknownLeaveType):
+		return 0, ErrLeaveTypeNotFound
+	case err != nil:
+		return 0, err
+	}
+	r.ID, r.Status = id, StatusPending
+	return id, audit.Record(tctx, l.storage, "create", audit.EntityLeaveRequest, id, nil, r)
+}
+
+// WithdrawLeaveRequest withdraws a PENDING request of the employee,
+// returns ErrRequestNotPending if the employee has no such pending request
+func (l *Leave) WithdrawLeaveRequest(ctx context.Context, employeeId, id int) (err error) {
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	withdrawn, err := l.storage.WithdrawLeaveRequest(tctx, id, employeeId)
+	if err != nil {
+		return err
+	}
+	if !withdrawn {
+		return ErrRequestNotPending
+	}
+	return audit.Record(tctx, l.storage, "withdraw", audit.EntityLeaveRequest, id,
+		map[string]interface{}{"Status": StatusPending}, map[string]interface{}{"Status": StatusWithdrawn})
+}
+
+// ListLeaveRequests lists the requests overlapping the [start, end] dates
+func (l *Leave) ListLeaveRequests(ctx context.Context, filter st.ListLeaveRequestFilter, start, end time.Time) ([]st.LeaveRequestWithDetails, error) {
+	return l.storage.ListLeaveRequestsByFilter(ctx, filter, util.CivilDate(start), util.CivilDate(end))
+}
+
+// Reviewer is the employee reviewing leave requests,
+// restricted to the employees of some primary job roles and locations
+type Reviewer struct {
+	EmployeeID  int
+	RoleIDs     []int // nil for every job role
+	LocationIDs []int // nil for every location
+}
+
+// check returns ErrRequestNotFound for the employees of other locations, ErrReviewNotAllowed for the other job roles
+func (r Reviewer) check(employee *st.Employee) error {
+	if !st.InLocations(r.LocationIDs, employee.LocationID) {
+		return ErrRequestNotFound
+	}
+	if r.RoleIDs == nil {
+		return nil
+	}
+	for _, id := range r.RoleIDs {
+		if id == employee.PrimaryRole {
+			return nil
+		}
+	}
+	return ErrReviewNotAllowed
+}
+
+// ApproveLeaveRequest approves a PENDING request and debits the balance of its leave type.
+// the approved shifts of the employee during the leave are flagged as conflicting, their ids are returned
+// for the reviewer to reassign them
+func (l *Leave) ApproveLeaveRequest(ctx context.Context, id int, reviewer Reviewer) (conflictingShiftIds []int, err error) {
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	req, err := l.lockPendingRequest(tctx, id, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	leaveType, err := l.leaveType(tctx, req.LeaveTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if leaveType.TracksBalance {
+		// the employee row is locked, concurrent approvals can't both spend the same balance
+		balance, err := l.storage.SumLeaveBalance(tctx, req.EmployeeID, req.LeaveTypeID)
+		if err != nil {
+			return nil, err
+		}
+		if balance < req.Days {
+			return nil, ErrInsufficientBalance
+		}
+		reviewerId := reviewer.EmployeeID
+		if _, err = l.storage.CreateLeaveBalanceEntry(tctx, st.LeaveBalanceEntry{
+			EmployeeID:     req.EmployeeID,
+			LeaveTypeID:    req.LeaveTypeID,
+			Days:           -req.Days,
+			Kind:           st.LeaveEntryLeave,
+			LeaveRequestID: &req.ID,
+			CreatedBy:      &reviewerId,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err = l.storage.ReviewLeaveRequest(tctx, req.ID, StatusApproved, reviewer.EmployeeID); err != nil {
+		return nil, err
+	}
+	if conflictingShiftIds, err = l.storage.FlagApprovedShiftRequestsOnLeave(tctx, req.ID); err != nil {
+		return nil, err
+	}
+	after := reviewed(StatusApproved, reviewer)
+	after["ConflictingShiftIDs"] = conflictingShiftIds
+	if err = audit.Record(tctx, l.storage, "approve", audit.EntityLeaveRequest, req.ID, req, after); err != nil {
+		return nil, err
+	}
+	return conflictingShiftIds, nil
+}
+
+// RejectLeaveRequest rejects a single PENDING request
+func (l *Leave) RejectLeaveRequest(ctx context.Context, id int, reviewer Reviewer) (err error) {
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	req, err := l.lockPendingRequest(tctx, id, reviewer)
+	if err != nil {
+		return err
+	}
+	if err = l.storage.ReviewLeaveRequest(tctx, req.ID, StatusRejected, reviewer.EmployeeID); err != nil {
+		return err
+	}
+	return audit.Record(tctx, l.storage, "reject", audit.EntityLeaveRequest, req.ID, req, reviewed(StatusRejected, reviewer))
+}
+
+// reviewed is the audited state of a reviewed request
+func reviewed(status string, reviewer Reviewer) map[string]interface{} {
+	return map[string]interface{}{"Status": status, "ReviewedBy": reviewer.EmployeeID}
+}
+
+// lockPendingRequest locks the request and its employee, after checking the reviewer may review it
+func (l *Leave) lockPendingRequest(ctx context.Context, id int, reviewer Reviewer) (*st.LeaveRequest, error) {
+	req, err := l.storage.LockLeaveRequestByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+	employee, err := l.storage.LockEmployeeByID(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := reviewer.check(employee); err != nil {
+		return nil, err
+	}
+	if req.Status != StatusPending {
+		return nil, ErrRequestNotPending
+	}
+	return req, nil
+}
+
+// leaveDays counts the calendar days from start through end
+func leaveDays(start, end time.Time) float64 {
+	return float64(int(end.Sub(start).Hours()/24) + 1)
+}
@@ -0,0 +1,212 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+	"payd/util"
+)
+
+const maxLocationNameLength = 100
+
+var ErrLocationNotFound = errors.New("location not found")
+var ErrInvalidLocationName = errors.New("invalid location name")
+var ErrDuplicateLocationName = errors.New("a location with the same name already exists")
+var ErrEmployeeNotFound = errors.New("employee not found")
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// defaultTimezone of the locations created without one
+const defaultTimezone = "UTC"
+
+type storage interface {
+	ListLocations(ctx context.Context, ids []int) ([]st.Location, error)
+	CreateLocation(ctx context.Context, name, timezone string) (int, error)
+	UpdateLocation(ctx context.Context, id int, name, timezone string) (bool, error)
+	ReplaceEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) ([]int, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type LocationInterface interface {
+	ListLocations(ctx context.Context, ids []int) ([]st.Location, error)
+	CreateLocation(ctx context.Context, name, timezone string) (st.Location, error)
+	UpdateLocation(ctx context.Context, id int, name, timezone string) error
+	SetEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) error
+	Timezones(ctx context.Context) (Timezones, error)
+}
+
+// Timezones maps the locations to their business timezone
+type Timezones map[int]*time.Location
+
+// In returns t in the timezone of the location, in UTC for a location without one
+func (z Timezones) In(locationId int, t time.Time) time.Time {
+	if loc, ok := z[locationId]; ok {
+		return t.In(loc)
+	}
+	return t.UTC()
+}
+
+// InPtr is In for an optional time
+func (z Timezones) InPtr(locationId int, t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	zoned := z.In(locationId, *t)
+	return &zoned
+}
+
+type Location struct {
+	storage storage
+}
+
+func NewLocation(storage storage) *Location {
+	return &Location{storage: storage}
+}
+
+// ListLocations lists the locations by name, restricted to ids when not nil
+func (l *Location) ListLocations(ctx context.Context, ids []int) ([]st.Location, error) {
+	return l.storage.ListLocations(ctx, ids)
+}
+
+// CreateLocation creates a location in the IANA timezone, UTC when empty
+func (l *Location) CreateLocation(ctx context.Context, name, timezone string) (location st.Location, err error) {
+	name, err = normalizeLocationName(name)
+	if err != nil {
+		return st.Location{}, err
+	}
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return st.Location{}, ErrInvalidTimezone
+	}
+
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return st.Location{}, err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	id, err := l.storage.CreateLocation(tctx, name, timezone)
+	if err != nil {
+		return st.Location{}, mapStorageError(err)
+	}
+	location = st.Location{ID: id, Name: name, Timezone: timezone}
+	if err = audit.Record(tctx, l.storage, "create", audit.EntityLocation, id, nil, location); err != nil {
+		return st.Location{}, err
+	}
+	return location, nil
+}
+
+// UpdateLocation renames the location and moves it to the IANA timezone, an empty timezone keeps the current one.
+// the shifts are instants, they keep their time and are rendered in the new timezone
+func (l *Location) UpdateLocation(ctx context.Context, id int, name, timezone string) (err error) {
+	name, err = normalizeLocationName(name)
+	if err != nil {
+		return err
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return ErrInvalidTimezone
+		}
+	}
+
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	before, err := l.storage.ListLocations(tctx, []int{id})
+	if err != nil {
+		return err
+	}
+	renamed, err := l.storage.UpdateLocation(tctx, id, name, timezone)
+	if err != nil {
+		return mapStorageError(err)
+	}
+	if !renamed || len(before) == 0 {
+		return ErrLocationNotFound
+	}
+	after := st.Location{ID: id, Name: name, Timezone: timezone}
+	if timezone == "" {
+		after.Timezone = before[0].Timezone
+	}
+	return audit.Record(tctx, l.storage, "update", audit.EntityLocation, id, before[0], after)
+}
+
+// Timezones returns the timezone of every location
+func (l *Location) Timezones(ctx context.Context) (Timezones, error) {
+	locations, err := l.storage.ListLocations(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	zones := make(Timezones, len(locations))
+	for _, loc := range locations {
+		tz, err := time.LoadLocation(loc.Timezone)
+		if err != nil {
+			// stored timezones are validated, the tz database of the host may still lack one
+			util.Log().WithContext(ctx).WithError(err).WithField("location_id", loc.ID).Error("load location timezone")
+			continue
+		}
+		zones[loc.ID] = tz
+	}
+	return zones, nil
+}
+
+// SetEmployeeLocations replaces the locations the employee manages on top of their own location,
+// the change applies to the tokens issued from now on
+func (l *Location) SetEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) (err error) {
+	tctx, err := l.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.storage.Finish(tctx, &err)
+
+	locationIds = dedupe(locationIds)
+	replaced, err := l.storage.ReplaceEmployeeLocations(tctx, employeeId, locationIds)
+	if err != nil {
+		return mapStorageError(err)
+	}
+	return audit.Record(tctx, l.storage, "set_locations", audit.EntityEmployee, employeeId,
+		map[string]interface{}{"ManagedLocationIDs": replaced}, map[string]interface{}{"ManagedLocationIDs": locationIds})
+}
+
+func normalizeLocationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxLocationNameLength {
+		return "", ErrInvalidLocationName
+	}
+	return name, nil
+}
+
+func dedupe(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func mapStorageError(err error) error {
+	switch {
+	case errors.Is(err, st.ErrDuplicateLocationName):
+		return ErrDuplicateLocationName
+	case errors.Is(err, st.ErrUnknownLocation):
+		return ErrLocationNotFound
+	case errors.Is(err, st.ErrUnknownEmployee):
+		return ErrEmployeeNotFound
+	}
+	return err
+}
@@ -0,0 +1,167 @@
+package location
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	locations  []st.Location
+	managed    map[int][]int
+	audits     []st.AuditLog
+	committed  bool
+	rolledBack bool
+}
+
+func (m *mockStorage) ListLocations(ctx context.Context, ids []int) ([]st.Location, error) {
+	var locations []st.Location
+	for _, l := range m.locations {
+		if st.InLocations(ids, l.ID) {
+			locations = append(locations, l)
+		}
+	}
+	return locations, nil
+}
+
+func (m *mockStorage) CreateLocation(ctx context.Context, name, timezone string) (int, error) {
+	for _, l := range m.locations {
+		if strings.EqualFold(l.Name, name) {
+			return 0, st.ErrDuplicateLocationName
+		}
+	}
+	id := len(m.locations) + 1
+	m.locations = append(m.locations, st.Location{ID: id, Name: name, Timezone: timezone})
+	return id, nil
+}
+
+func (m *mockStorage) UpdateLocation(ctx context.Context, id int, name, timezone string) (bool, error) {
+	for i := range m.locations {
+		if m.locations[i].ID == id {
+			m.locations[i].Name = name
+			if timezone != "" {
+				m.locations[i].Timezone = timezone
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockStorage) ReplaceEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) ([]int, error) {
+	for _, id := range locationIds {
+		if id > len(m.locations) {
+			return nil, st.ErrUnknownLocation
+		}
+	}
+	replaced := m.managed[employeeId]
+	m.managed[employeeId] = locationIds
+	return replaced, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func TestCreateAndUpdateLocation(t *testing.T) {
+	ctx := context.Background()
+	storage := &mockStorage{locations: []st.Location{{ID: 1, Name: "Main", Timezone: "UTC"}}}
+	svc := NewLocation(storage)
+
+	created, err := svc.CreateLocation(ctx, "  Downtown ", "")
+	require.NoError(t, err)
+	assert.Equal(t, st.Location{ID: 2, Name: "Downtown", Timezone: "UTC"}, created)
+
+	_, err = svc.CreateLocation(ctx, "downtown", "")
+	assert.ErrorIs(t, err, ErrDuplicateLocationName)
+
+	_, err = svc.CreateLocation(ctx, " ", "")
+	assert.ErrorIs(t, err, ErrInvalidLocationName)
+
+	_, err = svc.CreateLocation(ctx, "Airport", "Europe/Nowhere")
+	assert.ErrorIs(t, err, ErrInvalidTimezone)
+
+	assert.NoError(t, svc.UpdateLocation(ctx, 2, "Uptown", "Europe/Berlin"))
+	assert.NoError(t, svc.UpdateLocation(ctx, 2, "Uptown", ""), "keeps the timezone")
+	assert.ErrorIs(t, svc.UpdateLocation(ctx, 2, "Uptown", "Berlin"), ErrInvalidTimezone)
+	assert.ErrorIs(t, svc.UpdateLocation(ctx, 3, "Airport", ""), ErrLocationNotFound)
+
+	locations, err := svc.ListLocations(ctx, []int{2})
+	require.NoError(t, err)
+	assert.Equal(t, []st.Location{{ID: 2, Name: "Uptown", Timezone: "Europe/Berlin"}}, locations)
+
+	// the create and both updates
+	require.Len(t, storage.audits, 3)
+	assert.Equal(t, "create", storage.audits[0].Action)
+	assert.Equal(t, audit.EntityLocation, storage.audits[0].EntityType)
+	assert.Equal(t, 2, storage.audits[0].EntityID)
+	var after st.Location
+	require.NoError(t, json.Unmarshal(*storage.audits[2].After, &after))
+	assert.Equal(t, "Europe/Berlin", after.Timezone, "the kept timezone")
+}
+
+func TestTimezones(t *testing.T) {
+	svc := NewLocation(&mockStorage{locations: []st.Location{{ID: 1, Timezone: "UTC"}, {ID: 2, Timezone: "America/New_York"}}})
+	zones, err := svc.Timezones(context.Background())
+	require.NoError(t, err)
+
+	instant := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2025-03-09T12:00:00Z", zones.In(1, instant).Format(time.RFC3339))
+	// daylight saving time started at 2am local time
+	assert.Equal(t, "2025-03-09T08:00:00-04:00", zones.In(2, instant).Format(time.RFC3339))
+	assert.Equal(t, "2025-03-08T07:00:00-05:00", zones.In(2, instant.Add(-24*time.Hour)).Format(time.RFC3339))
+	assert.Equal(t, "2025-03-09T12:00:00Z", zones.In(3, instant).Format(time.RFC3339), "unknown location")
+	assert.Nil(t, zones.InPtr(2, nil))
+}
+
+func TestSetEmployeeLocations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("duplicates are dropped", func(t *testing.T) {
+		storage := &mockStorage{locations: []st.Location{{ID: 1}, {ID: 2}}, managed: map[int][]int{}}
+		err := NewLocation(storage).SetEmployeeLocations(ctx, 4, []int{2, 1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 1}, storage.managed[4])
+		assert.True(t, storage.committed)
+		require.Len(t, storage.audits, 1)
+		assert.JSONEq(t, `{"ManagedLocationIDs":[2,1]}`, string(*storage.audits[0].After))
+	})
+
+	t.Run("unknown location", func(t *testing.T) {
+		storage := &mockStorage{locations: []st.Location{{ID: 1}}, managed: map[int][]int{}}
+		err := NewLocation(storage).SetEmployeeLocations(ctx, 4, []int{3})
+		assert.ErrorIs(t, err, ErrLocationNotFound)
+		assert.True(t, storage.rolledBack)
+	})
+}
@@ -0,0 +1,194 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	st "payd/storage"
+	"payd/util"
+)
+
+// maxPeriod bounds the length of a pay period
+const maxPeriod = 62 * 24 * time.Hour
+
+// maxZoneOffset is the largest UTC offset of a timezone, the shifts of the period days are looked up that far around them
+const maxZoneOffset = 14 * time.Hour
+
+// the hours exported for a shift
+const (
+	SourceScheduled = "scheduled" // from the shift start to its end
+	SourceClocked   = "clocked"   // from the clock in, or the shift start when later, to the clock out less the breaks
+)
+
+var ErrInvalidPeriod = errors.New("end date must not be before start date")
+var ErrPeriodTooLong = errors.New("pay period must be at most 62 days")
+var ErrInvalidSource = errors.New("source must be scheduled or clocked")
+var ErrInvalidRules = errors.New("night hours must be within 0-23 and overtime thresholds must not be negative")
+
+type storage interface {
+	EachPayrollShift(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time, fn func(st.PayrollShift) error) error
+}
+
+type PayrollInterface interface {
+	Export(ctx context.Context, period Period, filter st.ListShiftRequestFilter, fn func(Row) error) error
+}
+
+// Period covers whole calendar days from Start through End, in the timezone of the location of every shift
+type Period struct {
+	Start  time.Time
+	End    time.Time
+	Source string
+}
+
+// Row is the hours of an employee in a job role over the pay period
+type Row struct {
+	EmployeeID   int
+	EmployeeName string
+	RoleID       int
+	RoleName     string
+	Shifts       int
+	Hours
+}
+
+type Payroll struct {
+	storage storage
+	rules   Rules
+}
+
+type Option func(*Payroll)
+
+func NewPayroll(storage storage, opts ...Option) *Payroll {
+	p := &Payroll{
+		storage: storage,
+		rules:   DefaultRules(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithRules replaces the default rules, invalid rules are refused by Export
+func WithRules(rules Rules) Option {
+	return func(p *Payroll) {
+		p.rules = rules
+	}
+}
+
+// Export calls fn with the hours of every employee and job role, by employee id then job role name.
+// the shifts are streamed from storage and only the rows of one employee are held at a time.
+// the shifts earlier in the week of the period start only count towards the weekly overtime.
+// days, weeks and night hours are those of the location of the shift, so a shift spanning a daylight
+// saving time change is split by its actual length
+func (p *Payroll) Export(ctx context.Context, period Period, filter st.ListShiftRequestFilter, fn func(Row) error) error {
+	if period.End.Before(period.Start) {
+		return ErrInvalidPeriod
+	}
+	end := period.End.AddDate(0, 0, 1)
+	if end.Sub(period.Start) > maxPeriod {
+		return ErrPeriodTooLong
+	}
+	if period.Source != SourceScheduled && period.Source != SourceClocked {
+		return ErrInvalidSource
+	}
+	if !p.rules.valid() {
+		return ErrInvalidRules
+	}
+
+	var (
+		employeeId int
+		rows       map[int]*Row // of the current employee by job role
+		weeks      map[time.Time]time.Duration
+		zones      = map[string]*time.Location{}
+	)
+	flush := func() error {
+		sorted := make([]*Row, 0, len(rows))
+		for _, r := range rows {
+			sorted = append(sorted, r)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].RoleName != sorted[j].RoleName {
+				return sorted[i].RoleName < sorted[j].RoleName
+			}
+			return sorted[i].RoleID < sorted[j].RoleID
+		})
+		for _, r := range sorted {
+			if err := fn(*r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	first := util.WeekStart(period.Start)
+	err := p.storage.EachPayrollShift(ctx, filter, first.Add(-maxZoneOffset), end.Add(maxZoneOffset), func(sh st.PayrollShift) error {
+		loc, ok := zones[sh.Timezone]
+		if !ok {
+			var err error
+			if loc, err = time.LoadLocation(sh.Timezone); err != nil {
+				util.Log().WithContext(ctx).WithError(err).WithField("location_id", sh.LocationID).Error("load location timezone")
+				loc = time.UTC
+			}
+			zones[sh.Timezone] = loc
+		}
+		// the calendar day the shift starts on at its location
+		start := sh.StartTime.In(loc)
+		day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Before(first) || !day.Before(end) {
+			return nil
+		}
+
+		if sh.EmployeeID != employeeId || rows == nil {
+			if err := flush(); err != nil {
+				return err
+			}
+			employeeId = sh.EmployeeID
+			rows = map[int]*Row{}
+			weeks = map[time.Time]time.Duration{}
+		}
+		from, to, breaks, ok := p.worked(sh, period.Source)
+		if !ok {
+			return nil
+		}
+		week := util.WeekStart(day)
+		hours := p.rules.split(from.In(loc), to.In(loc), breaks, weeks[week])
+		weeks[week] += hours.Total()
+		if day.Before(period.Start) {
+			return nil
+		}
+
+		r, ok := rows[sh.RoleID]
+		if !ok {
+			r = &Row{EmployeeID: sh.EmployeeID, EmployeeName: sh.EmployeeName, RoleID: sh.RoleID, RoleName: sh.RoleName}
+			rows[sh.RoleID] = r
+		}
+		r.Shifts++
+		r.add(hours)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// worked returns the time worked during the shift, false for a shift that wasn't clocked out of when clocked
+func (p *Payroll) worked(sh st.PayrollShift, source string) (time.Time, time.Time, time.Duration, bool) {
+	if source == SourceScheduled {
+		return sh.StartTime, sh.EndTime, 0, true
+	}
+	if sh.ClockIn == nil || sh.ClockOut == nil {
+		return time.Time{}, time.Time{}, 0, false
+	}
+	// clocking in early doesn't count as worked time, see timesheet.Attendance
+	from := *sh.ClockIn
+	if from.Before(sh.StartTime) {
+		from = sh.StartTime
+	}
+	if !sh.ClockOut.After(from) {
+		return time.Time{}, time.Time{}, 0, false
+	}
+	return from, *sh.ClockOut, time.Duration(sh.BreakSeconds) * time.Second, true
+}
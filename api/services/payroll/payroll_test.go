@@ -0,0 +1,138 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	shifts     []st.PayrollShift
+	filter     st.ListShiftRequestFilter
+	start, end time.Time
+}
+
+func (m *mockStorage) EachPayrollShift(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time, fn func(st.PayrollShift) error) error {
+	m.filter, m.start, m.end = filter, start, end
+	for _, sh := range m.shifts {
+		if err := fn(sh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shiftAt(employeeId, roleId int, day, hour, length int) st.PayrollShift {
+	start := monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	return st.PayrollShift{
+		EmployeeID: employeeId, EmployeeName: map[int]string{4: "Alice", 5: "Bob"}[employeeId],
+		RoleID: roleId, RoleName: map[int]string{1: "Waiter", 2: "Cook"}[roleId],
+		StartTime: start, EndTime: start.Add(time.Duration(length) * time.Hour),
+	}
+}
+
+func collect(t *testing.T, p *Payroll, period Period) []Row {
+	var rows []Row
+	require.NoError(t, p.Export(context.Background(), period, st.ListShiftRequestFilter{}, func(r Row) error {
+		rows = append(rows, r)
+		return nil
+	}))
+	return rows
+}
+
+func TestExport(t *testing.T) {
+	// the period starts on Wednesday
+	period := Period{Start: monday.AddDate(0, 0, 2), End: monday.AddDate(0, 0, 8), Source: SourceScheduled}
+
+	t.Run("scheduled hours by employee and role", func(t *testing.T) {
+		storage := &mockStorage{shifts: []st.PayrollShift{
+			// before the period, only counts towards the weekly overtime
+			shiftAt(4, 1, 0, 9, 10), shiftAt(4, 1, 1, 9, 10),
+			shiftAt(4, 1, 2, 9, 10),
+			shiftAt(4, 2, 3, 9, 8),
+			shiftAt(4, 1, 5, 9, 8),
+			shiftAt(5, 1, 2, 20, 8),
+		}}
+		rows := collect(t, NewPayroll(storage), period)
+
+		assert.Equal(t, monday.Add(-maxZoneOffset), storage.start, "from the start of the week of the period")
+		assert.Equal(t, monday.AddDate(0, 0, 9).Add(maxZoneOffset), storage.end)
+		require.Len(t, rows, 3)
+		assert.Equal(t, Row{EmployeeID: 4, EmployeeName: "Alice", RoleID: 2, RoleName: "Cook", Shifts: 1,
+			Hours: Hours{Regular: 8 * time.Hour}}, rows[0])
+		// 2 hours of daily overtime on Wednesday, 38 hours worked before Saturday
+		assert.Equal(t, Row{EmployeeID: 4, EmployeeName: "Alice", RoleID: 1, RoleName: "Waiter", Shifts: 2,
+			Hours: Hours{Regular: 8 * time.Hour, Overtime: 8 * time.Hour, Weekend: 2 * time.Hour}}, rows[1])
+		assert.Equal(t, Row{EmployeeID: 5, EmployeeName: "Bob", RoleID: 1, RoleName: "Waiter", Shifts: 1,
+			Hours: Hours{Regular: 2 * time.Hour, Night: 6 * time.Hour}}, rows[2])
+	})
+
+	t.Run("clocked hours", func(t *testing.T) {
+		clocked := shiftAt(4, 1, 3, 9, 8)
+		clockIn, clockOut := clocked.StartTime.Add(-10*time.Minute), clocked.EndTime.Add(time.Hour)
+		clocked.ClockIn, clocked.ClockOut, clocked.BreakSeconds = &clockIn, &clockOut, 1800
+		open := shiftAt(4, 1, 4, 9, 8)
+		open.ClockIn = &open.StartTime
+
+		storage := &mockStorage{shifts: []st.PayrollShift{clocked, open, shiftAt(4, 1, 5, 9, 8)}}
+		period := period
+		period.Source = SourceClocked
+		rows := collect(t, NewPayroll(storage), period)
+
+		require.Len(t, rows, 1)
+		assert.Equal(t, 1, rows[0].Shifts)
+		assert.Equal(t, Hours{Regular: 8 * time.Hour, Overtime: 30 * time.Minute}, rows[0].Hours)
+	})
+
+	t.Run("custom rules", func(t *testing.T) {
+		storage := &mockStorage{shifts: []st.PayrollShift{shiftAt(4, 1, 5, 9, 10)}}
+		rows := collect(t, NewPayroll(storage, WithRules(Rules{})), period)
+		require.Len(t, rows, 1)
+		assert.Equal(t, Hours{Regular: 10 * time.Hour}, rows[0].Hours)
+	})
+
+	t.Run("days of the location timezone", func(t *testing.T) {
+		// Tuesday 23:30 UTC is Wednesday 01:30 in Berlin, within the period
+		berlin := shiftAt(4, 1, 1, 23, 4)
+		berlin.Timezone = "Europe/Berlin"
+		// Wednesday 02:00 UTC is still Tuesday in New York, before the period
+		newYork := shiftAt(5, 1, 2, 2, 4)
+		newYork.Timezone = "America/New_York"
+
+		rows := collect(t, NewPayroll(&mockStorage{shifts: []st.PayrollShift{berlin, newYork}}), period)
+		require.Len(t, rows, 1)
+		assert.Equal(t, 4, rows[0].EmployeeID)
+		// 01:30 to 05:30 local time, night hours only
+		assert.Equal(t, Hours{Night: 4 * time.Hour}, rows[0].Hours)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p := NewPayroll(&mockStorage{})
+		noop := func(Row) error { return nil }
+		assert.ErrorIs(t, p.Export(context.Background(), Period{Start: monday, End: monday.AddDate(0, 0, -1), Source: SourceScheduled},
+			st.ListShiftRequestFilter{}, noop), ErrInvalidPeriod)
+		assert.ErrorIs(t, p.Export(context.Background(), Period{Start: monday, End: monday.AddDate(0, 3, 0), Source: SourceScheduled},
+			st.ListShiftRequestFilter{}, noop), ErrPeriodTooLong)
+		assert.ErrorIs(t, p.Export(context.Background(), Period{Start: monday, End: monday, Source: "planned"},
+			st.ListShiftRequestFilter{}, noop), ErrInvalidSource)
+		assert.ErrorIs(t, NewPayroll(&mockStorage{}, WithRules(Rules{NightStart: 24})).Export(context.Background(),
+			Period{Start: monday, End: monday, Source: SourceScheduled}, st.ListShiftRequestFilter{}, noop), ErrInvalidRules)
+	})
+
+	t.Run("write error stops the export", func(t *testing.T) {
+		storage := &mockStorage{shifts: []st.PayrollShift{shiftAt(4, 1, 3, 9, 8), shiftAt(5, 1, 3, 9, 8)}}
+		calls := 0
+		err := NewPayroll(storage).Export(context.Background(), period, st.ListShiftRequestFilter{}, func(Row) error {
+			calls++
+			return errors.New("broken pipe")
+		})
+		assert.EqualError(t, err, "broken pipe")
+		assert.Equal(t, 1, calls)
+	})
+}
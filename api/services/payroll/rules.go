@@ -0,0 +1,125 @@
+package payroll
+
+import (
+	"time"
+)
+
+// Rules split the worked hours of a shift, every hour falls into one category only:
+// overtime first, then weekend, then night, otherwise regular
+type Rules struct {
+	// hours of the day [NightStart, NightEnd), spanning midnight when NightStart > NightEnd, no night hours when equal
+	NightStart  int
+	NightEnd    int
+	WeekendDays []time.Weekday
+	// worked beyond it in a single shift is overtime, 0 disables
+	DailyOvertime time.Duration
+	// worked beyond it in a week starting on Monday is overtime, 0 disables
+	WeeklyOvertime time.Duration
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		NightStart:     22,
+		NightEnd:       6,
+		WeekendDays:    []time.Weekday{time.Saturday, time.Sunday},
+		DailyOvertime:  8 * time.Hour,
+		WeeklyOvertime: 40 * time.Hour,
+	}
+}
+
+func (r Rules) valid() bool {
+	return r.NightStart >= 0 && r.NightStart < 24 && r.NightEnd >= 0 && r.NightEnd < 24 &&
+		r.DailyOvertime >= 0 && r.WeeklyOvertime >= 0
+}
+
+// Hours of an employee in a job role
+type Hours struct {
+	Regular  time.Duration
+	Overtime time.Duration
+	Night    time.Duration
+	Weekend  time.Duration
+}
+
+func (h Hours) Total() time.Duration {
+	return h.Regular + h.Overtime + h.Night + h.Weekend
+}
+
+func (h *Hours) add(o Hours) {
+	h.Regular += o.Regular
+	h.Overtime += o.Overtime
+	h.Night += o.Night
+	h.Weekend += o.Weekend
+}
+
+// split the worked time of [from, to), the breaks shrink every category in proportion.
+// weekWorked is the time the employee already worked in the week of the shift
+func (r Rules) split(from, to time.Time, breaks, weekWorked time.Duration) Hours {
+	length := to.Sub(from)
+	worked := length - breaks
+	if length <= 0 || worked <= 0 {
+		return Hours{}
+	}
+	// the overtime is the tail of the shift
+	var overtime time.Duration
+	if r.DailyOvertime > 0 && worked > r.DailyOvertime {
+		overtime = worked - r.DailyOvertime
+	}
+	if r.WeeklyOvertime > 0 && weekWorked+worked > r.WeeklyOvertime {
+		overtime = max(overtime, min(worked, weekWorked+worked-r.WeeklyOvertime))
+	}
+	scale := float64(worked) / float64(length)
+	head := to.Add(-time.Duration(float64(overtime) / scale))
+
+	h := Hours{Overtime: overtime}
+	for t := from; t.Before(head); {
+		next := r.nextBoundary(t)
+		if next.After(head) {
+			next = head
+		}
+		d := time.Duration(float64(next.Sub(t)) * scale)
+		switch {
+		case r.weekend(t):
+			h.Weekend += d
+		case r.night(t):
+			h.Night += d
+		default:
+			h.Regular += d
+		}
+		t = next
+	}
+	// rounding leftovers go to the regular hours so that the categories add up to the worked time
+	h.Regular += worked - h.Total()
+	return h
+}
+
+func (r Rules) weekend(t time.Time) bool {
+	for _, d := range r.WeekendDays {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rules) night(t time.Time) bool {
+	hour := t.Hour()
+	switch {
+	case r.NightStart > r.NightEnd:
+		return hour >= r.NightStart || hour < r.NightEnd
+	case r.NightStart < r.NightEnd:
+		return hour >= r.NightStart && hour < r.NightEnd
+	}
+	return false
+}
+
+// nextBoundary returns the next midnight, night start or night end after t, in the wall clock of t
+// so that a day lasting 23 or 25 hours keeps its boundaries
+func (r Rules) nextBoundary(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	for _, hour := range []int{r.NightStart, r.NightEnd} {
+		if b := time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, t.Location()); b.After(t) && b.Before(next) {
+			next = b
+		}
+	}
+	return next
+}
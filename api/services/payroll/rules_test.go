@@ -0,0 +1,99 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a Monday
+var monday = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func TestSplit(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	}
+
+	tests := []struct {
+		name       string
+		rules      *Rules // defaults to DefaultRules
+		from, to   time.Time
+		breaks     time.Duration
+		weekWorked time.Duration
+		want       Hours
+	}{
+		{
+			name: "weekday",
+			from: at(0, 9),
+			to:   at(0, 17),
+			want: Hours{Regular: 8 * time.Hour},
+		},
+		{
+			name: "night spanning midnight",
+			from: at(2, 20),
+			to:   at(3, 4),
+			want: Hours{Regular: 2 * time.Hour, Night: 6 * time.Hour},
+		},
+		{
+			name: "friday night into the weekend",
+			from: at(4, 21),
+			to:   at(5, 3),
+			want: Hours{Regular: time.Hour, Night: 2 * time.Hour, Weekend: 3 * time.Hour},
+		},
+		{
+			name: "daily overtime is the tail of the shift",
+			from: at(0, 14),
+			to:   at(1, 0),
+			want: Hours{Regular: 8 * time.Hour, Overtime: 2 * time.Hour},
+		},
+		{
+			name:       "weekly overtime",
+			from:       at(3, 9),
+			to:         at(3, 17),
+			weekWorked: 36 * time.Hour,
+			want:       Hours{Regular: 4 * time.Hour, Overtime: 4 * time.Hour},
+		},
+		{
+			name:   "breaks shrink every category",
+			from:   at(0, 18),
+			to:     at(1, 2),
+			breaks: time.Hour,
+			want:   Hours{Regular: 3*time.Hour + 30*time.Minute, Night: 3*time.Hour + 30*time.Minute},
+		},
+		{
+			name:  "overtime disabled and no night hours",
+			rules: &Rules{},
+			from:  at(0, 14),
+			to:    at(1, 2),
+			want:  Hours{Regular: 12 * time.Hour},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := DefaultRules()
+			if tc.rules != nil {
+				rules = *tc.rules
+			}
+			assert.Equal(t, tc.want, rules.split(tc.from, tc.to, tc.breaks, tc.weekWorked))
+		})
+	}
+}
+
+func TestSplitAcrossDaylightSavingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	rules := Rules{NightStart: 22, NightEnd: 6}
+
+	// the clocks go forward from 02:00 to 03:00
+	from := time.Date(2025, 3, 29, 20, 0, 0, 0, berlin)
+	to := time.Date(2025, 3, 30, 6, 0, 0, 0, berlin)
+	assert.Equal(t, Hours{Regular: 2 * time.Hour, Night: 7 * time.Hour}, rules.split(from, to, 0, 0))
+
+	// the clocks go back from 03:00 to 02:00
+	from = time.Date(2025, 10, 25, 20, 0, 0, 0, berlin)
+	to = time.Date(2025, 10, 26, 6, 0, 0, 0, berlin)
+	assert.Equal(t, Hours{Regular: 2 * time.Hour, Night: 9 * time.Hour}, rules.split(from, to, 0, 0))
+}
@@ -0,0 +1,253 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"payd/services/audit"
+	"payd/storage"
+)
+
+const maxPrivilegeRoleNameLength = 50
+
+var ErrPrivilegeRoleNotFound = errors.New("privilege role not found")
+var ErrInvalidPrivilegeRoleName = errors.New("invalid privilege role name")
+var ErrDuplicatePrivilegeRoleName = errors.New("a privilege role with the same name already exists")
+var ErrBuiltinPrivilegeRole = errors.New("the builtin privilege role can't be changed")
+var ErrUnknownPermission = errors.New("unknown permission")
+var ErrUnscopedPermission = errors.New("permission can't be restricted to a job role")
+var ErrUnknownJobRole = errors.New("job role not found")
+var ErrEmployeeNotFound = errors.New("employee not found")
+
+// CreatePrivilegeRole creates a privilege role with its permissions in one transaction
+func (m *Manager) CreatePrivilegeRole(ctx context.Context, name string, permissions []Permission) (role PrivilegeRole, err error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxPrivilegeRoleNameLength {
+		return PrivilegeRole{}, ErrInvalidPrivilegeRoleName
+	}
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return PrivilegeRole{}, err
+	}
+
+	tctx, err := m.storage.NewTransacton(ctx)
+	if err != nil {
+		return PrivilegeRole{}, err
+	}
+	defer m.dbTransactions(tctx, &err)
+
+	id, err := m.storage.CreatePrivilegeRole(tctx, name)
+	if err != nil {
+		return PrivilegeRole{}, mapStorageError(err)
+	}
+	if err = m.storage.ReplacePrivilegeRolePermissions(tctx, id, toStorage(permissions)); err != nil {
+		return PrivilegeRole{}, mapStorageError(err)
+	}
+	role = PrivilegeRole{ID: id, Name: name, Permissions: permissions}
+	if err = audit.Record(tctx, m.storage, "create", audit.EntityPrivilegeRole, id, nil, role); err != nil {
+		return PrivilegeRole{}, err
+	}
+	return role, nil
+}
+
+// SetPrivilegeRolePermissions replaces the permissions of a privilege role,
+// the employees holding it get the new permissions on their next request
+func (m *Manager) SetPrivilegeRolePermissions(ctx context.Context, id int, permissions []Permission) (err error) {
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return err
+	}
+	if _, err = m.mutableRole(ctx, id); err != nil {
+		return err
+	}
+
+	tctx, err := m.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.dbTransactions(tctx, &err)
+
+	before, err := m.storedPermissions(tctx, id)
+	if err != nil {
+		return err
+	}
+	if err = m.storage.ReplacePrivilegeRolePermissions(tctx, id, toStorage(permissions)); err != nil {
+		return mapStorageError(err)
+	}
+	return audit.Record(tctx, m.storage, "set_permissions", audit.EntityPrivilegeRole, id,
+		map[string]interface{}{"Permissions": before}, map[string]interface{}{"Permissions": permissions})
+}
+
+// DeletePrivilegeRole deletes a privilege role and takes it away from every employee holding it
+func (m *Manager) DeletePrivilegeRole(ctx context.Context, id int) (err error) {
+	r, err := m.mutableRole(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tctx, err := m.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.dbTransactions(tctx, &err)
+
+	permissions, err := m.storedPermissions(tctx, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := m.storage.DeletePrivilegeRole(tctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// deleted concurrently
+		return ErrPrivilegeRoleNotFound
+	}
+	before := PrivilegeRole{ID: id, Name: r.Name, Permissions: permissions}
+	return audit.Record(tctx, m.storage, "delete", audit.EntityPrivilegeRole, id, before, nil)
+}
+
+// SetEmployeePrivilegeRoles replaces the privilege roles of the employee, the builtin role comes from
+// the identity role and can't be assigned
+func (m *Manager) SetEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) (err error) {
+	ids := make([]int, 0, len(privilegeRoleIds))
+	seen := make(map[int]bool)
+	for _, id := range privilegeRoleIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := m.mutableRole(ctx, id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	tctx, err := m.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.dbTransactions(tctx, &err)
+
+	assignments, err := m.storage.SelectAllEmployeePrivilegeRoles(tctx)
+	if err != nil {
+		return err
+	}
+	before := make([]int, 0)
+	for _, a := range assignments {
+		if a.EmployeeID == employeeId {
+			before = append(before, a.PrivilegeRoleID)
+		}
+	}
+	if err = m.storage.ReplaceEmployeePrivilegeRoles(tctx, employeeId, ids); err != nil {
+		return mapStorageError(err)
+	}
+	return audit.Record(tctx, m.storage, "set_privilege_roles", audit.EntityEmployee, employeeId,
+		map[string]interface{}{"PrivilegeRoleIDs": before}, map[string]interface{}{"PrivilegeRoleIDs": ids})
+}
+
+// mutableRole returns the privilege role unless it is the builtin one
+func (m *Manager) mutableRole(ctx context.Context, id int) (*storage.PrivilegeRole, error) {
+	r, err := m.storage.SelectPrivilegeRoleByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPrivilegeRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Builtin {
+		return nil, ErrBuiltinPrivilegeRole
+	}
+	return r, nil
+}
+
+// storedPermissions reads the permissions of the privilege role from the storage rather than the cache,
+// which may lag behind the changes of other instances
+func (m *Manager) storedPermissions(ctx context.Context, id int) ([]Permission, error) {
+	recs, err := m.storage.SelectAllPrivilegeRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]Permission, 0)
+	for _, p := range recs {
+		if p.PrivilegeRoleID == id {
+			permissions = append(permissions, Permission{Name: p.Permission, JobRoleID: p.JobRoleID})
+		}
+	}
+	sortPermissions(permissions)
+	return permissions, nil
+}
+
+// dbTransactions finishes the transaction bound to ctx like storage.Finish, and refreshes the cache
+// after a commit. defer only after calling storage.NewTransacton, with a pointer to the named error result
+func (m *Manager) dbTransactions(ctx context.Context, err *error) {
+	m.storage.Finish(ctx, err)
+	if *err == nil {
+		m.invalidate(ctx)
+	}
+}
+
+// invalidate refreshes the cache right away instead of waiting for the next tick,
+// on failure the change is picked up by the periodic refresh
+func (m *Manager) invalidate(ctx context.Context) {
+	if err := m.refresh(ctx); err != nil {
+		log.Printf("privilege role cache invalidation failed: %v", err)
+	}
+}
+
+// normalizePermissions validates the permissions and drops the duplicates
+func normalizePermissions(permissions []Permission) ([]Permission, error) {
+	known := make(map[string]bool, len(All))
+	for _, p := range All {
+		known[p] = true
+	}
+	grants := make(Grants)
+	for _, p := range permissions {
+		if !known[p.Name] {
+			return nil, fmt.Errorf("%w %q", ErrUnknownPermission, p.Name)
+		}
+		if p.JobRoleID != nil && !jobRoleScoped[p.Name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnscopedPermission, p.Name)
+		}
+		grants.add(p.Name, p.JobRoleID)
+	}
+
+	normalized := make([]Permission, 0, len(permissions))
+	for name, jobRoles := range grants {
+		if jobRoles == nil {
+			normalized = append(normalized, Permission{Name: name})
+			continue
+		}
+		for _, id := range jobRoles {
+			normalized = append(normalized, Permission{Name: name, JobRoleID: &id})
+		}
+	}
+	sortPermissions(normalized)
+	return normalized, nil
+}
+
+func toStorage(permissions []Permission) []storage.PrivilegeRolePermission {
+	recs := make([]storage.PrivilegeRolePermission, len(permissions))
+	for i, p := range permissions {
+		recs[i] = storage.PrivilegeRolePermission{Permission: p.Name, JobRoleID: p.JobRoleID}
+	}
+	return recs
+}
+
+func mapStorageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrDuplicatePrivilegeRoleName):
+		return ErrDuplicatePrivilegeRoleName
+	case errors.Is(err, storage.ErrUnknownJobRole):
+		return ErrUnknownJobRole
+	case errors.Is(err, storage.ErrUnknownEmployee):
+		return ErrEmployeeNotFound
+	case errors.Is(err, storage.ErrUnknownPrivilegeRole):
+		return ErrPrivilegeRoleNotFound
+	}
+	return err
+}
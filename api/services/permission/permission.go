@@ -0,0 +1,308 @@
+package permission
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"payd/services/audit"
+	"payd/storage"
+)
+
+// named permissions, grouped into privilege roles assigned to employees
+const (
+	ShiftsRead      = "shifts:read"
+	ShiftsWrite     = "shifts:write"
+	RequestsRead    = "requests:read"
+	RequestsApprove = "requests:approve"
+	EmployeesRead   = "employees:read"
+	EmployeesManage = "employees:manage"
+	RolesRead       = "roles:read"
+	RolesManage     = "roles:manage"
+	LocationsRead   = "locations:read"
+	LocationsManage = "locations:manage"
+	LeaveRead       = "leave:read"
+	LeaveApprove    = "leave:approve"
+	LeaveManage     = "leave:manage" // balance adjustments
+	TimesheetsRead  = "timesheets:read"
+	PayrollExport   = "payroll:export"
+	AuditRead       = "audit:read"
+	WebhooksManage  = "webhooks:manage"
+	// registrations, API tokens and privilege roles, it can grant any permission so it amounts to admin
+	AccessManage = "access:manage"
+)
+
+// All lists every permission, the builtin admin privilege role has all of them
+var All = []string{
+	ShiftsRead, ShiftsWrite,
+	RequestsRead, RequestsApprove,
+	EmployeesRead, EmployeesManage,
+	RolesRead, RolesManage,
+	LocationsRead, LocationsManage,
+	LeaveRead, LeaveApprove, LeaveManage,
+	TimesheetsRead, PayrollExport,
+	AuditRead, WebhooksManage,
+	AccessManage,
+}
+
+// jobRoleScoped are the permissions a grant can restrict to some job roles
+var jobRoleScoped = map[string]bool{RequestsRead: true, RequestsApprove: true, LeaveRead: true, LeaveApprove: true, TimesheetsRead: true, PayrollExport: true}
+
+// adminIdentityRole is the identity role granted the builtin privilege role
+const adminIdentityRole = "admin"
+
+// Grants maps the permissions of an employee to the job roles they apply to, nil for every job role
+type Grants map[string][]int
+
+// Has reports whether the permission is granted for at least one job role
+func (g Grants) Has(permission string) bool {
+	_, ok := g[permission]
+	return ok
+}
+
+// JobRoles returns the job roles the permission is restricted to, nil if it applies to every job role
+func (g Grants) JobRoles(permission string) []int {
+	return g[permission]
+}
+
+// Allows reports whether the permission is granted for the job role
+func (g Grants) Allows(permission string, jobRoleId int) bool {
+	jobRoles, ok := g[permission]
+	if !ok {
+		return false
+	}
+	if jobRoles == nil {
+		return true
+	}
+	for _, id := range jobRoles {
+		if id == jobRoleId {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether g grants every permission of other, for every job role other grants it for
+func (g Grants) Covers(other Grants) bool {
+	for permission, jobRoles := range other {
+		if !g.Has(permission) {
+			return false
+		}
+		if jobRoles == nil {
+			if g.JobRoles(permission) != nil {
+				return false
+			}
+			continue
+		}
+		for _, id := range jobRoles {
+			if !g.Allows(permission, id) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// add merges a grant, a grant for every job role wins over restricted ones
+func (g Grants) add(permission string, jobRoleId *int) {
+	jobRoles, ok := g[permission]
+	if ok && jobRoles == nil {
+		return
+	}
+	if jobRoleId == nil {
+		g[permission] = nil
+		return
+	}
+	for _, id := range jobRoles {
+		if id == *jobRoleId {
+			return
+		}
+	}
+	g[permission] = append(jobRoles, *jobRoleId)
+}
+
+type Permission struct {
+	Name      string
+	JobRoleID *int // nil for every job role
+}
+
+type PrivilegeRole struct {
+	ID          int
+	Name        string
+	Builtin     bool
+	Permissions []Permission
+}
+
+type Storage interface {
+	SelectAllPrivilegeRoles(ctx context.Context) ([]storage.PrivilegeRole, error)
+	SelectPrivilegeRoleByID(ctx context.Context, id int) (*storage.PrivilegeRole, error)
+	SelectAllPrivilegeRolePermissions(ctx context.Context) ([]storage.PrivilegeRolePermission, error)
+	SelectAllEmployeePrivilegeRoles(ctx context.Context) ([]storage.EmployeePrivilegeRole, error)
+	CreatePrivilegeRole(ctx context.Context, name string) (int, error)
+	DeletePrivilegeRole(ctx context.Context, id int) (bool, error)
+	ReplacePrivilegeRolePermissions(ctx context.Context, id int, permissions []storage.PrivilegeRolePermission) error
+	ReplaceEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) error
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+// GrantResolver resolves the permissions of an authorized caller
+type GrantResolver interface {
+	Grants(identityRole string, employeeId int) Grants
+}
+
+type ManagerInterface interface {
+	GrantResolver
+
+	GetPrivilegeRoles() []PrivilegeRole
+	GetEmployeePrivilegeRoles(employeeId int) []int
+	CreatePrivilegeRole(ctx context.Context, name string, permissions []Permission) (PrivilegeRole, error)
+	SetPrivilegeRolePermissions(ctx context.Context, id int, permissions []Permission) error
+	DeletePrivilegeRole(ctx context.Context, id int) error
+	SetEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) error
+}
+
+// Manager caches the privilege roles and their assignments so every request is authorized without hitting the db.
+// Changes made by this instance apply immediately, the ones made by other instances after the next refresh.
+type Manager struct {
+	storage Storage
+
+	mu        sync.RWMutex
+	roles     []PrivilegeRole
+	employees map[int][]int // employee id -> privilege role ids
+	tick      time.Duration
+}
+
+func NewManager(st Storage, tick time.Duration) *Manager {
+	return &Manager{
+		storage:   st,
+		roles:     make([]PrivilegeRole, 0),
+		employees: make(map[int][]int),
+		tick:      tick,
+	}
+}
+
+// Start begins the periodic refresh of privilege roles and performs an initial fetch.
+func (m *Manager) Start(ctx context.Context) error {
+	if err := m.refresh(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.refresh(ctx); err != nil {
+					log.Printf("periodic privilege role fetch failed: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// refresh fetches and updates the privilege roles and their assignments from storage.
+func (m *Manager) refresh(ctx context.Context) error {
+	storageRoles, err := m.storage.SelectAllPrivilegeRoles(ctx)
+	if err != nil {
+		return err
+	}
+	storagePermissions, err := m.storage.SelectAllPrivilegeRolePermissions(ctx)
+	if err != nil {
+		return err
+	}
+	assignments, err := m.storage.SelectAllEmployeePrivilegeRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	permissions := make(map[int][]Permission)
+	for _, p := range storagePermissions {
+		permissions[p.PrivilegeRoleID] = append(permissions[p.PrivilegeRoleID], Permission{Name: p.Permission, JobRoleID: p.JobRoleID})
+	}
+	roles := make([]PrivilegeRole, len(storageRoles))
+	for i, r := range storageRoles {
+		perms := permissions[r.ID]
+		sortPermissions(perms)
+		roles[i] = PrivilegeRole{ID: r.ID, Name: r.Name, Builtin: r.Builtin, Permissions: perms}
+	}
+	employees := make(map[int][]int)
+	for _, a := range assignments {
+		employees[a.EmployeeID] = append(employees[a.EmployeeID], a.PrivilegeRoleID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roles = roles
+	m.employees = employees
+	return nil
+}
+
+// Grants returns the permissions of the employee privilege roles, identities with the admin role
+// are granted the builtin privilege role too
+func (m *Manager) Grants(identityRole string, employeeId int) Grants {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	assigned := make(map[int]bool)
+	for _, id := range m.employees[employeeId] {
+		assigned[id] = true
+	}
+	grants := make(Grants)
+	for _, r := range m.roles {
+		if !assigned[r.ID] && !(r.Builtin && identityRole == adminIdentityRole) {
+			continue
+		}
+		for _, p := range r.Permissions {
+			grants.add(p.Name, p.JobRoleID)
+		}
+	}
+	return grants
+}
+
+// GetPrivilegeRoles returns a thread-safe copy of the latest privilege roles
+func (m *Manager) GetPrivilegeRoles() []PrivilegeRole {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	copied := make([]PrivilegeRole, len(m.roles))
+	for i, r := range m.roles {
+		r.Permissions = append([]Permission{}, r.Permissions...)
+		copied[i] = r
+	}
+	return copied
+}
+
+// GetEmployeePrivilegeRoles returns the ids of the privilege roles assigned to the employee
+func (m *Manager) GetEmployeePrivilegeRoles(employeeId int) []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]int{}, m.employees[employeeId]...)
+}
+
+func sortPermissions(perms []Permission) {
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].Name != perms[j].Name {
+			return perms[i].Name < perms[j].Name
+		}
+		return jobRoleKey(perms[i].JobRoleID) < jobRoleKey(perms[j].JobRoleID)
+	})
+}
+
+func jobRoleKey(id *int) int {
+	if id == nil {
+		return -1
+	}
+	return *id
+}
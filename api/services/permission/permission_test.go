@@ -0,0 +1,272 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	"payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStorage implements Storage interface for testing.
+type mockStorage struct {
+	mu          sync.Mutex
+	roles       []storage.PrivilegeRole
+	permissions []storage.PrivilegeRolePermission
+	assignments []storage.EmployeePrivilegeRole
+	audits      []storage.AuditLog
+	committed   bool
+	rolledBack  bool
+}
+
+func (m *mockStorage) SelectAllPrivilegeRoles(ctx context.Context) ([]storage.PrivilegeRole, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]storage.PrivilegeRole{}, m.roles...), nil
+}
+
+func (m *mockStorage) SelectPrivilegeRoleByID(ctx context.Context, id int) (*storage.PrivilegeRole, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.roles {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockStorage) SelectAllPrivilegeRolePermissions(ctx context.Context) ([]storage.PrivilegeRolePermission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]storage.PrivilegeRolePermission{}, m.permissions...), nil
+}
+
+func (m *mockStorage) SelectAllEmployeePrivilegeRoles(ctx context.Context) ([]storage.EmployeePrivilegeRole, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]storage.EmployeePrivilegeRole{}, m.assignments...), nil
+}
+
+func (m *mockStorage) CreatePrivilegeRole(ctx context.Context, name string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.roles {
+		if r.Name == name {
+			return 0, storage.ErrDuplicatePrivilegeRoleName
+		}
+	}
+	id := len(m.roles) + 1
+	m.roles = append(m.roles, storage.PrivilegeRole{ID: id, Name: name})
+	return id, nil
+}
+
+func (m *mockStorage) DeletePrivilegeRole(ctx context.Context, id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.roles {
+		if r.ID == id {
+			m.roles = append(m.roles[:i], m.roles[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockStorage) ReplacePrivilegeRolePermissions(ctx context.Context, id int, permissions []storage.PrivilegeRolePermission) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := make([]storage.PrivilegeRolePermission, 0, len(m.permissions))
+	for _, p := range m.permissions {
+		if p.PrivilegeRoleID != id {
+			kept = append(kept, p)
+		}
+	}
+	for _, p := range permissions {
+		p.PrivilegeRoleID = id
+		kept = append(kept, p)
+	}
+	m.permissions = kept
+	return nil
+}
+
+func (m *mockStorage) ReplaceEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := make([]storage.EmployeePrivilegeRole, 0, len(m.assignments))
+	for _, a := range m.assignments {
+		if a.EmployeeID != employeeId {
+			kept = append(kept, a)
+		}
+	}
+	for _, id := range privilegeRoleIds {
+		kept = append(kept, storage.EmployeePrivilegeRole{EmployeeID: employeeId, PrivilegeRoleID: id})
+	}
+	m.assignments = kept
+	return nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l storage.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func newTestManager(t *testing.T) (*Manager, *mockStorage) {
+	mockSt := &mockStorage{
+		roles: []storage.PrivilegeRole{{ID: 1, Name: "admin", Builtin: true}},
+	}
+	for _, p := range All {
+		mockSt.permissions = append(mockSt.permissions, storage.PrivilegeRolePermission{PrivilegeRoleID: 1, Permission: p})
+	}
+	// a long tick makes sure the cache is invalidated on change
+	m := NewManager(mockSt, time.Hour)
+	require.NoError(t, m.Start(context.Background()))
+	return m, mockSt
+}
+
+func TestGrants(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	t.Run("admin identities hold the builtin role", func(t *testing.T) {
+		grants := m.Grants("admin", 1)
+		for _, p := range All {
+			assert.True(t, grants.Has(p), p)
+			assert.Nil(t, grants.JobRoles(p))
+		}
+		assert.Empty(t, m.Grants("employee", 1))
+	})
+
+	t.Run("supervisor restricted to a job role", func(t *testing.T) {
+		supervisor, err := m.CreatePrivilegeRole(ctx, "Cook supervisor", []Permission{
+			{Name: RequestsRead, JobRoleID: intPtr(2)},
+			{Name: RequestsApprove, JobRoleID: intPtr(2)},
+			{Name: ShiftsRead},
+		})
+		require.NoError(t, err)
+		require.NoError(t, m.SetEmployeePrivilegeRoles(ctx, 7, []int{supervisor.ID}))
+
+		grants := m.Grants("employee", 7)
+		assert.True(t, grants.Has(RequestsApprove))
+		assert.True(t, grants.Allows(RequestsApprove, 2))
+		assert.False(t, grants.Allows(RequestsApprove, 3))
+		assert.Equal(t, []int{2}, grants.JobRoles(RequestsRead))
+		assert.True(t, grants.Allows(ShiftsRead, 3))
+		assert.False(t, grants.Has(ShiftsWrite))
+		assert.Equal(t, []int{supervisor.ID}, m.GetEmployeePrivilegeRoles(7))
+	})
+
+	t.Run("unrestricted grant wins over restricted ones", func(t *testing.T) {
+		approver, err := m.CreatePrivilegeRole(ctx, "Approver", []Permission{{Name: RequestsApprove}})
+		require.NoError(t, err)
+		require.NoError(t, m.SetEmployeePrivilegeRoles(ctx, 7, []int{2, approver.ID}))
+
+		grants := m.Grants("employee", 7)
+		assert.Nil(t, grants.JobRoles(RequestsApprove))
+		assert.True(t, grants.Allows(RequestsApprove, 3))
+		assert.Equal(t, []int{2}, grants.JobRoles(RequestsRead))
+	})
+}
+
+func TestGrantsCovers(t *testing.T) {
+	supervisor := Grants{RequestsApprove: {2}, ShiftsRead: nil}
+	assert.True(t, supervisor.Covers(Grants{}))
+	assert.True(t, supervisor.Covers(Grants{RequestsApprove: {2}}))
+	assert.False(t, supervisor.Covers(Grants{RequestsApprove: {3}}))
+	assert.False(t, supervisor.Covers(Grants{RequestsApprove: nil}))
+	assert.False(t, supervisor.Covers(Grants{ShiftsWrite: nil}))
+	assert.True(t, Grants{RequestsApprove: nil, ShiftsRead: nil}.Covers(supervisor))
+}
+
+func TestManagePrivilegeRoles(t *testing.T) {
+	ctx := context.Background()
+	m, mockSt := newTestManager(t)
+
+	t.Run("create", func(t *testing.T) {
+		created, err := m.CreatePrivilegeRole(ctx, "  Scheduler ", []Permission{
+			{Name: ShiftsWrite}, {Name: ShiftsRead}, {Name: ShiftsWrite},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, PrivilegeRole{ID: 2, Name: "Scheduler", Permissions: []Permission{{Name: ShiftsRead}, {Name: ShiftsWrite}}}, created)
+		assert.Contains(t, m.GetPrivilegeRoles(), created)
+		assert.True(t, mockSt.committed)
+
+		_, err = m.CreatePrivilegeRole(ctx, "Scheduler", nil)
+		assert.ErrorIs(t, err, ErrDuplicatePrivilegeRoleName)
+		_, err = m.CreatePrivilegeRole(ctx, " ", nil)
+		assert.ErrorIs(t, err, ErrInvalidPrivilegeRoleName)
+		_, err = m.CreatePrivilegeRole(ctx, "Root", []Permission{{Name: "everything"}})
+		assert.ErrorIs(t, err, ErrUnknownPermission)
+		_, err = m.CreatePrivilegeRole(ctx, "Cook scheduler", []Permission{{Name: ShiftsWrite, JobRoleID: intPtr(2)}})
+		assert.ErrorIs(t, err, ErrUnscopedPermission)
+	})
+
+	t.Run("set permissions", func(t *testing.T) {
+		require.NoError(t, m.SetPrivilegeRolePermissions(ctx, 2, []Permission{{Name: ShiftsRead}}))
+		assert.Equal(t, []Permission{{Name: ShiftsRead}}, m.GetPrivilegeRoles()[1].Permissions)
+
+		assert.ErrorIs(t, m.SetPrivilegeRolePermissions(ctx, 1, nil), ErrBuiltinPrivilegeRole)
+		assert.ErrorIs(t, m.SetPrivilegeRolePermissions(ctx, 9, nil), ErrPrivilegeRoleNotFound)
+	})
+
+	t.Run("assign", func(t *testing.T) {
+		assert.ErrorIs(t, m.SetEmployeePrivilegeRoles(ctx, 7, []int{1}), ErrBuiltinPrivilegeRole)
+		assert.ErrorIs(t, m.SetEmployeePrivilegeRoles(ctx, 7, []int{9}), ErrPrivilegeRoleNotFound)
+		require.NoError(t, m.SetEmployeePrivilegeRoles(ctx, 7, []int{2, 2}))
+		assert.Equal(t, []int{2}, m.GetEmployeePrivilegeRoles(7))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, m.DeletePrivilegeRole(ctx, 2))
+		assert.Len(t, m.GetPrivilegeRoles(), 1)
+		assert.False(t, m.Grants("employee", 7).Has(ShiftsRead))
+		assert.ErrorIs(t, m.DeletePrivilegeRole(ctx, 1), ErrBuiltinPrivilegeRole)
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		var actions []string
+		for _, l := range mockSt.audits {
+			actions = append(actions, l.Action)
+		}
+		assert.Equal(t, []string{"create", "set_permissions", "set_privilege_roles", "delete"}, actions)
+		assert.JSONEq(t, `{"Permissions":[{"Name":"shifts:read","JobRoleID":null},{"Name":"shifts:write","JobRoleID":null}]}`,
+			string(*mockSt.audits[1].Before))
+		assert.Equal(t, audit.EntityEmployee, mockSt.audits[2].EntityType)
+		assert.JSONEq(t, `{"PrivilegeRoleIDs":[2]}`, string(*mockSt.audits[2].After))
+		assert.Nil(t, mockSt.audits[3].After)
+	})
+}
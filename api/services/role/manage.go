@@ -0,0 +1,166 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"payd/services/audit"
+	"payd/storage"
+)
+
+// AdminRoleID is the seeded primary role of admins, it can't be renamed or archived
+const AdminRoleID = 0
+
+const maxRoleNameLength = 50
+
+var ErrRoleNotFound = errors.New("role not found")
+var ErrRoleArchived = errors.New("role is archived")
+var ErrInvalidRoleName = errors.New("invalid role name")
+var ErrDuplicateRoleName = errors.New("an active role with the same name already exists")
+var ErrProtectedRole = errors.New("the admin role can't be changed")
+var ErrUnknownLocation = errors.New("unknown location")
+
+// CreateRole creates a role of the location, locationId is nil for a role shared by every location
+func (rm *RoleManager) CreateRole(ctx context.Context, name string, locationId *int) (Role, error) {
+	name, err := normalizeRoleName(name)
+	if err != nil {
+		return Role{}, err
+	}
+	created, err := rm.createRole(ctx, name, locationId)
+	if err != nil {
+		return Role{}, err
+	}
+	rm.invalidate(ctx)
+	return created, nil
+}
+
+func (rm *RoleManager) createRole(ctx context.Context, name string, locationId *int) (created Role, err error) {
+	tctx, err := rm.storage.NewTransacton(ctx)
+	if err != nil {
+		return Role{}, err
+	}
+	defer rm.storage.Finish(tctx, &err)
+
+	id, err := rm.storage.CreateRole(tctx, name, locationId)
+	if err != nil {
+		return Role{}, mapRoleError(err)
+	}
+	created = Role{ID: id, Name: name, LocationID: locationId}
+	if err = audit.Record(tctx, rm.storage, "create", audit.EntityRole, id, nil, created); err != nil {
+		return Role{}, err
+	}
+	return created, nil
+}
+
+// RenameRole renames an active role, the new name shows up on the existing shifts and employees too
+func (rm *RoleManager) RenameRole(ctx context.Context, id int, name string) error {
+	name, err := normalizeRoleName(name)
+	if err != nil {
+		return err
+	}
+	if err := rm.renameRole(ctx, id, name); err != nil {
+		return err
+	}
+	rm.invalidate(ctx)
+	return nil
+}
+
+func (rm *RoleManager) renameRole(ctx context.Context, id int, name string) (err error) {
+	tctx, err := rm.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer rm.storage.Finish(tctx, &err)
+
+	r, err := rm.mutableRole(tctx, id)
+	if err != nil {
+		return err
+	}
+	renamed, err := rm.storage.RenameRole(tctx, id, name)
+	if err != nil {
+		return mapRoleError(err)
+	}
+	if !renamed {
+		// archived concurrently
+		return ErrRoleArchived
+	}
+	return audit.Record(tctx, rm.storage, "rename", audit.EntityRole, id,
+		map[string]interface{}{"Name": r.Name}, map[string]interface{}{"Name": name})
+}
+
+// ArchiveRole hides the role from GetRoles, existing shifts and employees keep it
+func (rm *RoleManager) ArchiveRole(ctx context.Context, id int) error {
+	if err := rm.archiveRole(ctx, id); err != nil {
+		return err
+	}
+	rm.invalidate(ctx)
+	return nil
+}
+
+func (rm *RoleManager) archiveRole(ctx context.Context, id int) (err error) {
+	tctx, err := rm.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer rm.storage.Finish(tctx, &err)
+
+	r, err := rm.mutableRole(tctx, id)
+	if err != nil {
+		return err
+	}
+	archived, err := rm.storage.ArchiveRole(tctx, id)
+	if err != nil {
+		return err
+	}
+	if !archived {
+		return ErrRoleArchived
+	}
+	return audit.Record(tctx, rm.storage, "archive", audit.EntityRole, id, r, nil)
+}
+
+// mutableRole returns the role unless it is the admin role or archived
+func (rm *RoleManager) mutableRole(ctx context.Context, id int) (*storage.Role, error) {
+	if id == AdminRoleID {
+		return nil, ErrProtectedRole
+	}
+	r, err := rm.storage.SelectRoleByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.ArchivedAt != nil {
+		return nil, ErrRoleArchived
+	}
+	return r, nil
+}
+
+// invalidate refreshes the cache right away instead of waiting for the next tick,
+// on failure the change is picked up by the periodic refresh
+func (rm *RoleManager) invalidate(ctx context.Context) {
+	if err := rm.refresh(ctx); err != nil {
+		log.Printf("role cache invalidation failed: %v", err)
+	}
+}
+
+func normalizeRoleName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxRoleNameLength {
+		return "", ErrInvalidRoleName
+	}
+	return name, nil
+}
+
+func mapRoleError(err error) error {
+	if errors.Is(err, storage.ErrDuplicateRoleName) {
+		return ErrDuplicateRoleName
+	}
+	if errors.Is(err, storage.ErrUnknownLocation) {
+		return ErrUnknownLocation
+	}
+	return err
+}
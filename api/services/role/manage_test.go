@@ -0,0 +1,143 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	"payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *mockStorage) SelectRoleByID(ctx context.Context, id int) (*storage.Role, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rolesToReturn {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockStorage) CreateRole(ctx context.Context, name string, locationId *int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rolesToReturn {
+		if r.ArchivedAt == nil && strings.EqualFold(r.Name, name) {
+			return 0, storage.ErrDuplicateRoleName
+		}
+	}
+	id := len(m.rolesToReturn)
+	m.rolesToReturn = append(m.rolesToReturn, storage.Role{ID: id, Name: name, LocationID: locationId})
+	return id, nil
+}
+
+func (m *mockStorage) RenameRole(ctx context.Context, id int, name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rolesToReturn[id].Name = name
+	return true, nil
+}
+
+func (m *mockStorage) ArchiveRole(ctx context.Context, id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.rolesToReturn[id].ArchivedAt = &now
+	return true, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l storage.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func TestManageRoles(t *testing.T) {
+	ctx := context.Background()
+	mockSt := &mockStorage{
+		rolesToReturn: []storage.Role{
+			{ID: 0, Name: "A"},
+			{ID: 1, Name: "Cashier"},
+		},
+	}
+	// a long tick makes sure the cache is invalidated on change
+	rm := NewRoleManager(mockSt, time.Hour)
+	require.NoError(t, rm.Start(ctx))
+
+	t.Run("create", func(t *testing.T) {
+		created, err := rm.CreateRole(ctx, "  Barista ", nil)
+		require.NoError(t, err)
+		assert.Equal(t, Role{ID: 2, Name: "Barista"}, created)
+		assert.Contains(t, rm.GetRoles(), created)
+
+		_, err = rm.CreateRole(ctx, "barista", nil)
+		assert.ErrorIs(t, err, ErrDuplicateRoleName)
+
+		_, err = rm.CreateRole(ctx, "   ", nil)
+		assert.ErrorIs(t, err, ErrInvalidRoleName)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		require.NoError(t, rm.RenameRole(ctx, 1, "Cashier Lead"))
+		assert.Equal(t, "Cashier Lead", rm.GetRoles()[1].Name)
+
+		assert.ErrorIs(t, rm.RenameRole(ctx, 9, "Unknown"), ErrRoleNotFound)
+		assert.ErrorIs(t, rm.RenameRole(ctx, AdminRoleID, "Boss"), ErrProtectedRole)
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		require.NoError(t, rm.ArchiveRole(ctx, 2))
+		for _, r := range rm.GetRoles() {
+			assert.NotEqual(t, 2, r.ID, "archived role must be hidden")
+		}
+		all := rm.GetAllRoles()
+		require.Len(t, all, 3)
+		assert.True(t, all[2].Archived)
+
+		assert.ErrorIs(t, rm.ArchiveRole(ctx, 2), ErrRoleArchived)
+		assert.ErrorIs(t, rm.RenameRole(ctx, 2, "Barista"), ErrRoleArchived)
+		assert.ErrorIs(t, rm.ArchiveRole(ctx, AdminRoleID), ErrProtectedRole)
+
+		// the name of an archived role can be reused
+		_, err := rm.CreateRole(ctx, "Barista", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		var actions []string
+		for _, l := range mockSt.audits {
+			assert.Equal(t, audit.EntityRole, l.EntityType)
+			actions = append(actions, l.Action)
+		}
+		assert.Equal(t, []string{"create", "rename", "archive", "create"}, actions)
+		assert.JSONEq(t, `{"Name":"Cashier"}`, string(*mockSt.audits[1].Before))
+	})
+}
@@ -6,20 +6,47 @@ import (
 	"sync"
 	"time"
 
+	"payd/services/audit"
 	"payd/storage"
 )
 
 type Role struct {
-	ID   int
-	Name string
+	ID         int
+	Name       string
+	LocationID *int // nil for the roles shared by every location
+	Archived   bool
+}
+
+// AvailableAt reports whether shifts and employees of the location can use the role
+func (r Role) AvailableAt(locationId int) bool {
+	return r.LocationID == nil || *r.LocationID == locationId
+}
+
+// VisibleIn reports whether the role is available at one of the locations, nil locationIds means every location
+func (r Role) VisibleIn(locationIds []int) bool {
+	return r.LocationID == nil || storage.InLocations(locationIds, *r.LocationID)
 }
 
 type Storage interface {
 	SelectAllRoles(ctx context.Context) ([]storage.Role, error)
+	SelectRoleByID(ctx context.Context, id int) (*storage.Role, error)
+	CreateRole(ctx context.Context, name string, locationId *int) (int, error)
+	RenameRole(ctx context.Context, id int, name string) (bool, error)
+	ArchiveRole(ctx context.Context, id int) (bool, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
 }
 
 type RoleManagerInterface interface {
 	GetRoles() []Role
+	GetAllRoles() []Role
+
+	CreateRole(ctx context.Context, name string, locationId *int) (Role, error)
+	RenameRole(ctx context.Context, id int, name string) error
+	ArchiveRole(ctx context.Context, id int) error
 }
 
 type RoleManager struct {
@@ -72,7 +99,7 @@ func (rm *RoleManager) refresh(ctx context.Context) error {
 
 	newRoles := make([]Role, len(storageRoles))
 	for i, sr := range storageRoles {
-		newRoles[i] = Role{ID: sr.ID, Name: sr.Name}
+		newRoles[i] = Role{ID: sr.ID, Name: sr.Name, LocationID: sr.LocationID, Archived: sr.ArchivedAt != nil}
 	}
 
 	rm.mu.Lock()
@@ -81,11 +108,26 @@ func (rm *RoleManager) refresh(ctx context.Context) error {
 	return nil
 }
 
-// GetRoles returns a thread-safe copy of the latest role list.
+// GetRoles returns a thread-safe copy of the latest active role list, the roles new shifts and employees can use.
 func (rm *RoleManager) GetRoles() []Role {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 
+	active := make([]Role, 0, len(rm.roles))
+	for _, r := range rm.roles {
+		if !r.Archived {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
+// GetAllRoles returns a thread-safe copy of the latest role list including the archived roles,
+// used to resolve the role of existing shifts and employees.
+func (rm *RoleManager) GetAllRoles() []Role {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
 	copied := make([]Role, len(rm.roles))
 	copy(copied, rm.roles)
 	return copied
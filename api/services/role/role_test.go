@@ -18,6 +18,7 @@ type mockStorage struct {
 	errToReturn   error
 	mu            sync.Mutex
 	callCount     int
+	audits        []storage.AuditLog
 }
 
 func (m *mockStorage) SelectAllRoles(ctx context.Context) ([]storage.Role, error) {
@@ -0,0 +1,283 @@
+package roster
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+	"payd/util"
+)
+
+// maxRange bounds the time range of a proposal
+const maxRange = 31 * 24 * time.Hour
+
+// maxZoneOffset is the largest UTC offset of a timezone, the approved shifts are looked up that far around the weeks
+const maxZoneOffset = 14 * time.Hour
+
+var ErrInvalidTimeRange = errors.New("start must be before end")
+var ErrRangeTooLong = errors.New("time range must be at most 31 days")
+var ErrInvalidLimits = errors.New("limits must not be negative")
+var ErrNothingToCommit = errors.New("no shift request to approve")
+
+type storage interface {
+	ListOpenShiftsByTimeRange(ctx context.Context, start, end time.Time, roleIds, locationIds []int) ([]st.ShiftWithAssignees, error)
+	ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error)
+	ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error)
+}
+
+type RosterInterface interface {
+	Propose(ctx context.Context, opts Options) (*Proposal, error)
+	Commit(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error)
+}
+
+// availabilityChecker compares a shift with the employee's declared availability, see availability.Availability
+type availabilityChecker interface {
+	CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error)
+}
+
+// leaveChecker refuses the shifts during approved leave, see leave.Leave
+type leaveChecker interface {
+	CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error
+}
+
+// timezoneLister maps the locations to their business timezone, see location.Location
+type timezoneLister interface {
+	Timezones(ctx context.Context) (location.Timezones, error)
+}
+
+// requestApprover approves the requests of a committed proposal in one transaction, see shiftrequest.ShiftRequest
+type requestApprover interface {
+	ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error)
+}
+
+// Options select the open shifts starting within [Start, End) to fill and the limits of the proposal
+type Options struct {
+	Start                time.Time
+	End                  time.Time
+	RoleIDs              []int // nil for every job role
+	LocationIDs          []int // nil for every location
+	MaxHoursPerWeek      float64
+	MaxShiftsPerEmployee int
+}
+
+// Proposal is the preview of a roster, committing it approves the request of every change
+type Proposal struct {
+	Changes  []Change
+	Unfilled []UnfilledShift
+}
+
+// Change assigns one request to a shift, a shift with several open slots can have several changes
+type Change struct {
+	Shift   st.Shift
+	Request st.ShiftRequestWithShiftDetails // approved by the commit
+	// the other pending requests of the shift the proposal fully staffs, rejected by the commit.
+	// listed on the last change of the shift
+	RejectedRequestIDs []int
+	// the shift is outside the employee's weekly availability
+	OutsideAvailability bool
+}
+
+type UnfilledShift struct {
+	Shift  st.Shift
+	Reason string
+	Slots  int // left open by the proposal
+}
+
+type Roster struct {
+	storage      storage
+	availability availabilityChecker
+	leave        leaveChecker
+	approver     requestApprover
+	zones        timezoneLister
+	solver       Solver
+}
+
+func NewRoster(storage storage, availability availabilityChecker, leave leaveChecker, approver requestApprover,
+	zones timezoneLister, solver Solver) *Roster {
+	return &Roster{
+		storage:      storage,
+		availability: availability,
+		leave:        leave,
+		approver:     approver,
+		zones:        zones,
+		solver:       solver,
+	}
+}
+
+// Propose assigns the pending requests of the open shifts with the solver, nothing is stored.
+// the requests of employees on leave or unavailable during the shift are left out
+func (r *Roster) Propose(ctx context.Context, opts Options) (*Proposal, error) {
+	if !opts.Start.Before(opts.End) {
+		return nil, ErrInvalidTimeRange
+	}
+	if opts.End.Sub(opts.Start) > maxRange {
+		return nil, ErrRangeTooLong
+	}
+	if opts.MaxHoursPerWeek < 0 || opts.MaxShiftsPerEmployee < 0 {
+		return nil, ErrInvalidLimits
+	}
+
+	zones, err := r.zones.Timezones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := r.storage.ListOpenShiftsByTimeRange(ctx, opts.Start, opts.End, opts.RoleIDs, opts.LocationIDs)
+	if err != nil {
+		return nil, err
+	}
+	openShifts := make(map[int]st.ShiftWithAssignees, len(shifts))
+	problem := Problem{
+		Busy:                 map[int][]Interval{},
+		MaxHoursPerWeek:      opts.MaxHoursPerWeek,
+		MaxShiftsPerEmployee: opts.MaxShiftsPerEmployee,
+	}
+	for _, sh := range shifts {
+		openShifts[sh.ID] = sh
+		problem.Shifts = append(problem.Shifts, Shift{
+			ID:       sh.ID,
+			Interval: Interval{Start: sh.StartTime, End: sh.EndTime, Zone: zones[sh.LocationID]},
+			Slots:    sh.OpenSlots(),
+		})
+	}
+
+	pending, err := r.storage.ListShiftRequestsByFilterAndTimeRange(ctx, st.ListShiftRequestFilter{
+		RoleIDs:     opts.RoleIDs,
+		LocationIDs: opts.LocationIDs,
+		Status:      shiftrequest.StatusPending,
+	}, opts.Start, opts.End)
+	if err != nil {
+		return nil, err
+	}
+	requests := map[int]st.ShiftRequestWithShiftDetails{}
+	pendingByShift := map[int][]int{}
+	for _, req := range pending {
+		if _, ok := openShifts[req.ShiftID]; !ok {
+			continue
+		}
+		requests[req.ID] = req
+		pendingByShift[req.ShiftID] = append(pendingByShift[req.ShiftID], req.ID)
+
+		candidate, ok, err := r.candidate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		problem.Candidates = append(problem.Candidates, candidate)
+		if _, ok := problem.Busy[req.EmployeeID]; !ok {
+			if problem.Busy[req.EmployeeID], err = r.busy(ctx, req.EmployeeID, opts.Start, opts.End, zones); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	solution, err := r.solver.Solve(ctx, problem)
+	if err != nil {
+		return nil, err
+	}
+
+	outside := map[int]bool{}
+	for _, c := range problem.Candidates {
+		outside[c.RequestID] = c.OutsideAvailability
+	}
+	proposal := &Proposal{}
+	chosen := map[int]bool{}
+	last := map[int]int{} // the index of the last change of every shift
+	for _, a := range solution.Assignments {
+		chosen[a.RequestID] = true
+		last[a.ShiftID] = len(proposal.Changes)
+		proposal.Changes = append(proposal.Changes, Change{
+			Shift:               openShifts[a.ShiftID].Shift,
+			Request:             requests[a.RequestID],
+			OutsideAvailability: outside[a.RequestID],
+		})
+	}
+	unfilled := map[int]bool{}
+	for _, u := range solution.Unfilled {
+		unfilled[u.ShiftID] = true
+		proposal.Unfilled = append(proposal.Unfilled, UnfilledShift{Shift: openShifts[u.ShiftID].Shift, Reason: u.Reason, Slots: u.Slots})
+	}
+	// approving the last open slot of a shift rejects its other pending requests, see shiftrequest.ApproveShiftRequest
+	for shiftId, i := range last {
+		if unfilled[shiftId] {
+			continue
+		}
+		for _, id := range pendingByShift[shiftId] {
+			if !chosen[id] {
+				proposal.Changes[i].RejectedRequestIDs = append(proposal.Changes[i].RejectedRequestIDs, id)
+			}
+		}
+	}
+	// the preview reads in shift order rather than in the order the solver filled the shifts
+	sort.SliceStable(proposal.Changes, func(i, j int) bool {
+		return shiftBefore(proposal.Changes[i].Shift, proposal.Changes[j].Shift)
+	})
+	sort.Slice(proposal.Unfilled, func(i, j int) bool {
+		return shiftBefore(proposal.Unfilled[i].Shift, proposal.Unfilled[j].Shift)
+	})
+	return proposal, nil
+}
+
+// Commit approves the requests of a previewed proposal and rejects the other pending requests of the shifts it fully staffs,
+// all or none in one transaction attributed to the reviewer.
+// the proposal is checked again, the error of a request gone stale since the preview is wrapped with its id
+func (r *Roster) Commit(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	if len(requestIds) == 0 {
+		return nil, ErrNothingToCommit
+	}
+	return r.approver.ApproveShiftRequests(ctx, requestIds, reviewer)
+}
+
+// candidate returns false for a request of an employee on leave or unavailable during the shift
+func (r *Roster) candidate(ctx context.Context, req st.ShiftRequestWithShiftDetails) (Candidate, bool, error) {
+	if err := r.leave.CheckShift(ctx, req.EmployeeID, req.LocationID, req.StartTime, req.EndTime); err != nil {
+		var onLeave *leave.OnLeaveError
+		if errors.As(err, &onLeave) {
+			return Candidate{}, false, nil
+		}
+		return Candidate{}, false, err
+	}
+	outside, err := r.availability.CheckShift(ctx, req.EmployeeID, req.StartTime, req.EndTime)
+	if err != nil {
+		var unavailable *availability.UnavailableError
+		if errors.As(err, &unavailable) {
+			return Candidate{}, false, nil
+		}
+		return Candidate{}, false, err
+	}
+	return Candidate{
+		RequestID:           req.ID,
+		EmployeeID:          req.EmployeeID,
+		ShiftID:             req.ShiftID,
+		OutsideAvailability: outside,
+	}, true, nil
+}
+
+func shiftBefore(a, b st.Shift) bool {
+	if !a.StartTime.Equal(b.StartTime) {
+		return a.StartTime.Before(b.StartTime)
+	}
+	return a.ID < b.ID
+}
+
+// busy returns the approved shifts of the employee over the whole weeks of [start, end) in any timezone
+func (r *Roster) busy(ctx context.Context, employeeId int, start, end time.Time, zones location.Timezones) ([]Interval, error) {
+	from := util.WeekStart(util.CivilDate(start)).Add(-maxZoneOffset)
+	to := util.WeekStart(util.CivilDate(end)).AddDate(0, 0, 7).Add(maxZoneOffset)
+	shifts, err := r.storage.ListOverlappingApprovedShifts(ctx, employeeId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy := make([]Interval, 0, len(shifts))
+	for _, sh := range shifts {
+		busy = append(busy, Interval{Start: sh.StartTime, End: sh.EndTime, Zone: zones[sh.LocationID]})
+	}
+	return busy, nil
+}
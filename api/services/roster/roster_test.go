@@ -0,0 +1,171 @@
+package roster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/location"
+	"payd/services/shiftrequest"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStorage struct {
+	shifts   []st.ShiftWithAssignees
+	requests []st.ShiftRequestWithShiftDetails
+	approved map[int][]st.Shift
+	filter   st.ListShiftRequestFilter
+}
+
+func (m *mockStorage) ListOpenShiftsByTimeRange(ctx context.Context, start, end time.Time, roleIds, locationIds []int) ([]st.ShiftWithAssignees, error) {
+	return m.shifts, nil
+}
+
+func (m *mockStorage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	m.filter = filter
+	return m.requests, nil
+}
+
+func (m *mockStorage) ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error) {
+	return m.approved[employeeId], nil
+}
+
+type mockAvailabilityChecker struct {
+	outside     map[int]bool // by employee
+	unavailable map[int]bool
+}
+
+func (m mockAvailabilityChecker) CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error) {
+	if m.unavailable[employeeId] {
+		return false, &availability.UnavailableError{}
+	}
+	return m.outside[employeeId], nil
+}
+
+type mockLeaveChecker struct {
+	onLeave map[int]bool
+}
+
+func (m mockLeaveChecker) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	if m.onLeave[employeeId] {
+		return &leave.OnLeaveError{LeaveRequestID: 1}
+	}
+	return nil
+}
+
+type mockTimezones location.Timezones
+
+func (m mockTimezones) Timezones(ctx context.Context) (location.Timezones, error) {
+	return location.Timezones(m), nil
+}
+
+type mockApprover struct {
+	requestIds []int
+	reviewer   shiftrequest.Reviewer
+}
+
+func (m *mockApprover) ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer shiftrequest.Reviewer) ([]string, error) {
+	m.requestIds, m.reviewer = requestIds, reviewer
+	return nil, nil
+}
+
+func pendingRequest(id, employeeId int, sh st.Shift) st.ShiftRequestWithShiftDetails {
+	return st.ShiftRequestWithShiftDetails{
+		ID: id, EmployeeID: employeeId, ShiftID: sh.ID, Status: shiftrequest.StatusPending,
+		RoleID: sh.RoleID, StartTime: sh.StartTime, EndTime: sh.EndTime,
+	}
+}
+
+func openShift(sh st.Shift, approved int) st.ShiftWithAssignees {
+	return st.ShiftWithAssignees{Shift: sh, Approved: approved}
+}
+
+func TestPropose(t *testing.T) {
+	first := st.Shift{ID: 1, RoleID: 2, StartTime: monday.Add(9 * time.Hour), EndTime: monday.Add(17 * time.Hour), Headcount: 1}
+	second := st.Shift{ID: 2, RoleID: 2, StartTime: monday.Add(33 * time.Hour), EndTime: monday.Add(41 * time.Hour), Headcount: 1}
+	third := st.Shift{ID: 3, RoleID: 2, StartTime: monday.Add(57 * time.Hour), EndTime: monday.Add(65 * time.Hour), Headcount: 3}
+	storage := &mockStorage{
+		shifts: []st.ShiftWithAssignees{openShift(first, 0), openShift(second, 0), openShift(third, 1)},
+		requests: []st.ShiftRequestWithShiftDetails{
+			pendingRequest(10, 4, first),
+			pendingRequest(11, 5, first),
+			pendingRequest(12, 6, second),
+			// on leave
+			pendingRequest(13, 7, second),
+			// a shift already filled since
+			pendingRequest(14, 4, st.Shift{ID: 9, RoleID: 2, StartTime: monday, EndTime: monday.Add(time.Hour)}),
+		},
+		approved: map[int][]st.Shift{4: {{ID: 8, StartTime: monday.AddDate(0, 0, 3), EndTime: monday.AddDate(0, 0, 3).Add(8 * time.Hour)}}},
+	}
+	r := NewRoster(storage, mockAvailabilityChecker{outside: map[int]bool{6: true}}, mockLeaveChecker{onLeave: map[int]bool{7: true}},
+		&mockApprover{}, mockTimezones{}, Greedy{})
+
+	proposal, err := r.Propose(context.Background(), Options{Start: monday, End: monday.AddDate(0, 0, 7), RoleIDs: []int{2}})
+	require.NoError(t, err)
+	assert.Equal(t, st.ListShiftRequestFilter{RoleIDs: []int{2}, Status: shiftrequest.StatusPending}, storage.filter)
+
+	require.Len(t, proposal.Changes, 2)
+	// employee 4 already has hours this week
+	assert.Equal(t, 11, proposal.Changes[0].Request.ID)
+	assert.Equal(t, []int{10}, proposal.Changes[0].RejectedRequestIDs)
+	assert.Equal(t, 12, proposal.Changes[1].Request.ID)
+	assert.True(t, proposal.Changes[1].OutsideAvailability)
+	assert.Equal(t, []int{13}, proposal.Changes[1].RejectedRequestIDs)
+
+	require.Len(t, proposal.Unfilled, 1)
+	assert.Equal(t, third, proposal.Unfilled[0].Shift)
+	assert.Equal(t, ReasonNoRequests, proposal.Unfilled[0].Reason)
+	assert.Equal(t, 2, proposal.Unfilled[0].Slots)
+}
+
+func TestProposeHeadcount(t *testing.T) {
+	crew := st.Shift{ID: 1, RoleID: 2, StartTime: monday.Add(9 * time.Hour), EndTime: monday.Add(17 * time.Hour), Headcount: 3}
+	storage := &mockStorage{
+		shifts: []st.ShiftWithAssignees{openShift(crew, 1)},
+		requests: []st.ShiftRequestWithShiftDetails{
+			pendingRequest(10, 4, crew),
+			pendingRequest(11, 5, crew),
+			pendingRequest(12, 6, crew),
+		},
+		approved: map[int][]st.Shift{4: {{ID: 8, StartTime: monday.AddDate(0, 0, 3), EndTime: monday.AddDate(0, 0, 3).Add(8 * time.Hour)}}},
+	}
+	r := NewRoster(storage, mockAvailabilityChecker{}, mockLeaveChecker{}, &mockApprover{}, mockTimezones{}, Greedy{})
+
+	proposal, err := r.Propose(context.Background(), Options{Start: monday, End: monday.AddDate(0, 0, 7)})
+	require.NoError(t, err)
+
+	// the two open slots go to the employees without hours this week
+	require.Len(t, proposal.Changes, 2)
+	assert.ElementsMatch(t, []int{11, 12}, []int{proposal.Changes[0].Request.ID, proposal.Changes[1].Request.ID})
+	assert.Empty(t, proposal.Changes[0].RejectedRequestIDs)
+	assert.Equal(t, []int{10}, proposal.Changes[1].RejectedRequestIDs)
+	assert.Empty(t, proposal.Unfilled)
+}
+
+func TestProposeValidation(t *testing.T) {
+	r := NewRoster(&mockStorage{}, mockAvailabilityChecker{}, mockLeaveChecker{}, &mockApprover{}, mockTimezones{}, Greedy{})
+
+	_, err := r.Propose(context.Background(), Options{Start: monday, End: monday})
+	assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	_, err = r.Propose(context.Background(), Options{Start: monday, End: monday.AddDate(0, 2, 0)})
+	assert.ErrorIs(t, err, ErrRangeTooLong)
+	_, err = r.Propose(context.Background(), Options{Start: monday, End: monday.AddDate(0, 0, 7), MaxHoursPerWeek: -1})
+	assert.ErrorIs(t, err, ErrInvalidLimits)
+}
+
+func TestCommit(t *testing.T) {
+	approver := &mockApprover{}
+	r := NewRoster(&mockStorage{}, mockAvailabilityChecker{}, mockLeaveChecker{}, approver, mockTimezones{}, Greedy{})
+
+	_, err := r.Commit(context.Background(), nil, shiftrequest.Reviewer{EmployeeID: 1})
+	assert.ErrorIs(t, err, ErrNothingToCommit)
+
+	_, err = r.Commit(context.Background(), []int{11, 12}, shiftrequest.Reviewer{EmployeeID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{11, 12}, approver.requestIds)
+}
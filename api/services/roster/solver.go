@@ -0,0 +1,201 @@
+package roster
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payd/util"
+)
+
+// the reasons a shift is left unfilled
+const (
+	ReasonNoRequests = "no pending requests"
+	ReasonNoEligible = "no eligible candidate"
+)
+
+// Problem is the input of a Solver, every candidate is a pending request of an employee for an open shift
+type Problem struct {
+	Shifts     []Shift
+	Candidates []Candidate
+	// the approved shifts of the candidates by employee, over whole weeks around the shifts
+	Busy map[int][]Interval
+	// the limit of approved and assigned hours of an employee within a week starting on Monday, 0 for no limit.
+	// the week of a shift is the one of the calendar date it starts on in its timezone
+	MaxHoursPerWeek float64
+	// the limit of shifts assigned to an employee by one solution, 0 for no limit
+	MaxShiftsPerEmployee int
+}
+
+type Interval struct {
+	Start time.Time
+	End   time.Time
+	Zone  *time.Location // of the shift location, nil for UTC
+}
+
+type Shift struct {
+	ID int
+	Interval
+	Slots int // the headcount still to staff, at least 1
+}
+
+type Candidate struct {
+	RequestID  int
+	EmployeeID int
+	ShiftID    int
+	// the shift is outside the employee's weekly availability, they are only picked when no one else is eligible
+	OutsideAvailability bool
+}
+
+type Assignment struct {
+	ShiftID    int
+	RequestID  int
+	EmployeeID int
+}
+
+type Unfilled struct {
+	ShiftID int
+	Reason  string
+	Slots   int // left open
+}
+
+// Solution assigns at most as many candidates as open slots to every shift of the problem
+type Solution struct {
+	Assignments []Assignment
+	Unfilled    []Unfilled
+}
+
+// Solver assigns candidates to shifts without double-booking them or exceeding the limits of the problem.
+// Greedy is the default, an exact ILP or CP backend can be plugged in with the same contract
+type Solver interface {
+	Solve(ctx context.Context, p Problem) (Solution, error)
+}
+
+// Greedy fills the most constrained shifts first, the ones with the fewest candidates per open slot.
+// every slot goes to the eligible candidate within their availability and with the fewest hours so far,
+// spreading the hours fairly. ties go to the earliest request
+type Greedy struct{}
+
+func (Greedy) Solve(ctx context.Context, p Problem) (Solution, error) {
+	candidates := map[int][]Candidate{}
+	for _, c := range p.Candidates {
+		candidates[c.ShiftID] = append(candidates[c.ShiftID], c)
+	}
+	shifts := append([]Shift(nil), p.Shifts...)
+	sort.SliceStable(shifts, func(i, j int) bool {
+		si, sj := len(candidates[shifts[i].ID])-shifts[i].Slots, len(candidates[shifts[j].ID])-shifts[j].Slots
+		if si != sj {
+			return si < sj
+		}
+		return shifts[i].Start.Before(shifts[j].Start)
+	})
+
+	loads := map[int]*load{}
+	loadOf := func(employeeId int) *load {
+		l, ok := loads[employeeId]
+		if !ok {
+			l = newLoad(p.Busy[employeeId])
+			loads[employeeId] = l
+		}
+		return l
+	}
+
+	var sol Solution
+	for _, sh := range shifts {
+		if err := ctx.Err(); err != nil {
+			return Solution{}, err
+		}
+		assigned := 0
+		for ; assigned < sh.Slots; assigned++ {
+			// an assigned candidate overlaps the shift from then on, they don't fit twice
+			var best *Candidate
+			for i, c := range candidates[sh.ID] {
+				l := loadOf(c.EmployeeID)
+				if !l.fits(sh.Interval, p.MaxHoursPerWeek, p.MaxShiftsPerEmployee) {
+					continue
+				}
+				if best == nil || better(c, l, *best, loadOf(best.EmployeeID)) {
+					best = &candidates[sh.ID][i]
+				}
+			}
+			if best == nil {
+				break
+			}
+			loadOf(best.EmployeeID).add(sh.Interval)
+			sol.Assignments = append(sol.Assignments, Assignment{ShiftID: sh.ID, RequestID: best.RequestID, EmployeeID: best.EmployeeID})
+		}
+		if assigned < sh.Slots {
+			reason := ReasonNoEligible
+			if assigned == len(candidates[sh.ID]) {
+				reason = ReasonNoRequests
+			}
+			sol.Unfilled = append(sol.Unfilled, Unfilled{ShiftID: sh.ID, Reason: reason, Slots: sh.Slots - assigned})
+		}
+	}
+	return sol, nil
+}
+
+// better reports whether candidate a with load la is preferred over b with load lb
+func better(a Candidate, la *load, b Candidate, lb *load) bool {
+	if a.OutsideAvailability != b.OutsideAvailability {
+		return !a.OutsideAvailability
+	}
+	if la.hours != lb.hours {
+		return la.hours < lb.hours
+	}
+	return a.RequestID < b.RequestID
+}
+
+// load tracks the shifts of an employee while solving
+type load struct {
+	intervals []Interval
+	weekHours map[time.Time]float64
+	hours     float64 // approved and assigned
+	assigned  int
+}
+
+func newLoad(busy []Interval) *load {
+	l := &load{weekHours: map[time.Time]float64{}}
+	for _, in := range busy {
+		l.intervals = append(l.intervals, in)
+		l.weekHours[in.week()] += hours(in)
+		l.hours += hours(in)
+	}
+	return l
+}
+
+func (l *load) fits(in Interval, maxHoursPerWeek float64, maxShifts int) bool {
+	if maxShifts > 0 && l.assigned >= maxShifts {
+		return false
+	}
+	if maxHoursPerWeek > 0 && l.weekHours[in.week()]+hours(in) > maxHoursPerWeek {
+		return false
+	}
+	for _, o := range l.intervals {
+		if o.Start.Before(in.End) && in.Start.Before(o.End) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *load) add(in Interval) {
+	l.intervals = append(l.intervals, in)
+	l.weekHours[in.week()] += hours(in)
+	l.hours += hours(in)
+	l.assigned++
+}
+
+func hours(in Interval) float64 {
+	return in.End.Sub(in.Start).Hours()
+}
+
+// week returns the calendar date of the Monday of the week the interval starts in, in its timezone.
+// the weeks of the shifts of different locations are compared by their dates
+func (in Interval) week() time.Time {
+	start := in.Start.UTC()
+	if in.Zone != nil {
+		start = in.Start.In(in.Zone)
+	}
+	return util.WeekStart(util.CivilDate(start))
+}
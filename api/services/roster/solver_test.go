@@ -0,0 +1,162 @@
+package roster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a Monday
+var monday = time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)
+
+func shiftAt(id int, day, hour, length int) Shift {
+	start := monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	return Shift{ID: id, Interval: Interval{Start: start, End: start.Add(time.Duration(length) * time.Hour)}, Slots: 1}
+}
+
+// newYork is 4 hours behind UTC in may
+var newYork = time.FixedZone("EDT", -4*60*60)
+
+func inZone(sh Shift, zone *time.Location) Shift {
+	sh.Zone = zone
+	return sh
+}
+
+func withSlots(sh Shift, slots int) Shift {
+	sh.Slots = slots
+	return sh
+}
+
+func TestGreedySolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		problem  Problem
+		assigned map[int][]int // shift id to employee ids
+		unfilled map[int]string
+	}{
+		{
+			name: "spreads the shifts fairly",
+			problem: Problem{
+				Shifts: []Shift{shiftAt(1, 0, 9, 8), shiftAt(2, 1, 9, 8)},
+				Candidates: []Candidate{
+					{RequestID: 10, EmployeeID: 4, ShiftID: 1},
+					{RequestID: 11, EmployeeID: 5, ShiftID: 1},
+					{RequestID: 12, EmployeeID: 4, ShiftID: 2},
+					{RequestID: 13, EmployeeID: 5, ShiftID: 2},
+				},
+			},
+			assigned: map[int][]int{1: {4}, 2: {5}},
+		},
+		{
+			name: "fills every slot of a shift",
+			problem: Problem{
+				Shifts: []Shift{withSlots(shiftAt(1, 0, 9, 8), 2), withSlots(shiftAt(2, 1, 9, 8), 3)},
+				Candidates: []Candidate{
+					{RequestID: 10, EmployeeID: 4, ShiftID: 1},
+					{RequestID: 11, EmployeeID: 5, ShiftID: 1},
+					{RequestID: 12, EmployeeID: 6, ShiftID: 1},
+					{RequestID: 13, EmployeeID: 4, ShiftID: 2},
+				},
+			},
+			// employee 4 fills the other shift first
+			assigned: map[int][]int{1: {5, 6}, 2: {4}},
+			unfilled: map[int]string{2: ReasonNoRequests},
+		},
+		{
+			name: "fills the most constrained shift first",
+			problem: Problem{
+				Shifts: []Shift{shiftAt(1, 0, 9, 8), shiftAt(2, 0, 12, 8)},
+				Candidates: []Candidate{
+					{RequestID: 10, EmployeeID: 4, ShiftID: 1},
+					{RequestID: 11, EmployeeID: 5, ShiftID: 1},
+					{RequestID: 12, EmployeeID: 4, ShiftID: 2},
+				},
+			},
+			assigned: map[int][]int{1: {5}, 2: {4}},
+		},
+		{
+			name: "no double-booking",
+			problem: Problem{
+				Shifts: []Shift{shiftAt(1, 0, 9, 8), shiftAt(2, 0, 12, 8)},
+				Candidates: []Candidate{
+					{RequestID: 10, EmployeeID: 4, ShiftID: 1},
+					{RequestID: 12, EmployeeID: 4, ShiftID: 2},
+				},
+			},
+			assigned: map[int][]int{1: {4}},
+			unfilled: map[int]string{2: ReasonNoEligible},
+		},
+		{
+			name: "approved shifts count towards the weekly hours",
+			problem: Problem{
+				Shifts:          []Shift{shiftAt(1, 4, 9, 8), shiftAt(2, 7, 9, 8)},
+				Candidates:      []Candidate{{RequestID: 10, EmployeeID: 4, ShiftID: 1}, {RequestID: 11, EmployeeID: 4, ShiftID: 2}},
+				Busy:            map[int][]Interval{4: {shiftAt(0, 0, 9, 8).Interval, shiftAt(0, 1, 9, 8).Interval}},
+				MaxHoursPerWeek: 20,
+			},
+			// the next week starts over
+			assigned: map[int][]int{2: {4}},
+			unfilled: map[int]string{1: ReasonNoEligible},
+		},
+		{
+			name: "weeks start on Monday in the timezone of the shifts",
+			problem: Problem{
+				// sunday 10:00-16:00 and monday 09:00-17:00 in new york
+				Shifts:     []Shift{inZone(shiftAt(1, 7, 13, 8), newYork), inZone(shiftAt(2, 6, 14, 6), newYork)},
+				Candidates: []Candidate{{RequestID: 10, EmployeeID: 4, ShiftID: 1}, {RequestID: 11, EmployeeID: 4, ShiftID: 2}},
+				// sunday 20:00-23:00 in new york, monday in UTC
+				Busy:            map[int][]Interval{4: {inZone(shiftAt(0, 7, 0, 3), newYork).Interval}},
+				MaxHoursPerWeek: 8,
+			},
+			assigned: map[int][]int{1: {4}},
+			unfilled: map[int]string{2: ReasonNoEligible},
+		},
+		{
+			name: "prefers candidates within their availability",
+			problem: Problem{
+				Shifts: []Shift{shiftAt(1, 0, 9, 8)},
+				Candidates: []Candidate{
+					{RequestID: 10, EmployeeID: 4, ShiftID: 1, OutsideAvailability: true},
+					{RequestID: 11, EmployeeID: 5, ShiftID: 1},
+				},
+				Busy: map[int][]Interval{5: {shiftAt(0, 1, 9, 8).Interval}},
+			},
+			assigned: map[int][]int{1: {5}},
+		},
+		{
+			name: "max shifts per employee",
+			problem: Problem{
+				Shifts:               []Shift{shiftAt(1, 0, 9, 8), shiftAt(2, 1, 9, 8), shiftAt(3, 2, 9, 8)},
+				Candidates:           []Candidate{{RequestID: 10, EmployeeID: 4, ShiftID: 1}, {RequestID: 11, EmployeeID: 4, ShiftID: 2}},
+				MaxShiftsPerEmployee: 1,
+			},
+			assigned: map[int][]int{1: {4}},
+			unfilled: map[int]string{2: ReasonNoEligible, 3: ReasonNoRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sol, err := Greedy{}.Solve(context.Background(), tt.problem)
+			require.NoError(t, err)
+
+			assigned := map[int][]int{}
+			for _, a := range sol.Assignments {
+				assigned[a.ShiftID] = append(assigned[a.ShiftID], a.EmployeeID)
+			}
+			assert.Equal(t, tt.assigned, assigned)
+
+			unfilled := map[int]string{}
+			for _, u := range sol.Unfilled {
+				unfilled[u.ShiftID] = u.Reason
+			}
+			if tt.unfilled == nil {
+				tt.unfilled = map[int]string{}
+			}
+			assert.Equal(t, tt.unfilled, unfilled)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package shift
+
+import (
+	"context"
+	"errors"
+
+	st "payd/storage"
+)
+
+var ErrShiftFullyStaffed = errors.New("shift is already fully staffed")
+var ErrEmployeeDoubleBooked = errors.New("employee already has an approved shift overlapping this one")
+
+// ConflictError is returned when assigning an employee to a shift would exceed the headcount of the shift
+// or double-book the employee, Err is ErrShiftFullyStaffed or ErrEmployeeDoubleBooked
+type ConflictError struct {
+	Err                error
+	EmployeeID         int
+	ShiftID            int
+	ConflictingShiftID int // the employee's overlapping shift, 0 if unknown
+}
+
+func (e *ConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// CheckAssignmentConflict returns a *ConflictError if approving the employee for the shift
+// would exceed its headcount or double-book the employee.
+// call it with a transactional ctx after locking the shift to make the check race free
+func (s *Shift) CheckAssignmentConflict(ctx context.Context, employeeId, shiftId int) error {
+	shift, err := s.storage.GetShiftByID(ctx, shiftId)
+	if err != nil {
+		return err
+	}
+
+	approved, err := s.storage.CountApprovedRequestsByShiftID(ctx, shiftId)
+	if err != nil {
+		return err
+	}
+	if approved >= shift.Headcount {
+		return &ConflictError{Err: ErrShiftFullyStaffed, EmployeeID: employeeId, ShiftID: shiftId}
+	}
+
+	overlaps, err := s.storage.ListOverlappingApprovedShifts(ctx, employeeId, shift.StartTime, shift.EndTime)
+	if err != nil {
+		return err
+	}
+	for _, o := range overlaps {
+		if o.ID != shiftId {
+			return &ConflictError{Err: ErrEmployeeDoubleBooked, EmployeeID: employeeId, ShiftID: shiftId, ConflictingShiftID: o.ID}
+		}
+	}
+	return nil
+}
+
+// AsConflictError converts the database headcount and double-booking guard errors into a *ConflictError,
+// any other error is returned as is
+func AsConflictError(err error, employeeId, shiftId int) error {
+	switch {
+	case errors.Is(err, st.ErrShiftFullyStaffed):
+		return &ConflictError{Err: ErrShiftFullyStaffed, EmployeeID: employeeId, ShiftID: shiftId}
+	case errors.Is(err, st.ErrOverlappingApprovedShift):
+		return &ConflictError{Err: ErrEmployeeDoubleBooked, EmployeeID: employeeId, ShiftID: shiftId}
+	}
+	return err
+}
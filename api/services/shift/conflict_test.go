@@ -0,0 +1,131 @@
+package shift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorage struct {
+	shifts        map[int]*st.Shift
+	approvedCount int
+	overlaps      []st.Shift
+	err           error
+
+	template *st.ShiftTemplate
+	created  []st.NewShift
+
+	approved []st.ShiftRequest
+	pending  []st.ShiftRequest
+	edits    []st.ShiftEditLog
+	audits   []st.AuditLog
+	events   []st.OutboxEvent
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error {
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *mockStorage) CreateNewShiftSchedules(ctx context.Context, shifts []st.NewShift) ([]int, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockStorage) GetShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.shifts[id], nil
+}
+
+func (m *mockStorage) CountApprovedRequestsByShiftID(ctx context.Context, shiftId int) (int, error) {
+	return m.approvedCount, nil
+}
+
+func (m *mockStorage) ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error) {
+	return m.overlaps, nil
+}
+
+func TestCheckAssignmentConflict(t *testing.T) {
+	start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+	shift := &st.Shift{ID: 3, RoleID: 1, StartTime: start, EndTime: start.Add(8 * time.Hour), Headcount: 2}
+
+	tests := []struct {
+		name        string
+		storage     *mockStorage
+		expectedErr error
+		conflicting int
+	}{
+		{
+			name:    "no conflict",
+			storage: &mockStorage{shifts: map[int]*st.Shift{3: shift}},
+		},
+		{
+			name:    "shift with an open slot",
+			storage: &mockStorage{shifts: map[int]*st.Shift{3: shift}, approvedCount: 1},
+		},
+		{
+			name:        "shift fully staffed",
+			storage:     &mockStorage{shifts: map[int]*st.Shift{3: shift}, approvedCount: 2},
+			expectedErr: ErrShiftFullyStaffed,
+		},
+		{
+			name: "employee has an overlapping shift",
+			storage: &mockStorage{shifts: map[int]*st.Shift{3: shift}, overlaps: []st.Shift{
+				{ID: 7, StartTime: start.Add(-2 * time.Hour), EndTime: start.Add(2 * time.Hour)},
+			}},
+			expectedErr: ErrEmployeeDoubleBooked,
+			conflicting: 7,
+		},
+		{
+			name:        "db error",
+			storage:     &mockStorage{err: errors.New("db error")},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewShift(tc.storage).CheckAssignmentConflict(context.Background(), 4, 3)
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedErr.Error())
+
+			var conflict *ConflictError
+			if errors.As(err, &conflict) {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, 4, conflict.EmployeeID)
+				assert.Equal(t, 3, conflict.ShiftID)
+				assert.Equal(t, tc.conflicting, conflict.ConflictingShiftID)
+			}
+		})
+	}
+}
+
+func TestAsConflictError(t *testing.T) {
+	var conflict *ConflictError
+
+	err := AsConflictError(st.ErrShiftFullyStaffed, 4, 3)
+	assert.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, ErrShiftFullyStaffed)
+
+	err = AsConflictError(st.ErrOverlappingApprovedShift, 4, 3)
+	assert.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, ErrEmployeeDoubleBooked)
+
+	dbErr := errors.New("db error")
+	assert.Equal(t, dbErr, AsConflictError(dbErr, 4, 3))
+}
@@ -2,9 +2,54 @@ package shift
 
 import (
 	"context"
-	"time"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
 )
 
-func (s *Shift) CreateNewShiftSchedule(ctx context.Context, roleId int, startTime, endTime time.Time) (int, error) {
-	return s.storage.CreateNewShiftSchedule(ctx, roleId, startTime, endTime)
+// CreateNewShiftSchedule creates the shift, a headcount of 0 staffs it with a single employee
+func (s *Shift) CreateNewShiftSchedule(ctx context.Context, shift st.NewShift) (int, error) {
+	ids, err := s.CreateNewShiftSchedules(ctx, []st.NewShift{shift})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// CreateNewShiftSchedules creates all the shifts atomically, the returned ids follow the order of shifts
+func (s *Shift) CreateNewShiftSchedules(ctx context.Context, shifts []st.NewShift) (ids []int, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	if ids, err = s.storage.CreateNewShiftSchedules(tctx, shifts); err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if err = audit.Record(tctx, s.storage, "create", audit.EntityShift, id, nil, shifts[i]); err != nil {
+			return nil, err
+		}
+		if err = webhook.Publish(tctx, s.storage, webhook.ShiftCreated, id, newShiftPayload(id, shifts[i])); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func newShiftPayload(id int, shift st.NewShift) webhook.ShiftPayload {
+	headcount := shift.Headcount
+	if headcount == 0 {
+		headcount = 1
+	}
+	return webhook.ShiftPayload{
+		ID:         id,
+		RoleID:     shift.RoleID,
+		LocationID: shift.LocationID,
+		StartTime:  shift.StartTime,
+		EndTime:    shift.EndTime,
+		Headcount:  headcount,
+	}
 }
@@ -0,0 +1,214 @@
+package shift
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/lib/pq"
+)
+
+var ErrShiftNotFound = errors.New("shift not found")
+var ErrShiftHasAssignee = errors.New("shift has an approved assignee, force and a reason are required")
+var ErrHeadcountBelowApproved = errors.New("headcount must not be lower than the approved assignees of the shift")
+var ErrRoleChangeWithAssignee = errors.New("the role of a shift with approved assignees can't be changed")
+
+// ShiftEdit is the attribution of an admin edit, Force and Reason are only required
+// when the shift already has an approved assignee
+type ShiftEdit struct {
+	EditedBy int
+	Force    bool
+	Reason   string
+	// the locations the editor may access, nil for every location.
+	// shifts of other locations are reported as not found
+	LocationIDs []int
+}
+
+func (s *Shift) ListShifts(ctx context.Context, filter st.ListShiftFilter) ([]st.ShiftWithAssignees, int, error) {
+	return s.storage.ListShiftsByFilter(ctx, filter)
+}
+
+func (s *Shift) GetShift(ctx context.Context, id int) (*st.ShiftWithAssignees, error) {
+	shift, err := s.storage.GetShiftWithAssigneesByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShiftNotFound
+	}
+	return shift, err
+}
+
+// ListShiftEdits lists the edits of the shift made in the locations, nil locationIds means every location
+func (s *Shift) ListShiftEdits(ctx context.Context, id int, locationIds []int) ([]st.ShiftEditLog, error) {
+	return s.storage.ListShiftEditLogsByShiftID(ctx, id, locationIds)
+}
+
+// UpdateShift replaces the role, times and headcount of the shift and logs the edit, a headcount of 0 is left unchanged.
+// moving a shift with approved assignees must not double-book any of them, a *ConflictError is returned otherwise.
+// ErrHeadcountBelowApproved is returned if the headcount is lowered below the approved assignees.
+// the role of a shift with approved assignees can't be changed, ErrRoleChangeWithAssignee is returned otherwise.
+// changing the role of an unassigned shift rejects its pending requests, made for the former role
+func (s *Shift) UpdateShift(ctx context.Context, id int, update st.NewShift, edit ShiftEdit) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	shift, assignees, err := s.lockShiftForEdit(tctx, id, edit)
+	if err != nil {
+		return err
+	}
+
+	if update.Headcount == 0 {
+		update.Headcount = shift.Headcount
+	}
+	if update.Headcount < len(assignees) {
+		return ErrHeadcountBelowApproved
+	}
+	roleChanged := update.RoleID != shift.RoleID
+	if roleChanged && len(assignees) > 0 {
+		return ErrRoleChangeWithAssignee
+	}
+
+	if !update.StartTime.Equal(shift.StartTime) || !update.EndTime.Equal(shift.EndTime) {
+		for _, assignee := range assignees {
+			overlaps, err := s.storage.ListOverlappingApprovedShifts(tctx, int(assignee), update.StartTime, update.EndTime)
+			if err != nil {
+				return err
+			}
+			for _, o := range overlaps {
+				if o.ID != id {
+					return &ConflictError{Err: ErrEmployeeDoubleBooked, EmployeeID: int(assignee), ShiftID: id, ConflictingShiftID: o.ID}
+				}
+			}
+		}
+	}
+
+	if _, err = s.storage.UpdateShift(tctx, id, update); err != nil {
+		if errors.Is(err, st.ErrHeadcountBelowApproved) {
+			return ErrHeadcountBelowApproved
+		}
+		return err
+	}
+	update.LocationID = shift.LocationID
+	if err = audit.Record(tctx, s.storage, "update", audit.EntityShift, id, shift, update); err != nil {
+		return err
+	}
+	if roleChanged {
+		rejected, err := s.storage.RejectPendingShiftRequestsByShiftID(tctx, id, 0, edit.EditedBy)
+		if err != nil {
+			return err
+		}
+		for _, r := range rejected {
+			err = webhook.Publish(tctx, s.storage, webhook.ShiftRequestRejected, r.ID, webhook.ShiftRequestPayload{
+				ID:         r.ID,
+				EmployeeID: r.EmployeeID,
+				ShiftID:    r.ShiftID,
+				Status:     r.Status,
+				ReviewedBy: r.ReviewedBy,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = s.storage.CreateShiftEditLog(tctx, st.ShiftEditLog{
+		ShiftID:      id,
+		LocationID:   shift.LocationID,
+		Action:       st.ShiftEditUpdate,
+		OldRoleID:    shift.RoleID,
+		OldStartTime: shift.StartTime,
+		OldEndTime:   shift.EndTime,
+		NewRoleID:    &update.RoleID,
+		NewStartTime: &update.StartTime,
+		NewEndTime:   &update.EndTime,
+		OldHeadcount: shift.Headcount,
+		NewHeadcount: &update.Headcount,
+		AssigneeIDs:  assignees,
+		Reason:       editReason(edit),
+		EditedBy:     edit.EditedBy,
+	})
+	return err
+}
+
+// CancelShift deletes the shift along with its requests and logs the cancellation
+func (s *Shift) CancelShift(ctx context.Context, id int, edit ShiftEdit) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	shift, assignees, err := s.lockShiftForEdit(tctx, id, edit)
+	if err != nil {
+		return err
+	}
+
+	if err = s.storage.DeleteShiftById(tctx, id); err != nil {
+		return err
+	}
+	if err = audit.Record(tctx, s.storage, "cancel", audit.EntityShift, id, shift, nil); err != nil {
+		return err
+	}
+	if err = webhook.Publish(tctx, s.storage, webhook.ShiftDeleted, id, webhook.NewShiftPayload(*shift)); err != nil {
+		return err
+	}
+
+	_, err = s.storage.CreateShiftEditLog(tctx, st.ShiftEditLog{
+		ShiftID:      id,
+		LocationID:   shift.LocationID,
+		Action:       st.ShiftEditCancel,
+		OldRoleID:    shift.RoleID,
+		OldStartTime: shift.StartTime,
+		OldEndTime:   shift.EndTime,
+		OldHeadcount: shift.Headcount,
+		AssigneeIDs:  assignees,
+		Reason:       editReason(edit),
+		EditedBy:     edit.EditedBy,
+	})
+	return err
+}
+
+// lockShiftForEdit locks the shift and returns it along with its approved assignees,
+// ErrShiftHasAssignee is returned if the edit of an assigned shift is neither forced nor justified
+func (s *Shift) lockShiftForEdit(ctx context.Context, id int, edit ShiftEdit) (*st.Shift, pq.Int64Array, error) {
+	shift, err := s.storage.LockShiftByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !st.InLocations(edit.LocationIDs, shift.LocationID) {
+		return nil, nil, ErrShiftNotFound
+	}
+
+	approved, err := s.storage.ListApprovedShiftRequestsByShiftID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(approved) == 0 {
+		return shift, nil, nil
+	}
+	if !edit.Force || editReason(edit) == nil {
+		return nil, nil, ErrShiftHasAssignee
+	}
+	assignees := make(pq.Int64Array, 0, len(approved))
+	for _, req := range approved {
+		assignees = append(assignees, int64(req.EmployeeID))
+	}
+	return shift, assignees, nil
+}
+
+func editReason(edit ShiftEdit) *string {
+	reason := strings.TrimSpace(edit.Reason)
+	if reason == "" {
+		return nil
+	}
+	return &reason
+}
@@ -0,0 +1,234 @@
+package shift
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *mockStorage) GetShiftWithAssigneesByID(ctx context.Context, id int) (*st.ShiftWithAssignees, error) {
+	shift, ok := m.shifts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &st.ShiftWithAssignees{Shift: *shift}, nil
+}
+
+func (m *mockStorage) ListShiftsByFilter(ctx context.Context, filter st.ListShiftFilter) ([]st.ShiftWithAssignees, int, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (m *mockStorage) LockShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	shift, ok := m.shifts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *shift
+	return &copied, nil
+}
+
+func (m *mockStorage) ListApprovedShiftRequestsByShiftID(ctx context.Context, shiftId int) ([]st.ShiftRequest, error) {
+	return m.approved, nil
+}
+
+func (m *mockStorage) RejectPendingShiftRequestsByShiftID(ctx context.Context, shiftId, exceptId, reviewedBy int) ([]st.ShiftRequest, error) {
+	var rejected []st.ShiftRequest
+	for _, r := range m.pending {
+		if r.ShiftID == shiftId && r.ID != exceptId {
+			r.Status = "REJECTED"
+			r.ReviewedBy = &reviewedBy
+			rejected = append(rejected, r)
+		}
+	}
+	m.pending = nil
+	return rejected, nil
+}
+
+func (m *mockStorage) UpdateShift(ctx context.Context, id int, shift st.NewShift) (bool, error) {
+	m.shifts[id].RoleID = shift.RoleID
+	m.shifts[id].StartTime = shift.StartTime
+	m.shifts[id].EndTime = shift.EndTime
+	m.shifts[id].Headcount = shift.Headcount
+	return true, nil
+}
+
+func (m *mockStorage) DeleteShiftById(ctx context.Context, shiftId int) error {
+	delete(m.shifts, shiftId)
+	return nil
+}
+
+func (m *mockStorage) CreateShiftEditLog(ctx context.Context, log st.ShiftEditLog) (int, error) {
+	m.edits = append(m.edits, log)
+	return len(m.edits), nil
+}
+
+func (m *mockStorage) ListShiftEditLogsByShiftID(ctx context.Context, shiftId int, locationIds []int) ([]st.ShiftEditLog, error) {
+	return m.edits, nil
+}
+
+func TestUpdateShift(t *testing.T) {
+	start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+	newShift := func() map[int]*st.Shift {
+		return map[int]*st.Shift{3: {ID: 3, RoleID: 1, StartTime: start, EndTime: start.Add(8 * time.Hour), Headcount: 2}}
+	}
+	update := st.NewShift{RoleID: 2, StartTime: start.Add(time.Hour), EndTime: start.Add(9 * time.Hour)}
+
+	t.Run("unassigned shift", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift()}
+		err := NewShift(storage).UpdateShift(context.Background(), 3, update, ShiftEdit{EditedBy: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, storage.shifts[3].RoleID)
+		require.Len(t, storage.edits, 1)
+		assert.Equal(t, st.ShiftEditUpdate, storage.edits[0].Action)
+		assert.Equal(t, 1, storage.edits[0].OldRoleID)
+		assert.Equal(t, update.StartTime, *storage.edits[0].NewStartTime)
+		// the headcount is left unchanged
+		assert.Equal(t, 2, storage.shifts[3].Headcount)
+		assert.Equal(t, 2, *storage.edits[0].NewHeadcount)
+		assert.Empty(t, storage.edits[0].AssigneeIDs)
+		assert.Nil(t, storage.edits[0].Reason)
+
+		require.Len(t, storage.audits, 1)
+		assert.Equal(t, "update", storage.audits[0].Action)
+		assert.Equal(t, 3, storage.audits[0].EntityID)
+		assert.Contains(t, string(*storage.audits[0].Before), `"RoleID":1`)
+		assert.Contains(t, string(*storage.audits[0].After), `"RoleID":2`)
+	})
+
+	t.Run("unknown shift", func(t *testing.T) {
+		err := NewShift(&mockStorage{shifts: newShift()}).UpdateShift(context.Background(), 4, update, ShiftEdit{EditedBy: 1})
+		assert.ErrorIs(t, err, ErrShiftNotFound)
+	})
+
+	t.Run("shift of another location", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift()}
+		storage.shifts[3].LocationID = 2
+		err := NewShift(storage).UpdateShift(context.Background(), 3, update, ShiftEdit{EditedBy: 1, LocationIDs: []int{1}})
+		assert.ErrorIs(t, err, ErrShiftNotFound)
+		assert.Equal(t, 1, storage.shifts[3].RoleID)
+		assert.Empty(t, storage.edits)
+	})
+
+	t.Run("assigned shift requires force and reason", func(t *testing.T) {
+		for _, edit := range []ShiftEdit{
+			{EditedBy: 1},
+			{EditedBy: 1, Force: true},
+			{EditedBy: 1, Force: true, Reason: "  "},
+			{EditedBy: 1, Reason: "sick"},
+		} {
+			storage := &mockStorage{shifts: newShift(), approved: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3}}}
+			err := NewShift(storage).UpdateShift(context.Background(), 3, update, edit)
+			assert.ErrorIs(t, err, ErrShiftHasAssignee)
+			assert.Empty(t, storage.edits)
+		}
+
+		storage := &mockStorage{shifts: newShift(), approved: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3}}}
+		moved := update
+		moved.RoleID = 1
+		err := NewShift(storage).UpdateShift(context.Background(), 3, moved, ShiftEdit{EditedBy: 1, Force: true, Reason: "store opens later"})
+		require.NoError(t, err)
+		require.Len(t, storage.edits, 1)
+		assert.Equal(t, pq.Int64Array{4}, storage.edits[0].AssigneeIDs)
+		assert.Equal(t, "store opens later", *storage.edits[0].Reason)
+	})
+
+	t.Run("role of an assigned shift", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift(), approved: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3}}}
+		err := NewShift(storage).UpdateShift(context.Background(), 3, update, ShiftEdit{EditedBy: 1, Force: true, Reason: "need a cook"})
+		assert.ErrorIs(t, err, ErrRoleChangeWithAssignee)
+		assert.Equal(t, 1, storage.shifts[3].RoleID)
+		assert.Empty(t, storage.edits)
+	})
+
+	t.Run("role change rejects the pending requests", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift(), pending: []st.ShiftRequest{
+			{ID: 5, EmployeeID: 4, ShiftID: 3, Status: "PENDING"},
+			{ID: 6, EmployeeID: 7, ShiftID: 3, Status: "PENDING"},
+		}}
+		err := NewShift(storage).UpdateShift(context.Background(), 3, update, ShiftEdit{EditedBy: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, storage.shifts[3].RoleID)
+		require.Len(t, storage.events, 2)
+		for _, e := range storage.events {
+			assert.Equal(t, webhook.ShiftRequestRejected, e.EventType)
+			assert.Contains(t, string(e.Payload), `"status":"REJECTED"`)
+		}
+		assert.Equal(t, 5, storage.events[0].EntityID)
+		assert.Equal(t, 6, storage.events[1].EntityID)
+
+		// the pending requests stay when the role is kept
+		storage = &mockStorage{shifts: newShift(), pending: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3, Status: "PENDING"}}}
+		kept := update
+		kept.RoleID = 1
+		require.NoError(t, NewShift(storage).UpdateShift(context.Background(), 3, kept, ShiftEdit{EditedBy: 1}))
+		assert.Len(t, storage.pending, 1)
+		assert.Empty(t, storage.events)
+	})
+
+	t.Run("headcount below the approved assignees", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift(), approved: []st.ShiftRequest{
+			{ID: 5, EmployeeID: 4, ShiftID: 3},
+			{ID: 6, EmployeeID: 7, ShiftID: 3},
+		}}
+		lowered := update
+		lowered.Headcount = 1
+		err := NewShift(storage).UpdateShift(context.Background(), 3, lowered, ShiftEdit{EditedBy: 1, Force: true, Reason: "slow night"})
+		assert.ErrorIs(t, err, ErrHeadcountBelowApproved)
+		assert.Equal(t, 2, storage.shifts[3].Headcount)
+		assert.Empty(t, storage.edits)
+	})
+
+	t.Run("moving an assigned shift must not double-book the assignee", func(t *testing.T) {
+		storage := &mockStorage{shifts: newShift(), approved: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3}},
+			overlaps: []st.Shift{{ID: 3}, {ID: 8}}}
+		moved := update
+		moved.RoleID = 1
+		err := NewShift(storage).UpdateShift(context.Background(), 3, moved, ShiftEdit{EditedBy: 1, Force: true, Reason: "moved"})
+
+		var conflict *ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.ErrorIs(t, err, ErrEmployeeDoubleBooked)
+		assert.Equal(t, 8, conflict.ConflictingShiftID)
+		assert.Equal(t, 1, storage.shifts[3].RoleID)
+		assert.Empty(t, storage.edits)
+	})
+}
+
+func TestCancelShift(t *testing.T) {
+	start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+	storage := &mockStorage{
+		shifts:   map[int]*st.Shift{3: {ID: 3, RoleID: 1, StartTime: start, EndTime: start.Add(8 * time.Hour)}},
+		approved: []st.ShiftRequest{{ID: 5, EmployeeID: 4, ShiftID: 3}, {ID: 6, EmployeeID: 7, ShiftID: 3}},
+	}
+	svc := NewShift(storage)
+
+	err := svc.CancelShift(context.Background(), 3, ShiftEdit{EditedBy: 1})
+	assert.ErrorIs(t, err, ErrShiftHasAssignee)
+	assert.Contains(t, storage.shifts, 3)
+
+	err = svc.CancelShift(context.Background(), 3, ShiftEdit{EditedBy: 1, Force: true, Reason: "closed for holiday"})
+	require.NoError(t, err)
+	assert.NotContains(t, storage.shifts, 3)
+	require.Len(t, storage.edits, 1)
+	assert.Equal(t, st.ShiftEditCancel, storage.edits[0].Action)
+	assert.Nil(t, storage.edits[0].NewRoleID)
+	assert.Equal(t, pq.Int64Array{4, 7}, storage.edits[0].AssigneeIDs)
+	require.Len(t, storage.audits, 1)
+	assert.Equal(t, "cancel", storage.audits[0].Action)
+	assert.Nil(t, storage.audits[0].After)
+	require.Len(t, storage.events, 1)
+	assert.Equal(t, webhook.ShiftDeleted, storage.events[0].EventType)
+	assert.Equal(t, 3, storage.events[0].EntityID)
+
+	err = svc.CancelShift(context.Background(), 3, ShiftEdit{EditedBy: 1})
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+}
@@ -0,0 +1,117 @@
+package shift
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+const (
+	FreqDaily  = "DAILY"
+	FreqWeekly = "WEEKLY"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is the supported subset of an RFC 5545 RRULE: FREQ (DAILY or WEEKLY), INTERVAL and BYDAY,
+// e.g. "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR". the bounds come from the template starts_on/ends_on instead of UNTIL/COUNT
+type Recurrence struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+}
+
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(val)
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRecurrence, val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRecurrence)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := rruleWeekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY %q", ErrInvalidRecurrence, day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported %s", ErrInvalidRecurrence, key)
+		}
+	}
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	return r, nil
+}
+
+// Occurs reports whether the recurrence anchored on the first day occurs on day.
+// both are calendar dates at midnight UTC, weeks start on monday
+func (r *Recurrence) Occurs(first, day time.Time) bool {
+	if day.Before(first) {
+		return false
+	}
+	switch r.Freq {
+	case FreqDaily:
+		if daysBetween(first, day)%r.Interval != 0 {
+			return false
+		}
+		return len(r.ByDay) == 0 || r.onWeekday(day.Weekday())
+	case FreqWeekly:
+		weeks := daysBetween(startOfWeek(first), startOfWeek(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == first.Weekday()
+		}
+		return r.onWeekday(day.Weekday())
+	}
+	return false
+}
+
+func (r *Recurrence) onWeekday(weekday time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func startOfWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
@@ -0,0 +1,67 @@
+package shift
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected *Recurrence
+		wantErr  bool
+	}{
+		{rule: "FREQ=DAILY", expected: &Recurrence{Freq: FreqDaily, Interval: 1}},
+		{rule: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", expected: &Recurrence{Freq: FreqWeekly, Interval: 2,
+			ByDay: []time.Weekday{time.Monday, time.Friday}}},
+		{rule: "freq=weekly;byday=sa", expected: &Recurrence{Freq: FreqWeekly, Interval: 1, ByDay: []time.Weekday{time.Saturday}}},
+		{rule: "", wantErr: true},
+		{rule: "FREQ=MONTHLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{rule: "FREQ", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.rule)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRecurrence)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, r)
+		})
+	}
+}
+
+func TestRecurrenceOccurs(t *testing.T) {
+	// 2025-06-02 is a monday
+	first := date(2025, 6, 2)
+
+	weekdays, _ := ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR")
+	assert.True(t, weekdays.Occurs(first, date(2025, 6, 6)))
+	assert.False(t, weekdays.Occurs(first, date(2025, 6, 7)))
+	assert.False(t, weekdays.Occurs(first, date(2025, 6, 1)), "before the first day")
+
+	biweekly, _ := ParseRecurrence("FREQ=WEEKLY;INTERVAL=2;BYDAY=SU")
+	assert.True(t, biweekly.Occurs(first, date(2025, 6, 8)))
+	assert.False(t, biweekly.Occurs(first, date(2025, 6, 15)))
+	assert.True(t, biweekly.Occurs(first, date(2025, 6, 22)))
+
+	sameWeekday, _ := ParseRecurrence("FREQ=WEEKLY")
+	assert.True(t, sameWeekday.Occurs(first, date(2025, 6, 9)))
+	assert.False(t, sameWeekday.Occurs(first, date(2025, 6, 10)))
+
+	everyThirdDay, _ := ParseRecurrence("FREQ=DAILY;INTERVAL=3")
+	assert.True(t, everyThirdDay.Occurs(first, date(2025, 6, 5)))
+	assert.False(t, everyThirdDay.Occurs(first, date(2025, 6, 6)))
+	// across the end of march DST change in europe, dates are UTC so days stay 24h
+	assert.True(t, everyThirdDay.Occurs(date(2025, 3, 29), date(2025, 4, 1)))
+}
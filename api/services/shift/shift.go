@@ -3,22 +3,86 @@ package shift
 import (
 	"context"
 	"time"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
 )
 
+const defaultTemplateHorizon = 28 * 24 * time.Hour
+
 type storage interface {
-	CreateNewShiftSchedule(ctx context.Context, roleId int, startTime, endTime time.Time) (int, error)
+	CreateNewShiftSchedules(ctx context.Context, shifts []st.NewShift) ([]int, error)
+	GetShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	CountApprovedRequestsByShiftID(ctx context.Context, shiftId int) (int, error)
+	ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error)
+
+	GetShiftWithAssigneesByID(ctx context.Context, id int) (*st.ShiftWithAssignees, error)
+	ListShiftsByFilter(ctx context.Context, filter st.ListShiftFilter) ([]st.ShiftWithAssignees, int, error)
+	LockShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	ListApprovedShiftRequestsByShiftID(ctx context.Context, shiftId int) ([]st.ShiftRequest, error)
+	RejectPendingShiftRequestsByShiftID(ctx context.Context, shiftId, exceptId, reviewedBy int) ([]st.ShiftRequest, error)
+	UpdateShift(ctx context.Context, id int, shift st.NewShift) (bool, error)
+	DeleteShiftById(ctx context.Context, shiftId int) error
+	CreateShiftEditLog(ctx context.Context, log st.ShiftEditLog) (int, error)
+	ListShiftEditLogsByShiftID(ctx context.Context, shiftId int, locationIds []int) ([]st.ShiftEditLog, error)
+
+	CreateShiftTemplate(ctx context.Context, t st.ShiftTemplate) (int, error)
+	ListShiftTemplates(ctx context.Context, locationIds []int) ([]st.ShiftTemplate, error)
+	SelectShiftTemplateByID(ctx context.Context, id int) (*st.ShiftTemplate, error)
+	LockShiftTemplateByID(ctx context.Context, id int) (*st.ShiftTemplate, error)
+	UpdateShiftTemplateGeneratedThrough(ctx context.Context, id int, through time.Time) error
+	DeleteShiftTemplate(ctx context.Context, id int) (bool, error)
+	CreateTemplateShifts(ctx context.Context, templateId int, shifts []st.NewShift) ([]st.Shift, error)
+
+	audit.Recorder
+	webhook.Publisher
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
 }
 
 type ShiftInterface interface {
-	CreateNewShiftSchedule(ctx context.Context, roleId int, startTime time.Time, endTime time.Time) (int, error)
+	CreateNewShiftSchedule(ctx context.Context, shift st.NewShift) (int, error)
+	CreateNewShiftSchedules(ctx context.Context, shifts []st.NewShift) ([]int, error)
+	ListShifts(ctx context.Context, filter st.ListShiftFilter) ([]st.ShiftWithAssignees, int, error)
+	GetShift(ctx context.Context, id int) (*st.ShiftWithAssignees, error)
+	ListShiftEdits(ctx context.Context, id int, locationIds []int) ([]st.ShiftEditLog, error)
+	UpdateShift(ctx context.Context, id int, update st.NewShift, edit ShiftEdit) error
+	CancelShift(ctx context.Context, id int, edit ShiftEdit) error
+
+	CreateShiftTemplate(ctx context.Context, tmpl st.ShiftTemplate) (int, error)
+	ListShiftTemplates(ctx context.Context, locationIds []int) ([]st.ShiftTemplate, error)
+	GetShiftTemplate(ctx context.Context, id int) (*st.ShiftTemplate, error)
+	DeleteShiftTemplate(ctx context.Context, id int) error
+	GenerateShiftsFromTemplate(ctx context.Context, id int) (int, error)
 }
 
 type Shift struct {
 	storage storage
+	now     func() time.Time
+
+	// how far ahead templates are materialized into shifts
+	templateHorizon time.Duration
+}
+
+type Option func(*Shift)
+
+func NewShift(storage storage, opts ...Option) *Shift {
+	s := &Shift{
+		storage:         storage,
+		now:             time.Now,
+		templateHorizon: defaultTemplateHorizon,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func NewShift(storage storage) *Shift {
-	return &Shift{
-		storage: storage,
+func WithTemplateHorizon(horizon time.Duration) Option {
+	return func(s *Shift) {
+		if horizon > 0 {
+			s.templateHorizon = horizon
+		}
 	}
 }
@@ -0,0 +1,244 @@
+package shift
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
+	"payd/util"
+)
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+var ErrInvalidTimeOfDay = util.ErrInvalidTimeOfDay
+var ErrTemplateNotFound = errors.New("shift template not found")
+
+// CreateShiftTemplate validates and stores a recurring shift template, no shift is generated yet
+func (s *Shift) CreateShiftTemplate(ctx context.Context, tmpl st.ShiftTemplate) (id int, err error) {
+	if _, err := ParseRecurrence(tmpl.Recurrence); err != nil {
+		return 0, err
+	}
+	if _, err := time.LoadLocation(tmpl.Timezone); err != nil || tmpl.Timezone == "" {
+		return 0, ErrInvalidTimezone
+	}
+	if _, err := util.ParseTimeOfDay(tmpl.StartTimeOfDay); err != nil {
+		return 0, err
+	}
+	if _, err := util.ParseTimeOfDay(tmpl.EndTimeOfDay); err != nil {
+		return 0, err
+	}
+
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	if id, err = s.storage.CreateShiftTemplate(tctx, tmpl); err != nil {
+		return 0, err
+	}
+	tmpl.ID = id
+	if err = audit.Record(tctx, s.storage, "create", audit.EntityShiftTemplate, id, nil, tmpl); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ListShiftTemplates lists the templates of the locations, nil locationIds means every location
+func (s *Shift) ListShiftTemplates(ctx context.Context, locationIds []int) ([]st.ShiftTemplate, error) {
+	return s.storage.ListShiftTemplates(ctx, locationIds)
+}
+
+func (s *Shift) GetShiftTemplate(ctx context.Context, id int) (*st.ShiftTemplate, error) {
+	tmpl, err := s.storage.SelectShiftTemplateByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	return tmpl, err
+}
+
+// DeleteShiftTemplate stops the generation of the template, the shifts already generated are kept
+func (s *Shift) DeleteShiftTemplate(ctx context.Context, id int) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	tmpl, err := s.storage.LockShiftTemplateByID(tctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTemplateNotFound
+	}
+	if err != nil {
+		return err
+	}
+	deleted, err := s.storage.DeleteShiftTemplate(tctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTemplateNotFound
+	}
+	return audit.Record(tctx, s.storage, "delete", audit.EntityShiftTemplate, id, tmpl, nil)
+}
+
+// GenerateShiftsFromTemplates materializes every template up to the rolling horizon,
+// returns the number of created shifts and the first error met, the remaining templates are still generated
+func (s *Shift) GenerateShiftsFromTemplates(ctx context.Context) (int, error) {
+	templates, err := s.storage.ListShiftTemplates(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	var firstErr error
+	for _, tmpl := range templates {
+		created, err := s.GenerateShiftsFromTemplate(ctx, tmpl.ID)
+		if err != nil {
+			util.Log().WithContext(ctx).WithError(err).WithField("template_id", tmpl.ID).Error("generate template shifts")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		total += created
+	}
+	return total, firstErr
+}
+
+// GenerateShiftsFromTemplate materializes the template occurrences between its watermark and the rolling horizon.
+// re-running it is a no-op until the horizon moves, and shifts deleted by an admin are not re-created
+func (s *Shift) GenerateShiftsFromTemplate(ctx context.Context, id int) (created int, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	tmpl, err := s.storage.LockShiftTemplateByID(tctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrTemplateNotFound
+		}
+		return 0, err
+	}
+	shifts, through, err := planTemplateShifts(tmpl, s.now(), s.templateHorizon)
+	if err != nil || through.IsZero() {
+		return 0, err
+	}
+	inserted, err := s.storage.CreateTemplateShifts(tctx, tmpl.ID, shifts)
+	if err != nil {
+		return 0, err
+	}
+	if err = s.storage.UpdateShiftTemplateGeneratedThrough(tctx, tmpl.ID, through); err != nil {
+		return 0, err
+	}
+	if len(inserted) == 0 {
+		return 0, nil
+	}
+	// the generated shifts are recorded as a whole, but published one by one
+	err = audit.Record(tctx, s.storage, "generate", audit.EntityShiftTemplate, tmpl.ID,
+		map[string]interface{}{"GeneratedThrough": tmpl.GeneratedThrough},
+		map[string]interface{}{"GeneratedThrough": through, "Created": len(inserted)})
+	if err != nil {
+		return 0, err
+	}
+	for _, sh := range inserted {
+		if err = webhook.Publish(tctx, s.storage, webhook.ShiftCreated, sh.ID, webhook.NewShiftPayload(sh)); err != nil {
+			return 0, err
+		}
+	}
+	return len(inserted), nil
+}
+
+// StartTemplateGenerator materializes the templates now and then on every tick until ctx is done
+func (s *Shift) StartTemplateGenerator(ctx context.Context, tick time.Duration) {
+	generate := func() {
+		created, err := s.GenerateShiftsFromTemplates(ctx)
+		if err != nil {
+			util.Log().WithError(err).Error("periodic shift template generation failed")
+		}
+		if created > 0 {
+			util.Log().WithField("created", created).Info("shifts generated from templates")
+		}
+	}
+	generate()
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				generate()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// planTemplateShifts returns the shifts due from the day after the watermark (but not in the past)
+// through the horizon, and the new watermark. a zero watermark means there is nothing to generate.
+// occurrences are built from the wall clock in the template timezone, so a shift spanning a DST change
+// keeps its local start/end and its real duration changes accordingly
+func planTemplateShifts(tmpl *st.ShiftTemplate, now time.Time, horizon time.Duration) ([]st.NewShift, time.Time, error) {
+	loc, err := time.LoadLocation(tmpl.Timezone)
+	if err != nil {
+		return nil, time.Time{}, ErrInvalidTimezone
+	}
+	rec, err := ParseRecurrence(tmpl.Recurrence)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	startTod, err := util.ParseTimeOfDay(tmpl.StartTimeOfDay)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	endTod, err := util.ParseTimeOfDay(tmpl.EndTimeOfDay)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	first := util.CivilDate(tmpl.StartsOn)
+	from := first
+	if tmpl.GeneratedThrough != nil {
+		if next := util.CivilDate(*tmpl.GeneratedThrough).AddDate(0, 0, 1); next.After(from) {
+			from = next
+		}
+	}
+	if today := util.CivilDate(now.In(loc)); today.After(from) {
+		from = today
+	}
+	through := util.CivilDate(now.Add(horizon).In(loc))
+	if tmpl.EndsOn != nil && util.CivilDate(*tmpl.EndsOn).Before(through) {
+		through = util.CivilDate(*tmpl.EndsOn)
+	}
+	if through.Before(from) {
+		return nil, time.Time{}, nil
+	}
+
+	var shifts []st.NewShift
+	for day := from; !day.After(through); day = day.AddDate(0, 0, 1) {
+		if !rec.Occurs(first, day) {
+			continue
+		}
+		start := util.OnDay(startTod, day, loc)
+		endDay := day
+		if endTod <= startTod {
+			endDay = day.AddDate(0, 0, 1)
+		}
+		end := util.OnDay(endTod, endDay, loc)
+		shifts = append(shifts, st.NewShift{
+			RoleID:     tmpl.RoleID,
+			LocationID: tmpl.LocationID,
+			StartTime:  start.UTC(),
+			EndTime:    end.UTC(),
+			Headcount:  tmpl.Headcount,
+		})
+	}
+	return shifts, through, nil
+}
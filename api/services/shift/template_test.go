@@ -0,0 +1,196 @@
+package shift
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *mockStorage) CreateShiftTemplate(ctx context.Context, t st.ShiftTemplate) (int, error) {
+	m.template = &t
+	return 1, nil
+}
+
+func (m *mockStorage) ListShiftTemplates(ctx context.Context, locationIds []int) ([]st.ShiftTemplate, error) {
+	return []st.ShiftTemplate{*m.template}, nil
+}
+
+func (m *mockStorage) SelectShiftTemplateByID(ctx context.Context, id int) (*st.ShiftTemplate, error) {
+	if m.template == nil || m.template.ID != id {
+		return nil, sql.ErrNoRows
+	}
+	copied := *m.template
+	return &copied, nil
+}
+
+func (m *mockStorage) LockShiftTemplateByID(ctx context.Context, id int) (*st.ShiftTemplate, error) {
+	copied := *m.template
+	return &copied, nil
+}
+
+func (m *mockStorage) UpdateShiftTemplateGeneratedThrough(ctx context.Context, id int, through time.Time) error {
+	m.template.GeneratedThrough = &through
+	return nil
+}
+
+func (m *mockStorage) DeleteShiftTemplate(ctx context.Context, id int) (bool, error) {
+	return m.template != nil && m.template.ID == id, nil
+}
+
+func (m *mockStorage) CreateTemplateShifts(ctx context.Context, templateId int, shifts []st.NewShift) ([]st.Shift, error) {
+	inserted := make([]st.Shift, 0, len(shifts))
+	for _, sh := range shifts {
+		m.created = append(m.created, sh)
+		inserted = append(inserted, st.Shift{ID: len(m.created), RoleID: sh.RoleID, LocationID: sh.LocationID,
+			StartTime: sh.StartTime, EndTime: sh.EndTime, Headcount: 1, TemplateID: &templateId})
+	}
+	return inserted, nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func TestPlanTemplateShifts(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	t.Run("weekdays in Asia/Jakarta", func(t *testing.T) {
+		tmpl := &st.ShiftTemplate{RoleID: 2, StartTimeOfDay: "08:00:00", EndTimeOfDay: "16:00:00",
+			Timezone: "Asia/Jakarta", Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", StartsOn: date(2025, 6, 2)}
+		// monday 2025-06-02 06:00 in jakarta
+		now := time.Date(2025, 6, 2, 6, 0, 0, 0, jakarta)
+
+		shifts, through, err := planTemplateShifts(tmpl, now, 7*24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, date(2025, 6, 9), through)
+		require.Len(t, shifts, 6) // mon-fri and the following monday
+		assert.Equal(t, time.Date(2025, 6, 2, 1, 0, 0, 0, time.UTC), shifts[0].StartTime)
+		assert.Equal(t, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), shifts[0].EndTime)
+		assert.Equal(t, time.Date(2025, 6, 9, 1, 0, 0, 0, time.UTC), shifts[5].StartTime)
+		for _, s := range shifts {
+			assert.Equal(t, 2, s.RoleID)
+			assert.Equal(t, time.UTC, s.StartTime.Location())
+		}
+	})
+
+	t.Run("overnight shifts across DST changes", func(t *testing.T) {
+		tmpl := &st.ShiftTemplate{RoleID: 1, StartTimeOfDay: "22:00", EndTimeOfDay: "06:00",
+			Timezone: "America/New_York", Recurrence: "FREQ=DAILY", StartsOn: date(2025, 3, 1)}
+
+		// clocks spring forward on 2025-03-09 02:00
+		shifts, _, err := planTemplateShifts(tmpl, time.Date(2025, 3, 7, 12, 0, 0, 0, newYork), 3*24*time.Hour)
+		require.NoError(t, err)
+		require.Len(t, shifts, 4)
+		assert.Equal(t, 8*time.Hour, shifts[0].EndTime.Sub(shifts[0].StartTime))
+		assert.Equal(t, 7*time.Hour, shifts[1].EndTime.Sub(shifts[1].StartTime), "2025-03-08 night is one hour shorter")
+		assert.Equal(t, 22, shifts[2].StartTime.In(newYork).Hour())
+		assert.Equal(t, 6, shifts[2].EndTime.In(newYork).Hour())
+
+		// clocks fall back on 2025-11-02 02:00
+		shifts, _, err = planTemplateShifts(tmpl, time.Date(2025, 11, 1, 12, 0, 0, 0, newYork), 24*time.Hour)
+		require.NoError(t, err)
+		require.Len(t, shifts, 2)
+		assert.Equal(t, 9*time.Hour, shifts[0].EndTime.Sub(shifts[0].StartTime), "2025-11-01 night is one hour longer")
+		assert.Equal(t, 8*time.Hour, shifts[1].EndTime.Sub(shifts[1].StartTime))
+	})
+
+	t.Run("watermark, past dates and ends_on bound the range", func(t *testing.T) {
+		generated := date(2025, 6, 4)
+		endsOn := date(2025, 6, 6)
+		tmpl := &st.ShiftTemplate{RoleID: 1, StartTimeOfDay: "08:00", EndTimeOfDay: "12:00", Timezone: "UTC",
+			Recurrence: "FREQ=DAILY", StartsOn: date(2025, 5, 1), EndsOn: &endsOn, GeneratedThrough: &generated}
+
+		shifts, through, err := planTemplateShifts(tmpl, time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC), 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, endsOn, through)
+		require.Len(t, shifts, 2)
+		assert.Equal(t, date(2025, 6, 5).Add(8*time.Hour), shifts[0].StartTime)
+
+		// nothing is generated in the past even without a watermark
+		tmpl.GeneratedThrough = nil
+		shifts, _, err = planTemplateShifts(tmpl, time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.Len(t, shifts, 2)
+
+		// template over
+		shifts, through, err = planTemplateShifts(tmpl, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, shifts)
+		assert.True(t, through.IsZero())
+	})
+}
+
+func TestGenerateShiftsFromTemplate(t *testing.T) {
+	storage := &mockStorage{}
+	svc := NewShift(storage, WithTemplateHorizon(6*24*time.Hour))
+	svc.now = func() time.Time { return time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC) }
+
+	_, err := svc.CreateShiftTemplate(context.Background(), st.ShiftTemplate{RoleID: 2, LocationID: 3, StartTimeOfDay: "08:00",
+		EndTimeOfDay: "16:00", Timezone: "Asia/Jakarta", Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", StartsOn: date(2025, 6, 2)})
+	require.NoError(t, err)
+	storage.template.ID = 1
+
+	created, err := svc.GenerateShiftsFromTemplate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, created)
+	assert.Equal(t, 3, storage.created[0].LocationID)
+	require.Len(t, storage.events, 5)
+	assert.Equal(t, webhook.ShiftCreated, storage.events[0].EventType)
+	assert.Contains(t, string(storage.events[0].Payload), `"templateId":1`)
+
+	// re-running with the same clock is a no-op
+	created, err = svc.GenerateShiftsFromTemplate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	// a day later only the new day is generated
+	svc.now = func() time.Time { return time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC) }
+	created, err = svc.GenerateShiftsFromTemplates(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.Len(t, storage.created, 6)
+}
+
+func TestCreateShiftTemplateValidation(t *testing.T) {
+	valid := st.ShiftTemplate{RoleID: 2, StartTimeOfDay: "08:00", EndTimeOfDay: "16:00", Timezone: "Asia/Jakarta",
+		Recurrence: "FREQ=DAILY", StartsOn: date(2025, 6, 2)}
+
+	invalidTz := valid
+	invalidTz.Timezone = "Mars/Olympus"
+	_, err := NewShift(&mockStorage{}).CreateShiftTemplate(context.Background(), invalidTz)
+	assert.ErrorIs(t, err, ErrInvalidTimezone)
+
+	invalidRule := valid
+	invalidRule.Recurrence = "FREQ=YEARLY"
+	_, err = NewShift(&mockStorage{}).CreateShiftTemplate(context.Background(), invalidRule)
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+
+	invalidTime := valid
+	invalidTime.EndTimeOfDay = "25:00"
+	_, err = NewShift(&mockStorage{}).CreateShiftTemplate(context.Background(), invalidTime)
+	assert.ErrorIs(t, err, ErrInvalidTimeOfDay)
+}
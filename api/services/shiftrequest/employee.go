@@ -0,0 +1,93 @@
+package shiftrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/webhook"
+	st "payd/storage"
+)
+
+// GetAvailableShifts lists the shifts of the given role and locations that have no approved request yet,
+// nil locationIds means every location
+func (s *ShiftRequest) GetAvailableShifts(ctx context.Context, roleId int, locationIds []int, start, end time.Time) ([]st.Shift, error) {
+	return s.storage.GetAvailableShiftsByTimeRangeAndRole(ctx, start, end, roleId, locationIds)
+}
+
+// RequestShift submits a PENDING request of the employee for the shift.
+// roleId is the employee's primary role, an employee can only request shifts of their own role
+// at the locations they may access, the shifts of other locations are reported as not found.
+// returns a *shift.ConflictError if the shift is already taken or overlaps another approved shift of the employee,
+// a *leave.OnLeaveError or an *availability.UnavailableError if the employee is on leave or unavailable.
+// the warnings report a shift outside the employee's weekly availability, it is requested anyway
+func (s *ShiftRequest) RequestShift(ctx context.Context, employeeId, roleId int, locationIds []int, shiftId int) (id int, warnings []string, err error) {
+	shift, err := s.storage.GetShiftByID(ctx, shiftId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrShiftNotFound
+		}
+		return 0, nil, err
+	}
+	if !st.InLocations(locationIds, shift.LocationID) {
+		return 0, nil, ErrShiftNotFound
+	}
+	if shift.RoleID != roleId {
+		return 0, nil, ErrRoleMismatch
+	}
+	if !shift.StartTime.After(s.now()) {
+		return 0, nil, ErrShiftAlreadyStarted
+	}
+
+	requests, err := s.storage.ListShiftRequestsByFilterAndTimeRange(ctx, st.ListShiftRequestFilter{ShiftID: shiftId, EmployeeID: employeeId},
+		shift.StartTime, shift.StartTime)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, req := range requests {
+		if req.Status == StatusPending || req.Status == StatusApproved {
+			return 0, nil, ErrAlreadyRequested
+		}
+	}
+	// requesting a shift that can't be approved anymore is refused early
+	if err := s.conflicts.CheckAssignmentConflict(ctx, employeeId, shiftId); err != nil {
+		return 0, nil, err
+	}
+	warnings, err = s.checkAvailability(ctx, employeeId, shift)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	id, err = s.storage.CreateShiftRequest(tctx, employeeId, shiftId)
+	if errors.Is(err, st.ErrDuplicateShiftRequest) {
+		return 0, nil, ErrAlreadyRequested
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	req := st.ShiftRequest{ID: id, EmployeeID: employeeId, ShiftID: shiftId, Status: StatusPending}
+	if err = audit.Record(tctx, s.storage, "create", audit.EntityShiftRequest, id, nil, req); err != nil {
+		return 0, nil, err
+	}
+	if err = publish(tctx, s.storage, webhook.ShiftRequestSubmitted, req); err != nil {
+		return 0, nil, err
+	}
+	return id, warnings, nil
+}
+
+// ListEmployeeShiftRequests lists the employee's own requests for shifts starting within the time range,
+// status is optional
+func (s *ShiftRequest) ListEmployeeShiftRequests(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	return s.storage.ListShiftRequestsByFilterAndTimeRange(ctx, st.ListShiftRequestFilter{
+		EmployeeID: employeeId,
+		Status:     status,
+	}, start, end)
+}
@@ -0,0 +1,238 @@
+package shiftrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/shift"
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorage struct {
+	shift           *st.Shift
+	shiftErr        error
+	requests        []st.ShiftRequestWithShiftDetails
+	createErr       error
+	createdEmployee int
+	createdShift    int
+
+	request       *st.ShiftRequest
+	lockErr       error
+	reviewErr     error
+	reviews       []review
+	approvedCount int
+	rejectedShift int
+	committed     bool
+	rolledBack    bool
+	audits        []st.AuditLog
+	events        []st.OutboxEvent
+}
+
+func (m *mockStorage) GetShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	return m.shift, m.shiftErr
+}
+
+func (m *mockStorage) LockShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	return m.shift, m.shiftErr
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error {
+	m.events = append(m.events, e)
+	return nil
+}
+
+type mockConflictChecker struct {
+	err error
+}
+
+func (m *mockConflictChecker) CheckAssignmentConflict(ctx context.Context, employeeId, shiftId int) error {
+	return m.err
+}
+
+type mockLeaveChecker struct {
+	err error
+}
+
+func (m *mockLeaveChecker) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	return m.err
+}
+
+type mockAvailabilityChecker struct {
+	outside bool
+	err     error
+}
+
+func (m *mockAvailabilityChecker) CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error) {
+	return m.outside, m.err
+}
+
+func (m *mockStorage) GetAvailableShiftsByTimeRangeAndRole(ctx context.Context, start, end time.Time, roleId int, locationIds []int) ([]st.Shift, error) {
+	return []st.Shift{*m.shift}, nil
+}
+
+func (m *mockStorage) CreateShiftRequest(ctx context.Context, employeeId, shiftId int) (int, error) {
+	m.createdEmployee, m.createdShift = employeeId, shiftId
+	if m.createErr != nil {
+		return 0, m.createErr
+	}
+	return 11, nil
+}
+
+func (m *mockStorage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	return m.requests, nil
+}
+
+func TestRequestShift(t *testing.T) {
+	now := time.Date(2025, 5, 14, 9, 0, 0, 0, time.UTC)
+	upcoming := &st.Shift{ID: 3, RoleID: 2, LocationID: 1, StartTime: now.Add(24 * time.Hour), EndTime: now.Add(32 * time.Hour)}
+
+	tests := []struct {
+		name        string
+		storage     *mockStorage
+		conflictErr error
+		available   *mockAvailabilityChecker
+		leaveErr    error
+		roleId      int
+		locationIds []int
+		expectedId  int
+		expectedErr error
+		// of a shift outside the weekly availability
+		expectedWarnings []string
+	}{
+		{
+			name:       "success",
+			storage:    &mockStorage{shift: upcoming},
+			roleId:     2,
+			expectedId: 11,
+		},
+		{
+			name:        "shift not found",
+			storage:     &mockStorage{shiftErr: sql.ErrNoRows},
+			roleId:      2,
+			expectedErr: ErrShiftNotFound,
+		},
+		{
+			name:        "shift of another location",
+			storage:     &mockStorage{shift: upcoming},
+			roleId:      2,
+			locationIds: []int{2},
+			expectedErr: ErrShiftNotFound,
+		},
+		{
+			name:        "role mismatch",
+			storage:     &mockStorage{shift: upcoming},
+			roleId:      1,
+			expectedErr: ErrRoleMismatch,
+		},
+		{
+			name: "shift already started",
+			storage: &mockStorage{shift: &st.Shift{ID: 3, RoleID: 2,
+				StartTime: now.Add(-time.Hour), EndTime: now.Add(7 * time.Hour)}},
+			roleId:      2,
+			expectedErr: ErrShiftAlreadyStarted,
+		},
+		{
+			name:        "shift already approved for someone else",
+			storage:     &mockStorage{shift: upcoming},
+			conflictErr: &shift.ConflictError{Err: shift.ErrShiftFullyStaffed, EmployeeID: 4, ShiftID: 3},
+			roleId:      2,
+			expectedErr: shift.ErrShiftFullyStaffed,
+		},
+		{
+			name:        "employee already works an overlapping shift",
+			storage:     &mockStorage{shift: upcoming},
+			conflictErr: &shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: 4, ShiftID: 3, ConflictingShiftID: 8},
+			roleId:      2,
+			expectedErr: shift.ErrEmployeeDoubleBooked,
+		},
+		{
+			name:        "employee declared to be unavailable",
+			storage:     &mockStorage{shift: upcoming},
+			available:   &mockAvailabilityChecker{err: &availability.UnavailableError{Unavailability: st.Unavailability{ID: 2, Reason: "dentist"}}},
+			roleId:      2,
+			expectedErr: availability.ErrEmployeeUnavailable,
+		},
+		{
+			name:        "employee on approved leave",
+			storage:     &mockStorage{shift: upcoming},
+			leaveErr:    &leave.OnLeaveError{LeaveRequestID: 6},
+			roleId:      2,
+			expectedErr: leave.ErrEmployeeOnLeave,
+		},
+		{
+			name:             "shift outside the weekly availability",
+			storage:          &mockStorage{shift: upcoming},
+			available:        &mockAvailabilityChecker{outside: true},
+			roleId:           2,
+			expectedId:       11,
+			expectedWarnings: []string{availability.ErrOutsideAvailability.Error()},
+		},
+		{
+			name: "pending request already exists",
+			storage: &mockStorage{shift: upcoming, requests: []st.ShiftRequestWithShiftDetails{
+				{EmployeeID: 4, ShiftID: 3, Status: "PENDING"},
+			}},
+			roleId:      2,
+			expectedErr: ErrAlreadyRequested,
+		},
+		{
+			name: "previously rejected request can be resubmitted",
+			storage: &mockStorage{shift: upcoming, requests: []st.ShiftRequestWithShiftDetails{
+				{EmployeeID: 4, ShiftID: 3, Status: "REJECTED"},
+			}},
+			roleId:     2,
+			expectedId: 11,
+		},
+		{
+			name:        "concurrent duplicate request",
+			storage:     &mockStorage{shift: upcoming, createErr: st.ErrDuplicateShiftRequest},
+			roleId:      2,
+			expectedErr: ErrAlreadyRequested,
+		},
+		{
+			name:        "db error",
+			storage:     &mockStorage{shift: upcoming, createErr: errors.New("db error")},
+			roleId:      2,
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			available := tc.available
+			if available == nil {
+				available = &mockAvailabilityChecker{}
+			}
+			svc := NewShiftRequest(tc.storage, &mockConflictChecker{err: tc.conflictErr}, available, &mockLeaveChecker{err: tc.leaveErr})
+			svc.now = func() time.Time { return now }
+
+			id, warnings, err := svc.RequestShift(context.Background(), 4, tc.roleId, tc.locationIds, 3)
+			if tc.expectedErr != nil {
+				assert.EqualError(t, err, tc.expectedErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedId, id)
+			assert.Equal(t, tc.expectedWarnings, warnings)
+			assert.Equal(t, 4, tc.storage.createdEmployee)
+			assert.Equal(t, 3, tc.storage.createdShift)
+			if assert.Len(t, tc.storage.events, 1) {
+				assert.Equal(t, webhook.ShiftRequestSubmitted, tc.storage.events[0].EventType)
+				assert.Equal(t, tc.expectedId, tc.storage.events[0].EntityID)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package shiftrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/shift"
+	"payd/services/webhook"
+	st "payd/storage"
+)
+
+// ListShiftRequests lists the shift requests of every employee for shifts starting within the time range
+func (s *ShiftRequest) ListShiftRequests(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	return s.storage.ListShiftRequestsByFilterAndTimeRange(ctx, filter, start, end)
+}
+
+// Reviewer is the employee reviewing shift requests, restricted to the shifts of some job roles and locations
+type Reviewer struct {
+	EmployeeID  int
+	RoleIDs     []int // nil for every job role
+	LocationIDs []int // nil for every location
+}
+
+// check returns ErrRequestNotFound for the shifts of other locations, ErrReviewNotAllowed for the other job roles
+func (r Reviewer) check(sh *st.Shift) error {
+	if !st.InLocations(r.LocationIDs, sh.LocationID) {
+		return ErrRequestNotFound
+	}
+	if !r.canReview(sh.RoleID) {
+		return ErrReviewNotAllowed
+	}
+	return nil
+}
+
+func (r Reviewer) canReview(roleId int) bool {
+	if r.RoleIDs == nil {
+		return true
+	}
+	for _, id := range r.RoleIDs {
+		if id == roleId {
+			return true
+		}
+	}
+	return false
+}
+
+// ApproveShiftRequest approves a PENDING request and, once the shift is fully staffed, rejects every other
+// PENDING request of the same shift, all in one transaction attributed to the reviewer.
+// returns a *shift.ConflictError if the shift is already fully staffed or the approval would double-book the employee,
+// a *leave.OnLeaveError or an *availability.UnavailableError if the employee is on leave or unavailable.
+// the warnings report a shift outside the employee's weekly availability, it is approved anyway
+func (s *ShiftRequest) ApproveShiftRequest(ctx context.Context, requestId int, reviewer Reviewer) (warnings []string, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	return s.approve(tctx, requestId, reviewer)
+}
+
+// ApproveShiftRequests approves every request like ApproveShiftRequest, all or none in one transaction.
+// the error of a request is wrapped with its id
+func (s *ShiftRequest) ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer Reviewer) (warnings []string, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	for _, id := range requestIds {
+		w, err := s.approve(tctx, id, reviewer)
+		if err != nil {
+			return nil, fmt.Errorf("shift request %d: %w", id, err)
+		}
+		warnings = append(warnings, w...)
+	}
+	return warnings, nil
+}
+
+// approve approves the request within the transaction bound to ctx
+func (s *ShiftRequest) approve(ctx context.Context, requestId int, reviewer Reviewer) ([]string, error) {
+	req, err := s.lockPendingRequest(ctx, requestId)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := s.storage.LockShiftByID(ctx, req.ShiftID)
+	if err != nil {
+		return nil, err
+	}
+	if err = reviewer.check(sh); err != nil {
+		return nil, err
+	}
+	if err = s.conflicts.CheckAssignmentConflict(ctx, req.EmployeeID, req.ShiftID); err != nil {
+		return nil, err
+	}
+	warnings, err := s.checkAvailability(ctx, req.EmployeeID, sh)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.storage.ReviewShiftRequest(ctx, req.ID, StatusApproved, reviewer.EmployeeID); err != nil {
+		// the database guard caught a double-booking the check above couldn't see
+		return nil, shift.AsConflictError(err, req.EmployeeID, req.ShiftID)
+	}
+	approved, err := s.storage.CountApprovedRequestsByShiftID(ctx, req.ShiftID)
+	if err != nil {
+		return nil, err
+	}
+	var rejected []st.ShiftRequest
+	if approved >= sh.Headcount {
+		if rejected, err = s.storage.RejectPendingShiftRequestsByShiftID(ctx, req.ShiftID, req.ID, reviewer.EmployeeID); err != nil {
+			return nil, err
+		}
+	}
+	// the pending requests rejected along with the approval are counted, the shift is fully staffed
+	after := reviewed(StatusApproved, reviewer)
+	after["RejectedPending"] = len(rejected)
+	if err = audit.Record(ctx, s.storage, "approve", audit.EntityShiftRequest, req.ID, req, after); err != nil {
+		return nil, err
+	}
+	if err = publish(ctx, s.storage, webhook.ShiftRequestApproved, reviewedRequest(*req, StatusApproved, reviewer)); err != nil {
+		return nil, err
+	}
+	// the requests rejected along with the approval are published one by one, each for its own employee
+	for _, r := range rejected {
+		if err = publish(ctx, s.storage, webhook.ShiftRequestRejected, r); err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// RejectShiftRequest rejects a single PENDING request
+func (s *ShiftRequest) RejectShiftRequest(ctx context.Context, requestId int, reviewer Reviewer) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	req, err := s.lockPendingRequest(tctx, requestId)
+	if err != nil {
+		return err
+	}
+	if reviewer.RoleIDs != nil || reviewer.LocationIDs != nil {
+		sh, err := s.storage.GetShiftByID(tctx, req.ShiftID)
+		if err != nil {
+			return err
+		}
+		if err = reviewer.check(sh); err != nil {
+			return err
+		}
+	}
+	if err = s.storage.ReviewShiftRequest(tctx, req.ID, StatusRejected, reviewer.EmployeeID); err != nil {
+		return err
+	}
+	if err = audit.Record(tctx, s.storage, "reject", audit.EntityShiftRequest, req.ID, req, reviewed(StatusRejected, reviewer)); err != nil {
+		return err
+	}
+	return publish(tctx, s.storage, webhook.ShiftRequestRejected, reviewedRequest(*req, StatusRejected, reviewer))
+}
+
+// reviewed is the audited state of a reviewed request
+func reviewed(status string, reviewer Reviewer) map[string]interface{} {
+	return map[string]interface{}{"Status": status, "ReviewedBy": reviewer.EmployeeID}
+}
+
+// reviewedRequest is the request once reviewed
+func reviewedRequest(req st.ShiftRequest, status string, reviewer Reviewer) st.ShiftRequest {
+	req.Status = status
+	req.ReviewedBy = &reviewer.EmployeeID
+	return req
+}
+
+// publish writes the event of the request to the outbox, within the transaction bound to ctx
+func publish(ctx context.Context, p webhook.Publisher, eventType string, req st.ShiftRequest) error {
+	return webhook.Publish(ctx, p, eventType, req.ID, webhook.ShiftRequestPayload{
+		ID:         req.ID,
+		EmployeeID: req.EmployeeID,
+		ShiftID:    req.ShiftID,
+		Status:     req.Status,
+		ReviewedBy: req.ReviewedBy,
+	})
+}
+
+func (s *ShiftRequest) lockPendingRequest(ctx context.Context, requestId int) (*st.ShiftRequest, error) {
+	req, err := s.storage.LockShiftRequestByID(ctx, requestId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+	if req.Status != StatusPending {
+		return nil, ErrRequestNotPending
+	}
+	return req, nil
+}
@@ -0,0 +1,278 @@
+package shiftrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"payd/services/audit"
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/shift"
+	"payd/services/webhook"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type review struct {
+	id       int
+	status   string
+	reviewer int
+}
+
+func (m *mockStorage) LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error) {
+	return m.request, m.lockErr
+}
+
+func (m *mockStorage) ReviewShiftRequest(ctx context.Context, id int, status string, reviewedBy int) error {
+	if m.reviewErr != nil {
+		return m.reviewErr
+	}
+	m.reviews = append(m.reviews, review{id, status, reviewedBy})
+	return nil
+}
+
+// CountApprovedRequestsByShiftID counts the approvals of the test on top of the already approved requests
+func (m *mockStorage) CountApprovedRequestsByShiftID(ctx context.Context, shiftId int) (int, error) {
+	count := m.approvedCount
+	for _, r := range m.reviews {
+		if r.status == StatusApproved {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockStorage) RejectPendingShiftRequestsByShiftID(ctx context.Context, shiftId, exceptId, reviewedBy int) ([]st.ShiftRequest, error) {
+	m.rejectedShift = shiftId
+	return []st.ShiftRequest{{ID: exceptId + 1, EmployeeID: 7, ShiftID: shiftId, Status: StatusRejected, ReviewedBy: &reviewedBy}}, nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+var cookShift = &st.Shift{ID: 3, RoleID: 2, LocationID: 1, Headcount: 1}
+
+func TestApproveShiftRequest(t *testing.T) {
+	pending := &st.ShiftRequest{ID: 5, EmployeeID: 4, ShiftID: 3, Status: StatusPending}
+
+	tests := []struct {
+		name           string
+		storage        *mockStorage
+		reviewer       Reviewer
+		conflictErr    error
+		available      mockAvailabilityChecker
+		leaveErr       error
+		expectedErr    error
+		expectedReview []review
+		// of a shift outside the weekly availability
+		expectedWarnings []string
+	}{
+		{
+			name:           "approve and reject the others",
+			storage:        &mockStorage{shift: cookShift, request: pending},
+			reviewer:       Reviewer{EmployeeID: 1},
+			expectedReview: []review{{5, StatusApproved, 1}},
+		},
+		{
+			name:           "reviewer restricted to the shift role",
+			storage:        &mockStorage{shift: cookShift, request: pending},
+			reviewer:       Reviewer{EmployeeID: 1, RoleIDs: []int{1, 2}},
+			expectedReview: []review{{5, StatusApproved, 1}},
+		},
+		{
+			name:        "reviewer restricted to another role",
+			storage:     &mockStorage{shift: cookShift, request: pending},
+			reviewer:    Reviewer{EmployeeID: 1, RoleIDs: []int{1}},
+			expectedErr: ErrReviewNotAllowed,
+		},
+		{
+			name:        "shift of another location",
+			storage:     &mockStorage{shift: cookShift, request: pending},
+			reviewer:    Reviewer{EmployeeID: 1, LocationIDs: []int{2}},
+			expectedErr: ErrRequestNotFound,
+		},
+		{
+			name:        "request not found",
+			storage:     &mockStorage{lockErr: sql.ErrNoRows},
+			expectedErr: ErrRequestNotFound,
+		},
+		{
+			name:        "request already reviewed",
+			storage:     &mockStorage{request: &st.ShiftRequest{ID: 5, ShiftID: 3, Status: StatusRejected}},
+			expectedErr: ErrRequestNotPending,
+		},
+		{
+			name:        "shift already approved",
+			storage:     &mockStorage{shift: cookShift, request: pending},
+			conflictErr: &shift.ConflictError{Err: shift.ErrShiftFullyStaffed, EmployeeID: 4, ShiftID: 3},
+			expectedErr: shift.ErrShiftFullyStaffed,
+		},
+		{
+			name:        "employee declared to be unavailable",
+			storage:     &mockStorage{shift: cookShift, request: pending},
+			available:   mockAvailabilityChecker{err: &availability.UnavailableError{Unavailability: st.Unavailability{ID: 2, Reason: "dentist"}}},
+			expectedErr: availability.ErrEmployeeUnavailable,
+		},
+		{
+			name:        "employee on approved leave",
+			storage:     &mockStorage{shift: cookShift, request: pending},
+			leaveErr:    &leave.OnLeaveError{LeaveRequestID: 6},
+			expectedErr: leave.ErrEmployeeOnLeave,
+		},
+		{
+			name:             "shift outside the weekly availability",
+			storage:          &mockStorage{shift: cookShift, request: pending},
+			reviewer:         Reviewer{EmployeeID: 1},
+			available:        mockAvailabilityChecker{outside: true},
+			expectedReview:   []review{{5, StatusApproved, 1}},
+			expectedWarnings: []string{availability.ErrOutsideAvailability.Error()},
+		},
+		{
+			name:        "concurrent double-booking caught by the database",
+			storage:     &mockStorage{shift: cookShift, request: pending, reviewErr: st.ErrOverlappingApprovedShift},
+			expectedErr: shift.ErrEmployeeDoubleBooked,
+		},
+		{
+			name:        "db error rolls back",
+			storage:     &mockStorage{shift: cookShift, request: pending, reviewErr: errors.New("db error")},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewShiftRequest(tc.storage, &mockConflictChecker{err: tc.conflictErr}, &tc.available, &mockLeaveChecker{err: tc.leaveErr})
+			warnings, err := svc.ApproveShiftRequest(context.Background(), 5, tc.reviewer)
+			if tc.expectedErr != nil {
+				assert.EqualError(t, err, tc.expectedErr.Error())
+				if errors.Is(tc.expectedErr, shift.ErrShiftFullyStaffed) || errors.Is(tc.expectedErr, shift.ErrEmployeeDoubleBooked) {
+					var conflict *shift.ConflictError
+					assert.ErrorAs(t, err, &conflict)
+				}
+				assert.True(t, tc.storage.rolledBack)
+				assert.False(t, tc.storage.committed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedWarnings, warnings)
+			assert.Equal(t, tc.expectedReview, tc.storage.reviews)
+			assert.Equal(t, 3, tc.storage.rejectedShift)
+			assert.True(t, tc.storage.committed)
+		})
+	}
+}
+
+func TestApproveShiftRequestHeadcount(t *testing.T) {
+	pending := &st.ShiftRequest{ID: 5, EmployeeID: 4, ShiftID: 3, Status: StatusPending}
+	crewShift := &st.Shift{ID: 3, RoleID: 2, LocationID: 1, Headcount: 3}
+
+	t.Run("open slots left keep the other requests pending", func(t *testing.T) {
+		storage := &mockStorage{shift: crewShift, request: pending, approvedCount: 1}
+		_, err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).ApproveShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []review{{5, StatusApproved, 1}}, storage.reviews)
+		assert.Zero(t, storage.rejectedShift)
+		assert.True(t, storage.committed)
+	})
+
+	t.Run("last slot rejects the other requests", func(t *testing.T) {
+		storage := &mockStorage{shift: crewShift, request: pending, approvedCount: 2}
+		_, err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).ApproveShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, storage.rejectedShift)
+		assert.True(t, storage.committed)
+		if assert.Len(t, storage.audits, 1) {
+			assert.Equal(t, "approve", storage.audits[0].Action)
+			assert.Equal(t, audit.EntityShiftRequest, storage.audits[0].EntityType)
+			assert.Equal(t, 5, storage.audits[0].EntityID)
+			assert.JSONEq(t, `{"Status":"APPROVED","ReviewedBy":1,"RejectedPending":1}`, string(*storage.audits[0].After))
+		}
+		if assert.Len(t, storage.events, 2) {
+			assert.Equal(t, webhook.ShiftRequestApproved, storage.events[0].EventType)
+			assert.JSONEq(t, `{"id":5,"employeeId":4,"shiftId":3,"status":"APPROVED","reviewedBy":1}`, string(storage.events[0].Payload))
+			assert.Equal(t, webhook.ShiftRequestRejected, storage.events[1].EventType)
+			assert.Equal(t, 6, storage.events[1].EntityID)
+		}
+	})
+}
+
+func TestApproveShiftRequests(t *testing.T) {
+	storage := &mockStorage{shift: cookShift, request: &st.ShiftRequest{ID: 5, EmployeeID: 4, ShiftID: 3, Status: StatusPending}}
+	svc := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{})
+
+	_, err := svc.ApproveShiftRequests(context.Background(), []int{5, 6}, Reviewer{EmployeeID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, storage.reviews, 2)
+	assert.True(t, storage.committed)
+
+	storage = &mockStorage{shift: cookShift, request: &st.ShiftRequest{ID: 5, EmployeeID: 4, ShiftID: 3, Status: StatusApproved}}
+	svc = NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{})
+	_, err = svc.ApproveShiftRequests(context.Background(), []int{5}, Reviewer{EmployeeID: 1})
+	assert.ErrorIs(t, err, ErrRequestNotPending)
+	assert.EqualError(t, err, "shift request 5: "+ErrRequestNotPending.Error())
+	assert.True(t, storage.rolledBack)
+}
+
+func TestRejectShiftRequest(t *testing.T) {
+	t.Run("reject pending request", func(t *testing.T) {
+		storage := &mockStorage{request: &st.ShiftRequest{ID: 5, ShiftID: 3, Status: StatusPending}}
+		err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).RejectShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []review{{5, StatusRejected, 1}}, storage.reviews)
+		assert.Zero(t, storage.rejectedShift)
+		assert.True(t, storage.committed)
+		if assert.Len(t, storage.audits, 1) {
+			assert.Equal(t, "reject", storage.audits[0].Action)
+			assert.JSONEq(t, `{"Status":"REJECTED","ReviewedBy":1}`, string(*storage.audits[0].After))
+		}
+		if assert.Len(t, storage.events, 1) {
+			assert.Equal(t, webhook.ShiftRequestRejected, storage.events[0].EventType)
+		}
+	})
+
+	t.Run("reject approved request", func(t *testing.T) {
+		storage := &mockStorage{request: &st.ShiftRequest{ID: 5, ShiftID: 3, Status: StatusApproved}}
+		err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).RejectShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1})
+		assert.ErrorIs(t, err, ErrRequestNotPending)
+		assert.Empty(t, storage.reviews)
+		assert.True(t, storage.rolledBack)
+	})
+
+	t.Run("reviewer restricted to another role", func(t *testing.T) {
+		storage := &mockStorage{shift: cookShift, request: &st.ShiftRequest{ID: 5, ShiftID: 3, Status: StatusPending}}
+		err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).RejectShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1, RoleIDs: []int{1}})
+		assert.ErrorIs(t, err, ErrReviewNotAllowed)
+		assert.Empty(t, storage.reviews)
+		assert.True(t, storage.rolledBack)
+	})
+
+	t.Run("reviewer restricted to another location", func(t *testing.T) {
+		storage := &mockStorage{shift: cookShift, request: &st.ShiftRequest{ID: 5, ShiftID: 3, Status: StatusPending}}
+		err := NewShiftRequest(storage, &mockConflictChecker{}, &mockAvailabilityChecker{}, &mockLeaveChecker{}).RejectShiftRequest(context.Background(), 5, Reviewer{EmployeeID: 1, LocationIDs: []int{2}})
+		assert.ErrorIs(t, err, ErrRequestNotFound)
+		assert.Empty(t, storage.reviews)
+		assert.True(t, storage.rolledBack)
+	})
+}
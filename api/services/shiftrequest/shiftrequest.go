@@ -0,0 +1,104 @@
+package shiftrequest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/availability"
+	"payd/services/webhook"
+	st "payd/storage"
+)
+
+const (
+	StatusPending   = "PENDING"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusWithdrawn = "WITHDRAWN"
+)
+
+var ErrShiftNotFound = errors.New("shift not found")
+var ErrRoleMismatch = errors.New("shift is not for the employee's primary role")
+var ErrShiftAlreadyStarted = errors.New("shift has already started")
+var ErrAlreadyRequested = errors.New("shift already requested")
+var ErrRequestNotFound = errors.New("shift request not found")
+var ErrRequestNotPending = errors.New("shift request is not pending")
+var ErrReviewNotAllowed = errors.New("reviewer may not review requests for this role")
+
+type storage interface {
+	GetShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	LockShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	GetAvailableShiftsByTimeRangeAndRole(ctx context.Context, start, end time.Time, roleId int, locationIds []int) ([]st.Shift, error)
+	CreateShiftRequest(ctx context.Context, employeeId, shiftId int) (int, error)
+	ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error)
+	LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error)
+	ReviewShiftRequest(ctx context.Context, id int, status string, reviewedBy int) error
+	CountApprovedRequestsByShiftID(ctx context.Context, shiftId int) (int, error)
+	RejectPendingShiftRequestsByShiftID(ctx context.Context, shiftId, exceptId, reviewedBy int) ([]st.ShiftRequest, error)
+
+	audit.Recorder
+	webhook.Publisher
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type ShiftRequestInterface interface {
+	GetAvailableShifts(ctx context.Context, roleId int, locationIds []int, start, end time.Time) ([]st.Shift, error)
+	RequestShift(ctx context.Context, employeeId, roleId int, locationIds []int, shiftId int) (int, []string, error)
+	ListEmployeeShiftRequests(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error)
+
+	ListShiftRequests(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]st.ShiftRequestWithShiftDetails, error)
+	ApproveShiftRequest(ctx context.Context, requestId int, reviewer Reviewer) ([]string, error)
+	ApproveShiftRequests(ctx context.Context, requestIds []int, reviewer Reviewer) ([]string, error)
+	RejectShiftRequest(ctx context.Context, requestId int, reviewer Reviewer) error
+}
+
+// conflictChecker detects double-booking, see shift.Shift
+type conflictChecker interface {
+	CheckAssignmentConflict(ctx context.Context, employeeId, shiftId int) error
+}
+
+// availabilityChecker compares a shift with the employee's declared availability, see availability.Availability
+type availabilityChecker interface {
+	CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error)
+}
+
+// leaveChecker refuses the shifts during approved leave, see leave.Leave
+type leaveChecker interface {
+	CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error
+}
+
+type ShiftRequest struct {
+	storage      storage
+	conflicts    conflictChecker
+	availability availabilityChecker
+	leave        leaveChecker
+	now          func() time.Time
+}
+
+func NewShiftRequest(storage storage, conflicts conflictChecker, availability availabilityChecker, leave leaveChecker) *ShiftRequest {
+	return &ShiftRequest{
+		storage:      storage,
+		conflicts:    conflicts,
+		availability: availability,
+		leave:        leave,
+		now:          time.Now,
+	}
+}
+
+// checkAvailability returns the *leave.OnLeaveError or *availability.UnavailableError refusing the shift,
+// or the warnings of a shift outside the employee's weekly availability
+func (s *ShiftRequest) checkAvailability(ctx context.Context, employeeId int, sh *st.Shift) ([]string, error) {
+	if err := s.leave.CheckShift(ctx, employeeId, sh.LocationID, sh.StartTime, sh.EndTime); err != nil {
+		return nil, err
+	}
+	outside, err := s.availability.CheckShift(ctx, employeeId, sh.StartTime, sh.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if outside {
+		return []string{availability.ErrOutsideAvailability.Error()}, nil
+	}
+	return nil, nil
+}
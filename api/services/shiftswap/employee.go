@@ -0,0 +1,189 @@
+package shiftswap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+)
+
+// OfferShift offers an APPROVED shift request of the employee to the colleagues of the shift role.
+// the employee stays assigned to the shift until an admin approves a claim
+func (s *ShiftSwap) OfferShift(ctx context.Context, employeeId, shiftRequestId int) (id int, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	req, sh, err := s.lockAssignment(tctx, shiftRequestId)
+	if err != nil {
+		return 0, err
+	}
+	if req.EmployeeID != employeeId {
+		return 0, ErrRequestNotFound
+	}
+	if req.Status != requestApproved {
+		return 0, ErrRequestNotApproved
+	}
+	if !sh.StartTime.After(s.now()) {
+		return 0, ErrShiftAlreadyStarted
+	}
+
+	id, err = s.storage.CreateShiftSwap(tctx, req.ID, employeeId)
+	if errors.Is(err, st.ErrDuplicateShiftSwap) {
+		return 0, ErrAlreadyOffered
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = audit.Record(tctx, s.storage, "offer", audit.EntityShiftSwap, id, nil,
+		st.ShiftSwap{ID: id, ShiftRequestID: req.ID, OfferedBy: employeeId, Status: StatusOpen})
+	return id, err
+}
+
+// ListOpenSwaps lists the OPEN swaps the employee may claim: upcoming shifts of their primary role
+// at the locations they may access, offered by colleagues. nil locationIds means every location
+func (s *ShiftSwap) ListOpenSwaps(ctx context.Context, employeeId, roleId int, locationIds []int) ([]st.ShiftSwapWithShiftDetails, error) {
+	swaps, err := s.storage.ListOpenShiftSwapsByRole(ctx, roleId, locationIds, s.now())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]st.ShiftSwapWithShiftDetails, 0, len(swaps))
+	for _, sw := range swaps {
+		if sw.OfferedBy != employeeId {
+			res = append(res, sw)
+		}
+	}
+	return res, nil
+}
+
+// ListEmployeeSwaps lists the swaps the employee offered or claimed for shifts starting within the time range,
+// status is optional
+func (s *ShiftSwap) ListEmployeeSwaps(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	return s.storage.ListShiftSwapsByFilter(ctx, st.ListShiftSwapFilter{
+		EmployeeID: employeeId,
+		Status:     status,
+	}, start, end)
+}
+
+// ClaimSwap claims an OPEN swap for the employee, giving swapShiftRequestId in exchange unless it is nil.
+// roleId is the employee's primary role, only the shifts of their role at the locations they may access can be claimed.
+// returns a *shift.ConflictError if the shift overlaps another approved shift of the employee,
+// a *leave.OnLeaveError or an *availability.UnavailableError if either employee is on leave or unavailable
+// for the shift they would take over.
+// the warnings report a shift outside the weekly availability, it is claimed anyway
+func (s *ShiftSwap) ClaimSwap(ctx context.Context, employeeId, roleId int, locationIds []int, id int, swapShiftRequestId *int) (warnings []string, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	swap, err := s.lockSwap(tctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, sh, err := s.lockAssignment(tctx, swap.ShiftRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if !st.InLocations(locationIds, sh.LocationID) {
+		return nil, ErrSwapNotFound
+	}
+	if swap.Status != StatusOpen {
+		return nil, ErrSwapNotOpen
+	}
+	if swap.OfferedBy == employeeId {
+		return nil, ErrOwnSwap
+	}
+	if sh.RoleID != roleId {
+		return nil, ErrRoleMismatch
+	}
+	if !sh.StartTime.After(s.now()) {
+		return nil, ErrShiftAlreadyStarted
+	}
+
+	var swapShift *st.Shift
+	if swapShiftRequestId != nil {
+		var swapReq *st.ShiftRequest
+		swapReq, swapShift, err = s.lockAssignment(tctx, *swapShiftRequestId)
+		if errors.Is(err, ErrRequestNotFound) {
+			return nil, ErrInvalidExchange
+		}
+		if err != nil {
+			return nil, err
+		}
+		if swapReq.EmployeeID != employeeId || swapReq.Status != requestApproved || swapShift.RoleID != sh.RoleID {
+			return nil, ErrInvalidExchange
+		}
+		if !swapShift.StartTime.After(s.now()) {
+			return nil, ErrShiftAlreadyStarted
+		}
+	}
+	// an exchange of overlapping shifts double-books neither employee
+	if warnings, err = s.checkAssignee(tctx, employeeId, sh, swapShift); err != nil {
+		return nil, err
+	}
+	if swapShift != nil {
+		offererWarnings, err := s.checkAssignee(tctx, swap.OfferedBy, swapShift, sh)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, offererWarnings...)
+	}
+
+	if err = s.storage.ClaimShiftSwap(tctx, swap.ID, employeeId, swapShiftRequestId); err != nil {
+		return nil, err
+	}
+	after := map[string]interface{}{"Status": StatusClaimed, "ClaimedBy": employeeId, "SwapShiftRequestID": swapShiftRequestId}
+	if err = audit.Record(tctx, s.storage, "claim", audit.EntityShiftSwap, swap.ID, swap, after); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// CancelSwap cancels an OPEN or CLAIMED swap the employee offered
+func (s *ShiftSwap) CancelSwap(ctx context.Context, employeeId, id int) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	cancelled, err := s.storage.CancelShiftSwap(tctx, id, employeeId)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrSwapNotActive
+	}
+	return audit.Record(tctx, s.storage, "cancel", audit.EntityShiftSwap, id, nil, map[string]interface{}{"Status": StatusCancelled})
+}
+
+func (s *ShiftSwap) lockSwap(ctx context.Context, id int) (*st.ShiftSwap, error) {
+	swap, err := s.storage.LockShiftSwapByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSwapNotFound
+	}
+	return swap, err
+}
+
+// lockAssignment locks the shift request then its shift
+func (s *ShiftSwap) lockAssignment(ctx context.Context, shiftRequestId int) (*st.ShiftRequest, *st.Shift, error) {
+	req, err := s.storage.LockShiftRequestByID(ctx, shiftRequestId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrRequestNotFound
+		}
+		return nil, nil, err
+	}
+	sh, err := s.storage.LockShiftByID(ctx, req.ShiftID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, sh, nil
+}
@@ -0,0 +1,338 @@
+package shiftswap
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"payd/services/availability"
+	"payd/services/leave"
+	"payd/services/shift"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2025, 5, 15, 8, 0, 0, 0, time.UTC)
+
+type mockStorage struct {
+	shifts    map[int]*st.Shift
+	requests  map[int]*st.ShiftRequest
+	swaps     map[int]*st.ShiftSwap
+	overlaps  map[int][]st.Shift // approved shifts by employee
+	createErr error
+	// transfers maps the shift request ids to their new employee
+	transfers   map[int]int
+	transferErr error
+	released    []int
+	claimed     *st.ShiftSwap
+	reviewed    string
+	cancelledOf []int
+	audits      []st.AuditLog
+
+	committed  bool
+	rolledBack bool
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		shifts: map[int]*st.Shift{
+			1: {ID: 1, RoleID: 2, LocationID: 1, StartTime: now.Add(24 * time.Hour), EndTime: now.Add(32 * time.Hour)},
+			2: {ID: 2, RoleID: 2, LocationID: 1, StartTime: now.Add(48 * time.Hour), EndTime: now.Add(56 * time.Hour)},
+			3: {ID: 3, RoleID: 3, LocationID: 1, StartTime: now.Add(72 * time.Hour), EndTime: now.Add(80 * time.Hour)},
+			// overlaps shift 1
+			4: {ID: 4, RoleID: 2, LocationID: 1, StartTime: now.Add(26 * time.Hour), EndTime: now.Add(34 * time.Hour)},
+		},
+		requests: map[int]*st.ShiftRequest{
+			10: {ID: 10, EmployeeID: 4, ShiftID: 1, Status: "APPROVED"},
+			11: {ID: 11, EmployeeID: 5, ShiftID: 2, Status: "APPROVED"},
+			12: {ID: 12, EmployeeID: 5, ShiftID: 3, Status: "APPROVED"},
+			13: {ID: 13, EmployeeID: 5, ShiftID: 4, Status: "APPROVED"},
+		},
+		swaps:     map[int]*st.ShiftSwap{},
+		overlaps:  map[int][]st.Shift{},
+		transfers: map[int]int{},
+	}
+}
+
+func (m *mockStorage) LockShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	sh, ok := m.shifts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return sh, nil
+}
+
+func (m *mockStorage) LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error) {
+	req, ok := m.requests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return req, nil
+}
+
+func (m *mockStorage) ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error) {
+	var shifts []st.Shift
+	for _, sh := range m.overlaps[employeeId] {
+		if sh.StartTime.Before(end) && sh.EndTime.After(start) {
+			shifts = append(shifts, sh)
+		}
+	}
+	return shifts, nil
+}
+
+func (m *mockStorage) CreateShiftSwap(ctx context.Context, shiftRequestId, offeredBy int) (int, error) {
+	if m.createErr != nil {
+		return 0, m.createErr
+	}
+	return 7, nil
+}
+
+func (m *mockStorage) LockShiftSwapByID(ctx context.Context, id int) (*st.ShiftSwap, error) {
+	swap, ok := m.swaps[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return swap, nil
+}
+
+func (m *mockStorage) ClaimShiftSwap(ctx context.Context, id, claimedBy int, swapShiftRequestId *int) error {
+	m.claimed = &st.ShiftSwap{ID: id, ClaimedBy: &claimedBy, SwapShiftRequestID: swapShiftRequestId}
+	return nil
+}
+
+func (m *mockStorage) ReviewShiftSwap(ctx context.Context, id int, status string, reviewedBy int) error {
+	m.reviewed = status
+	return nil
+}
+
+func (m *mockStorage) CancelShiftSwap(ctx context.Context, id, offeredBy int) (bool, error) {
+	swap, ok := m.swaps[id]
+	return ok && swap.OfferedBy == offeredBy && (swap.Status == StatusOpen || swap.Status == StatusClaimed), nil
+}
+
+func (m *mockStorage) CancelActiveShiftSwapsByShiftRequestID(ctx context.Context, shiftRequestId, exceptId, reviewedBy int) (int64, error) {
+	m.cancelledOf = append(m.cancelledOf, shiftRequestId)
+	return 0, nil
+}
+
+func (m *mockStorage) ListShiftSwapsByFilter(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) ListOpenShiftSwapsByRole(ctx context.Context, roleId int, locationIds []int, start time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	return []st.ShiftSwapWithShiftDetails{
+		{ShiftSwap: st.ShiftSwap{ID: 1, OfferedBy: 4}},
+		{ShiftSwap: st.ShiftSwap{ID: 2, OfferedBy: 5}},
+	}, nil
+}
+
+func (m *mockStorage) ReleaseShiftRequest(ctx context.Context, id int) error {
+	m.released = append(m.released, id)
+	return nil
+}
+
+func (m *mockStorage) TransferShiftRequest(ctx context.Context, id, employeeId int) error {
+	if m.transferErr != nil {
+		return m.transferErr
+	}
+	m.transfers[id] = employeeId
+	return nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+type mockAvailabilityChecker struct {
+	outside bool
+	err     error
+}
+
+func (m mockAvailabilityChecker) CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error) {
+	return m.outside, m.err
+}
+
+type mockLeaveChecker struct {
+	onLeave map[int]bool // by employee
+}
+
+func (m mockLeaveChecker) CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error {
+	if m.onLeave[employeeId] {
+		return &leave.OnLeaveError{LeaveRequestID: 9}
+	}
+	return nil
+}
+
+func newShiftSwap(storage *mockStorage, available mockAvailabilityChecker, onLeave map[int]bool) *ShiftSwap {
+	s := NewShiftSwap(storage, available, mockLeaveChecker{onLeave: onLeave})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestOfferShift(t *testing.T) {
+	tests := []struct {
+		name        string
+		employeeId  int
+		requestId   int
+		status      string
+		createErr   error
+		expectedErr error
+	}{
+		{name: "offer an approved shift", employeeId: 4, requestId: 10},
+		{name: "someone else's request", employeeId: 5, requestId: 10, expectedErr: ErrRequestNotFound},
+		{name: "unknown request", employeeId: 4, requestId: 99, expectedErr: ErrRequestNotFound},
+		{name: "pending request", employeeId: 4, requestId: 10, status: "PENDING", expectedErr: ErrRequestNotApproved},
+		{name: "already offered", employeeId: 4, requestId: 10, createErr: st.ErrDuplicateShiftSwap, expectedErr: ErrAlreadyOffered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMockStorage()
+			storage.createErr = tt.createErr
+			if tt.status != "" {
+				storage.requests[10].Status = tt.status
+			}
+			s := newShiftSwap(storage, mockAvailabilityChecker{}, nil)
+
+			id, err := s.OfferShift(context.Background(), tt.employeeId, tt.requestId)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, storage.rolledBack)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 7, id)
+			assert.True(t, storage.committed)
+		})
+	}
+}
+
+func TestListOpenSwapsExcludesOwnOffers(t *testing.T) {
+	s := newShiftSwap(newMockStorage(), mockAvailabilityChecker{}, nil)
+	swaps, err := s.ListOpenSwaps(context.Background(), 4, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, swaps, 1)
+	assert.Equal(t, 2, swaps[0].ID)
+}
+
+func TestClaimSwap(t *testing.T) {
+	exchange := 11
+	otherRole := 12
+	someoneElses := 10
+	overlapping := 13
+
+	tests := []struct {
+		name             string
+		employeeId       int
+		roleId           int
+		locationIds      []int
+		swapRequestId    *int
+		status           string
+		overlaps         []st.Shift
+		available        mockAvailabilityChecker
+		onLeave          map[int]bool
+		expectedErr      error
+		expectedWarnings []string
+	}{
+		{name: "give-away", employeeId: 5, roleId: 2},
+		{name: "exchange", employeeId: 5, roleId: 2, swapRequestId: &exchange},
+		{
+			name:          "exchange of an overlapping shift",
+			employeeId:    5,
+			roleId:        2,
+			swapRequestId: &overlapping,
+			overlaps:      []st.Shift{{ID: 4, StartTime: now.Add(26 * time.Hour), EndTime: now.Add(34 * time.Hour)}},
+		},
+		{name: "other location", employeeId: 5, roleId: 2, locationIds: []int{2}, expectedErr: ErrSwapNotFound},
+		{name: "not open", employeeId: 5, roleId: 2, status: StatusClaimed, expectedErr: ErrSwapNotOpen},
+		{name: "own offer", employeeId: 4, roleId: 2, expectedErr: ErrOwnSwap},
+		{name: "other role", employeeId: 5, roleId: 3, expectedErr: ErrRoleMismatch},
+		{name: "exchange of another role", employeeId: 5, roleId: 2, swapRequestId: &otherRole, expectedErr: ErrInvalidExchange},
+		{name: "exchange of someone else", employeeId: 5, roleId: 2, swapRequestId: &someoneElses, expectedErr: ErrInvalidExchange},
+		{
+			name:        "claimant double-booked",
+			employeeId:  5,
+			roleId:      2,
+			overlaps:    []st.Shift{{ID: 8, StartTime: now.Add(25 * time.Hour), EndTime: now.Add(30 * time.Hour)}},
+			expectedErr: shift.ErrEmployeeDoubleBooked,
+		},
+		{name: "claimant on leave", employeeId: 5, roleId: 2, onLeave: map[int]bool{5: true}, expectedErr: leave.ErrEmployeeOnLeave},
+		{
+			name:          "offerer on leave during the exchanged shift",
+			employeeId:    5,
+			roleId:        2,
+			swapRequestId: &exchange,
+			onLeave:       map[int]bool{4: true},
+			expectedErr:   leave.ErrEmployeeOnLeave,
+		},
+		{
+			name:             "outside the weekly availability",
+			employeeId:       5,
+			roleId:           2,
+			available:        mockAvailabilityChecker{outside: true},
+			expectedWarnings: []string{availability.ErrOutsideAvailability.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMockStorage()
+			status := StatusOpen
+			if tt.status != "" {
+				status = tt.status
+			}
+			storage.swaps[7] = &st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: status}
+			storage.overlaps[tt.employeeId] = tt.overlaps
+			s := newShiftSwap(storage, tt.available, tt.onLeave)
+
+			warnings, err := s.ClaimSwap(context.Background(), tt.employeeId, tt.roleId, tt.locationIds, 7, tt.swapRequestId)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, storage.claimed)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedWarnings, warnings)
+			require.NotNil(t, storage.claimed)
+			assert.Equal(t, tt.employeeId, *storage.claimed.ClaimedBy)
+			assert.Equal(t, tt.swapRequestId, storage.claimed.SwapShiftRequestID)
+		})
+	}
+}
+
+func TestCancelSwap(t *testing.T) {
+	storage := newMockStorage()
+	storage.swaps[7] = &st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusApproved}
+	s := newShiftSwap(storage, mockAvailabilityChecker{}, nil)
+
+	assert.ErrorIs(t, s.CancelSwap(context.Background(), 4, 7), ErrSwapNotActive)
+	storage.swaps[7].Status = StatusClaimed
+	assert.ErrorIs(t, s.CancelSwap(context.Background(), 5, 7), ErrSwapNotActive)
+	assert.NoError(t, s.CancelSwap(context.Background(), 4, 7))
+}
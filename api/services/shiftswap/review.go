@@ -0,0 +1,170 @@
+package shiftswap
+
+import (
+	"context"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/shift"
+	st "payd/storage"
+)
+
+// ListSwaps lists the swaps of shifts starting within the time range
+func (s *ShiftSwap) ListSwaps(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error) {
+	return s.storage.ListShiftSwapsByFilter(ctx, filter, start, end)
+}
+
+// Reviewer is the employee reviewing swaps, restricted to the shifts of some job roles and locations
+type Reviewer struct {
+	EmployeeID  int
+	RoleIDs     []int // nil for every job role
+	LocationIDs []int // nil for every location
+}
+
+// check returns ErrSwapNotFound for the shifts of other locations, ErrReviewNotAllowed for the other job roles
+func (r Reviewer) check(sh *st.Shift) error {
+	if !st.InLocations(r.LocationIDs, sh.LocationID) {
+		return ErrSwapNotFound
+	}
+	if r.RoleIDs == nil {
+		return nil
+	}
+	for _, id := range r.RoleIDs {
+		if id == sh.RoleID {
+			return nil
+		}
+	}
+	return ErrReviewNotAllowed
+}
+
+// ApproveSwap approves a CLAIMED swap: the offered shift request is reassigned to the claimant and,
+// for an exchange, the claimant's shift request to the offerer, all in one transaction attributed to the reviewer.
+// the other swaps of both shift requests are cancelled, the swap row is kept as the history of the transfer.
+// returns a *shift.ConflictError if either employee would be double-booked,
+// a *leave.OnLeaveError or an *availability.UnavailableError if either employee is on leave or unavailable.
+// the warnings report a shift outside the weekly availability, it is approved anyway
+func (s *ShiftSwap) ApproveSwap(ctx context.Context, id int, reviewer Reviewer) (warnings []string, err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	swap, err := s.lockSwap(tctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req, sh, err := s.lockAssignment(tctx, swap.ShiftRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if err = reviewer.check(sh); err != nil {
+		return nil, err
+	}
+	if swap.Status != StatusClaimed {
+		return nil, ErrSwapNotClaimed
+	}
+	claimantId := *swap.ClaimedBy
+	if req.EmployeeID != swap.OfferedBy || req.Status != requestApproved {
+		return nil, ErrAssignmentChanged
+	}
+
+	var swapReq *st.ShiftRequest
+	var swapShift *st.Shift
+	if swap.SwapShiftRequestID != nil {
+		if swapReq, swapShift, err = s.lockAssignment(tctx, *swap.SwapShiftRequestID); err != nil {
+			return nil, err
+		}
+		if err = reviewer.check(swapShift); err != nil {
+			return nil, err
+		}
+		if swapReq.EmployeeID != claimantId || swapReq.Status != requestApproved {
+			return nil, ErrAssignmentChanged
+		}
+	}
+	if warnings, err = s.checkAssignee(tctx, claimantId, sh, swapShift); err != nil {
+		return nil, err
+	}
+	if swapReq != nil {
+		offererWarnings, err := s.checkAssignee(tctx, swap.OfferedBy, swapShift, sh)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, offererWarnings...)
+
+		// the claimant gives their shift up first, the database guard would see them holding both shifts otherwise
+		if err = s.storage.ReleaseShiftRequest(tctx, swapReq.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.storage.TransferShiftRequest(tctx, req.ID, claimantId); err != nil {
+		// the database guard caught a double-booking the checks above couldn't see
+		return nil, shift.AsConflictError(err, claimantId, req.ShiftID)
+	}
+	if _, err = s.storage.CancelActiveShiftSwapsByShiftRequestID(tctx, req.ID, swap.ID, reviewer.EmployeeID); err != nil {
+		return nil, err
+	}
+	if swapReq != nil {
+		if err = s.storage.TransferShiftRequest(tctx, swapReq.ID, swap.OfferedBy); err != nil {
+			return nil, shift.AsConflictError(err, swap.OfferedBy, swapReq.ShiftID)
+		}
+		if _, err = s.storage.CancelActiveShiftSwapsByShiftRequestID(tctx, swapReq.ID, swap.ID, reviewer.EmployeeID); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.storage.ReviewShiftSwap(tctx, swap.ID, StatusApproved, reviewer.EmployeeID); err != nil {
+		return nil, err
+	}
+	if err = audit.Record(tctx, s.storage, "approve", audit.EntityShiftSwap, swap.ID, swap, reviewed(StatusApproved, reviewer)); err != nil {
+		return nil, err
+	}
+	if err = recordTransfer(tctx, s.storage, req.ID, req.EmployeeID, claimantId); err != nil {
+		return nil, err
+	}
+	if swapReq != nil {
+		if err = recordTransfer(tctx, s.storage, swapReq.ID, swapReq.EmployeeID, swap.OfferedBy); err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// RejectSwap rejects a CLAIMED swap, the offerer keeps the shift and may offer it again
+func (s *ShiftSwap) RejectSwap(ctx context.Context, id int, reviewer Reviewer) (err error) {
+	tctx, err := s.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.storage.Finish(tctx, &err)
+
+	swap, err := s.lockSwap(tctx, id)
+	if err != nil {
+		return err
+	}
+	_, sh, err := s.lockAssignment(tctx, swap.ShiftRequestID)
+	if err != nil {
+		return err
+	}
+	if err = reviewer.check(sh); err != nil {
+		return err
+	}
+	if swap.Status != StatusClaimed {
+		return ErrSwapNotClaimed
+	}
+	if err = s.storage.ReviewShiftSwap(tctx, swap.ID, StatusRejected, reviewer.EmployeeID); err != nil {
+		return err
+	}
+	return audit.Record(tctx, s.storage, "reject", audit.EntityShiftSwap, swap.ID, swap, reviewed(StatusRejected, reviewer))
+}
+
+// reviewed is the audited state of a reviewed swap
+func reviewed(status string, reviewer Reviewer) map[string]interface{} {
+	return map[string]interface{}{"Status": status, "ReviewedBy": reviewer.EmployeeID}
+}
+
+// recordTransfer audits the reassignment of a shift request by an approved swap
+func recordTransfer(ctx context.Context, r audit.Recorder, shiftRequestId, from, to int) error {
+	return audit.Record(ctx, r, "transfer", audit.EntityShiftRequest, shiftRequestId,
+		map[string]interface{}{"EmployeeID": from}, map[string]interface{}{"EmployeeID": to})
+}
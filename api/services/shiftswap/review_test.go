@@ -0,0 +1,150 @@
+package shiftswap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/leave"
+	"payd/services/shift"
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproveSwap(t *testing.T) {
+	claimant := 5
+	exchange := 11
+	overlapping := 13
+
+	tests := []struct {
+		name              string
+		swap              st.ShiftSwap
+		reviewer          Reviewer
+		reassigned        int // the employee of request 10 when the assignment changed since the offer
+		overlaps          map[int][]st.Shift
+		onLeave           map[int]bool
+		transferErr       error
+		expectedErr       error
+		expectedTransfers map[int]int
+	}{
+		{
+			name:              "give-away",
+			swap:              st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:          Reviewer{EmployeeID: 1},
+			expectedTransfers: map[int]int{10: 5},
+		},
+		{
+			name:              "exchange",
+			swap:              st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant, SwapShiftRequestID: &exchange},
+			reviewer:          Reviewer{EmployeeID: 1, RoleIDs: []int{2}},
+			expectedTransfers: map[int]int{10: 5, 11: 4},
+		},
+		{
+			name:     "exchange of overlapping shifts",
+			swap:     st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant, SwapShiftRequestID: &overlapping},
+			reviewer: Reviewer{EmployeeID: 1},
+			overlaps: map[int][]st.Shift{
+				4: {{ID: 1, StartTime: now.Add(24 * time.Hour), EndTime: now.Add(32 * time.Hour)}},
+				5: {{ID: 4, StartTime: now.Add(26 * time.Hour), EndTime: now.Add(34 * time.Hour)}},
+			},
+			expectedTransfers: map[int]int{10: 5, 13: 4},
+		},
+		{
+			name:        "not claimed",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusOpen},
+			reviewer:    Reviewer{EmployeeID: 1},
+			expectedErr: ErrSwapNotClaimed,
+		},
+		{
+			name:        "reviewer restricted to another role",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:    Reviewer{EmployeeID: 1, RoleIDs: []int{3}},
+			expectedErr: ErrReviewNotAllowed,
+		},
+		{
+			name:        "reviewer of another location",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:    Reviewer{EmployeeID: 1, LocationIDs: []int{2}},
+			expectedErr: ErrSwapNotFound,
+		},
+		{
+			name:        "assignment changed since the offer",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:    Reviewer{EmployeeID: 1},
+			reassigned:  6,
+			expectedErr: ErrAssignmentChanged,
+		},
+		{
+			name:        "claimant took leave since the claim",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:    Reviewer{EmployeeID: 1},
+			onLeave:     map[int]bool{5: true},
+			expectedErr: leave.ErrEmployeeOnLeave,
+		},
+		{
+			name:        "database guard",
+			swap:        st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant},
+			reviewer:    Reviewer{EmployeeID: 1},
+			transferErr: st.ErrOverlappingApprovedShift,
+			expectedErr: shift.ErrEmployeeDoubleBooked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMockStorage()
+			swap := tt.swap
+			storage.swaps[7] = &swap
+			storage.transferErr = tt.transferErr
+			for employeeId, shifts := range tt.overlaps {
+				storage.overlaps[employeeId] = shifts
+			}
+			if tt.reassigned != 0 {
+				storage.requests[10].EmployeeID = tt.reassigned
+			}
+			s := newShiftSwap(storage, mockAvailabilityChecker{}, tt.onLeave)
+
+			_, err := s.ApproveSwap(context.Background(), 7, tt.reviewer)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, storage.rolledBack)
+				assert.Empty(t, storage.reviewed)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, storage.committed)
+			assert.Equal(t, StatusApproved, storage.reviewed)
+			assert.Equal(t, tt.expectedTransfers, storage.transfers)
+			if tt.swap.SwapShiftRequestID != nil {
+				// the claimant's shift request is released before either transfer
+				assert.Equal(t, []int{*tt.swap.SwapShiftRequestID}, storage.released)
+			}
+			assert.Len(t, storage.cancelledOf, len(tt.expectedTransfers))
+			// the approval then a transfer per reassigned shift request
+			require.Len(t, storage.audits, 1+len(tt.expectedTransfers))
+			assert.Equal(t, "approve", storage.audits[0].Action)
+			assert.Equal(t, 7, storage.audits[0].EntityID)
+			for _, l := range storage.audits[1:] {
+				assert.Equal(t, "transfer", l.Action)
+				assert.Equal(t, audit.EntityShiftRequest, l.EntityType)
+				assert.JSONEq(t, fmt.Sprintf(`{"EmployeeID":%d}`, tt.expectedTransfers[l.EntityID]), string(*l.After))
+			}
+		})
+	}
+}
+
+func TestRejectSwap(t *testing.T) {
+	claimant := 5
+	storage := newMockStorage()
+	storage.swaps[7] = &st.ShiftSwap{ID: 7, ShiftRequestID: 10, OfferedBy: 4, Status: StatusClaimed, ClaimedBy: &claimant}
+	s := newShiftSwap(storage, mockAvailabilityChecker{}, nil)
+
+	assert.ErrorIs(t, s.RejectSwap(context.Background(), 8, Reviewer{EmployeeID: 1}), ErrSwapNotFound)
+	require.NoError(t, s.RejectSwap(context.Background(), 7, Reviewer{EmployeeID: 1}))
+	assert.Equal(t, StatusRejected, storage.reviewed)
+	assert.Empty(t, storage.transfers)
+}
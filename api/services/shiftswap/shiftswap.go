@@ -0,0 +1,122 @@
+package shiftswap
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	"payd/services/availability"
+	"payd/services/shift"
+	st "payd/storage"
+)
+
+const (
+	StatusOpen      = "OPEN"
+	StatusClaimed   = "CLAIMED"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusCancelled = "CANCELLED"
+)
+
+// the status of the shift requests being swapped
+const requestApproved = "APPROVED"
+
+var ErrRequestNotFound = errors.New("shift request not found")
+var ErrRequestNotApproved = errors.New("only approved shift requests can be offered")
+var ErrAlreadyOffered = errors.New("shift request is already offered")
+var ErrSwapNotFound = errors.New("shift swap not found")
+var ErrSwapNotOpen = errors.New("shift swap is not open")
+var ErrSwapNotClaimed = errors.New("shift swap is not claimed")
+var ErrSwapNotActive = errors.New("shift swap is not open or claimed")
+var ErrOwnSwap = errors.New("employees can't claim their own shift")
+var ErrRoleMismatch = errors.New("shift is not for the employee's primary role")
+var ErrShiftAlreadyStarted = errors.New("shift has already started")
+var ErrInvalidExchange = errors.New("the shift request given in exchange must be an approved request of the claimant for a shift of the same role")
+var ErrAssignmentChanged = errors.New("shift assignment changed since the offer")
+var ErrReviewNotAllowed = errors.New("reviewer may not review swaps for this role")
+
+type storage interface {
+	LockShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error)
+	ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]st.Shift, error)
+	CreateShiftSwap(ctx context.Context, shiftRequestId, offeredBy int) (int, error)
+	LockShiftSwapByID(ctx context.Context, id int) (*st.ShiftSwap, error)
+	ClaimShiftSwap(ctx context.Context, id, claimedBy int, swapShiftRequestId *int) error
+	ReviewShiftSwap(ctx context.Context, id int, status string, reviewedBy int) error
+	CancelShiftSwap(ctx context.Context, id, offeredBy int) (bool, error)
+	CancelActiveShiftSwapsByShiftRequestID(ctx context.Context, shiftRequestId, exceptId, reviewedBy int) (int64, error)
+	ListShiftSwapsByFilter(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error)
+	ListOpenShiftSwapsByRole(ctx context.Context, roleId int, locationIds []int, start time.Time) ([]st.ShiftSwapWithShiftDetails, error)
+	ReleaseShiftRequest(ctx context.Context, id int) error
+	TransferShiftRequest(ctx context.Context, id, employeeId int) error
+
+	audit.Recorder
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type ShiftSwapInterface interface {
+	OfferShift(ctx context.Context, employeeId, shiftRequestId int) (int, error)
+	ListOpenSwaps(ctx context.Context, employeeId, roleId int, locationIds []int) ([]st.ShiftSwapWithShiftDetails, error)
+	ListEmployeeSwaps(ctx context.Context, employeeId int, status string, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error)
+	ClaimSwap(ctx context.Context, employeeId, roleId int, locationIds []int, id int, swapShiftRequestId *int) ([]string, error)
+	CancelSwap(ctx context.Context, employeeId, id int) error
+
+	ListSwaps(ctx context.Context, filter st.ListShiftSwapFilter, start, end time.Time) ([]st.ShiftSwapWithShiftDetails, error)
+	ApproveSwap(ctx context.Context, id int, reviewer Reviewer) ([]string, error)
+	RejectSwap(ctx context.Context, id int, reviewer Reviewer) error
+}
+
+// availabilityChecker compares a shift with the employee's declared availability, see availability.Availability
+type availabilityChecker interface {
+	CheckShift(ctx context.Context, employeeId int, start, end time.Time) (bool, error)
+}
+
+// leaveChecker refuses the shifts during approved leave, see leave.Leave
+type leaveChecker interface {
+	CheckShift(ctx context.Context, employeeId, locationId int, start, end time.Time) error
+}
+
+type ShiftSwap struct {
+	storage      storage
+	availability availabilityChecker
+	leave        leaveChecker
+	now          func() time.Time
+}
+
+func NewShiftSwap(storage storage, availability availabilityChecker, leave leaveChecker) *ShiftSwap {
+	return &ShiftSwap{
+		storage:      storage,
+		availability: availability,
+		leave:        leave,
+		now:          time.Now,
+	}
+}
+
+// checkAssignee returns a *shift.ConflictError if the employee has another approved shift overlapping sh
+// besides the one they give away in exchange, nil if none,
+// the *leave.OnLeaveError or *availability.UnavailableError refusing it,
+// or the warnings of a shift outside the employee's weekly availability
+func (s *ShiftSwap) checkAssignee(ctx context.Context, employeeId int, sh, givenAway *st.Shift) ([]string, error) {
+	overlaps, err := s.storage.ListOverlappingApprovedShifts(ctx, employeeId, sh.StartTime, sh.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overlaps {
+		if o.ID != sh.ID && (givenAway == nil || o.ID != givenAway.ID) {
+			return nil, &shift.ConflictError{Err: shift.ErrEmployeeDoubleBooked, EmployeeID: employeeId, ShiftID: sh.ID, ConflictingShiftID: o.ID}
+		}
+	}
+	if err := s.leave.CheckShift(ctx, employeeId, sh.LocationID, sh.StartTime, sh.EndTime); err != nil {
+		return nil, err
+	}
+	outside, err := s.availability.CheckShift(ctx, employeeId, sh.StartTime, sh.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if outside {
+		return []string{availability.ErrOutsideAvailability.Error()}, nil
+	}
+	return nil, nil
+}
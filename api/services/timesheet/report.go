@@ -0,0 +1,177 @@
+package timesheet
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	st "payd/storage"
+)
+
+// maxRange bounds the period of a report
+const maxRange = 92 * 24 * time.Hour
+
+var ErrInvalidTimeRange = errors.New("start must be before end")
+var ErrRangeTooLong = errors.New("time range must be at most 92 days")
+
+// attendance of an approved shift
+const (
+	StatusUpcoming  = "UPCOMING"   // not clocked in yet and the shift hasn't ended
+	StatusClockedIn = "CLOCKED_IN" // not clocked out yet
+	StatusCompleted = "COMPLETED"
+	StatusNoShow    = "NO_SHOW"
+	StatusOnLeave   = "ON_LEAVE" // the employee took approved leave during the shift after it was approved
+)
+
+// Attendance compares an approved shift with its time entry
+type Attendance struct {
+	Request st.ShiftRequestWithShiftDetails
+	Entry   *st.TimeEntry // nil until the employee clocks in
+	Status  string
+	// from the shift start to the clock in, zero within the late grace window
+	Late time.Duration
+	// from the shift start, or the clock in when later, to the clock out less the breaks, zero until clocked out
+	Worked time.Duration
+	// worked beyond the length of the shift
+	Overtime time.Duration
+}
+
+func (a Attendance) Scheduled() time.Duration {
+	return a.Request.EndTime.Sub(a.Request.StartTime)
+}
+
+// Summary totals the attendance of an employee over a period
+type Summary struct {
+	EmployeeID   int
+	EmployeeName string
+	Shifts       int
+	Scheduled    time.Duration
+	Worked       time.Duration
+	LateShifts   int
+	Late         time.Duration
+	NoShows      int
+	Overtime     time.Duration
+}
+
+// ListAttendance returns the attendance of the approved shifts starting within the period, in shift order
+func (t *Timesheet) ListAttendance(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]Attendance, error) {
+	if !start.Before(end) {
+		return nil, ErrInvalidTimeRange
+	}
+	if end.Sub(start) > maxRange {
+		return nil, ErrRangeTooLong
+	}
+
+	filter.Status = requestApproved
+	requests, err := t.storage.ListShiftRequestsByFilterAndTimeRange(ctx, filter, start, end)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(requests))
+	for _, r := range requests {
+		ids = append(ids, r.ID)
+	}
+	entries, err := t.storage.ListTimeEntriesByShiftRequestIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byRequest := make(map[int]st.TimeEntry, len(entries))
+	for _, e := range entries {
+		byRequest[e.ShiftRequestID] = e
+	}
+
+	now := t.now()
+	res := make([]Attendance, 0, len(requests))
+	for _, r := range requests {
+		var entry *st.TimeEntry
+		if e, ok := byRequest[r.ID]; ok {
+			entry = &e
+		}
+		res = append(res, t.attendance(r, entry, now))
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i].Request, res[j].Request
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.ID < b.ID
+	})
+	return res, nil
+}
+
+// Summarize totals the attendance of every employee with approved shifts starting within the period, by name
+func (t *Timesheet) Summarize(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]Summary, error) {
+	attendance, err := t.ListAttendance(ctx, filter, start, end)
+	if err != nil {
+		return nil, err
+	}
+	byEmployee := map[int]*Summary{}
+	res := []*Summary{}
+	for _, a := range attendance {
+		s, ok := byEmployee[a.Request.EmployeeID]
+		if !ok {
+			s = &Summary{EmployeeID: a.Request.EmployeeID, EmployeeName: a.Request.EmployeeName}
+			byEmployee[a.Request.EmployeeID] = s
+			res = append(res, s)
+		}
+		s.Shifts++
+		s.Scheduled += a.Scheduled()
+		s.Worked += a.Worked
+		s.Overtime += a.Overtime
+		if a.Late > 0 {
+			s.LateShifts++
+			s.Late += a.Late
+		}
+		if a.Status == StatusNoShow {
+			s.NoShows++
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].EmployeeName != res[j].EmployeeName {
+			return res[i].EmployeeName < res[j].EmployeeName
+		}
+		return res[i].EmployeeID < res[j].EmployeeID
+	})
+	summaries := make([]Summary, 0, len(res))
+	for _, s := range res {
+		summaries = append(summaries, *s)
+	}
+	return summaries, nil
+}
+
+func (t *Timesheet) attendance(r st.ShiftRequestWithShiftDetails, entry *st.TimeEntry, now time.Time) Attendance {
+	a := Attendance{Request: r, Entry: entry}
+	if entry == nil {
+		switch {
+		case r.ConflictingLeaveRequestID != nil:
+			a.Status = StatusOnLeave
+		case now.Before(r.EndTime):
+			a.Status = StatusUpcoming
+		default:
+			a.Status = StatusNoShow
+		}
+		return a
+	}
+
+	if late := entry.ClockIn.Sub(r.StartTime); late > t.lateGrace {
+		a.Late = late
+	}
+	if entry.ClockOut == nil {
+		a.Status = StatusClockedIn
+		return a
+	}
+	a.Status = StatusCompleted
+	// clocking in early doesn't count as worked time
+	from := entry.ClockIn
+	if from.Before(r.StartTime) {
+		from = r.StartTime
+	}
+	if worked := entry.ClockOut.Sub(from) - entry.Breaks(); worked > 0 {
+		a.Worked = worked
+	}
+	if overtime := a.Worked - a.Scheduled(); overtime > 0 {
+		a.Overtime = overtime
+	}
+	return a
+}
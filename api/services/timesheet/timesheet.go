@@ -0,0 +1,221 @@
+package timesheet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+)
+
+const (
+	defaultEarlyGrace = 15 * time.Minute
+	defaultLateGrace  = 5 * time.Minute
+)
+
+// the status of the shift requests employees clock in for
+const requestApproved = "APPROVED"
+
+var ErrRequestNotFound = errors.New("shift request not found")
+var ErrRequestNotApproved = errors.New("only approved shift requests can be clocked in for")
+var ErrTooEarly = errors.New("too early to clock in for the shift")
+var ErrShiftEnded = errors.New("shift has already ended")
+var ErrAlreadyRecorded = errors.New("shift request already has a time entry")
+var ErrAlreadyClockedIn = errors.New("employee is already clocked in for another shift")
+var ErrNotClockedIn = errors.New("employee is not clocked in")
+var ErrAlreadyOnBreak = errors.New("employee is already on a break")
+var ErrNotOnBreak = errors.New("employee is not on a break")
+
+type storage interface {
+	LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error)
+	GetShiftByID(ctx context.Context, id int) (*st.Shift, error)
+	CreateTimeEntry(ctx context.Context, shiftRequestId, employeeId int, clockIn time.Time) (int, error)
+	LockOpenTimeEntryByEmployeeID(ctx context.Context, employeeId int) (*st.TimeEntry, error)
+	ClockOutTimeEntry(ctx context.Context, id int, clockOut time.Time) error
+	StartTimeEntryBreak(ctx context.Context, timeEntryId int, start time.Time) (int, error)
+	EndTimeEntryBreak(ctx context.Context, timeEntryId int, end time.Time) (bool, error)
+	ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error)
+	ListTimeEntriesByShiftRequestIDs(ctx context.Context, shiftRequestIds []int) ([]st.TimeEntry, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type TimesheetInterface interface {
+	ClockIn(ctx context.Context, employeeId, shiftRequestId int) (int, error)
+	ClockOut(ctx context.Context, employeeId int) error
+	StartBreak(ctx context.Context, employeeId int) error
+	EndBreak(ctx context.Context, employeeId int) error
+
+	ListAttendance(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]Attendance, error)
+	Summarize(ctx context.Context, filter st.ListShiftRequestFilter, start, end time.Time) ([]Summary, error)
+}
+
+type Timesheet struct {
+	storage storage
+	now     func() time.Time
+
+	// how long before the shift start employees may clock in
+	earlyGrace time.Duration
+	// how long after the shift start a clock in isn't late yet
+	lateGrace time.Duration
+}
+
+type Option func(*Timesheet)
+
+func NewTimesheet(storage storage, opts ...Option) *Timesheet {
+	t := &Timesheet{
+		storage:    storage,
+		now:        time.Now,
+		earlyGrace: defaultEarlyGrace,
+		lateGrace:  defaultLateGrace,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func WithEarlyGrace(grace time.Duration) Option {
+	return func(t *Timesheet) {
+		if grace > 0 {
+			t.earlyGrace = grace
+		}
+	}
+}
+
+func WithLateGrace(grace time.Duration) Option {
+	return func(t *Timesheet) {
+		if grace > 0 {
+			t.lateGrace = grace
+		}
+	}
+}
+
+// ClockIn starts the time entry of an approved shift request of the employee,
+// from the early grace window before the shift start until the shift end
+func (t *Timesheet) ClockIn(ctx context.Context, employeeId, shiftRequestId int) (id int, err error) {
+	tctx, err := t.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer t.storage.Finish(tctx, &err)
+
+	req, err := t.storage.LockShiftRequestByID(tctx, shiftRequestId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrRequestNotFound
+		}
+		return 0, err
+	}
+	if req.EmployeeID != employeeId {
+		return 0, ErrRequestNotFound
+	}
+	if req.Status != requestApproved {
+		return 0, ErrRequestNotApproved
+	}
+	sh, err := t.storage.GetShiftByID(tctx, req.ShiftID)
+	if err != nil {
+		return 0, err
+	}
+	now := t.now()
+	if now.Before(sh.StartTime.Add(-t.earlyGrace)) {
+		return 0, ErrTooEarly
+	}
+	if !now.Before(sh.EndTime) {
+		return 0, ErrShiftEnded
+	}
+
+	id, err = t.storage.CreateTimeEntry(tctx, req.ID, employeeId, now)
+	switch {
+	case errors.Is(err, st.ErrDuplicateTimeEntry):
+		return 0, ErrAlreadyRecorded
+	case errors.Is(err, st.ErrAlreadyClockedIn):
+		return 0, ErrAlreadyClockedIn
+	case err != nil:
+		return 0, err
+	}
+	entry := st.TimeEntry{ID: id, ShiftRequestID: req.ID, EmployeeID: employeeId, ClockIn: now}
+	return id, audit.Record(tctx, t.storage, "clock_in", audit.EntityTimeEntry, id, nil, entry)
+}
+
+// ClockOut ends the time entry the employee is clocked in for, along with the ongoing break
+func (t *Timesheet) ClockOut(ctx context.Context, employeeId int) (err error) {
+	tctx, err := t.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.storage.Finish(tctx, &err)
+
+	entry, err := t.openEntry(tctx, employeeId)
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	if err = t.storage.ClockOutTimeEntry(tctx, entry.ID, now); err != nil {
+		return err
+	}
+	return audit.Record(tctx, t.storage, "clock_out", audit.EntityTimeEntry, entry.ID, entry, map[string]interface{}{"ClockOut": now})
+}
+
+func (t *Timesheet) StartBreak(ctx context.Context, employeeId int) (err error) {
+	tctx, err := t.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.storage.Finish(tctx, &err)
+
+	entry, err := t.openEntry(tctx, employeeId)
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	_, err = t.storage.StartTimeEntryBreak(tctx, entry.ID, now)
+	if errors.Is(err, st.ErrBreakAlreadyStarted) {
+		return ErrAlreadyOnBreak
+	}
+	if err != nil {
+		return err
+	}
+	return audit.Record(tctx, t.storage, "start_break", audit.EntityTimeEntry, entry.ID,
+		map[string]interface{}{"OnBreak": false}, map[string]interface{}{"OnBreak": true, "BreakStart": now})
+}
+
+func (t *Timesheet) EndBreak(ctx context.Context, employeeId int) (err error) {
+	tctx, err := t.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.storage.Finish(tctx, &err)
+
+	entry, err := t.openEntry(tctx, employeeId)
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	ended, err := t.storage.EndTimeEntryBreak(tctx, entry.ID, now)
+	if err != nil {
+		return err
+	}
+	if !ended {
+		return ErrNotOnBreak
+	}
+	return audit.Record(tctx, t.storage, "end_break", audit.EntityTimeEntry, entry.ID,
+		map[string]interface{}{"OnBreak": true}, map[string]interface{}{"OnBreak": false, "BreakEnd": now})
+}
+
+// openEntry locks the time entry the employee is clocked in for
+func (t *Timesheet) openEntry(ctx context.Context, employeeId int) (*st.TimeEntry, error) {
+	entry, err := t.storage.LockOpenTimeEntryByEmployeeID(ctx, employeeId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotClockedIn
+		}
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,261 @@
+package timesheet
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	st "payd/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var shiftStart = time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+type mockStorage struct {
+	requests   map[int]*st.ShiftRequest
+	shifts     map[int]*st.Shift
+	open       *st.TimeEntry
+	onBreak    bool
+	createErr  error
+	clockedIn  time.Time
+	clockedOut time.Time
+	details    []st.ShiftRequestWithShiftDetails
+	entries    []st.TimeEntry
+	filter     st.ListShiftRequestFilter
+	audits     []st.AuditLog
+	committed  bool
+	rolledBack bool
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		requests: map[int]*st.ShiftRequest{
+			5: {ID: 5, EmployeeID: 4, ShiftID: 3, Status: requestApproved},
+			6: {ID: 6, EmployeeID: 4, ShiftID: 3, Status: "PENDING"},
+		},
+		shifts: map[int]*st.Shift{3: {ID: 3, StartTime: shiftStart, EndTime: shiftStart.Add(8 * time.Hour)}},
+	}
+}
+
+func (m *mockStorage) LockShiftRequestByID(ctx context.Context, id int) (*st.ShiftRequest, error) {
+	req, ok := m.requests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return req, nil
+}
+
+func (m *mockStorage) GetShiftByID(ctx context.Context, id int) (*st.Shift, error) {
+	return m.shifts[id], nil
+}
+
+func (m *mockStorage) CreateTimeEntry(ctx context.Context, shiftRequestId, employeeId int, clockIn time.Time) (int, error) {
+	if m.createErr != nil {
+		return 0, m.createErr
+	}
+	m.clockedIn = clockIn
+	return 7, nil
+}
+
+func (m *mockStorage) LockOpenTimeEntryByEmployeeID(ctx context.Context, employeeId int) (*st.TimeEntry, error) {
+	if m.open == nil {
+		return nil, sql.ErrNoRows
+	}
+	return m.open, nil
+}
+
+func (m *mockStorage) ClockOutTimeEntry(ctx context.Context, id int, clockOut time.Time) error {
+	m.clockedOut = clockOut
+	m.onBreak = false
+	return nil
+}
+
+func (m *mockStorage) StartTimeEntryBreak(ctx context.Context, timeEntryId int, start time.Time) (int, error) {
+	if m.onBreak {
+		return 0, st.ErrBreakAlreadyStarted
+	}
+	m.onBreak = true
+	return 1, nil
+}
+
+func (m *mockStorage) EndTimeEntryBreak(ctx context.Context, timeEntryId int, end time.Time) (bool, error) {
+	ended := m.onBreak
+	m.onBreak = false
+	return ended, nil
+}
+
+func (m *mockStorage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter st.ListShiftRequestFilter, start time.Time, end time.Time) ([]st.ShiftRequestWithShiftDetails, error) {
+	m.filter = filter
+	return m.details, nil
+}
+
+func (m *mockStorage) ListTimeEntriesByShiftRequestIDs(ctx context.Context, shiftRequestIds []int) ([]st.TimeEntry, error) {
+	return m.entries, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func newTimesheet(storage storage, now time.Time, opts ...Option) *Timesheet {
+	t := NewTimesheet(storage, opts...)
+	t.now = func() time.Time { return now }
+	return t
+}
+
+func TestClockIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId int
+		now       time.Time
+		createErr error
+		opts      []Option
+		wantErr   error
+	}{
+		{name: "within the early grace window", requestId: 5, now: shiftStart.Add(-10 * time.Minute)},
+		{name: "late", requestId: 5, now: shiftStart.Add(time.Hour)},
+		{name: "too early", requestId: 5, now: shiftStart.Add(-20 * time.Minute), wantErr: ErrTooEarly},
+		{name: "wider early grace window", requestId: 5, now: shiftStart.Add(-20 * time.Minute), opts: []Option{WithEarlyGrace(30 * time.Minute)}},
+		{name: "shift ended", requestId: 5, now: shiftStart.Add(8 * time.Hour), wantErr: ErrShiftEnded},
+		{name: "not approved", requestId: 6, now: shiftStart, wantErr: ErrRequestNotApproved},
+		{name: "unknown request", requestId: 9, now: shiftStart, wantErr: ErrRequestNotFound},
+		{name: "already recorded", requestId: 5, now: shiftStart, createErr: st.ErrDuplicateTimeEntry, wantErr: ErrAlreadyRecorded},
+		{name: "clocked in for another shift", requestId: 5, now: shiftStart, createErr: st.ErrAlreadyClockedIn, wantErr: ErrAlreadyClockedIn},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := newMockStorage()
+			storage.createErr = tc.createErr
+			id, err := newTimesheet(storage, tc.now, tc.opts...).ClockIn(context.Background(), 4, tc.requestId)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.True(t, storage.rolledBack)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 7, id)
+			assert.Equal(t, tc.now, storage.clockedIn)
+			assert.True(t, storage.committed)
+		})
+	}
+
+	t.Run("request of another employee", func(t *testing.T) {
+		_, err := newTimesheet(newMockStorage(), shiftStart).ClockIn(context.Background(), 8, 5)
+		assert.ErrorIs(t, err, ErrRequestNotFound)
+	})
+}
+
+func TestClockOutAndBreaks(t *testing.T) {
+	storage := newMockStorage()
+	ts := newTimesheet(storage, shiftStart.Add(4*time.Hour))
+
+	assert.ErrorIs(t, ts.ClockOut(context.Background(), 4), ErrNotClockedIn)
+	assert.ErrorIs(t, ts.StartBreak(context.Background(), 4), ErrNotClockedIn)
+
+	storage.open = &st.TimeEntry{ID: 7, ShiftRequestID: 5, EmployeeID: 4, ClockIn: shiftStart}
+	assert.ErrorIs(t, ts.EndBreak(context.Background(), 4), ErrNotOnBreak)
+	require.NoError(t, ts.StartBreak(context.Background(), 4))
+	assert.ErrorIs(t, ts.StartBreak(context.Background(), 4), ErrAlreadyOnBreak)
+	require.NoError(t, ts.EndBreak(context.Background(), 4))
+
+	require.NoError(t, ts.ClockOut(context.Background(), 4))
+	assert.Equal(t, shiftStart.Add(4*time.Hour), storage.clockedOut)
+
+	var actions []string
+	for _, l := range storage.audits {
+		assert.Equal(t, 7, l.EntityID)
+		actions = append(actions, l.Action)
+	}
+	assert.Equal(t, []string{"start_break", "end_break", "clock_out"}, actions)
+}
+
+func TestListAttendance(t *testing.T) {
+	day := func(n int) st.ShiftRequestWithShiftDetails {
+		start := shiftStart.AddDate(0, 0, n)
+		return st.ShiftRequestWithShiftDetails{ID: 10 + n, EmployeeID: 4, EmployeeName: "Alice", ShiftID: 20 + n,
+			StartTime: start, EndTime: start.Add(8 * time.Hour)}
+	}
+	at := func(n int, d time.Duration) *time.Time {
+		t := shiftStart.AddDate(0, 0, n).Add(d)
+		return &t
+	}
+	leaveId := 2
+	onLeave := day(3)
+	onLeave.ConflictingLeaveRequestID = &leaveId
+	other := day(2)
+	other.ID, other.EmployeeID, other.EmployeeName = 30, 5, "Bob"
+
+	storage := newMockStorage()
+	storage.details = []st.ShiftRequestWithShiftDetails{day(4), day(0), day(1), day(2), onLeave, day(5), other}
+	storage.entries = []st.TimeEntry{
+		// on time, 30 minutes of break and an hour past the end
+		{ShiftRequestID: 10, ClockIn: *at(0, -10*time.Minute), ClockOut: at(0, 9*time.Hour), BreakSeconds: 1800},
+		// late
+		{ShiftRequestID: 11, ClockIn: *at(1, 20*time.Minute), ClockOut: at(1, 8*time.Hour)},
+		// within the late grace window, still clocked in
+		{ShiftRequestID: 14, ClockIn: *at(4, 4*time.Minute)},
+	}
+	ts := newTimesheet(storage, shiftStart.AddDate(0, 0, 4).Add(2*time.Hour))
+
+	start, end := shiftStart.AddDate(0, 0, -1), shiftStart.AddDate(0, 0, 7)
+	attendance, err := ts.ListAttendance(context.Background(), st.ListShiftRequestFilter{RoleIDs: []int{2}}, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, st.ListShiftRequestFilter{RoleIDs: []int{2}, Status: requestApproved}, storage.filter)
+
+	require.Len(t, attendance, 7)
+	statuses := []string{}
+	for _, a := range attendance {
+		statuses = append(statuses, a.Status)
+	}
+	assert.Equal(t, []string{StatusCompleted, StatusCompleted, StatusNoShow, StatusNoShow, StatusOnLeave, StatusClockedIn, StatusUpcoming}, statuses)
+
+	assert.Zero(t, attendance[0].Late)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, attendance[0].Worked)
+	assert.Equal(t, 30*time.Minute, attendance[0].Overtime)
+	assert.Equal(t, 20*time.Minute, attendance[1].Late)
+	assert.Equal(t, 7*time.Hour+40*time.Minute, attendance[1].Worked)
+	assert.Zero(t, attendance[1].Overtime)
+	assert.Zero(t, attendance[5].Late)
+
+	summaries, err := ts.Summarize(context.Background(), st.ListShiftRequestFilter{}, start, end)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, Summary{
+		EmployeeID: 4, EmployeeName: "Alice", Shifts: 6, Scheduled: 48 * time.Hour,
+		Worked: 16*time.Hour + 10*time.Minute, LateShifts: 1, Late: 20 * time.Minute, NoShows: 1, Overtime: 30 * time.Minute,
+	}, summaries[0])
+	assert.Equal(t, "Bob", summaries[1].EmployeeName)
+	assert.Equal(t, 1, summaries[1].NoShows)
+
+	_, err = ts.ListAttendance(context.Background(), st.ListShiftRequestFilter{}, end, start)
+	assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	_, err = ts.ListAttendance(context.Background(), st.ListShiftRequestFilter{}, start, start.AddDate(0, 4, 0))
+	assert.ErrorIs(t, err, ErrRangeTooLong)
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	st "payd/storage"
+	"payd/util"
+)
+
+// the headers of a delivery, see Sign for the signature
+const (
+	EventHeader     = "X-Payd-Event"
+	DeliveryHeader  = "X-Payd-Delivery"
+	TimestampHeader = "X-Payd-Timestamp"
+	SignatureHeader = "X-Payd-Signature"
+)
+
+const (
+	// events fanned out and deliveries attempted per batch
+	batchSize = 20
+	// the longest a delivery stays claimed by a dispatcher without a result
+	claimLease = 5 * time.Minute
+	// the part of the response body kept as the error of a failed attempt
+	maxErrorBody = 512
+)
+
+// Body is the JSON posted to the webhooks
+type Body struct {
+	ID        int64           `json:"id"` // the event id, the same on every retry
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign returns the signature of the delivery body sent at timestamp (unix seconds): "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret. receivers recompute it to authenticate
+// the delivery, and reject old timestamps to prevent replays
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatch fans out the new outbox events to the webhooks and attempts the due deliveries.
+// returns the number of deliveries attempted
+func (w *Webhook) Dispatch(ctx context.Context) (int, error) {
+	for {
+		dispatched, err := w.storage.FanOutOutboxEvents(ctx, w.now(), batchSize)
+		if err != nil {
+			return 0, err
+		}
+		if dispatched < batchSize {
+			break
+		}
+	}
+
+	attempted := 0
+	for {
+		deliveries, err := w.storage.ClaimWebhookDeliveries(ctx, w.now(), claimLease, batchSize)
+		if err != nil {
+			return attempted, err
+		}
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			wg.Add(1)
+			go func(d st.WebhookDelivery) {
+				defer wg.Done()
+				w.attempt(ctx, d)
+			}(d)
+		}
+		wg.Wait()
+		attempted += len(deliveries)
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+	}
+}
+
+// StartDispatcher dispatches now and then on every tick until ctx is done
+func (w *Webhook) StartDispatcher(ctx context.Context, tick time.Duration) {
+	dispatch := func() {
+		attempted, err := w.Dispatch(ctx)
+		if err != nil {
+			util.Log().WithError(err).Error("periodic webhook dispatch failed")
+		}
+		if attempted > 0 {
+			util.Log().WithField("attempted", attempted).Debug("webhooks dispatched")
+		}
+	}
+	dispatch()
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				dispatch()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// attempt posts the delivery and records the outcome, a failed attempt is retried after the backoff
+// unless it was the last one
+func (w *Webhook) attempt(ctx context.Context, d st.WebhookDelivery) {
+	log := util.Log().WithContext(ctx).WithField("delivery_id", d.ID).WithField("webhook_id", d.WebhookID)
+
+	statusCode, err := w.post(ctx, d)
+	if err == nil {
+		if err := w.storage.MarkWebhookDelivered(ctx, d.ID, statusCode, w.now()); err != nil {
+			log.WithError(err).Error("record webhook delivery")
+		}
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	var next *time.Time
+	attempts := d.Attempts + 1
+	if attempts < w.maxAttempts {
+		at := w.now().Add(w.retryDelay(attempts))
+		next = &at
+	} else {
+		log.WithError(err).Warn("webhook delivery given up")
+	}
+	if err := w.storage.MarkWebhookAttemptFailed(ctx, d.ID, code, err.Error(), next); err != nil {
+		log.WithError(err).Error("record failed webhook attempt")
+	}
+}
+
+// post sends the signed delivery, any response but a 2xx is a failure.
+// returns the status code of the response, 0 without one
+func (w *Webhook) post(ctx context.Context, d st.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(Body{ID: d.EventID, Type: d.EventType, CreatedAt: d.EventCreatedAt, Data: d.Payload})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := w.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(d.WebhookSecret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+}
+
+// retryDelay is the backoff after the attempts, doubled on every attempt up to maxBackoff
+func (w *Webhook) retryDelay(attempts int) time.Duration {
+	delay := w.backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.maxBackoff {
+			return w.maxBackoff
+		}
+	}
+	return delay
+}
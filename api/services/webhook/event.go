@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	st "payd/storage"
+	"payd/util"
+)
+
+// the types of the roster events delivered to the webhooks
+const (
+	ShiftCreated          = "shift.created"
+	ShiftDeleted          = "shift.deleted"
+	ShiftRequestSubmitted = "shift_request.submitted"
+	ShiftRequestApproved  = "shift_request.approved"
+	ShiftRequestRejected  = "shift_request.rejected"
+	EmployeeActivated     = "employee.activated"
+)
+
+// EventTypes lists every event type a webhook can subscribe to
+var EventTypes = []string{
+	ShiftCreated, ShiftDeleted,
+	ShiftRequestSubmitted, ShiftRequestApproved, ShiftRequestRejected,
+	EmployeeActivated,
+}
+
+// ShiftPayload is the data of the shift events
+type ShiftPayload struct {
+	ID         int       `json:"id"`
+	RoleID     int       `json:"roleId"`
+	LocationID int       `json:"locationId"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	Headcount  int       `json:"headcount"`
+	TemplateID *int      `json:"templateId,omitempty"` // the template the shift was generated from
+}
+
+func NewShiftPayload(sh st.Shift) ShiftPayload {
+	return ShiftPayload{
+		ID:         sh.ID,
+		RoleID:     sh.RoleID,
+		LocationID: sh.LocationID,
+		StartTime:  sh.StartTime,
+		EndTime:    sh.EndTime,
+		Headcount:  sh.Headcount,
+		TemplateID: sh.TemplateID,
+	}
+}
+
+// ShiftRequestPayload is the data of the shift request events
+type ShiftRequestPayload struct {
+	ID         int    `json:"id"`
+	EmployeeID int    `json:"employeeId"`
+	ShiftID    int    `json:"shiftId"`
+	Status     string `json:"status"`
+	ReviewedBy *int   `json:"reviewedBy,omitempty"`
+}
+
+// EmployeePayload is the data of the employee events
+type EmployeePayload struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	PrimaryRole int    `json:"primaryRole"`
+	LocationID  int    `json:"locationId"`
+}
+
+// Publisher stores the events in the outbox, implemented by the storage
+type Publisher interface {
+	InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error
+}
+
+// Publish writes the event on the entity to the outbox, along with the request id of ctx.
+// call it with the transactional context of the change so that the event is only delivered if the change is committed
+func Publish(ctx context.Context, p Publisher, eventType string, entityId int, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	e := st.OutboxEvent{EventType: eventType, EntityID: entityId, Payload: data}
+	if id := util.RequestID(ctx); id != "" {
+		e.RequestID = &id
+	}
+	return p.InsertOutboxEvent(ctx, e)
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"payd/services/audit"
+	st "payd/storage"
+)
+
+// SecretPrefix starts every webhook secret
+const SecretPrefix = "whsec_"
+
+const (
+	defaultMaxAttempts = 8
+	defaultBackoff     = 30 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = 10 * time.Second
+	// maxPageSize bounds the page of ListDeliveries
+	maxPageSize = 500
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+var ErrDeliveryNotFound = errors.New("dead webhook delivery not found")
+var ErrInvalidURL = errors.New("webhook url must be an absolute http or https url")
+var ErrUnknownEventType = errors.New("unknown event type")
+var ErrInvalidStatus = errors.New("status must be PENDING, DELIVERED or DEAD")
+var ErrInvalidPage = errors.New("limit must be within 1-500 and offset must not be negative")
+
+type storage interface {
+	CreateWebhook(ctx context.Context, w st.Webhook) (int, error)
+	ListWebhooks(ctx context.Context) ([]st.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) (*st.Webhook, error)
+	FanOutOutboxEvents(ctx context.Context, now time.Time, limit int) (int, error)
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]st.WebhookDelivery, error)
+	MarkWebhookDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error
+	MarkWebhookAttemptFailed(ctx context.Context, id int64, statusCode *int, lastError string, nextAttemptAt *time.Time) error
+	RetryWebhookDelivery(ctx context.Context, id int64, now time.Time) (bool, error)
+	ListWebhookDeliveries(ctx context.Context, filter st.ListWebhookDeliveryFilter) ([]st.WebhookDelivery, int, error)
+
+	audit.Recorder
+
+	NewTransacton(ctx context.Context) (context.Context, error)
+	Finish(ctx context.Context, err *error)
+}
+
+type WebhookInterface interface {
+	CreateWebhook(ctx context.Context, createdBy int, url string, eventTypes []string) (int, string, error)
+	ListWebhooks(ctx context.Context) ([]st.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, filter st.ListWebhookDeliveryFilter) ([]st.WebhookDelivery, int, error)
+	RetryDelivery(ctx context.Context, id int64) error
+}
+
+type Webhook struct {
+	storage     storage
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+}
+
+type Option func(*Webhook)
+
+func NewWebhook(storage storage, opts ...Option) *Webhook {
+	w := &Webhook{
+		storage:     storage,
+		client:      &http.Client{Timeout: defaultTimeout},
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// WithClient sets the client delivering the webhooks, its timeout bounds every attempt
+func WithClient(client *http.Client) Option {
+	return func(w *Webhook) {
+		if client != nil {
+			w.client = client
+		}
+	}
+}
+
+// WithMaxAttempts sets the attempts after which a delivery is given up on, defaults to 8
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(w *Webhook) {
+		if maxAttempts > 0 {
+			w.maxAttempts = maxAttempts
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled on every retry up to max.
+// defaults to 30 seconds up to an hour
+func WithBackoff(backoff, max time.Duration) Option {
+	return func(w *Webhook) {
+		if backoff > 0 {
+			w.backoff = backoff
+		}
+		if max >= w.backoff {
+			w.maxBackoff = max
+		}
+	}
+}
+
+// CreateWebhook registers the endpoint for the event types, every event type when empty.
+// returns the id and the secret signing the deliveries
+func (w *Webhook) CreateWebhook(ctx context.Context, createdBy int, endpoint string, eventTypes []string) (id int, secret string, err error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return 0, "", ErrInvalidURL
+	}
+	for _, eventType := range eventTypes {
+		if !knownEventType(eventType) {
+			return 0, "", fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+		}
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return 0, "", err
+	}
+	secret = SecretPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	tctx, err := w.storage.NewTransacton(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer w.storage.Finish(tctx, &err)
+
+	rec := st.Webhook{URL: endpoint, Secret: secret, EventTypes: eventTypes, CreatedBy: createdBy}
+	if id, err = w.storage.CreateWebhook(tctx, rec); err != nil {
+		return 0, "", err
+	}
+	// the secret stays out of the log
+	err = audit.Record(tctx, w.storage, "create", audit.EntityWebhook, id, nil, map[string]interface{}{
+		"URL":        rec.URL,
+		"EventTypes": rec.EventTypes,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return id, secret, nil
+}
+
+func (w *Webhook) ListWebhooks(ctx context.Context) ([]st.Webhook, error) {
+	return w.storage.ListWebhooks(ctx)
+}
+
+// DeleteWebhook stops the deliveries to the webhook, the pending ones are left as they are
+func (w *Webhook) DeleteWebhook(ctx context.Context, id int) (err error) {
+	tctx, err := w.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer w.storage.Finish(tctx, &err)
+
+	deleted, err := w.storage.DeleteWebhook(tctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == nil {
+		return ErrWebhookNotFound
+	}
+	return audit.Record(tctx, w.storage, "delete", audit.EntityWebhook, id, map[string]interface{}{
+		"URL":        deleted.URL,
+		"EventTypes": deleted.EventTypes,
+	}, nil)
+}
+
+// ListDeliveries returns a page of the deliveries matching the filter, newest first, and the number of matching
+// deliveries. the DEAD ones are the dead letters, given up on after the last attempt
+func (w *Webhook) ListDeliveries(ctx context.Context, filter st.ListWebhookDeliveryFilter) ([]st.WebhookDelivery, int, error) {
+	switch filter.Status {
+	case "", st.WebhookDeliveryPending, st.WebhookDeliveryDelivered, st.WebhookDeliveryDead:
+	default:
+		return nil, 0, ErrInvalidStatus
+	}
+	if filter.Limit <= 0 || filter.Limit > maxPageSize || filter.Offset < 0 {
+		return nil, 0, ErrInvalidPage
+	}
+	return w.storage.ListWebhookDeliveries(ctx, filter)
+}
+
+// RetryDelivery queues a DEAD delivery again, it gets all its attempts back
+func (w *Webhook) RetryDelivery(ctx context.Context, id int64) (err error) {
+	tctx, err := w.storage.NewTransacton(ctx)
+	if err != nil {
+		return err
+	}
+	defer w.storage.Finish(tctx, &err)
+
+	retried, err := w.storage.RetryWebhookDelivery(tctx, id, w.now())
+	if err != nil {
+		return err
+	}
+	if !retried {
+		return ErrDeliveryNotFound
+	}
+	return audit.Record(tctx, w.storage, "retry", audit.EntityWebhookDelivery, int(id),
+		map[string]interface{}{"Status": st.WebhookDeliveryDead},
+		map[string]interface{}{"Status": st.WebhookDeliveryPending, "Attempts": 0})
+}
+
+func knownEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	st "payd/storage"
+	"payd/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attemptResult struct {
+	statusCode *int
+	lastError  string
+	next       *time.Time
+}
+
+type mockStorage struct {
+	mu         sync.Mutex
+	webhooks   []st.Webhook
+	deleted    *st.Webhook
+	fannedOut  int
+	due        []st.WebhookDelivery
+	delivered  map[int64]int
+	failed     map[int64]attemptResult
+	retried    bool
+	filter     st.ListWebhookDeliveryFilter
+	audits     []st.AuditLog
+	events     []st.OutboxEvent
+	committed  bool
+	rolledBack bool
+}
+
+func (m *mockStorage) CreateWebhook(ctx context.Context, w st.Webhook) (int, error) {
+	m.webhooks = append(m.webhooks, w)
+	return len(m.webhooks), nil
+}
+
+func (m *mockStorage) ListWebhooks(ctx context.Context) ([]st.Webhook, error) {
+	return m.webhooks, nil
+}
+
+func (m *mockStorage) DeleteWebhook(ctx context.Context, id int) (*st.Webhook, error) {
+	return m.deleted, nil
+}
+
+func (m *mockStorage) FanOutOutboxEvents(ctx context.Context, now time.Time, limit int) (int, error) {
+	m.fannedOut++
+	return 0, nil
+}
+
+// ClaimWebhookDeliveries hands out the due deliveries once
+func (m *mockStorage) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]st.WebhookDelivery, error) {
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *mockStorage) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered[id] = statusCode
+	return nil
+}
+
+func (m *mockStorage) MarkWebhookAttemptFailed(ctx context.Context, id int64, statusCode *int, lastError string, nextAttemptAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed[id] = attemptResult{statusCode: statusCode, lastError: lastError, next: nextAttemptAt}
+	return nil
+}
+
+func (m *mockStorage) RetryWebhookDelivery(ctx context.Context, id int64, now time.Time) (bool, error) {
+	return m.retried, nil
+}
+
+func (m *mockStorage) ListWebhookDeliveries(ctx context.Context, filter st.ListWebhookDeliveryFilter) ([]st.WebhookDelivery, int, error) {
+	m.filter = filter
+	return nil, 0, nil
+}
+
+func (m *mockStorage) InsertAuditLog(ctx context.Context, l st.AuditLog) error {
+	m.audits = append(m.audits, l)
+	return nil
+}
+
+func (m *mockStorage) InsertOutboxEvent(ctx context.Context, e st.OutboxEvent) error {
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *mockStorage) NewTransacton(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *mockStorage) Commit(ctx context.Context) error {
+	m.committed = true
+	return nil
+}
+
+func (m *mockStorage) Rollback(ctx context.Context) error {
+	m.rolledBack = true
+	return nil
+}
+
+func (m *mockStorage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		_ = m.Rollback(ctx)
+		return
+	}
+	*err = m.Commit(ctx)
+}
+
+func newMockStorage(due ...st.WebhookDelivery) *mockStorage {
+	return &mockStorage{due: due, delivered: map[int64]int{}, failed: map[int64]attemptResult{}}
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver answers every delivery with the status and keeps what it received
+func receiver(t *testing.T, status int) (*httptest.Server, chan received) {
+	ch := make(chan received, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		ch <- received{header: r.Header, body: body}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("try later"))
+	}))
+	t.Cleanup(server.Close)
+	return server, ch
+}
+
+func TestDispatch(t *testing.T) {
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	delivery := func(url string, attempts int) st.WebhookDelivery {
+		return st.WebhookDelivery{ID: 11, EventID: 7, EventType: ShiftCreated, Payload: json.RawMessage(`{"id":3}`),
+			EventCreatedAt: now.Add(-time.Minute), WebhookID: 2, WebhookURL: url, WebhookSecret: "whsec_test", Attempts: attempts}
+	}
+
+	t.Run("signed delivery", func(t *testing.T) {
+		server, ch := receiver(t, http.StatusNoContent)
+		storage := newMockStorage(delivery(server.URL, 0))
+		svc := NewWebhook(storage)
+		svc.now = func() time.Time { return now }
+
+		attempted, err := svc.Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempted)
+		assert.Equal(t, 1, storage.fannedOut)
+		assert.Equal(t, map[int64]int{11: http.StatusNoContent}, storage.delivered)
+
+		got := <-ch
+		assert.Equal(t, ShiftCreated, got.header.Get(EventHeader))
+		assert.Equal(t, "11", got.header.Get(DeliveryHeader))
+		timestamp, err := strconv.ParseInt(got.header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, now.Unix(), timestamp)
+		assert.Equal(t, Sign("whsec_test", timestamp, got.body), got.header.Get(SignatureHeader))
+		assert.NotEqual(t, Sign("whsec_other", timestamp, got.body), got.header.Get(SignatureHeader))
+		assert.JSONEq(t, `{"id":7,"type":"shift.created","createdAt":"2025-06-01T08:59:00Z","data":{"id":3}}`, string(got.body))
+	})
+
+	t.Run("failed attempt is retried after the backoff", func(t *testing.T) {
+		server, _ := receiver(t, http.StatusServiceUnavailable)
+		storage := newMockStorage(delivery(server.URL, 2))
+		svc := NewWebhook(storage, WithBackoff(time.Minute, time.Hour))
+		svc.now = func() time.Time { return now }
+
+		_, err := svc.Dispatch(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, storage.failed, int64(11))
+		res := storage.failed[11]
+		require.NotNil(t, res.statusCode)
+		assert.Equal(t, http.StatusServiceUnavailable, *res.statusCode)
+		assert.Contains(t, res.lastError, "try later")
+		require.NotNil(t, res.next)
+		// third attempt
+		assert.Equal(t, now.Add(4*time.Minute), *res.next)
+	})
+
+	t.Run("last attempt gives up", func(t *testing.T) {
+		server, _ := receiver(t, http.StatusInternalServerError)
+		storage := newMockStorage(delivery(server.URL, 2))
+		svc := NewWebhook(storage, WithMaxAttempts(3))
+		svc.now = func() time.Time { return now }
+
+		_, err := svc.Dispatch(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, storage.failed, int64(11))
+		assert.Nil(t, storage.failed[11].next)
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		server, _ := receiver(t, http.StatusOK)
+		server.Close()
+		storage := newMockStorage(delivery(server.URL, 0))
+		svc := NewWebhook(storage)
+		svc.now = func() time.Time { return now }
+
+		_, err := svc.Dispatch(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, storage.failed, int64(11))
+		assert.Nil(t, storage.failed[11].statusCode)
+		assert.NotNil(t, storage.failed[11].next)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	svc := NewWebhook(newMockStorage(), WithBackoff(30*time.Second, 5*time.Minute))
+
+	assert.Equal(t, 30*time.Second, svc.retryDelay(1))
+	assert.Equal(t, time.Minute, svc.retryDelay(2))
+	assert.Equal(t, 4*time.Minute, svc.retryDelay(4))
+	assert.Equal(t, 5*time.Minute, svc.retryDelay(5))
+	assert.Equal(t, 5*time.Minute, svc.retryDelay(20))
+}
+
+func TestCreateWebhook(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		storage := newMockStorage()
+		id, secret, err := NewWebhook(storage).CreateWebhook(context.Background(), 1, "https://pos.example.com/hooks", []string{ShiftCreated})
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+		assert.Regexp(t, "^"+SecretPrefix, secret)
+		assert.Equal(t, secret, storage.webhooks[0].Secret)
+		assert.True(t, storage.committed)
+		require.Len(t, storage.audits, 1)
+		assert.NotContains(t, string(*storage.audits[0].After), secret)
+	})
+
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		wantErr    error
+	}{
+		{name: "relative url", url: "/hooks", wantErr: ErrInvalidURL},
+		{name: "unsupported scheme", url: "ftp://pos.example.com", wantErr: ErrInvalidURL},
+		{name: "unknown event type", url: "https://pos.example.com", eventTypes: []string{"shift.moved"}, wantErr: ErrUnknownEventType},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := newMockStorage()
+			_, _, err := NewWebhook(storage).CreateWebhook(context.Background(), 1, tc.url, tc.eventTypes)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Empty(t, storage.webhooks)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	storage := newMockStorage()
+	err := NewWebhook(storage).DeleteWebhook(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.True(t, storage.rolledBack)
+
+	storage = newMockStorage()
+	storage.deleted = &st.Webhook{ID: 3, URL: "https://pos.example.com"}
+	require.NoError(t, NewWebhook(storage).DeleteWebhook(context.Background(), 3))
+	require.Len(t, storage.audits, 1)
+	assert.Equal(t, "delete", storage.audits[0].Action)
+}
+
+func TestListDeliveries(t *testing.T) {
+	storage := newMockStorage()
+	svc := NewWebhook(storage)
+
+	_, _, err := svc.ListDeliveries(context.Background(), st.ListWebhookDeliveryFilter{Status: st.WebhookDeliveryDead, Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, st.WebhookDeliveryDead, storage.filter.Status)
+
+	_, _, err = svc.ListDeliveries(context.Background(), st.ListWebhookDeliveryFilter{Status: "LOST", Limit: 50})
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+	_, _, err = svc.ListDeliveries(context.Background(), st.ListWebhookDeliveryFilter{Limit: maxPageSize + 1})
+	assert.ErrorIs(t, err, ErrInvalidPage)
+}
+
+func TestRetryDelivery(t *testing.T) {
+	storage := newMockStorage()
+	assert.ErrorIs(t, NewWebhook(storage).RetryDelivery(context.Background(), 11), ErrDeliveryNotFound)
+
+	storage = newMockStorage()
+	storage.retried = true
+	require.NoError(t, NewWebhook(storage).RetryDelivery(context.Background(), 11))
+	require.Len(t, storage.audits, 1)
+	assert.Equal(t, "retry", storage.audits[0].Action)
+}
+
+func TestPublish(t *testing.T) {
+	storage := newMockStorage()
+	ctx := util.WithRequestID(context.Background(), "req-1")
+
+	err := Publish(ctx, storage, ShiftRequestApproved, 5, ShiftRequestPayload{ID: 5, EmployeeID: 4, ShiftID: 3, Status: "APPROVED"})
+	require.NoError(t, err)
+	require.Len(t, storage.events, 1)
+	e := storage.events[0]
+	assert.Equal(t, ShiftRequestApproved, e.EventType)
+	assert.Equal(t, 5, e.EntityID)
+	assert.JSONEq(t, `{"id":5,"employeeId":4,"shiftId":3,"status":"APPROVED"}`, string(e.Payload))
+	require.NotNil(t, e.RequestID)
+	assert.Equal(t, "req-1", *e.RequestID)
+}
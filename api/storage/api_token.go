@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type APIToken struct {
+	ID             int    `db:"id"`
+	Name           string `db:"name"`
+	TokenHash      string `db:"token_hash"`
+	Prefix         string `db:"prefix"`
+	EmployeeID     int    `db:"employee_id"`
+	EmployeeName   string `db:"employee_name"`
+	EmployeeStatus string `db:"employee_status"` // status of the employee the token acts as
+	EmployeeRoleID int    `db:"employee_role_id"`
+	// the locations of the employee, see Employee
+	EmployeeLocationID         int            `db:"employee_location_id"`
+	EmployeeManagedLocationIDs pq.Int64Array  `db:"employee_managed_location_ids"`
+	Role                       string         `db:"role"`
+	Scopes                     pq.StringArray `db:"scopes"`
+	ExpiresAt                  time.Time      `db:"expires_at"`
+	LastUsedAt                 *time.Time     `db:"last_used_at"`
+	CreatedBy                  int            `db:"created_by"`
+	CreatedAt                  time.Time      `db:"created_at"`
+	RevokedAt                  *time.Time     `db:"revoked_at"`
+}
+
+const apiTokenColumns = `t.id, t.name, t.token_hash, t.prefix, t.employee_id, e.name AS employee_name,
+	e.status AS employee_status, e.role_id AS employee_role_id, e.location_id AS employee_location_id,
+	ARRAY(SELECT el.location_id FROM employee_locations el WHERE el.employee_id = e.id ORDER BY el.location_id)
+		AS employee_managed_location_ids,
+	t.role, t.scopes, t.expires_at, t.last_used_at,
+	t.created_by, t.created_at, t.revoked_at`
+
+// CreateAPIToken stores the token, the expiration is expected in UTC
+func (s *Storage) CreateAPIToken(ctx context.Context, t APIToken) (int, error) {
+	var id int
+	query := `
+		INSERT INTO api_tokens (name, token_hash, prefix, employee_id, role, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, t.Name, t.TokenHash, t.Prefix, t.EmployeeID, t.Role, t.Scopes,
+		t.ExpiresAt, t.CreatedBy).Scan(&id)
+	return id, err
+}
+
+func (s *Storage) SelectAPITokenByHash(ctx context.Context, tokenHash string) (*APIToken, error) {
+	var rec APIToken
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN employees e ON e.id = t.employee_id WHERE t.token_hash = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, tokenHash)
+	return &rec, err
+}
+
+func (s *Storage) SelectAPITokenByID(ctx context.Context, id int) (*APIToken, error) {
+	var rec APIToken
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN employees e ON e.id = t.employee_id WHERE t.id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// ListAPITokens returns every token including the revoked and expired ones, newest first
+func (s *Storage) ListAPITokens(ctx context.Context) ([]APIToken, error) {
+	var recs []APIToken
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN employees e ON e.id = t.employee_id ORDER BY t.id DESC`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+// TouchAPIToken records the use of the token, at most once per interval to keep requests from writing every time
+func (s *Storage) TouchAPIToken(ctx context.Context, id int, now time.Time, interval time.Duration) error {
+	query := `UPDATE api_tokens SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at <= $3)`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id, now, now.Add(-interval))
+	return err
+}
+
+// RevokeAPIToken returns false if the token doesn't exist or is already revoked
+func (s *Storage) RevokeAPIToken(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		employeeID, err := st.CreateNewEmployee(ctx, "Payroll", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		id, err := st.CreateAPIToken(ctx, APIToken{Name: "payroll export", TokenHash: "hash", Prefix: "payd_abcdef",
+			EmployeeID: employeeID, Role: "admin", Scopes: []string{"GET /admin/schedules", "/admin/shift-requests/*"},
+			ExpiresAt: now.Add(24 * time.Hour), CreatedBy: adminID})
+		assert.NoError(t, err)
+		_, err = st.CreateAPIToken(ctx, APIToken{Name: "bad role", TokenHash: "hash-2", Prefix: "payd_ghijkl",
+			EmployeeID: employeeID, Role: "root", Scopes: []string{"/*"}, ExpiresAt: now, CreatedBy: adminID})
+		assert.Error(t, err)
+
+		rec, err := st.SelectAPITokenByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, id, rec.ID)
+		assert.Equal(t, "Payroll", rec.EmployeeName)
+		assert.Equal(t, "ACTIVE", rec.EmployeeStatus)
+		assert.Equal(t, 1, rec.EmployeeRoleID)
+		assert.Equal(t, []string{"GET /admin/schedules", "/admin/shift-requests/*"}, []string(rec.Scopes))
+		assert.Nil(t, rec.LastUsedAt)
+
+		// touched once per interval
+		assert.NoError(t, st.TouchAPIToken(ctx, id, now, time.Minute))
+		assert.NoError(t, st.TouchAPIToken(ctx, id, now.Add(30*time.Second), time.Minute))
+		rec, err = st.SelectAPITokenByID(ctx, id)
+		assert.NoError(t, err)
+		if assert.NotNil(t, rec.LastUsedAt) {
+			assert.True(t, now.Equal(*rec.LastUsedAt))
+		}
+		assert.NoError(t, st.TouchAPIToken(ctx, id, now.Add(time.Minute), time.Minute))
+		rec, err = st.SelectAPITokenByID(ctx, id)
+		assert.NoError(t, err)
+		assert.True(t, now.Add(time.Minute).Equal(*rec.LastUsedAt))
+
+		revoked, err := st.RevokeAPIToken(ctx, id)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = st.RevokeAPIToken(ctx, id)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+
+		tokens, err := st.ListAPITokens(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, tokens, 1) {
+			assert.NotNil(t, tokens[0].RevokedAt)
+		}
+
+		_, err = st.SelectAPITokenByHash(ctx, "unknown")
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
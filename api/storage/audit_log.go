@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditLog is a state-changing action, Before and After are the JSON of the entity around it
+type AuditLog struct {
+	ID         int64            `db:"id"`
+	ActorID    *int             `db:"actor_id"`
+	Actor      string           `db:"actor"`
+	Action     string           `db:"action"`
+	EntityType string           `db:"entity_type"`
+	EntityID   int              `db:"entity_id"`
+	Before     *json.RawMessage `db:"before"` // nil when the action created the entity
+	After      *json.RawMessage `db:"after"`  // nil when the action deleted the entity
+	RequestID  *string          `db:"request_id"`
+	CreatedAt  time.Time        `db:"created_at"`
+}
+
+// ListAuditLogFilter restricts the audit logs to [Start, End), zero values don't filter
+type ListAuditLogFilter struct {
+	EntityType string
+	EntityID   int
+	ActorID    int
+	Start      time.Time
+	End        time.Time
+	Limit      int
+	Offset     int
+}
+
+// InsertAuditLog appends the entry to the audit log, within the transaction bound to ctx if any
+func (s *Storage) InsertAuditLog(ctx context.Context, l AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, actor, action, entity_type, entity_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query,
+		l.ActorID, l.Actor, l.Action, l.EntityType, l.EntityID, jsonArg(l.Before), jsonArg(l.After), l.RequestID)
+	return err
+}
+
+// ListAuditLogs lists a page of the audit logs matching the filter, newest first,
+// returns the page and the number of logs matching the filter
+func (s *Storage) ListAuditLogs(ctx context.Context, filter ListAuditLogFilter) ([]AuditLog, int, error) {
+	where := ` WHERE TRUE`
+	var args []interface{}
+	argPos := 1
+
+	if filter.EntityType != "" {
+		where += fmt.Sprintf(" AND entity_type = $%d", argPos)
+		args = append(args, filter.EntityType)
+		argPos++
+	}
+	if filter.EntityID != 0 {
+		where += fmt.Sprintf(" AND entity_id = $%d", argPos)
+		args = append(args, filter.EntityID)
+		argPos++
+	}
+	if filter.ActorID != 0 {
+		where += fmt.Sprintf(" AND actor_id = $%d", argPos)
+		args = append(args, filter.ActorID)
+		argPos++
+	}
+	if !filter.Start.IsZero() {
+		where += fmt.Sprintf(" AND created_at >= $%d", argPos)
+		args = append(args, filter.Start)
+		argPos++
+	}
+	if !filter.End.IsZero() {
+		where += fmt.Sprintf(" AND created_at < $%d", argPos)
+		args = append(args, filter.End)
+		argPos++
+	}
+
+	var total int
+	if err := s.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_logs`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, actor_id, actor, action, entity_type, entity_id, before, after, request_id, created_at
+		FROM audit_logs` + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	var logs []AuditLog
+	err := s.db.SelectContext(ctx, &logs, query, args...)
+	return logs, total, err
+}
+
+// jsonArg passes the JSON as text so that postgres casts it to JSONB, nil as NULL
+func jsonArg(raw *json.RawMessage) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return string(*raw)
+}
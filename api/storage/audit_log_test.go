@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		actorID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		requestID := "req-1"
+		after := json.RawMessage(`{"status": "APPROVED"}`)
+		assert.NoError(t, st.InsertAuditLog(ctx, AuditLog{ActorID: &actorID, Actor: "admin@example.com", Action: "approve",
+			EntityType: "shift_request", EntityID: 5, After: &after, RequestID: &requestID}))
+		assert.NoError(t, st.InsertAuditLog(ctx, AuditLog{Actor: "system", Action: "create", EntityType: "shift", EntityID: 5}))
+
+		logs, total, err := st.ListAuditLogs(ctx, ListAuditLogFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		if assert.Len(t, logs, 2) {
+			// newest first
+			assert.Equal(t, "create", logs[0].Action)
+			assert.Nil(t, logs[0].ActorID)
+			assert.Nil(t, logs[0].After)
+			assert.Equal(t, "approve", logs[1].Action)
+			assert.Equal(t, actorID, *logs[1].ActorID)
+			assert.JSONEq(t, `{"status":"APPROVED"}`, string(*logs[1].After))
+			assert.Nil(t, logs[1].Before)
+			assert.Equal(t, requestID, *logs[1].RequestID)
+		}
+
+		logs, total, err = st.ListAuditLogs(ctx, ListAuditLogFilter{EntityType: "shift_request", EntityID: 5, ActorID: actorID, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, logs, 1)
+
+		logs, total, err = st.ListAuditLogs(ctx, ListAuditLogFilter{Start: time.Now().Add(time.Hour), Limit: 10})
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, logs)
+
+		logs, total, err = st.ListAuditLogs(ctx, ListAuditLogFilter{Limit: 1, Offset: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		if assert.Len(t, logs, 1) {
+			assert.Equal(t, "approve", logs[0].Action)
+		}
+
+		// the log is append-only
+		_, err = st.db.ExecContext(ctx, `UPDATE audit_logs SET action = 'reject'`)
+		assert.ErrorIs(t, mapConstraintError(err), ErrAuditLogAppendOnly)
+		_, err = st.db.ExecContext(ctx, `DELETE FROM audit_logs`)
+		assert.ErrorIs(t, mapConstraintError(err), ErrAuditLogAppendOnly)
+	})
+}
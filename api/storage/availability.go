@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AvailabilityWindow is a weekly window an employee can work in
+type AvailabilityWindow struct {
+	ID             int       `db:"id"`
+	EmployeeID     int       `db:"employee_id"`
+	Weekday        int       `db:"weekday"`           // time.Weekday of the start
+	StartTimeOfDay string    `db:"start_time_of_day"` // HH:MM:SS wall clock in Timezone
+	EndTimeOfDay   string    `db:"end_time_of_day"`   // HH:MM:SS wall clock in Timezone
+	Timezone       string    `db:"timezone"`          // IANA name
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Unavailability is a one-off period an employee can't work
+type Unavailability struct {
+	ID         int       `db:"id"`
+	EmployeeID int       `db:"employee_id"`
+	StartTime  time.Time `db:"start_time"`
+	EndTime    time.Time `db:"end_time"`
+	Reason     string    `db:"reason"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (s *Storage) ListAvailabilityWindows(ctx context.Context, employeeId int) ([]AvailabilityWindow, error) {
+	var recs []AvailabilityWindow
+	query := `
+		SELECT id, employee_id, weekday, start_time_of_day, end_time_of_day, timezone, created_at
+		FROM employee_availabilities
+		WHERE employee_id = $1
+		ORDER BY weekday, start_time_of_day, id
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, employeeId)
+	return recs, err
+}
+
+// ReplaceAvailabilityWindows replaces every weekly window of the employee, call it in a transaction
+func (s *Storage) ReplaceAvailabilityWindows(ctx context.Context, employeeId int, windows []AvailabilityWindow) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM employee_availabilities WHERE employee_id = $1`, employeeId); err != nil {
+		return err
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	weekdays := make([]int64, 0, len(windows))
+	starts := make([]string, 0, len(windows))
+	ends := make([]string, 0, len(windows))
+	timezones := make([]string, 0, len(windows))
+	for _, w := range windows {
+		weekdays = append(weekdays, int64(w.Weekday))
+		starts = append(starts, w.StartTimeOfDay)
+		ends = append(ends, w.EndTimeOfDay)
+		timezones = append(timezones, w.Timezone)
+	}
+	query := `
+		INSERT INTO employee_availabilities (employee_id, weekday, start_time_of_day, end_time_of_day, timezone)
+		SELECT $1, unnest($2::SMALLINT[]), unnest($3::TIME[]), unnest($4::TIME[]), unnest($5::TEXT[])
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, employeeId, pq.Array(weekdays), pq.Array(starts), pq.Array(ends),
+		pq.Array(timezones))
+	return err
+}
+
+// CreateUnavailability stores the period, the times are expected in UTC
+func (s *Storage) CreateUnavailability(ctx context.Context, u Unavailability) (int, error) {
+	var id int
+	query := `
+		INSERT INTO employee_unavailabilities (employee_id, start_time, end_time, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, u.EmployeeID, u.StartTime, u.EndTime, u.Reason).Scan(&id)
+	return id, err
+}
+
+// ListUnavailabilitiesByTimeRange lists the periods of the employee overlapping the time range
+func (s *Storage) ListUnavailabilitiesByTimeRange(ctx context.Context, employeeId int, start, end time.Time) ([]Unavailability, error) {
+	var recs []Unavailability
+	query := `
+		SELECT id, employee_id, start_time, end_time, reason, created_at
+		FROM employee_unavailabilities
+		WHERE employee_id = $1 AND start_time < $3 AND end_time > $2
+		ORDER BY start_time, id
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, employeeId, start, end)
+	return recs, err
+}
+
+// DeleteUnavailability returns the deleted period, nil if the employee has no such period
+func (s *Storage) DeleteUnavailability(ctx context.Context, id, employeeId int) (*Unavailability, error) {
+	var rec Unavailability
+	query := `
+		DELETE FROM employee_unavailabilities
+		WHERE id = $1 AND employee_id = $2
+		RETURNING id, employee_id, start_time, end_time, reason, created_at
+	`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id, employeeId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
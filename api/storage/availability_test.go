@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailabilityWindows(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+
+		require.NoError(t, st.ReplaceAvailabilityWindows(ctx, employeeId, []AvailabilityWindow{
+			{Weekday: 5, StartTimeOfDay: "18:00", EndTimeOfDay: "02:00", Timezone: "Europe/Paris"},
+			{Weekday: 1, StartTimeOfDay: "09:00", EndTimeOfDay: "17:00", Timezone: "Europe/Paris"},
+		}))
+		windows, err := st.ListAvailabilityWindows(ctx, employeeId)
+		require.NoError(t, err)
+		require.Len(t, windows, 2)
+		assert.Equal(t, 1, windows[0].Weekday)
+		assert.Equal(t, "09:00:00", windows[0].StartTimeOfDay)
+		assert.Equal(t, "02:00:00", windows[1].EndTimeOfDay)
+
+		require.NoError(t, st.ReplaceAvailabilityWindows(ctx, employeeId, nil))
+		windows, err = st.ListAvailabilityWindows(ctx, employeeId)
+		require.NoError(t, err)
+		assert.Empty(t, windows)
+	})
+}
+
+func TestUnavailabilities(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+
+		id, err := st.CreateUnavailability(ctx, Unavailability{
+			EmployeeID: employeeId, StartTime: start, EndTime: start.Add(2 * time.Hour), Reason: "dentist",
+		})
+		require.NoError(t, err)
+
+		overlapping, err := st.ListUnavailabilitiesByTimeRange(ctx, employeeId, start.Add(time.Hour), start.Add(5*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, overlapping, 1)
+		assert.Equal(t, "dentist", overlapping[0].Reason)
+
+		adjacent, err := st.ListUnavailabilitiesByTimeRange(ctx, employeeId, start.Add(2*time.Hour), start.Add(5*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, adjacent, "touching periods don't overlap")
+
+		deleted, err := st.DeleteUnavailability(ctx, id, employeeId+1)
+		require.NoError(t, err)
+		assert.Nil(t, deleted, "only the employee's own periods")
+
+		deleted, err = st.DeleteUnavailability(ctx, id, employeeId)
+		require.NoError(t, err)
+		require.NotNil(t, deleted)
+		assert.Equal(t, "dentist", deleted.Reason)
+	})
+}
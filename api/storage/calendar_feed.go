@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CalendarFeed struct {
+	ID             int           `db:"id"`
+	EmployeeID     int           `db:"employee_id"`
+	EmployeeName   string        `db:"employee_name"`
+	EmployeeStatus string        `db:"employee_status"`
+	RoleID         *int          `db:"role_id"` // nil for the personal feed of the employee
+	RoleName       *string       `db:"role_name"`
+	LocationIDs    pq.Int64Array `db:"location_ids"` // nil for every location
+	CreatedAt      time.Time     `db:"created_at"`
+	RevokedAt      *time.Time    `db:"revoked_at"`
+}
+
+const calendarFeedColumns = `f.id, f.employee_id, e.name AS employee_name, e.status AS employee_status,
+	f.role_id, r.name AS role_name, f.location_ids, f.created_at, f.revoked_at`
+
+const calendarFeedFrom = ` FROM calendar_feeds f
+	JOIN employees e ON e.id = f.employee_id
+	LEFT JOIN roles r ON r.id = f.role_id`
+
+// CalendarShift is a shift with approved requests as listed by a calendar feed, or a cancelled one
+type CalendarShift struct {
+	ShiftID      int            `db:"shift_id"`
+	RoleID       int            `db:"role_id"`
+	RoleName     string         `db:"role_name"`
+	LocationID   int            `db:"location_id"`
+	LocationName string         `db:"location_name"`
+	StartTime    time.Time      `db:"start_time"`
+	EndTime      time.Time      `db:"end_time"`
+	ModifiedAt   time.Time      `db:"modified_at"`
+	Edits        int            `db:"edits"` // the number of admin edits, including the cancellation
+	Cancelled    bool           `db:"cancelled"`
+	Assignees    pq.StringArray `db:"assignees"` // names of the approved employees, empty when cancelled
+}
+
+// CalendarShiftFilter restricts the shifts of a feed, zero values don't filter
+type CalendarShiftFilter struct {
+	EmployeeID  int
+	RoleID      int
+	LocationIDs []int // restricts the shifts to these locations when not nil
+}
+
+// CreateCalendarFeed stores the feed, LocationIDs and RoleID are only meant for job role feeds
+func (s *Storage) CreateCalendarFeed(ctx context.Context, f CalendarFeed, tokenHash string) (int, error) {
+	var id int
+	query := `
+		INSERT INTO calendar_feeds (token_hash, employee_id, role_id, location_ids)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, tokenHash, f.EmployeeID, f.RoleID, f.LocationIDs).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+func (s *Storage) SelectCalendarFeedByHash(ctx context.Context, tokenHash string) (*CalendarFeed, error) {
+	var rec CalendarFeed
+	query := `SELECT ` + calendarFeedColumns + calendarFeedFrom + ` WHERE f.token_hash = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, tokenHash)
+	return &rec, err
+}
+
+// ListRoleCalendarFeeds returns the job role feeds that aren't revoked, newest first
+func (s *Storage) ListRoleCalendarFeeds(ctx context.Context) ([]CalendarFeed, error) {
+	var recs []CalendarFeed
+	query := `SELECT ` + calendarFeedColumns + calendarFeedFrom +
+		` WHERE f.role_id IS NOT NULL AND f.revoked_at IS NULL ORDER BY f.id DESC`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+// RevokeEmployeeCalendarFeed revokes the personal feed of the employee and returns its id, 0 if there is none
+func (s *Storage) RevokeEmployeeCalendarFeed(ctx context.Context, employeeId int) (int, error) {
+	var id int
+	query := `
+		UPDATE calendar_feeds SET revoked_at = CURRENT_TIMESTAMP
+		WHERE employee_id = $1 AND role_id IS NULL AND revoked_at IS NULL
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, employeeId).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// RevokeRoleCalendarFeed returns false if the job role feed doesn't exist or is already revoked
+func (s *Storage) RevokeRoleCalendarFeed(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE calendar_feeds SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND role_id IS NOT NULL AND revoked_at IS NULL
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListCalendarShifts lists the shifts with approved requests ending from since, by start time
+func (s *Storage) ListCalendarShifts(ctx context.Context, filter CalendarShiftFilter, since time.Time) ([]CalendarShift, error) {
+	query := `
+		SELECT s.id AS shift_id, s.role_id, r.name AS role_name, s.location_id, l.name AS location_name,
+			s.start_time, s.end_time, COALESCE(s.updated_at, s.created_at) AS modified_at,
+			(SELECT COUNT(*) FROM shift_edit_logs el WHERE el.shift_id = s.id) AS edits,
+			FALSE AS cancelled, ARRAY_AGG(e.name ORDER BY sr.id) AS assignees
+		FROM shifts s
+		JOIN roles r ON r.id = s.role_id
+		JOIN locations l ON l.id = s.location_id
+		JOIN shift_requests sr ON sr.shift_id = s.id AND sr.status = 'APPROVED'
+		JOIN employees e ON e.id = sr.employee_id
+		WHERE s.end_time >= $1
+	`
+	args := []interface{}{since}
+	argPos := len(args) + 1
+
+	if filter.EmployeeID != 0 {
+		query += fmt.Sprintf(" AND sr.employee_id = $%d", argPos)
+		args = append(args, filter.EmployeeID)
+		argPos++
+	}
+	if filter.RoleID != 0 {
+		query += fmt.Sprintf(" AND s.role_id = $%d", argPos)
+		args = append(args, filter.RoleID)
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		query += fmt.Sprintf(" AND s.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+	}
+	query += " GROUP BY s.id, r.name, l.name ORDER BY s.start_time, s.id"
+
+	var shifts []CalendarShift
+	err := s.conn(ctx).SelectContext(ctx, &shifts, query, args...)
+	return shifts, err
+}
+
+// ListCancelledCalendarShifts lists the shifts cancelled while they had approved requests, ending from since.
+// a feed keeps them as cancelled events so that calendars drop them even if they missed the removal
+func (s *Storage) ListCancelledCalendarShifts(ctx context.Context, filter CalendarShiftFilter, since time.Time) ([]CalendarShift, error) {
+	query := `
+		SELECT c.shift_id, c.old_role_id AS role_id, r.name AS role_name, c.location_id, l.name AS location_name,
+			c.old_start_time AS start_time, c.old_end_time AS end_time, c.edited_at AS modified_at,
+			(SELECT COUNT(*) FROM shift_edit_logs el WHERE el.shift_id = c.shift_id) AS edits,
+			TRUE AS cancelled, '{}'::TEXT[] AS assignees
+		FROM shift_edit_logs c
+		JOIN roles r ON r.id = c.old_role_id
+		JOIN locations l ON l.id = c.location_id
+		WHERE c.action = 'CANCEL' AND c.old_end_time >= $1 AND CARDINALITY(c.assignee_ids) > 0
+	`
+	args := []interface{}{since}
+	argPos := len(args) + 1
+
+	if filter.EmployeeID != 0 {
+		query += fmt.Sprintf(" AND $%d = ANY(c.assignee_ids)", argPos)
+		args = append(args, filter.EmployeeID)
+		argPos++
+	}
+	if filter.RoleID != 0 {
+		query += fmt.Sprintf(" AND c.old_role_id = $%d", argPos)
+		args = append(args, filter.RoleID)
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		query += fmt.Sprintf(" AND c.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+	}
+	query += " ORDER BY c.old_start_time, c.shift_id"
+
+	var shifts []CalendarShift
+	err := s.conn(ctx).SelectContext(ctx, &shifts, query, args...)
+	return shifts, err
+}
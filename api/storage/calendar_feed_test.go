@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeeds(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+
+		t.Run("one personal feed at a time", func(t *testing.T) {
+			id, err := st.CreateCalendarFeed(ctx, CalendarFeed{EmployeeID: employeeId}, "hash-1")
+			require.NoError(t, err)
+			_, err = st.CreateCalendarFeed(ctx, CalendarFeed{EmployeeID: employeeId}, "hash-2")
+			assert.Error(t, err)
+
+			revoked, err := st.RevokeEmployeeCalendarFeed(ctx, employeeId)
+			require.NoError(t, err)
+			assert.Equal(t, id, revoked)
+			_, err = st.CreateCalendarFeed(ctx, CalendarFeed{EmployeeID: employeeId}, "hash-2")
+			require.NoError(t, err)
+
+			feed, err := st.SelectCalendarFeedByHash(ctx, "hash-1")
+			require.NoError(t, err)
+			assert.NotNil(t, feed.RevokedAt)
+			assert.Nil(t, feed.RoleID)
+		})
+		t.Run("job role feeds", func(t *testing.T) {
+			unknown := 999
+			_, err := st.CreateCalendarFeed(ctx, CalendarFeed{EmployeeID: employeeId, RoleID: &unknown}, "hash-3")
+			assert.ErrorIs(t, err, ErrUnknownJobRole)
+
+			roleId := 3
+			id, err := st.CreateCalendarFeed(ctx, CalendarFeed{EmployeeID: employeeId, RoleID: &roleId,
+				LocationIDs: pq.Int64Array{DefaultLocationID}}, "hash-4")
+			require.NoError(t, err)
+			feeds, err := st.ListRoleCalendarFeeds(ctx)
+			require.NoError(t, err)
+			require.Len(t, feeds, 1)
+			assert.Equal(t, pq.Int64Array{DefaultLocationID}, feeds[0].LocationIDs)
+
+			revoked, err := st.RevokeRoleCalendarFeed(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, revoked)
+			revoked, err = st.RevokeRoleCalendarFeed(ctx, id)
+			require.NoError(t, err)
+			assert.False(t, revoked)
+		})
+		t.Run("shifts", func(t *testing.T) {
+			start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+			shiftId, err := st.CreateNewShiftSchedule(ctx, 3, DefaultLocationID, start, start.Add(8*time.Hour))
+			require.NoError(t, err)
+			requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+			require.NoError(t, err)
+
+			shifts, err := st.ListCalendarShifts(ctx, CalendarShiftFilter{EmployeeID: employeeId}, start)
+			require.NoError(t, err)
+			assert.Empty(t, shifts, "pending requests aren't listed")
+
+			require.NoError(t, st.ReviewShiftRequest(ctx, requestId, "APPROVED", employeeId))
+			shifts, err = st.ListCalendarShifts(ctx, CalendarShiftFilter{RoleID: 3, LocationIDs: []int{DefaultLocationID}}, start)
+			require.NoError(t, err)
+			require.Len(t, shifts, 1)
+			assert.Equal(t, shiftId, shifts[0].ShiftID)
+			assert.Equal(t, pq.StringArray{"Waiter"}, shifts[0].Assignees)
+			assert.False(t, shifts[0].Cancelled)
+
+			_, err = st.CreateShiftEditLog(ctx, ShiftEditLog{ShiftID: shiftId, LocationID: DefaultLocationID,
+				Action: ShiftEditCancel, OldRoleID: 3, OldStartTime: start, OldEndTime: start.Add(8 * time.Hour),
+				OldHeadcount: 1, AssigneeIDs: pq.Int64Array{int64(employeeId)}, EditedBy: employeeId})
+			require.NoError(t, err)
+			require.NoError(t, st.DeleteShiftById(ctx, shiftId))
+
+			cancelled, err := st.ListCancelledCalendarShifts(ctx, CalendarShiftFilter{EmployeeID: employeeId}, start)
+			require.NoError(t, err)
+			require.Len(t, cancelled, 1)
+			assert.True(t, cancelled[0].Cancelled)
+			assert.Equal(t, 1, cancelled[0].Edits)
+		})
+	})
+}
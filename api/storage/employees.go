@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Employee struct {
@@ -11,29 +15,113 @@ type Employee struct {
 	Name        string    `db:"name"`
 	Status      string    `db:"status"`
 	CreatedAt   time.Time `db:"created_at"`
+	IdentityID  *string   `db:"identity_id"` // kratos identity id
+	LocationID  int       `db:"location_id"`
+	// the locations managed on top of LocationID, see employee_locations
+	ManagedLocationIDs pq.Int64Array `db:"managed_location_ids"`
+}
+
+const employeeColumns = `id, name, status, role_id, created_at, identity_id, location_id,
+	ARRAY(SELECT el.location_id FROM employee_locations el WHERE el.employee_id = employees.id ORDER BY el.location_id)
+		AS managed_location_ids`
+
+type ListEmployeeFilter struct {
+	RoleID      int
+	LocationIDs []int // restricts the employees to these locations when not nil
+	Status      string
+	Search      string // case insensitive substring of the name
+	Limit       int
+	Offset      int
 }
 
-func (s *Storage) CreateNewEmployee(ctx context.Context, name, status string, roleId int) (int, error) {
+func (s *Storage) CreateNewEmployee(ctx context.Context, name, status string, roleId, locationId int) (int, error) {
 	var id int
-	query := `INSERT INTO employees (name, status, role_id) VALUES ($1, $2, $3) RETURNING id`
+	query := `INSERT INTO employees (name, status, role_id, location_id) VALUES ($1, $2, $3, $4) RETURNING id`
 	if t := getTx(ctx); t != nil {
-		err := t.QueryRowContext(ctx, query, name, status, roleId).Scan(&id)
-		return id, err
+		err := t.QueryRowContext(ctx, query, name, status, roleId, locationId).Scan(&id)
+		return id, mapConstraintError(err)
 	}
-	err := s.db.QueryRowContext(ctx, query, name, status, roleId).Scan(&id)
-	return id, err
+	err := s.db.QueryRowContext(ctx, query, name, status, roleId, locationId).Scan(&id)
+	return id, mapConstraintError(err)
 }
 
 // UpdateEmployeeStatus updates the status of an employee (used for non-activating or reactivating employee status)
 func (s *Storage) UpdateEmployeeStatus(ctx context.Context, id int, status string) error {
 	query := `UPDATE employees SET status = $1 WHERE id = $2`
-	_, err := s.db.ExecContext(ctx, query, status, id)
+	_, err := s.conn(ctx).ExecContext(ctx, query, status, id)
 	return err
 }
 
 func (s *Storage) SelectEmployeeByID(ctx context.Context, id int) (*Employee, error) {
 	var rec Employee
-	query := `SELECT id, name, status, role_id, created_at FROM employees WHERE id = $1`
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = $1`
 	err := s.db.GetContext(ctx, &rec, query, id)
 	return &rec, err
 }
+
+// LockEmployeeByID selects the employee and locks its row until the end of the transaction bound to ctx
+func (s *Storage) LockEmployeeByID(ctx context.Context, id int) (*Employee, error) {
+	var rec Employee
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = $1 FOR UPDATE`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+func (s *Storage) UpdateEmployeeIdentityID(ctx context.Context, id int, identityId string) error {
+	query := `UPDATE employees SET identity_id = $1 WHERE id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, identityId, id)
+	return err
+}
+
+func (s *Storage) UpdateEmployeeRole(ctx context.Context, id int, roleId int) error {
+	query := `UPDATE employees SET role_id = $1 WHERE id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, roleId, id)
+	return err
+}
+
+// ListEmployeesByFilter lists a page of the employees ordered by name,
+// returns the page and the number of employees matching the filter
+func (s *Storage) ListEmployeesByFilter(ctx context.Context, filter ListEmployeeFilter) ([]Employee, int, error) {
+	where := ` WHERE TRUE`
+	args := []interface{}{}
+	argPos := 1
+
+	if filter.RoleID != 0 {
+		where += fmt.Sprintf(" AND role_id = $%d", argPos)
+		args = append(args, filter.RoleID)
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		where += fmt.Sprintf(" AND location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+		argPos++
+	}
+	if filter.Status != "" {
+		where += fmt.Sprintf(" AND status = $%d", argPos)
+		args = append(args, filter.Status)
+		argPos++
+	}
+	if filter.Search != "" {
+		where += fmt.Sprintf(` AND name ILIKE '%%' || $%d || '%%'`, argPos)
+		args = append(args, escapeLike(filter.Search))
+		argPos++
+	}
+
+	var total int
+	if err := s.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM employees`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + employeeColumns + ` FROM employees` + where +
+		fmt.Sprintf(" ORDER BY name, id LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	var employees []Employee
+	err := s.db.SelectContext(ctx, &employees, query, args...)
+	return employees, total, err
+}
+
+// escapeLike escapes the LIKE wildcards so s is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -18,7 +18,7 @@ func TestInsertAndSelectRecord(t *testing.T) {
 			name := "Test Employee"
 			status := "ACTIVE"
 
-			id, err := st.CreateNewEmployee(ctx, name, status, 1)
+			id, err := st.CreateNewEmployee(ctx, name, status, 1, DefaultLocationID)
 			assert.NoError(t, err)
 			assert.Greater(t, id, 0)
 
@@ -30,16 +30,16 @@ func TestInsertAndSelectRecord(t *testing.T) {
 		})
 
 		t.Run("Invalid status", func(t *testing.T) {
-			_, err := st.CreateNewEmployee(ctx, "Invalid Status Employee", "UNKNOWN", 1)
+			_, err := st.CreateNewEmployee(ctx, "Invalid Status Employee", "UNKNOWN", 1, DefaultLocationID)
 			assert.Error(t, err)
 		})
 
 		t.Run("Empty name", func(t *testing.T) {
-			_, err := st.CreateNewEmployee(ctx, "", "ACTIVE", 1)
+			_, err := st.CreateNewEmployee(ctx, "", "ACTIVE", 1, DefaultLocationID)
 			assert.Error(t, err)
 		})
 		t.Run("Invalid Role", func(t *testing.T) {
-			_, err := st.CreateNewEmployee(ctx, "Invalid Role", "ACTIVE", -1)
+			_, err := st.CreateNewEmployee(ctx, "Invalid Role", "ACTIVE", -1, DefaultLocationID)
 			assert.Error(t, err)
 		})
 	})
@@ -49,10 +49,10 @@ func TestUpdateStatus(t *testing.T) {
 	_withTestDatabase(t, func(st *Storage) {
 		ctx := context.Background()
 		t.Run("Update one employee out of two", func(t *testing.T) {
-			id1, err := st.CreateNewEmployee(ctx, "Emp One", "ACTIVE", 1)
+			id1, err := st.CreateNewEmployee(ctx, "Emp One", "ACTIVE", 1, DefaultLocationID)
 			assert.NoError(t, err)
 
-			id2, err := st.CreateNewEmployee(ctx, "Emp Two", "ACTIVE", 1)
+			id2, err := st.CreateNewEmployee(ctx, "Emp Two", "ACTIVE", 1, DefaultLocationID)
 			assert.NoError(t, err)
 
 			err = st.UpdateEmployeeStatus(ctx, id2, "INACTIVE")
@@ -80,7 +80,7 @@ func TestTransactionRollback(t *testing.T) {
 			txCtx1, err := st.NewTransacton(ctx)
 			require.NoError(t, err)
 
-			id1, err := st.CreateNewEmployee(txCtx1, "Employee 1", "ACTIVE", 1)
+			id1, err := st.CreateNewEmployee(txCtx1, "Employee 1", "ACTIVE", 1, DefaultLocationID)
 			require.NoError(t, err)
 
 			err = st.Commit(txCtx1)
@@ -90,14 +90,14 @@ func TestTransactionRollback(t *testing.T) {
 			txCtx2, err := st.NewTransacton(ctx)
 			require.NoError(t, err)
 
-			id2, err := st.CreateNewEmployee(txCtx2, "Employee 2", "ACTIVE", 1)
+			id2, err := st.CreateNewEmployee(txCtx2, "Employee 2", "ACTIVE", 1, DefaultLocationID)
 			require.NoError(t, err)
 
 			err = st.Rollback(txCtx2)
 			require.NoError(t, err)
 
 			// 3rd record: non-transactional
-			id3, err := st.CreateNewEmployee(ctx, "Employee 3", "ACTIVE", 1)
+			id3, err := st.CreateNewEmployee(ctx, "Employee 3", "ACTIVE", 1, DefaultLocationID)
 			require.NoError(t, err)
 
 			// Validate ID sequences
@@ -120,3 +120,54 @@ func TestTransactionRollback(t *testing.T) {
 		})
 	})
 }
+
+func TestListEmployeesByFilter(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		alice, err := st.CreateNewEmployee(ctx, "Alice", "ACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+		_, err = st.CreateNewEmployee(ctx, "Bob", "INACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+		_, err = st.CreateNewEmployee(ctx, "Malice_2", "ACTIVE", 2, DefaultLocationID)
+		require.NoError(t, err)
+
+		tests := []struct {
+			name      string
+			filter    ListEmployeeFilter
+			wantNames []string
+			wantTotal int
+		}{
+			{"all", ListEmployeeFilter{Limit: 10}, []string{"Alice", "Bob", "Malice_2"}, 3},
+			{"by role", ListEmployeeFilter{RoleID: 1, Limit: 10}, []string{"Alice", "Bob"}, 2},
+			{"by status", ListEmployeeFilter{Status: "INACTIVE", Limit: 10}, []string{"Bob"}, 1},
+			{"search is case insensitive", ListEmployeeFilter{Search: "ALIC", Limit: 10}, []string{"Alice", "Malice_2"}, 2},
+			{"wildcards are literal", ListEmployeeFilter{Search: "e_", Limit: 10}, []string{"Malice_2"}, 1},
+			{"paginated", ListEmployeeFilter{Limit: 1, Offset: 1}, []string{"Bob"}, 3},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				employees, total, err := st.ListEmployeesByFilter(ctx, tc.filter)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantTotal, total)
+				names := make([]string, 0, len(employees))
+				for _, e := range employees {
+					names = append(names, e.Name)
+				}
+				assert.Equal(t, tc.wantNames, names)
+			})
+		}
+
+		t.Run("identity and role", func(t *testing.T) {
+			require.NoError(t, st.UpdateEmployeeIdentityID(ctx, alice, "kratos-alice"))
+			require.NoError(t, st.UpdateEmployeeRole(ctx, alice, 3))
+
+			record, err := st.LockEmployeeByID(ctx, alice)
+			assert.NoError(t, err)
+			assert.Equal(t, "kratos-alice", *record.IdentityID)
+			assert.Equal(t, 3, record.PrimaryRole)
+
+			_, err = st.LockEmployeeByID(ctx, alice+100)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+}
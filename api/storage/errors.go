@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateShiftRequest = errors.New("employee already has an active request for this shift")
+var ErrShiftFullyStaffed = errors.New("shift already has its headcount of approved requests")
+var ErrHeadcountBelowApproved = errors.New("headcount is lower than the approved requests of the shift")
+var ErrOverlappingApprovedShift = errors.New("employee already has an approved shift overlapping this one")
+var ErrDuplicateRoleName = errors.New("an active role with the same name already exists")
+var ErrDuplicatePrivilegeRoleName = errors.New("a privilege role with the same name already exists")
+var ErrUnknownJobRole = errors.New("job role does not exist")
+var ErrUnknownEmployee = errors.New("employee does not exist")
+var ErrUnknownPrivilegeRole = errors.New("privilege role does not exist")
+var ErrDuplicateLocationName = errors.New("a location with the same name already exists")
+var ErrUnknownLocation = errors.New("location does not exist")
+var ErrOverlappingLeaveRequest = errors.New("employee already has leave overlapping this period")
+var ErrUnknownLeaveType = errors.New("leave type does not exist")
+var ErrDuplicateShiftSwap = errors.New("shift request is already offered")
+var ErrDuplicateTimeEntry = errors.New("shift request already has a time entry")
+var ErrAlreadyClockedIn = errors.New("employee is already clocked in")
+var ErrBreakAlreadyStarted = errors.New("time entry already has an ongoing break")
+var ErrAuditLogAppendOnly = errors.New("audit logs are append-only")
+
+// constraint names mapped to storage errors, see migrations
+var constraintErrors = map[string]error{
+	"uniq_shift_requests_active_employee_shift": ErrDuplicateShiftRequest,
+	"shift_requests_shift_headcount":            ErrShiftFullyStaffed,
+	"shifts_headcount_approved":                 ErrHeadcountBelowApproved,
+	"shift_requests_employee_no_overlap":        ErrOverlappingApprovedShift,
+	"uniq_roles_active_name":                    ErrDuplicateRoleName,
+	"uniq_privilege_roles_name":                 ErrDuplicatePrivilegeRoleName,
+	"fk_privilege_role_permissions_job_role":    ErrUnknownJobRole,
+	"fk_employee_privilege_roles_employee":      ErrUnknownEmployee,
+	"fk_employee_privilege_roles_role":          ErrUnknownPrivilegeRole,
+	"uniq_locations_name":                       ErrDuplicateLocationName,
+	"fk_employees_location":                     ErrUnknownLocation,
+	"fk_shifts_location":                        ErrUnknownLocation,
+	"fk_shift_templates_location":               ErrUnknownLocation,
+	"fk_roles_location":                         ErrUnknownLocation,
+	"fk_employee_locations_employee":            ErrUnknownEmployee,
+	"fk_employee_locations_location":            ErrUnknownLocation,
+	"leave_requests_employee_no_overlap":        ErrOverlappingLeaveRequest,
+	"fk_leave_requests_leave_type":              ErrUnknownLeaveType,
+	"fk_leave_balance_entries_employee":         ErrUnknownEmployee,
+	"uniq_shift_swaps_active_request":           ErrDuplicateShiftSwap,
+	"uniq_time_entries_shift_request":           ErrDuplicateTimeEntry,
+	"uniq_time_entries_open_employee":           ErrAlreadyClockedIn,
+	"uniq_time_entry_breaks_open":               ErrBreakAlreadyStarted,
+	"fk_calendar_feeds_role":                    ErrUnknownJobRole,
+	"audit_logs_append_only":                    ErrAuditLogAppendOnly,
+}
+
+// mapConstraintError translates a postgres constraint violation into one of the storage errors,
+// any other error is returned as is
+func mapConstraintError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	if mapped, ok := constraintErrors[pqErr.Constraint]; ok {
+		return mapped
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type RevokedJWT struct {
+	JTI       string    `db:"jti"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type EmployeeJWTRevocation struct {
+	EmployeeID    int       `db:"employee_id"`
+	RevokedBefore time.Time `db:"revoked_before"`
+}
+
+// RevokeJWT revokes a single access token until it expires, revoking it twice is a no-op
+func (s *Storage) RevokeJWT(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_jwts (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+	_, err := s.conn(ctx).ExecContext(ctx, query, jti, expiresAt)
+	return err
+}
+
+// SelectRevokedJWTs returns the revoked access tokens not expired at now
+func (s *Storage) SelectRevokedJWTs(ctx context.Context, now time.Time) ([]RevokedJWT, error) {
+	var recs []RevokedJWT
+	query := `SELECT jti, expires_at FROM revoked_jwts WHERE expires_at > $1`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, now)
+	return recs, err
+}
+
+// DeleteExpiredRevokedJWTs removes the revocations of tokens expired at now, they are rejected by exp anyway
+func (s *Storage) DeleteExpiredRevokedJWTs(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM revoked_jwts WHERE expires_at <= $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeEmployeeJWTs revokes every access token of the employee issued up to before, a later revocation is kept
+func (s *Storage) RevokeEmployeeJWTs(ctx context.Context, employeeId int, before time.Time) error {
+	query := `
+		INSERT INTO employee_jwt_revocations (employee_id, revoked_before) VALUES ($1, $2)
+		ON CONFLICT (employee_id)
+		DO UPDATE SET revoked_before = GREATEST(employee_jwt_revocations.revoked_before, EXCLUDED.revoked_before)
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, employeeId, before)
+	return err
+}
+
+func (s *Storage) SelectEmployeeJWTRevocations(ctx context.Context) ([]EmployeeJWTRevocation, error) {
+	var recs []EmployeeJWTRevocation
+	query := `SELECT employee_id, revoked_before FROM employee_jwt_revocations`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevokedJWTs(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+
+		assert.NoError(t, st.RevokeJWT(ctx, "jti-1", now.Add(time.Minute)))
+		assert.NoError(t, st.RevokeJWT(ctx, "jti-1", now.Add(time.Minute)), "revoking twice is a no-op")
+		assert.NoError(t, st.RevokeJWT(ctx, "jti-2", now.Add(-time.Minute)))
+
+		recs, err := st.SelectRevokedJWTs(ctx, now)
+		assert.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, "jti-1", recs[0].JTI)
+			assert.True(t, now.Add(time.Minute).Equal(recs[0].ExpiresAt))
+		}
+
+		n, err := st.DeleteExpiredRevokedJWTs(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
+
+func TestEmployeeJWTRevocations(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+		employeeID, err := st.CreateNewEmployee(ctx, "John", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		assert.NoError(t, st.RevokeEmployeeJWTs(ctx, employeeID, now))
+		// an older revocation doesn't move revoked_before back
+		assert.NoError(t, st.RevokeEmployeeJWTs(ctx, employeeID, now.Add(-time.Hour)))
+
+		recs, err := st.SelectEmployeeJWTRevocations(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, employeeID, recs[0].EmployeeID)
+			assert.True(t, now.Equal(recs[0].RevokedBefore))
+		}
+
+		assert.NoError(t, st.CreateRefreshToken(ctx, RefreshToken{TokenHash: "hash", FamilyID: "family", IdentityID: "identity",
+			EmployeeID: employeeID, Email: "john@mail.com", Role: "employee", ExpiresAt: now.Add(time.Hour),
+			FamilyExpiresAt: now.Add(time.Hour)}))
+		n, err := st.RevokeRefreshTokensByEmployeeID(ctx, employeeID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
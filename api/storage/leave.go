@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	LeaveEntryAccrual    = "ACCRUAL"
+	LeaveEntryAdjustment = "ADJUSTMENT"
+	LeaveEntryLeave      = "LEAVE"
+)
+
+type LeaveType struct {
+	ID             int     `db:"id"`
+	Code           string  `db:"code"`
+	Name           string  `db:"name"`
+	TracksBalance  bool    `db:"tracks_balance"`
+	MonthlyAccrual float64 `db:"monthly_accrual"` // days
+}
+
+// LeaveRequest covers whole calendar days from StartDate through EndDate
+type LeaveRequest struct {
+	ID          int        `db:"id"`
+	EmployeeID  int        `db:"employee_id"`
+	LeaveTypeID int        `db:"leave_type_id"`
+	StartDate   time.Time  `db:"start_date"`
+	EndDate     time.Time  `db:"end_date"`
+	Days        float64    `db:"days"`
+	Reason      string     `db:"reason"`
+	Status      string     `db:"status"`
+	RequestedAt time.Time  `db:"requested_at"`
+	ReviewedAt  *time.Time `db:"reviewed_at"`
+	ReviewedBy  *int       `db:"reviewed_by"`
+}
+
+type LeaveRequestWithDetails struct {
+	LeaveRequest
+	EmployeeName   string `db:"employee_name"`
+	EmployeeRoleID int    `db:"employee_role_id"`
+	LocationID     int    `db:"location_id"` // of the employee
+	LeaveTypeCode  string `db:"leave_type_code"`
+}
+
+type ListLeaveRequestFilter struct {
+	EmployeeID  int
+	LeaveTypeID int
+	RoleIDs     []int // restricts the employees to these primary roles when not nil
+	LocationIDs []int // restricts the employees to these locations when not nil
+	Status      string
+}
+
+// LeaveBalance is the sum of the balance entries of an employee for a leave type
+type LeaveBalance struct {
+	LeaveTypeID   int     `db:"leave_type_id"`
+	LeaveTypeCode string  `db:"leave_type_code"`
+	Days          float64 `db:"days"`
+}
+
+type LeaveBalanceEntry struct {
+	ID             int        `db:"id"`
+	EmployeeID     int        `db:"employee_id"`
+	LeaveTypeID    int        `db:"leave_type_id"`
+	Days           float64    `db:"days"` // credited when positive
+	Kind           string     `db:"kind"`
+	AccrualMonth   *time.Time `db:"accrual_month"`
+	LeaveRequestID *int       `db:"leave_request_id"`
+	Note           string     `db:"note"`
+	CreatedBy      *int       `db:"created_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+const leaveRequestColumns = `lr.id, lr.employee_id, lr.leave_type_id, lr.start_date, lr.end_date, lr.days, lr.reason,
+	lr.status, lr.requested_at, lr.reviewed_at, lr.reviewed_by`
+
+func (s *Storage) ListLeaveTypes(ctx context.Context) ([]LeaveType, error) {
+	var recs []LeaveType
+	query := `SELECT id, code, name, tracks_balance, monthly_accrual FROM leave_types ORDER BY id`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+func (s *Storage) SelectLeaveTypeByID(ctx context.Context, id int) (*LeaveType, error) {
+	var rec LeaveType
+	query := `SELECT id, code, name, tracks_balance, monthly_accrual FROM leave_types WHERE id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// CreateLeaveRequest stores a PENDING request,
+// returns ErrOverlappingLeaveRequest if the employee already has active leave during the period
+func (s *Storage) CreateLeaveRequest(ctx context.Context, r LeaveRequest) (int, error) {
+	var id int
+	query := `
+		INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, days, reason, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 'PENDING')
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, r.EmployeeID, r.LeaveTypeID, r.StartDate, r.EndDate, r.Days,
+		r.Reason).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// LockLeaveRequestByID selects the leave request and locks its row until the end of the transaction bound to ctx
+func (s *Storage) LockLeaveRequestByID(ctx context.Context, id int) (*LeaveRequest, error) {
+	var rec LeaveRequest
+	query := `SELECT ` + leaveRequestColumns + ` FROM leave_requests lr WHERE lr.id = $1 FOR UPDATE`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// ReviewLeaveRequest sets the status of the leave request along with the reviewer attribution
+func (s *Storage) ReviewLeaveRequest(ctx context.Context, id int, status string, reviewedBy int) error {
+	query := `
+		UPDATE leave_requests
+		SET status = $1, reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $2
+		WHERE id = $3
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, status, reviewedBy, id)
+	return mapConstraintError(err)
+}
+
+// WithdrawLeaveRequest withdraws a PENDING request of the employee, returns false if there is none
+func (s *Storage) WithdrawLeaveRequest(ctx context.Context, id, employeeId int) (bool, error) {
+	query := `
+		UPDATE leave_requests
+		SET status = 'WITHDRAWN', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $2
+		WHERE id = $1 AND employee_id = $2 AND status = 'PENDING'
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id, employeeId)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListLeaveRequestsByFilter lists the requests overlapping the [start, end] dates, latest first
+func (s *Storage) ListLeaveRequestsByFilter(ctx context.Context, filter ListLeaveRequestFilter, start, end time.Time) ([]LeaveRequestWithDetails, error) {
+	query := `
+		SELECT ` + leaveRequestColumns + `, e.name AS employee_name, e.role_id AS employee_role_id, e.location_id,
+			lt.code AS leave_type_code
+		FROM leave_requests lr
+		JOIN employees e ON e.id = lr.employee_id
+		JOIN leave_types lt ON lt.id = lr.leave_type_id
+		WHERE lr.start_date <= $2 AND lr.end_date >= $1
+	`
+	args := []interface{}{start, end}
+	argPos := len(args) + 1
+
+	if filter.EmployeeID != 0 {
+		query += fmt.Sprintf(" AND lr.employee_id = $%d", argPos)
+		args = append(args, filter.EmployeeID)
+		argPos++
+	}
+	if filter.LeaveTypeID != 0 {
+		query += fmt.Sprintf(" AND lr.leave_type_id = $%d", argPos)
+		args = append(args, filter.LeaveTypeID)
+		argPos++
+	}
+	if filter.RoleIDs != nil {
+		query += fmt.Sprintf(" AND e.role_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.RoleIDs))
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		query += fmt.Sprintf(" AND e.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+		argPos++
+	}
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND lr.status = $%d", argPos)
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY lr.start_date DESC, lr.id DESC`
+
+	var recs []LeaveRequestWithDetails
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, args...)
+	return recs, err
+}
+
+// ListApprovedLeaveByTimeRange lists the APPROVED leave of the employee overlapping the [start, end) range,
+// the leave days run from midnight to midnight in the timezone of the location
+func (s *Storage) ListApprovedLeaveByTimeRange(ctx context.Context, employeeId, locationId int, start, end time.Time) ([]LeaveRequest, error) {
+	var recs []LeaveRequest
+	query := `
+		SELECT ` + leaveRequestColumns + `
+		FROM leave_requests lr
+		JOIN locations l ON l.id = $2
+		WHERE lr.employee_id = $1
+		  AND lr.status = 'APPROVED'
+		  AND lr.start_date::TIMESTAMP AT TIME ZONE l.timezone < $4
+		  AND (lr.end_date + 1)::TIMESTAMP AT TIME ZONE l.timezone > $3
+		ORDER BY lr.start_date
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, employeeId, locationId, start, end)
+	return recs, err
+}
+
+// FlagApprovedShiftRequestsOnLeave marks the approved shift requests of the employee of the leave request overlapping
+// its leave days as conflicting with it, returns the ids of their shifts. the leave days run from midnight to midnight
+// in the timezone of the location of each shift
+func (s *Storage) FlagApprovedShiftRequestsOnLeave(ctx context.Context, leaveRequestId int) ([]int, error) {
+	var shiftIds []int
+	query := `
+		UPDATE shift_requests sr
+		SET conflicting_leave_request_id = lr.id
+		FROM leave_requests lr, shifts s
+		JOIN locations l ON l.id = s.location_id
+		WHERE lr.id = $1
+		  AND s.id = sr.shift_id
+		  AND sr.employee_id = lr.employee_id
+		  AND sr.status = 'APPROVED'
+		  AND s.start_time < (lr.end_date + 1)::TIMESTAMP AT TIME ZONE l.timezone
+		  AND s.end_time > lr.start_date::TIMESTAMP AT TIME ZONE l.timezone
+		RETURNING s.id
+	`
+	err := s.conn(ctx).SelectContext(ctx, &shiftIds, query, leaveRequestId)
+	return shiftIds, err
+}
+
+// ListLeaveBalances returns the balance of the employee for every leave type tracking one
+func (s *Storage) ListLeaveBalances(ctx context.Context, employeeId int) ([]LeaveBalance, error) {
+	var recs []LeaveBalance
+	query := `
+		SELECT lt.id AS leave_type_id, lt.code AS leave_type_code, COALESCE(SUM(lbe.days), 0) AS days
+		FROM leave_types lt
+		LEFT JOIN leave_balance_entries lbe ON lbe.leave_type_id = lt.id AND lbe.employee_id = $1
+		WHERE lt.tracks_balance
+		GROUP BY lt.id, lt.code
+		ORDER BY lt.id
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, employeeId)
+	return recs, err
+}
+
+// SumLeaveBalance returns the balance of the employee for the leave type
+func (s *Storage) SumLeaveBalance(ctx context.Context, employeeId, leaveTypeId int) (float64, error) {
+	var days float64
+	query := `SELECT COALESCE(SUM(days), 0) FROM leave_balance_entries WHERE employee_id = $1 AND leave_type_id = $2`
+	err := s.conn(ctx).GetContext(ctx, &days, query, employeeId, leaveTypeId)
+	return days, err
+}
+
+func (s *Storage) CreateLeaveBalanceEntry(ctx context.Context, e LeaveBalanceEntry) (int, error) {
+	var id int
+	query := `
+		INSERT INTO leave_balance_entries (employee_id, leave_type_id, days, kind, accrual_month, leave_request_id,
+			note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, e.EmployeeID, e.LeaveTypeID, e.Days, e.Kind, e.AccrualMonth,
+		e.LeaveRequestID, e.Note, e.CreatedBy).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// AccrueLeave credits the monthly accrual of the month to every active employee, once per month and leave type.
+// returns the number of credited balances
+func (s *Storage) AccrueLeave(ctx context.Context, month time.Time) (int64, error) {
+	query := `
+		INSERT INTO leave_balance_entries (employee_id, leave_type_id, days, kind, accrual_month)
+		SELECT e.id, lt.id, lt.monthly_accrual, 'ACCRUAL', $1
+		FROM employees e
+		CROSS JOIN leave_types lt
+		WHERE e.status = 'ACTIVE' AND lt.tracks_balance AND lt.monthly_accrual > 0
+		ON CONFLICT (employee_id, leave_type_id, accrual_month) WHERE kind = 'ACCRUAL' DO NOTHING
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, month)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaveRequests(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+		types, err := st.ListLeaveTypes(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, types)
+		annual := types[0]
+		assert.Equal(t, "ANNUAL", annual.Code)
+
+		start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+		id, err := st.CreateLeaveRequest(ctx, LeaveRequest{
+			EmployeeID: employeeId, LeaveTypeID: annual.ID, StartDate: start, EndDate: start.AddDate(0, 0, 2), Days: 3,
+		})
+		require.NoError(t, err)
+
+		t.Run("overlapping request", func(t *testing.T) {
+			_, err := st.CreateLeaveRequest(ctx, LeaveRequest{
+				EmployeeID: employeeId, LeaveTypeID: annual.ID, StartDate: start.AddDate(0, 0, 2), EndDate: start.AddDate(0, 0, 3), Days: 2,
+			})
+			assert.ErrorIs(t, err, ErrOverlappingLeaveRequest)
+		})
+		t.Run("unknown leave type", func(t *testing.T) {
+			_, err := st.CreateLeaveRequest(ctx, LeaveRequest{
+				EmployeeID: employeeId, LeaveTypeID: 100, StartDate: start.AddDate(0, 1, 0), EndDate: start.AddDate(0, 1, 0), Days: 1,
+			})
+			assert.ErrorIs(t, err, ErrUnknownLeaveType)
+		})
+		t.Run("list by filter", func(t *testing.T) {
+			recs, err := st.ListLeaveRequestsByFilter(ctx, ListLeaveRequestFilter{EmployeeID: employeeId}, start.AddDate(0, 0, 2), start.AddDate(0, 0, 5))
+			require.NoError(t, err)
+			require.Len(t, recs, 1)
+			assert.Equal(t, "ANNUAL", recs[0].LeaveTypeCode)
+			assert.Equal(t, DefaultLocationID, recs[0].LocationID)
+
+			recs, err = st.ListLeaveRequestsByFilter(ctx, ListLeaveRequestFilter{EmployeeID: employeeId, RoleIDs: []int{}}, start, start)
+			require.NoError(t, err)
+			assert.Empty(t, recs)
+		})
+		t.Run("approved leave flags the shifts", func(t *testing.T) {
+			shiftId, err := st.CreateNewShiftSchedule(ctx, 3, DefaultLocationID, start.AddDate(0, 0, 2).Add(22*time.Hour), start.AddDate(0, 0, 3).Add(2*time.Hour))
+			require.NoError(t, err)
+			requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+			require.NoError(t, err)
+			require.NoError(t, st.ReviewShiftRequest(ctx, requestId, "APPROVED", employeeId))
+
+			require.NoError(t, st.ReviewLeaveRequest(ctx, id, "APPROVED", employeeId))
+			leave, err := st.ListApprovedLeaveByTimeRange(ctx, employeeId, DefaultLocationID, start.AddDate(0, 0, 2).Add(23*time.Hour), start.AddDate(0, 0, 3))
+			require.NoError(t, err)
+			assert.Len(t, leave, 1)
+			leave, err = st.ListApprovedLeaveByTimeRange(ctx, employeeId, DefaultLocationID, start.AddDate(0, 0, 3), start.AddDate(0, 0, 4))
+			require.NoError(t, err)
+			assert.Empty(t, leave, "the leave ends at midnight")
+
+			shiftIds, err := st.FlagApprovedShiftRequestsOnLeave(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, []int{shiftId}, shiftIds)
+		})
+		t.Run("leave days in the timezone of the shift location", func(t *testing.T) {
+			// new york is 4 hours behind UTC in june, its leave days run from 04:00 UTC through 04:00 UTC
+			newYork, err := st.CreateLocation(ctx, "New York", "America/New_York")
+			require.NoError(t, err)
+			eveningBefore := [2]time.Time{start, start.Add(3*time.Hour + 30*time.Minute)}
+			lastDayLate := [2]time.Time{start.AddDate(0, 0, 3).Add(2 * time.Hour), start.AddDate(0, 0, 3).Add(3*time.Hour + 30*time.Minute)}
+
+			leave, err := st.ListApprovedLeaveByTimeRange(ctx, employeeId, newYork, eveningBefore[0], eveningBefore[1])
+			require.NoError(t, err)
+			assert.Empty(t, leave, "the evening before the first leave day in new york")
+			leave, err = st.ListApprovedLeaveByTimeRange(ctx, employeeId, newYork, lastDayLate[0], lastDayLate[1])
+			require.NoError(t, err)
+			assert.Len(t, leave, 1, "late on the last leave day in new york")
+
+			var shiftIds []int
+			for _, times := range [][2]time.Time{eveningBefore, lastDayLate} {
+				shiftId, err := st.CreateNewShiftSchedule(ctx, 3, newYork, times[0], times[1])
+				require.NoError(t, err)
+				requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+				require.NoError(t, err)
+				require.NoError(t, st.ReviewShiftRequest(ctx, requestId, "APPROVED", employeeId))
+				shiftIds = append(shiftIds, shiftId)
+			}
+			flagged, err := st.FlagApprovedShiftRequestsOnLeave(ctx, id)
+			require.NoError(t, err)
+			assert.Contains(t, flagged, shiftIds[1])
+			assert.NotContains(t, flagged, shiftIds[0])
+		})
+		t.Run("withdraw only pending", func(t *testing.T) {
+			withdrawn, err := st.WithdrawLeaveRequest(ctx, id, employeeId)
+			require.NoError(t, err)
+			assert.False(t, withdrawn)
+		})
+	})
+}
+
+func TestLeaveBalances(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+		month := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		credited, err := st.AccrueLeave(ctx, month)
+		require.NoError(t, err)
+		assert.Greater(t, credited, int64(0))
+		credited, err = st.AccrueLeave(ctx, month)
+		require.NoError(t, err)
+		assert.Zero(t, credited, "accruing twice within a month is a no-op")
+
+		balances, err := st.ListLeaveBalances(ctx, employeeId)
+		require.NoError(t, err)
+		require.Len(t, balances, 1)
+		assert.InDelta(t, 2.08, balances[0].Days, 0.001)
+
+		_, err = st.CreateLeaveBalanceEntry(ctx, LeaveBalanceEntry{
+			EmployeeID: employeeId, LeaveTypeID: balances[0].LeaveTypeID, Days: -0.5, Kind: LeaveEntryAdjustment,
+		})
+		require.NoError(t, err)
+		days, err := st.SumLeaveBalance(ctx, employeeId, balances[0].LeaveTypeID)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.58, days, 0.001)
+
+		_, err = st.CreateLeaveBalanceEntry(ctx, LeaveBalanceEntry{
+			EmployeeID: 10000, LeaveTypeID: balances[0].LeaveTypeID, Days: 1, Kind: LeaveEntryAdjustment,
+		})
+		assert.ErrorIs(t, err, ErrUnknownEmployee)
+	})
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultLocationID is the seeded location of the rows created before locations
+const DefaultLocationID = 1
+
+type Location struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	Timezone  string    `db:"timezone"` // IANA name of the business timezone
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// InLocations reports whether the location is one of locationIds,
+// nil locationIds means every location like in the location filters of the queries
+func InLocations(locationIds []int, locationId int) bool {
+	if locationIds == nil {
+		return true
+	}
+	for _, id := range locationIds {
+		if id == locationId {
+			return true
+		}
+	}
+	return false
+}
+
+// ListLocations lists the locations by name, restricted to ids when not nil
+func (s *Storage) ListLocations(ctx context.Context, ids []int) ([]Location, error) {
+	query := `SELECT id, name, timezone, created_at FROM locations`
+	args := []interface{}{}
+	if ids != nil {
+		query += ` WHERE id = ANY($1)`
+		args = append(args, pq.Array(ids))
+	}
+	query += ` ORDER BY name, id`
+
+	var recs []Location
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, args...)
+	return recs, err
+}
+
+// CreateLocation returns ErrDuplicateLocationName if the name is taken, case insensitively
+func (s *Storage) CreateLocation(ctx context.Context, name, timezone string) (int, error) {
+	var id int
+	query := `INSERT INTO locations (name, timezone) VALUES ($1, $2) RETURNING id`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, name, timezone).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// UpdateLocation renames the location and changes its timezone unless empty, returns false if the location doesn't exist
+func (s *Storage) UpdateLocation(ctx context.Context, id int, name, timezone string) (bool, error) {
+	query := `UPDATE locations SET name = $1, timezone = COALESCE(NULLIF($2, ''), timezone) WHERE id = $3`
+	res, err := s.conn(ctx).ExecContext(ctx, query, name, timezone, id)
+	if err != nil {
+		return false, mapConstraintError(err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReplaceEmployeeLocations replaces the locations the employee manages on top of their own and returns the replaced ones,
+// returns ErrUnknownEmployee or ErrUnknownLocation for missing ones
+func (s *Storage) ReplaceEmployeeLocations(ctx context.Context, employeeId int, locationIds []int) ([]int, error) {
+	var replaced []int
+	query := `DELETE FROM employee_locations WHERE employee_id = $1 RETURNING location_id`
+	if err := s.conn(ctx).SelectContext(ctx, &replaced, query, employeeId); err != nil {
+		return nil, err
+	}
+	sort.Ints(replaced)
+	if len(locationIds) == 0 {
+		return replaced, nil
+	}
+	query = `
+		INSERT INTO employee_locations (employee_id, location_id)
+		SELECT $1, unnest($2::INTEGER[])
+	`
+	if _, err := s.conn(ctx).ExecContext(ctx, query, employeeId, pq.Array(locationIds)); err != nil {
+		return nil, mapConstraintError(err)
+	}
+	return replaced, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageLocations(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		id, err := st.CreateLocation(ctx, "Harbour", "Europe/Berlin")
+		require.NoError(t, err)
+
+		_, err = st.CreateLocation(ctx, "harbour", "UTC")
+		assert.ErrorIs(t, err, ErrDuplicateLocationName)
+
+		renamed, err := st.UpdateLocation(ctx, id, "Dock", "")
+		require.NoError(t, err)
+		assert.True(t, renamed)
+
+		renamed, err = st.UpdateLocation(ctx, 999, "Nowhere", "UTC")
+		require.NoError(t, err)
+		assert.False(t, renamed)
+
+		all, err := st.ListLocations(ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, all, 2, "the seeded location and the new one")
+
+		scoped, err := st.ListLocations(ctx, []int{id})
+		require.NoError(t, err)
+		require.Len(t, scoped, 1)
+		assert.Equal(t, "Dock", scoped[0].Name)
+		assert.Equal(t, "Europe/Berlin", scoped[0].Timezone, "kept by an empty timezone")
+	})
+}
+
+func TestReplaceEmployeeLocations(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Manager", "ACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+		harbour, err := st.CreateLocation(ctx, "Harbour", "UTC")
+		require.NoError(t, err)
+
+		replaced, err := st.ReplaceEmployeeLocations(ctx, employeeId, []int{harbour})
+		require.NoError(t, err)
+		assert.Empty(t, replaced)
+		employee, err := st.SelectEmployeeByID(ctx, employeeId)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultLocationID, employee.LocationID)
+		assert.ElementsMatch(t, []int64{int64(harbour)}, employee.ManagedLocationIDs)
+
+		_, err = st.ReplaceEmployeeLocations(ctx, employeeId, []int{999})
+		assert.ErrorIs(t, err, ErrUnknownLocation)
+
+		replaced, err = st.ReplaceEmployeeLocations(ctx, employeeId, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{harbour}, replaced)
+		employee, err = st.SelectEmployeeByID(ctx, employeeId)
+		require.NoError(t, err)
+		assert.Empty(t, employee.ManagedLocationIDs)
+	})
+}
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE UNIQUE INDEX uniq_shift_requests_active_employee_shift ON shift_requests (employee_id, shift_id)
+WHERE status IN ('PENDING', 'APPROVED');
+
+-- +goose Down
+DROP INDEX IF EXISTS uniq_shift_requests_active_employee_shift;
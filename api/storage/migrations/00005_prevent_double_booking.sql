@@ -0,0 +1,43 @@
+-- +goose Up
+-- a shift can only have one approved assignee
+CREATE UNIQUE INDEX uniq_shift_requests_approved_shift ON shift_requests (shift_id)
+WHERE status = 'APPROVED';
+
+-- an employee can't have two approved shifts overlapping in time,
+-- the employee row is locked so concurrent approvals for the same employee are serialized
+-- +goose StatementBegin
+CREATE FUNCTION check_employee_shift_overlap() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status <> 'APPROVED' THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM 1 FROM employees WHERE id = NEW.employee_id FOR UPDATE;
+
+    IF EXISTS (
+        SELECT 1
+        FROM shift_requests sr
+        JOIN shifts s ON s.id = sr.shift_id
+        JOIN shifts ns ON ns.id = NEW.shift_id
+        WHERE sr.employee_id = NEW.employee_id
+          AND sr.status = 'APPROVED'
+          AND sr.id <> NEW.id
+          AND s.start_time < ns.end_time
+          AND ns.start_time < s.end_time
+    ) THEN
+        RAISE EXCEPTION 'employee % already has an approved shift overlapping shift %', NEW.employee_id, NEW.shift_id
+            USING ERRCODE = 'exclusion_violation', CONSTRAINT = 'shift_requests_employee_no_overlap';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_shift_requests_employee_no_overlap
+BEFORE INSERT OR UPDATE OF status, shift_id, employee_id ON shift_requests
+FOR EACH ROW EXECUTE FUNCTION check_employee_shift_overlap();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_shift_requests_employee_no_overlap ON shift_requests;
+DROP FUNCTION IF EXISTS check_employee_shift_overlap();
+DROP INDEX IF EXISTS uniq_shift_requests_approved_shift;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- recurring shifts, start/end times are wall clock times in the template timezone,
+-- an end time not after the start time means the shift ends the next day
+CREATE TABLE shift_templates (
+    id SERIAL PRIMARY KEY,
+    role_id INTEGER NOT NULL REFERENCES roles(id),
+    start_time_of_day TIME NOT NULL,
+    end_time_of_day TIME NOT NULL,
+    timezone TEXT NOT NULL,
+    recurrence TEXT NOT NULL,
+    starts_on DATE NOT NULL,
+    ends_on DATE CHECK (ends_on IS NULL OR ends_on >= starts_on),
+    generated_through DATE, -- last local date materialized into shifts
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE shifts ADD COLUMN template_id INTEGER REFERENCES shift_templates(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX uniq_shifts_template_start ON shifts (template_id, start_time)
+WHERE template_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uniq_shifts_template_start;
+ALTER TABLE shifts DROP COLUMN IF EXISTS template_id;
+DROP TABLE IF EXISTS shift_templates;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE shifts ADD COLUMN updated_at TIMESTAMP;
+
+-- snapshot of every admin edit or cancellation of a shift,
+-- shift_id is not a foreign key so the log outlives a cancelled shift
+CREATE TABLE shift_edit_logs (
+    id SERIAL PRIMARY KEY,
+    shift_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('UPDATE', 'CANCEL')),
+    old_role_id INTEGER NOT NULL,
+    old_start_time TIMESTAMP NOT NULL,
+    old_end_time TIMESTAMP NOT NULL,
+    new_role_id INTEGER, -- null on CANCEL
+    new_start_time TIMESTAMP,
+    new_end_time TIMESTAMP,
+    assignee_id INTEGER REFERENCES employees(id), -- the approved employee at the time of the edit
+    reason TEXT,
+    edited_by INTEGER NOT NULL REFERENCES employees(id),
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shift_edit_logs_shift_id ON shift_edit_logs (shift_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS shift_edit_logs;
+ALTER TABLE shifts DROP COLUMN IF EXISTS updated_at;
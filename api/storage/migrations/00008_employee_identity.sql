@@ -0,0 +1,18 @@
+-- +goose Up
+-- kratos identity of the employee, set on activation, null for employees activated before this migration
+ALTER TABLE employees ADD COLUMN identity_id TEXT UNIQUE;
+
+-- requests withdrawn on behalf of the employee, e.g. on deactivation
+ALTER TABLE shift_requests DROP CONSTRAINT shift_requests_status_check;
+ALTER TABLE shift_requests ADD CONSTRAINT shift_requests_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'WITHDRAWN'));
+
+CREATE INDEX idx_employees_role_status ON employees (role_id, status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_employees_role_status;
+UPDATE shift_requests SET status = 'REJECTED' WHERE status = 'WITHDRAWN';
+ALTER TABLE shift_requests DROP CONSTRAINT shift_requests_status_check;
+ALTER TABLE shift_requests ADD CONSTRAINT shift_requests_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED'));
+ALTER TABLE employees DROP COLUMN IF EXISTS identity_id;
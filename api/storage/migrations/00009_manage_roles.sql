@@ -0,0 +1,17 @@
+-- +goose Up
+-- roles were only seeded, ids are now generated for the roles created by admins
+CREATE SEQUENCE roles_id_seq OWNED BY roles.id;
+SELECT setval('roles_id_seq', GREATEST((SELECT MAX(id) FROM roles), 1));
+ALTER TABLE roles ALTER COLUMN id SET DEFAULT nextval('roles_id_seq');
+
+-- archived roles can't be used for new shifts or employees but still resolve for the existing ones
+ALTER TABLE roles ADD COLUMN archived_at TIMESTAMP;
+
+CREATE UNIQUE INDEX uniq_roles_active_name ON roles (lower(name))
+WHERE archived_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uniq_roles_active_name;
+ALTER TABLE roles DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE roles ALTER COLUMN id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS roles_id_seq;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- opaque refresh tokens, only their sha256 is stored.
+-- every rotation of a login shares the family, a reused token revokes the whole family
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    family_id TEXT NOT NULL,
+    identity_id TEXT NOT NULL, -- kratos identity
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    email TEXT NOT NULL,
+    role TEXT NOT NULL, -- privilege-based(admin/employee)
+    expires_at TIMESTAMP NOT NULL,
+    family_expires_at TIMESTAMP NOT NULL, -- absolute lifetime of the login, rotations can't extend it
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP, -- rotated into a new token
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_tokens;
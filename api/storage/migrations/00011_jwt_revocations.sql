@@ -0,0 +1,22 @@
+-- +goose Up
+-- access tokens revoked one by one (logout), kept until the token expires
+CREATE TABLE revoked_jwts (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_jwts_expires_at ON revoked_jwts (expires_at);
+
+-- every access token of the employee issued up to revoked_before is revoked (deactivation, log out everywhere)
+CREATE TABLE employee_jwt_revocations (
+    employee_id INTEGER PRIMARY KEY REFERENCES employees(id),
+    revoked_before TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_refresh_tokens_employee_id ON refresh_tokens (employee_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_employee_id;
+DROP TABLE IF EXISTS employee_jwt_revocations;
+DROP TABLE IF EXISTS revoked_jwts;
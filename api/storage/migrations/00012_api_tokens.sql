@@ -0,0 +1,22 @@
+-- +goose Up
+-- personal API tokens issued by admins for integrations, only their sha256 is stored.
+-- the token acts as the employee with the privilege role, restricted to the scopes (routes)
+CREATE TABLE api_tokens (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    prefix TEXT NOT NULL, -- first characters of the token, to recognize it in listings
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    role TEXT NOT NULL CHECK (role IN ('admin', 'employee')),
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    created_by INTEGER NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_tokens_employee_id ON api_tokens (employee_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_tokens;
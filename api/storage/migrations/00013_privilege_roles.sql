@@ -0,0 +1,43 @@
+-- +goose Up
+-- privilege roles group named permissions (shifts:write, requests:approve...), a permission can be restricted to
+-- a job role (roles table), e.g. a shift supervisor approving the requests of cooks only
+CREATE TABLE privilege_roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    builtin BOOLEAN NOT NULL DEFAULT FALSE, -- granted from the identity role, can't be changed
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uniq_privilege_roles_name ON privilege_roles (lower(name));
+
+CREATE TABLE privilege_role_permissions (
+    privilege_role_id INTEGER NOT NULL REFERENCES privilege_roles(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    job_role_id INTEGER, -- NULL grants the permission for every job role
+    CONSTRAINT fk_privilege_role_permissions_job_role FOREIGN KEY (job_role_id) REFERENCES roles(id)
+);
+
+CREATE UNIQUE INDEX uniq_privilege_role_permissions ON privilege_role_permissions
+    (privilege_role_id, permission, COALESCE(job_role_id, -1));
+
+CREATE TABLE employee_privilege_roles (
+    employee_id INTEGER NOT NULL,
+    privilege_role_id INTEGER NOT NULL,
+    PRIMARY KEY (employee_id, privilege_role_id),
+    CONSTRAINT fk_employee_privilege_roles_employee FOREIGN KEY (employee_id) REFERENCES employees(id),
+    CONSTRAINT fk_employee_privilege_roles_role FOREIGN KEY (privilege_role_id) REFERENCES privilege_roles(id) ON DELETE CASCADE
+);
+
+-- identities with the admin role get every permission
+INSERT INTO privilege_roles (name, builtin) VALUES ('admin', TRUE);
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, p
+FROM privilege_roles,
+     unnest(ARRAY['shifts:read', 'shifts:write', 'requests:read', 'requests:approve', 'employees:read',
+                  'employees:manage', 'roles:read', 'roles:manage', 'access:manage']) AS p
+WHERE name = 'admin';
+
+-- +goose Down
+DROP TABLE IF EXISTS employee_privilege_roles;
+DROP TABLE IF EXISTS privilege_role_permissions;
+DROP TABLE IF EXISTS privilege_roles;
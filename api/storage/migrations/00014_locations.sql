@@ -0,0 +1,60 @@
+-- +goose Up
+-- every shift, employee and template belongs to a location (branch), the existing rows to the seeded one
+CREATE TABLE locations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uniq_locations_name ON locations (lower(name));
+
+INSERT INTO locations (id, name) VALUES (1, 'Main');
+SELECT setval('locations_id_seq', 1);
+
+ALTER TABLE employees ADD COLUMN location_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE employees ALTER COLUMN location_id DROP DEFAULT;
+ALTER TABLE employees ADD CONSTRAINT fk_employees_location FOREIGN KEY (location_id) REFERENCES locations(id);
+
+ALTER TABLE shifts ADD COLUMN location_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shifts ALTER COLUMN location_id DROP DEFAULT;
+ALTER TABLE shifts ADD CONSTRAINT fk_shifts_location FOREIGN KEY (location_id) REFERENCES locations(id);
+CREATE INDEX idx_shifts_location_start_time ON shifts (location_id, start_time);
+
+ALTER TABLE shift_templates ADD COLUMN location_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shift_templates ALTER COLUMN location_id DROP DEFAULT;
+ALTER TABLE shift_templates ADD CONSTRAINT fk_shift_templates_location FOREIGN KEY (location_id) REFERENCES locations(id);
+
+-- kept on the log so the edits of cancelled shifts stay scoped to their location
+ALTER TABLE shift_edit_logs ADD COLUMN location_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shift_edit_logs ALTER COLUMN location_id DROP DEFAULT;
+ALTER TABLE shift_edit_logs ADD CONSTRAINT fk_shift_edit_logs_location FOREIGN KEY (location_id) REFERENCES locations(id);
+
+-- NULL for the roles shared by every location
+ALTER TABLE roles ADD COLUMN location_id INTEGER;
+ALTER TABLE roles ADD CONSTRAINT fk_roles_location FOREIGN KEY (location_id) REFERENCES locations(id);
+
+-- the locations an employee manages on top of their own, e.g. an area manager
+CREATE TABLE employee_locations (
+    employee_id INTEGER NOT NULL,
+    location_id INTEGER NOT NULL,
+    PRIMARY KEY (employee_id, location_id),
+    CONSTRAINT fk_employee_locations_employee FOREIGN KEY (employee_id) REFERENCES employees(id),
+    CONSTRAINT fk_employee_locations_location FOREIGN KEY (location_id) REFERENCES locations(id)
+);
+
+-- admins keep managing every location
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, p
+FROM privilege_roles, unnest(ARRAY['locations:read', 'locations:manage']) AS p
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission IN ('locations:read', 'locations:manage');
+DROP TABLE IF EXISTS employee_locations;
+ALTER TABLE roles DROP COLUMN IF EXISTS location_id;
+ALTER TABLE shift_edit_logs DROP COLUMN IF EXISTS location_id;
+ALTER TABLE shift_templates DROP COLUMN IF EXISTS location_id;
+DROP INDEX IF EXISTS idx_shifts_location_start_time;
+ALTER TABLE shifts DROP COLUMN IF EXISTS location_id;
+ALTER TABLE employees DROP COLUMN IF EXISTS location_id;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- the weekly windows an employee can work in, wall clock times in the window timezone,
+-- an end time not after the start time means the window ends the next day.
+-- an employee without windows is considered available at any time
+CREATE TABLE employee_availabilities (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 is sunday
+    start_time_of_day TIME NOT NULL,
+    end_time_of_day TIME NOT NULL,
+    timezone TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_employee_availabilities_employee_id ON employee_availabilities (employee_id);
+
+-- one-off periods the employee can't work, e.g. a doctor's appointment
+CREATE TABLE employee_unavailabilities (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL CHECK (end_time > start_time),
+    reason TEXT NOT NULL CHECK (char_length(reason) > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_employee_unavailabilities_employee_start ON employee_unavailabilities (employee_id, start_time);
+
+-- +goose Down
+DROP TABLE IF EXISTS employee_unavailabilities;
+DROP TABLE IF EXISTS employee_availabilities;
@@ -0,0 +1,105 @@
+-- +goose Up
+-- kinds of leave, the balance of the tracked ones limits the leave an employee can take
+-- and is credited every month to the active employees
+CREATE TABLE leave_types (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    tracks_balance BOOLEAN NOT NULL,
+    monthly_accrual NUMERIC(6, 2) NOT NULL DEFAULT 0 CHECK (monthly_accrual >= 0) -- days
+);
+
+INSERT INTO leave_types (code, name, tracks_balance, monthly_accrual) VALUES
+('ANNUAL', 'Annual leave', TRUE, 2.08),
+('SICK', 'Sick leave', FALSE, 0),
+('UNPAID', 'Unpaid leave', FALSE, 0);
+
+-- whole calendar days from start_date through end_date, same lifecycle as shift_requests
+CREATE TABLE leave_requests (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    leave_type_id INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL CHECK (end_date >= start_date),
+    days NUMERIC(6, 2) NOT NULL CHECK (days > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'WITHDRAWN')),
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP,
+    reviewed_by INTEGER REFERENCES employees(id),
+    CONSTRAINT fk_leave_requests_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types(id)
+);
+
+CREATE INDEX idx_leave_requests_employee_start_date ON leave_requests (employee_id, start_date);
+
+-- an employee can't have two active leave requests overlapping in time,
+-- the employee row is locked so concurrent requests of the same employee are serialized
+-- +goose StatementBegin
+CREATE FUNCTION check_employee_leave_overlap() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status NOT IN ('PENDING', 'APPROVED') THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM 1 FROM employees WHERE id = NEW.employee_id FOR UPDATE;
+
+    IF EXISTS (
+        SELECT 1
+        FROM leave_requests lr
+        WHERE lr.employee_id = NEW.employee_id
+          AND lr.status IN ('PENDING', 'APPROVED')
+          AND lr.id <> NEW.id
+          AND lr.start_date <= NEW.end_date
+          AND NEW.start_date <= lr.end_date
+    ) THEN
+        RAISE EXCEPTION 'employee % already has leave overlapping % - %', NEW.employee_id, NEW.start_date, NEW.end_date
+            USING ERRCODE = 'exclusion_violation', CONSTRAINT = 'leave_requests_employee_no_overlap';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_leave_requests_employee_no_overlap
+BEFORE INSERT OR UPDATE OF status, start_date, end_date, employee_id ON leave_requests
+FOR EACH ROW EXECUTE FUNCTION check_employee_leave_overlap();
+
+-- ledger of the balances, the balance of an employee for a leave type is the sum of its entries.
+-- accruals are credited once per month, approved leave is debited once per request
+CREATE TABLE leave_balance_entries (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL,
+    leave_type_id INTEGER NOT NULL REFERENCES leave_types(id),
+    days NUMERIC(6, 2) NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('ACCRUAL', 'ADJUSTMENT', 'LEAVE')),
+    accrual_month DATE, -- first day of the month of an ACCRUAL
+    leave_request_id INTEGER REFERENCES leave_requests(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES employees(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_leave_balance_entries_employee FOREIGN KEY (employee_id) REFERENCES employees(id)
+);
+
+CREATE INDEX idx_leave_balance_entries_employee ON leave_balance_entries (employee_id, leave_type_id);
+CREATE UNIQUE INDEX uniq_leave_balance_entries_accrual ON leave_balance_entries (employee_id, leave_type_id, accrual_month)
+WHERE kind = 'ACCRUAL';
+CREATE UNIQUE INDEX uniq_leave_balance_entries_request ON leave_balance_entries (leave_request_id)
+WHERE leave_request_id IS NOT NULL;
+
+-- set on the approved shift requests overlapping leave approved afterwards, for the managers to reassign
+ALTER TABLE shift_requests ADD COLUMN conflicting_leave_request_id INTEGER REFERENCES leave_requests(id);
+
+-- admins keep managing everything
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, p
+FROM privilege_roles, unnest(ARRAY['leave:read', 'leave:approve', 'leave:manage']) AS p
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission IN ('leave:read', 'leave:approve', 'leave:manage');
+ALTER TABLE shift_requests DROP COLUMN IF EXISTS conflicting_leave_request_id;
+DROP TABLE IF EXISTS leave_balance_entries;
+DROP TRIGGER IF EXISTS trg_leave_requests_employee_no_overlap ON leave_requests;
+DROP FUNCTION IF EXISTS check_employee_leave_overlap();
+DROP TABLE IF EXISTS leave_requests;
+DROP TABLE IF EXISTS leave_types;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- an employee offers one of their approved shift requests to the colleagues of the shift role,
+-- a colleague claims it outright or proposes one of their own approved shift requests in exchange,
+-- then an admin approves the transfer which reassigns the shift requests.
+-- the rows are kept once closed as the history of the transfers
+CREATE TABLE shift_swaps (
+    id SERIAL PRIMARY KEY,
+    shift_request_id INTEGER NOT NULL REFERENCES shift_requests(id) ON DELETE CASCADE,
+    offered_by INTEGER NOT NULL REFERENCES employees(id),
+    status TEXT NOT NULL CHECK (status IN ('OPEN', 'CLAIMED', 'APPROVED', 'REJECTED', 'CANCELLED')),
+    claimed_by INTEGER REFERENCES employees(id),
+    claimed_at TIMESTAMP,
+    -- the claimant's shift request given in exchange, null for a give-away
+    swap_shift_request_id INTEGER REFERENCES shift_requests(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP,
+    reviewed_by INTEGER REFERENCES employees(id),
+    CHECK (status = 'OPEN' OR status = 'CANCELLED' OR claimed_by IS NOT NULL)
+);
+
+-- a shift request can only be offered once at a time
+CREATE UNIQUE INDEX uniq_shift_swaps_active_request ON shift_swaps (shift_request_id)
+WHERE status IN ('OPEN', 'CLAIMED');
+
+CREATE INDEX idx_shift_swaps_status ON shift_swaps (status);
+
+-- +goose Down
+DROP TABLE IF EXISTS shift_swaps;
@@ -0,0 +1,94 @@
+-- +goose Up
+-- a shift is staffed by up to headcount employees, templates pass their headcount on to the generated shifts
+ALTER TABLE shifts ADD COLUMN headcount INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shifts ADD CONSTRAINT shifts_headcount_positive CHECK (headcount > 0);
+ALTER TABLE shift_templates ADD COLUMN headcount INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shift_templates ADD CONSTRAINT shift_templates_headcount_positive CHECK (headcount > 0);
+
+-- replaced by the headcount guard below
+DROP INDEX IF EXISTS uniq_shift_requests_approved_shift;
+
+-- a shift can't have more approved requests than its headcount,
+-- the shift row is locked so concurrent approvals for the same shift are serialized
+-- +goose StatementBegin
+CREATE FUNCTION check_shift_headcount() RETURNS trigger AS $$
+DECLARE
+    max_approved INTEGER;
+BEGIN
+    IF NEW.status <> 'APPROVED' THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT headcount INTO max_approved FROM shifts WHERE id = NEW.shift_id FOR UPDATE;
+
+    IF (
+        SELECT COUNT(*)
+        FROM shift_requests
+        WHERE shift_id = NEW.shift_id
+          AND status = 'APPROVED'
+          AND id <> NEW.id
+    ) >= max_approved THEN
+        RAISE EXCEPTION 'shift % is fully staffed', NEW.shift_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'shift_requests_shift_headcount';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_shift_requests_shift_headcount
+BEFORE INSERT OR UPDATE OF status, shift_id ON shift_requests
+FOR EACH ROW EXECUTE FUNCTION check_shift_headcount();
+
+-- the headcount of a shift can't be lowered below its approved requests
+-- +goose StatementBegin
+CREATE FUNCTION check_shift_headcount_lowered() RETURNS trigger AS $$
+BEGIN
+    IF NEW.headcount >= OLD.headcount THEN
+        RETURN NEW;
+    END IF;
+
+    IF (
+        SELECT COUNT(*)
+        FROM shift_requests
+        WHERE shift_id = NEW.id
+          AND status = 'APPROVED'
+    ) > NEW.headcount THEN
+        RAISE EXCEPTION 'shift % has more approved requests than a headcount of %', NEW.id, NEW.headcount
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'shifts_headcount_approved';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_shifts_headcount_approved
+BEFORE UPDATE OF headcount ON shifts
+FOR EACH ROW EXECUTE FUNCTION check_shift_headcount_lowered();
+
+-- a shift edit snapshots every approved employee rather than a single one, and the headcount
+ALTER TABLE shift_edit_logs ADD COLUMN assignee_ids INTEGER[] NOT NULL DEFAULT '{}';
+UPDATE shift_edit_logs SET assignee_ids = ARRAY[assignee_id] WHERE assignee_id IS NOT NULL;
+ALTER TABLE shift_edit_logs DROP COLUMN assignee_id;
+ALTER TABLE shift_edit_logs ADD COLUMN old_headcount INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE shift_edit_logs ADD COLUMN new_headcount INTEGER; -- null on CANCEL
+UPDATE shift_edit_logs SET new_headcount = 1 WHERE action = 'UPDATE';
+
+-- +goose Down
+ALTER TABLE shift_edit_logs DROP COLUMN new_headcount;
+ALTER TABLE shift_edit_logs DROP COLUMN old_headcount;
+ALTER TABLE shift_edit_logs ADD COLUMN assignee_id INTEGER REFERENCES employees(id);
+UPDATE shift_edit_logs SET assignee_id = assignee_ids[1];
+ALTER TABLE shift_edit_logs DROP COLUMN assignee_ids;
+
+DROP TRIGGER IF EXISTS trg_shifts_headcount_approved ON shifts;
+DROP FUNCTION IF EXISTS check_shift_headcount_lowered();
+DROP TRIGGER IF EXISTS trg_shift_requests_shift_headcount ON shift_requests;
+DROP FUNCTION IF EXISTS check_shift_headcount();
+
+-- fails while a shift has several approved requests
+CREATE UNIQUE INDEX uniq_shift_requests_approved_shift ON shift_requests (shift_id)
+WHERE status = 'APPROVED';
+
+ALTER TABLE shift_templates DROP COLUMN IF EXISTS headcount;
+ALTER TABLE shifts DROP COLUMN IF EXISTS headcount;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- the attendance of an approved shift request, clocked in once and clocked out once
+CREATE TABLE time_entries (
+    id SERIAL PRIMARY KEY,
+    shift_request_id INTEGER NOT NULL REFERENCES shift_requests(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    clock_in TIMESTAMP NOT NULL,
+    clock_out TIMESTAMP CHECK (clock_out >= clock_in),
+    CONSTRAINT uniq_time_entries_shift_request UNIQUE (shift_request_id)
+);
+
+-- an employee is clocked in for one shift at a time
+CREATE UNIQUE INDEX uniq_time_entries_open_employee ON time_entries (employee_id)
+WHERE clock_out IS NULL;
+
+CREATE INDEX idx_time_entries_employee_clock_in ON time_entries (employee_id, clock_in);
+
+-- breaks taken while clocked in, not counted as worked time
+CREATE TABLE time_entry_breaks (
+    id SERIAL PRIMARY KEY,
+    time_entry_id INTEGER NOT NULL REFERENCES time_entries(id) ON DELETE CASCADE,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP CHECK (end_time >= start_time)
+);
+
+-- one break at a time
+CREATE UNIQUE INDEX uniq_time_entry_breaks_open ON time_entry_breaks (time_entry_id)
+WHERE end_time IS NULL;
+
+-- admins keep managing everything
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, 'timesheets:read'
+FROM privilege_roles
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission = 'timesheets:read';
+DROP TABLE IF EXISTS time_entry_breaks;
+DROP TABLE IF EXISTS time_entries;
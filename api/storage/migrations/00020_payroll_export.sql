@@ -0,0 +1,9 @@
+-- +goose Up
+-- admins keep managing everything
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, 'payroll:export'
+FROM privilege_roles
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission = 'payroll:export';
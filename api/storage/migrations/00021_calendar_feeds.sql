@@ -0,0 +1,21 @@
+-- +goose Up
+-- iCalendar feeds of approved shifts. calendar apps can't send an Authorization header,
+-- so the token is part of the feed URL and only its sha256 is stored
+CREATE TABLE calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    -- the employee whose shifts are listed, or the creator of a job role feed
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    -- a job role feed lists every approved shift of the role, null for the shifts of the employee
+    role_id INTEGER CONSTRAINT fk_calendar_feeds_role REFERENCES roles(id),
+    location_ids INTEGER[], -- the locations of a job role feed, null for every location
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- an employee has one personal feed at a time, a new one replaces it
+CREATE UNIQUE INDEX uniq_calendar_feeds_employee ON calendar_feeds (employee_id)
+WHERE role_id IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_feeds;
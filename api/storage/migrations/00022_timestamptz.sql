@@ -0,0 +1,133 @@
+-- +goose Up
+-- the scheduling times were stored as UTC wall clock in TIMESTAMP columns, which drops the offset of
+-- the times written with another zone. they become instants, the existing values are read as UTC
+ALTER TABLE shifts
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shift_edit_logs
+    ALTER COLUMN old_start_time TYPE TIMESTAMPTZ USING old_start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN old_end_time TYPE TIMESTAMPTZ USING old_end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN new_start_time TYPE TIMESTAMPTZ USING new_start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN new_end_time TYPE TIMESTAMPTZ USING new_end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN edited_at TYPE TIMESTAMPTZ USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE employee_unavailabilities
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC';
+
+ALTER TABLE time_entries
+    ALTER COLUMN clock_in TYPE TIMESTAMPTZ USING clock_in AT TIME ZONE 'UTC',
+    ALTER COLUMN clock_out TYPE TIMESTAMPTZ USING clock_out AT TIME ZONE 'UTC';
+
+ALTER TABLE time_entry_breaks
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC';
+
+-- so are the request, review and token times, compared with the instants written by the api
+ALTER TABLE shift_requests
+    ALTER COLUMN requested_at TYPE TIMESTAMPTZ USING requested_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMPTZ USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE leave_requests
+    ALTER COLUMN requested_at TYPE TIMESTAMPTZ USING requested_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMPTZ USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shift_swaps
+    ALTER COLUMN claimed_at TYPE TIMESTAMPTZ USING claimed_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMPTZ USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN family_expires_at TYPE TIMESTAMPTZ USING family_expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN used_at TYPE TIMESTAMPTZ USING used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ USING revoked_at AT TIME ZONE 'UTC';
+
+ALTER TABLE revoked_jwts
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE employee_jwt_revocations
+    ALTER COLUMN revoked_before TYPE TIMESTAMPTZ USING revoked_before AT TIME ZONE 'UTC';
+
+ALTER TABLE api_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN last_used_at TYPE TIMESTAMPTZ USING last_used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ USING revoked_at AT TIME ZONE 'UTC';
+
+ALTER TABLE calendar_feeds
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ USING revoked_at AT TIME ZONE 'UTC';
+
+-- the business timezone of the location, the shift times of its job roles are rendered in it
+ALTER TABLE locations ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC' CHECK (char_length(timezone) > 0);
+
+-- +goose Down
+ALTER TABLE locations DROP COLUMN IF EXISTS timezone;
+
+ALTER TABLE calendar_feeds
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMP USING revoked_at AT TIME ZONE 'UTC';
+
+ALTER TABLE api_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN last_used_at TYPE TIMESTAMP USING last_used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMP USING revoked_at AT TIME ZONE 'UTC';
+
+ALTER TABLE employee_jwt_revocations
+    ALTER COLUMN revoked_before TYPE TIMESTAMP USING revoked_before AT TIME ZONE 'UTC';
+
+ALTER TABLE revoked_jwts
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN family_expires_at TYPE TIMESTAMP USING family_expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN used_at TYPE TIMESTAMP USING used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMP USING revoked_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shift_swaps
+    ALTER COLUMN claimed_at TYPE TIMESTAMP USING claimed_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMP USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE leave_requests
+    ALTER COLUMN requested_at TYPE TIMESTAMP USING requested_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMP USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shift_requests
+    ALTER COLUMN requested_at TYPE TIMESTAMP USING requested_at AT TIME ZONE 'UTC',
+    ALTER COLUMN reviewed_at TYPE TIMESTAMP USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE time_entry_breaks
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC';
+
+ALTER TABLE time_entries
+    ALTER COLUMN clock_in TYPE TIMESTAMP USING clock_in AT TIME ZONE 'UTC',
+    ALTER COLUMN clock_out TYPE TIMESTAMP USING clock_out AT TIME ZONE 'UTC';
+
+ALTER TABLE employee_unavailabilities
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC';
+
+ALTER TABLE shift_edit_logs
+    ALTER COLUMN old_start_time TYPE TIMESTAMP USING old_start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN old_end_time TYPE TIMESTAMP USING old_end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN new_start_time TYPE TIMESTAMP USING new_start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN new_end_time TYPE TIMESTAMP USING new_end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN edited_at TYPE TIMESTAMP USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shifts
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
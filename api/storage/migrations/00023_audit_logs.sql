@@ -0,0 +1,48 @@
+-- +goose Up
+-- every state-changing action, written in the transaction of the change. the actor is not a foreign key
+-- so that the log outlives what it refers to, the ids are those at the time of the action
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER, -- the employee of the caller, null for the system and the callers not linked to an employee
+    actor TEXT NOT NULL, -- the identity or API token of the caller, 'system' for the scheduled jobs
+    action TEXT NOT NULL CHECK (char_length(action) > 0),
+    entity_type TEXT NOT NULL CHECK (char_length(entity_type) > 0),
+    entity_id INTEGER NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_logs_actor ON audit_logs (actor_id, created_at);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- the log is append-only
+-- +goose StatementBegin
+CREATE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit logs are append-only'
+        USING ERRCODE = 'insufficient_privilege', CONSTRAINT = 'audit_logs_append_only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_audit_logs_append_only
+BEFORE UPDATE OR DELETE ON audit_logs
+FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER trg_audit_logs_no_truncate
+BEFORE TRUNCATE ON audit_logs
+FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
+
+-- admins keep managing everything
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, 'audit:read'
+FROM privilege_roles
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission = 'audit:read';
+DROP TABLE IF EXISTS audit_logs;
+DROP FUNCTION IF EXISTS reject_audit_log_change();
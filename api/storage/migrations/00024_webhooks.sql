@@ -0,0 +1,55 @@
+-- +goose Up
+-- the roster events, written in the transaction of the change so that an event exists if and only if the change
+-- was committed. the dispatcher fans them out to the webhooks registered at that time
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL CHECK (char_length(event_type) > 0),
+    entity_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMPTZ -- null until fanned out
+);
+
+CREATE INDEX idx_outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+-- the endpoints notified of the events, the secret signs the deliveries so it's kept in clear
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL CHECK (url ~ '^https?://'),
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- empty for every event type
+    created_by INTEGER NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ -- the deliveries to a deleted webhook are kept, but not attempted anymore
+);
+
+-- an event delivered to a webhook, retried with a backoff until delivered or given up on (DEAD)
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id),
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER, -- null when the last attempt got no response
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uniq_webhook_deliveries_event_webhook UNIQUE (event_id, webhook_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries (status, created_at);
+
+-- admins keep managing everything
+INSERT INTO privilege_role_permissions (privilege_role_id, permission)
+SELECT id, 'webhooks:manage'
+FROM privilege_roles
+WHERE builtin;
+
+-- +goose Down
+DELETE FROM privilege_role_permissions WHERE permission = 'webhooks:manage';
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PayrollShift is an approved shift request along with its time entry, if any
+type PayrollShift struct {
+	ShiftRequestID int        `db:"shift_request_id"`
+	EmployeeID     int        `db:"employee_id"`
+	EmployeeName   string     `db:"employee_name"`
+	RoleID         int        `db:"role_id"`
+	RoleName       string     `db:"role_name"`
+	LocationID     int        `db:"location_id"`
+	Timezone       string     `db:"timezone"` // of the location
+	StartTime      time.Time  `db:"start_time"`
+	EndTime        time.Time  `db:"end_time"`
+	ClockIn        *time.Time `db:"clock_in"`
+	ClockOut       *time.Time `db:"clock_out"`
+	BreakSeconds   int64      `db:"break_seconds"` // of the ended breaks
+}
+
+// EachPayrollShift calls fn with every approved shift starting within [start, end), one row at a time
+// ordered by employee then start time, so that exports don't hold the whole period in memory.
+// the Status of the filter is ignored, iteration stops at the first error of fn
+func (s *Storage) EachPayrollShift(ctx context.Context, filter ListShiftRequestFilter, start, end time.Time,
+	fn func(PayrollShift) error) error {
+	query := `
+		SELECT sr.id AS shift_request_id, sr.employee_id, e.name AS employee_name,
+			s.role_id, r.name AS role_name, s.location_id, l.timezone, s.start_time, s.end_time,
+			te.clock_in, te.clock_out,
+			COALESCE((
+				SELECT SUM(EXTRACT(EPOCH FROM b.end_time - b.start_time))
+				FROM time_entry_breaks b
+				WHERE b.time_entry_id = te.id
+			), 0)::BIGINT AS break_seconds
+		FROM shift_requests sr
+		JOIN shifts s ON sr.shift_id = s.id
+		JOIN employees e ON sr.employee_id = e.id
+		JOIN roles r ON s.role_id = r.id
+		JOIN locations l ON s.location_id = l.id
+		LEFT JOIN time_entries te ON te.shift_request_id = sr.id
+		WHERE sr.status = 'APPROVED' AND s.start_time >= $1 AND s.start_time < $2
+	`
+	args := []interface{}{start, end}
+	argPos := len(args) + 1
+
+	if filter.EmployeeID != 0 {
+		query += fmt.Sprintf(" AND sr.employee_id = $%d", argPos)
+		args = append(args, filter.EmployeeID)
+		argPos++
+	}
+	if filter.RoleID != 0 {
+		query += fmt.Sprintf(" AND s.role_id = $%d", argPos)
+		args = append(args, filter.RoleID)
+		argPos++
+	}
+	if filter.RoleIDs != nil {
+		query += fmt.Sprintf(" AND s.role_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.RoleIDs))
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		query += fmt.Sprintf(" AND s.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+	}
+	query += " ORDER BY sr.employee_id, s.start_time, s.id"
+
+	rows, err := s.conn(ctx).QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rec PayrollShift
+		if err := rows.StructScan(&rec); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
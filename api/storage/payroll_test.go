@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEachPayrollShift(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+		start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+		var requestIds []int
+		for day := 0; day < 3; day++ {
+			shiftStart := start.AddDate(0, 0, day)
+			shiftId, err := st.CreateNewShiftSchedule(ctx, 3, DefaultLocationID, shiftStart, shiftStart.Add(8*time.Hour))
+			require.NoError(t, err)
+			requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+			require.NoError(t, err)
+			requestIds = append(requestIds, requestId)
+		}
+		// the last request stays pending
+		require.NoError(t, st.ReviewShiftRequest(ctx, requestIds[0], "APPROVED", employeeId))
+		require.NoError(t, st.ReviewShiftRequest(ctx, requestIds[1], "APPROVED", employeeId))
+
+		entryId, err := st.CreateTimeEntry(ctx, requestIds[1], employeeId, start.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		_, err = st.StartTimeEntryBreak(ctx, entryId, start.AddDate(0, 0, 1).Add(4*time.Hour))
+		require.NoError(t, err)
+		_, err = st.EndTimeEntryBreak(ctx, entryId, start.AddDate(0, 0, 1).Add(4*time.Hour+30*time.Minute))
+		require.NoError(t, err)
+		require.NoError(t, st.ClockOutTimeEntry(ctx, entryId, start.AddDate(0, 0, 1).Add(8*time.Hour)))
+
+		var shifts []PayrollShift
+		err = st.EachPayrollShift(ctx, ListShiftRequestFilter{EmployeeID: employeeId}, start, start.AddDate(0, 0, 7),
+			func(sh PayrollShift) error {
+				shifts = append(shifts, sh)
+				return nil
+			})
+		require.NoError(t, err)
+		require.Len(t, shifts, 2)
+		assert.Equal(t, requestIds[0], shifts[0].ShiftRequestID)
+		assert.Nil(t, shifts[0].ClockIn)
+		assert.Equal(t, "Waiter", shifts[1].EmployeeName)
+		assert.NotNil(t, shifts[1].ClockOut)
+		assert.Equal(t, int64(1800), shifts[1].BreakSeconds)
+	})
+}
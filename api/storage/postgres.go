@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"payd/util"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -34,7 +35,8 @@ func NewStorage(opts ...StorageOption) (*Storage, error) {
 		}
 	}
 
-	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%d dbname=%s sslmode=%s",
+	// the session timezone is pinned so that TIMESTAMPTZ values are read in UTC whatever the server default
+	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%d dbname=%s sslmode=%s timezone=UTC",
 		s.user, s.password, s.host, s.port, s.dbname, s.sslmode)
 	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
@@ -75,6 +77,21 @@ func getTx(ctx context.Context) *tx {
 	return nil
 }
 
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx
+type queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// conn returns the transaction bound to ctx if any, otherwise the db pool
+func (s *Storage) conn(ctx context.Context) queryer {
+	if t := getTx(ctx); t != nil {
+		return t
+	}
+	return s.db
+}
+
 func (s *Storage) Commit(ctx context.Context) error {
 	t := getTx(ctx)
 	if t == nil {
@@ -101,6 +118,20 @@ func (s *Storage) Rollback(ctx context.Context) error {
 	return t.Rollback()
 }
 
+// Finish commits or rolls back the transaction bound to ctx depending on err.
+// defer only after calling NewTransacton, with a pointer to the named error result
+func (s *Storage) Finish(ctx context.Context, err *error) {
+	if *err != nil {
+		if rbErr := s.Rollback(ctx); rbErr != nil {
+			util.Log().WithContext(ctx).WithError(rbErr).Error("failed rollback")
+		}
+		return
+	}
+	if *err = s.Commit(ctx); *err != nil {
+		util.Log().WithContext(ctx).WithError(*err).Error("failed commit")
+	}
+}
+
 func (s *Storage) RunMigrations(migrationDir string) error {
 	return goose.Run("up", s.db.DB, migrationDir)
 }
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PrivilegeRole struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	Builtin   bool      `db:"builtin"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type PrivilegeRolePermission struct {
+	PrivilegeRoleID int    `db:"privilege_role_id"`
+	Permission      string `db:"permission"`
+	JobRoleID       *int   `db:"job_role_id"` // nil for every job role
+}
+
+type EmployeePrivilegeRole struct {
+	EmployeeID      int `db:"employee_id"`
+	PrivilegeRoleID int `db:"privilege_role_id"`
+}
+
+func (s *Storage) SelectAllPrivilegeRoles(ctx context.Context) ([]PrivilegeRole, error) {
+	var recs []PrivilegeRole
+	query := `SELECT id, name, builtin, created_at FROM privilege_roles ORDER BY id`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+func (s *Storage) SelectPrivilegeRoleByID(ctx context.Context, id int) (*PrivilegeRole, error) {
+	var rec PrivilegeRole
+	query := `SELECT id, name, builtin, created_at FROM privilege_roles WHERE id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+func (s *Storage) SelectAllPrivilegeRolePermissions(ctx context.Context) ([]PrivilegeRolePermission, error) {
+	var recs []PrivilegeRolePermission
+	query := `SELECT privilege_role_id, permission, job_role_id FROM privilege_role_permissions`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+func (s *Storage) SelectAllEmployeePrivilegeRoles(ctx context.Context) ([]EmployeePrivilegeRole, error) {
+	var recs []EmployeePrivilegeRole
+	query := `SELECT employee_id, privilege_role_id FROM employee_privilege_roles`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+// CreatePrivilegeRole returns ErrDuplicatePrivilegeRoleName if the name is taken, case insensitively
+func (s *Storage) CreatePrivilegeRole(ctx context.Context, name string) (int, error) {
+	var id int
+	query := `INSERT INTO privilege_roles (name) VALUES ($1) RETURNING id`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, name).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// DeletePrivilegeRole deletes a non builtin role along with its permissions and assignments,
+// returns false if there is no such role
+func (s *Storage) DeletePrivilegeRole(ctx context.Context, id int) (bool, error) {
+	query := `DELETE FROM privilege_roles WHERE id = $1 AND NOT builtin`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReplacePrivilegeRolePermissions replaces every permission of the role, returns ErrUnknownJobRole for a missing job role
+func (s *Storage) ReplacePrivilegeRolePermissions(ctx context.Context, id int, permissions []PrivilegeRolePermission) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM privilege_role_permissions WHERE privilege_role_id = $1`, id); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		query := `INSERT INTO privilege_role_permissions (privilege_role_id, permission, job_role_id) VALUES ($1, $2, $3)`
+		if _, err := s.conn(ctx).ExecContext(ctx, query, id, p.Permission, p.JobRoleID); err != nil {
+			return mapConstraintError(err)
+		}
+	}
+	return nil
+}
+
+// ReplaceEmployeePrivilegeRoles replaces the privilege roles assigned to the employee,
+// returns ErrUnknownEmployee or ErrUnknownPrivilegeRole for missing ones
+func (s *Storage) ReplaceEmployeePrivilegeRoles(ctx context.Context, employeeId int, privilegeRoleIds []int) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM employee_privilege_roles WHERE employee_id = $1`, employeeId); err != nil {
+		return err
+	}
+	if len(privilegeRoleIds) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO employee_privilege_roles (employee_id, privilege_role_id)
+		SELECT $1, unnest($2::INTEGER[])
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, employeeId, pq.Array(privilegeRoleIds))
+	return mapConstraintError(err)
+}
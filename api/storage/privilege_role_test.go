@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivilegeRole(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		employeeID, err := st.CreateNewEmployee(ctx, "Supervisor", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		roles, err := st.SelectAllPrivilegeRoles(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, roles, 1) {
+			assert.Equal(t, "admin", roles[0].Name)
+			assert.True(t, roles[0].Builtin)
+		}
+
+		id, err := st.CreatePrivilegeRole(ctx, "Shift supervisor")
+		assert.NoError(t, err)
+		_, err = st.CreatePrivilegeRole(ctx, "shift SUPERVISOR")
+		assert.ErrorIs(t, err, ErrDuplicatePrivilegeRoleName)
+
+		jobRole := 1
+		assert.NoError(t, st.ReplacePrivilegeRolePermissions(ctx, id, []PrivilegeRolePermission{
+			{Permission: "requests:read", JobRoleID: &jobRole},
+			{Permission: "requests:approve", JobRoleID: &jobRole},
+			{Permission: "shifts:read"},
+		}))
+		unknownJobRole := 999
+		err = st.ReplacePrivilegeRolePermissions(ctx, id, []PrivilegeRolePermission{{Permission: "requests:read", JobRoleID: &unknownJobRole}})
+		assert.ErrorIs(t, err, ErrUnknownJobRole)
+
+		perms, err := st.SelectAllPrivilegeRolePermissions(ctx)
+		assert.NoError(t, err)
+		var supervisorPerms []PrivilegeRolePermission
+		for _, p := range perms {
+			if p.PrivilegeRoleID == id {
+				supervisorPerms = append(supervisorPerms, p)
+			}
+		}
+		assert.Len(t, supervisorPerms, 3)
+
+		assert.NoError(t, st.ReplaceEmployeePrivilegeRoles(ctx, employeeID, []int{id}))
+		assert.ErrorIs(t, st.ReplaceEmployeePrivilegeRoles(ctx, employeeID+100, []int{id}), ErrUnknownEmployee)
+		assert.ErrorIs(t, st.ReplaceEmployeePrivilegeRoles(ctx, employeeID, []int{id + 100}), ErrUnknownPrivilegeRole)
+		assignments, err := st.SelectAllEmployeePrivilegeRoles(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []EmployeePrivilegeRole{{EmployeeID: employeeID, PrivilegeRoleID: id}}, assignments)
+
+		// the builtin role can't be deleted, deleting a role takes it away from its holders
+		deleted, err := st.DeletePrivilegeRole(ctx, roles[0].ID)
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+		deleted, err = st.DeletePrivilegeRole(ctx, id)
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		assignments, err = st.SelectAllEmployeePrivilegeRoles(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, assignments)
+	})
+}
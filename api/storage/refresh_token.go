@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type RefreshToken struct {
+	ID              int        `db:"id"`
+	TokenHash       string     `db:"token_hash"`
+	FamilyID        string     `db:"family_id"`
+	IdentityID      string     `db:"identity_id"`
+	EmployeeID      int        `db:"employee_id"`
+	Email           string     `db:"email"`
+	Role            string     `db:"role"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	FamilyExpiresAt time.Time  `db:"family_expires_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UsedAt          *time.Time `db:"used_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+}
+
+// CreateRefreshToken stores the token, the expirations are expected in UTC
+func (s *Storage) CreateRefreshToken(ctx context.Context, t RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, family_id, identity_id, employee_id, email, role, expires_at, family_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, t.TokenHash, t.FamilyID, t.IdentityID, t.EmployeeID, t.Email, t.Role,
+		t.ExpiresAt, t.FamilyExpiresAt)
+	return err
+}
+
+// LockRefreshTokenByHash selects the token and locks its row until the end of the transaction bound to ctx,
+// so a token can only be rotated once
+func (s *Storage) LockRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var rec RefreshToken
+	query := `
+		SELECT id, token_hash, family_id, identity_id, employee_id, email, role, expires_at, family_expires_at,
+			created_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, tokenHash)
+	return &rec, err
+}
+
+func (s *Storage) MarkRefreshTokenUsed(ctx context.Context, id int) error {
+	query := `UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+// RevokeRefreshTokenFamily revokes every token of the family, returns the number of revoked tokens
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyId string) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, familyId)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeRefreshTokenFamilyByHash revokes the family of the token, returns the number of revoked tokens
+func (s *Storage) RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string) (int64, error) {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)
+		  AND revoked_at IS NULL
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeRefreshTokensByEmployeeID revokes every token family of the employee, returns the number of revoked tokens
+func (s *Storage) RevokeRefreshTokensByEmployeeID(ctx context.Context, employeeId int) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE employee_id = $1 AND revoked_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, employeeId)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
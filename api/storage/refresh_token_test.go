@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		employeeID, err := st.CreateNewEmployee(ctx, "John", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		newToken := func(hash, family string) RefreshToken {
+			return RefreshToken{TokenHash: hash, FamilyID: family, IdentityID: "identity", EmployeeID: employeeID,
+				Email: "john@mail.com", Role: "employee", ExpiresAt: now.Add(time.Hour), FamilyExpiresAt: now.Add(24 * time.Hour)}
+		}
+		assert.NoError(t, st.CreateRefreshToken(ctx, newToken("hash-1", "family-a")))
+		assert.NoError(t, st.CreateRefreshToken(ctx, newToken("hash-2", "family-a")))
+		assert.NoError(t, st.CreateRefreshToken(ctx, newToken("hash-3", "family-b")))
+		assert.Error(t, st.CreateRefreshToken(ctx, newToken("hash-1", "family-c")), "hash must be unique")
+
+		rec, err := st.LockRefreshTokenByHash(ctx, "hash-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "family-a", rec.FamilyID)
+		assert.Equal(t, employeeID, rec.EmployeeID)
+		assert.True(t, now.Add(time.Hour).Equal(rec.ExpiresAt))
+		assert.Nil(t, rec.UsedAt)
+		assert.Nil(t, rec.RevokedAt)
+
+		assert.NoError(t, st.MarkRefreshTokenUsed(ctx, rec.ID))
+		rec, err = st.LockRefreshTokenByHash(ctx, "hash-1")
+		assert.NoError(t, err)
+		assert.NotNil(t, rec.UsedAt)
+
+		n, err := st.RevokeRefreshTokenFamilyByHash(ctx, "hash-2")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		n, err = st.RevokeRefreshTokenFamily(ctx, "family-a")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n, "already revoked")
+
+		rec, err = st.LockRefreshTokenByHash(ctx, "hash-3")
+		assert.NoError(t, err)
+		assert.Nil(t, rec.RevokedAt)
+
+		n, err = st.RevokeRefreshTokenFamilyByHash(ctx, "unknown")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		_, err = st.LockRefreshTokenByHash(ctx, "unknown")
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
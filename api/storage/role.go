@@ -2,16 +2,57 @@ package storage
 
 import (
 	"context"
+	"time"
 )
 
 type Role struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID         int        `db:"id"`
+	Name       string     `db:"name"`
+	LocationID *int       `db:"location_id"` // nil for the roles shared by every location
+	ArchivedAt *time.Time `db:"archived_at"`
 }
 
+// SelectAllRoles returns the archived roles too
 func (s *Storage) SelectAllRoles(ctx context.Context) ([]Role, error) {
 	var roles []Role
-	query := `SELECT id, name FROM roles`
+	query := `SELECT id, name, location_id, archived_at FROM roles ORDER BY id`
 	err := s.db.SelectContext(ctx, &roles, query)
 	return roles, err
 }
+
+func (s *Storage) SelectRoleByID(ctx context.Context, id int) (*Role, error) {
+	var rec Role
+	query := `SELECT id, name, location_id, archived_at FROM roles WHERE id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// CreateRole creates a role of the location, locationId is nil for a role shared by every location
+func (s *Storage) CreateRole(ctx context.Context, name string, locationId *int) (int, error) {
+	var id int
+	query := `INSERT INTO roles (name, location_id) VALUES ($1, $2) RETURNING id`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, name, locationId).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// RenameRole returns false if the role doesn't exist or is archived
+func (s *Storage) RenameRole(ctx context.Context, id int, name string) (bool, error) {
+	query := `UPDATE roles SET name = $1 WHERE id = $2 AND archived_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, name, id)
+	if err != nil {
+		return false, mapConstraintError(err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ArchiveRole returns false if the role doesn't exist or is already archived
+func (s *Storage) ArchiveRole(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE roles SET archived_at = CURRENT_TIMESTAMP WHERE id = $1 AND archived_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
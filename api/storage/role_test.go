@@ -32,3 +32,48 @@ func TestSelectAllRoles(t *testing.T) {
 		})
 	})
 }
+
+func TestManageRoles(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		id, err := st.CreateRole(ctx, "Barista", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 4, id, "ids continue after the seeded roles")
+
+		_, err = st.CreateRole(ctx, "cashier", nil)
+		assert.ErrorIs(t, err, ErrDuplicateRoleName)
+
+		renamed, err := st.RenameRole(ctx, id, "Head Barista")
+		require.NoError(t, err)
+		assert.True(t, renamed)
+
+		_, err = st.RenameRole(ctx, id, "Cook")
+		assert.ErrorIs(t, err, ErrDuplicateRoleName)
+
+		archived, err := st.ArchiveRole(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, archived)
+
+		archived, err = st.ArchiveRole(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, archived, "already archived")
+
+		renamed, err = st.RenameRole(ctx, id, "Barista")
+		require.NoError(t, err)
+		assert.False(t, renamed, "archived roles can't be renamed")
+
+		// the archived role still resolves and its name can be reused
+		r, err := st.SelectRoleByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Head Barista", r.Name)
+		assert.NotNil(t, r.ArchivedAt)
+
+		_, err = st.CreateRole(ctx, "Head Barista", nil)
+		assert.NoError(t, err)
+
+		roles, err := st.SelectAllRoles(ctx)
+		require.NoError(t, err)
+		assert.Len(t, roles, 6)
+	})
+}
@@ -3,28 +3,128 @@ package storage
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Shift struct {
-	ID        int       `db:"id"`
-	RoleID    int       `db:"role_id"`
-	StartTime time.Time `db:"start_time"`
-	EndTime   time.Time `db:"end_time"`
-	CreatedAt time.Time `db:"created_at"`
+	ID         int        `db:"id"`
+	RoleID     int        `db:"role_id"`
+	LocationID int        `db:"location_id"`
+	StartTime  time.Time  `db:"start_time"`
+	EndTime    time.Time  `db:"end_time"`
+	CreatedAt  time.Time  `db:"created_at"`
+	TemplateID *int       `db:"template_id"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+	Headcount  int        `db:"headcount"` // the number of employees staffing the shift
+}
+
+// ShiftWithAssignees is a shift along with its APPROVED employees, in the order they were requested
+type ShiftWithAssignees struct {
+	Shift
+	Approved      int            `db:"approved"`
+	AssigneeIDs   pq.Int64Array  `db:"assignee_ids"`
+	AssigneeNames pq.StringArray `db:"assignee_names"`
 }
 
-func (s *Storage) CreateNewShiftSchedule(ctx context.Context, roleId int, startTime, endTime time.Time) (int, error) {
+// OpenSlots is the headcount still to staff
+func (s ShiftWithAssignees) OpenSlots() int {
+	if s.Approved >= s.Headcount {
+		return 0
+	}
+	return s.Headcount - s.Approved
+}
+
+type ListShiftFilter struct {
+	Start       time.Time
+	End         time.Time
+	RoleID      int
+	LocationIDs []int // restricts the shifts to these locations when not nil
+	Assigned    *bool // nil lists both assigned and unassigned shifts
+	Filled      *bool // nil lists both fully staffed shifts and shifts with open slots
+	Limit       int
+	Offset      int
+}
+
+type NewShift struct {
+	RoleID     int
+	LocationID int // ignored on update, a shift stays at its location
+	StartTime  time.Time
+	EndTime    time.Time
+	Headcount  int
+}
+
+func (s *Storage) CreateNewShiftSchedule(ctx context.Context, roleId, locationId int, startTime, endTime time.Time) (int, error) {
 	var id int
-	query := `INSERT INTO shifts (role_id, start_time, end_time) VALUES ($1, $2, $3) RETURNING id`
-	err := s.db.QueryRowContext(ctx, query, roleId, startTime, endTime).Scan(&id)
-	return id, err
+	query := `INSERT INTO shifts (role_id, location_id, start_time, end_time) VALUES ($1, $2, $3, $4) RETURNING id`
+	err := s.db.QueryRowContext(ctx, query, roleId, locationId, startTime, endTime).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// CreateNewShiftSchedules inserts all the shifts in a single multi-row statement, so either all or none are created.
+// the returned ids are in the same order as shifts
+func (s *Storage) CreateNewShiftSchedules(ctx context.Context, shifts []NewShift) ([]int, error) {
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+	query, args := insertShiftsQuery(shifts, nil)
+	query += ` RETURNING id`
+
+	ids := make([]int, 0, len(shifts))
+	err := s.conn(ctx).SelectContext(ctx, &ids, query, args...)
+	return ids, mapConstraintError(err)
+}
+
+// insertShiftsQuery builds a multi-row insert of the shifts, templateId may be nil
+func insertShiftsQuery(shifts []NewShift, templateId *int) (string, []interface{}) {
+	values := make([]string, 0, len(shifts))
+	args := make([]interface{}, 0, len(shifts)*6)
+	for i, sh := range shifts {
+		n := i * 6
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+		args = append(args, sh.RoleID, sh.LocationID, sh.StartTime, sh.EndTime, headcountOrDefault(sh.Headcount), templateId)
+	}
+	return `INSERT INTO shifts (role_id, location_id, start_time, end_time, headcount, template_id) VALUES ` +
+		strings.Join(values, ", "), args
+}
+
+// a shift is staffed by a single employee unless told otherwise
+func headcountOrDefault(headcount int) int {
+	if headcount == 0 {
+		return 1
+	}
+	return headcount
 }
 
-func (s *Storage) GetAvailableShiftsByTimeRangeAndRole(ctx context.Context, start, end time.Time, roleId int) ([]Shift, error) {
+func (s *Storage) GetShiftByID(ctx context.Context, id int) (*Shift, error) {
+	var rec Shift
+	query := `SELECT ` + shiftColumns + ` FROM shifts WHERE id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// LockShiftByID selects the shift and locks its row until the end of the transaction bound to ctx,
+// used to serialize concurrent approvals for the same shift
+func (s *Storage) LockShiftByID(ctx context.Context, id int) (*Shift, error) {
+	var rec Shift
+	query := `SELECT ` + shiftColumns + ` FROM shifts WHERE id = $1 FOR UPDATE`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// GetAvailableShiftsByTimeRangeAndRole lists the shifts of the role with open slots, restricted to locationIds when not nil
+func (s *Storage) GetAvailableShiftsByTimeRangeAndRole(ctx context.Context, start, end time.Time, roleId int, locationIds []int) ([]Shift, error) {
 	if start.IsZero() || end.IsZero() {
 		return nil, fmt.Errorf("both start and end time must be provided")
 	}
+	args := []interface{}{start, end, roleId}
+	locationFilter := ""
+	if locationIds != nil {
+		locationFilter = "AND s.location_id = ANY($4)"
+		args = append(args, pq.Array(locationIds))
+	}
 	var shifts []Shift
 	query := `
         SELECT s.*
@@ -32,19 +132,136 @@ func (s *Storage) GetAvailableShiftsByTimeRangeAndRole(ctx context.Context, star
         WHERE s.role_id = $3
           AND s.start_time >= $1
           AND s.start_time <= $2
-          AND s.id NOT IN (
-              SELECT sr.shift_id
+          ` + locationFilter + `
+          AND (
+              SELECT COUNT(*)
               FROM shift_requests sr
-              WHERE sr.status = 'APPROVED'
-          )
+              WHERE sr.shift_id = s.id AND sr.status = 'APPROVED'
+          ) < s.headcount
         ORDER BY s.start_time
     `
-	err := s.db.SelectContext(ctx, &shifts, query, start, end, roleId)
+	err := s.db.SelectContext(ctx, &shifts, query, args...)
+	return shifts, err
+}
+
+// ListOpenShiftsByTimeRange lists the shifts with open slots starting within [start, end),
+// restricted to roleIds and locationIds when not nil
+func (s *Storage) ListOpenShiftsByTimeRange(ctx context.Context, start, end time.Time, roleIds, locationIds []int) ([]ShiftWithAssignees, error) {
+	query := shiftWithAssigneesQuery + `
+		WHERE s.start_time >= $1 AND s.start_time < $2
+		  AND a.approved < s.headcount
+	`
+	args := []interface{}{start, end}
+	if roleIds != nil {
+		args = append(args, pq.Array(roleIds))
+		query += fmt.Sprintf(" AND s.role_id = ANY($%d)", len(args))
+	}
+	if locationIds != nil {
+		args = append(args, pq.Array(locationIds))
+		query += fmt.Sprintf(" AND s.location_id = ANY($%d)", len(args))
+	}
+	query += ` ORDER BY s.start_time, s.id`
+
+	var shifts []ShiftWithAssignees
+	err := s.conn(ctx).SelectContext(ctx, &shifts, query, args...)
 	return shifts, err
 }
 
+const shiftColumns = `id, role_id, location_id, start_time, end_time, created_at, template_id, updated_at, headcount`
+
+// the approved employees are aggregated per shift, the lateral subquery always yields one row
+const shiftAssigneesJoin = `
+	FROM shifts s
+	CROSS JOIN LATERAL (
+		SELECT COUNT(sr.id) AS approved,
+			COALESCE(array_agg(sr.employee_id ORDER BY sr.id), '{}') AS assignee_ids,
+			COALESCE(array_agg(e.name ORDER BY sr.id), '{}') AS assignee_names
+		FROM shift_requests sr
+		JOIN employees e ON e.id = sr.employee_id
+		WHERE sr.shift_id = s.id AND sr.status = 'APPROVED'
+	) a
+`
+
+const shiftWithAssigneesQuery = `
+	SELECT s.id, s.role_id, s.location_id, s.start_time, s.end_time, s.created_at, s.template_id, s.updated_at, s.headcount,
+		a.approved, a.assignee_ids, a.assignee_names
+` + shiftAssigneesJoin
+
+func (s *Storage) GetShiftWithAssigneesByID(ctx context.Context, id int) (*ShiftWithAssignees, error) {
+	var rec ShiftWithAssignees
+	err := s.db.GetContext(ctx, &rec, shiftWithAssigneesQuery+` WHERE s.id = $1`, id)
+	return &rec, err
+}
+
+// ListShiftsByFilter lists a page of the shifts starting within [filter.Start, filter.End),
+// returns the page and the number of shifts matching the filter
+func (s *Storage) ListShiftsByFilter(ctx context.Context, filter ListShiftFilter) ([]ShiftWithAssignees, int, error) {
+	if filter.Start.IsZero() || filter.End.IsZero() {
+		return nil, 0, fmt.Errorf("both start and end time must be provided")
+	}
+	where := ` WHERE s.start_time >= $1 AND s.start_time < $2`
+	args := []interface{}{filter.Start, filter.End}
+	argPos := len(args) + 1
+
+	if filter.RoleID != 0 {
+		where += fmt.Sprintf(" AND s.role_id = $%d", argPos)
+		args = append(args, filter.RoleID)
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		where += fmt.Sprintf(" AND s.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+		argPos++
+	}
+	if filter.Assigned != nil {
+		if *filter.Assigned {
+			where += " AND a.approved > 0"
+		} else {
+			where += " AND a.approved = 0"
+		}
+	}
+	if filter.Filled != nil {
+		if *filter.Filled {
+			where += " AND a.approved >= s.headcount"
+		} else {
+			where += " AND a.approved < s.headcount"
+		}
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*)` + shiftAssigneesJoin + where
+	if err := s.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := shiftWithAssigneesQuery + where +
+		fmt.Sprintf(" ORDER BY s.start_time, s.id LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	var shifts []ShiftWithAssignees
+	err := s.db.SelectContext(ctx, &shifts, query, args...)
+	return shifts, total, err
+}
+
+// UpdateShift replaces the role, times and headcount of the shift, returns false if the shift doesn't exist.
+// ErrHeadcountBelowApproved is returned if the headcount is lowered below the approved requests
+func (s *Storage) UpdateShift(ctx context.Context, id int, shift NewShift) (bool, error) {
+	query := `
+		UPDATE shifts
+		SET role_id = $1, start_time = $2, end_time = $3, headcount = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, shift.RoleID, shift.StartTime, shift.EndTime, headcountOrDefault(shift.Headcount), id)
+	if err != nil {
+		return false, mapConstraintError(err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// the shift requests are deleted along with the shift, see 00003_cascade_delete_on_shift_requests
 func (s *Storage) DeleteShiftById(ctx context.Context, shiftId int) error {
 	query := `DELETE FROM shifts WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, shiftId)
+	_, err := s.conn(ctx).ExecContext(ctx, query, shiftId)
 	return err
 }
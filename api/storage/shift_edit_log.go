@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ShiftEditUpdate = "UPDATE"
+	ShiftEditCancel = "CANCEL"
+)
+
+type ShiftEditLog struct {
+	ID           int           `db:"id"`
+	ShiftID      int           `db:"shift_id"`
+	LocationID   int           `db:"location_id"`
+	Action       string        `db:"action"`
+	OldRoleID    int           `db:"old_role_id"`
+	OldStartTime time.Time     `db:"old_start_time"`
+	OldEndTime   time.Time     `db:"old_end_time"`
+	NewRoleID    *int          `db:"new_role_id"`
+	NewStartTime *time.Time    `db:"new_start_time"`
+	NewEndTime   *time.Time    `db:"new_end_time"`
+	OldHeadcount int           `db:"old_headcount"`
+	NewHeadcount *int          `db:"new_headcount"`
+	AssigneeIDs  pq.Int64Array `db:"assignee_ids"` // the approved employees at the time of the edit
+	Reason       *string       `db:"reason"`
+	EditedBy     int           `db:"edited_by"`
+	EditedAt     time.Time     `db:"edited_at"`
+}
+
+func (s *Storage) CreateShiftEditLog(ctx context.Context, log ShiftEditLog) (int, error) {
+	var id int
+	query := `
+		INSERT INTO shift_edit_logs (shift_id, location_id, action, old_role_id, old_start_time, old_end_time,
+			new_role_id, new_start_time, new_end_time, old_headcount, new_headcount, assignee_ids, reason, edited_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, log.ShiftID, log.LocationID, log.Action, log.OldRoleID, log.OldStartTime, log.OldEndTime,
+		log.NewRoleID, log.NewStartTime, log.NewEndTime, log.OldHeadcount, log.NewHeadcount, assigneeIDs(log.AssigneeIDs), log.Reason, log.EditedBy).Scan(&id)
+	return id, err
+}
+
+// assigneeIDs stores an empty array rather than null for a shift without approved employees
+func assigneeIDs(ids pq.Int64Array) pq.Int64Array {
+	if ids == nil {
+		return pq.Int64Array{}
+	}
+	return ids
+}
+
+// ListShiftEditLogsByShiftID lists the edits of the shift made in the locations, nil locationIds means every location
+func (s *Storage) ListShiftEditLogsByShiftID(ctx context.Context, shiftId int, locationIds []int) ([]ShiftEditLog, error) {
+	var logs []ShiftEditLog
+	query := `
+		SELECT id, shift_id, location_id, action, old_role_id, old_start_time, old_end_time,
+			new_role_id, new_start_time, new_end_time, old_headcount, new_headcount, assignee_ids, reason, edited_by, edited_at
+		FROM shift_edit_logs
+		WHERE shift_id = $1
+	`
+	args := []interface{}{shiftId}
+	if locationIds != nil {
+		query += ` AND location_id = ANY($2)`
+		args = append(args, pq.Array(locationIds))
+	}
+	query += ` ORDER BY id`
+	err := s.conn(ctx).SelectContext(ctx, &logs, query, args...)
+	return logs, err
+}
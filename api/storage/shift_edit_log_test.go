@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftEditLog(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		shiftID, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start, start.Add(8*time.Hour))
+		assert.NoError(t, err)
+
+		newRole := 2
+		newStart, newEnd := start.Add(time.Hour), start.Add(9*time.Hour)
+		_, err = st.CreateShiftEditLog(ctx, ShiftEditLog{ShiftID: shiftID, LocationID: DefaultLocationID, Action: ShiftEditUpdate, OldRoleID: 1,
+			OldStartTime: start, OldEndTime: start.Add(8 * time.Hour), NewRoleID: &newRole, NewStartTime: &newStart,
+			NewEndTime: &newEnd, EditedBy: adminID})
+		assert.NoError(t, err)
+
+		// the log outlives the cancelled shift
+		reason := "closed for holiday"
+		assert.NoError(t, st.DeleteShiftById(ctx, shiftID))
+		_, err = st.CreateShiftEditLog(ctx, ShiftEditLog{ShiftID: shiftID, LocationID: DefaultLocationID, Action: ShiftEditCancel, OldRoleID: 2,
+			OldStartTime: newStart, OldEndTime: newEnd, Reason: &reason, EditedBy: adminID})
+		assert.NoError(t, err)
+
+		logs, err := st.ListShiftEditLogsByShiftID(ctx, shiftID, []int{DefaultLocationID + 1})
+		assert.NoError(t, err)
+		assert.Empty(t, logs)
+
+		logs, err = st.ListShiftEditLogsByShiftID(ctx, shiftID, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, logs, 2) {
+			assert.Equal(t, ShiftEditUpdate, logs[0].Action)
+			assert.Equal(t, 2, *logs[0].NewRoleID)
+			assert.Nil(t, logs[0].Reason)
+			assert.Equal(t, ShiftEditCancel, logs[1].Action)
+			assert.Nil(t, logs[1].NewStartTime)
+			assert.Equal(t, reason, *logs[1].Reason)
+		}
+
+		_, err = st.CreateShiftEditLog(ctx, ShiftEditLog{ShiftID: shiftID, LocationID: DefaultLocationID, Action: "MOVE", OldRoleID: 2,
+			OldStartTime: newStart, OldEndTime: newEnd, EditedBy: adminID})
+		assert.Error(t, err)
+	})
+}
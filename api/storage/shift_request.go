@@ -4,8 +4,20 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+type ShiftRequest struct {
+	ID          int        `db:"id"`
+	EmployeeID  int        `db:"employee_id"`
+	ShiftID     int        `db:"shift_id"`
+	Status      string     `db:"status"`
+	RequestedAt time.Time  `db:"requested_at"`
+	ReviewedAt  *time.Time `db:"reviewed_at"`
+	ReviewedBy  *int       `db:"reviewed_by"`
+}
+
 type ShiftRequestWithShiftDetails struct {
 	ID           int        `db:"id"`
 	EmployeeID   int        `db:"employee_id"`
@@ -17,15 +29,21 @@ type ShiftRequestWithShiftDetails struct {
 	ReviewedBy   *int       `db:"reviewed_by"`
 	RoleID       int        `db:"role_id"`
 	RoleName     string     `db:"role_name"`
+	LocationID   int        `db:"location_id"`
 	StartTime    time.Time  `db:"start_time"`
 	EndTime      time.Time  `db:"end_time"`
+	// the approved leave the employee took during the shift after it was approved
+	ConflictingLeaveRequestID *int `db:"conflicting_leave_request_id"`
 }
 
 type ListShiftRequestFilter struct {
 	EmployeeID int
 	ShiftID    int
 	RoleID     int
-	Status     string
+	RoleIDs    []int // restricts the shifts to these roles when not nil
+	// restricts the shifts to these locations when not nil
+	LocationIDs []int
+	Status      string
 }
 
 func (s *Storage) CreateShiftRequest(ctx context.Context, employeeId, shiftId int) (int, error) {
@@ -35,8 +53,46 @@ func (s *Storage) CreateShiftRequest(ctx context.Context, employeeId, shiftId in
 		VALUES ($1, $2, 'PENDING')
 		RETURNING id
 	`
-	err := s.db.QueryRowxContext(ctx, query, employeeId, shiftId).Scan(&id)
-	return id, err
+	err := s.conn(ctx).QueryRowxContext(ctx, query, employeeId, shiftId).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// CountApprovedRequestsByShiftID counts the APPROVED requests of the shift
+func (s *Storage) CountApprovedRequestsByShiftID(ctx context.Context, shiftId int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM shift_requests WHERE shift_id = $1 AND status = 'APPROVED'`
+	err := s.conn(ctx).GetContext(ctx, &count, query, shiftId)
+	return count, err
+}
+
+// ListApprovedShiftRequestsByShiftID lists the APPROVED requests of the shift in the order they were requested
+func (s *Storage) ListApprovedShiftRequestsByShiftID(ctx context.Context, shiftId int) ([]ShiftRequest, error) {
+	var recs []ShiftRequest
+	query := `
+		SELECT id, employee_id, shift_id, status, requested_at, reviewed_at, reviewed_by
+		FROM shift_requests
+		WHERE shift_id = $1 AND status = 'APPROVED'
+		ORDER BY id
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, shiftId)
+	return recs, err
+}
+
+// ListOverlappingApprovedShifts lists the shifts the employee is approved for that overlap the [start, end) range
+func (s *Storage) ListOverlappingApprovedShifts(ctx context.Context, employeeId int, start, end time.Time) ([]Shift, error) {
+	var shifts []Shift
+	query := `
+		SELECT s.id, s.role_id, s.location_id, s.start_time, s.end_time, s.created_at, s.template_id, s.headcount
+		FROM shifts s
+		JOIN shift_requests sr ON sr.shift_id = s.id
+		WHERE sr.employee_id = $1
+		  AND sr.status = 'APPROVED'
+		  AND s.start_time < $3
+		  AND s.end_time > $2
+		ORDER BY s.start_time
+	`
+	err := s.conn(ctx).SelectContext(ctx, &shifts, query, employeeId, start, end)
+	return shifts, err
 }
 
 func (s *Storage) UpdateShiftRequestStatusByShiftID(ctx context.Context, shiftId int, status string) error {
@@ -45,10 +101,61 @@ func (s *Storage) UpdateShiftRequestStatusByShiftID(ctx context.Context, shiftId
 		SET status = $1
 		WHERE shift_id = $2
 	`
-	_, err := s.db.ExecContext(ctx, query, status, shiftId)
+	_, err := s.conn(ctx).ExecContext(ctx, query, status, shiftId)
 	return err
 }
 
+// LockShiftRequestByID selects the shift request and locks its row until the end of the transaction bound to ctx
+func (s *Storage) LockShiftRequestByID(ctx context.Context, id int) (*ShiftRequest, error) {
+	var rec ShiftRequest
+	query := `
+		SELECT id, employee_id, shift_id, status, requested_at, reviewed_at, reviewed_by
+		FROM shift_requests
+		WHERE id = $1
+		FOR UPDATE
+	`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// ReviewShiftRequest sets the status of a single shift request along with the reviewer attribution
+func (s *Storage) ReviewShiftRequest(ctx context.Context, id int, status string, reviewedBy int) error {
+	query := `
+		UPDATE shift_requests
+		SET status = $1, reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $2
+		WHERE id = $3
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, status, reviewedBy, id)
+	return mapConstraintError(err)
+}
+
+// RejectPendingShiftRequestsByShiftID rejects every other PENDING request on the shift, returns the rejected requests
+func (s *Storage) RejectPendingShiftRequestsByShiftID(ctx context.Context, shiftId, exceptId, reviewedBy int) ([]ShiftRequest, error) {
+	query := `
+		UPDATE shift_requests
+		SET status = 'REJECTED', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $1
+		WHERE shift_id = $2 AND id <> $3 AND status = 'PENDING'
+		RETURNING id, employee_id, shift_id, status, requested_at, reviewed_at, reviewed_by
+	`
+	var rejected []ShiftRequest
+	err := s.conn(ctx).SelectContext(ctx, &rejected, query, reviewedBy, shiftId, exceptId)
+	return rejected, err
+}
+
+// WithdrawPendingShiftRequestsByEmployeeID withdraws every PENDING request of the employee, returns the number of withdrawn requests
+func (s *Storage) WithdrawPendingShiftRequestsByEmployeeID(ctx context.Context, employeeId, reviewedBy int) (int64, error) {
+	query := `
+		UPDATE shift_requests
+		SET status = 'WITHDRAWN', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $1
+		WHERE employee_id = $2 AND status = 'PENDING'
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, reviewedBy, employeeId)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *Storage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, filter ListShiftRequestFilter,
 	start time.Time,
 	end time.Time) ([]ShiftRequestWithShiftDetails, error) {
@@ -59,7 +166,8 @@ func (s *Storage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, fil
         SELECT 
             sr.id, sr.employee_id, e.name AS employee_name, 
 			sr.shift_id, sr.status, sr.requested_at, sr.reviewed_at, sr.reviewed_by,
-            s.role_id, r.name AS role_name, s.start_time, s.end_time
+            s.role_id, r.name AS role_name, s.location_id, s.start_time, s.end_time,
+            sr.conflicting_leave_request_id
         FROM 
             shift_requests sr
         JOIN 
@@ -92,6 +200,18 @@ func (s *Storage) ListShiftRequestsByFilterAndTimeRange(ctx context.Context, fil
 		argPos++
 	}
 
+	if filter.RoleIDs != nil {
+		baseQuery += fmt.Sprintf(" AND s.role_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.RoleIDs))
+		argPos++
+	}
+
+	if filter.LocationIDs != nil {
+		baseQuery += fmt.Sprintf(" AND s.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+		argPos++
+	}
+
 	if filter.Status != "" {
 		baseQuery += fmt.Sprintf(" AND sr.status = $%d", argPos)
 		args = append(args, filter.Status)
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -15,14 +16,14 @@ func TestCreateShiftRequest(t *testing.T) {
 		employeeName := "emp 1"
 		employeeStatus := "ACTIVE"
 		roleID := 1
-		employeeId, err := st.CreateNewEmployee(ctx, employeeName, employeeStatus, roleID)
+		employeeId, err := st.CreateNewEmployee(ctx, employeeName, employeeStatus, roleID, DefaultLocationID)
 		assert.NoError(t, err)
 		assert.Greater(t, employeeId, 0)
 
 		// Create a shift
 		startTime := time.Now().Add(1 * time.Hour)
 		endTime := startTime.Add(8 * time.Hour)
-		shiftId, err := st.CreateNewShiftSchedule(ctx, roleID, startTime, endTime)
+		shiftId, err := st.CreateNewShiftSchedule(ctx, roleID, DefaultLocationID, startTime, endTime)
 		assert.NoError(t, err)
 		assert.Greater(t, shiftId, 0)
 		t.Run("Valid shift request insert", func(t *testing.T) {
@@ -30,6 +31,10 @@ func TestCreateShiftRequest(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Greater(t, requestID, 0)
 		})
+		t.Run("Duplicate active request", func(t *testing.T) {
+			_, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+			assert.ErrorIs(t, err, ErrDuplicateShiftRequest)
+		})
 		t.Run("Invalid shift id", func(t *testing.T) {
 			_, err := st.CreateShiftRequest(ctx, employeeId, 100)
 			assert.Error(t, err)
@@ -56,7 +61,7 @@ func TestListShiftRequestsByFilterAndTimeRange(t *testing.T) {
 		}
 
 		for _, emp := range employees {
-			employeeID, err := st.CreateNewEmployee(ctx, emp.name, emp.status, emp.role)
+			employeeID, err := st.CreateNewEmployee(ctx, emp.name, emp.status, emp.role, DefaultLocationID)
 			assert.NoError(t, err)
 			assert.Greater(t, employeeID, 0)
 		}
@@ -72,7 +77,7 @@ func TestListShiftRequestsByFilterAndTimeRange(t *testing.T) {
 			{1, time.Date(2025, 5, 16, 9, 0, 0, 0, time.UTC), time.Date(2025, 5, 16, 17, 0, 0, 0, time.UTC)},
 		}
 		for _, stf := range shiftTimes {
-			idShift, err := st.CreateNewShiftSchedule(ctx, stf.role, stf.start, stf.end)
+			idShift, err := st.CreateNewShiftSchedule(ctx, stf.role, DefaultLocationID, stf.start, stf.end)
 			assert.NoError(t, err)
 			assert.Greater(t, idShift, 0)
 		}
@@ -215,12 +220,12 @@ func TestUpdateShiftRequestStatusByShiftID(t *testing.T) {
 	_withTestDatabase(t, func(st *Storage) {
 		ctx := context.Background()
 		// Setup: Create an employee
-		employeeID, err := st.CreateNewEmployee(ctx, "Test User", "ACTIVE", 1)
+		employeeID, err := st.CreateNewEmployee(ctx, "Test User", "ACTIVE", 1, DefaultLocationID)
 		assert.NoError(t, err)
 		assert.Greater(t, employeeID, 0)
 
 		// Setup: Create a shift
-		shiftID, err := st.CreateNewShiftSchedule(ctx, 1, time.Now(), time.Now().Add(8*time.Hour))
+		shiftID, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, time.Now(), time.Now().Add(8*time.Hour))
 		assert.NoError(t, err)
 		assert.Greater(t, shiftID, 0)
 
@@ -259,3 +264,183 @@ func TestUpdateShiftRequestStatusByShiftID(t *testing.T) {
 		})
 	})
 }
+
+func TestReviewShiftRequest(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		emp1, err := st.CreateNewEmployee(ctx, "Emp One", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+		emp2, err := st.CreateNewEmployee(ctx, "Emp Two", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+		shiftID, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start, start.Add(8*time.Hour))
+		assert.NoError(t, err)
+
+		req1, err := st.CreateShiftRequest(ctx, emp1, shiftID)
+		assert.NoError(t, err)
+		req2, err := st.CreateShiftRequest(ctx, emp2, shiftID)
+		assert.NoError(t, err)
+
+		t.Run("rolled back review leaves requests untouched", func(t *testing.T) {
+			txCtx, err := st.NewTransacton(ctx)
+			assert.NoError(t, err)
+
+			err = st.ReviewShiftRequest(txCtx, req1, "APPROVED", adminID)
+			assert.NoError(t, err)
+			assert.NoError(t, st.Rollback(txCtx))
+
+			rec, err := st.LockShiftRequestByID(ctx, req1)
+			assert.NoError(t, err)
+			assert.Equal(t, "PENDING", rec.Status)
+			assert.Nil(t, rec.ReviewedAt)
+			assert.Nil(t, rec.ReviewedBy)
+		})
+
+		t.Run("approve one and reject the other pending requests", func(t *testing.T) {
+			txCtx, err := st.NewTransacton(ctx)
+			assert.NoError(t, err)
+
+			rec, err := st.LockShiftRequestByID(txCtx, req1)
+			assert.NoError(t, err)
+			assert.Equal(t, shiftID, rec.ShiftID)
+
+			err = st.ReviewShiftRequest(txCtx, req1, "APPROVED", adminID)
+			assert.NoError(t, err)
+
+			rejected, err := st.RejectPendingShiftRequestsByShiftID(txCtx, shiftID, req1, adminID)
+			assert.NoError(t, err)
+			if assert.Len(t, rejected, 1) {
+				assert.Equal(t, req2, rejected[0].ID)
+				assert.Equal(t, "REJECTED", rejected[0].Status)
+			}
+			assert.NoError(t, st.Commit(txCtx))
+
+			approved, err := st.LockShiftRequestByID(ctx, req1)
+			assert.NoError(t, err)
+			assert.Equal(t, "APPROVED", approved.Status)
+			assert.NotNil(t, approved.ReviewedAt)
+			assert.Equal(t, adminID, *approved.ReviewedBy)
+
+			other, err := st.LockShiftRequestByID(ctx, req2)
+			assert.NoError(t, err)
+			assert.Equal(t, "REJECTED", other.Status)
+			assert.Equal(t, adminID, *other.ReviewedBy)
+		})
+
+		t.Run("unknown request", func(t *testing.T) {
+			_, err := st.LockShiftRequestByID(ctx, req2+100)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+}
+
+func TestDoubleBookingGuard(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		emp1, err := st.CreateNewEmployee(ctx, "Emp One", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+		emp2, err := st.CreateNewEmployee(ctx, "Emp Two", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+
+		day := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+		morning, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, day.Add(8*time.Hour), day.Add(16*time.Hour))
+		assert.NoError(t, err)
+		overlapping, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, day.Add(12*time.Hour), day.Add(20*time.Hour))
+		assert.NoError(t, err)
+		adjacent, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, day.Add(16*time.Hour), day.Add(22*time.Hour))
+		assert.NoError(t, err)
+
+		morningReq1, err := st.CreateShiftRequest(ctx, emp1, morning)
+		assert.NoError(t, err)
+		morningReq2, err := st.CreateShiftRequest(ctx, emp2, morning)
+		assert.NoError(t, err)
+		overlappingReq, err := st.CreateShiftRequest(ctx, emp1, overlapping)
+		assert.NoError(t, err)
+		adjacentReq, err := st.CreateShiftRequest(ctx, emp1, adjacent)
+		assert.NoError(t, err)
+
+		assert.NoError(t, st.ReviewShiftRequest(ctx, morningReq1, "APPROVED", adminID))
+
+		t.Run("count approved requests of a shift", func(t *testing.T) {
+			count, err := st.CountApprovedRequestsByShiftID(ctx, morning)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			count, err = st.CountApprovedRequestsByShiftID(ctx, overlapping)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+
+		t.Run("list overlapping approved shifts", func(t *testing.T) {
+			shifts, err := st.ListOverlappingApprovedShifts(ctx, emp1, day.Add(12*time.Hour), day.Add(20*time.Hour))
+			assert.NoError(t, err)
+			assert.Len(t, shifts, 1)
+			assert.Equal(t, morning, shifts[0].ID)
+
+			// touching boundaries are not overlapping
+			shifts, err = st.ListOverlappingApprovedShifts(ctx, emp1, day.Add(16*time.Hour), day.Add(22*time.Hour))
+			assert.NoError(t, err)
+			assert.Empty(t, shifts)
+		})
+
+		t.Run("approval beyond the headcount of the shift is refused", func(t *testing.T) {
+			err := st.ReviewShiftRequest(ctx, morningReq2, "APPROVED", adminID)
+			assert.ErrorIs(t, err, ErrShiftFullyStaffed)
+		})
+
+		t.Run("overlapping approval for the same employee is refused", func(t *testing.T) {
+			err := st.ReviewShiftRequest(ctx, overlappingReq, "APPROVED", adminID)
+			assert.ErrorIs(t, err, ErrOverlappingApprovedShift)
+		})
+
+		t.Run("adjacent shift can be approved", func(t *testing.T) {
+			err := st.ReviewShiftRequest(ctx, adjacentReq, "APPROVED", adminID)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestWithdrawPendingShiftRequestsByEmployeeID(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		assert.NoError(t, err)
+		employeeID, err := st.CreateNewEmployee(ctx, "Emp", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+		ids, err := st.CreateNewShiftSchedules(ctx, []NewShift{
+			{RoleID: 1, LocationID: DefaultLocationID, StartTime: start, EndTime: start.Add(8 * time.Hour)},
+			{RoleID: 1, LocationID: DefaultLocationID, StartTime: start.Add(24 * time.Hour), EndTime: start.Add(32 * time.Hour)},
+		})
+		assert.NoError(t, err)
+
+		approvedID, err := st.CreateShiftRequest(ctx, employeeID, ids[0])
+		assert.NoError(t, err)
+		assert.NoError(t, st.ReviewShiftRequest(ctx, approvedID, "APPROVED", adminID))
+		pendingID, err := st.CreateShiftRequest(ctx, employeeID, ids[1])
+		assert.NoError(t, err)
+
+		withdrawn, err := st.WithdrawPendingShiftRequestsByEmployeeID(ctx, employeeID, adminID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), withdrawn)
+
+		req, err := st.LockShiftRequestByID(ctx, pendingID)
+		assert.NoError(t, err)
+		assert.Equal(t, "WITHDRAWN", req.Status)
+		assert.Equal(t, adminID, *req.ReviewedBy)
+
+		req, err = st.LockShiftRequestByID(ctx, approvedID)
+		assert.NoError(t, err)
+		assert.Equal(t, "APPROVED", req.Status)
+
+		// a withdrawn request doesn't block a new one
+		_, err = st.CreateShiftRequest(ctx, employeeID, ids[1])
+		assert.NoError(t, err)
+	})
+}
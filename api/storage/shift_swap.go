@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ShiftSwap struct {
+	ID                 int        `db:"id"`
+	ShiftRequestID     int        `db:"shift_request_id"` // the offered shift request
+	OfferedBy          int        `db:"offered_by"`
+	Status             string     `db:"status"`
+	ClaimedBy          *int       `db:"claimed_by"`
+	ClaimedAt          *time.Time `db:"claimed_at"`
+	SwapShiftRequestID *int       `db:"swap_shift_request_id"` // given in exchange, nil for a give-away
+	CreatedAt          time.Time  `db:"created_at"`
+	ReviewedAt         *time.Time `db:"reviewed_at"`
+	ReviewedBy         *int       `db:"reviewed_by"`
+}
+
+type ShiftSwapWithShiftDetails struct {
+	ShiftSwap
+	OfferedByName string     `db:"offered_by_name"`
+	ClaimedByName *string    `db:"claimed_by_name"`
+	ShiftID       int        `db:"shift_id"`
+	RoleID        int        `db:"role_id"`
+	LocationID    int        `db:"location_id"`
+	StartTime     time.Time  `db:"start_time"`
+	EndTime       time.Time  `db:"end_time"`
+	SwapShiftID   *int       `db:"swap_shift_id"`
+	SwapStartTime *time.Time `db:"swap_start_time"`
+	SwapEndTime   *time.Time `db:"swap_end_time"`
+}
+
+type ListShiftSwapFilter struct {
+	EmployeeID  int   // offered or claimed by the employee
+	RoleIDs     []int // restricts the shifts to these roles when not nil
+	LocationIDs []int // restricts the shifts to these locations when not nil
+	Status      string
+}
+
+const shiftSwapColumns = `sw.id, sw.shift_request_id, sw.offered_by, sw.status, sw.claimed_by, sw.claimed_at,
+	sw.swap_shift_request_id, sw.created_at, sw.reviewed_at, sw.reviewed_by`
+
+// CreateShiftSwap offers the shift request, returns ErrDuplicateShiftSwap if it is already on offer
+func (s *Storage) CreateShiftSwap(ctx context.Context, shiftRequestId, offeredBy int) (int, error) {
+	var id int
+	query := `
+		INSERT INTO shift_swaps (shift_request_id, offered_by, status)
+		VALUES ($1, $2, 'OPEN')
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, shiftRequestId, offeredBy).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// LockShiftSwapByID selects the swap and locks its row until the end of the transaction bound to ctx
+func (s *Storage) LockShiftSwapByID(ctx context.Context, id int) (*ShiftSwap, error) {
+	var rec ShiftSwap
+	query := `SELECT ` + shiftSwapColumns + ` FROM shift_swaps sw WHERE sw.id = $1 FOR UPDATE`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// ClaimShiftSwap records the claimant of the swap and the shift request they give in exchange, if any
+func (s *Storage) ClaimShiftSwap(ctx context.Context, id, claimedBy int, swapShiftRequestId *int) error {
+	query := `
+		UPDATE shift_swaps
+		SET status = 'CLAIMED', claimed_by = $2, claimed_at = CURRENT_TIMESTAMP, swap_shift_request_id = $3
+		WHERE id = $1
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id, claimedBy, swapShiftRequestId)
+	return mapConstraintError(err)
+}
+
+// ReviewShiftSwap sets the status of the swap along with the reviewer attribution
+func (s *Storage) ReviewShiftSwap(ctx context.Context, id int, status string, reviewedBy int) error {
+	query := `
+		UPDATE shift_swaps
+		SET status = $1, reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $2
+		WHERE id = $3
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, status, reviewedBy, id)
+	return err
+}
+
+// CancelShiftSwap cancels an OPEN or CLAIMED swap offered by the employee, returns false if there is none
+func (s *Storage) CancelShiftSwap(ctx context.Context, id, offeredBy int) (bool, error) {
+	query := `
+		UPDATE shift_swaps
+		SET status = 'CANCELLED', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $2
+		WHERE id = $1 AND offered_by = $2 AND status IN ('OPEN', 'CLAIMED')
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id, offeredBy)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CancelActiveShiftSwapsByShiftRequestID cancels the OPEN or CLAIMED swaps offering or exchanging the shift request,
+// returns the number of cancelled swaps
+func (s *Storage) CancelActiveShiftSwapsByShiftRequestID(ctx context.Context, shiftRequestId, exceptId, reviewedBy int) (int64, error) {
+	query := `
+		UPDATE shift_swaps
+		SET status = 'CANCELLED', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $3
+		WHERE (shift_request_id = $1 OR swap_shift_request_id = $1) AND id <> $2 AND status IN ('OPEN', 'CLAIMED')
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, shiftRequestId, exceptId, reviewedBy)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListShiftSwapsByFilter lists the swaps of shifts starting within the [start, end) range, earliest shift first
+func (s *Storage) ListShiftSwapsByFilter(ctx context.Context, filter ListShiftSwapFilter, start, end time.Time) ([]ShiftSwapWithShiftDetails, error) {
+	query := `
+		SELECT ` + shiftSwapColumns + `, o.name AS offered_by_name, c.name AS claimed_by_name,
+			s.id AS shift_id, s.role_id, s.location_id, s.start_time, s.end_time,
+			ss.id AS swap_shift_id, ss.start_time AS swap_start_time, ss.end_time AS swap_end_time
+		FROM shift_swaps sw
+		JOIN shift_requests sr ON sr.id = sw.shift_request_id
+		JOIN shifts s ON s.id = sr.shift_id
+		JOIN employees o ON o.id = sw.offered_by
+		LEFT JOIN employees c ON c.id = sw.claimed_by
+		LEFT JOIN shift_requests ssr ON ssr.id = sw.swap_shift_request_id
+		LEFT JOIN shifts ss ON ss.id = ssr.shift_id
+		WHERE s.start_time >= $1 AND s.start_time < $2
+	`
+	args := []interface{}{start, end}
+	argPos := len(args) + 1
+
+	if filter.EmployeeID != 0 {
+		query += fmt.Sprintf(" AND (sw.offered_by = $%d OR sw.claimed_by = $%d)", argPos, argPos)
+		args = append(args, filter.EmployeeID)
+		argPos++
+	}
+	if filter.RoleIDs != nil {
+		query += fmt.Sprintf(" AND s.role_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.RoleIDs))
+		argPos++
+	}
+	if filter.LocationIDs != nil {
+		query += fmt.Sprintf(" AND s.location_id = ANY($%d)", argPos)
+		args = append(args, pq.Array(filter.LocationIDs))
+		argPos++
+	}
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND sw.status = $%d", argPos)
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY s.start_time, sw.id`
+
+	var recs []ShiftSwapWithShiftDetails
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, args...)
+	return recs, err
+}
+
+// ListOpenShiftSwapsByRole lists the OPEN swaps of shifts of the role starting after start at the locations,
+// nil locationIds means every location
+func (s *Storage) ListOpenShiftSwapsByRole(ctx context.Context, roleId int, locationIds []int, start time.Time) ([]ShiftSwapWithShiftDetails, error) {
+	query := `
+		SELECT ` + shiftSwapColumns + `, o.name AS offered_by_name, NULL AS claimed_by_name,
+			s.id AS shift_id, s.role_id, s.location_id, s.start_time, s.end_time,
+			NULL AS swap_shift_id, NULL AS swap_start_time, NULL AS swap_end_time
+		FROM shift_swaps sw
+		JOIN shift_requests sr ON sr.id = sw.shift_request_id
+		JOIN shifts s ON s.id = sr.shift_id
+		JOIN employees o ON o.id = sw.offered_by
+		WHERE sw.status = 'OPEN' AND s.role_id = $1 AND s.start_time > $2
+	`
+	args := []interface{}{roleId, start}
+	if locationIds != nil {
+		query += ` AND s.location_id = ANY($3)`
+		args = append(args, pq.Array(locationIds))
+	}
+	query += ` ORDER BY s.start_time, sw.id`
+
+	var recs []ShiftSwapWithShiftDetails
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, args...)
+	return recs, err
+}
+
+// ReleaseShiftRequest moves the approved shift request back to PENDING until TransferShiftRequest
+// approves it again in the same transaction, so that an exchange of overlapping shifts never sees
+// an employee holding both of them
+func (s *Storage) ReleaseShiftRequest(ctx context.Context, id int) error {
+	query := `
+		UPDATE shift_requests
+		SET status = 'PENDING'
+		WHERE id = $1
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+// TransferShiftRequest reassigns the shift request to the employee as APPROVED,
+// the leave conflict flagged for the previous assignee no longer applies.
+// returns ErrOverlappingApprovedShift if the employee has an approved shift overlapping it
+func (s *Storage) TransferShiftRequest(ctx context.Context, id, employeeId int) error {
+	query := `
+		UPDATE shift_requests
+		SET employee_id = $2, status = 'APPROVED', conflicting_leave_request_id = NULL
+		WHERE id = $1
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id, employeeId)
+	return mapConstraintError(err)
+}
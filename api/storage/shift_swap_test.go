@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftSwaps(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		offerer, err := st.CreateNewEmployee(ctx, "Alice", "ACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+		claimant, err := st.CreateNewEmployee(ctx, "Bob", "ACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+
+		start := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		assign := func(employeeId int, start time.Time) int {
+			shiftId, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start, start.Add(8*time.Hour))
+			require.NoError(t, err)
+			requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+			require.NoError(t, err)
+			require.NoError(t, st.ReviewShiftRequest(ctx, requestId, "APPROVED", employeeId))
+			return requestId
+		}
+		offered := assign(offerer, start)
+		exchanged := assign(claimant, start.Add(24*time.Hour))
+
+		id, err := st.CreateShiftSwap(ctx, offered, offerer)
+		require.NoError(t, err)
+		_, err = st.CreateShiftSwap(ctx, offered, offerer)
+		assert.ErrorIs(t, err, ErrDuplicateShiftSwap)
+
+		open, err := st.ListOpenShiftSwapsByRole(ctx, 1, []int{DefaultLocationID}, time.Now())
+		require.NoError(t, err)
+		require.Len(t, open, 1)
+		assert.Equal(t, "Alice", open[0].OfferedByName)
+
+		require.NoError(t, st.ClaimShiftSwap(ctx, id, claimant, &exchanged))
+		swap, err := st.LockShiftSwapByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "CLAIMED", swap.Status)
+		assert.Equal(t, claimant, *swap.ClaimedBy)
+
+		require.NoError(t, st.TransferShiftRequest(ctx, offered, claimant))
+		require.NoError(t, st.TransferShiftRequest(ctx, exchanged, offerer))
+		require.NoError(t, st.ReviewShiftSwap(ctx, id, "APPROVED", offerer))
+
+		history, err := st.ListShiftSwapsByFilter(ctx, ListShiftSwapFilter{EmployeeID: claimant}, start.Add(-time.Hour), start.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "APPROVED", history[0].Status)
+		require.NotNil(t, history[0].SwapStartTime)
+		assert.True(t, history[0].SwapStartTime.Equal(start.Add(24*time.Hour)))
+
+		req, err := st.LockShiftRequestByID(ctx, offered)
+		require.NoError(t, err)
+		assert.Equal(t, claimant, req.EmployeeID)
+
+		t.Run("transfer onto an overlapping shift", func(t *testing.T) {
+			overlapping := assign(offerer, start.Add(2*time.Hour))
+			err := st.TransferShiftRequest(ctx, overlapping, claimant)
+			assert.ErrorIs(t, err, ErrOverlappingApprovedShift)
+		})
+		t.Run("exchange overlapping shifts", func(t *testing.T) {
+			later := start.Add(72 * time.Hour)
+			given := assign(offerer, later)
+			taken := assign(claimant, later.Add(2*time.Hour))
+
+			require.NoError(t, st.ReleaseShiftRequest(ctx, taken))
+			require.NoError(t, st.TransferShiftRequest(ctx, given, claimant))
+			require.NoError(t, st.TransferShiftRequest(ctx, taken, offerer))
+
+			req, err := st.LockShiftRequestByID(ctx, taken)
+			require.NoError(t, err)
+			assert.Equal(t, offerer, req.EmployeeID)
+			assert.Equal(t, "APPROVED", req.Status)
+		})
+		t.Run("cancel only active swaps", func(t *testing.T) {
+			cancelled, err := st.CancelShiftSwap(ctx, id, offerer)
+			require.NoError(t, err)
+			assert.False(t, cancelled)
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ShiftTemplate struct {
+	ID               int        `db:"id"`
+	RoleID           int        `db:"role_id"`
+	LocationID       int        `db:"location_id"`       // of the generated shifts
+	StartTimeOfDay   string     `db:"start_time_of_day"` // HH:MM:SS wall clock in Timezone
+	EndTimeOfDay     string     `db:"end_time_of_day"`   // HH:MM:SS wall clock in Timezone
+	Timezone         string     `db:"timezone"`          // IANA name
+	Recurrence       string     `db:"recurrence"`        // RRULE subset, see shift.ParseRecurrence
+	StartsOn         time.Time  `db:"starts_on"`
+	EndsOn           *time.Time `db:"ends_on"`
+	GeneratedThrough *time.Time `db:"generated_through"`
+	Headcount        int        `db:"headcount"` // of the generated shifts
+	CreatedAt        time.Time  `db:"created_at"`
+}
+
+const shiftTemplateColumns = `id, role_id, location_id, start_time_of_day, end_time_of_day, timezone, recurrence,
+	starts_on, ends_on, generated_through, created_at, headcount`
+
+func (s *Storage) CreateShiftTemplate(ctx context.Context, t ShiftTemplate) (int, error) {
+	var id int
+	query := `
+		INSERT INTO shift_templates (role_id, location_id, start_time_of_day, end_time_of_day, timezone, recurrence,
+			starts_on, ends_on, headcount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, t.RoleID, t.LocationID, t.StartTimeOfDay, t.EndTimeOfDay, t.Timezone,
+		t.Recurrence, t.StartsOn, t.EndsOn, headcountOrDefault(t.Headcount)).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// ListShiftTemplates lists the templates, restricted to locationIds when not nil
+func (s *Storage) ListShiftTemplates(ctx context.Context, locationIds []int) ([]ShiftTemplate, error) {
+	query := `SELECT ` + shiftTemplateColumns + ` FROM shift_templates`
+	args := []interface{}{}
+	if locationIds != nil {
+		query += ` WHERE location_id = ANY($1)`
+		args = append(args, pq.Array(locationIds))
+	}
+	query += ` ORDER BY id`
+
+	var templates []ShiftTemplate
+	err := s.conn(ctx).SelectContext(ctx, &templates, query, args...)
+	return templates, err
+}
+
+func (s *Storage) SelectShiftTemplateByID(ctx context.Context, id int) (*ShiftTemplate, error) {
+	var rec ShiftTemplate
+	query := `SELECT ` + shiftTemplateColumns + ` FROM shift_templates WHERE id = $1`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// LockShiftTemplateByID selects the template and locks its row until the end of the transaction bound to ctx,
+// so concurrent generators don't materialize the same dates twice
+func (s *Storage) LockShiftTemplateByID(ctx context.Context, id int) (*ShiftTemplate, error) {
+	var rec ShiftTemplate
+	query := `SELECT ` + shiftTemplateColumns + ` FROM shift_templates WHERE id = $1 FOR UPDATE`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	return &rec, err
+}
+
+// UpdateShiftTemplateGeneratedThrough moves the materialization watermark of the template
+func (s *Storage) UpdateShiftTemplateGeneratedThrough(ctx context.Context, id int, through time.Time) error {
+	query := `UPDATE shift_templates SET generated_through = $1 WHERE id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, through, id)
+	return err
+}
+
+// DeleteShiftTemplate deletes the template, already generated shifts are kept
+func (s *Storage) DeleteShiftTemplate(ctx context.Context, id int) (bool, error) {
+	query := `DELETE FROM shift_templates WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreateTemplateShifts inserts the shifts generated from a template in one statement,
+// shifts already generated for the same template and start time are skipped.
+// returns the inserted shifts
+func (s *Storage) CreateTemplateShifts(ctx context.Context, templateId int, shifts []NewShift) ([]Shift, error) {
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+	query, args := insertShiftsQuery(shifts, &templateId)
+	query += ` ON CONFLICT (template_id, start_time) WHERE template_id IS NOT NULL DO NOTHING RETURNING ` + shiftColumns
+	var inserted []Shift
+	err := s.conn(ctx).SelectContext(ctx, &inserted, query, args...)
+	return inserted, err
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftTemplate(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		startsOn := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+		id, err := st.CreateShiftTemplate(ctx, ShiftTemplate{
+			RoleID:         2,
+			LocationID:     DefaultLocationID,
+			StartTimeOfDay: "08:00",
+			EndTimeOfDay:   "16:00",
+			Timezone:       "Asia/Jakarta",
+			Recurrence:     "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			StartsOn:       startsOn,
+		})
+		require.NoError(t, err)
+		assert.Greater(t, id, 0)
+
+		t.Run("list templates", func(t *testing.T) {
+			templates, err := st.ListShiftTemplates(ctx, nil)
+			assert.NoError(t, err)
+			require.Len(t, templates, 1)
+			tmpl := templates[0]
+			assert.Equal(t, "08:00:00", tmpl.StartTimeOfDay)
+			assert.Equal(t, "16:00:00", tmpl.EndTimeOfDay)
+			assert.Equal(t, "Asia/Jakarta", tmpl.Timezone)
+			assert.True(t, startsOn.Equal(tmpl.StartsOn))
+			assert.Nil(t, tmpl.EndsOn)
+			assert.Nil(t, tmpl.GeneratedThrough)
+		})
+
+		t.Run("ends_on before starts_on is refused", func(t *testing.T) {
+			endsOn := startsOn.AddDate(0, 0, -1)
+			_, err := st.CreateShiftTemplate(ctx, ShiftTemplate{RoleID: 2, LocationID: DefaultLocationID, StartTimeOfDay: "08:00", EndTimeOfDay: "16:00",
+				Timezone: "UTC", Recurrence: "FREQ=DAILY", StartsOn: startsOn, EndsOn: &endsOn})
+			assert.Error(t, err)
+		})
+
+		t.Run("generated shifts are idempotent", func(t *testing.T) {
+			first := time.Date(2025, 6, 2, 1, 0, 0, 0, time.UTC)
+			shifts := []NewShift{
+				{RoleID: 2, LocationID: DefaultLocationID, StartTime: first, EndTime: first.Add(8 * time.Hour)},
+				{RoleID: 2, LocationID: DefaultLocationID, StartTime: first.AddDate(0, 0, 1), EndTime: first.AddDate(0, 0, 1).Add(8 * time.Hour)},
+			}
+			inserted, err := st.CreateTemplateShifts(ctx, id, shifts)
+			assert.NoError(t, err)
+			assert.Len(t, inserted, 2)
+
+			shifts = append(shifts, NewShift{RoleID: 2, LocationID: DefaultLocationID, StartTime: first.AddDate(0, 0, 2), EndTime: first.AddDate(0, 0, 2).Add(8 * time.Hour)})
+			inserted, err = st.CreateTemplateShifts(ctx, id, shifts)
+			assert.NoError(t, err)
+			if assert.Len(t, inserted, 1) {
+				assert.Equal(t, first.AddDate(0, 0, 2), inserted[0].StartTime.UTC())
+				assert.Equal(t, id, *inserted[0].TemplateID)
+			}
+
+			available, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, first, first.AddDate(0, 0, 3), 2, nil)
+			assert.NoError(t, err)
+			require.Len(t, available, 3)
+			assert.Equal(t, id, *available[0].TemplateID)
+		})
+
+		t.Run("lock and move the watermark", func(t *testing.T) {
+			txCtx, err := st.NewTransacton(ctx)
+			require.NoError(t, err)
+			tmpl, err := st.LockShiftTemplateByID(txCtx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, id, tmpl.ID)
+
+			through := startsOn.AddDate(0, 0, 27)
+			assert.NoError(t, st.UpdateShiftTemplateGeneratedThrough(txCtx, id, through))
+			assert.NoError(t, st.Commit(txCtx))
+
+			tmpl, err = st.LockShiftTemplateByID(ctx, id)
+			assert.NoError(t, err)
+			assert.True(t, through.Equal(*tmpl.GeneratedThrough))
+		})
+
+		t.Run("delete keeps generated shifts", func(t *testing.T) {
+			deleted, err := st.DeleteShiftTemplate(ctx, id)
+			assert.NoError(t, err)
+			assert.True(t, deleted)
+
+			deleted, err = st.DeleteShiftTemplate(ctx, id)
+			assert.NoError(t, err)
+			assert.False(t, deleted)
+
+			first := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+			available, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, first, first.AddDate(0, 0, 3), 2, nil)
+			assert.NoError(t, err)
+			require.Len(t, available, 3)
+			assert.Nil(t, available[0].TemplateID)
+		})
+	})
+}
@@ -2,10 +2,13 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateShift(t *testing.T) {
@@ -16,7 +19,7 @@ func TestCreateShift(t *testing.T) {
 			shift1Start := dayA.Add(9 * time.Hour)
 			shift1End := shift1Start.Add(8 * time.Hour)
 
-			id1, err := st.CreateNewShiftSchedule(ctx, 1, shift1Start, shift1End)
+			id1, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, shift1Start, shift1End)
 			assert.NoError(t, err)
 			assert.Greater(t, id1, 0)
 
@@ -25,12 +28,122 @@ func TestCreateShift(t *testing.T) {
 		t.Run("Insert shift with invalid role_id should fail", func(t *testing.T) {
 			start := time.Now()
 			end := start.Add(8 * time.Hour)
-			_, err := st.CreateNewShiftSchedule(ctx, -1, start, end) // role_id 0 is invalid (no FK)
+			_, err := st.CreateNewShiftSchedule(ctx, -1, DefaultLocationID, start, end) // role_id 0 is invalid (no FK)
 			assert.Error(t, err)
 		})
 	})
 }
 
+func TestCreateNewShiftSchedules(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		day := time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC)
+
+		t.Run("insert all in one statement", func(t *testing.T) {
+			shifts := []NewShift{
+				{RoleID: 1, LocationID: DefaultLocationID, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(17 * time.Hour)},
+				{RoleID: 2, LocationID: DefaultLocationID, StartTime: day.Add(10 * time.Hour), EndTime: day.Add(18 * time.Hour)},
+				{RoleID: 3, LocationID: DefaultLocationID, StartTime: day.Add(11 * time.Hour), EndTime: day.Add(19 * time.Hour)},
+			}
+			ids, err := st.CreateNewShiftSchedules(ctx, shifts)
+			assert.NoError(t, err)
+			assert.Len(t, ids, 3)
+
+			for i, id := range ids {
+				shift, err := st.GetShiftByID(ctx, id)
+				assert.NoError(t, err)
+				assert.Equal(t, shifts[i].RoleID, shift.RoleID)
+				assert.True(t, shifts[i].StartTime.Equal(shift.StartTime))
+				assert.Nil(t, shift.TemplateID)
+			}
+		})
+
+		t.Run("one invalid row inserts nothing", func(t *testing.T) {
+			next := day.AddDate(0, 0, 1)
+			shifts := []NewShift{
+				{RoleID: 1, LocationID: DefaultLocationID, StartTime: next.Add(9 * time.Hour), EndTime: next.Add(17 * time.Hour)},
+				{RoleID: -1, LocationID: DefaultLocationID, StartTime: next.Add(9 * time.Hour), EndTime: next.Add(17 * time.Hour)},
+			}
+			_, err := st.CreateNewShiftSchedules(ctx, shifts)
+			assert.Error(t, err)
+
+			available, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, next, next.AddDate(0, 0, 1), 1, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, available)
+		})
+
+		t.Run("empty batch", func(t *testing.T) {
+			ids, err := st.CreateNewShiftSchedules(ctx, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, ids)
+		})
+	})
+}
+
+func TestGetShiftByID(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+		end := start.Add(8 * time.Hour)
+
+		id, err := st.CreateNewShiftSchedule(ctx, 2, DefaultLocationID, start, end)
+		assert.NoError(t, err)
+
+		t.Run("existing shift", func(t *testing.T) {
+			shift, err := st.GetShiftByID(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, id, shift.ID)
+			assert.Equal(t, 2, shift.RoleID)
+			assert.True(t, start.Equal(shift.StartTime))
+			assert.True(t, end.Equal(shift.EndTime))
+		})
+
+		t.Run("unknown shift", func(t *testing.T) {
+			_, err := st.GetShiftByID(ctx, id+100)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+}
+
+func TestShiftTimezoneRoundTrip(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		locationId, err := st.CreateLocation(ctx, "Berlin", "Europe/Berlin")
+		require.NoError(t, err)
+
+		tests := []struct {
+			name       string
+			start, end time.Time
+			length     time.Duration
+		}{
+			{"winter", time.Date(2025, 1, 15, 9, 0, 0, 0, berlin), time.Date(2025, 1, 15, 17, 0, 0, 0, berlin), 8 * time.Hour},
+			{"clocks go forward", time.Date(2025, 3, 29, 22, 0, 0, 0, berlin), time.Date(2025, 3, 30, 6, 0, 0, 0, berlin), 7 * time.Hour},
+			{"clocks go back", time.Date(2025, 10, 25, 22, 0, 0, 0, berlin), time.Date(2025, 10, 26, 6, 0, 0, 0, berlin), 9 * time.Hour},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				id, err := st.CreateNewShiftSchedule(ctx, 1, locationId, tc.start, tc.end)
+				require.NoError(t, err)
+
+				shift, err := st.GetShiftByID(ctx, id)
+				require.NoError(t, err)
+				assert.True(t, tc.start.Equal(shift.StartTime), "%s != %s", tc.start, shift.StartTime)
+				assert.True(t, tc.end.Equal(shift.EndTime), "%s != %s", tc.end, shift.EndTime)
+				assert.Equal(t, tc.length, shift.EndTime.Sub(shift.StartTime))
+				assert.Equal(t, tc.start.Format("15:04"), shift.StartTime.In(berlin).Format("15:04"))
+
+				// bounds in another timezone select the same instants
+				shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, tc.start.UTC(), tc.end.In(time.FixedZone("", -5*3600)), 1, []int{locationId})
+				require.NoError(t, err)
+				require.Len(t, shifts, 1)
+				assert.Equal(t, id, shifts[0].ID)
+			})
+		}
+	})
+}
+
 func TestGetAvailableShiftsByTimeRangeAndRole(t *testing.T) {
 	_withTestDatabase(t, func(st *Storage) {
 		ctx := context.Background()
@@ -49,14 +162,14 @@ func TestGetAvailableShiftsByTimeRangeAndRole(t *testing.T) {
 
 		shiftIDs := make([]int, 0, len(shifts))
 		for _, s := range shifts {
-			id, err := st.CreateNewShiftSchedule(ctx, s.role, s.start, s.end)
+			id, err := st.CreateNewShiftSchedule(ctx, s.role, DefaultLocationID, s.start, s.end)
 			assert.NoError(t, err)
 			assert.Greater(t, id, 0)
 			shiftIDs = append(shiftIDs, id)
 		}
 
 		// Create employees for shift requests
-		employeeID, err := st.CreateNewEmployee(ctx, "Test Emp", "ACTIVE", roleID)
+		employeeID, err := st.CreateNewEmployee(ctx, "Test Emp", "ACTIVE", roleID, DefaultLocationID)
 		assert.NoError(t, err)
 		assert.Greater(t, employeeID, 0)
 
@@ -72,17 +185,17 @@ func TestGetAvailableShiftsByTimeRangeAndRole(t *testing.T) {
 		end := time.Date(2025, 5, 15, 23, 59, 59, 0, time.UTC)
 
 		t.Run("returns error if start time is zero", func(t *testing.T) {
-			_, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, time.Time{}, end, roleID)
+			_, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, time.Time{}, end, roleID, nil)
 			assert.Error(t, err)
 		})
 
 		t.Run("returns error if end time is zero", func(t *testing.T) {
-			_, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, time.Time{}, roleID)
+			_, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, time.Time{}, roleID, nil)
 			assert.Error(t, err)
 		})
 
 		t.Run("returns available shifts excluding approved shift requests", func(t *testing.T) {
-			shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, end, roleID)
+			shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, end, roleID, nil)
 			assert.NoError(t, err)
 
 			// The first shift is approved and should be excluded, so only one shift should be returned
@@ -94,26 +207,54 @@ func TestGetAvailableShiftsByTimeRangeAndRole(t *testing.T) {
 		})
 
 		t.Run("returns empty slice if no shifts match role", func(t *testing.T) {
-			shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, end, 9999) // non-existing role
+			shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start, end, 9999, nil) // non-existing role
 			assert.NoError(t, err)
 			assert.Empty(t, shifts)
 		})
 	})
 }
 
+func TestListOpenShiftsByTimeRange(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		start := time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)
+		assigned, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start.Add(9*time.Hour), start.Add(17*time.Hour))
+		require.NoError(t, err)
+		open, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start.Add(33*time.Hour), start.Add(41*time.Hour))
+		require.NoError(t, err)
+		_, err = st.CreateNewShiftSchedule(ctx, 2, DefaultLocationID, start.Add(33*time.Hour), start.Add(41*time.Hour))
+		require.NoError(t, err)
+		// starts at the end of the range
+		_, err = st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start.AddDate(0, 0, 7), start.AddDate(0, 0, 7).Add(8*time.Hour))
+		require.NoError(t, err)
+
+		employeeID, err := st.CreateNewEmployee(ctx, "Test Emp", "ACTIVE", 1, DefaultLocationID)
+		require.NoError(t, err)
+		reqID, err := st.CreateShiftRequest(ctx, employeeID, assigned)
+		require.NoError(t, err)
+		require.NoError(t, st.ReviewShiftRequest(ctx, reqID, "APPROVED", employeeID))
+
+		shifts, err := st.ListOpenShiftsByTimeRange(ctx, start, start.AddDate(0, 0, 7), []int{1}, []int{DefaultLocationID})
+		require.NoError(t, err)
+		require.Len(t, shifts, 1)
+		assert.Equal(t, open, shifts[0].ID)
+	})
+}
+
 func TestDeleteShiftByID(t *testing.T) {
 	_withTestDatabase(t, func(st *Storage) {
 		ctx := context.Background()
 		// Setup a role and employee
 		roleID := 1
-		employeeID, err := st.CreateNewEmployee(ctx, "Tester", "ACTIVE", roleID)
+		employeeID, err := st.CreateNewEmployee(ctx, "Tester", "ACTIVE", roleID, DefaultLocationID)
 		assert.NoError(t, err)
 
 		// Create a shift
 		start := time.Date(2025, 7, 15, 9, 0, 0, 0, time.UTC)
 		end := time.Date(2025, 7, 15, 17, 0, 0, 0, time.UTC)
 
-		shiftID, err := st.CreateNewShiftSchedule(ctx, roleID, start, end)
+		shiftID, err := st.CreateNewShiftSchedule(ctx, roleID, DefaultLocationID, start, end)
 		assert.NoError(t, err)
 		assert.Greater(t, shiftID, 0)
 
@@ -127,7 +268,7 @@ func TestDeleteShiftByID(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify shift is deleted using GetAvailableShiftsByTimeRangeAndRole
-		availableShifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start.Add(-time.Hour), end.Add(time.Hour), roleID)
+		availableShifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start.Add(-time.Hour), end.Add(time.Hour), roleID, nil)
 		assert.NoError(t, err)
 		for _, s := range availableShifts {
 			assert.NotEqual(t, shiftID, s.ID, "Deleted shift should not be in available shifts")
@@ -144,3 +285,150 @@ func TestDeleteShiftByID(t *testing.T) {
 		assert.Empty(t, requests, "Shift request for deleted shift should not exist")
 	})
 }
+
+func TestListShiftsByFilter(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		day := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+
+		ids, err := st.CreateNewShiftSchedules(ctx, []NewShift{
+			{RoleID: 1, LocationID: DefaultLocationID, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(17 * time.Hour), Headcount: 2},
+			{RoleID: 1, LocationID: DefaultLocationID, StartTime: day.Add(18 * time.Hour), EndTime: day.Add(22 * time.Hour)},
+			{RoleID: 2, LocationID: DefaultLocationID, StartTime: day.Add(10 * time.Hour), EndTime: day.Add(14 * time.Hour)},
+			{RoleID: 1, LocationID: DefaultLocationID, StartTime: day.Add(33 * time.Hour), EndTime: day.Add(41 * time.Hour)}, // next day
+		})
+		assert.NoError(t, err)
+
+		employeeID, err := st.CreateNewEmployee(ctx, "Alice", "ACTIVE", 1, DefaultLocationID)
+		assert.NoError(t, err)
+		_, err = st.CreateShiftRequest(ctx, employeeID, ids[0])
+		assert.NoError(t, err)
+		assert.NoError(t, st.UpdateShiftRequestStatusByShiftID(ctx, ids[0], "APPROVED"))
+
+		filter := ListShiftFilter{Start: day, End: day.Add(24 * time.Hour), Limit: 10}
+
+		t.Run("all shifts of the day", func(t *testing.T) {
+			shifts, total, err := st.ListShiftsByFilter(ctx, filter)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, total)
+			assert.Len(t, shifts, 3)
+			assert.Equal(t, ids[0], shifts[0].ID)
+			assert.Equal(t, 2, shifts[0].Headcount)
+			assert.Equal(t, 1, shifts[0].Approved)
+			assert.Equal(t, pq.Int64Array{int64(employeeID)}, shifts[0].AssigneeIDs)
+			assert.Equal(t, pq.StringArray{"Alice"}, shifts[0].AssigneeNames)
+			assert.Equal(t, 1, shifts[0].OpenSlots())
+			assert.Equal(t, 0, shifts[1].Approved)
+			assert.Empty(t, shifts[1].AssigneeIDs)
+		})
+
+		t.Run("by role and assignment", func(t *testing.T) {
+			unassigned := false
+			f := filter
+			f.RoleID = 1
+			f.Assigned = &unassigned
+			shifts, total, err := st.ListShiftsByFilter(ctx, f)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, total)
+			assert.Equal(t, ids[1], shifts[0].ID)
+		})
+
+		t.Run("by staffing", func(t *testing.T) {
+			filled := false
+			f := filter
+			f.RoleID = 1
+			f.Filled = &filled
+			shifts, total, err := st.ListShiftsByFilter(ctx, f)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, total)
+			assert.Equal(t, ids[0], shifts[0].ID)
+			assert.Equal(t, ids[1], shifts[1].ID)
+		})
+
+		t.Run("paginated", func(t *testing.T) {
+			f := filter
+			f.Limit = 1
+			f.Offset = 1
+			shifts, total, err := st.ListShiftsByFilter(ctx, f)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, total)
+			assert.Len(t, shifts, 1)
+			assert.Equal(t, ids[2], shifts[0].ID)
+		})
+
+		t.Run("get with assignees", func(t *testing.T) {
+			shift, err := st.GetShiftWithAssigneesByID(ctx, ids[0])
+			assert.NoError(t, err)
+			assert.Equal(t, pq.Int64Array{int64(employeeID)}, shift.AssigneeIDs)
+
+			_, err = st.GetShiftWithAssigneesByID(ctx, ids[3]+100)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+}
+
+func TestUpdateShift(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		start := time.Date(2025, 5, 15, 9, 0, 0, 0, time.UTC)
+
+		id, err := st.CreateNewShiftSchedule(ctx, 1, DefaultLocationID, start, start.Add(8*time.Hour))
+		assert.NoError(t, err)
+
+		updated, err := st.UpdateShift(ctx, id, NewShift{RoleID: 2, LocationID: DefaultLocationID, StartTime: start.Add(time.Hour), EndTime: start.Add(9 * time.Hour)})
+		assert.NoError(t, err)
+		assert.True(t, updated)
+
+		shift, err := st.GetShiftByID(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, shift.RoleID)
+		assert.True(t, start.Add(time.Hour).Equal(shift.StartTime))
+		assert.NotNil(t, shift.UpdatedAt)
+
+		updated, err = st.UpdateShift(ctx, id+100, NewShift{RoleID: 2, LocationID: DefaultLocationID, StartTime: start, EndTime: start.Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.False(t, updated)
+	})
+}
+
+func TestShiftHeadcount(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		start := time.Date(2025, 5, 15, 18, 0, 0, 0, time.UTC)
+		shift := NewShift{RoleID: 1, LocationID: DefaultLocationID, StartTime: start, EndTime: start.Add(5 * time.Hour), Headcount: 2}
+
+		ids, err := st.CreateNewShiftSchedules(ctx, []NewShift{shift})
+		require.NoError(t, err)
+		requests := make([]int, 0, 3)
+		for _, name := range []string{"Alice", "Bob", "Carol"} {
+			employeeID, err := st.CreateNewEmployee(ctx, name, "ACTIVE", 1, DefaultLocationID)
+			require.NoError(t, err)
+			reqID, err := st.CreateShiftRequest(ctx, employeeID, ids[0])
+			require.NoError(t, err)
+			requests = append(requests, reqID)
+		}
+		available := func() []Shift {
+			shifts, err := st.GetAvailableShiftsByTimeRangeAndRole(ctx, start.Add(-time.Hour), start.Add(time.Hour), 1, nil)
+			require.NoError(t, err)
+			return shifts
+		}
+
+		require.NoError(t, st.ReviewShiftRequest(ctx, requests[0], "APPROVED", 1))
+		assert.Len(t, available(), 1)
+
+		require.NoError(t, st.ReviewShiftRequest(ctx, requests[1], "APPROVED", 1))
+		assert.Empty(t, available())
+
+		err = st.ReviewShiftRequest(ctx, requests[2], "APPROVED", 1)
+		assert.ErrorIs(t, err, ErrShiftFullyStaffed)
+
+		shift.Headcount = 1
+		_, err = st.UpdateShift(ctx, ids[0], shift)
+		assert.ErrorIs(t, err, ErrHeadcountBelowApproved)
+
+		shift.Headcount = 3
+		_, err = st.UpdateShift(ctx, ids[0], shift)
+		require.NoError(t, err)
+		require.NoError(t, st.ReviewShiftRequest(ctx, requests[2], "APPROVED", 1))
+	})
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TimeEntry is the attendance of an approved shift request, ClockOut is nil while the employee is clocked in
+type TimeEntry struct {
+	ID             int        `db:"id"`
+	ShiftRequestID int        `db:"shift_request_id"`
+	EmployeeID     int        `db:"employee_id"`
+	ClockIn        time.Time  `db:"clock_in"`
+	ClockOut       *time.Time `db:"clock_out"`
+	// the ended breaks of the entry
+	BreakSeconds int64 `db:"break_seconds"`
+	OnBreak      bool  `db:"on_break"`
+}
+
+func (e TimeEntry) Breaks() time.Duration {
+	return time.Duration(e.BreakSeconds) * time.Second
+}
+
+type TimeEntryBreak struct {
+	ID          int        `db:"id"`
+	TimeEntryID int        `db:"time_entry_id"`
+	StartTime   time.Time  `db:"start_time"`
+	EndTime     *time.Time `db:"end_time"`
+}
+
+const timeEntryQuery = `
+	SELECT te.id, te.shift_request_id, te.employee_id, te.clock_in, te.clock_out,
+		COALESCE(SUM(EXTRACT(EPOCH FROM b.end_time - b.start_time)), 0)::BIGINT AS break_seconds,
+		COALESCE(BOOL_OR(b.id IS NOT NULL AND b.end_time IS NULL), FALSE) AS on_break
+	FROM time_entries te
+	LEFT JOIN time_entry_breaks b ON b.time_entry_id = te.id
+`
+
+// CreateTimeEntry clocks the employee in for the shift request,
+// returns ErrDuplicateTimeEntry if the request already has an entry
+// and ErrAlreadyClockedIn if the employee is clocked in for another one
+func (s *Storage) CreateTimeEntry(ctx context.Context, shiftRequestId, employeeId int, clockIn time.Time) (int, error) {
+	var id int
+	query := `
+		INSERT INTO time_entries (shift_request_id, employee_id, clock_in)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, shiftRequestId, employeeId, clockIn).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// LockOpenTimeEntryByEmployeeID selects the entry the employee is clocked in for
+// and locks its row until the end of the transaction bound to ctx, sql.ErrNoRows if there is none
+func (s *Storage) LockOpenTimeEntryByEmployeeID(ctx context.Context, employeeId int) (*TimeEntry, error) {
+	var rec TimeEntry
+	query := `
+		SELECT id, shift_request_id, employee_id, clock_in, clock_out
+		FROM time_entries
+		WHERE employee_id = $1 AND clock_out IS NULL
+		FOR UPDATE
+	`
+	err := s.conn(ctx).GetContext(ctx, &rec, query, employeeId)
+	return &rec, err
+}
+
+// ClockOutTimeEntry ends the entry along with its ongoing break
+func (s *Storage) ClockOutTimeEntry(ctx context.Context, id int, clockOut time.Time) error {
+	if _, err := s.EndTimeEntryBreak(ctx, id, clockOut); err != nil {
+		return err
+	}
+	query := `UPDATE time_entries SET clock_out = $1 WHERE id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, clockOut, id)
+	return err
+}
+
+// StartTimeEntryBreak returns ErrBreakAlreadyStarted if the entry has an ongoing break
+func (s *Storage) StartTimeEntryBreak(ctx context.Context, timeEntryId int, start time.Time) (int, error) {
+	var id int
+	query := `
+		INSERT INTO time_entry_breaks (time_entry_id, start_time)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, timeEntryId, start).Scan(&id)
+	return id, mapConstraintError(err)
+}
+
+// EndTimeEntryBreak ends the ongoing break of the entry, returns false if there is none
+func (s *Storage) EndTimeEntryBreak(ctx context.Context, timeEntryId int, end time.Time) (bool, error) {
+	query := `UPDATE time_entry_breaks SET end_time = $1 WHERE time_entry_id = $2 AND end_time IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, end, timeEntryId)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListTimeEntriesByShiftRequestIDs returns the entries of the shift requests along with their break time
+func (s *Storage) ListTimeEntriesByShiftRequestIDs(ctx context.Context, shiftRequestIds []int) ([]TimeEntry, error) {
+	recs := []TimeEntry{}
+	query := timeEntryQuery + `
+		WHERE te.shift_request_id = ANY($1)
+		GROUP BY te.id
+		ORDER BY te.clock_in
+	`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, pq.Array(shiftRequestIds))
+	return recs, err
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeEntries(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+
+		employeeId, err := st.CreateNewEmployee(ctx, "Waiter", "ACTIVE", 3, DefaultLocationID)
+		require.NoError(t, err)
+		start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+		shiftId, err := st.CreateNewShiftSchedule(ctx, 3, DefaultLocationID, start, start.Add(8*time.Hour))
+		require.NoError(t, err)
+		otherShiftId, err := st.CreateNewShiftSchedule(ctx, 3, DefaultLocationID, start.AddDate(0, 0, 1), start.AddDate(0, 0, 1).Add(8*time.Hour))
+		require.NoError(t, err)
+		requestId, err := st.CreateShiftRequest(ctx, employeeId, shiftId)
+		require.NoError(t, err)
+		otherRequestId, err := st.CreateShiftRequest(ctx, employeeId, otherShiftId)
+		require.NoError(t, err)
+
+		entryId, err := st.CreateTimeEntry(ctx, requestId, employeeId, start.Add(-5*time.Minute))
+		require.NoError(t, err)
+
+		t.Run("clocked in once per shift request", func(t *testing.T) {
+			_, err := st.CreateTimeEntry(ctx, requestId, employeeId, start)
+			assert.ErrorIs(t, err, ErrDuplicateTimeEntry)
+		})
+		t.Run("clocked in for one shift at a time", func(t *testing.T) {
+			_, err := st.CreateTimeEntry(ctx, otherRequestId, employeeId, start)
+			assert.ErrorIs(t, err, ErrAlreadyClockedIn)
+		})
+		t.Run("one break at a time", func(t *testing.T) {
+			_, err := st.StartTimeEntryBreak(ctx, entryId, start.Add(2*time.Hour))
+			require.NoError(t, err)
+			_, err = st.StartTimeEntryBreak(ctx, entryId, start.Add(3*time.Hour))
+			assert.ErrorIs(t, err, ErrBreakAlreadyStarted)
+
+			entries, err := st.ListTimeEntriesByShiftRequestIDs(ctx, []int{requestId})
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.True(t, entries[0].OnBreak)
+			assert.Zero(t, entries[0].BreakSeconds)
+
+			ended, err := st.EndTimeEntryBreak(ctx, entryId, start.Add(2*time.Hour+30*time.Minute))
+			require.NoError(t, err)
+			assert.True(t, ended)
+			ended, err = st.EndTimeEntryBreak(ctx, entryId, start.Add(3*time.Hour))
+			require.NoError(t, err)
+			assert.False(t, ended)
+		})
+		t.Run("clock out ends the ongoing break", func(t *testing.T) {
+			entry, err := st.LockOpenTimeEntryByEmployeeID(ctx, employeeId)
+			require.NoError(t, err)
+			assert.Equal(t, entryId, entry.ID)
+
+			_, err = st.StartTimeEntryBreak(ctx, entryId, start.Add(7*time.Hour+45*time.Minute))
+			require.NoError(t, err)
+			require.NoError(t, st.ClockOutTimeEntry(ctx, entryId, start.Add(8*time.Hour)))
+
+			_, err = st.LockOpenTimeEntryByEmployeeID(ctx, employeeId)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+
+			entries, err := st.ListTimeEntriesByShiftRequestIDs(ctx, []int{requestId, otherRequestId})
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.False(t, entries[0].OnBreak)
+			assert.Equal(t, 45*time.Minute, entries[0].Breaks())
+			assert.True(t, entries[0].ClockOut.Equal(start.Add(8*time.Hour)))
+		})
+	})
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// the states of a webhook delivery
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryDead      = "DEAD" // given up on after the last attempt
+)
+
+// OutboxEvent is a roster event, Payload is the JSON of the entity after the change
+type OutboxEvent struct {
+	ID           int64           `db:"id"`
+	EventType    string          `db:"event_type"`
+	EntityID     int             `db:"entity_id"`
+	Payload      json.RawMessage `db:"payload"`
+	RequestID    *string         `db:"request_id"`
+	CreatedAt    time.Time       `db:"created_at"`
+	DispatchedAt *time.Time      `db:"dispatched_at"`
+}
+
+// Webhook is an endpoint notified of the events of EventTypes, of every event when empty
+type Webhook struct {
+	ID         int            `db:"id"`
+	URL        string         `db:"url"`
+	Secret     string         `db:"secret"`
+	EventTypes pq.StringArray `db:"event_types"`
+	CreatedBy  int            `db:"created_by"`
+	CreatedAt  time.Time      `db:"created_at"`
+	DeletedAt  *time.Time     `db:"deleted_at"`
+}
+
+// WebhookDelivery is an event delivered to a webhook along with the event and the webhook URL,
+// WebhookSecret is only selected by ClaimWebhookDeliveries
+type WebhookDelivery struct {
+	ID             int64           `db:"id"`
+	EventID        int64           `db:"event_id"`
+	EventType      string          `db:"event_type"`
+	Payload        json.RawMessage `db:"payload"`
+	EventCreatedAt time.Time       `db:"event_created_at"`
+	WebhookID      int             `db:"webhook_id"`
+	WebhookURL     string          `db:"webhook_url"`
+	WebhookSecret  string          `db:"webhook_secret"`
+	Status         string          `db:"status"`
+	Attempts       int             `db:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code"`
+	LastError      *string         `db:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+// ListWebhookDeliveryFilter restricts the deliveries, zero values don't filter
+type ListWebhookDeliveryFilter struct {
+	WebhookID int
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+const webhookColumns = `id, url, secret, event_types, created_by, created_at, deleted_at`
+
+const webhookDeliveryColumns = `d.id, d.event_id, e.event_type, e.payload, e.created_at AS event_created_at,
+	d.webhook_id, w.url AS webhook_url, d.status, d.attempts, d.next_attempt_at, d.last_status_code, d.last_error,
+	d.delivered_at, d.created_at`
+
+// InsertOutboxEvent appends the event to the outbox, within the transaction bound to ctx if any
+func (s *Storage) InsertOutboxEvent(ctx context.Context, e OutboxEvent) error {
+	query := `INSERT INTO outbox_events (event_type, entity_id, payload, request_id) VALUES ($1, $2, $3, $4)`
+	_, err := s.conn(ctx).ExecContext(ctx, query, e.EventType, e.EntityID, string(e.Payload), e.RequestID)
+	return err
+}
+
+// FanOutOutboxEvents marks up to limit undispatched events as dispatched and queues their delivery to the webhooks
+// subscribed to them, in one statement. the events locked by a concurrent dispatcher are skipped.
+// returns the number of dispatched events
+func (s *Storage) FanOutOutboxEvents(ctx context.Context, now time.Time, limit int) (int, error) {
+	query := `
+		WITH events AS (
+			UPDATE outbox_events
+			SET dispatched_at = $1
+			WHERE id IN (
+				SELECT id FROM outbox_events
+				WHERE dispatched_at IS NULL
+				ORDER BY id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, event_type
+		), deliveries AS (
+			INSERT INTO webhook_deliveries (event_id, webhook_id, next_attempt_at)
+			SELECT e.id, w.id, $1
+			FROM events e
+			JOIN webhooks w ON w.deleted_at IS NULL
+				AND (cardinality(w.event_types) = 0 OR e.event_type = ANY(w.event_types))
+			ON CONFLICT ON CONSTRAINT uniq_webhook_deliveries_event_webhook DO NOTHING
+		)
+		SELECT COUNT(*) FROM events
+	`
+	var dispatched int
+	err := s.conn(ctx).GetContext(ctx, &dispatched, query, now, limit)
+	return dispatched, err
+}
+
+// ClaimWebhookDeliveries returns up to limit PENDING deliveries due at now, oldest first, and pushes back their
+// next attempt by lease so that no other dispatcher attempts them meanwhile.
+// the deliveries to deleted webhooks are left out
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = $2
+			WHERE id IN (
+				SELECT d.id FROM webhook_deliveries d
+				JOIN webhooks w ON w.id = d.webhook_id
+				WHERE d.status = 'PENDING' AND d.next_attempt_at <= $1 AND w.deleted_at IS NULL
+				ORDER BY d.next_attempt_at, d.id
+				LIMIT $3
+				FOR UPDATE OF d SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `, w.secret AS webhook_secret
+		FROM claimed d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id
+		ORDER BY d.id
+	`
+	var recs []WebhookDelivery
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, now, now.Add(lease), limit)
+	return recs, err
+}
+
+// MarkWebhookDelivered records the successful attempt of the delivery
+func (s *Storage) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'DELIVERED', attempts = attempts + 1, last_status_code = $2, last_error = NULL, delivered_at = $3
+		WHERE id = $1
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id, statusCode, now)
+	return err
+}
+
+// MarkWebhookAttemptFailed records the failed attempt of the delivery, statusCode is nil when there was no response.
+// the delivery is retried at nextAttemptAt, or given up on (DEAD) when nil
+func (s *Storage) MarkWebhookAttemptFailed(ctx context.Context, id int64, statusCode *int, lastError string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_status_code = $2, last_error = $3,
+			status = CASE WHEN $4::TIMESTAMPTZ IS NULL THEN 'DEAD' ELSE 'PENDING' END,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1
+	`
+	_, err := s.conn(ctx).ExecContext(ctx, query, id, statusCode, lastError, nextAttemptAt)
+	return err
+}
+
+// RetryWebhookDelivery queues a DEAD delivery again with its attempts reset,
+// returns false if the delivery doesn't exist or isn't DEAD
+func (s *Storage) RetryWebhookDelivery(ctx context.Context, id int64, now time.Time) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = 0, next_attempt_at = $2
+		WHERE id = $1 AND status = 'DEAD'
+	`
+	res, err := s.conn(ctx).ExecContext(ctx, query, id, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListWebhookDeliveries lists a page of the deliveries matching the filter, newest first,
+// returns the page and the number of deliveries matching the filter
+func (s *Storage) ListWebhookDeliveries(ctx context.Context, filter ListWebhookDeliveryFilter) ([]WebhookDelivery, int, error) {
+	where := ` WHERE TRUE`
+	var args []interface{}
+	argPos := 1
+
+	if filter.WebhookID != 0 {
+		where += fmt.Sprintf(" AND d.webhook_id = $%d", argPos)
+		args = append(args, filter.WebhookID)
+		argPos++
+	}
+	if filter.Status != "" {
+		where += fmt.Sprintf(" AND d.status = $%d", argPos)
+		args = append(args, filter.Status)
+		argPos++
+	}
+
+	var total int
+	if err := s.conn(ctx).GetContext(ctx, &total, `SELECT COUNT(*) FROM webhook_deliveries d`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id` + where +
+		fmt.Sprintf(" ORDER BY d.created_at DESC, d.id DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	var recs []WebhookDelivery
+	err := s.conn(ctx).SelectContext(ctx, &recs, query, args...)
+	return recs, total, err
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, w Webhook) (int, error) {
+	var id int
+	query := `INSERT INTO webhooks (url, secret, event_types, created_by) VALUES ($1, $2, COALESCE($3, '{}'), $4) RETURNING id`
+	err := s.conn(ctx).QueryRowxContext(ctx, query, w.URL, w.Secret, w.EventTypes, w.CreatedBy).Scan(&id)
+	return id, err
+}
+
+// ListWebhooks returns the webhooks that aren't deleted, oldest first
+func (s *Storage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var recs []Webhook
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE deleted_at IS NULL ORDER BY id`
+	err := s.conn(ctx).SelectContext(ctx, &recs, query)
+	return recs, err
+}
+
+// DeleteWebhook soft-deletes the webhook and returns it, nil if it doesn't exist or is already deleted
+func (s *Storage) DeleteWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var rec Webhook
+	query := `UPDATE webhooks SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL RETURNING ` + webhookColumns
+	err := s.conn(ctx).GetContext(ctx, &rec, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	_withTestDatabase(t, func(st *Storage) {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+
+		adminID, err := st.CreateNewEmployee(ctx, "Admin", "ACTIVE", 0, DefaultLocationID)
+		require.NoError(t, err)
+		everyID, err := st.CreateWebhook(ctx, Webhook{URL: "https://pos.example.com/hooks", Secret: "whsec_every", CreatedBy: adminID})
+		require.NoError(t, err)
+		deletedID, err := st.CreateWebhook(ctx, Webhook{URL: "https://payroll.example.com/hooks", Secret: "whsec_deleted",
+			EventTypes: []string{"shift.deleted"}, CreatedBy: adminID})
+		require.NoError(t, err)
+
+		webhooks, err := st.ListWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+		assert.Empty(t, webhooks[0].EventTypes)
+		assert.Equal(t, []string{"shift.deleted"}, []string(webhooks[1].EventTypes))
+
+		// an event is only written along with its committed change
+		txCtx, err := st.NewTransacton(ctx)
+		require.NoError(t, err)
+		require.NoError(t, st.InsertOutboxEvent(txCtx, OutboxEvent{EventType: "shift.created", EntityID: 1, Payload: json.RawMessage(`{"id":1}`)}))
+		require.NoError(t, st.Rollback(txCtx))
+
+		requestID := "req-1"
+		require.NoError(t, st.InsertOutboxEvent(ctx, OutboxEvent{EventType: "shift.created", EntityID: 1, Payload: json.RawMessage(`{"id":1}`), RequestID: &requestID}))
+		require.NoError(t, st.InsertOutboxEvent(ctx, OutboxEvent{EventType: "shift.deleted", EntityID: 1, Payload: json.RawMessage(`{"id":1}`)}))
+
+		dispatched, err := st.FanOutOutboxEvents(ctx, now, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, dispatched)
+		dispatched, err = st.FanOutOutboxEvents(ctx, now, 10)
+		require.NoError(t, err)
+		assert.Zero(t, dispatched)
+
+		// the deliveries are claimed once until the lease expires
+		claimed, err := st.ClaimWebhookDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 3)
+		perWebhook := map[int][]string{}
+		for _, d := range claimed {
+			perWebhook[d.WebhookID] = append(perWebhook[d.WebhookID], d.EventType)
+			assert.JSONEq(t, `{"id":1}`, string(d.Payload))
+			if d.WebhookID == everyID {
+				assert.Equal(t, "whsec_every", d.WebhookSecret)
+			}
+		}
+		assert.ElementsMatch(t, []string{"shift.created", "shift.deleted"}, perWebhook[everyID])
+		assert.Equal(t, []string{"shift.deleted"}, perWebhook[deletedID])
+		again, err := st.ClaimWebhookDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, again)
+
+		code := 500
+		require.NoError(t, st.MarkWebhookDelivered(ctx, claimed[0].ID, 204, now))
+		require.NoError(t, st.MarkWebhookAttemptFailed(ctx, claimed[1].ID, &code, "unexpected status 500", nil))
+		next := now.Add(30 * time.Second)
+		require.NoError(t, st.MarkWebhookAttemptFailed(ctx, claimed[2].ID, nil, "connection refused", &next))
+
+		dead, total, err := st.ListWebhookDeliveries(ctx, ListWebhookDeliveryFilter{Status: WebhookDeliveryDead, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		if assert.Len(t, dead, 1) {
+			assert.Equal(t, claimed[1].ID, dead[0].ID)
+			assert.Equal(t, 1, dead[0].Attempts)
+			assert.Equal(t, 500, *dead[0].LastStatusCode)
+			assert.Empty(t, dead[0].WebhookSecret)
+		}
+		all, total, err := st.ListWebhookDeliveries(ctx, ListWebhookDeliveryFilter{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Len(t, all, 3)
+
+		// a dead delivery is queued again, once
+		retried, err := st.RetryWebhookDelivery(ctx, claimed[1].ID, now)
+		require.NoError(t, err)
+		assert.True(t, retried)
+		retried, err = st.RetryWebhookDelivery(ctx, claimed[1].ID, now)
+		require.NoError(t, err)
+		assert.False(t, retried)
+
+		// the deliveries to a deleted webhook aren't attempted anymore
+		deleted, err := st.DeleteWebhook(ctx, deletedID)
+		require.NoError(t, err)
+		require.NotNil(t, deleted)
+		deleted, err = st.DeleteWebhook(ctx, deletedID)
+		require.NoError(t, err)
+		assert.Nil(t, deleted)
+
+		claimed, err = st.ClaimWebhookDeliveries(ctx, now.Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		for _, d := range claimed {
+			assert.Equal(t, everyID, d.WebhookID)
+			assert.Equal(t, WebhookDeliveryPending, d.Status)
+		}
+	})
+}
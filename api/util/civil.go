@@ -0,0 +1,38 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidTimeOfDay = errors.New("invalid time of day")
+
+// ParseTimeOfDay accepts HH:MM and the HH:MM:SS format of postgres TIME, returns the time since midnight
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidTimeOfDay, value)
+}
+
+// OnDay returns the wall clock time of day on the calendar date of day in loc
+func OnDay(tod time.Duration, day time.Time, loc *time.Location) time.Time {
+	h := int(tod / time.Hour)
+	m := int(tod % time.Hour / time.Minute)
+	s := int(tod % time.Minute / time.Second)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, loc)
+}
+
+// CivilDate truncates t to its calendar date at midnight UTC
+func CivilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// WeekStart returns the Monday midnight of the week of t, in the location of t
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("09:30")
+	require.NoError(t, err)
+	assert.Equal(t, 9*time.Hour+30*time.Minute, tod)
+
+	tod, err = ParseTimeOfDay("22:15:30")
+	require.NoError(t, err)
+	assert.Equal(t, 22*time.Hour+15*time.Minute+30*time.Second, tod)
+
+	for _, value := range []string{"", "9h", "24:00", "12:60"} {
+		_, err = ParseTimeOfDay(value)
+		assert.ErrorIs(t, err, ErrInvalidTimeOfDay, value)
+	}
+}
+
+func TestOnDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// the calendar date is kept whatever the zone of day
+	day := time.Date(2025, 3, 30, 23, 0, 0, 0, time.UTC)
+	at := OnDay(9*time.Hour+30*time.Minute, day, berlin)
+	assert.Equal(t, time.Date(2025, 3, 30, 7, 30, 0, 0, time.UTC), at.UTC())
+
+	assert.Equal(t, time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC), CivilDate(at))
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, WeekStart(monday.Add(10*time.Hour)))
+	assert.Equal(t, monday, WeekStart(monday.AddDate(0, 0, 6).Add(23*time.Hour)))
+	assert.Equal(t, monday.AddDate(0, 0, 7), WeekStart(monday.AddDate(0, 0, 7)))
+
+	// the week starts at midnight in the location of t
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	sundayNight := time.Date(2025, 5, 18, 22, 0, 0, 0, newYork)
+	assert.Equal(t, time.Date(2025, 5, 12, 0, 0, 0, 0, newYork), WeekStart(sundayNight))
+}
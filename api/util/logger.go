@@ -26,6 +26,7 @@ func InitLogger(level logrus.Level) {
 			},
 		})
 		logger.SetLevel(level)
+		logger.AddHook(requestIDHook{})
 	})
 }
 
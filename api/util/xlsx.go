@@ -0,0 +1,134 @@
+package util
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// the parts of a workbook with a single worksheet, besides the worksheet itself
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// XLSXWriter writes a workbook of a single worksheet one row at a time,
+// rows are compressed into w as they are written instead of being held in memory
+type XLSXWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewXLSXWriter writes the workbook parts preceding the rows of the worksheet named sheet
+func NewXLSXWriter(w io.Writer, sheet string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+	var name xmlText
+	name.escape(sheet)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return nil, err
+		}
+	}
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &XLSXWriter{zip: zw, sheet: bufio.NewWriter(f)}
+	if _, err := x.sheet.WriteString(xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// WriteRow appends a row, integers and floats are written as numbers, anything else as text
+func (x *XLSXWriter) WriteRow(cells ...interface{}) error {
+	x.row++
+	var b xmlText
+	b = append(b, `<row r="`+strconv.Itoa(x.row)+`">`...)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(x.row)
+		switch v := cell.(type) {
+		case int:
+			b = append(b, `<c r="`+ref+`"><v>`+strconv.Itoa(v)+`</v></c>`...)
+		case float64:
+			b = append(b, `<c r="`+ref+`"><v>`+strconv.FormatFloat(v, 'f', -1, 64)+`</v></c>`...)
+		default:
+			b = append(b, `<c r="`+ref+`" t="inlineStr"><is><t xml:space="preserve">`...)
+			b.escape(fmt.Sprint(v))
+			b = append(b, `</t></is></c>`...)
+		}
+	}
+	b = append(b, `</row>`...)
+	_, err := x.sheet.Write(b)
+	return err
+}
+
+// Flush pushes the buffered rows to the compressor
+func (x *XLSXWriter) Flush() error {
+	return x.sheet.Flush()
+}
+
+// Close ends the worksheet and writes the zip directory, it doesn't close the underlying writer
+func (x *XLSXWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xlsxColumn returns the column letters of the zero based index: A, B, ..., Z, AA, AB...
+func xlsxColumn(i int) string {
+	var col []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		col = append([]byte{byte('A' + (i-1)%26)}, col...)
+	}
+	return string(col)
+}
+
+type xmlText []byte
+
+func (b *xmlText) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+// escape appends s escaped as XML character data, invalid characters are replaced
+func (b *xmlText) escape(s string) {
+	_ = xml.EscapeText(b, []byte(s))
+}
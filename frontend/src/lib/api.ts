@@ -9,14 +9,31 @@ export async function login(username: string, password: string): Promise<Respons
 	});
 }
 
-// TODO on backend
+// logs out under the refresh path, the only one the refresh cookie is sent to, so that it's revoked too
 export async function logout() {
-	await fetch(`${BASE_URL}/logout`, {
+	await fetch(`${BASE_URL}/refresh/logout`, {
 		method: 'POST',
 		credentials: 'include'
 	});
 }
 
+// revokes every session of the logged in user, including the ones on other devices
+export async function logoutEverywhere() {
+	await fetch(`${BASE_URL}/logout/all`, {
+		method: 'POST',
+		credentials: 'include'
+	});
+}
+
+// rotates the refresh cookie and sets a new access token cookie, false when the session is over
+export async function refreshSession(): Promise<boolean> {
+	const response = await fetch(`${BASE_URL}/refresh`, {
+		method: 'POST',
+		credentials: 'include'
+	});
+	return response.ok;
+}
+
 export async function registerUser(
 	email: string,
 	primaryRole: number | null,